@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ellie/apps/cli/internal/ctxindex"
+	"ellie/apps/cli/internal/paths"
+)
+
+// ── prompt context ──────────────────────────────────────────────────────────
+
+// withContext appends the files selected by --context to prompt, whole or,
+// with --context-index, as the chunks most relevant to it.
+func withContext(prompt string) (string, error) {
+	if len(contextPaths) == 0 && !contextIndex {
+		return prompt, nil
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	all, err := ctxindex.ListFiles(root)
+	if err != nil {
+		return "", fmt.Errorf("cannot list files: %w", err)
+	}
+	patterns := contextPaths
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	files := ctxindex.Filter(all, patterns)
+	if len(files) == 0 {
+		return "", fmt.Errorf("--context matches no files: %s", strings.Join(contextPaths, ", "))
+	}
+
+	var snippets []ctxindex.Snippet
+	var used int
+	if contextIndex {
+		snippets, used, err = indexedContext(root, all, files, prompt)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		var skipped []string
+		snippets, used, skipped = ctxindex.Whole(root, files, contextBudget)
+		if len(skipped) > 0 {
+			fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf(
+				"Left out %d of %d files that are binary or over the %d-token budget — try --context-index",
+				len(skipped), len(files), contextBudget)))
+		}
+	}
+	if len(snippets) == 0 {
+		return "", fmt.Errorf("no context fits in --context-budget %d", contextBudget)
+	}
+
+	var b strings.Builder
+	b.WriteString(prompt)
+	labels := make([]string, len(snippets))
+	for i, s := range snippets {
+		b.WriteString("\n\n")
+		b.WriteString(fenced(s.Label(), s.Text))
+		labels[i] = s.Label()
+	}
+	fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf("Sending %d context snippets (~%d tokens): %s",
+		len(snippets), used, truncate(strings.Join(labels, ", "), 200))))
+	return b.String(), nil
+}
+
+// indexedContext updates the cached index of root and packs the chunks of
+// files that best match prompt.
+func indexedContext(root string, all, files []string, prompt string) ([]ctxindex.Snippet, int, error) {
+	dir, err := paths.Cache()
+	if err != nil {
+		return nil, 0, err
+	}
+	sum := sha256.Sum256([]byte(root))
+	cache := filepath.Join(dir, "index", hex.EncodeToString(sum[:8])+".gob")
+
+	ix, err := ctxindex.Load(cache)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot read context index: %w", err)
+	}
+	if n := ix.Update(root, all); n > 0 {
+		fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf("Indexed %d changed files", n)))
+	}
+	// Embeddings are saved even when ranking fails part way.
+	defer func() {
+		if err := ix.Save(cache); err != nil {
+			fmt.Fprintln(os.Stderr, styleDim.Render("Cannot cache context index: "+err.Error()))
+		}
+	}()
+	hits, err := semanticHits(ix, root, files, prompt)
+	if err != nil {
+		url, _ := setting("context.embed_url")
+		fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf("No embeddings from %s (%v) — ranking by keywords instead; see context.embed_url", url, err)))
+	}
+	if hits == nil {
+		hits = ix.Search(prompt, files)
+	}
+	if len(hits) == 0 {
+		return nil, 0, fmt.Errorf("nothing in the --context files relates to the prompt")
+	}
+	snippets, used := ctxindex.Pack(root, hits, contextBudget)
+	return snippets, used, nil
+}
+
+// semanticHits ranks the chunks of files by similarity to prompt, with
+// embeddings from context.embed_url. Chunks not embedded yet are embedded
+// first. It returns nil, nil when context.embed_url is empty.
+func semanticHits(ix *ctxindex.Index, root string, files []string, prompt string) ([]ctxindex.Hit, error) {
+	url, _ := setting("context.embed_url")
+	if url == "" {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	embedder := &ctxindex.TEI{URL: url}
+	n, err := ix.Embed(ctx, root, files, embedder)
+	if n > 0 {
+		fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf("Embedded %d chunks", n)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	query, err := embedder.Embed(ctx, []string{prompt})
+	if err != nil {
+		return nil, err
+	}
+	return ix.SearchVector(query[0], files), nil
+}
+
+// stdinLimit is --stdin-limit, in KiB.
+var stdinLimit int
+
+// defaultStdinLimit is the KiB of piped input sent by default: a large
+// diff or log, well within a model's context.
+const defaultStdinLimit = 100
+
+// checkStdinLimit rejects a --stdin-limit that would send nothing.
+func checkStdinLimit() error {
+	if stdinLimit < 1 {
+		return fmt.Errorf("--stdin-limit must be at least 1")
+	}
+	return nil
+}
+
+// pipedInput returns what is piped or redirected to standard input, cut
+// to --stdin-limit, and whether there was more. Reading stops at the
+// limit: a pipe such as tail -f may never end. A terminal, or an input
+// that is neither a pipe nor a file, isn't read: a script run without
+// one must not hang waiting.
+func pipedInput() (string, bool, error) {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return "", false, nil
+	}
+	if m := fi.Mode(); m&os.ModeNamedPipe == 0 && !m.IsRegular() {
+		return "", false, nil
+	}
+	if err := checkStdinLimit(); err != nil {
+		return "", false, err
+	}
+	limit := int64(stdinLimit) << 10
+	data, err := io.ReadAll(io.LimitReader(os.Stdin, limit+1))
+	if err != nil {
+		return "", false, fmt.Errorf("cannot read standard input: %w", err)
+	}
+	cut := int64(len(data)) > limit
+	if cut {
+		data = trimPartialRune(data[:limit])
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return "", false, fmt.Errorf("standard input is not text; only text can be sent with the prompt")
+	}
+	// End on a whole line where there is one.
+	if i := bytes.LastIndexByte(data, '\n'); cut && i > 0 {
+		data = data[:i+1]
+	}
+	return string(data), cut, nil
+}
+
+// trimPartialRune drops a character cut short at the end of data.
+func trimPartialRune(data []byte) []byte {
+	for i := 0; i < utf8.UTFMax-1 && len(data) > 0; i++ {
+		if r, size := utf8.DecodeLastRune(data); r != utf8.RuneError || size > 1 {
+			break
+		}
+		data = data[:len(data)-1]
+	}
+	return data
+}
+
+// withStdin adds piped standard input to prompt as context, as in
+// git diff | ellie ask "review this". With no prompt, the input is the
+// prompt. Input cut to --stdin-limit says so, both to the user and in
+// the prompt.
+func withStdin(prompt string) (string, error) {
+	input, cut, err := pipedInput()
+	if err != nil || strings.TrimSpace(input) == "" {
+		return prompt, err
+	}
+	if cut {
+		limit := formatBytes(int64(stdinLimit) << 10)
+		warn(fmt.Sprintf("standard input is more than %s; sent the first %s — pass --stdin-limit to send more", limit, formatBytes(int64(len(input)))))
+		input += fmt.Sprintf("[… input over %s not included]\n", limit)
+	}
+	if prompt == "" {
+		return input, nil
+	}
+	return prompt + "\n\n" + fenced("standard input", strings.TrimRight(input, "\n")), nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"charm.land/lipgloss/v2"
+
+	"ellie/apps/cli/pkg/ellieapi"
+)
+
+// ── CI mode ─────────────────────────────────────────────────────────────────
+
+// strictMode turns warnings, such as version skew or a config file that
+// was ignored, into a failed exit once the command is done.
+var strictMode bool
+
+// warnings counts the warnings printed by warn, for --strict.
+var warnings atomic.Int32
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&strictMode, "strict", false, "Fail if the command printed any warnings, such as version skew or an ignored config file")
+	if ciMode() {
+		// No colours or other escape codes in CI logs.
+		plain := lipgloss.NewStyle()
+		styleBold, styleOk, styleErr, styleDim = plain, plain, plain, plain
+	}
+}
+
+// ciMode reports whether ELLIE_CI is set. In CI mode nothing prompts or
+// animates: commands that would ask for something fail and name the flag
+// that provides it instead, and warnings and errors are logged as
+// timestamped logfmt lines.
+func ciMode() bool {
+	switch strings.ToLower(os.Getenv("ELLIE_CI")) {
+	case "", "0", "false", "no":
+		return false
+	}
+	return true
+}
+
+// ciLog writes a logfmt line such as
+//
+//	time=2026-01-02T15:04:05Z level=warn msg="cannot save profiles"
+//
+// to stderr.
+func ciLog(level, msg string) {
+	fmt.Fprintf(os.Stderr, "time=%s level=%s msg=%s\n", time.Now().UTC().Format(time.RFC3339), level, strconv.Quote(msg))
+}
+
+// warn prints a warning to stderr and counts it for --strict.
+func warn(msg string) {
+	warnings.Add(1)
+	if ciMode() {
+		ciLog("warn", msg)
+		return
+	}
+	fmt.Fprintln(os.Stderr, styleDim.Render("Warning: "+msg))
+}
+
+// printError prints the error a command failed with.
+func printError(err error) {
+	var hint string
+	var e *ellieapi.Error
+	if errors.As(err, &e) {
+		hint = e.Hint
+	}
+	if ciMode() {
+		if hint != "" {
+			err = fmt.Errorf("%w (%s)", err, hint)
+		}
+		ciLog("error", err.Error())
+		return
+	}
+	fmt.Fprintln(os.Stderr, styleErr.Render("Error:"), err)
+	if hint != "" {
+		fmt.Fprintln(os.Stderr, styleDim.Render("  → "+hint))
+	}
+}
+
+// noPrompt fails, in CI mode or with --quiet, a command about to prompt
+// for something, such as "choosing a provider"; instead says how to do
+// without, usually with a flag. Otherwise it returns nil.
+func noPrompt(what, instead string) error {
+	switch {
+	case ciMode():
+		return fmt.Errorf("%s is interactive and ELLIE_CI is set — %s", what, instead)
+	case quiet:
+		return fmt.Errorf("%s is interactive, which --quiet is not for — %s", what, instead)
+	}
+	return nil
+}
+
+// strictFailure is the error a command that otherwise succeeded ends with
+// under --strict, or nil.
+func strictFailure() error {
+	n := warnings.Load()
+	if !strictMode || n == 0 {
+		return nil
+	}
+	if n == 1 {
+		return fmt.Errorf("failing because of 1 warning and --strict")
+	}
+	return fmt.Errorf("failing because of %d warnings and --strict", n)
+}
@@ -0,0 +1,494 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/agent"
+	"ellie/apps/cli/internal/credentials"
+	"ellie/apps/cli/internal/paths"
+)
+
+// ── credential agent ────────────────────────────────────────────────────────
+
+// agentCheckInterval is how often the agent looks at the OAuth token.
+const agentCheckInterval = time.Minute
+
+var (
+	agentStartTimeout time.Duration
+	agentStopTimeout  time.Duration
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Hold unlocked credentials in the background, like ssh-agent",
+	Long: `Run a small background agent that reads the OS keyring credentials once,
+keeps them in memory, and hands them to ellie commands over a unix socket
+in the state directory, so commands don't each unlock the keyring. Only
+processes of the user who started the agent are answered: the agent asks
+the kernel who is connecting. Set ELLIE_AGENT_SOCK to use another socket.
+
+While it runs, the agent also keeps the server's Anthropic OAuth token
+fresh: it checks the token every minute and refreshes it when it expires
+within agent.refresh_before (10m by default), updating the active auth
+profile with it.
+
+Commands work the same without the agent; they read the keyring
+themselves.`,
+}
+
+var agentStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the agent in the background",
+	Args:  cobra.NoArgs,
+	RunE:  runAgentStart,
+}
+
+var agentStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the agent, dropping the credentials it holds",
+	Args:  cobra.NoArgs,
+	RunE:  runAgentStop,
+}
+
+var agentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the agent, the credentials it holds and the OAuth token's refreshes",
+	Args:  cobra.NoArgs,
+	RunE:  runAgentStatus,
+}
+
+var agentRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Have the agent check the OAuth token now",
+	Args:  cobra.NoArgs,
+	RunE:  runAgentRefresh,
+}
+
+// agentRunCmd is the agent itself, started by ellie agent start.
+var agentRunCmd = &cobra.Command{
+	Use:    "run",
+	Short:  "Run the credential agent in the foreground",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runAgent,
+}
+
+func init() {
+	agentStartCmd.Flags().DurationVar(&agentStartTimeout, "wait-timeout", 2*time.Minute, "How long to wait for the keyring to be unlocked")
+	agentStopCmd.Flags().DurationVar(&agentStopTimeout, "wait-timeout", 5*time.Second, "How long to wait for the agent to exit before killing it")
+}
+
+// agentSocket returns the path of the agent's socket.
+func agentSocket() (string, error) {
+	if s := os.Getenv("ELLIE_AGENT_SOCK"); s != "" {
+		return s, nil
+	}
+	dir, err := paths.State()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "run", "agent.sock"), nil
+}
+
+// callAgent sends req to the running agent. It returns
+// agent.ErrNotRunning when there is none.
+func callAgent(timeout time.Duration, req agent.Request) (*agent.Response, error) {
+	socket, err := agentSocket()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return agent.Call(ctx, socket, req)
+}
+
+// agentStatus returns the running agent's status, or nil.
+func agentStatus() *agent.Status {
+	resp, err := callAgent(2*time.Second, agent.Request{Op: agent.OpStatus})
+	if err != nil {
+		return nil
+	}
+	return resp.Status
+}
+
+// ── keyring access through the agent ────────────────────────────────────────
+
+// runningAgent is the agent this process is, or nil. The agent reads the
+// keyring through its own cache instead of asking itself.
+var runningAgent *credentialAgent
+
+// agentKeyring is the keyring localCredentials uses: the agent's cache
+// when one is running, the OS keyring otherwise. Changes go to the OS
+// keyring, and the agent drops what it held for them.
+type agentKeyring struct{}
+
+func (agentKeyring) Get(service, account string) (string, error) {
+	if runningAgent != nil {
+		return runningAgent.cache.Get(service, account)
+	}
+	resp, err := callAgent(10*time.Second, agent.Request{Op: agent.OpGet, Service: service, Account: account})
+	var remote *agent.RemoteError
+	switch {
+	case err == nil:
+		return resp.Secret, nil
+	case errors.As(err, &remote) && remote.Code == agent.CodeNotFound:
+		return "", credentials.ErrNotFound
+	case errors.As(err, &remote) && remote.Code == agent.CodeUnsupported:
+		return "", fmt.Errorf("%w: %s", credentials.ErrUnsupported, remote.Message)
+	case errors.As(err, &remote) && remote.Code != agent.CodeDenied:
+		return "", err
+	}
+	// No agent, or one that isn't ours: read the keyring ourselves.
+	return credentials.System().Get(service, account)
+}
+
+func (k agentKeyring) Set(service, account, secret string) error {
+	defer k.forget(service, account)
+	return credentials.System().Set(service, account, secret)
+}
+
+func (k agentKeyring) Delete(service, account string) error {
+	defer k.forget(service, account)
+	return credentials.System().Delete(service, account)
+}
+
+func (agentKeyring) forget(service, account string) {
+	if runningAgent != nil {
+		runningAgent.cache.Forget(service, account)
+		return
+	}
+	_, _ = callAgent(2*time.Second, agent.Request{Op: agent.OpForget, Service: service, Account: account})
+}
+
+// ── commands ────────────────────────────────────────────────────────────────
+
+func runAgentStart(cmd *cobra.Command, args []string) error {
+	if !agent.Supported() {
+		return fmt.Errorf("ellie agent can't run here: %v, so it couldn't keep other users out", agent.ErrPeerUnsupported)
+	}
+	if st := agentStatus(); st != nil {
+		return fmt.Errorf("the agent is already running (pid %d) — see ellie agent status", st.PID)
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate ellie executable: %w", err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+
+	child := exec.Command(self, "agent", "run")
+	child.Stdin, child.Stdout, child.Stderr = devNull, devNull, devNull
+	child.SysProcAttr = detachAttr()
+	logCommand(child)
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("cannot start the agent: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- child.Wait() }()
+
+	// The agent unlocks the keyring before it listens, which may ask for
+	// a password.
+	stop := spinner("Unlocking the keyring...")
+	deadline := time.Now().Add(agentStartTimeout)
+	var st *agent.Status
+	for st == nil && time.Now().Before(deadline) {
+		select {
+		case err := <-exited:
+			stop()
+			return fmt.Errorf("the agent exited during startup: %v", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+		st = agentStatus()
+	}
+	stop()
+	if st == nil {
+		return fmt.Errorf("the agent did not start within %s (pid %d) — stop it with ellie agent stop", agentStartTimeout, child.Process.Pid)
+	}
+
+	socket, _ := agentSocket()
+	fmt.Println(styleOk.Render("✓"), "Agent running", styleDim.Render(fmt.Sprintf("(pid %d, holding %d credential(s))", st.PID, st.Cached)))
+	fmt.Println(styleDim.Render("  Socket:            " + socket))
+	fmt.Println(styleDim.Render("  Check on it with:  ellie agent status"))
+	fmt.Println(styleDim.Render("  Stop it with:      ellie agent stop"))
+	return nil
+}
+
+func runAgentStop(cmd *cobra.Command, args []string) error {
+	pid := 0
+	if st := agentStatus(); st != nil {
+		pid = st.PID
+	} else if p, _, ok := runningProcess("agent"); ok {
+		pid = p
+	} else {
+		fmt.Println(styleDim.Render("Agent is not running."))
+		return nil
+	}
+	if _, err := callAgent(2*time.Second, agent.Request{Op: agent.OpStop}); err != nil {
+		if err := stopProcess(pid, agentStopTimeout); err != nil {
+			return err
+		}
+	} else {
+		deadline := time.Now().Add(agentStopTimeout)
+		for processAlive(pid) && time.Now().Before(deadline) {
+			time.Sleep(50 * time.Millisecond)
+		}
+		if processAlive(pid) {
+			if err := stopProcess(pid, agentStopTimeout); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Println(styleOk.Render("✓"), "Stopped the agent", styleDim.Render(fmt.Sprintf("(pid %d)", pid)))
+	return nil
+}
+
+// agentStatusReport is the --json output of ellie agent status.
+type agentStatusReport struct {
+	Running bool   `json:"running"`
+	Socket  string `json:"socket"`
+	*agent.Status
+}
+
+func runAgentStatus(cmd *cobra.Command, args []string) error {
+	st := agentStatus()
+	socket, _ := agentSocket()
+	if jsonFlag {
+		if err := printJSON(agentStatusReport{Running: st != nil, Socket: socket, Status: st}); err != nil {
+			return err
+		}
+	}
+	if st == nil {
+		fmt.Println(styleDim.Render("Agent is not running (start it with ellie agent start)."))
+		return errSilent
+	}
+	printAgentStatus(st, socket)
+	return nil
+}
+
+func printAgentStatus(st *agent.Status, socket string) {
+	fmt.Println()
+	fmt.Println(styleBold.Render("Agent"))
+	fmt.Println(strings.Repeat("─", 40))
+	fmt.Printf("  %-12s %s %s\n", "Agent", styleOk.Render("running"),
+		styleDim.Render(fmt.Sprintf("(pid %d, up %s)", st.PID, formatUptime(time.Since(st.Since)))))
+	fmt.Printf("  %-12s %s\n", "Socket", styleDim.Render(socket))
+	fmt.Printf("  %-12s %d %s\n", "Credentials", st.Cached, styleDim.Render(fmt.Sprintf("(%d request(s) answered from memory)", st.Served)))
+
+	r := st.Refresh
+	switch {
+	case r.CheckedAt.IsZero():
+		fmt.Printf("  %-12s %s\n", "OAuth", styleDim.Render("not checked yet"))
+	case !r.OAuth && r.LastError == "":
+		fmt.Printf("  %-12s %s\n", "OAuth", styleDim.Render("the server doesn't sign in to Anthropic with OAuth"))
+	case !r.Expires.IsZero():
+		fmt.Printf("  %-12s expires %s %s\n", "OAuth", r.Expires.Local().Format("Jan 2 15:04"),
+			styleDim.Render(fmt.Sprintf("(in %s; refreshed %s before)", formatUptime(time.Until(r.Expires)), formatUptime(r.Before))))
+	}
+	if !r.LastAt.IsZero() {
+		fmt.Printf("  %-12s %s\n", "Refreshed", formatDuration(time.Since(r.LastAt)))
+	}
+	if r.LastError != "" {
+		fmt.Printf("  %-12s %s\n", "Last error", styleErr.Render(r.LastError))
+	}
+	fmt.Println()
+}
+
+func runAgentRefresh(cmd *cobra.Command, args []string) error {
+	resp, err := callAgent(time.Minute, agent.Request{Op: agent.OpRefresh})
+	if errors.Is(err, agent.ErrNotRunning) {
+		return fmt.Errorf("the agent is not running (start it with ellie agent start)")
+	}
+	if err != nil {
+		return err
+	}
+	socket, _ := agentSocket()
+	printAgentStatus(resp.Status, socket)
+	return nil
+}
+
+// ── the agent ───────────────────────────────────────────────────────────────
+
+// credentialAgent serves keyring secrets from memory and keeps the
+// server's OAuth token fresh.
+type credentialAgent struct {
+	cache *agent.Cache
+	since time.Time
+
+	mu      sync.Mutex
+	refresh agent.Refresh
+	// refreshMu serializes token checks: the timer's and requested ones.
+	refreshMu sync.Mutex
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// agentRefreshBefore returns agent.refresh_before.
+func agentRefreshBefore() time.Duration {
+	if v, ok := setting("agent.refresh_before"); ok {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return 10 * time.Minute
+}
+
+func runAgent(cmd *cobra.Command, args []string) error {
+	if !agent.Supported() {
+		return agent.ErrPeerUnsupported
+	}
+	if pid, _, ok := runningProcess("agent"); ok {
+		return fmt.Errorf("the agent is already running (pid %d)", pid)
+	}
+	socket, err := agentSocket()
+	if err != nil {
+		return err
+	}
+	if agentStatus() != nil {
+		return fmt.Errorf("an agent is already listening on %s", socket)
+	}
+
+	a := &credentialAgent{
+		cache:   agent.NewCache(credentials.System().Get),
+		since:   time.Now(),
+		refresh: agent.Refresh{Before: agentRefreshBefore()},
+		stop:    make(chan struct{}),
+	}
+	runningAgent = a
+	a.unlock()
+
+	removePid, err := writePidfile("agent", os.Getpid())
+	if err != nil {
+		return err
+	}
+	defer removePid()
+
+	if err := os.MkdirAll(filepath.Dir(socket), 0o755); err != nil {
+		return err
+	}
+	// Only a crashed agent leaves its socket behind; nothing answered on
+	// it above.
+	_ = os.Remove(socket)
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("cannot open the agent socket: %w", err)
+	}
+	defer os.Remove(socket)
+	defer ln.Close()
+	if err := os.Chmod(socket, 0o600); err != nil {
+		return err
+	}
+	go agent.Serve(ln, os.Getuid(), a.handle)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	tick := time.NewTicker(agentCheckInterval)
+	defer tick.Stop()
+	a.checkToken()
+	for {
+		select {
+		case <-tick.C:
+			a.checkToken()
+		case <-sigCh:
+			return nil
+		case <-a.stop:
+			return nil
+		}
+	}
+}
+
+// unlock reads the Anthropic credential and the auth profiles from the
+// keyring, so that any unlock prompt comes now rather than during a
+// command.
+func (a *credentialAgent) unlock() {
+	_, _ = localCredentials.Load("anthropic")
+	if profiles, err := loadAuthProfiles(); err == nil {
+		for _, name := range profiles.Names() {
+			_, _ = localCredentials.LoadProfile(profiles.Profiles[name].Provider, name)
+		}
+	}
+}
+
+func (a *credentialAgent) status() *agent.Status {
+	cached, served := a.cache.Stats()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return &agent.Status{PID: os.Getpid(), Since: a.since, Cached: cached, Served: served, Refresh: a.refresh}
+}
+
+func (a *credentialAgent) handle(req agent.Request) agent.Response {
+	switch req.Op {
+	case agent.OpGet:
+		secret, err := a.cache.Get(req.Service, req.Account)
+		switch {
+		case errors.Is(err, credentials.ErrNotFound):
+			return agent.Response{Error: err.Error(), Code: agent.CodeNotFound}
+		case errors.Is(err, credentials.ErrUnsupported):
+			return agent.Response{Error: err.Error(), Code: agent.CodeUnsupported}
+		case err != nil:
+			return agent.Response{Error: err.Error()}
+		}
+		return agent.Response{Secret: secret}
+	case agent.OpForget:
+		a.cache.Forget(req.Service, req.Account)
+	case agent.OpStatus:
+	case agent.OpRefresh:
+		a.checkToken()
+	case agent.OpStop:
+		a.stopOnce.Do(func() { close(a.stop) })
+	default:
+		return agent.Response{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+	}
+	return agent.Response{Status: a.status()}
+}
+
+// checkToken refreshes the server's Anthropic OAuth token when it
+// expires within agent.refresh_before.
+func (a *credentialAgent) checkToken() {
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+
+	// The context key keeps ELLIE_AUTO_REFRESH from refreshing as well.
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), autoRefreshKey{}, true), 30*time.Second)
+	defer cancel()
+	status, err := fetchAnthropicOAuthStatus(ctx)
+
+	a.mu.Lock()
+	r := a.refresh
+	a.mu.Unlock()
+	r.CheckedAt, r.LastError = time.Now(), ""
+	r.OAuth = err == nil && status.isOAuth()
+	r.Expires = status.expiry()
+	if err != nil {
+		r.LastError = err.Error()
+	} else if r.OAuth && !r.Expires.IsZero() && time.Until(r.Expires) <= r.Before {
+		if expires, err := refreshAnthropicToken(ctx); err != nil {
+			r.LastError = "cannot refresh the token: " + err.Error()
+		} else {
+			r.LastAt, r.Expires = time.Now(), expires
+			syncActiveOAuthProfile()
+		}
+	}
+	a.mu.Lock()
+	a.refresh = r
+	a.mu.Unlock()
+}
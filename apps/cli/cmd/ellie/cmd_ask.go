@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/chatui"
+)
+
+// ── ask ─────────────────────────────────────────────────────────────────────
+
+var (
+	askModel  string
+	askSystem string
+)
+
+var askCmd = &cobra.Command{
+	Use:   "ask [prompt]...",
+	Short: "Ask one question and stream the answer",
+	Long: `Send one prompt and stream the answer to the terminal as it is written.
+The words of the prompt are joined, so quoting it is optional:
+
+  ellie ask why is my build failing?
+
+Input piped or redirected to ellie ask is sent along with the prompt, or
+is the prompt when none is given. Only the first --stdin-limit KiB are
+sent; when the input is longer, ellie says it was cut, and so does the
+prompt:
+
+  git diff | ellie ask "review this"
+  ellie ask "summarize the failures" < test.log
+
+Each question gets a conversation of its own, apart from the one ellie
+chat continues. --model asks for a model instead of default_model (see
+ellie models); servers that can't switch models per prompt answer with
+their own, and ellie says so. --system sends instructions the answer
+should follow as the system prompt, and --continue has an answer cut off
+at the token limit finished. --max-tokens caps the answer and --thinking
+asks for extended thinking; both are checked against the model's limits
+before the question is sent.
+
+--context, --context-index and --context-budget send files along, as
+with ellie chat --prompt:
+
+  ellie ask "where is the session token refreshed?" --context . --context-index
+
+With --json nothing is streamed: the answer is printed once complete,
+with the model, tokens and cost, in the shape of ellie chat --format json.
+Nor is it with --extract or --jq, which print only part of the answer, as
+with ellie chat --prompt:
+
+  ellie ask "write a jq filter for the ids" --extract code`,
+	RunE: runAsk,
+}
+
+func init() {
+	askCmd.Flags().StringVarP(&askModel, "model", "m", "", "Model to answer with (default: the default_model setting)")
+	askCmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Most tokens the answer may take (default: the server's)")
+	askCmd.Flags().BoolVar(&thinking, "thinking", false, "Ask the model to think before answering")
+	askCmd.Flags().StringVarP(&askSystem, "system", "s", "", "System prompt for the answer, e.g. \"answer in one sentence\"")
+	askCmd.Flags().BoolVar(&continueAnswer, "continue", false, "Keep requesting continuations while the answer hits the token limit")
+	askCmd.Flags().IntVar(&stdinLimit, "stdin-limit", defaultStdinLimit, "Most KiB of piped input to send")
+	askCmd.Flags().StringArrayVar(&contextPaths, "context", nil, "Send these files, directories or globs along (repeatable)")
+	askCmd.Flags().BoolVar(&contextIndex, "context-index", false, "Send only the chunks of the --context files most relevant to the prompt")
+	askCmd.Flags().IntVar(&contextBudget, "context-budget", 8000, "Most tokens of context to send")
+	askCmd.Flags().StringVar(&extractMode, "extract", "", "Print only the first code block (code, code:<lang>) or JSON value (json)")
+	askCmd.Flags().StringVar(&jqFilter, "jq", "", "Apply a jq-style filter to the JSON in the answer")
+	jsonCommands[askCmd] = true
+}
+
+func runAsk(cmd *cobra.Command, args []string) error {
+	if err := checkStdinLimit(); err != nil {
+		return err
+	}
+	if err := checkMaxTokens(); err != nil {
+		return err
+	}
+	post, err := newPostProcessor(extractMode, jqFilter)
+	if err != nil {
+		return err
+	}
+	prompt, err := withStdin(strings.TrimSpace(strings.Join(args, " ")))
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return fmt.Errorf("no prompt — give one, or pipe it in")
+	}
+	title, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+	if prompt, err = withContext(prompt); err != nil {
+		return err
+	}
+	base := requireBaseURL()
+	client := chatui.NewHTTPClient(base)
+	if err := requireChatServer(client, base); err != nil {
+		return err
+	}
+	model, err := requestModel(client, askModel)
+	if err != nil {
+		return err
+	}
+
+	thread, err := client.CreateAssistantThread(context.Background(), "Ask: "+truncate(title, 60))
+	if err != nil {
+		return apiError(err)
+	}
+	o := oneShot{
+		cfg: chatui.OneShotConfig{
+			BaseURL:   base,
+			BranchID:  thread.BranchID,
+			Format:    "text",
+			Model:     model,
+			System:    strings.TrimSpace(askSystem),
+			MaxTokens: maxTokens,
+			Thinking:  thinking,
+		},
+		post:   post,
+		stream: !jsonFlag && post == nil,
+	}
+	if jsonFlag {
+		o.cfg.Format = "json"
+	}
+	return o.run(client, prompt)
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+)
+
+var (
+	attachmentsUnlinked  bool
+	attachmentsOlderThan string
+	attachmentsDryRun    bool
+	attachmentsYes       bool
+)
+
+var attachmentsCmd = &cobra.Command{
+	Use:   "attachments",
+	Short: "Manage files uploaded as prompt attachments",
+	Args:  cobra.NoArgs,
+	RunE:  runAttachmentsList,
+}
+
+var attachmentsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List uploaded files with their size and sessions",
+	Args:  cobra.NoArgs,
+	RunE:  runAttachmentsList,
+}
+
+var attachmentsDeleteCmd = &cobra.Command{
+	Use:   "delete [id]...",
+	Short: "Delete uploaded files",
+	Long: `Delete uploaded files from the server's attachment store, by id or by
+selection:
+
+  ellie attachments delete 3f9c2a1e
+  ellie attachments delete --older-than 30d
+  ellie attachments delete --older-than 7d --unlinked
+
+Sessions that used a deleted file keep the message but can no longer
+show or resend the file. --dry-run lists what would be deleted.`,
+	RunE: runAttachmentsDelete,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{attachmentsCmd, attachmentsListCmd} {
+		c.Flags().BoolVar(&attachmentsUnlinked, "unlinked", false, "Only files no session refers to")
+	}
+	attachmentsDeleteCmd.Flags().StringVar(&attachmentsOlderThan, "older-than", "", "Delete files uploaded longer ago than this (e.g. 30d, 12h)")
+	attachmentsDeleteCmd.Flags().BoolVar(&attachmentsUnlinked, "unlinked", false, "Only delete files no session refers to")
+	attachmentsDeleteCmd.Flags().BoolVar(&attachmentsDryRun, "dry-run", false, "List the files that would be deleted")
+	attachmentsDeleteCmd.Flags().BoolVarP(&attachmentsYes, "yes", "y", false, "Delete without asking for confirmation")
+}
+
+// attachment mirrors an upload as returned by GET /api/attachments.
+type attachment struct {
+	ID        string              `json:"id"`
+	Filename  string              `json:"filename"`
+	MimeType  string              `json:"mimeType,omitempty"`
+	Size      int64               `json:"size"`
+	CreatedAt float64             `json:"createdAt"` // Unix ms
+	Sessions  []attachmentSession `json:"sessions"`
+}
+
+type attachmentSession struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+}
+
+func (a attachment) created() time.Time {
+	return time.UnixMilli(int64(a.CreatedAt))
+}
+
+type attachmentsResponse struct {
+	Attachments []attachment `json:"attachments"`
+	TotalBytes  int64        `json:"totalBytes"`
+}
+
+func fetchAttachments() (*attachmentsResponse, error) {
+	resp, err := httpClient.Get(baseURL() + "/api/attachments")
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == 404 {
+		return nil, fmt.Errorf("the server at %s does not support managing attachments", baseURL())
+	}
+	if resp.StatusCode != 200 {
+		return nil, serverError(resp)
+	}
+	var out attachmentsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	sort.Slice(out.Attachments, func(i, j int) bool {
+		return out.Attachments[i].CreatedAt > out.Attachments[j].CreatedAt
+	})
+	return &out, nil
+}
+
+func runAttachmentsList(cmd *cobra.Command, args []string) error {
+	state, err := fetchAttachments()
+	if err != nil {
+		return err
+	}
+	shown := state.Attachments
+	if attachmentsUnlinked {
+		shown = filterAttachments(shown, time.Time{}, true)
+	}
+	if jsonFlag {
+		return printJSON(attachmentsResponse{Attachments: shown, TotalBytes: state.TotalBytes})
+	}
+
+	fmt.Println()
+	fmt.Println(styleBold.Render("Attachments"))
+	fmt.Println(strings.Repeat("─", 40))
+	if len(shown) == 0 {
+		fmt.Println(styleDim.Render("  No attachments."))
+	} else {
+		printAttachments(shown)
+	}
+	fmt.Println()
+
+	var shownBytes int64
+	for _, a := range shown {
+		shownBytes += a.Size
+	}
+	summary := fmt.Sprintf("%d file(s), %s", len(shown), formatBytes(shownBytes))
+	if len(shown) != len(state.Attachments) {
+		summary += fmt.Sprintf(" of %d file(s), %s", len(state.Attachments), formatBytes(state.TotalBytes))
+	}
+	fmt.Println(styleDim.Render("  " + summary + " stored on " + baseURL()))
+	fmt.Println()
+	return nil
+}
+
+func printAttachments(list []attachment) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  ID\tNAME\tSIZE\tUPLOADED\tSESSIONS")
+	for _, a := range list {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", a.ID, truncate(a.Filename, 40), formatBytes(a.Size), formatDuration(time.Since(a.created())), sessionsLabel(a.Sessions))
+	}
+	tw.Flush()
+}
+
+func sessionsLabel(sessions []attachmentSession) string {
+	if len(sessions) == 0 {
+		return styleDim.Render("none")
+	}
+	first := sessions[0].Title
+	if first == "" {
+		first = sessions[0].ID
+	}
+	first = truncate(first, 30)
+	if len(sessions) > 1 {
+		first += fmt.Sprintf(" +%d", len(sessions)-1)
+	}
+	return first
+}
+
+func runAttachmentsDelete(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 && (attachmentsOlderThan != "" || attachmentsUnlinked) {
+		return fmt.Errorf("pass attachment ids or --older-than/--unlinked, not both")
+	}
+	if len(args) == 0 && attachmentsOlderThan == "" && !attachmentsUnlinked {
+		return fmt.Errorf("name the attachments to delete, or select them with --older-than or --unlinked")
+	}
+	var cutoff time.Time
+	if attachmentsOlderThan != "" {
+		age, err := parseAge(attachmentsOlderThan)
+		if err != nil {
+			return err
+		}
+		cutoff = time.Now().Add(-age)
+	}
+
+	state, err := fetchAttachments()
+	if err != nil {
+		return err
+	}
+
+	var targets []attachment
+	if len(args) > 0 {
+		byID := make(map[string]attachment, len(state.Attachments))
+		for _, a := range state.Attachments {
+			byID[a.ID] = a
+		}
+		for _, id := range args {
+			a, ok := byID[id]
+			if !ok {
+				return fmt.Errorf("no attachment %q — see ellie attachments list", id)
+			}
+			targets = append(targets, a)
+		}
+	} else {
+		targets = filterAttachments(state.Attachments, cutoff, attachmentsUnlinked)
+	}
+	if len(targets) == 0 {
+		fmt.Println(styleDim.Render("No attachments match."))
+		return nil
+	}
+
+	var bytes int64
+	linked := 0
+	for _, a := range targets {
+		bytes += a.Size
+		if len(a.Sessions) > 0 {
+			linked++
+		}
+	}
+	fmt.Println()
+	printAttachments(targets)
+	fmt.Println()
+	if attachmentsDryRun {
+		fmt.Println(styleDim.Render(fmt.Sprintf("Would delete %d file(s), %s.", len(targets), formatBytes(bytes))))
+		return nil
+	}
+
+	if !attachmentsYes {
+		if err := noPrompt("confirming the deletion", "pass --yes"); err != nil {
+			return err
+		}
+		title := fmt.Sprintf("Delete %d file(s), %s?", len(targets), formatBytes(bytes))
+		if linked > 0 {
+			title = fmt.Sprintf("Delete %d file(s), %s? %d are used in sessions.", len(targets), formatBytes(bytes), linked)
+		}
+		var confirm bool
+		err := huh.NewConfirm().
+			Title(title).
+			Affirmative("Delete").
+			Negative("Cancel").
+			Value(&confirm).
+			Run()
+		if err != nil || !confirm {
+			fmt.Println("Cancelled.")
+			return errSilent
+		}
+	}
+
+	var freed int64
+	for i, a := range targets {
+		if err := deleteAttachment(a.ID); err != nil {
+			if i > 0 {
+				fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf("%d of %d file(s) were deleted before the failure", i, len(targets))))
+			}
+			return fmt.Errorf("deleting %s: %w", a.ID, err)
+		}
+		freed += a.Size
+	}
+	fmt.Println(styleOk.Render("✓"), fmt.Sprintf("Deleted %d file(s), freed %s", len(targets), formatBytes(freed)))
+	return nil
+}
+
+// filterAttachments keeps attachments uploaded before cutoff (if set) and,
+// with unlinked, only those no session refers to.
+func filterAttachments(list []attachment, cutoff time.Time, unlinked bool) []attachment {
+	var out []attachment
+	for _, a := range list {
+		if !cutoff.IsZero() && !a.created().Before(cutoff) {
+			continue
+		}
+		if unlinked && len(a.Sessions) > 0 {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+func deleteAttachment(id string) error {
+	req, err := http.NewRequest(http.MethodDelete, baseURL()+"/api/attachments/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach server at %s", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 && resp.StatusCode != 204 {
+		return serverError(resp)
+	}
+	return nil
+}
+
+// parseAge parses a duration that may also be given in days, e.g. 30d.
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid age %q (use e.g. 30d or 12h)", s)
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// formatBytes renders a size in binary units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -2,17 +2,24 @@ package main
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/credentials"
+	"ellie/apps/cli/internal/loopback"
+	"ellie/apps/cli/pkg/ellieapi"
 )
 
 // waitForEnter pauses until the user presses Enter.
@@ -23,32 +30,38 @@ func waitForEnter() {
 }
 
 // serverError builds a human-readable error from a non-200 server response.
-// It tries to parse JSON {"error":"..."} and adds context for common codes.
 func serverError(resp *http.Response) error {
-	body, _ := io.ReadAll(resp.Body)
+	return apiError(ellieapi.ResponseError(resp))
+}
 
-	// Try to extract a message from JSON body
-	msg := strings.TrimSpace(string(body))
-	var parsed struct {
-		Error string `json:"error"`
-	}
-	if json.Unmarshal(body, &parsed) == nil && parsed.Error != "" {
-		msg = parsed.Error
-	}
+// apiClient returns a client for the server at baseURL().
+func apiClient() *ellieapi.Client {
+	return ellieapi.New(baseURL(), httpClient)
+}
 
-	switch resp.StatusCode {
-	case 404:
-		return fmt.Errorf("server returned 404 — the server may not be running or is missing this route (%s)", baseURL())
-	case 403:
-		return fmt.Errorf("forbidden — auth routes are only available from localhost")
-	case 500:
-		return fmt.Errorf("server error: %s", msg)
-	default:
-		if msg != "" {
-			return fmt.Errorf("server returned %d: %s", resp.StatusCode, msg)
-		}
-		return fmt.Errorf("server returned %d", resp.StatusCode)
+// errorHints say what to do about the errors the server reports, by
+// code, when the server doesn't say itself.
+var errorHints = map[string]string{
+	"token_expired": "run ellie auth refresh to renew the token",
+	"unauthorized":  "the server did not accept ellie's credentials — run ellie login, or check the selected environment's token",
+	"forbidden":     "this route is only available from localhost — run the command on the server's host",
+	"rate_limited":  "wait a moment and try again",
+	"unavailable":   "a service the server depends on is down — ellie doctor checks them",
+	"internal":      "the server failed — ellie logs shows what happened",
+}
+
+// apiError adds a hint to a server's refusal, for the user. Other
+// errors, such as an unreachable server, are returned as they are.
+func apiError(err error) error {
+	var e *ellieapi.Error
+	if !errors.As(err, &e) || e.Hint != "" {
+		return err
+	}
+	e.Hint = errorHints[e.Code]
+	if e.Code == "not_found" {
+		e.Hint = "the server may not be running or is missing this route (" + serverAddress(baseURL()) + ")"
 	}
+	return err
 }
 
 // ── auth (interactive wizard) ────────────────────────────────────────────────
@@ -56,15 +69,42 @@ func serverError(resp *http.Response) error {
 var authCmd = &cobra.Command{
 	Use:   "auth",
 	Short: "Interactive authentication setup",
-	RunE:  runAuthWizard,
+	Long: `Interactive authentication setup. Credentials are stored on the ellie
+server. Anthropic keys and tokens can instead be kept in the OS keyring
+(macOS Keychain, Windows Credential Manager, Secret Service) with --local,
+which is also used automatically when the server is unreachable. A keyring
+credential is passed to servers started with ellie dev or ellie start.
+
+The OAuth flows receive the browser's redirect on a temporary listener
+on 127.0.0.1. On a headless host, or when the browser runs on another
+machine, pass --manual to paste the code shown by the browser instead.
+
+Keys and tokens are typed into a masked field. Over mosh and in mintty a
+simpler line reader is used instead; set ELLIE_SECRET_INPUT=raw to force
+it, or pass --stdin-secret to read each secret from standard input.`,
+	RunE: runAuthWizard,
+}
+
+// authManual makes the OAuth flow ask for the code shown by the browser
+// instead of receiving it on a local callback listener.
+var authManual bool
+
+func init() {
+	authCmd.PersistentFlags().BoolVar(&authLocal, "local", false, "Store Anthropic credentials in the OS keyring instead of on the server")
+	authCmd.Flags().BoolVar(&authManual, "manual", false, "OAuth: paste the code from the browser instead of receiving it on 127.0.0.1")
 }
 
 func runAuthWizard(cmd *cobra.Command, args []string) error {
+	if err := noPrompt("choosing a provider", "use ellie auth api-key --key-stdin, auth token --token-stdin, auth vertex, auth bedrock or auth azure"); err != nil {
+		return err
+	}
 	var provider string
 	err := huh.NewSelect[string]().
 		Title("Choose a provider to authenticate").
 		Options(
 			huh.NewOption("Anthropic", "anthropic"),
+			huh.NewOption("Google Gemini", "gemini"),
+			huh.NewOption("Azure OpenAI", "azure"),
 			huh.NewOption("Groq", "groq"),
 			huh.NewOption("Brave Search", "brave"),
 			huh.NewOption("ElevenLabs (TTS)", "elevenlabs"),
@@ -80,6 +120,10 @@ func runAuthWizard(cmd *cobra.Command, args []string) error {
 	switch provider {
 	case "anthropic":
 		return authAnthropic()
+	case "gemini":
+		return authGemini()
+	case "azure":
+		return authAzureOpenAI()
 	case "groq":
 		return authGroq()
 	case "brave":
@@ -102,56 +146,80 @@ var authStatusCmd = &cobra.Command{
 	RunE:  runAuthStatus,
 }
 
+// authStatusReport is the --json output of ellie auth status.
+type authStatusReport struct {
+	Keyring   *keyringStatus             `json:"keyring,omitempty"`
+	Providers map[string]json.RawMessage `json:"providers"` // the server's status by provider slug, null when unsupported
+	Error     string                     `json:"error,omitempty"`
+}
+
+type keyringStatus struct {
+	Method  string    `json:"method"`
+	Preview string    `json:"preview"`
+	SavedAt time.Time `json:"savedAt"`
+}
+
 func runAuthStatus(cmd *cobra.Command, args []string) error {
+	report := authStatusReport{Providers: map[string]json.RawMessage{}}
+	done := func(err error) error {
+		if jsonFlag {
+			if err != nil {
+				report.Error = err.Error()
+			}
+			if jerr := printJSON(report); jerr != nil {
+				return jerr
+			}
+		}
+		return err
+	}
+
 	fmt.Println()
 	fmt.Println(styleBold.Render("Auth Status"))
 	fmt.Println(strings.Repeat("─", 40))
 
-	if err := printProviderStatus("Anthropic", "/api/auth/anthropic/status"); err != nil {
-		return err
+	local := printLocalCredentialStatus()
+	if local != nil {
+		report.Keyring = &keyringStatus{Method: local.Method, Preview: local.Preview(), SavedAt: local.SavedAt}
 	}
-	if err := printProviderStatus("Groq", "/api/auth/groq/status"); err != nil {
-		return err
-	}
-	if err := printProviderStatus("Brave Search", "/api/auth/brave/status"); err != nil {
-		return err
-	}
-	if err := printProviderStatus("ElevenLabs", "/api/auth/elevenlabs/status"); err != nil {
-		return err
-	}
-	if err := printProviderStatus("CivitAI", "/api/auth/civitai/status"); err != nil {
-		return err
+	for _, p := range authProviders {
+		status, err := printProviderStatus(p.name, p.slug)
+		if err != nil {
+			if p.slug == "anthropic" && local != nil {
+				fmt.Println()
+				fmt.Println(styleDim.Render("  " + err.Error()))
+				fmt.Println()
+				report.Error = err.Error()
+				return done(nil)
+			}
+			return done(err)
+		}
+		report.Providers[p.slug] = status
 	}
 
+	printAuthProfiles()
+
 	// Channel statuses (non-fatal if server doesn't support channels yet)
 	_ = printChannelStatuses()
 
 	fmt.Println()
-	return nil
+	return done(nil)
 }
 
-func printProviderStatus(name string, path string) error {
-	url := baseURL() + path
-	resp, err := httpClient.Get(url)
-	if err != nil {
-		return fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return serverError(resp)
-	}
-
-	var status struct {
-		Mode       *string  `json:"mode"`
-		Source     string   `json:"source"`
-		Configured bool     `json:"configured"`
-		ExpiresAt  *float64 `json:"expires_at,omitempty"`
-		Expired    *bool    `json:"expired,omitempty"`
-		Preview    *string  `json:"preview,omitempty"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
-		return fmt.Errorf("invalid response: %w", err)
+// printProviderStatus prints a provider's credential state and returns
+// the server's response, or nil when the server doesn't know the provider.
+func printProviderStatus(name, provider string) (json.RawMessage, error) {
+	status, err := apiClient().AuthStatus(context.Background(), provider)
+	switch {
+	case ellieapi.IsUnreachable(err):
+		return nil, fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	case ellieapi.IsStatus(err, 404):
+		// Servers older than a provider don't have its routes.
+		fmt.Println()
+		fmt.Println(styleBold.Render("  " + name))
+		fmt.Println(styleDim.Render("    Not supported by this server"))
+		return nil, nil
+	case err != nil:
+		return nil, apiError(err)
 	}
 
 	fmt.Println()
@@ -159,7 +227,7 @@ func printProviderStatus(name string, path string) error {
 
 	if !status.Configured || status.Mode == nil {
 		fmt.Println("    Not configured")
-		return nil
+		return status.Raw, nil
 	}
 
 	fmt.Println("    Mode:   ", *status.Mode)
@@ -168,6 +236,30 @@ func printProviderStatus(name string, path string) error {
 	if status.Preview != nil {
 		fmt.Println("    Key:    ", *status.Preview)
 	}
+	if status.Project != "" {
+		fmt.Println("    Project:", status.Project+"/"+status.Location)
+	}
+	if status.Account != "" {
+		fmt.Println("    Account:", status.Account)
+	}
+	if status.CredentialsPath != "" {
+		fmt.Println("    File:   ", status.CredentialsPath)
+	}
+	if status.Endpoint != "" {
+		fmt.Println("    Endpoint:", status.Endpoint)
+	}
+	if status.Deployment != "" {
+		fmt.Println("    Deployment:", status.Deployment)
+	}
+	if status.Region != "" {
+		fmt.Println("    Region: ", status.Region)
+	}
+	if status.AWSProfile != "" {
+		fmt.Println("    Profile:", status.AWSProfile)
+	}
+	if status.RoleARN != "" {
+		fmt.Println("    Role:   ", status.RoleARN)
+	}
 
 	if status.ExpiresAt != nil {
 		exp := time.UnixMilli(int64(*status.ExpiresAt))
@@ -177,80 +269,113 @@ func printProviderStatus(name string, path string) error {
 		}
 		fmt.Println("    Expires:", expStr)
 	}
-	return nil
+	return status.Raw, nil
 }
 
 // ── auth clear ───────────────────────────────────────────────────────────────
 
+var authClearYes bool
+
 var authClearCmd = &cobra.Command{
-	Use:   "clear",
-	Short: "Remove stored credentials (choose provider)",
-	RunE:  runAuthClear,
+	Use:       "clear [provider]",
+	Short:     "Remove stored credentials (choose provider)",
+	Long:      "Remove a provider's stored credentials, or every provider's with 'all'. Without a provider, asks which.",
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"anthropic", "gemini", "azure", "groq", "brave", "elevenlabs", "civitai", "whatsapp", "all"},
+	RunE:      runAuthClear,
+}
+
+func init() {
+	authClearCmd.Flags().BoolVarP(&authClearYes, "yes", "y", false, "Clear without asking for confirmation")
 }
 
 func runAuthClear(cmd *cobra.Command, args []string) error {
 	var target string
-	err := huh.NewSelect[string]().
-		Title("Which provider credentials should be cleared?").
-		Options(
-			huh.NewOption("Anthropic", "anthropic"),
-			huh.NewOption("Groq", "groq"),
-			huh.NewOption("Brave Search", "brave"),
-			huh.NewOption("ElevenLabs", "elevenlabs"),
-			huh.NewOption("CivitAI", "civitai"),
-			huh.NewOption("WhatsApp", "whatsapp"),
-			huh.NewOption("All providers", "all"),
-		).
-		Value(&target).
-		Run()
-	if err != nil {
-		return errSilent
+	if len(args) == 1 {
+		target = args[0]
+	} else {
+		if err := noPrompt("choosing a provider", "name it: ellie auth clear <provider>"); err != nil {
+			return err
+		}
+		err := huh.NewSelect[string]().
+			Title("Which provider credentials should be cleared?").
+			Options(
+				huh.NewOption("Anthropic", "anthropic"),
+				huh.NewOption("Google Gemini", "gemini"),
+				huh.NewOption("Azure OpenAI", "azure"),
+				huh.NewOption("Groq", "groq"),
+				huh.NewOption("Brave Search", "brave"),
+				huh.NewOption("ElevenLabs", "elevenlabs"),
+				huh.NewOption("CivitAI", "civitai"),
+				huh.NewOption("WhatsApp", "whatsapp"),
+				huh.NewOption("All providers", "all"),
+			).
+			Value(&target).
+			Run()
+		if err != nil {
+			return errSilent
+		}
 	}
 
 	// Confirm before clearing
-	var confirm bool
-	label := target
-	if target == "all" {
-		label = "all providers"
+	if !authClearYes {
+		if err := noPrompt("confirming the removal", "pass --yes"); err != nil {
+			return err
+		}
+		var confirm bool
+		label := target
+		if target == "all" {
+			label = "all providers"
+		}
+		err := huh.NewConfirm().
+			Title(fmt.Sprintf("Clear %s credentials?", label)).
+			Affirmative("Yes, clear").
+			Negative("Cancel").
+			Value(&confirm).
+			Run()
+		if err != nil || !confirm {
+			fmt.Println("Cancelled.")
+			return errSilent
+		}
 	}
-	err = huh.NewConfirm().
-		Title(fmt.Sprintf("Clear %s credentials?", label)).
-		Affirmative("Yes, clear").
-		Negative("Cancel").
-		Value(&confirm).
-		Run()
-	if err != nil || !confirm {
-		fmt.Println("Cancelled.")
-		return errSilent
+
+	if target == "anthropic" || target == "all" {
+		clearLocalCredential("anthropic", "Anthropic")
 	}
 
 	switch target {
 	case "anthropic":
-		return clearProvider("Anthropic", "/api/auth/anthropic/clear")
+		return clearProvider("Anthropic", "anthropic")
+	case "gemini":
+		return clearProvider("Google Gemini", "gemini")
+	case "azure":
+		return clearProvider("Azure OpenAI", "azure")
 	case "groq":
-		return clearProvider("Groq", "/api/auth/groq/clear")
+		return clearProvider("Groq", "groq")
 	case "brave":
-		return clearProvider("Brave Search", "/api/auth/brave/clear")
+		return clearProvider("Brave Search", "brave")
 	case "elevenlabs":
-		return clearProvider("ElevenLabs", "/api/auth/elevenlabs/clear")
+		return clearProvider("ElevenLabs", "elevenlabs")
 	case "civitai":
-		return clearProvider("CivitAI", "/api/auth/civitai/clear")
+		return clearProvider("CivitAI", "civitai")
 	case "whatsapp":
 		return clearChannel("WhatsApp", "whatsapp")
 	case "all":
-		if err := clearProvider("Anthropic", "/api/auth/anthropic/clear"); err != nil {
+		if err := clearProvider("Anthropic", "anthropic"); err != nil {
 			return err
 		}
-		if err := clearProvider("Groq", "/api/auth/groq/clear"); err != nil {
+		_ = clearProvider("Google Gemini", "gemini") // non-fatal: older servers lack it
+		_ = clearProvider("Azure OpenAI", "azure")
+		if err := clearProvider("Groq", "groq"); err != nil {
 			return err
 		}
-		if err := clearProvider("Brave Search", "/api/auth/brave/clear"); err != nil {
+		if err := clearProvider("Brave Search", "brave"); err != nil {
 			return err
 		}
-		if err := clearProvider("ElevenLabs", "/api/auth/elevenlabs/clear"); err != nil {
+		if err := clearProvider("ElevenLabs", "elevenlabs"); err != nil {
 			return err
 		}
-		if err := clearProvider("CivitAI", "/api/auth/civitai/clear"); err != nil {
+		if err := clearProvider("CivitAI", "civitai"); err != nil {
 			return err
 		}
 		_ = clearChannel("WhatsApp", "whatsapp") // non-fatal
@@ -259,26 +384,16 @@ func runAuthClear(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func clearProvider(name string, path string) error {
-	url := baseURL() + path
-	resp, err := httpClient.Post(url, "application/json", nil)
-	if err != nil {
+func clearProvider(name, provider string) error {
+	cleared, err := apiClient().ClearAuth(context.Background(), provider)
+	if ellieapi.IsUnreachable(err) {
 		return fmt.Errorf("cannot reach server at %s", baseURL())
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return serverError(resp)
-	}
-
-	var result struct {
-		Cleared bool `json:"cleared"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("invalid response: %w", err)
+	if err != nil {
+		return apiError(err)
 	}
 
-	if result.Cleared {
+	if cleared {
 		fmt.Println(styleOk.Render(name + " credentials removed."))
 	} else {
 		fmt.Println("No stored " + name + " credentials found.")
@@ -297,6 +412,7 @@ func authAnthropic() error {
 			huh.NewOption("OAuth (Max/Pro plan — claude.ai)", "oauth_max"),
 			huh.NewOption("OAuth (Console — creates API key)", "oauth_console"),
 			huh.NewOption("Bearer Token", "token"),
+			huh.NewOption("AWS Bedrock", "bedrock"),
 		).
 		Value(&method).
 		Run()
@@ -313,43 +429,23 @@ func authAnthropic() error {
 		return authOAuth("console")
 	case "token":
 		return authToken()
+	case "bedrock":
+		return authBedrock()
 	}
 	return nil
 }
 
 func authGroq() error {
-	var key string
-	err := huh.NewInput().
-		Title("Enter your Groq API key").
-		Description("Get one at https://console.groq.com/keys").
-		Placeholder("gsk_...").
-		EchoMode(huh.EchoModePassword).
-		Value(&key).
-		Run()
-	if err != nil || strings.TrimSpace(key) == "" {
+	key, err := promptSecret("Groq API key", "Get one at https://console.groq.com/keys")
+	if err != nil || key == "" {
 		fmt.Fprintln(os.Stderr, "Cancelled.")
 		return errSilent
 	}
 
 	fmt.Println(styleDim.Render("Validating key..."))
 
-	body, _ := json.Marshal(map[string]any{
-		"key":      strings.TrimSpace(key),
-		"validate": true,
-	})
-
-	resp, err := httpClient.Post(baseURL()+"/api/auth/groq/api-key", "application/json", bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("cannot reach server: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 401 {
-		return fmt.Errorf("invalid API key — check the key and try again")
-	}
-
-	if resp.StatusCode != 200 {
-		return serverError(resp)
+	if err := setAPIKey("groq", strings.TrimSpace(key), true); err != nil {
+		return err
 	}
 
 	fmt.Println(styleOk.Render("Groq API key saved successfully."))
@@ -357,38 +453,16 @@ func authGroq() error {
 }
 
 func authBraveSearch() error {
-	var key string
-	err := huh.NewInput().
-		Title("Enter your Brave Search API key").
-		Description("Get one at https://brave.com/search/api/").
-		Placeholder("BSA...").
-		EchoMode(huh.EchoModePassword).
-		Value(&key).
-		Run()
-	if err != nil || strings.TrimSpace(key) == "" {
+	key, err := promptSecret("Brave Search API key", "Get one at https://brave.com/search/api/")
+	if err != nil || key == "" {
 		fmt.Fprintln(os.Stderr, "Cancelled.")
 		return errSilent
 	}
 
 	fmt.Println(styleDim.Render("Validating key..."))
 
-	body, _ := json.Marshal(map[string]any{
-		"key":      strings.TrimSpace(key),
-		"validate": true,
-	})
-
-	resp, err := httpClient.Post(baseURL()+"/api/auth/brave/api-key", "application/json", bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("cannot reach server: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 401 {
-		return fmt.Errorf("invalid API key — check the key and try again")
-	}
-
-	if resp.StatusCode != 200 {
-		return serverError(resp)
+	if err := setAPIKey("brave", strings.TrimSpace(key), true); err != nil {
+		return err
 	}
 
 	fmt.Println(styleOk.Render("Brave Search API key saved successfully."))
@@ -396,38 +470,16 @@ func authBraveSearch() error {
 }
 
 func authElevenLabs() error {
-	var key string
-	err := huh.NewInput().
-		Title("Enter your ElevenLabs API key").
-		Description("Get one at https://elevenlabs.io/app/settings/api-keys").
-		Placeholder("sk_...").
-		EchoMode(huh.EchoModePassword).
-		Value(&key).
-		Run()
-	if err != nil || strings.TrimSpace(key) == "" {
+	key, err := promptSecret("ElevenLabs API key", "Get one at https://elevenlabs.io/app/settings/api-keys")
+	if err != nil || key == "" {
 		fmt.Fprintln(os.Stderr, "Cancelled.")
 		return errSilent
 	}
 
 	fmt.Println(styleDim.Render("Validating key..."))
 
-	body, _ := json.Marshal(map[string]any{
-		"key":      strings.TrimSpace(key),
-		"validate": true,
-	})
-
-	resp, err := httpClient.Post(baseURL()+"/api/auth/elevenlabs/api-key", "application/json", bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("cannot reach server: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 401 {
-		return fmt.Errorf("invalid API key — check the key and try again")
-	}
-
-	if resp.StatusCode != 200 {
-		return serverError(resp)
+	if err := setAPIKey("elevenlabs", strings.TrimSpace(key), true); err != nil {
+		return err
 	}
 
 	fmt.Println(styleOk.Render("ElevenLabs API key saved successfully."))
@@ -435,37 +487,16 @@ func authElevenLabs() error {
 }
 
 func authCivitAI() error {
-	var key string
-	err := huh.NewInput().
-		Title("Enter your CivitAI API key").
-		Description("Get one at https://civitai.com/user/account (API Keys section)").
-		EchoMode(huh.EchoModePassword).
-		Value(&key).
-		Run()
-	if err != nil || strings.TrimSpace(key) == "" {
+	key, err := promptSecret("CivitAI API key", "Get one at https://civitai.com/user/account (API Keys section)")
+	if err != nil || key == "" {
 		fmt.Fprintln(os.Stderr, "Cancelled.")
 		return errSilent
 	}
 
 	fmt.Println(styleDim.Render("Validating key..."))
 
-	body, _ := json.Marshal(map[string]any{
-		"key":      strings.TrimSpace(key),
-		"validate": true,
-	})
-
-	resp, err := httpClient.Post(baseURL()+"/api/auth/civitai/api-key", "application/json", bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("cannot reach server: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 401 {
-		return fmt.Errorf("invalid API key — check the key and try again")
-	}
-
-	if resp.StatusCode != 200 {
-		return serverError(resp)
+	if err := setAPIKey("civitai", strings.TrimSpace(key), true); err != nil {
+		return err
 	}
 
 	fmt.Println(styleOk.Render("CivitAI API key saved successfully."))
@@ -473,98 +504,123 @@ func authCivitAI() error {
 }
 
 func authApiKey() error {
-	var key string
-	err := huh.NewInput().
-		Title("Enter your Anthropic API key").
-		Placeholder("sk-ant-...").
-		EchoMode(huh.EchoModePassword).
-		Value(&key).
-		Run()
-	if err != nil || strings.TrimSpace(key) == "" {
+	key, err := promptSecret("Anthropic API key", "")
+	if err != nil || key == "" {
 		fmt.Fprintln(os.Stderr, "Cancelled.")
 		return errSilent
 	}
 
 	fmt.Println(styleDim.Render("Validating key..."))
+	if err := saveAPIKey("anthropic", strings.TrimSpace(key), true); err != nil {
+		return err
+	}
 
-	body, _ := json.Marshal(map[string]any{
-		"key":      strings.TrimSpace(key),
-		"validate": true,
-	})
+	fmt.Println(styleOk.Render("API key saved successfully."))
+	return nil
+}
 
-	resp, err := httpClient.Post(baseURL()+"/api/auth/anthropic/api-key", "application/json", bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("cannot reach server: %w", err)
+// saveAPIKey stores an API key for provider, optionally asking the server
+// to check it against the provider first.
+func saveAPIKey(provider, key string, validate bool) error {
+	if authLocal {
+		if provider != "anthropic" {
+			return fmt.Errorf("only Anthropic credentials can be stored in the OS keyring")
+		}
+		return saveLocalCredential(provider, credentials.MethodAPIKey, key)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == 401 {
-		return fmt.Errorf("invalid API key — check the key and try again")
+	err := setAPIKey(provider, key, validate)
+	if ellieapi.IsUnreachable(err) {
+		return saveCredentialFallback(provider, credentials.MethodAPIKey, key, errors.Unwrap(err))
 	}
-
-	if resp.StatusCode != 200 {
-		return serverError(resp)
+	if err != nil {
+		return err
+	}
+	if provider == "anthropic" {
+		noteAnthropicCredential(map[string]string{"type": credentials.MethodAPIKey, "key": key})
 	}
-
-	fmt.Println(styleOk.Render("API key saved successfully."))
 	return nil
 }
 
 func authToken() error {
-	var token string
-	err := huh.NewInput().
-		Title("Enter your Anthropic bearer token").
-		Placeholder("sk-ant-oat01-...").
-		EchoMode(huh.EchoModePassword).
-		Value(&token).
-		Run()
-	if err != nil || strings.TrimSpace(token) == "" {
+	token, err := promptSecret("Anthropic bearer token", "")
+	if err != nil || token == "" {
 		fmt.Fprintln(os.Stderr, "Cancelled.")
 		return errSilent
 	}
 
-	body, _ := json.Marshal(map[string]any{
-		"token": strings.TrimSpace(token),
-	})
-
-	resp, err := httpClient.Post(baseURL()+"/api/auth/anthropic/token", "application/json", bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("cannot reach server: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return serverError(resp)
+	if err := saveToken(strings.TrimSpace(token)); err != nil {
+		return err
 	}
 
 	fmt.Println(styleOk.Render("Token saved successfully."))
 	return nil
 }
 
-func authOAuth(mode string) error {
-	// Step 1: Get authorize URL
-	body, _ := json.Marshal(map[string]string{"mode": mode})
-	resp, err := httpClient.Post(baseURL()+"/api/auth/anthropic/oauth/authorize", "application/json", bytes.NewReader(body))
+// setAPIKey stores an API key for provider on the server.
+func setAPIKey(provider, key string, validate bool) error {
+	err := apiClient().SetAPIKey(context.Background(), provider, key, validate)
+	if ellieapi.IsStatus(err, 401) {
+		return fmt.Errorf("invalid API key — check the key and try again")
+	}
+	return apiError(err)
+}
+
+// saveToken stores an Anthropic bearer token.
+func saveToken(token string) error {
+	if authLocal {
+		return saveLocalCredential("anthropic", credentials.MethodToken, token)
+	}
+
+	err := apiClient().SetToken(context.Background(), token)
+	if ellieapi.IsUnreachable(err) {
+		return saveCredentialFallback("anthropic", credentials.MethodToken, token, errors.Unwrap(err))
+	}
 	if err != nil {
-		return fmt.Errorf("cannot reach server: %w", err)
+		return apiError(err)
 	}
-	defer resp.Body.Close()
+	noteAnthropicCredential(map[string]string{"type": credentials.MethodToken, "token": token})
+	return nil
+}
 
-	if resp.StatusCode != 200 {
-		return serverError(resp)
+func authOAuth(mode string) error {
+	// The browser is sent back to a listener on this machine, unless the
+	// user asked to paste the code (e.g. on a headless host over SSH).
+	var cb *loopback.Listener
+	if !authManual {
+		var err error
+		if cb, err = loopback.Listen("/callback"); err != nil {
+			fmt.Println(styleDim.Render("Cannot listen for the browser callback (" + err.Error() + ") — paste the code instead."))
+		} else {
+			defer cb.Close()
+		}
 	}
 
-	var authResp struct {
-		URL      string `json:"url"`
-		Verifier string `json:"verifier"`
-		State    string `json:"state"`
+	// Step 1: Get authorize URL
+	var redirectURI string
+	if cb != nil {
+		redirectURI = cb.RedirectURI()
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
-		return fmt.Errorf("invalid response: %w", err)
+	authResp, err := apiClient().OAuthAuthorize(context.Background(), mode, redirectURI)
+	if err != nil {
+		return apiError(err)
 	}
 	if authResp.URL == "" || authResp.Verifier == "" {
 		return fmt.Errorf("server returned empty authorize URL or verifier")
 	}
+	authURL, err := url.Parse(authResp.URL)
+	if err != nil {
+		return fmt.Errorf("server returned an invalid authorize URL: %w", err)
+	}
+	if authResp.State == "" {
+		authResp.State = authURL.Query().Get("state")
+	}
+	// Older servers ignore redirect_uri and keep the hosted callback page.
+	if cb != nil && authURL.Query().Get("redirect_uri") != cb.RedirectURI() {
+		fmt.Println(styleDim.Render("The server doesn't support the browser callback — paste the code instead."))
+		cb.Close()
+		cb = nil
+	}
 
 	// Step 2: Open browser
 	fmt.Println(styleBold.Render("Opening browser for authentication..."))
@@ -574,43 +630,52 @@ func authOAuth(mode string) error {
 	}
 	fmt.Println()
 
-	// Step 3: Prompt for callback code
+	// Step 3: Receive the callback code, from the listener or the user
 	var callbackCode string
-	err = huh.NewInput().
-		Title("Paste the callback code from the browser").
-		Placeholder("code#state").
-		Value(&callbackCode).
-		Run()
-	if err != nil || strings.TrimSpace(callbackCode) == "" {
-		fmt.Fprintln(os.Stderr, "Cancelled.")
-		return errSilent
+	if cb != nil {
+		fmt.Println(styleDim.Render("Waiting for the browser... (Ctrl+C to cancel; use --manual on a headless host)"))
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		res, err := cb.Wait(ctx, authResp.State)
+		cancel()
+		stop()
+		switch {
+		case errors.Is(err, context.Canceled):
+			fmt.Fprintln(os.Stderr, "Cancelled.")
+			return errSilent
+		case errors.Is(err, context.DeadlineExceeded):
+			return fmt.Errorf("timed out waiting for the browser — run ellie auth again, or use --manual")
+		case err != nil:
+			return err
+		}
+		callbackCode = res.Code + "#" + res.State
+	} else {
+		err = huh.NewInput().
+			Title("Paste the callback code from the browser").
+			Placeholder("code#state").
+			Value(&callbackCode).
+			Run()
+		if err != nil || strings.TrimSpace(callbackCode) == "" {
+			fmt.Fprintln(os.Stderr, "Cancelled.")
+			return errSilent
+		}
 	}
 
 	// Step 4: Exchange
-	exchangeBody, _ := json.Marshal(map[string]string{
-		"callback_code": strings.TrimSpace(callbackCode),
-		"verifier":      authResp.Verifier,
-		"mode":          mode,
-	})
-	resp2, err := httpClient.Post(baseURL()+"/api/auth/anthropic/oauth/exchange", "application/json", bytes.NewReader(exchangeBody))
-	if err != nil {
-		return fmt.Errorf("cannot reach server: %w", err)
-	}
-	defer resp2.Body.Close()
-
-	if resp2.StatusCode != 200 {
-		return serverError(resp2)
+	exchange := ellieapi.OAuthExchange{
+		CallbackCode: strings.TrimSpace(callbackCode),
+		Verifier:     authResp.Verifier,
+		Mode:         mode,
 	}
-
-	var exchangeResp struct {
-		OK      bool   `json:"ok"`
-		Mode    string `json:"mode"`
-		Message string `json:"message"`
+	if cb != nil {
+		exchange.RedirectURI = cb.RedirectURI()
 	}
-	if err := json.NewDecoder(resp2.Body).Decode(&exchangeResp); err != nil {
-		return fmt.Errorf("invalid response: %w", err)
+	exchangeResp, err := apiClient().OAuthExchange(context.Background(), exchange)
+	if err != nil {
+		return apiError(err)
 	}
 
+	noteAnthropicCredential(nil)
 	fmt.Println(styleOk.Render("Authentication successful!"))
 	fmt.Println(styleDim.Render(exchangeResp.Message))
 	return nil
@@ -770,31 +835,20 @@ func authWhatsApp() error {
 
 	// Step 6: POST login/start
 	fmt.Println(styleDim.Render("Connecting to WhatsApp..."))
-	loginClient := &http.Client{Timeout: 30 * time.Second}
-	body, _ := json.Marshal(map[string]any{
-		"accountId": "default",
-		"settings":  settings,
-	})
-	resp, err := loginClient.Post(baseURL()+"/api/channels/whatsapp/login/start", "application/json", bytes.NewReader(body))
-	if err != nil {
-		fmt.Fprintln(os.Stderr, styleErr.Render("Failed to connect: "+err.Error()))
-		waitForEnter()
-		return errSilent
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		fmt.Fprintln(os.Stderr, styleErr.Render("Server error: "+serverError(resp).Error()))
+	loginClient := ellieapi.New(baseURL(), &http.Client{Timeout: 30 * time.Second, Transport: credentialTransport})
+	loginResp, err := loginClient.WhatsAppLoginStart(context.Background(), "default", settings)
+	switch {
+	case ellieapi.IsUnreachable(err):
+		fmt.Fprintln(os.Stderr, styleErr.Render("Failed to connect: "+errors.Unwrap(err).Error()))
 		waitForEnter()
 		return errSilent
-	}
-
-	var loginResp struct {
-		QR         string `json:"qr"`
-		QRTerminal string `json:"qrTerminal"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&loginResp); err != nil {
-		fmt.Fprintln(os.Stderr, styleErr.Render("Invalid response: "+err.Error()))
+	case err != nil:
+		var apiErr *ellieapi.Error
+		if errors.As(err, &apiErr) {
+			fmt.Fprintln(os.Stderr, styleErr.Render("Server error: "+apiError(err).Error()))
+		} else {
+			fmt.Fprintln(os.Stderr, styleErr.Render("Invalid response: "+err.Error()))
+		}
 		waitForEnter()
 		return errSilent
 	}
@@ -818,18 +872,13 @@ func authWhatsApp() error {
 
 	// Step 7: Long-poll login/wait (5.5 min — outlast the server's 5 min timeout)
 	fmt.Println(styleDim.Render("Waiting for WhatsApp to connect..."))
-	waitClient := &http.Client{Timeout: 330 * time.Second}
-	waitBody, _ := json.Marshal(map[string]any{"accountId": "default"})
-	resp2, err := waitClient.Post(baseURL()+"/api/channels/whatsapp/login/wait", "application/json", bytes.NewReader(waitBody))
-	if err != nil {
-		fmt.Fprintln(os.Stderr, styleErr.Render("Login timed out or failed: "+err.Error()))
-		waitForEnter()
-		return errSilent
-	}
-	defer resp2.Body.Close()
-
-	if resp2.StatusCode != 200 {
-		fmt.Fprintln(os.Stderr, styleErr.Render("Login failed: "+serverError(resp2).Error()))
+	waitClient := ellieapi.New(baseURL(), &http.Client{Timeout: 330 * time.Second, Transport: credentialTransport})
+	if err := waitClient.WhatsAppLoginWait(context.Background(), "default"); err != nil {
+		if ellieapi.IsUnreachable(err) {
+			fmt.Fprintln(os.Stderr, styleErr.Render("Login timed out or failed: "+errors.Unwrap(err).Error()))
+		} else {
+			fmt.Fprintln(os.Stderr, styleErr.Render("Login failed: "+apiError(err).Error()))
+		}
 		waitForEnter()
 		return errSilent
 	}
@@ -865,44 +914,22 @@ func normalizeE164(number string) string {
 // ── channel helpers ───────────────────────────────────────────────────────────
 
 func printChannelStatuses() error {
-	resp, err := httpClient.Get(baseURL() + "/api/channels")
-	if err != nil {
+	channels, err := apiClient().Channels(context.Background())
+	if ellieapi.IsUnreachable(err) {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
+	if err != nil {
 		return nil // silently skip if not supported
 	}
 
-	var channels []struct {
-		ID          string `json:"id"`
-		DisplayName string `json:"displayName"`
-		Status      struct {
-			State             string  `json:"state"`
-			ConnectedAt       float64 `json:"connectedAt,omitempty"`
-			Error             string  `json:"error,omitempty"`
-			Detail            string  `json:"detail,omitempty"`
-			ReconnectAttempts int     `json:"reconnectAttempts,omitempty"`
-			LastConnectedAt   float64 `json:"lastConnectedAt,omitempty"`
-			LastDisconnect    string  `json:"lastDisconnect,omitempty"`
-			LastMessageAt     float64 `json:"lastMessageAt,omitempty"`
-			LastError         string  `json:"lastError,omitempty"`
-			SelfId            string  `json:"selfId,omitempty"`
-		} `json:"status"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&channels); err != nil {
-		return nil
-	}
-
 	for _, ch := range channels {
 		fmt.Println()
 		fmt.Println(styleBold.Render("  " + ch.DisplayName))
 		switch ch.Status.State {
 		case "connected":
 			fmt.Println("    Status:  ", styleOk.Render("Connected"))
-			if ch.Status.SelfId != "" {
-				fmt.Println("    Self:    ", ch.Status.SelfId)
+			if ch.Status.SelfID != "" {
+				fmt.Println("    Self:    ", ch.Status.SelfID)
 			}
 			if ch.Status.ConnectedAt > 0 {
 				t := time.UnixMilli(int64(ch.Status.ConnectedAt))
@@ -969,20 +996,15 @@ func formatDuration(d time.Duration) string {
 }
 
 func clearChannel(name string, channelId string) error {
-	body, _ := json.Marshal(map[string]any{"accountId": "default"})
-	resp, err := httpClient.Post(baseURL()+"/api/channels/"+channelId+"/logout", "application/json", bytes.NewReader(body))
-	if err != nil {
+	err := apiClient().ChannelLogout(context.Background(), channelId, "default")
+	switch {
+	case ellieapi.IsUnreachable(err):
 		return fmt.Errorf("cannot reach server at %s", baseURL())
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 404 {
+	case ellieapi.IsStatus(err, 404):
 		fmt.Println("No " + name + " connection found.")
 		return nil
-	}
-
-	if resp.StatusCode != 200 {
-		return serverError(resp)
+	case err != nil:
+		return apiError(err)
 	}
 
 	fmt.Println(styleOk.Render(name + " disconnected and credentials removed."))
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/audit"
+)
+
+// ── auth audit ───────────────────────────────────────────────────────────────
+
+var (
+	auditVerify   bool
+	auditLimit    int
+	auditProvider string
+)
+
+var authAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the local log of credential changes",
+	Long: `Show every credential set, rotate, clear and refresh performed from
+this machine's CLI, with who ran it and which server it went to.
+
+The log is append-only and hash-chained: each entry includes the hash of
+the previous one, so edits or deletions are detected. --verify checks the
+chain and exits non-zero if it is broken.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthAudit,
+}
+
+func init() {
+	authAuditCmd.Flags().BoolVar(&auditVerify, "verify", false, "Only verify the hash chain")
+	authAuditCmd.Flags().IntVarP(&auditLimit, "lines", "n", 0, "Show only the last N entries")
+	authAuditCmd.Flags().StringVar(&auditProvider, "provider", "", "Only show entries for this provider")
+}
+
+func runAuthAudit(cmd *cobra.Command, args []string) error {
+	log, err := credentialAuditLog()
+	if err != nil {
+		return err
+	}
+	entries, err := log.Entries()
+	if err != nil {
+		return err
+	}
+	brokenAt, verr := audit.Verify(entries)
+
+	if auditVerify {
+		if verr != nil {
+			fmt.Fprintln(os.Stderr, styleErr.Render("✗"), "Audit log chain is broken:", verr)
+			return errSilent
+		}
+		fmt.Println(styleOk.Render("✓"), fmt.Sprintf("Audit log intact (%d entries)", len(entries)))
+		return nil
+	}
+
+	shown := entries
+	if auditProvider != "" {
+		shown = nil
+		for _, e := range entries {
+			if e.Provider == auditProvider {
+				shown = append(shown, e)
+			}
+		}
+	}
+	if auditLimit > 0 && len(shown) > auditLimit {
+		shown = shown[len(shown)-auditLimit:]
+	}
+
+	if jsonFlag {
+		enc := json.NewEncoder(jsonOut)
+		for _, e := range shown {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+	} else {
+		printAuditEntries("Credential Audit Log", "No credential operations recorded.", shown, brokenAt)
+	}
+
+	if verr != nil {
+		fmt.Fprintln(os.Stderr, styleErr.Render("✗"), "Audit log chain is broken:", verr)
+		return errSilent
+	}
+	return nil
+}
+
+func printAuditEntries(title, empty string, entries []audit.Entry, brokenAt int) {
+	fmt.Println()
+	fmt.Println(styleBold.Render(title))
+	fmt.Println(strings.Repeat("─", 60))
+	if len(entries) == 0 {
+		fmt.Println(styleDim.Render("  " + empty))
+		fmt.Println()
+		return
+	}
+	for _, e := range entries {
+		what := strings.TrimSpace(e.Op + " " + e.Provider)
+		if e.Method != "" {
+			what += " (" + e.Method + ")"
+		}
+		server := e.Server
+		if e.ServerIP != "" && !strings.Contains(server, e.ServerIP) {
+			server += " [" + e.ServerIP + "]"
+		}
+		status := styleOk.Render("ok")
+		if !e.OK {
+			status = styleErr.Render("failed: " + e.Detail)
+		}
+		mark := " "
+		if brokenAt > 0 && e.Seq >= brokenAt {
+			mark = styleErr.Render("!")
+		}
+		fmt.Printf("%s %4d  %s  %-28s %s\n", mark, e.Seq, e.Time.Local().Format("2006-01-02 15:04:05"), what, status)
+		fmt.Println(styleDim.Render(fmt.Sprintf("        by %s → %s", e.Actor, server)))
+	}
+	fmt.Println()
+}
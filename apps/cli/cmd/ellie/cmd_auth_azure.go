@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/azureopenai"
+)
+
+// ── azure openai auth flow ───────────────────────────────────────────────────
+
+var (
+	azureEndpoint   string
+	azureDeployment string
+	azureAPIVersion string
+	azureNoValidate bool
+)
+
+var authAzureCmd = &cobra.Command{
+	Use:   "azure",
+	Short: "Configure an Azure OpenAI resource",
+	Long: `Store the endpoint, chat deployment and key of an Azure OpenAI resource,
+without the wizard. The key is read from standard input with
+--stdin-secret, or prompted for:
+
+  echo "$AZURE_OPENAI_API_KEY" | ellie auth azure --stdin-secret \
+    --endpoint https://my-resource.openai.azure.com --deployment gpt-4o
+
+The endpoint and key default to AZURE_OPENAI_ENDPOINT and
+AZURE_OPENAI_API_KEY. Before anything is saved, the deployment is called
+with an empty request to check the endpoint, deployment and key; this
+generates no tokens.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthAzure,
+}
+
+func init() {
+	authAzureCmd.Flags().StringVar(&azureEndpoint, "endpoint", "", "Resource endpoint, e.g. https://my-resource.openai.azure.com (default: AZURE_OPENAI_ENDPOINT)")
+	authAzureCmd.Flags().StringVar(&azureDeployment, "deployment", "", "Name of the chat model deployment")
+	authAzureCmd.Flags().StringVar(&azureAPIVersion, "api-version", azureopenai.DefaultAPIVersion, "Azure OpenAI API version")
+	authAzureCmd.Flags().BoolVar(&azureNoValidate, "no-validate", false, "Save without checking the endpoint")
+	authAzureCmd.MarkFlagRequired("deployment")
+}
+
+func runAuthAzure(cmd *cobra.Command, args []string) error {
+	if azureEndpoint == "" {
+		azureEndpoint = os.Getenv("AZURE_OPENAI_ENDPOINT")
+	}
+	if azureEndpoint == "" {
+		return fmt.Errorf("pass --endpoint or set AZURE_OPENAI_ENDPOINT")
+	}
+	endpoint, err := azureopenai.NormalizeEndpoint(azureEndpoint)
+	if err != nil {
+		return err
+	}
+
+	key := os.Getenv("AZURE_OPENAI_API_KEY")
+	if key == "" || authStdinSecret {
+		if key, err = promptSecret("Azure OpenAI API key", ""); err != nil {
+			return err
+		}
+	}
+	if key == "" {
+		return fmt.Errorf("no API key given")
+	}
+
+	cfg := azureopenai.Config{Endpoint: endpoint, Deployment: azureDeployment, APIVersion: azureAPIVersion, Key: key}
+	if err := saveAzureConfig(cfg, !azureNoValidate); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Azure OpenAI deployment", cfg.Deployment, "saved")
+	return nil
+}
+
+func authAzureOpenAI() error {
+	cfg := azureopenai.Config{Endpoint: os.Getenv("AZURE_OPENAI_ENDPOINT"), APIVersion: azureopenai.DefaultAPIVersion}
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewInput().
+			Title("Azure OpenAI endpoint").
+			Description("Shown under Keys and Endpoint for the resource in the Azure portal").
+			Placeholder("https://my-resource.openai.azure.com").
+			Validate(func(s string) error {
+				_, err := azureopenai.NormalizeEndpoint(s)
+				return err
+			}).
+			Value(&cfg.Endpoint),
+		huh.NewInput().
+			Title("Deployment name").
+			Description("The name you gave the chat model deployment, not the model").
+			Placeholder("gpt-4o").
+			Validate(func(s string) error {
+				if strings.TrimSpace(s) == "" {
+					return fmt.Errorf("a deployment is required")
+				}
+				return nil
+			}).
+			Value(&cfg.Deployment),
+	)).Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Cancelled.")
+		return errSilent
+	}
+	cfg.Endpoint, _ = azureopenai.NormalizeEndpoint(cfg.Endpoint)
+	cfg.Deployment = strings.TrimSpace(cfg.Deployment)
+
+	cfg.Key, err = promptSecret("Azure OpenAI API key", "KEY 1 or KEY 2 from the same page")
+	if err != nil || cfg.Key == "" {
+		fmt.Fprintln(os.Stderr, "Cancelled.")
+		return errSilent
+	}
+
+	if err := saveAzureConfig(cfg, true); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("Azure OpenAI deployment " + cfg.Deployment + " saved successfully."))
+	return nil
+}
+
+// saveAzureConfig checks cfg against the Azure endpoint, unless told not
+// to, and stores it on the server.
+func saveAzureConfig(cfg azureopenai.Config, validate bool) error {
+	if authLocal {
+		return fmt.Errorf("only Anthropic credentials can be stored in the OS keyring")
+	}
+
+	if validate {
+		fmt.Fprintln(os.Stderr, styleDim.Render("Checking "+cfg.Endpoint+"..."))
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := azureopenai.Validate(ctx, &http.Client{}, cfg)
+		cancel()
+		switch {
+		case errors.Is(err, azureopenai.ErrUnauthorized):
+			return fmt.Errorf("invalid API key — check the key and try again")
+		case errors.Is(err, azureopenai.ErrDeploymentNotFound):
+			return fmt.Errorf("%w — use the deployment name from Azure AI Foundry, not the model name", err)
+		case err != nil:
+			return err
+		}
+	}
+
+	// The server stores the key with the endpoint it belongs to; it has
+	// been checked above, so the server need not call Azure again.
+	body, _ := json.Marshal(map[string]any{
+		"key":         cfg.Key,
+		"endpoint":    cfg.Endpoint,
+		"deployment":  cfg.Deployment,
+		"api_version": cfg.APIVersion,
+		"validate":    false,
+	})
+	resp, err := httpClient.Post(baseURL()+"/api/auth/azure/api-key", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot reach server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 200:
+		return nil
+	case 404:
+		return fmt.Errorf("this server does not support Azure OpenAI — upgrade it first")
+	}
+	return serverError(resp)
+}
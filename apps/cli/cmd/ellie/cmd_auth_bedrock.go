@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/awsconfig"
+	"ellie/apps/cli/internal/credentials"
+)
+
+// ── bedrock auth flow ─────────────────────────────────────────────────────────
+
+// bedrockConfig is how the server reaches Anthropic models on AWS Bedrock.
+// No AWS secret is sent: the server resolves the profile through its own
+// AWS configuration and optionally assumes a role on top of it.
+type bedrockConfig struct {
+	AWSProfile string `json:"aws_profile,omitempty"`
+	Region     string `json:"region"`
+	RoleARN    string `json:"role_arn,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+}
+
+var (
+	bedrockProfile    string
+	bedrockRegion     string
+	bedrockRoleARN    string
+	bedrockExternalID string
+	bedrockNoValidate bool
+)
+
+var authBedrockCmd = &cobra.Command{
+	Use:   "bedrock",
+	Short: "Use Anthropic models through AWS Bedrock",
+	Long: `Point the server at AWS credentials for Anthropic models on Bedrock,
+without prompting:
+
+  ellie auth bedrock --aws-profile bedrock --region us-east-1
+  ellie auth bedrock --region eu-central-1 \
+    --role-arn arn:aws:iam::123456789012:role/ellie-bedrock
+
+The server uses the named profile from its AWS config (or its default
+credential chain), assumes --role-arn if given, and checks access by
+listing Bedrock's Anthropic models. No AWS secrets are stored by ellie.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthBedrock,
+}
+
+func init() {
+	// --profile is taken by the ellie credential profile flag on auth.
+	authBedrockCmd.Flags().StringVar(&bedrockProfile, "aws-profile", "", "AWS profile the server uses (default: AWS_PROFILE, or its default credential chain)")
+	authBedrockCmd.Flags().StringVar(&bedrockRegion, "region", "", "Bedrock region (default: AWS_REGION or the profile's region)")
+	authBedrockCmd.Flags().StringVar(&bedrockRoleARN, "role-arn", "", "IAM role to assume for Bedrock calls")
+	authBedrockCmd.Flags().StringVar(&bedrockExternalID, "external-id", "", "External ID required by the role's trust policy")
+	authBedrockCmd.Flags().BoolVar(&bedrockNoValidate, "no-validate", false, "Store the settings without checking them with Bedrock")
+}
+
+func runAuthBedrock(cmd *cobra.Command, args []string) error {
+	cfg := bedrockConfig{AWSProfile: bedrockProfile, Region: bedrockRegion, RoleARN: bedrockRoleARN, ExternalID: bedrockExternalID}
+	if cfg.AWSProfile == "" {
+		cfg.AWSProfile = os.Getenv("AWS_PROFILE")
+	}
+	if cfg.Region == "" {
+		p, _ := awsconfig.Find(awsconfig.DefaultProfile())
+		if cfg.AWSProfile != "" {
+			p, _ = awsconfig.Find(cfg.AWSProfile)
+		}
+		cfg.Region = awsconfig.DefaultRegion(p)
+	}
+	if cfg.Region == "" {
+		return fmt.Errorf("no AWS region configured — pass --region")
+	}
+	if err := validateRoleARN(cfg.RoleARN); err != nil {
+		return err
+	}
+	if cfg.ExternalID != "" && cfg.RoleARN == "" {
+		return fmt.Errorf("--external-id only applies with --role-arn")
+	}
+
+	if !bedrockNoValidate {
+		fmt.Fprintln(os.Stderr, styleDim.Render("Checking access to Bedrock..."))
+	}
+	if err := saveBedrockConfig(cfg, !bedrockNoValidate); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Anthropic will use AWS Bedrock in", cfg.Region)
+	return nil
+}
+
+func authBedrock() error {
+	profiles, err := awsconfig.Profiles()
+	if err != nil {
+		return fmt.Errorf("cannot read AWS config: %w", err)
+	}
+
+	// An empty profile leaves the choice to the server's credential chain
+	// (environment, instance role, ...).
+	var profile string
+	if len(profiles) > 0 {
+		opts := make([]huh.Option[string], 0, len(profiles)+1)
+		for _, p := range profiles {
+			label := p.Name
+			var notes []string
+			if p.Region != "" {
+				notes = append(notes, p.Region)
+			}
+			if p.SSO {
+				notes = append(notes, "SSO")
+			}
+			if p.RoleARN != "" {
+				notes = append(notes, "assumes a role")
+			}
+			if len(notes) > 0 {
+				label += "  (" + strings.Join(notes, ", ") + ")"
+			}
+			opts = append(opts, huh.NewOption(label, p.Name))
+		}
+		opts = append(opts, huh.NewOption("None — the server's default AWS credentials", ""))
+		profile = awsconfig.DefaultProfile()
+		err = huh.NewSelect[string]().
+			Title("Which AWS profile should the server use?").
+			Description("Read from " + awsconfig.ConfigPath() + " — the server needs the same profile").
+			Options(opts...).
+			Value(&profile).
+			Run()
+		if err != nil {
+			return errSilent
+		}
+	}
+
+	p, _ := awsconfig.Find(profile)
+	cfg := bedrockConfig{AWSProfile: profile, Region: awsconfig.DefaultRegion(p)}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	err = huh.NewForm(huh.NewGroup(
+		huh.NewInput().
+			Title("Bedrock region").
+			Description("A region where Anthropic models are enabled for your account").
+			Value(&cfg.Region),
+		huh.NewInput().
+			Title("IAM role to assume (optional)").
+			Description("Leave empty to call Bedrock with the profile's own credentials").
+			Placeholder("arn:aws:iam::123456789012:role/ellie-bedrock").
+			Validate(func(s string) error { return validateRoleARN(strings.TrimSpace(s)) }).
+			Value(&cfg.RoleARN),
+	)).Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Cancelled.")
+		return errSilent
+	}
+	cfg.Region, cfg.RoleARN = strings.TrimSpace(cfg.Region), strings.TrimSpace(cfg.RoleARN)
+	if cfg.Region == "" {
+		fmt.Fprintln(os.Stderr, "Cancelled.")
+		return errSilent
+	}
+	if cfg.RoleARN != "" {
+		err = huh.NewInput().
+			Title("External ID (optional)").
+			Description("Only if the role's trust policy requires one").
+			Value(&cfg.ExternalID).
+			Run()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Cancelled.")
+			return errSilent
+		}
+		cfg.ExternalID = strings.TrimSpace(cfg.ExternalID)
+	}
+
+	fmt.Println(styleDim.Render("Checking access to Bedrock..."))
+	if err := saveBedrockConfig(cfg, true); err != nil {
+		return err
+	}
+
+	fmt.Println(styleOk.Render("Anthropic will use AWS Bedrock in " + cfg.Region + "."))
+	return nil
+}
+
+// validateRoleARN checks that arn, if set, names an IAM role.
+func validateRoleARN(arn string) error {
+	if arn == "" {
+		return nil
+	}
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || !strings.HasPrefix(parts[1], "aws") || parts[2] != "iam" || !strings.HasPrefix(parts[5], "role/") {
+		return fmt.Errorf("%q is not an IAM role ARN (arn:aws:iam::<account>:role/<name>)", arn)
+	}
+	return nil
+}
+
+// saveBedrockConfig stores the Bedrock settings as the Anthropic
+// credential on the server, optionally asking it to check them first.
+func saveBedrockConfig(cfg bedrockConfig, validate bool) error {
+	if authLocal {
+		return fmt.Errorf("Bedrock settings are stored on the server — --local is not supported")
+	}
+
+	body, _ := json.Marshal(struct {
+		bedrockConfig
+		Validate bool `json:"validate"`
+	}{cfg, validate})
+
+	resp, err := httpClient.Post(baseURL()+"/api/auth/anthropic/bedrock", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot reach server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 200:
+	case 401:
+		return fmt.Errorf("Bedrock refused access in %s — check the AWS credentials, the bedrock:ListFoundationModels permission and model access", cfg.Region)
+	case 404:
+		return fmt.Errorf("this server does not support AWS Bedrock — upgrade it, or use another Anthropic auth method")
+	default:
+		return serverError(resp)
+	}
+
+	noteAnthropicCredential(map[string]string{
+		"type":        credentials.MethodBedrock,
+		"aws_profile": cfg.AWSProfile,
+		"region":      cfg.Region,
+		"role_arn":    cfg.RoleARN,
+		"external_id": cfg.ExternalID,
+	})
+	return nil
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/gcpcreds"
+)
+
+// ── gemini auth flows ─────────────────────────────────────────────────────────
+
+// defaultVertexLocation is the Vertex AI region used when none is given.
+const defaultVertexLocation = "us-central1"
+
+// Vertex AI credential modes understood by /api/auth/gemini/vertex.
+const (
+	vertexServiceAccount = "service_account"
+	vertexADC            = "adc"
+)
+
+// vertexConfig is what the server needs to call Gemini through Vertex AI.
+// The credential file stays where it is; the server reads it by path.
+type vertexConfig struct {
+	Mode            string `json:"mode"`
+	CredentialsPath string `json:"credentials_path"`
+	Project         string `json:"project"`
+	Location        string `json:"location"`
+}
+
+var (
+	vertexCredentials string
+	vertexUseADC      bool
+	vertexProject     string
+	vertexLocation    string
+	vertexNoValidate  bool
+)
+
+var authVertexCmd = &cobra.Command{
+	Use:   "vertex",
+	Short: "Use Gemini through Vertex AI with Google Cloud credentials",
+	Long: `Point the server at Google Cloud credentials for Gemini on Vertex AI,
+without prompting. Either a service account key file or Application
+Default Credentials (GOOGLE_APPLICATION_CREDENTIALS, or the file written
+by gcloud auth application-default login) can be used:
+
+  ellie auth vertex --credentials ./sa.json --location europe-west4
+  ellie auth vertex --adc --project my-project
+
+The file is checked locally and its path is stored on the server, which
+must be able to read it. The project defaults to the one in the file or
+GOOGLE_CLOUD_PROJECT. For a Gemini API key from Google AI Studio, use
+ellie auth api-key --provider gemini.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthVertex,
+}
+
+func init() {
+	authVertexCmd.Flags().StringVar(&vertexCredentials, "credentials", "", "Service account key file (JSON)")
+	authVertexCmd.Flags().BoolVar(&vertexUseADC, "adc", false, "Use Application Default Credentials")
+	authVertexCmd.Flags().StringVar(&vertexProject, "project", "", "Google Cloud project (default: from the credentials or GOOGLE_CLOUD_PROJECT)")
+	authVertexCmd.Flags().StringVar(&vertexLocation, "location", defaultVertexLocation, "Vertex AI region")
+	authVertexCmd.Flags().BoolVar(&vertexNoValidate, "no-validate", false, "Store the settings without checking them with Google")
+	authVertexCmd.MarkFlagsMutuallyExclusive("credentials", "adc")
+	authVertexCmd.MarkFlagsOneRequired("credentials", "adc")
+}
+
+func runAuthVertex(cmd *cobra.Command, args []string) error {
+	mode, path := vertexServiceAccount, vertexCredentials
+	if vertexUseADC {
+		mode = vertexADC
+		var err error
+		if path, err = gcpcreds.ADCPath(); err != nil {
+			return err
+		}
+	}
+	creds, err := gcpcreds.Load(path)
+	if err != nil {
+		return err
+	}
+	if mode == vertexServiceAccount && creds.Type != gcpcreds.TypeServiceAccount {
+		return fmt.Errorf("%s is not a service account key (type %s) — use --adc for gcloud credentials", path, creds.Type)
+	}
+
+	cfg := vertexConfig{Mode: mode, CredentialsPath: creds.Path, Project: vertexProject, Location: vertexLocation}
+	if cfg.Project == "" {
+		cfg.Project = vertexDefaultProject(creds)
+	}
+	if cfg.Project == "" {
+		return fmt.Errorf("no Google Cloud project in %s — pass --project", path)
+	}
+	if !vertexNoValidate {
+		fmt.Fprintln(os.Stderr, styleDim.Render("Checking credentials with Vertex AI..."))
+	}
+	if err := saveVertexConfig(cfg, !vertexNoValidate); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Gemini will use Vertex AI as", creds.Account(), "in", cfg.Project+"/"+cfg.Location)
+	return nil
+}
+
+func authGemini() error {
+	var method string
+	err := huh.NewSelect[string]().
+		Title("How would you like to authenticate with Google Gemini?").
+		Options(
+			huh.NewOption("API Key (Google AI Studio)", "api_key"),
+			huh.NewOption("Vertex AI — service account key file", vertexServiceAccount),
+			huh.NewOption("Vertex AI — Application Default Credentials (gcloud)", vertexADC),
+		).
+		Value(&method).
+		Run()
+	if err != nil {
+		return errSilent
+	}
+
+	switch method {
+	case "api_key":
+		return authGeminiAPIKey()
+	case vertexServiceAccount, vertexADC:
+		return authGeminiVertex(method)
+	}
+	return nil
+}
+
+func authGeminiAPIKey() error {
+	key, err := promptSecret("Gemini API key", "Get one at https://aistudio.google.com/apikey")
+	if err != nil || key == "" {
+		fmt.Fprintln(os.Stderr, "Cancelled.")
+		return errSilent
+	}
+
+	fmt.Println(styleDim.Render("Validating key..."))
+	if err := saveAPIKey("gemini", strings.TrimSpace(key), true); err != nil {
+		return err
+	}
+
+	fmt.Println(styleOk.Render("Gemini API key saved successfully."))
+	return nil
+}
+
+func authGeminiVertex(mode string) error {
+	var creds *gcpcreds.File
+	if mode == vertexADC {
+		path, err := gcpcreds.ADCPath()
+		if err != nil {
+			return err
+		}
+		if creds, err = gcpcreds.Load(path); err != nil {
+			return err
+		}
+		fmt.Println(styleDim.Render("Using " + creds.Path + " (" + creds.Account() + ")"))
+	} else {
+		var path string
+		err := huh.NewInput().
+			Title("Path to the service account key file").
+			Description("Created under IAM → Service accounts → Keys; needs the Vertex AI User role").
+			Placeholder("~/keys/ellie-sa.json").
+			Validate(func(s string) error {
+				f, err := gcpcreds.Load(expandHome(strings.TrimSpace(s)))
+				if err == nil && f.Type != gcpcreds.TypeServiceAccount {
+					return fmt.Errorf("not a service account key (type %s)", f.Type)
+				}
+				creds = f
+				return err
+			}).
+			Value(&path).
+			Run()
+		if err != nil || creds == nil {
+			fmt.Fprintln(os.Stderr, "Cancelled.")
+			return errSilent
+		}
+	}
+
+	cfg := vertexConfig{Mode: mode, CredentialsPath: creds.Path, Project: vertexDefaultProject(creds), Location: defaultVertexLocation}
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewInput().
+			Title("Google Cloud project").
+			Validate(func(s string) error {
+				if strings.TrimSpace(s) == "" {
+					return fmt.Errorf("a project is required")
+				}
+				return nil
+			}).
+			Value(&cfg.Project),
+		huh.NewInput().
+			Title("Vertex AI region").
+			Description("e.g. us-central1, europe-west4, asia-northeast1").
+			Value(&cfg.Location),
+	)).Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Cancelled.")
+		return errSilent
+	}
+	cfg.Project, cfg.Location = strings.TrimSpace(cfg.Project), strings.TrimSpace(cfg.Location)
+	if cfg.Location == "" {
+		cfg.Location = defaultVertexLocation
+	}
+
+	fmt.Println(styleDim.Render("Checking credentials with Vertex AI..."))
+	if err := saveVertexConfig(cfg, true); err != nil {
+		return err
+	}
+
+	fmt.Println(styleOk.Render("Gemini will use Vertex AI in " + cfg.Project + "/" + cfg.Location + "."))
+	return nil
+}
+
+// vertexDefaultProject picks the project from the credentials, falling
+// back to the environment.
+func vertexDefaultProject(creds *gcpcreds.File) string {
+	if p := creds.Project(); p != "" {
+		return p
+	}
+	return gcpcreds.DefaultProject()
+}
+
+// saveVertexConfig stores Vertex AI settings for Gemini on the server,
+// optionally asking it to check them with Google first.
+func saveVertexConfig(cfg vertexConfig, validate bool) error {
+	if authLocal {
+		return fmt.Errorf("only Anthropic credentials can be stored in the OS keyring")
+	}
+
+	body, _ := json.Marshal(struct {
+		vertexConfig
+		Validate bool `json:"validate"`
+	}{cfg, validate})
+
+	resp, err := httpClient.Post(baseURL()+"/api/auth/gemini/vertex", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot reach server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 200:
+		return nil
+	case 401:
+		return fmt.Errorf("Google rejected the credentials — check that the account has the Vertex AI User role in %s", cfg.Project)
+	case 404:
+		return fmt.Errorf("this server does not support Vertex AI credentials — upgrade it, or use a Gemini API key")
+	}
+	return serverError(resp)
+}
+
+// expandHome replaces a leading ~ with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + path[1:]
+}
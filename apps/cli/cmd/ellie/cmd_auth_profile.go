@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/audit"
+	"ellie/apps/cli/internal/credentials"
+	"ellie/apps/cli/internal/paths"
+)
+
+// ── auth profiles ───────────────────────────────────────────────────────────
+
+// authProfile saves the Anthropic credential set by an auth command as a
+// named profile.
+var authProfile string
+
+// The Anthropic credential saved by the current command, in the shape of
+// the server's credentials file. When the server created it (OAuth flows)
+// only anthropicSaved is set and the entry is read back from the file.
+var (
+	anthropicSaved      bool
+	savedAnthropicEntry json.RawMessage
+)
+
+var authUseCmd = &cobra.Command{
+	Use:   "use [profile]",
+	Short: "Switch the server to a saved auth profile",
+	Long: `Switch the server's Anthropic credential to a saved profile. Profiles
+are created by adding --profile to any auth command:
+
+  ellie auth --profile work               # run the wizard, save as "work"
+  echo "$KEY" | ellie auth api-key --key-stdin --profile console
+  ellie auth use work
+
+Profile secrets are kept in the OS keyring. API key and token profiles
+are sent to the server like any new credential. OAuth profiles are
+written to the server's credentials file (CREDENTIALS_PATH), so they only
+work with a server on this machine, which then needs a restart.
+
+Without an argument, pick a profile from a list.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAuthUse,
+}
+
+func init() {
+	authCmd.PersistentFlags().StringVar(&authProfile, "profile", "", "Also save the Anthropic credential as this named profile")
+	authCmd.PersistentPreRunE = checkAuthProfileFlags
+	authCmd.PersistentPostRunE = captureAuthProfile
+}
+
+// noteAnthropicCredential records a credential the server accepted, for
+// --profile. A nil entry means it has to be read from the credentials file.
+func noteAnthropicCredential(entry map[string]string) {
+	anthropicSaved = true
+	savedAnthropicEntry = nil
+	if entry != nil {
+		savedAnthropicEntry, _ = json.Marshal(entry)
+	}
+}
+
+func checkAuthProfileFlags(cmd *cobra.Command, args []string) error {
+	if authProfile == "" {
+		return nil
+	}
+	if authLocal {
+		return fmt.Errorf("--profile cannot be combined with --local")
+	}
+	return credentials.ValidProfileName(authProfile)
+}
+
+// captureAuthProfile runs after a successful auth command and saves what
+// it stored as the --profile profile.
+func captureAuthProfile(cmd *cobra.Command, args []string) error {
+	if authProfile == "" || cmd == authUseCmd {
+		return nil
+	}
+	if !anthropicSaved {
+		return fmt.Errorf("--profile only applies to Anthropic credentials — no profile was saved")
+	}
+	entry := savedAnthropicEntry
+	if entry == nil {
+		var err error
+		if entry, _, err = readServerAnthropicEntry(); err != nil {
+			return fmt.Errorf("credential saved, but cannot capture it for profile %s: %w", authProfile, err)
+		}
+	}
+
+	profiles, err := loadAuthProfiles()
+	if err != nil {
+		return err
+	}
+	if err := saveAuthProfile(profiles, authProfile, entry); err != nil {
+		return err
+	}
+	profiles.Active = authProfile
+	if err := profiles.Save(); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Saved as profile", styleBold.Render(authProfile), styleDim.Render("(active)"))
+	return nil
+}
+
+func runAuthUse(cmd *cobra.Command, args []string) error {
+	profiles, err := loadAuthProfiles()
+	if err != nil {
+		return err
+	}
+	if len(profiles.Profiles) == 0 {
+		return fmt.Errorf("no saved profiles — create one with ellie auth --profile <name>")
+	}
+
+	var name string
+	if len(args) == 1 {
+		name = args[0]
+	} else {
+		if err := noPrompt("choosing a profile", "name it: ellie auth use <profile>"); err != nil {
+			return err
+		}
+		var options []huh.Option[string]
+		for _, n := range profiles.Names() {
+			label := n + "  " + profiles.Profiles[n].Method
+			if n == profiles.Active {
+				label += "  (active)"
+			}
+			options = append(options, huh.NewOption(label, n))
+		}
+		err := huh.NewSelect[string]().
+			Title("Switch to which profile?").
+			Options(options...).
+			Value(&name).
+			Run()
+		if err != nil {
+			return errSilent
+		}
+	}
+
+	target, ok := profiles.Profiles[name]
+	if !ok {
+		return fmt.Errorf("no profile named %s (saved: %s)", name, strings.Join(profiles.Names(), ", "))
+	}
+
+	// Refresh the outgoing profile's copy before it is replaced.
+	if profiles.Active != name {
+		updateOAuthProfile(profiles, profiles.Active)
+	}
+
+	cred, err := localCredentials.LoadProfile("anthropic", name)
+	if err != nil {
+		return fmt.Errorf("cannot load profile %s from the OS keyring: %w", name, err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(cred.Secret), &entry); err != nil {
+		return fmt.Errorf("profile %s is corrupt: %w", name, err)
+	}
+
+	switch target.Method {
+	case credentials.MethodAPIKey:
+		key, _ := entry["key"].(string)
+		err = saveAPIKey("anthropic", key, false)
+	case credentials.MethodToken:
+		token, _ := entry["token"].(string)
+		err = saveToken(token)
+	case credentials.MethodOAuth:
+		err = writeServerAnthropicEntry(json.RawMessage(cred.Secret))
+	case credentials.MethodBedrock:
+		var cfg bedrockConfig
+		if err = json.Unmarshal([]byte(cred.Secret), &cfg); err == nil {
+			err = saveBedrockConfig(cfg, false)
+		}
+	default:
+		err = fmt.Errorf("profile %s has unknown method %q", name, target.Method)
+	}
+	if err != nil {
+		return err
+	}
+
+	profiles.Active = name
+	if err := profiles.Save(); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Switched to profile", styleBold.Render(name), styleDim.Render("("+target.Method+")"))
+	if target.Method == credentials.MethodOAuth {
+		fmt.Println(styleDim.Render("  Restart the server (ellie restart, or restart ellie dev) so it picks up the OAuth credential."))
+	}
+	return nil
+}
+
+// updateOAuthProfile copies the server's current OAuth credential into
+// profile name, if that is an OAuth profile. The server rotates OAuth
+// refresh tokens, so a copy taken earlier stops working.
+func updateOAuthProfile(profiles *credentials.Profiles, name string) bool {
+	p, ok := profiles.Profiles[name]
+	if !ok || p.Method != credentials.MethodOAuth {
+		return false
+	}
+	entry, _, err := readServerAnthropicEntry()
+	if err != nil || entryMethod(entry) != credentials.MethodOAuth {
+		return false
+	}
+	if err := saveAuthProfile(profiles, name, entry); err != nil {
+		warn("cannot update profile " + name + ": " + err.Error())
+		return false
+	}
+	return true
+}
+
+// syncActiveOAuthProfile updates the active profile after the server
+// refreshed its OAuth token.
+func syncActiveOAuthProfile() {
+	profiles, err := loadAuthProfiles()
+	if err != nil || !updateOAuthProfile(profiles, profiles.Active) {
+		return
+	}
+	if err := profiles.Save(); err != nil {
+		warn("cannot save profiles: " + err.Error())
+	}
+}
+
+// saveAuthProfile stores entry in the keyring as profile name and records
+// it in the index (without saving the index).
+func saveAuthProfile(profiles *credentials.Profiles, name string, entry json.RawMessage) error {
+	method := entryMethod(entry)
+	if method == "" {
+		return fmt.Errorf("unrecognized Anthropic credential — profile %s not saved", name)
+	}
+	cred := credentials.Credential{Provider: "anthropic", Profile: name, Method: method, Secret: string(entry), SavedAt: time.Now()}
+	if err := localCredentials.Save(cred); err != nil {
+		return fmt.Errorf("cannot save profile %s to the OS keyring: %w", name, err)
+	}
+	profiles.Profiles[name] = credentials.Profile{
+		Provider: "anthropic",
+		Method:   method,
+		Preview:  entryPreview(entry),
+		SavedAt:  cred.SavedAt,
+	}
+	return nil
+}
+
+// entryMethod returns the type of a credentials-file entry.
+func entryMethod(entry json.RawMessage) string {
+	var e struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(entry, &e) != nil {
+		return ""
+	}
+	switch e.Type {
+	case credentials.MethodAPIKey, credentials.MethodToken, credentials.MethodOAuth, credentials.MethodBedrock:
+		return e.Type
+	}
+	return ""
+}
+
+// entryPreview returns what identifies an entry in profile listings: the
+// masked secret, or the region and AWS profile for Bedrock.
+func entryPreview(entry json.RawMessage) string {
+	if entryMethod(entry) == credentials.MethodBedrock {
+		var cfg bedrockConfig
+		_ = json.Unmarshal(entry, &cfg)
+		if cfg.AWSProfile != "" {
+			return cfg.Region + " (" + cfg.AWSProfile + ")"
+		}
+		return cfg.Region
+	}
+	return credentials.Credential{Secret: entrySecret(entry)}.Preview()
+}
+
+// entrySecret returns the secret a credentials-file entry is identified by.
+func entrySecret(entry json.RawMessage) string {
+	var e struct {
+		Key    string `json:"key"`
+		Token  string `json:"token"`
+		Access string `json:"access"`
+	}
+	_ = json.Unmarshal(entry, &e)
+	return e.Key + e.Token + e.Access
+}
+
+func loadAuthProfiles() (*credentials.Profiles, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return nil, err
+	}
+	return credentials.LoadProfiles(filepath.Join(dir, "profiles.json"))
+}
+
+// serverCredentialsPath returns the credentials file of the local server:
+// CREDENTIALS_PATH, the production build's file while ellie start is
+// running, or the monorepo's file used by ellie dev.
+func serverCredentialsPath() (string, error) {
+	if p := os.Getenv("CREDENTIALS_PATH"); p != "" {
+		return p, nil
+	}
+	root, err := findMonorepoRoot()
+	if err != nil {
+		return "", err
+	}
+	if _, _, ok := runningProcess("start"); ok {
+		return filepath.Join(root, "dist", "release", ".credentials.json"), nil
+	}
+	return filepath.Join(root, ".credentials.json"), nil
+}
+
+// readServerAnthropicEntry returns the anthropic entry of the server's
+// credentials file.
+func readServerAnthropicEntry() (json.RawMessage, string, error) {
+	path, err := serverCredentialsPath()
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, path, fmt.Errorf("%s: %w", path, err)
+	}
+	entry, ok := m["anthropic"]
+	if !ok {
+		return nil, path, fmt.Errorf("no Anthropic credential in %s", path)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, entry); err != nil {
+		return nil, path, err
+	}
+	return compact.Bytes(), path, nil
+}
+
+// writeServerAnthropicEntry replaces the anthropic entry of the server's
+// credentials file, keeping the other providers.
+func writeServerAnthropicEntry(entry json.RawMessage) error {
+	path, err := serverCredentialsPath()
+	if err != nil {
+		return err
+	}
+	m := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &m); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+	m["anthropic"] = entry
+
+	out, err := json.MarshalIndent(m, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, append(out, '\n'), 0o600)
+	}
+	e := audit.Entry{
+		Actor:    auditActor(),
+		Op:       "set",
+		Provider: "anthropic",
+		Method:   credentials.MethodOAuth,
+		Server:   "file:" + path,
+		OK:       err == nil,
+	}
+	if err != nil {
+		e.Detail = err.Error()
+	}
+	recordCredentialOp(e)
+	return err
+}
+
+// printAuthProfiles lists saved profiles for auth status.
+func printAuthProfiles() {
+	profiles, err := loadAuthProfiles()
+	if err != nil || len(profiles.Profiles) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println(styleBold.Render("  Profiles"))
+	for _, name := range profiles.Names() {
+		p := profiles.Profiles[name]
+		marker, label := "  ", fmt.Sprintf("%-16s", name)
+		if name == profiles.Active {
+			marker, label = styleOk.Render("● "), styleBold.Render(label)
+		}
+		fmt.Printf("    %s%s %-8s %s\n", marker, label, p.Method, styleDim.Render(p.Preview))
+	}
+}
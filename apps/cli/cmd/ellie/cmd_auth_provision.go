@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/provision"
+)
+
+// ── auth provision ───────────────────────────────────────────────────────────
+
+var provisionTarget string
+
+var authProvisionCmd = &cobra.Command{
+	Use:   "provision <manifest.yaml>",
+	Short: "Apply credentials to one or more machines from a manifest",
+	Long: `Apply credentials from a manifest to one or more ellie servers.
+
+Each entry names a target, a provider and a secret:
+
+  target: ssh://admin@lab-01        # default for entries without one
+  entries:
+    - user: alice
+      target: ssh://alice@lab-02?port=3000
+      provider: anthropic
+      key_env: ALICE_ANTHROPIC_KEY
+    - user: bob
+      provider: groq
+      key: gsk_...
+
+Targets are either an http(s):// server URL or ssh://[user@]host[:port],
+in which case the server port (default 3000) is forwarded over SSH so the
+loopback-only auth routes are reachable. If any entry fails, credentials
+applied earlier in the run are cleared again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAuthProvision,
+}
+
+func init() {
+	authProvisionCmd.Flags().StringVar(&provisionTarget, "target", "", "Default target for entries that don't set one (ssh://… or http://…)")
+}
+
+// provisionEntry is a single credential assignment from the manifest.
+type provisionEntry struct {
+	User     string
+	Target   string
+	Provider string
+	Key      string
+	Token    string
+	Validate bool
+}
+
+// provisionOutcome records what happened to an entry so it can be
+// reported and, if needed, rolled back.
+type provisionOutcome struct {
+	entry        provisionEntry
+	applied      bool
+	hadPrevious  bool
+	err          error
+	rolledBack   bool
+	rollbackNote string
+}
+
+// provisionProviders maps manifest provider names to their auth route prefix.
+var provisionProviders = map[string]string{
+	"anthropic":  "/api/auth/anthropic",
+	"gemini":     "/api/auth/gemini",
+	"groq":       "/api/auth/groq",
+	"brave":      "/api/auth/brave",
+	"elevenlabs": "/api/auth/elevenlabs",
+	"civitai":    "/api/auth/civitai",
+}
+
+func runAuthProvision(cmd *cobra.Command, args []string) error {
+	entries, err := loadProvisionManifest(args[0])
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("manifest %s has no entries", args[0])
+	}
+
+	// Group by target so each machine gets a single tunnel.
+	var targets []string
+	byTarget := map[string][]provisionEntry{}
+	for _, e := range entries {
+		if _, ok := byTarget[e.Target]; !ok {
+			targets = append(targets, e.Target)
+		}
+		byTarget[e.Target] = append(byTarget[e.Target], e)
+	}
+
+	var outcomes []*provisionOutcome
+	var done []string // targets fully or partly applied, for rollback
+	failed := false
+
+	for _, target := range targets {
+		fmt.Println(styleDim.Render("Provisioning " + target + "..."))
+		base, closeTunnel, err := openProvisionTarget(target)
+		if err != nil {
+			for _, e := range byTarget[target] {
+				outcomes = append(outcomes, &provisionOutcome{entry: e, err: err})
+			}
+			failed = true
+			break
+		}
+
+		for _, e := range byTarget[target] {
+			o := applyProvisionEntry(base, e)
+			outcomes = append(outcomes, o)
+			if o.err != nil {
+				failed = true
+				break
+			}
+		}
+
+		if failed {
+			rollbackProvision(base, target, outcomes)
+		}
+		closeTunnel()
+		if failed {
+			break
+		}
+		done = append(done, target)
+	}
+
+	// Entries on earlier targets were applied successfully; undo them too.
+	if failed {
+		for _, target := range done {
+			base, closeTunnel, err := openProvisionTarget(target)
+			if err != nil {
+				for _, o := range outcomes {
+					if o.entry.Target == target && o.applied {
+						o.rollbackNote = "rollback failed: " + err.Error()
+					}
+				}
+				continue
+			}
+			rollbackProvision(base, target, outcomes)
+			closeTunnel()
+		}
+	}
+
+	printProvisionResults(outcomes, len(entries))
+	if failed {
+		return errSilent
+	}
+	return nil
+}
+
+// applyProvisionEntry records the provider's prior state, then stores
+// the entry's secret on the server at base.
+func applyProvisionEntry(base string, e provisionEntry) *provisionOutcome {
+	o := &provisionOutcome{entry: e}
+	prefix := provisionProviders[e.Provider]
+
+	configured, err := provisionStatus(base, prefix)
+	if err != nil {
+		o.err = err
+		return o
+	}
+	o.hadPrevious = configured
+
+	var path string
+	var payload map[string]any
+	if e.Token != "" {
+		path = prefix + "/token"
+		payload = map[string]any{"token": e.Token}
+	} else {
+		path = prefix + "/api-key"
+		payload = map[string]any{"key": e.Key, "validate": e.Validate}
+	}
+
+	ctx := context.Background()
+	if configured {
+		ctx = withAuditOp(ctx, "rotate")
+	}
+	resp, err := provisionPost(ctx, base, path, payload)
+	if err != nil {
+		o.err = err
+		return o
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 401 {
+		o.err = fmt.Errorf("invalid API key")
+		return o
+	}
+	if resp.StatusCode != 200 {
+		o.err = serverError(resp)
+		return o
+	}
+	o.applied = true
+	return o
+}
+
+// rollbackProvision clears credentials that this run applied to target.
+// Providers that were already configured cannot be restored because the
+// server never returns stored secrets, so they are left as-is and noted.
+func rollbackProvision(base, target string, outcomes []*provisionOutcome) {
+	for _, o := range outcomes {
+		if o.entry.Target != target || !o.applied {
+			continue
+		}
+		if o.hadPrevious {
+			o.rollbackNote = "previous credential was overwritten and cannot be restored"
+			continue
+		}
+		resp, err := provisionPost(context.Background(), base, provisionProviders[o.entry.Provider]+"/clear", nil)
+		if err != nil {
+			o.rollbackNote = "rollback failed: " + err.Error()
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 200 {
+			o.rollbackNote = fmt.Sprintf("rollback failed: server returned %d", resp.StatusCode)
+			continue
+		}
+		o.rolledBack = true
+	}
+}
+
+func printProvisionResults(outcomes []*provisionOutcome, total int) {
+	fmt.Println()
+	fmt.Println(styleBold.Render("Provisioning Results"))
+	fmt.Println(strings.Repeat("─", 60))
+	for _, o := range outcomes {
+		who := o.entry.User
+		if who == "" {
+			who = "-"
+		}
+		var status string
+		switch {
+		case o.err != nil:
+			status = styleErr.Render("failed: " + o.err.Error())
+		case o.rolledBack:
+			status = styleDim.Render("applied, rolled back")
+		case o.applied && o.rollbackNote != "":
+			status = styleErr.Render("applied, " + o.rollbackNote)
+		case o.applied:
+			status = styleOk.Render("applied")
+		}
+		fmt.Printf("  %-12s %-10s %-28s %s\n", who, o.entry.Provider, o.entry.Target, status)
+	}
+	if skipped := total - len(outcomes); skipped > 0 {
+		fmt.Println(styleDim.Render(fmt.Sprintf("  %d entries not attempted", skipped)))
+	}
+	fmt.Println()
+}
+
+func provisionStatus(base, prefix string) (bool, error) {
+	resp, err := httpClient.Get(base + prefix + "/status")
+	if err != nil {
+		return false, fmt.Errorf("cannot reach server at %s", base)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return false, serverError(resp)
+	}
+	var status struct {
+		Configured bool `json:"configured"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return false, fmt.Errorf("invalid response: %w", err)
+	}
+	return status.Configured, nil
+}
+
+func provisionPost(ctx context.Context, base, path string, payload map[string]any) (*http.Response, error) {
+	var body []byte
+	if payload != nil {
+		body, _ = json.Marshal(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach server at %s", base)
+	}
+	return resp, nil
+}
+
+// ── targets ──────────────────────────────────────────────────────────────────
+
+// openProvisionTarget returns a base URL for target and a function that
+// tears down any tunnel opened for it.
+func openProvisionTarget(target string) (string, func(), error) {
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return strings.TrimRight(target, "/"), func() {}, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "ssh" || u.Hostname() == "" {
+		return "", nil, fmt.Errorf("invalid target %q (expected ssh://host or http(s)://host)", target)
+	}
+
+	remotePort := u.Query().Get("port")
+	if remotePort == "" {
+		remotePort = "3000"
+	}
+
+	localPort, err := freeLocalPort()
+	if err != nil {
+		return "", nil, err
+	}
+
+	dest := u.Hostname()
+	if u.User != nil {
+		dest = u.User.Username() + "@" + dest
+	}
+	sshArgs := []string{
+		"-N",
+		"-o", "BatchMode=yes",
+		"-o", "ExitOnForwardFailure=yes",
+		"-L", fmt.Sprintf("%d:localhost:%s", localPort, remotePort),
+	}
+	if u.Port() != "" {
+		sshArgs = append(sshArgs, "-p", u.Port())
+	}
+	sshArgs = append(sshArgs, dest)
+
+	tunnel := exec.Command("ssh", sshArgs...)
+	var stderr bytes.Buffer
+	tunnel.Stderr = &stderr
+	logCommand(tunnel)
+	if err := tunnel.Start(); err != nil {
+		return "", nil, fmt.Errorf("start ssh tunnel: %w", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = tunnel.Wait()
+		close(exited)
+	}()
+	stop := func() {
+		_ = tunnel.Process.Kill()
+		<-exited
+	}
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort))
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	for {
+		if conn, err := net.DialTimeout("tcp", addr, 500*time.Millisecond); err == nil {
+			conn.Close()
+			return "http://" + addr, stop, nil
+		}
+		select {
+		case <-exited:
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				msg = "ssh exited"
+			}
+			return "", nil, fmt.Errorf("ssh tunnel to %s failed: %s", u.Host, msg)
+		case <-ctx.Done():
+			stop()
+			return "", nil, fmt.Errorf("timed out opening ssh tunnel to %s", u.Host)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func freeLocalPort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("cannot allocate local port: %w", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// ── manifest ─────────────────────────────────────────────────────────────────
+
+// loadProvisionManifest reads the manifest and resolves defaults and
+// secrets.
+func loadProvisionManifest(path string) ([]provisionEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open manifest: %w", err)
+	}
+	defer f.Close()
+	manifest, err := provision.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	top, items := manifest.Top, manifest.Entries
+
+	defaultTarget := provisionTarget
+	if defaultTarget == "" {
+		defaultTarget = top["target"]
+	}
+
+	entries := make([]provisionEntry, 0, len(items))
+	for i, item := range items {
+		e := provisionEntry{
+			User:     item["user"],
+			Target:   item["target"],
+			Provider: strings.ToLower(item["provider"]),
+			Key:      item["key"],
+			Token:    item["token"],
+			Validate: item["validate"] != "false",
+		}
+		if e.Target == "" {
+			e.Target = defaultTarget
+		}
+		if v := item["key_env"]; v != "" {
+			e.Key = os.Getenv(v)
+			if e.Key == "" {
+				return nil, fmt.Errorf("entry %d: environment variable %s is empty", i+1, v)
+			}
+		}
+		if v := item["token_env"]; v != "" {
+			e.Token = os.Getenv(v)
+			if e.Token == "" {
+				return nil, fmt.Errorf("entry %d: environment variable %s is empty", i+1, v)
+			}
+		}
+
+		switch {
+		case e.Target == "":
+			return nil, fmt.Errorf("entry %d: no target (set target in the manifest or pass --target)", i+1)
+		case provisionProviders[e.Provider] == "":
+			return nil, fmt.Errorf("entry %d: unknown provider %q", i+1, item["provider"])
+		case e.Key == "" && e.Token == "":
+			return nil, fmt.Errorf("entry %d: needs key, key_env, token or token_env", i+1)
+		case e.Token != "" && e.Provider != "anthropic":
+			return nil, fmt.Errorf("entry %d: bearer tokens are only supported for anthropic", i+1)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
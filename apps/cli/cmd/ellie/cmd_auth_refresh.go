@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/credentials"
+)
+
+// ── auth refresh ────────────────────────────────────────────────────────────
+
+var authRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Refresh the Anthropic OAuth access token now",
+	Long: `Ask the server to exchange the stored Anthropic OAuth refresh token for
+a new access token, and show when it expires.
+
+To refresh automatically, set ELLIE_AUTO_REFRESH to a lead time, e.g.
+ELLIE_AUTO_REFRESH=30m (or just 30, in minutes). Any command that talks to
+the server then refreshes the token first when it expires within that
+time.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthRefresh,
+}
+
+// errRefreshUnsupported is returned by servers without the refresh route.
+var errRefreshUnsupported = errors.New("this server has no refresh endpoint — it refreshes OAuth tokens itself when it uses them; upgrade the server to refresh on demand")
+
+// anthropicOAuthStatus is the part of the Anthropic auth status that
+// matters for refreshing.
+type anthropicOAuthStatus struct {
+	Mode      *string  `json:"mode"`
+	ExpiresAt *float64 `json:"expires_at,omitempty"`
+}
+
+func (s anthropicOAuthStatus) isOAuth() bool {
+	return s.Mode != nil && *s.Mode == credentials.MethodOAuth
+}
+
+func (s anthropicOAuthStatus) expiry() time.Time {
+	if s.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(*s.ExpiresAt))
+}
+
+func runAuthRefresh(cmd *cobra.Command, args []string) error {
+	status, err := fetchAnthropicOAuthStatus(context.Background())
+	if err != nil {
+		return err
+	}
+	if !status.isOAuth() {
+		mode := "not configured"
+		if status.Mode != nil {
+			mode = *status.Mode
+		}
+		return fmt.Errorf("Anthropic isn't signed in with OAuth (%s) — nothing to refresh", mode)
+	}
+
+	expires, err := refreshAnthropicToken(context.Background())
+	if err != nil {
+		return err
+	}
+	noteAnthropicCredential(nil)
+	syncActiveOAuthProfile()
+
+	fmt.Print(styleOk.Render("✓"), " Refreshed the Anthropic OAuth token")
+	if !expires.IsZero() {
+		fmt.Print(styleDim.Render(" (expires " + expires.Local().Format("Jan 2 15:04") + ", in " + formatUptime(time.Until(expires)) + ")"))
+	}
+	fmt.Println()
+	return nil
+}
+
+func fetchAnthropicOAuthStatus(ctx context.Context) (anthropicOAuthStatus, error) {
+	var status anthropicOAuthStatus
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL()+"/api/auth/anthropic/status", nil)
+	if err != nil {
+		return status, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return status, fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return status, serverError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return status, fmt.Errorf("invalid response: %w", err)
+	}
+	return status, nil
+}
+
+// refreshAnthropicToken has the server refresh its Anthropic OAuth token
+// and returns the new expiry, or zero when the server doesn't report it.
+func refreshAnthropicToken(ctx context.Context) (time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL()+"/api/auth/anthropic/refresh", strings.NewReader("{}"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot reach server: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return time.Time{}, errRefreshUnsupported
+	default:
+		return time.Time{}, serverError(resp)
+	}
+	var out anthropicOAuthStatus
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return out.expiry(), nil
+}
+
+// ── automatic refresh ───────────────────────────────────────────────────────
+
+// autoRefreshTransport refreshes the server's Anthropic OAuth token before
+// the first request a command makes to a server, when ELLIE_AUTO_REFRESH
+// is set and the token expires within that lead time. Problems are
+// reported once on stderr and never fail the command's own request.
+type autoRefreshTransport struct {
+	base http.RoundTripper
+}
+
+type autoRefreshKey struct{}
+
+var (
+	autoRefreshMu   sync.Mutex
+	autoRefreshDone = map[string]bool{}
+)
+
+func (t autoRefreshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests that change credentials go straight through.
+	changing := req.Method != http.MethodGet && strings.HasPrefix(req.URL.Path, "/api/auth/")
+	if lead, ok := autoRefreshLead(); ok && !changing && req.Context().Value(autoRefreshKey{}) == nil {
+		t.maybeRefresh(req.Context(), serverOrigin(req.URL.String()), lead)
+	}
+	return t.base.RoundTrip(req)
+}
+
+func (t autoRefreshTransport) maybeRefresh(ctx context.Context, origin string, lead time.Duration) {
+	autoRefreshMu.Lock()
+	defer autoRefreshMu.Unlock()
+	if autoRefreshDone[origin] || origin != serverOrigin(baseURL()) {
+		return
+	}
+	autoRefreshDone[origin] = true
+
+	// The checks below go through httpClient so the refresh is audited;
+	// the context key keeps them from coming back here.
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, autoRefreshKey{}, true), 30*time.Second)
+	defer cancel()
+	status, err := fetchAnthropicOAuthStatus(ctx)
+	if err != nil || !status.isOAuth() || status.expiry().IsZero() || time.Until(status.expiry()) > lead {
+		return
+	}
+	expires, err := refreshAnthropicToken(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, styleErr.Render("!")+" Cannot refresh the Anthropic OAuth token: "+err.Error())
+		return
+	}
+	syncActiveOAuthProfile()
+	msg := "Refreshed the Anthropic OAuth token"
+	if !expires.IsZero() {
+		msg += " (expires in " + formatUptime(time.Until(expires)) + ")"
+	}
+	fmt.Fprintln(os.Stderr, styleDim.Render(msg))
+}
+
+// autoRefreshLead parses ELLIE_AUTO_REFRESH: a duration, or minutes.
+func autoRefreshLead() (time.Duration, bool) {
+	v := strings.TrimSpace(os.Getenv("ELLIE_AUTO_REFRESH"))
+	if v == "" {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		return time.Duration(n) * time.Minute, n > 0
+	}
+	d, err := time.ParseDuration(v)
+	return d, err == nil && d > 0
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// ── auth api-key / auth token (non-interactive) ─────────────────────────────
+
+var (
+	authKeyStdin   bool
+	authTokenStdin bool
+	authNoValidate bool
+	authProvider   string
+)
+
+var authAPIKeyCmd = &cobra.Command{
+	Use:   "api-key",
+	Short: "Store a provider API key",
+	Long: `Store an API key on the server. With --key-stdin the key is read from
+standard input, so this works in scripts and CI without a terminal:
+
+  echo "$ANTHROPIC_API_KEY" | ellie auth api-key --key-stdin
+  ellie auth api-key --provider groq --key-stdin --no-validate < groq.key
+
+Without --key-stdin the key is prompted for, which requires a terminal.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthAPIKey,
+}
+
+var authTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Store an Anthropic bearer token",
+	Long: `Store an Anthropic bearer token on the server. With --token-stdin the
+token is read from standard input:
+
+  echo "$ANTHROPIC_TOKEN" | ellie auth token --token-stdin`,
+	Args: cobra.NoArgs,
+	RunE: runAuthToken,
+}
+
+func init() {
+	authAPIKeyCmd.Flags().BoolVar(&authKeyStdin, "key-stdin", false, "Read the key from standard input")
+	authAPIKeyCmd.Flags().BoolVar(&authNoValidate, "no-validate", false, "Store the key without checking it with the provider")
+	authAPIKeyCmd.Flags().StringVar(&authProvider, "provider", "anthropic", "Provider: anthropic, gemini, groq, brave, elevenlabs, civitai")
+	authTokenCmd.Flags().BoolVar(&authTokenStdin, "token-stdin", false, "Read the token from standard input")
+}
+
+func runAuthAPIKey(cmd *cobra.Command, args []string) error {
+	if _, ok := provisionProviders[authProvider]; !ok {
+		return fmt.Errorf("unknown provider %q (use anthropic, gemini, groq, brave, elevenlabs or civitai)", authProvider)
+	}
+	fromStdin := authKeyStdin || authStdinSecret
+	if !fromStdin {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return fmt.Errorf("no terminal to prompt for the key — pass --key-stdin and pipe the key in")
+		}
+		if authProvider == "anthropic" && !authNoValidate {
+			return authApiKey()
+		}
+	}
+
+	key, err := readSecret(fromStdin, "API key")
+	if err != nil {
+		return err
+	}
+	if !authNoValidate && !authLocal {
+		fmt.Fprintln(os.Stderr, styleDim.Render("Validating key..."))
+	}
+	if err := saveAPIKey(authProvider, key, !authNoValidate); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "API key saved for", authProvider)
+	return nil
+}
+
+func runAuthToken(cmd *cobra.Command, args []string) error {
+	if !authTokenStdin && !authStdinSecret {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return fmt.Errorf("no terminal to prompt for the token — pass --token-stdin and pipe the token in")
+		}
+		return authToken()
+	}
+
+	token, err := readSecret(true, "token")
+	if err != nil {
+		return err
+	}
+	if err := saveToken(token); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Token saved for anthropic")
+	return nil
+}
+
+// readSecret reads a secret from stdin (first line, trimmed), or prompts
+// for it without echo when fromStdin is false.
+func readSecret(fromStdin bool, what string) (string, error) {
+	if !fromStdin {
+		fmt.Fprintf(os.Stderr, "Enter %s: ", what)
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if s := strings.TrimSpace(string(b)); s != "" {
+			return s, nil
+		}
+		return "", fmt.Errorf("no %s entered", what)
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("cannot read %s from stdin: %w", what, err)
+	}
+	s := strings.TrimSpace(line)
+	if s == "" {
+		return "", fmt.Errorf("no %s on stdin", what)
+	}
+	return s, nil
+}
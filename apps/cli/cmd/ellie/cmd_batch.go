@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/chatui"
+	"ellie/apps/cli/internal/jobs"
+)
+
+// ── batch ───────────────────────────────────────────────────────────────────
+
+var (
+	batchParallel int
+	batchOutput   string
+	batchDetach   bool
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch <prompts-file>",
+	Short: "Run a file of prompts, each in its own conversation",
+	Long: `Send every prompt in a file to the assistant, each in a new conversation
+so the answers don't influence each other, and write the results as JSON
+lines: the answer or error, model, tokens, cost and time taken.
+
+The file has one prompt per line, identified by its line number, or JSON
+lines of {"id": ..., "prompt": ...}.
+
+--extract and --jq work as with ellie chat --prompt: the part of each
+answer they select is recorded as its output, and an answer it can't be
+extracted from counts as failed:
+
+  ellie batch questions.txt --extract json --jq .verdict -o verdicts.jsonl
+
+With --output, prompts that already have an answer in the output file are
+skipped, so an interrupted run picks up where it stopped. --detach hands
+the run to a background runner that outlives the terminal; manage it with
+ellie jobs:
+
+  ellie batch evals.jsonl -j 4 --detach
+  ellie jobs attach <id>`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBatch,
+}
+
+func init() {
+	batchCmd.Flags().IntVarP(&batchParallel, "parallel", "j", 1, "Prompts to run at once")
+	batchCmd.Flags().StringVarP(&batchOutput, "output", "o", "", "Append results to this file instead of standard output")
+	batchCmd.Flags().BoolVarP(&batchDetach, "detach", "d", false, "Run in the background as a job (see ellie jobs)")
+	batchCmd.Flags().StringVar(&extractMode, "extract", "", "Record only the first code block (code, code:<lang>) or JSON value (json) of each answer")
+	batchCmd.Flags().StringVar(&jqFilter, "jq", "", "Record what this jq-style filter selects from the JSON in each answer")
+}
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	if batchParallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+	post, err := newPostProcessor(extractMode, jqFilter)
+	if err != nil {
+		return err
+	}
+	items, err := jobs.ReadItems(expandHome(args[0]))
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("%s has no prompts", args[0])
+	}
+
+	base := requireBaseURL()
+	if _, err := chatui.NewHTTPClient(base).GetStatus(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, styleErr.Render("Cannot connect to server at "+base))
+		fmt.Fprintln(os.Stderr, styleDim.Render("Make sure the server is running (ellie dev or ellie start)"))
+		return errSilent
+	}
+	if batchDetach {
+		return startBatchJob(args[0], items, base)
+	}
+
+	out := io.Writer(os.Stdout)
+	pending := items
+	if batchOutput != "" {
+		results, err := jobs.ReadResults(batchOutput)
+		if err != nil {
+			return err
+		}
+		pending = jobs.Pending(items, results)
+		if len(pending) == 0 {
+			fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf("All %d prompts already have results in %s", len(items), batchOutput)))
+			return nil
+		}
+		if skipped := len(items) - len(pending); skipped > 0 {
+			fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf("Resuming: %d of %d prompts already done", skipped, len(items))))
+		}
+		f, err := os.OpenFile(batchOutput, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	done := len(items) - len(pending)
+	failed := runBatchItems(ctx, base, pending, batchParallel, post, out, func(r jobs.Result) {
+		done++
+		printBatchProgress(os.Stderr, done, len(items), r)
+	})
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, styleDim.Render("Interrupted — run the same command again to finish the remaining prompts"))
+		return errSilent
+	}
+	if failed > 0 {
+		fmt.Fprintln(os.Stderr, styleErr.Render(fmt.Sprintf("%d of %d prompts failed", failed, len(pending))))
+		return errSilent
+	}
+	return nil
+}
+
+// runBatchItems runs items on the server, parallel at a time, writing each
+// result to out as a JSON line, and returns how many failed. post, when
+// not nil, extracts each result's output. Prompts cut off by ctx are not
+// recorded, so they run again on resume.
+func runBatchItems(ctx context.Context, base string, items []jobs.Item, parallel int, post *postProcessor, out io.Writer, progress func(jobs.Result)) (failed int) {
+	queue := make(chan jobs.Item)
+	go func() {
+		defer close(queue)
+		for _, it := range items {
+			select {
+			case queue <- it:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	enc := json.NewEncoder(out)
+	var wg sync.WaitGroup
+	for range min(parallel, len(items)) {
+		wg.Go(func() {
+			for it := range queue {
+				r := runBatchItem(ctx, base, it, post)
+				if ctx.Err() != nil {
+					return
+				}
+				mu.Lock()
+				if err := enc.Encode(r); err != nil && r.Error == "" {
+					r.Error = "cannot write result: " + err.Error()
+				}
+				if r.Error != "" {
+					failed++
+				}
+				progress(r)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return failed
+}
+
+// runBatchItem answers one prompt in a conversation of its own.
+func runBatchItem(ctx context.Context, base string, it jobs.Item, post *postProcessor) (r jobs.Result) {
+	start := time.Now()
+	r = jobs.Result{ID: it.ID, Prompt: it.Prompt}
+	defer func() { r.DurationMs = time.Since(start).Milliseconds() }()
+
+	thread, err := chatui.NewHTTPClient(base).CreateAssistantThread(ctx, "Batch: "+truncate(it.Prompt, 60))
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.BranchID = thread.BranchID
+	res, err := chatui.RunOneShot(ctx, chatui.OneShotConfig{BaseURL: base, BranchID: thread.BranchID, Format: "text"}, it.Prompt)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Content = res.Content
+	r.Error = res.Error
+	if res.Model != nil {
+		r.Model = *res.Model
+	}
+	r.PromptTokens = res.PromptTokens
+	r.CompletionTokens = res.CompletionTokens
+	r.Cost = res.TotalCost
+	if post != nil && r.Error == "" {
+		if r.Output, err = post.apply(res.Content); err != nil {
+			r.Error = err.Error()
+		}
+	}
+	return r
+}
+
+func printBatchProgress(w io.Writer, done, total int, r jobs.Result) {
+	took := (time.Duration(r.DurationMs) * time.Millisecond).Round(100 * time.Millisecond)
+	if r.Error != "" {
+		fmt.Fprintf(w, "[%d/%d] %s %s %s\n", done, total, styleErr.Render("✗"), r.ID, styleDim.Render(truncate(r.Error, 80)))
+		return
+	}
+	fmt.Fprintf(w, "[%d/%d] %s %s %s\n", done, total, styleOk.Render("✓"), r.ID, styleDim.Render(took.String()))
+}
+
+// startBatchJob records items as a job and hands it to a background
+// runner.
+func startBatchJob(input string, items []jobs.Item, base string) error {
+	store, err := jobStore()
+	if err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(expandHome(input))
+	if err != nil {
+		return err
+	}
+	job := &jobs.Job{Input: abs, Server: base, Parallel: batchParallel, Extract: extractMode, JQ: jqFilter, Total: len(items), Status: jobs.StatusRunning}
+	if err := store.Create(job); err != nil {
+		return err
+	}
+	if batchOutput != "" {
+		if job.Output, err = filepath.Abs(expandHome(batchOutput)); err != nil {
+			return err
+		}
+	} else {
+		job.Output = store.ResultsPath(job.ID)
+	}
+	if err := jobs.WriteItems(store.InputPath(job.ID), items); err != nil {
+		return err
+	}
+	if err := store.Save(job); err != nil {
+		return err
+	}
+	if err := spawnJobRunner(store, job); err != nil {
+		job.Status, job.Error, job.Finished = jobs.StatusFailed, err.Error(), time.Now().UTC()
+		store.Save(job)
+		return err
+	}
+
+	fmt.Println(styleOk.Render("✓"), "Started job", styleBold.Render(job.ID), styleDim.Render(fmt.Sprintf("(%d prompts)", len(items))))
+	fmt.Println(styleDim.Render("  Results:  " + job.Output))
+	fmt.Println(styleDim.Render("  Follow:   ellie jobs attach " + job.ID))
+	fmt.Println(styleDim.Render("  Cancel:   ellie jobs cancel " + job.ID))
+	return nil
+}
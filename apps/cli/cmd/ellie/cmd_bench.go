@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/bench"
+)
+
+var (
+	benchCold       bool
+	benchWarm       bool
+	benchIterations int
+	benchFilters    []string
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark the development pipeline",
+}
+
+var benchBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Compare cold-cache and warm-cache build times",
+	Long: `Run turbo run build repeatedly and report per-task timings, so changes
+to the build pipeline can be measured rather than guessed at.
+
+--cold clears turbo's local cache before every iteration. --warm primes
+the cache with one unmeasured build, then measures builds against it.
+Without either flag both are run and compared side by side. Timings come
+from turbo's run summaries (--summarize); --json prints every run and the
+aggregated statistics instead of a table.`,
+	Args: cobra.NoArgs,
+	RunE: runBenchBuild,
+}
+
+func init() {
+	benchBuildCmd.Flags().BoolVar(&benchCold, "cold", false, "Measure builds with an empty cache")
+	benchBuildCmd.Flags().BoolVar(&benchWarm, "warm", false, "Measure builds with a primed cache")
+	benchBuildCmd.Flags().IntVarP(&benchIterations, "iterations", "n", 3, "Measured builds per mode")
+	benchBuildCmd.Flags().StringArrayVar(&benchFilters, "filter", nil, "Only build matching packages (turbo --filter, repeatable)")
+}
+
+func runBenchBuild(cmd *cobra.Command, args []string) error {
+	if benchIterations < 1 {
+		return fmt.Errorf("--iterations must be at least 1")
+	}
+	root, err := findMonorepoRoot()
+	if err != nil {
+		return err
+	}
+	turboPath, err := findBin("turbo", root)
+	if err != nil {
+		return err
+	}
+
+	var modes []string
+	if benchCold || !benchWarm {
+		modes = append(modes, bench.Cold)
+	}
+	if benchWarm || !benchCold {
+		modes = append(modes, bench.Warm)
+	}
+
+	var runs []bench.Run
+	for _, mode := range modes {
+		if mode == bench.Warm {
+			fmt.Println(styleDim.Render("warm  priming the cache..."))
+			if _, err := benchBuildOnce(root, turboPath); err != nil {
+				return err
+			}
+		}
+		for i := 1; i <= benchIterations; i++ {
+			if mode == bench.Cold {
+				if err := clearTurboCache(root); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("%-5s %d/%d ", mode, i, benchIterations)
+			run, err := benchBuildOnce(root, turboPath)
+			if err != nil {
+				fmt.Println()
+				return err
+			}
+			run.Mode, run.Iteration = mode, i
+			fmt.Println(styleDim.Render(fmt.Sprintf("%s, %d tasks", bench.Format(run.Wall), len(run.Tasks))))
+			runs = append(runs, run)
+		}
+	}
+
+	report := bench.Summarize(runs)
+	if jsonFlag {
+		return printJSON(report)
+	}
+	fmt.Println()
+	return report.WriteTable(os.Stdout)
+}
+
+// benchBuildOnce runs one summarized turbo build and reads its timings.
+// Build output is kept in a temporary log that is shown only on failure.
+func benchBuildOnce(root, turboPath string) (bench.Run, error) {
+	logFile, err := os.CreateTemp("", "ellie-bench-*.log")
+	if err != nil {
+		return bench.Run{}, err
+	}
+	defer os.Remove(logFile.Name())
+	defer logFile.Close()
+
+	turboArgs := []string{"run", "build", "--summarize", "--output-logs=errors-only"}
+	for _, f := range benchFilters {
+		turboArgs = append(turboArgs, "--filter="+f)
+	}
+	c := exec.Command(turboPath, turboArgs...)
+	c.Dir = root
+	c.Stdout, c.Stderr = logFile, logFile
+
+	logCommand(c)
+	start := time.Now()
+	err = c.Run()
+	wall := time.Since(start)
+	if err != nil {
+		logFile.Seek(0, io.SeekStart)
+		out, _ := io.ReadAll(logFile)
+		fmt.Fprintln(os.Stderr, strings.TrimSpace(string(out)))
+		return bench.Run{}, fmt.Errorf("turbo run build failed: %w", err)
+	}
+
+	summary, err := latestTurboSummary(root, start)
+	if err != nil {
+		return bench.Run{}, err
+	}
+	defer os.Remove(summary)
+	f, err := os.Open(summary)
+	if err != nil {
+		return bench.Run{}, err
+	}
+	defer f.Close()
+	tasks, err := bench.ParseSummary(f)
+	if err != nil {
+		return bench.Run{}, fmt.Errorf("%s: %w", summary, err)
+	}
+	return bench.Run{Wall: wall, Tasks: tasks}, nil
+}
+
+// latestTurboSummary returns the newest run summary written since start.
+func latestTurboSummary(root string, start time.Time) (string, error) {
+	dir := filepath.Join(root, ".turbo", "runs")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("turbo wrote no run summary: %w", err)
+	}
+	var newest string
+	var newestTime time.Time
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.ModTime().Before(start.Add(-time.Second)) || info.ModTime().Before(newestTime) {
+			continue
+		}
+		newest, newestTime = filepath.Join(dir, e.Name()), info.ModTime()
+	}
+	if newest == "" {
+		return "", fmt.Errorf("turbo wrote no run summary to %s", dir)
+	}
+	return newest, nil
+}
+
+// clearTurboCache removes turbo's local cache: TURBO_CACHE_DIR, or the
+// default .turbo/cache, and the node_modules/.cache/turbo location older
+// turbo versions used.
+func clearTurboCache(root string) error {
+	dirs := []string{filepath.Join(root, "node_modules", ".cache", "turbo")}
+	if d := os.Getenv("TURBO_CACHE_DIR"); d != "" {
+		if !filepath.IsAbs(d) {
+			d = filepath.Join(root, d)
+		}
+		dirs = append(dirs, d)
+	} else {
+		dirs = append(dirs, filepath.Join(root, ".turbo", "cache"))
+	}
+	for _, d := range dirs {
+		if err := os.RemoveAll(d); err != nil {
+			return fmt.Errorf("cannot clear turbo cache: %w", err)
+		}
+	}
+	return nil
+}
@@ -2,23 +2,51 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	buildAndStart bool
+	buildKeep     int
+)
+
 var buildCmd = &cobra.Command{
 	Use:   "build",
 	Short: "Build the production release bundle",
 	RunE:  runBuild,
 }
 
+func init() {
+	buildCmd.Flags().BoolVar(&buildAndStart, "and-start", false, "Start the production server after a successful build")
+	buildCmd.Flags().IntVar(&buildKeep, "keep", 5, "Number of previous builds to keep in dist/releases for rollback (0 = keep all)")
+}
+
+// releaseArtifacts are the files ellie start needs, relative to dist/release.
+var releaseArtifacts = []string{"start.sh", "server.js", "web"}
+
 func runBuild(cmd *cobra.Command, args []string) error {
 	root, err := findMonorepoRoot()
 	if err != nil {
 		return err
 	}
+	if err := buildRelease(root); err != nil {
+		return err
+	}
 
+	if !buildAndStart {
+		fmt.Println(styleDim.Render("  Run it with: ellie start"))
+		return nil
+	}
+	fmt.Println()
+	return runStart(cmd, nil)
+}
+
+// buildRelease builds dist/release and snapshots it for rollback.
+func buildRelease(root string) error {
 	bunPath, err := findBin("bun", root)
 	if err != nil {
 		return err
@@ -26,11 +54,43 @@ func runBuild(cmd *cobra.Command, args []string) error {
 
 	script := filepath.Join(root, "scripts", "build-release.ts")
 
+	if err := applyTaskOutput(""); err != nil {
+		return err
+	}
+
 	fmt.Println(styleBold.Render("Building release bundle..."))
 	fmt.Println()
 
-	if exitCode := runProcess(bunPath, []string{"run", script}, root); exitCode != 0 {
+	exitCode := runProcess(bunPath, []string{"run", script}, root)
+	closeTaskOutput(exitCode)
+	if exitCode != 0 {
 		return exitCodeError(exitCode)
 	}
+
+	if err := verifyReleaseBuild(root); err != nil {
+		return err
+	}
+	meta, err := snapshotRelease(root, buildKeep)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	fmt.Println(styleOk.Render("✓"), "Release bundle ready at", styleBold.Render(filepath.Join("dist", "release")),
+		styleDim.Render("(build "+meta.ID+")"))
+	return nil
+}
+
+// verifyReleaseBuild checks that the build produced everything ellie start
+// needs, so a half-finished bundle fails here rather than at startup.
+func verifyReleaseBuild(root string) error {
+	var missing []string
+	for _, name := range releaseArtifacts {
+		if _, err := os.Stat(filepath.Join(root, "dist", "release", name)); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("build finished but dist/release is missing: %s", strings.Join(missing, ", "))
+	}
 	return nil
 }
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	tea "charm.land/bubbletea/v2"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	"ellie/apps/cli/internal/chatui"
 )
@@ -16,36 +18,141 @@ import (
 var chatCmd = &cobra.Command{
 	Use:   "chat",
 	Short: "Open interactive chat TUI",
-	RunE:  runChat,
+	Long: `Open the interactive chat TUI, or with --prompt send one prompt and
+print the answer.
+
+For scripts, --extract and --jq replace the printed answer with part of it:
+
+  --extract code         the first fenced code block
+  --extract code:python  the first python code block
+  --extract json         the first JSON object or array
+  --jq '.items[].id'     a jq-style filter over the extracted JSON
+
+Strings selected by --jq are printed without quotes, as with jq -r. When
+nothing can be extracted the command exits with status 1.
+
+--context sends files with the prompt: paths, directories or globs such
+as 'internal/**/*.go', included whole until --context-budget is spent.
+On large repositories add --context-index to send only the chunks most
+relevant to the prompt instead. Relevance comes from embeddings by the
+Text Embeddings Inference server at context.embed_url — by default the
+one ellie's server runs for memory — or, when none answers, from keyword
+matching. The local index behind it is cached and updated
+incrementally, so only changed files are read and embedded again:
+
+  ellie chat -P "why does token refresh fail?" --context . --context-index
+
+--attach sends files as attachments instead, such as screenshots for a
+vision model. Attached images, and images in the answer, are shown in
+the terminal when it supports kitty, iTerm2 or sixel graphics (see
+ui.images); elsewhere their dimensions are printed.
+
+  ellie chat -P "what is wrong with this layout?" --attach screenshot.png
+
+Input piped to ellie chat --prompt is sent along, up to --stdin-limit KiB,
+as with ellie ask.
+
+--model asks for a model instead of default_model, by id or provider/id
+as ellie models list shows them. --max-tokens caps the answer and
+--thinking asks for extended thinking; both are checked against the
+model's limits before the prompt is sent.`,
+	RunE: runChat,
 }
 
 var (
-	transcriptDir string
-	promptText    string
-	outputFormat  string
+	transcriptDir  string
+	promptText     string
+	outputFormat   string
+	continueAnswer bool
+	extractMode    string
+	jqFilter       string
+	contextPaths   []string
+	contextIndex   bool
+	contextBudget  int
+	attachPaths    []string
+	chatModel      string
+	maxTokens      int
+	thinking       bool
 )
 
 func init() {
 	chatCmd.Flags().StringVar(&transcriptDir, "transcript-dir", ".", "Directory to save transcripts")
 	chatCmd.Flags().StringVarP(&promptText, "prompt", "P", "", "One-shot prompt (skip TUI, print response, exit)")
 	chatCmd.Flags().StringVar(&outputFormat, "format", "markdown", "Output format for --prompt: text, markdown, json")
+	chatCmd.Flags().BoolVar(&continueAnswer, "continue", false, "With --prompt, keep requesting continuations while the answer hits the token limit")
+	chatCmd.Flags().StringVar(&extractMode, "extract", "", "With --prompt, print only the first code block (code, code:<lang>) or JSON value (json)")
+	chatCmd.Flags().StringVar(&jqFilter, "jq", "", "With --prompt, apply a jq-style filter to the JSON in the answer")
+	chatCmd.Flags().StringArrayVar(&contextPaths, "context", nil, "With --prompt, send these files, directories or globs along (repeatable)")
+	chatCmd.Flags().BoolVar(&contextIndex, "context-index", false, "Send only the chunks of the --context files most relevant to the prompt")
+	chatCmd.Flags().IntVar(&contextBudget, "context-budget", 8000, "Most tokens of context to send")
+	chatCmd.Flags().StringArrayVar(&attachPaths, "attach", nil, "With --prompt, attach this file, e.g. an image for a vision model (repeatable)")
+	chatCmd.Flags().IntVar(&stdinLimit, "stdin-limit", defaultStdinLimit, "With --prompt, most KiB of piped input to send")
+	chatCmd.Flags().StringVarP(&chatModel, "model", "m", "", "Model to answer with (default: the default_model setting)")
+	chatCmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "With --prompt, most tokens the answer may take (default: the server's)")
+	chatCmd.Flags().BoolVar(&thinking, "thinking", false, "With --prompt, ask the model to think before answering")
 }
 
 func runChat(cmd *cobra.Command, args []string) error {
+	if (extractMode != "" || jqFilter != "") && promptText == "" {
+		return fmt.Errorf("--extract and --jq need --prompt")
+	}
+	if (len(contextPaths) > 0 || contextIndex) && promptText == "" {
+		return fmt.Errorf("--context and --context-index need --prompt")
+	}
+	if len(attachPaths) > 0 && promptText == "" {
+		return fmt.Errorf("--attach needs --prompt")
+	}
+	if (cmd.Flags().Changed("max-tokens") || thinking) && promptText == "" {
+		return fmt.Errorf("--max-tokens and --thinking need --prompt")
+	}
+	if err := checkMaxTokens(); err != nil {
+		return err
+	}
+	if err := checkStdinLimit(); err != nil {
+		return err
+	}
+	var files []chatui.PendingAttachment
+	for _, path := range attachPaths {
+		f, err := chatui.AttachFile(expandHome(path))
+		if err != nil {
+			return fmt.Errorf("--attach: %w", err)
+		}
+		files = append(files, f)
+	}
+	post, err := newPostProcessor(extractMode, jqFilter)
+	if err != nil {
+		return err
+	}
+
 	base := requireBaseURL()
 
-	// Verify server is reachable
 	client := chatui.NewHTTPClient(base)
-	if _, err := client.GetStatus(context.Background()); err != nil {
-		fmt.Fprintln(os.Stderr, styleErr.Render("Cannot connect to server at "+base))
-		fmt.Fprintln(os.Stderr, styleDim.Render("Make sure the server is running (ellie dev or ellie start)"))
-		fmt.Fprintln(os.Stderr, styleDim.Render("Set ELLIE_API_URL if the server is at a different address"))
-		return errSilent
+	if err := requireChatServer(client, base); err != nil {
+		return err
 	}
 
 	// One-shot mode
 	if promptText != "" {
-		return runOneShot(client, base, promptText, outputFormat)
+		prompt, err := withContext(promptText)
+		if err != nil {
+			return err
+		}
+		if prompt, err = withStdin(prompt); err != nil {
+			return err
+		}
+		for _, f := range files {
+			if f.Category != "image" {
+				continue
+			}
+			if data, err := os.ReadFile(f.FilePath); err == nil {
+				showImage(os.Stderr, f.Name, data)
+			}
+		}
+		return runOneShot(client, base, prompt, outputFormat, post, files...)
+	}
+
+	if err := noPrompt("the chat", "pass --prompt to ask one question"); err != nil {
+		return err
 	}
 
 	// Resolve the current branch from the server
@@ -55,7 +162,17 @@ func runChat(cmd *cobra.Command, args []string) error {
 		return errSilent
 	}
 
-	model := chatui.NewModel(base, current.BranchID, transcriptDir)
+	return runChatTUI(base, current.BranchID)
+}
+
+// runChatTUI opens the chat on branchID until the user quits.
+func runChatTUI(base, branchID string) error {
+	requested, err := requestModel(chatui.NewHTTPClient(base), chatModel)
+	if err != nil {
+		return err
+	}
+	model := chatui.NewModel(base, branchID, transcriptDir)
+	model.SetRequestModel(requested)
 
 	p := tea.NewProgram(model)
 
@@ -72,45 +189,189 @@ func runChat(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runOneShot(client *chatui.HTTPClient, baseURL, prompt, format string) error {
-	switch format {
-	case "text", "markdown", "json":
-	default:
-		fmt.Fprintf(os.Stderr, "invalid format %q: must be text, markdown, or json\n", format)
+// requireChatServer checks that the server at base is reachable, saying
+// how to start it when not.
+func requireChatServer(client *chatui.HTTPClient, base string) error {
+	if _, err := client.GetStatus(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, styleErr.Render("Cannot connect to server at "+serverAddress(base)))
+		fmt.Fprintln(os.Stderr, styleDim.Render("Make sure the server is running (ellie dev or ellie start)"))
+		fmt.Fprintln(os.Stderr, styleDim.Render("Pass --base-url or set ELLIE_API_URL if the server is at a different address"))
 		return errSilent
 	}
+	return nil
+}
 
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer cancel()
+// checkMaxTokens checks --max-tokens.
+func checkMaxTokens() error {
+	if maxTokens < 0 {
+		return fmt.Errorf("--max-tokens can't be negative")
+	}
+	return nil
+}
 
+// runOneShot sends prompt on the current branch, with the model --model
+// asks for, and prints the answer in format.
+func runOneShot(client *chatui.HTTPClient, baseURL, prompt, format string, post *postProcessor, files ...chatui.PendingAttachment) error {
 	current, err := client.GetAssistantCurrent(context.Background())
 	if err != nil {
 		fmt.Fprintln(os.Stderr, styleErr.Render("Cannot resolve current branch: "+err.Error()))
 		return errSilent
 	}
-
-	cfg := chatui.OneShotConfig{
-		BaseURL:  baseURL,
-		BranchID: current.BranchID,
-		Format:   format,
+	model, err := requestModel(client, chatModel)
+	if err != nil {
+		return err
 	}
+	o := oneShot{
+		cfg: chatui.OneShotConfig{
+			BaseURL:   baseURL,
+			BranchID:  current.BranchID,
+			Format:    format,
+			Model:     model,
+			MaxTokens: maxTokens,
+			Thinking:  thinking,
+		},
+		post: post,
+	}
+	return o.run(client, prompt, files...)
+}
 
-	result, err := chatui.RunOneShot(ctx, cfg, prompt)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, styleErr.Render("Error:"), err)
+// oneShot is a prompt sent by ellie chat --prompt or ellie ask, and how
+// its answer is printed: in cfg.Format, or only the part post selects.
+type oneShot struct {
+	cfg  chatui.OneShotConfig
+	post *postProcessor
+	// stream prints a text answer as it is written instead of once
+	// complete.
+	stream bool
+}
+
+// run sends prompt, with --continue has a truncated answer finished, and
+// prints the answer. An answer that ends in an error fails the command.
+func (o oneShot) run(client *chatui.HTTPClient, prompt string, files ...chatui.PendingAttachment) error {
+	switch o.cfg.Format {
+	case "text", "markdown", "json":
+	default:
+		fmt.Fprintf(os.Stderr, "invalid format %q: must be text, markdown, or json\n", o.cfg.Format)
 		return errSilent
 	}
 
-	if result.Error != "" {
-		fmt.Fprintln(os.Stderr, styleErr.Render("Agent error:"), result.Error)
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// streamed ends with the last text printed, to end the answer on a
+	// newline.
+	var streamed string
+	cfg := o.cfg
+	if o.stream {
+		cfg.OnText = func(text string) {
+			fmt.Print(text)
+			streamed = text
+		}
+	}
+	endStream := func() {
+		if streamed != "" && !strings.HasSuffix(streamed, "\n") {
+			fmt.Println()
+		}
+		streamed = ""
 	}
 
-	output, err := chatui.FormatResult(result, format)
+	result, err := chatui.RunOneShot(ctx, cfg, prompt, files...)
+	endStream()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, styleErr.Render("Format error:"), err)
-		return errSilent
+		return apiError(err)
+	}
+
+	if continueAnswer && chatui.IsTruncated(result.StopReason) {
+		result, err = chatui.ContinueOneShot(ctx, cfg, result)
+		endStream()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, styleErr.Render("Error while continuing:"), err)
+		}
+	}
+
+	warnOtherModel(result, cfg.Model)
+	printAgentError(result)
+	if chatui.IsTruncated(result.StopReason) {
+		hint := "pass --continue to have it finished automatically"
+		if continueAnswer {
+			hint = fmt.Sprintf("still cut off after %d continuations", result.Continuations)
+		}
+		fmt.Fprintln(os.Stderr, styleDim.Render("Answer was cut off at the token limit — "+hint))
 	}
 
-	fmt.Println(output)
+	switch {
+	case o.post != nil:
+		output, err := o.post.apply(result.Content)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, styleErr.Render("Error:"), err)
+			return errSilent
+		}
+		fmt.Println(output)
+		return nil
+	case o.stream:
+	default:
+		output, err := chatui.FormatResult(result, cfg.Format)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, styleErr.Render("Format error:"), err)
+			return errSilent
+		}
+		if output != "" {
+			fmt.Println(output)
+		}
+	}
+
+	// Images go with the answer on a terminal; when it's piped they are
+	// only mentioned, on standard error, to keep the output clean.
+	if cfg.Format != "json" {
+		out := os.Stdout
+		if !term.IsTerminal(int(out.Fd())) {
+			out = os.Stderr
+		}
+		for _, img := range result.Images {
+			data, err := client.DownloadMedia(ctx, img.UploadID)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, styleDim.Render("Could not fetch image "+img.UploadID+": "+err.Error()))
+				continue
+			}
+			showImage(out, img.UploadID, data)
+		}
+	}
+	if result.Error != "" {
+		return errSilent
+	}
 	return nil
 }
+
+// warnOtherModel warns when a model other than the one asked for
+// answered. Either may be given as provider/id; only the ids are compared.
+func warnOtherModel(result *chatui.OneShotResult, model string) {
+	if model == "" || result.Model == nil {
+		return
+	}
+	if !strings.EqualFold(modelID(*result.Model), modelID(model)) {
+		warn(fmt.Sprintf("answered by %s, not %s: the server doesn't support choosing the model per prompt", *result.Model, model))
+	}
+}
+
+// modelID is the id of a model named as id or provider/id.
+func modelID(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// printAgentError reports the error a one-shot answer ended with, if
+// any, and whether the provider has an incident that explains it.
+func printAgentError(result *chatui.OneShotResult) {
+	if result.Error == "" {
+		return
+	}
+	fmt.Fprintln(os.Stderr, styleErr.Render("Agent error:"), result.Error)
+	var provider string
+	if result.Provider != nil {
+		provider = *result.Provider
+	}
+	if hint, incident := upstreamHint(provider); incident {
+		fmt.Fprintln(os.Stderr, styleErr.Render(hint))
+	} else if hint != "" {
+		fmt.Fprintln(os.Stderr, styleDim.Render(hint))
+	}
+}
@@ -14,7 +14,7 @@ var configSetFlag string
 
 var configCmd = &cobra.Command{
 	Use:   "config",
-	Short: "View or update channel configuration",
+	Short: "View or update settings and channel configuration",
 }
 
 var configWhatsAppCmd = &cobra.Command{
@@ -80,6 +80,10 @@ func runConfigWhatsApp(cmd *cobra.Command, args []string) error {
 	printSetting("History Limit", currentSettings["historyLimit"])
 	fmt.Println()
 
+	if ciMode() {
+		return nil
+	}
+
 	// Ask if they want to edit
 	var wantEdit bool
 	err = huh.NewConfirm().
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/config"
+)
+
+// ── config doctor ───────────────────────────────────────────────────────────
+
+var (
+	configDoctorFix    bool
+	configDoctorDryRun bool
+)
+
+var configDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Find deprecated settings and environment variables, and migrate them",
+	Long: `Look through every config file that applies here, and the environment,
+for deprecated settings and variables, and show what replaces each.
+
+Settings with a direct replacement can be moved over automatically:
+ellie offers to rewrite the files, keeping their comments and layout
+(--fix rewrites without asking). The synced team config is left alone —
+change it where the team keeps it — and a deprecated environment
+variable has to be renamed wherever it is set. Until then both keep
+working.
+
+` + configFilesHelp,
+	Args: cobra.NoArgs,
+	RunE: runConfigDoctor,
+}
+
+func init() {
+	configDoctorCmd.Flags().BoolVar(&configDoctorFix, "fix", false, "Rewrite the config files without asking")
+	configDoctorCmd.Flags().BoolVar(&configDoctorDryRun, "dry-run", false, "Only report, without offering to rewrite anything")
+	configDoctorCmd.MarkFlagsMutuallyExclusive("fix", "dry-run")
+}
+
+func runConfigDoctor(cmd *cobra.Command, args []string) error {
+	files, err := configFiles()
+	if err != nil {
+		return err
+	}
+
+	var found, fixable int
+	var toFix []configFile
+	for _, f := range files {
+		label := f.path + styleDim.Render(" ("+f.layer+")")
+		data, err := os.ReadFile(f.path)
+		var migrations []config.Migration
+		if err == nil {
+			migrations, err = config.Migrations(data)
+		}
+		if err != nil {
+			fmt.Println(styleErr.Render("✗"), label)
+			fmt.Println("  " + err.Error())
+			fmt.Println(styleDim.Render("  Fix it first — ellie config validate shows what's wrong."))
+			continue
+		}
+		if len(migrations) == 0 {
+			fmt.Println(styleOk.Render("✓"), label)
+			continue
+		}
+
+		fmt.Println(styleErr.Render("!"), label)
+		auto := 0
+		for _, m := range migrations {
+			if to := m.To(); to != "" {
+				fmt.Printf("  line %d: %s → %s\n", m.Line, m.Key, styleBold.Render(to))
+				auto++
+			} else {
+				fmt.Printf("  line %d: %s %s\n", m.Line, m.Key, styleDim.Render("— change by hand"))
+			}
+			fmt.Println(styleDim.Render("    " + m.Note))
+		}
+		found += len(migrations)
+		if auto == 0 {
+			continue
+		}
+		if f.layer == "team" {
+			fmt.Println(styleDim.Render("  Synced from the team config — migrate it there, then run ellie config sync."))
+			continue
+		}
+		toFix = append(toFix, f)
+		fixable += auto
+	}
+
+	if deps := config.EnvDeprecations(os.Getenv); len(deps) > 0 {
+		fmt.Println(styleErr.Render("!"), "environment")
+		for _, d := range deps {
+			fmt.Printf("  %s → %s\n", d.Var, styleBold.Render(d.Replacement))
+			fmt.Println(styleDim.Render(fmt.Sprintf("    sets %s; rename it where it is set (shell profile, .env, CI)", d.Key)))
+		}
+		found += len(deps)
+	} else {
+		fmt.Println(styleOk.Render("✓"), "environment")
+	}
+
+	fmt.Println()
+	switch {
+	case found == 0:
+		fmt.Println(styleOk.Render("✓"), "No deprecated settings in use.")
+		return nil
+	case fixable == 0:
+		fmt.Println(styleDim.Render(fmt.Sprintf("%d deprecated setting(s) — still working, but none ellie can rewrite here", found)))
+		return nil
+	case configDoctorDryRun:
+		fmt.Println(styleDim.Render(fmt.Sprintf("%d deprecated setting(s); %d can be migrated with ellie config doctor --fix", found, fixable)))
+		return nil
+	}
+
+	if !configDoctorFix {
+		if err := noPrompt("confirming the rewrite", "pass --fix, or --dry-run to only report"); err != nil {
+			return err
+		}
+		var confirm bool
+		err := huh.NewConfirm().
+			Title(fmt.Sprintf("Migrate %d setting(s) in %d file(s)?", fixable, len(toFix))).
+			Affirmative("Rewrite").
+			Negative("Cancel").
+			Value(&confirm).
+			Run()
+		if err != nil || !confirm {
+			fmt.Println("Cancelled.")
+			return errSilent
+		}
+	}
+
+	for _, f := range toFix {
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			return err
+		}
+		out, done, err := config.Migrate(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.path, err)
+		}
+		if err := config.WriteFile(f.path, out); err != nil {
+			return err
+		}
+		fmt.Println(styleOk.Render("✓"), "Updated", f.path, styleDim.Render(fmt.Sprintf("(%d setting(s))", len(done))))
+	}
+	return nil
+}
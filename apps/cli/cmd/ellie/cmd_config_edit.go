@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/config"
+)
+
+// ── config edit ─────────────────────────────────────────────────────────────
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit your config file in $EDITOR",
+	Long: `Open your config file — or with --project the project's .ellierc or
+ellie.toml — in $VISUAL or $EDITOR. The file is checked when the editor
+exits: syntax errors, unknown settings and bad values are listed with
+their line numbers, and you can fix them or discard the edit. The file is
+only replaced once it is valid.
+
+` + configFilesHelp,
+	Args: cobra.NoArgs,
+	RunE: runConfigEdit,
+}
+
+func init() {
+	configEditCmd.Flags().BoolVar(&configProject, "project", false, "Edit the project's .ellierc or ellie.toml instead of your own config")
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	if err := noPrompt("ellie config edit", "use ellie config set"); err != nil {
+		return err
+	}
+	path, _, err := configTarget()
+	if err != nil {
+		return err
+	}
+	original, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// Edit a copy, so a half-finished file never takes effect.
+	tmp, err := os.CreateTemp("", "ellie-*.toml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(original)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err := runEditor(tmp.Name()); err != nil {
+			return err
+		}
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Println(styleDim.Render("No changes to " + path))
+			return nil
+		}
+
+		problems, err := config.Validate(edited)
+		if err == nil && len(config.Errors(problems)) == 0 {
+			return saveEditedConfig(path, original, edited)
+		}
+		fmt.Println()
+		fmt.Println(styleErr.Render("✗"), path, "has problems:")
+		if err != nil {
+			fmt.Println("  " + err.Error())
+		}
+		for _, p := range problems {
+			fmt.Println("  " + p.Error())
+		}
+		fmt.Println()
+
+		again := true
+		err = huh.NewConfirm().
+			Title("Edit again?").
+			Affirmative("Edit again").
+			Negative("Discard changes").
+			Value(&again).
+			Run()
+		if err != nil || !again {
+			fmt.Println(styleDim.Render("Discarded — " + path + " is unchanged."))
+			return errSilent
+		}
+	}
+}
+
+func saveEditedConfig(path string, original, edited []byte) error {
+	if err := config.WriteFile(path, edited); err != nil {
+		return err
+	}
+	old, _ := config.Parse(original)
+	values, _ := config.Parse(edited)
+	changes := config.Diff(old, values)
+	fmt.Println(styleOk.Render("✓"), "Saved", path)
+	printConfigChanges(os.Stdout, changes)
+	return nil
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, falling back to vi
+// (notepad on Windows). The variable may include arguments, as in
+// "code --wait".
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], path)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	logCommand(c)
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", fields[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/config"
+)
+
+// ── config files ────────────────────────────────────────────────────────────
+
+var (
+	configProject    bool
+	configListOrigin bool
+)
+
+const configFilesHelp = `Settings come from these files, each overriding the one before:
+
+  team      synced with 'ellie config sync'
+  user      ellie.toml in your config directory — ~/.config/ellie, or
+            ~/Library/Application Support/ellie on macOS — or ELLIE_CONFIG
+  project   ellie.toml in the repository — the nearest one in the current
+            directory or its parents; a [tool.ellie] table in it, if any,
+            holds the settings
+  ellierc   .ellierc next to it, for overrides kept out of the shared file
+
+Environment variables such as ELLIE_API_URL override them all.
+
+Values may refer to environment variables, so one shared file can serve
+several people: ${VAR} is replaced by VAR, which must be set, and
+${VAR:-default} falls back to default when VAR is unset or empty. $$ is
+a literal $.
+
+  [server]
+  url = "http://${ELLIE_HOST:-localhost}:3000"`
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a setting",
+	Long:  "Print a setting's value, exiting 1 if it is not set.\n\n" + configFilesHelp,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting in your config file",
+	Long: `Change a setting in your user config file, or with --project in the
+project's. Lists are comma-separated:
+
+  ellie config set server.url https://ellie.example.com
+  ellie config set timeouts.auth 1m
+  ellie config set --project dev.filters '!cli,!docs'
+
+` + configFilesHelp,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting from your config file",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List settings",
+	Long:  "List every known setting and its value; --origin adds the file or variable\neach comes from.\n\n" + configFilesHelp,
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{configSetCmd, configUnsetCmd} {
+		c.Flags().BoolVar(&configProject, "project", false, "Write the project's .ellierc or ellie.toml instead of your own config")
+	}
+	configListCmd.Flags().BoolVar(&configListOrigin, "origin", false, "Show where each setting comes from")
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	s, ok := settings().Get(args[0])
+	if !ok {
+		if _, known := config.Lookup(args[0]); !known {
+			return fmt.Errorf("unknown setting %s — see 'ellie config list'", args[0])
+		}
+		return errSilent
+	}
+	fmt.Println(config.FormatValue(s.Value))
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, ok := config.Lookup(args[0])
+	if !ok {
+		return fmt.Errorf("unknown setting %s — see 'ellie config list'", args[0])
+	}
+	// A value with ${VAR} references is stored as written and checked
+	// once expanded, when it's loaded.
+	var v any = args[1]
+	var err error
+	if strings.Contains(args[1], "${") {
+		if _, err := config.Expand(args[1], os.Getenv); err != nil && !errors.Is(err, config.ErrUnsetVariable) {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+	} else if v, err = key.Parse(args[1]); err != nil {
+		return err
+	}
+	path, prefix, err := configTarget()
+	if err != nil {
+		return err
+	}
+	if err := config.SetFile(path, prefix+args[0], v); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), args[0], "=", config.FormatValue(v), styleDim.Render("in "+path))
+	// settings() was loaded before the write, so only what outranks the
+	// file we wrote can still be in the way.
+	if s, _ := settings().Get(args[0]); strings.HasPrefix(s.Origin, "env ") || layerRank(s.Path) > layerRank(path) {
+		from := s.Origin
+		if s.Path != "" {
+			from += " config " + s.Path
+		}
+		fmt.Println(styleDim.Render(fmt.Sprintf("  %s from %s still takes precedence", config.FormatValue(s.Value), from)))
+	}
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	path, prefix, err := configTarget()
+	if err != nil {
+		return err
+	}
+	ok, err := config.UnsetFile(path, prefix+args[0])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println(styleDim.Render(args[0] + " is not set in " + path))
+		return nil
+	}
+	fmt.Println(styleOk.Render("✓"), "Removed", args[0], styleDim.Render("from "+path))
+	return nil
+}
+
+// configTarget is the file set and unset change, and the table prefix
+// its settings live under: the user's config, or with --project the
+// project's .ellierc or ellie.toml, else a new ellie.toml in the current
+// directory.
+func configTarget() (path, prefix string, err error) {
+	if !configProject {
+		path, err = config.UserPath()
+		return path, "", err
+	}
+	for _, name := range []string{"ellierc", "project"} {
+		if l := settings().Layer(name); l != nil {
+			return l.Path, l.Prefix, nil
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(cwd, config.FileName), "", nil
+}
+
+// layerRank orders config files by precedence; a file not loaded yet is
+// new and so ranks above the rest.
+func layerRank(path string) int {
+	if path == "" {
+		return -1
+	}
+	layers := settings().Layers
+	for i, l := range layers {
+		if l.Path == path {
+			return i
+		}
+	}
+	return len(layers)
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	cfg := settings()
+	keys := cfg.Keys()
+
+	if jsonFlag {
+		type entry struct {
+			Key    string `json:"key"`
+			Value  any    `json:"value"`
+			Origin string `json:"origin,omitempty"`
+			Path   string `json:"path,omitempty"`
+		}
+		out := []entry{}
+		for _, k := range keys {
+			s, _ := cfg.Get(k)
+			out = append(out, entry{Key: k, Value: s.Value, Origin: s.Origin, Path: s.Path})
+		}
+		return printJSON(out)
+	}
+
+	fmt.Println()
+	fmt.Println(styleBold.Render("Settings"))
+	fmt.Println(strings.Repeat("─", 40))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, k := range keys {
+		s, ok := cfg.Get(k)
+		value := "(unset)"
+		if ok {
+			value = config.FormatValue(s.Value)
+		}
+		if !configListOrigin {
+			fmt.Fprintf(tw, "%s\t%s\n", k, value)
+			continue
+		}
+		origin := s.Origin
+		if s.Path != "" {
+			origin += " " + s.Path
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", k, value, styleDim.Render(origin))
+	}
+	tw.Flush()
+	if configListOrigin {
+		fmt.Println()
+		fmt.Println(styleDim.Render("Precedence: env > ellierc > project > user > team > default"))
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/config"
+)
+
+// ── team config sync ────────────────────────────────────────────────────────
+
+var (
+	configSyncRef   string
+	configSyncPath  string
+	configSyncCheck bool
+)
+
+var configSyncCmd = &cobra.Command{
+	Use:   "sync [git-url|server]",
+	Short: "Pull the team-shared base config",
+	Long: `Pull a team-shared base config — models, server URL and other defaults
+everyone on the team should start from — from a git repository or from
+the ellie server, and keep it beneath personal settings:
+
+  ellie config sync git@github.com:acme/ellie-config.git
+  ellie config sync https://github.com/acme/platform.git --ref main --path tools/ellie.toml
+  ellie config sync server
+
+The source is remembered, so later runs need no arguments; ELLIE_TEAM_CONFIG
+gives a source when none has been synced yet. Each sync lists the settings
+that changed upstream, and --check reports whether the upstream config has
+moved on since the last sync without applying it, exiting 1 if it has.
+
+Personal settings and environment variables (e.g. ELLIE_API_URL) still
+win over the team's; sync points out where they do.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigSync,
+}
+
+func init() {
+	configSyncCmd.Flags().StringVar(&configSyncRef, "ref", "", "Branch or tag to read (default: the repository's default branch)")
+	configSyncCmd.Flags().StringVar(&configSyncPath, "path", config.DefaultTeamPath, "Path of the config file in the repository")
+	configSyncCmd.Flags().BoolVar(&configSyncCheck, "check", false, "Only report whether the team config changed upstream")
+}
+
+func runConfigSync(cmd *cobra.Command, args []string) error {
+	dir, err := teamConfigDir()
+	if err != nil {
+		return err
+	}
+	current, st, err := config.LoadTeam(dir)
+	if err != nil && st == nil && !errors.Is(err, config.ErrNoTeamConfig) {
+		return err
+	}
+	if current == nil {
+		current = config.Values{}
+	}
+
+	var src config.TeamSource
+	switch {
+	case len(args) == 1:
+		src = config.TeamSource{URL: args[0]}
+	case st != nil:
+		src = st.Source
+	case os.Getenv("ELLIE_TEAM_CONFIG") != "":
+		src = config.TeamSource{URL: os.Getenv("ELLIE_TEAM_CONFIG")}
+	default:
+		return fmt.Errorf("no team config source — pass a git URL or 'server', or set ELLIE_TEAM_CONFIG")
+	}
+	if cmd.Flags().Changed("ref") || len(args) == 1 {
+		src.Ref = configSyncRef
+	}
+	if cmd.Flags().Changed("path") || len(args) == 1 {
+		src.Path = configSyncPath
+	}
+	if src.IsServer() && (src.Ref != "" || (src.Path != "" && src.Path != config.DefaultTeamPath)) {
+		return fmt.Errorf("--ref and --path only apply to git sources")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if configSyncCheck {
+		return checkTeamConfig(ctx, src, st)
+	}
+
+	fmt.Fprintln(os.Stderr, styleDim.Render("Fetching team config from "+src.String()+"..."))
+	data, revision, err := fetchTeamConfig(ctx, src)
+	if err != nil {
+		return err
+	}
+	values, err := config.Parse(data)
+	if err != nil {
+		return fmt.Errorf("team config from %s: %w", src, err)
+	}
+	if err := config.SaveTeam(dir, data, config.TeamState{Source: src, Revision: revision, SyncedAt: time.Now().UTC()}); err != nil {
+		return fmt.Errorf("cannot save team config: %w", err)
+	}
+
+	changes := config.Diff(current, values)
+	switch {
+	case st != nil && st.Revision == revision:
+		fmt.Println(styleOk.Render("✓"), "Team config is up to date", styleDim.Render("("+shortRevision(revision)+")"))
+	case len(changes) == 0:
+		fmt.Println(styleOk.Render("✓"), "Synced team config from", src.String(), styleDim.Render("("+shortRevision(revision)+", no setting changed)"))
+	default:
+		fmt.Println(styleOk.Render("✓"), "Synced team config from", src.String(), styleDim.Render("("+shortRevision(revision)+")"))
+		printConfigChanges(os.Stdout, changes)
+	}
+	printTeamOverrides(values)
+	return nil
+}
+
+// fetchTeamConfig downloads the team config and identifies its version.
+func fetchTeamConfig(ctx context.Context, src config.TeamSource) ([]byte, string, error) {
+	if !src.IsServer() {
+		return config.FetchGit(ctx, src)
+	}
+	resp, err := httpClient.Get(baseURL() + "/api/config/team")
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == 404 {
+		return nil, "", fmt.Errorf("the server at %s does not serve a team config", baseURL())
+	}
+	if resp.StatusCode != 200 {
+		return nil, "", serverError(resp)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid response: %w", err)
+	}
+	return data, config.ContentRevision(data), nil
+}
+
+// checkTeamConfig compares the synced revision with the upstream one.
+func checkTeamConfig(ctx context.Context, src config.TeamSource, st *config.TeamState) error {
+	var upstream string
+	var err error
+	if src.IsServer() {
+		_, upstream, err = fetchTeamConfig(ctx, src)
+	} else {
+		upstream, err = config.RemoteRevision(ctx, src)
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case st == nil:
+		fmt.Println(styleErr.Render("✗"), "No team config synced yet — run 'ellie config sync'")
+		return errSilent
+	case st.Source != src:
+		fmt.Println(styleErr.Render("✗"), "The team config was synced from", st.Source.String(), "— run 'ellie config sync' to switch")
+		return errSilent
+	case st.Revision != upstream:
+		fmt.Println(styleErr.Render("✗"), "The team config changed upstream",
+			styleDim.Render("("+shortRevision(st.Revision)+" → "+shortRevision(upstream)+")"), "— run 'ellie config sync'")
+		return errSilent
+	}
+	fmt.Println(styleOk.Render("✓"), "Team config is up to date", styleDim.Render("(synced "+formatDuration(time.Since(st.SyncedAt))+")"))
+	return nil
+}
+
+func printConfigChanges(w io.Writer, changes []config.Change) {
+	for _, c := range changes {
+		switch {
+		case c.Old == nil:
+			fmt.Fprintf(w, "  %s %s = %s\n", styleOk.Render("+"), c.Key, config.FormatValue(c.New))
+		case c.New == nil:
+			fmt.Fprintf(w, "  %s %s\n", styleErr.Render("-"), c.Key)
+		default:
+			fmt.Fprintf(w, "  ~ %s: %s → %s\n", c.Key, config.FormatValue(c.Old), config.FormatValue(c.New))
+		}
+	}
+}
+
+// printTeamOverrides points out team settings that personal config or
+// the environment override, since those silently drift from the team's.
+func printTeamOverrides(team config.Values) {
+	cfg := config.Config{Layers: []config.Layer{{Name: "team", Values: team}}}
+	for _, l := range settings().Layers {
+		if l.Name != "team" {
+			cfg.Layers = append(cfg.Layers, l)
+		}
+	}
+	for _, key := range team.Keys() {
+		s, _ := cfg.Get(key)
+		if s.Origin == "team" {
+			continue
+		}
+		from := s.Origin
+		if s.Path != "" {
+			from += " config " + s.Path
+		}
+		fmt.Println(styleDim.Render(fmt.Sprintf("  %s = %s from %s overrides the team's %s",
+			key, config.FormatValue(s.Value), from, config.FormatValue(team[key]))))
+	}
+}
+
+func shortRevision(rev string) string {
+	if len(rev) > 12 {
+		return rev[:12]
+	}
+	return rev
+}
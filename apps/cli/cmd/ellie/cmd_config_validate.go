@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/config"
+)
+
+// ── config validate ─────────────────────────────────────────────────────────
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]...",
+	Short: "Check config files against the settings schema",
+	Long: `Check every config file that applies here — or the files given — for
+syntax errors, unknown settings and values of the wrong type, and the
+environment variables that override settings for bad values. Deprecated
+settings are reported as warnings.
+
+Exits with an error when anything is wrong, so a project can gate its
+shared ellie.toml in CI; add --strict to fail on deprecations too:
+
+  ellie config validate --strict
+
+` + configFilesHelp,
+	RunE: runConfigValidate,
+}
+
+// configFile is a config file to check and the layer it belongs to.
+type configFile struct {
+	layer, path string
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	var files []configFile
+	for _, a := range args {
+		files = append(files, configFile{"", expandHome(a)})
+	}
+	if len(args) == 0 {
+		var err error
+		if files, err = configFiles(); err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			fmt.Println(styleDim.Render("No config files here — nothing to check."))
+		}
+	}
+
+	var errCount, deprecated int
+	for _, f := range files {
+		label := f.path
+		if f.layer != "" {
+			label += styleDim.Render(" (" + f.layer + ")")
+		}
+		data, err := os.ReadFile(f.path)
+		var problems []config.Problem
+		if err == nil {
+			problems, err = config.Validate(data)
+		}
+		if err != nil {
+			fmt.Println(styleErr.Render("✗"), label)
+			fmt.Println("  " + err.Error())
+			errCount++
+			continue
+		}
+		errs := config.Errors(problems)
+		if len(errs) > 0 {
+			fmt.Println(styleErr.Render("✗"), label)
+			errCount += len(errs)
+		} else {
+			fmt.Println(styleOk.Render("✓"), label)
+		}
+		for _, p := range problems {
+			if p.Deprecated {
+				fmt.Println(styleDim.Render(fmt.Sprintf("  line %d: warning: %s", p.Line, p.Msg)))
+				deprecated++
+				warnings.Add(1)
+				continue
+			}
+			fmt.Println("  " + p.Error())
+		}
+	}
+
+	envErrs := config.EnvProblems(os.Getenv)
+	if _, _, err := selectedEnv(); err != nil {
+		envErrs = append(envErrs, err)
+	}
+	if len(envErrs) > 0 {
+		fmt.Println(styleErr.Render("✗"), "environment")
+		for _, err := range envErrs {
+			fmt.Println("  " + err.Error())
+		}
+		errCount += len(envErrs)
+	}
+
+	if errCount > 0 {
+		fmt.Println()
+		fmt.Println(styleErr.Render(fmt.Sprintf("%d problem(s) found", errCount)))
+		return errSilent
+	}
+	if deprecated > 0 {
+		fmt.Println()
+		fmt.Println(styleDim.Render(fmt.Sprintf("%d deprecated setting(s) — still working, but worth replacing (see ellie config doctor)", deprecated)))
+	}
+	return nil
+}
+
+// configFiles lists the config files that exist, lowest precedence
+// first, as config.Load reads them.
+func configFiles() ([]configFile, error) {
+	var files []configFile
+	add := func(layer, path string) {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			files = append(files, configFile{layer, path})
+		}
+	}
+	if dir, err := teamConfigDir(); err == nil {
+		add("team", filepath.Join(dir, config.FileName))
+	}
+	user, err := config.UserPath()
+	if err != nil {
+		return nil, err
+	}
+	add("user", user)
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range config.FindProject(cwd) {
+		if p == user {
+			continue
+		}
+		layer := "project"
+		if filepath.Base(p) == config.RCFileName {
+			layer = "ellierc"
+		}
+		add(layer, p)
+	}
+	return files, nil
+}
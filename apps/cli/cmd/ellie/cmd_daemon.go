@@ -0,0 +1,467 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/daemon"
+	"ellie/apps/cli/internal/paths"
+)
+
+// ── background daemon ───────────────────────────────────────────────────────
+
+var (
+	daemonStartTimeout time.Duration
+	daemonStopTimeout  time.Duration
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run the production server under a supervisor in the background",
+	Long: `Run the production server from the last ellie build under a background
+supervisor. The supervisor records its pid, restarts the server when it
+crashes — waiting 1s, then 2s, 4s… up to a minute between attempts — and
+answers on a control socket in the state directory, which ellie status,
+ellie logs, ellie restart and ellie stop use when a daemon is running.
+
+A server that exits cleanly is not restarted; the daemon stops with it.
+So does one in a crash loop — by default 5 crashes within 2 minutes, set
+with crash_loop.failures and crash_loop.window — after writing its last
+error output to the log.`,
+}
+
+var daemonStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the supervised production server in the background",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStart,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the daemon and its server",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStop,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the daemon's server, restarts and last exit code",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+// daemonRunCmd is the supervisor itself, started by ellie daemon start.
+var daemonRunCmd = &cobra.Command{
+	Use:    "run",
+	Short:  "Supervise the production server in the foreground",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runDaemonSupervisor,
+}
+
+func init() {
+	daemonStartCmd.Flags().DurationVar(&daemonStartTimeout, "wait-timeout", 60*time.Second, "How long to wait for the server to become healthy")
+	daemonStopCmd.Flags().DurationVar(&daemonStopTimeout, "wait-timeout", 30*time.Second, "How long to wait for the daemon to shut down before killing it")
+}
+
+// daemonSocket returns the path of the supervisor's control socket.
+func daemonSocket() (string, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "run", "daemon.sock"), nil
+}
+
+// callDaemon sends op to the running daemon. It returns
+// daemon.ErrNotRunning when there is none.
+func callDaemon(op string) (*daemon.Status, error) {
+	socket, err := daemonSocket()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	resp, err := daemon.Call(ctx, socket, daemon.Request{Op: op})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// daemonStatus returns the running daemon's status, or nil.
+func daemonStatus() *daemon.Status {
+	st, err := callDaemon(daemon.OpStatus)
+	if err != nil {
+		return nil
+	}
+	return st
+}
+
+func runDaemonStart(cmd *cobra.Command, args []string) error {
+	root, err := findMonorepoRoot()
+	if err != nil {
+		return err
+	}
+	if _, err := releaseStartScript(root); err != nil {
+		return err
+	}
+	if st := daemonStatus(); st != nil {
+		return fmt.Errorf("the daemon is already running (pid %d) — see ellie daemon status", st.PID)
+	}
+	if pid, _, ok := runningProcess("start"); ok {
+		return fmt.Errorf("production server is already running (pid %d) — stop it with ellie stop first", pid)
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate ellie executable: %w", err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+
+	child := exec.Command(self, "daemon", "run")
+	child.Dir = root
+	child.Stdin, child.Stdout, child.Stderr = devNull, devNull, devNull
+	child.SysProcAttr = detachAttr()
+	logCommand(child)
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("cannot start the daemon: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- child.Wait() }()
+	healthy := make(chan bool, 1)
+	base := baseURL()
+	go func() { healthy <- waitHealthy(base, daemonStartTimeout) }()
+
+	fmt.Println(styleDim.Render(fmt.Sprintf("Starting the daemon (pid %d)...", child.Process.Pid)))
+	select {
+	case err := <-exited:
+		fmt.Fprintln(os.Stderr, styleErr.Render("✗"), "Daemon exited during startup:", err)
+		fmt.Fprintln(os.Stderr, styleDim.Render("  See ellie logs --source start"))
+		return errSilent
+	case ok := <-healthy:
+		if !ok {
+			msg := fmt.Sprintf("Server did not become healthy within %s", daemonStartTimeout)
+			if st := daemonStatus(); st != nil && st.LastExit != nil {
+				msg += fmt.Sprintf(" (it exited with code %d; the daemon keeps retrying)", *st.LastExit)
+			}
+			fmt.Fprintln(os.Stderr, styleErr.Render("✗"), msg)
+			fmt.Fprintln(os.Stderr, styleDim.Render("  See ellie logs --source start, or stop it with ellie daemon stop"))
+			return errSilent
+		}
+	}
+
+	fmt.Println(styleOk.Render("✓"), "Production server running at", styleBold.Render(base), styleDim.Render(fmt.Sprintf("(daemon pid %d)", child.Process.Pid)))
+	fmt.Println(styleDim.Render("  Check on it with:   ellie daemon status"))
+	fmt.Println(styleDim.Render("  Follow output with: ellie logs -f"))
+	fmt.Println(styleDim.Render("  Stop it with:       ellie daemon stop"))
+	return nil
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) error {
+	st := daemonStatus()
+	if st == nil {
+		// A daemon whose socket is gone can still be stopped by pid.
+		pid, _, ok := runningProcess("daemon")
+		if !ok {
+			fmt.Println(styleDim.Render("Daemon is not running."))
+			return nil
+		}
+		st = &daemon.Status{PID: pid}
+	}
+	fmt.Println(styleDim.Render(fmt.Sprintf("Stopping the daemon (pid %d)...", st.PID)))
+	if err := stopDaemon(st.PID, daemonStopTimeout); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Stopped")
+	return nil
+}
+
+// stopDaemon asks the daemon with pid to stop its server and exit,
+// killing it if it hasn't within timeout.
+func stopDaemon(pid int, timeout time.Duration) error {
+	if _, err := callDaemon(daemon.OpStop); err != nil {
+		return stopProcess(pid, timeout)
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if !processAlive(pid) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return stopProcess(pid, 5*time.Second)
+}
+
+// daemonStatusReport is the --json output of ellie daemon status.
+type daemonStatusReport struct {
+	Running bool `json:"running"`
+	*daemon.Status
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	st := daemonStatus()
+	if jsonFlag {
+		if err := printJSON(daemonStatusReport{Running: st != nil, Status: st}); err != nil {
+			return err
+		}
+	}
+	if st == nil {
+		fmt.Println(styleDim.Render("Daemon is not running (start it with ellie daemon start)."))
+		return errSilent
+	}
+
+	fmt.Println()
+	fmt.Println(styleBold.Render("Daemon"))
+	fmt.Println(strings.Repeat("─", 40))
+	fmt.Printf("  %-10s %s %s\n", "Daemon", styleOk.Render(st.State),
+		styleDim.Render(fmt.Sprintf("(pid %d, up %s)", st.PID, formatUptime(time.Since(st.Since)))))
+	switch {
+	case st.ServerPID != 0:
+		fmt.Printf("  %-10s %s\n", "Server", fmt.Sprintf("pid %d, up %s", st.ServerPID, formatUptime(time.Since(st.ServerSince))))
+	case st.State == daemon.StateBackoff:
+		fmt.Printf("  %-10s %s\n", "Server", styleErr.Render(fmt.Sprintf("down — next start in %s", time.Until(st.NextStart).Round(time.Second))))
+	}
+	restarts := fmt.Sprint(st.Restarts)
+	if st.LastExit != nil {
+		restarts += styleDim.Render(fmt.Sprintf(" (last exit code %d)", *st.LastExit))
+	}
+	fmt.Printf("  %-10s %s\n", "Restarts", restarts)
+	fmt.Printf("  %-10s %s\n", "Log", styleDim.Render(st.Log))
+	fmt.Println()
+	return nil
+}
+
+// supervisor runs the production server for ellie daemon, restarting it
+// when it crashes.
+type supervisor struct {
+	root, script string
+	log          *logStore
+	// stderr keeps the last lines the current server run wrote to
+	// stderr, for a crash loop report.
+	stderr *outputTail
+
+	mu     sync.Mutex
+	status daemon.Status
+
+	restart  chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func runDaemonSupervisor(cmd *cobra.Command, args []string) error {
+	root, err := findMonorepoRoot()
+	if err != nil {
+		return err
+	}
+	script, err := releaseStartScript(root)
+	if err != nil {
+		return err
+	}
+	if pid, _, ok := runningProcess("daemon"); ok {
+		return fmt.Errorf("the daemon is already running (pid %d)", pid)
+	}
+
+	log, err := openLogStore("start", "server")
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+	logFile, _ := logPath("start")
+
+	removePid, err := writePidfile("daemon", os.Getpid())
+	if err != nil {
+		return err
+	}
+	defer removePid()
+
+	socket, err := daemonSocket()
+	if err != nil {
+		return err
+	}
+	// Only a crashed daemon leaves its socket behind; we checked above
+	// that it is gone.
+	_ = os.Remove(socket)
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("cannot open the control socket: %w", err)
+	}
+	defer os.Remove(socket)
+	defer ln.Close()
+
+	s := &supervisor{
+		root: root, script: script, log: log, stderr: newOutputTail(crashTailLines),
+		status:  daemon.Status{PID: os.Getpid(), Since: time.Now(), Log: logFile},
+		restart: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+	go daemon.Serve(ln, s.handle)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		<-sigCh
+		s.requestStop()
+	}()
+
+	if code := s.run(); code != 0 {
+		return exitCodeError(code)
+	}
+	return nil
+}
+
+func (s *supervisor) handle(req daemon.Request) daemon.Response {
+	switch req.Op {
+	case daemon.OpStatus:
+	case daemon.OpRestart:
+		select {
+		case s.restart <- struct{}{}:
+		default:
+		}
+	case daemon.OpStop:
+		s.requestStop()
+	default:
+		return daemon.Response{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+	}
+	s.mu.Lock()
+	st := s.status
+	s.mu.Unlock()
+	return daemon.Response{Status: &st}
+}
+
+func (s *supervisor) requestStop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// notice records a line from the supervisor in the server's log.
+func (s *supervisor) notice(msg string) {
+	s.log.writeLine("daemon: " + msg)
+}
+
+// run starts the server and keeps it running until asked to stop, or it
+// is in a crash loop. It returns the daemon's exit code.
+func (s *supervisor) run() int {
+	backoff := daemon.Backoff{Min: time.Second, Max: time.Minute, Reset: time.Minute}
+	loop := crashLoop()
+	for {
+		server, exited, err := s.startServer()
+		if err != nil {
+			s.notice("Cannot start the server: " + err.Error())
+			return 1
+		}
+		started := time.Now()
+
+		select {
+		case err = <-exited:
+		case <-s.restart:
+			s.notice("Restarting the server on request...")
+			stopChild(server, exited)
+			backoff.Clear()
+			continue
+		case <-s.stop:
+			s.setState(daemon.StateStopping)
+			s.notice("Stopping the server...")
+			stopChild(server, exited)
+			return 0
+		}
+
+		code := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			code = 1
+		}
+		s.mu.Lock()
+		s.status.ServerPID = 0
+		s.status.LastExit = &code
+		s.mu.Unlock()
+		if code == 0 {
+			s.notice("The server exited cleanly; stopping the daemon.")
+			return 0
+		}
+		if loop.Crash(time.Now()) {
+			for _, line := range crashLoopReport(loop, code, s.stderr.Lines()) {
+				s.notice(line)
+			}
+			return code
+		}
+
+		delay := backoff.Next(time.Since(started))
+		s.mu.Lock()
+		s.status.Restarts++
+		s.status.State = daemon.StateBackoff
+		s.status.NextStart = time.Now().Add(delay)
+		restarts := s.status.Restarts
+		s.mu.Unlock()
+		s.notice(fmt.Sprintf("The server exited with code %d; restarting in %s (restart %d)...", code, delay, restarts))
+
+		select {
+		case <-time.After(delay):
+		case <-s.restart:
+			backoff.Clear()
+		case <-s.stop:
+			return 0
+		}
+	}
+}
+
+// startServer starts one run of the server, its output going to the log.
+func (s *supervisor) startServer() (*exec.Cmd, <-chan error, error) {
+	w, flush := s.log.Writer()
+	s.stderr.Reset()
+	tail, flushTail := s.stderr.Writer()
+	server := exec.Command(s.script)
+	server.Dir = s.root
+	server.Env = append(os.Environ(), localCredentialEnv()...)
+	server.Stdout, server.Stderr = w, io.MultiWriter(w, tail)
+	// Don't let a grandchild holding the output pipe keep a stopped
+	// server from being reaped.
+	server.WaitDelay = 5 * time.Second
+	logCommand(server)
+	if err := server.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	s.status.State = daemon.StateRunning
+	s.status.ServerPID = server.Process.Pid
+	s.status.ServerSince = time.Now()
+	s.status.NextStart = time.Time{}
+	s.mu.Unlock()
+
+	exited := make(chan error, 1)
+	go func() {
+		err := server.Wait()
+		flush()
+		flushTail()
+		exited <- err
+	}()
+	return server, exited, nil
+}
+
+func (s *supervisor) setState(state string) {
+	s.mu.Lock()
+	s.status.State = state
+	s.mu.Unlock()
+}
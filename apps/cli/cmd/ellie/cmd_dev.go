@@ -2,20 +2,100 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/charmbracelet/x/ansi"
 	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/config"
+	"ellie/apps/cli/internal/logfilter"
+	"ellie/apps/cli/internal/paths"
+	"ellie/apps/cli/internal/workspace"
+)
+
+var (
+	devOnlyChanged  bool
+	devBaseBranch   string
+	devMute         []string
+	devOnly         []string
+	devLevel        string
+	devResetFilters bool
+	devTurboFilter  []string
 )
 
 var devCmd = &cobra.Command{
-	Use:   "dev",
+	Use:   "dev [-- turbo args...]",
 	Short: "Start development server (hot reload)",
-	RunE:  runDev,
+	Long: `Start the development servers with turbo, with hot reload.
+
+--filter picks the packages to run, as turbo's --filter does, in place of
+the dev.filters setting (which leaves out the CLI by default). Arguments
+after -- are passed on to turbo:
+
+  ellie dev --filter web --filter server -- --concurrency=4
+
+--mute, --only and --level hide lines from the terminal by the app that
+wrote them and by log level; ellie logs still has every line. The choice
+is remembered for the project and used by the next ellie dev until it is
+changed or cleared with --reset-filters:
+
+  ellie dev --mute docs --level warn
+
+--port sets the port the server binds; --port auto takes the first free
+one from the configured port up and prints it.
+
+With --wait, the dev servers run in the background and ellie returns once
+the server accepts requests, or fails after --wait-timeout — for scripts that
+need a running server, such as end-to-end tests. Stop them with
+ellie stop --dev.
+
+--instance runs a dev instance of its own beside the others — to compare
+two branches side by side, run each from its checkout or worktree:
+
+  ellie dev --instance blue          # in ../ellie-main
+  ellie dev --instance green         # in ../ellie-feature
+
+Each instance keeps its own state directory (pidfiles, logs, sessions)
+and gives its server its own DATA_DIR under it, so their databases are
+apart too. Its server binds dev.instances.<name>.port, else the first
+free port, and gets the NAME=value variables in dev.instances.<name>.env.
+Pass the same --instance to other commands, or set ELLIE_INSTANCE, to
+work with that instance: ellie logs --instance blue, ellie stop --dev
+--instance blue. ellie ps lists the instances that are running.
+
+With --no-turbo, ellie runs each selected package's dev script itself
+instead of through turbo — --filter then takes package names and !name
+only. Their output is prefixed with the app's name, and in a terminal a
+number key restarts that app, a restarts them all and q stops them.
+
+--output summary shows turbo's line for each task and the whole output of
+those that fail; --output errors-only shows only the failing tasks'
+output, for CI logs that stay readable. ellie logs still has every line.`,
+	RunE: runDev,
+}
+
+func init() {
+	devCmd.Flags().BoolVar(&devOnlyChanged, "only-changed", false, "Only run packages affected by changes since --base")
+	devCmd.Flags().StringVar(&devBaseBranch, "base", "main", "Base branch for --only-changed")
+	devCmd.Flags().StringSliceVar(&devMute, "mute", nil, "Hide this app's output from the terminal (repeatable)")
+	devCmd.Flags().StringSliceVar(&devOnly, "only", nil, "Show only this app's output in the terminal (repeatable)")
+	devCmd.Flags().StringVar(&devLevel, "level", "", "Show only lines at this log level and above: debug, info, warn or error")
+	devCmd.Flags().BoolVar(&devResetFilters, "reset-filters", false, "Forget the project's saved --mute, --only and --level")
+	devCmd.Flags().StringArrayVar(&devTurboFilter, "filter", nil, "Run only the packages turbo's filter selects, instead of dev.filters (repeatable)")
+	devCmd.MarkFlagsMutuallyExclusive("filter", "only-changed")
 }
 
+// devOutputFilter, when set, hides lines of the child's output from the
+// terminal. The log keeps them all.
+var devOutputFilter *logfilter.Filter
+
 // killPort kills any process listening on the given TCP port.
 func killPort(port string) {
 	if runtime.GOOS == "windows" {
@@ -66,19 +146,303 @@ func runDev(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	turboPath, err := findBin("turbo", root)
+	wd, err := watchdogFor(cmd, "dev")
+	if err != nil {
+		return err
+	}
+
+	extra, err := turboPassthrough(cmd, args)
+	if err != nil {
+		return err
+	}
+	turboArgs := append([]string{"run", "dev"}, devFilters()...)
+	if devOnlyChanged {
+		filters, err := affectedFilters(root, devBaseBranch, "cli")
+		if err != nil {
+			return err
+		}
+		if filters != nil && len(filters) == 0 {
+			fmt.Println(styleDim.Render("No packages changed since " + devBaseBranch + " — nothing to run."))
+			return nil
+		}
+		if filters != nil {
+			turboArgs = append([]string{"run", "dev"}, filters...)
+		}
+	}
+	turboArgs = append(turboArgs, extra...)
+	if devNoTurbo && len(extra) > 0 {
+		return fmt.Errorf("arguments after -- are for turbo, which --no-turbo does not run")
+	}
+	var apps []*devApp
+	var turboPath string
+	if devNoTurbo {
+		if apps, err = devApps(root, turboArgs[2:]); err != nil {
+			return err
+		}
+	} else if turboPath, err = findBin("turbo", root); err != nil {
+		return err
+	}
+
+	filter, err := devLogFilter(cmd, root)
 	if err != nil {
 		return err
 	}
+	if !filter.Empty() {
+		devOutputFilter = &filter
+	}
+	if err := applyTaskOutput(""); err != nil {
+		return err
+	}
+
+	if currentInstance != "" && serverPortFlag == "" {
+		serverPortFlag = "auto"
+		if s, ok := instanceSetting("port"); ok {
+			serverPortFlag = config.FormatValue(s.Value)
+		}
+	}
 
-	killExistingEllie()
-	killPort("3000")
+	if devWait {
+		port, err := applyServerPort()
+		if err != nil {
+			return err
+		}
+		return devDetached(cmd, root, port, extra)
+	}
+
+	// Another instance's servers match the same patterns; leave them be.
+	if currentInstance == "" && !devInstanceRunning() {
+		killExistingEllie()
+	}
+	port, err := applyServerPort()
+	if err != nil {
+		return err
+	}
+	if currentInstance != "" {
+		env, err := instanceServerEnv()
+		if err != nil {
+			return err
+		}
+		serverEnv = append(serverEnv, env...)
+		state, _ := paths.State()
+		fmt.Println(styleDim.Render(fmt.Sprintf("Instance %s: server on port %d, state in %s", currentInstance, port, state)))
+	}
+	if serverPortFlag != "auto" {
+		if port == 0 {
+			port = 3000
+		}
+		killPort(strconv.Itoa(port))
+	}
 
 	fmt.Println(styleBold.Render("Starting dev server..."))
 	fmt.Println()
 
-	if exitCode := runProcess(turboPath, []string{"run", "dev", "--filter=!cli"}, root); exitCode != 0 {
+	log, err := openLogStore("dev", "turbo")
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	removePid, err := writePidfile("dev", os.Getpid())
+	if err != nil {
+		return err
+	}
+	defer removePid()
+	if err := saveInvocation("dev"); err != nil {
+		warn("cannot record the command line: " + err.Error())
+	}
+	if serverPortFlag != "" {
+		removePort, err := writeServerPort("dev", port)
+		if err != nil {
+			return err
+		}
+		defer removePort()
+	}
+	want := port
+	if want == 0 {
+		want, _ = urlPort(baseURL())
+	}
+	serverPortWatch = watchServerPort("dev", want)
+	defer serverPortWatch.Close()
+
+	var exitCode int
+	if devNoTurbo {
+		exitCode, err = runDevApps(root, apps, log)
+		if err != nil {
+			return err
+		}
+	} else {
+		exitCode = runWatchedProcess(turboPath, turboArgs, root, log, wd)
+	}
+	closeTaskOutput(exitCode)
+	if exitCode != 0 {
 		return exitCodeError(exitCode)
 	}
 	return nil
 }
+
+// devLogFilter resolves the terminal filter for the project at root: the
+// one given by flags, which is then remembered, else the one saved by the
+// last ellie dev.
+func devLogFilter(cmd *cobra.Command, root string) (logfilter.Filter, error) {
+	given := cmd.Flags().Changed("mute") || cmd.Flags().Changed("only") || cmd.Flags().Changed("level")
+	if given {
+		f := logfilter.Filter{Mute: devMute, Only: devOnly, Level: devLevel}
+		if err := f.Validate(); err != nil {
+			return f, err
+		}
+		if store, err := devFilterStore(); err == nil {
+			if err := store.Set(root, f); err != nil {
+				warn("cannot remember the log filters: " + err.Error())
+			}
+		}
+		return f, nil
+	}
+
+	store, err := devFilterStore()
+	if err != nil {
+		return logfilter.Filter{}, nil
+	}
+	if devResetFilters {
+		if err := store.Set(root, logfilter.Filter{}); err != nil {
+			return logfilter.Filter{}, err
+		}
+		fmt.Println(styleDim.Render("Cleared the saved log filters for this project."))
+		return logfilter.Filter{}, nil
+	}
+	f, ok, err := store.Get(root)
+	if err != nil {
+		warn("ignoring saved log filters: " + err.Error())
+		return logfilter.Filter{}, nil
+	}
+	if ok {
+		fmt.Println(styleDim.Render("Log filters from the last ellie dev: " + f.String() + " (--reset-filters to clear)"))
+	}
+	return f, nil
+}
+
+func devFilterStore() (logfilter.Store, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return logfilter.Store{}, err
+	}
+	return logfilter.Store{Path: filepath.Join(dir, "dev-filters.json")}, nil
+}
+
+// filteredWriter passes the lines written to it on to w when f allows
+// them. The returned func writes a last unterminated line.
+func filteredWriter(w io.Writer, f logfilter.Filter) (io.Writer, func()) {
+	lw := &lineWriter{onLine: func(line string) {
+		text := strings.TrimRight(ansi.Strip(line), "\r")
+		app := ""
+		if m := turboPrefix.FindStringSubmatch(text); m != nil {
+			app, text = m[1], m[3]
+		}
+		if f.Allow(app, text) {
+			io.WriteString(w, line+"\n")
+		}
+	}}
+	return lw, lw.flush
+}
+
+// devFilters returns the turbo --filter args from --filter, else from
+// dev.filters, which leaves out the CLI by default.
+func devFilters() []string {
+	var filters []string
+	if len(devTurboFilter) > 0 {
+		for _, f := range devTurboFilter {
+			filters = append(filters, "--filter="+f)
+		}
+		return filters
+	}
+	if s, ok := settings().Get("dev.filters"); ok {
+		list, _ := s.Value.([]any)
+		for _, f := range list {
+			if f, ok := f.(string); ok && f != "" {
+				filters = append(filters, "--filter="+f)
+			}
+		}
+	}
+	return filters
+}
+
+// turboPassthrough returns the arguments given after --, for turbo. Any
+// before it are a mistake.
+func turboPassthrough(cmd *cobra.Command, args []string) ([]string, error) {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 {
+		dash = len(args)
+	}
+	if dash > 0 {
+		return nil, fmt.Errorf("unexpected argument %q — pass arguments for turbo after --", args[0])
+	}
+	return args[dash:], nil
+}
+
+// affectedFilters returns turbo --filter args for the packages affected by
+// changes since base (committed, staged, unstaged and untracked), leaving
+// out those named in exclude. It returns nil when files outside any
+// package changed, meaning the whole workspace should run.
+func affectedFilters(root, base string, exclude ...string) ([]string, error) {
+	pkgs, err := workspace.Discover(root)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeBase := base
+	if out, err := gitOutput(root, "merge-base", "HEAD", base); err == nil {
+		mergeBase = strings.TrimSpace(out)
+	}
+	diff, err := gitOutput(root, "diff", "--name-only", mergeBase)
+	if err != nil {
+		return nil, fmt.Errorf("git diff against %s failed: %w", base, err)
+	}
+	untracked, err := gitOutput(root, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files failed: %w", err)
+	}
+	changed := append(gitPaths(diff), gitPaths(untracked)...)
+
+	names, rootChanged := workspace.Affected(pkgs, changed)
+	if rootChanged {
+		fmt.Println(styleDim.Render("Root files changed since " + base + " — running all packages."))
+		return nil, nil
+	}
+
+	filters := []string{}
+	for _, name := range names {
+		if slices.Contains(exclude, name) {
+			continue
+		}
+		filters = append(filters, "--filter="+name)
+	}
+	if len(filters) > 0 {
+		fmt.Println(styleDim.Render("Affected packages: " + strings.Join(names, ", ")))
+	}
+	return filters, nil
+}
+
+// gitPaths splits the output of git diff --name-only or git ls-files,
+// one path per line; paths may contain spaces.
+func gitPaths(out string) []string {
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	logCommand(c)
+	out, err := c.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/buildinfo"
+	"ellie/apps/cli/internal/paths"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the local setup and suggest fixes",
+	Long: `Check everything ellie depends on — node, bun and turbo, the monorepo
+root, the API server, provider credentials, the provider's status page and
+the state directory — and print a fix for each problem found.
+
+Exits non-zero if any check fails.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+type checkLevel int
+
+const (
+	checkOK checkLevel = iota
+	checkWarn
+	checkFail
+)
+
+// doctorCheck is the result of a single diagnostic.
+type doctorCheck struct {
+	name   string
+	level  checkLevel
+	detail string
+	fix    string
+}
+
+func (l checkLevel) String() string {
+	switch l {
+	case checkWarn:
+		return "warn"
+	case checkFail:
+		return "fail"
+	}
+	return "ok"
+}
+
+// doctorReport is the --json output of ellie doctor.
+type doctorReport struct {
+	Sections []doctorSection `json:"sections"`
+	Failed   int             `json:"failed"`
+	Warnings int             `json:"warnings"`
+}
+
+type doctorSection struct {
+	Title  string            `json:"title"`
+	Checks []doctorCheckJSON `json:"checks"`
+}
+
+type doctorCheckJSON struct {
+	Name   string `json:"name"`
+	Level  string `json:"level"` // ok, warn or fail
+	Detail string `json:"detail,omitempty"`
+	Fix    string `json:"fix,omitempty"`
+}
+
+// doctorChecks is a titled group of checks.
+type doctorChecks struct {
+	title  string
+	checks []doctorCheck
+}
+
+// runDoctorChecks runs every check, grouped as ellie doctor shows them.
+func runDoctorChecks() []doctorChecks {
+	root, rootErr := findMonorepoRoot()
+	server := checkServer(baseURL())
+	credentials := []doctorCheck{{name: "providers", level: checkWarn, detail: "skipped (server unreachable)"}}
+	if server.level != checkFail {
+		credentials = checkCredentials(baseURL())
+	}
+	return []doctorChecks{
+		{"Project", []doctorCheck{
+			{name: "ellie", level: checkOK, detail: buildinfo.Get().String()},
+			checkRoot(root, rootErr),
+		}},
+		{"Toolchain", checkToolchain(root)},
+		{"Server", []doctorCheck{server}},
+		{"Credentials", credentials},
+		{"Upstream", checkUpstream()},
+		{"Filesystem", []doctorCheck{checkStateDir()}},
+	}
+}
+
+// newDoctorReport converts the results of runDoctorChecks to JSON.
+func newDoctorReport(sections []doctorChecks) doctorReport {
+	report := doctorReport{Sections: []doctorSection{}}
+	for _, s := range sections {
+		section := doctorSection{Title: s.title, Checks: []doctorCheckJSON{}}
+		for _, c := range s.checks {
+			check := doctorCheckJSON{Name: c.name, Level: c.level.String(), Detail: ansi.Strip(c.detail)}
+			switch c.level {
+			case checkWarn:
+				report.Warnings++
+				check.Fix = c.fix
+			case checkFail:
+				report.Failed++
+				check.Fix = c.fix
+			}
+			section.Checks = append(section.Checks, check)
+		}
+		report.Sections = append(report.Sections, section)
+	}
+	return report
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	sections := runDoctorChecks()
+
+	fmt.Println()
+	fmt.Println(styleBold.Render("Ellie Doctor"))
+	fmt.Println(strings.Repeat("─", 40))
+
+	for _, s := range sections {
+		fmt.Println()
+		fmt.Println(styleBold.Render("  " + s.title))
+		for _, c := range s.checks {
+			var mark string
+			switch c.level {
+			case checkOK:
+				mark = styleOk.Render("✓")
+			case checkWarn:
+				mark = styleDim.Render("!")
+			case checkFail:
+				mark = styleErr.Render("✗")
+			}
+			fmt.Printf("    %s %-14s %s\n", mark, c.name, c.detail)
+			if c.level != checkOK && c.fix != "" {
+				fmt.Println(styleDim.Render("      → " + c.fix))
+			}
+		}
+	}
+	report := newDoctorReport(sections)
+	failed, warned := report.Failed, report.Warnings
+	if jsonFlag {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case failed > 0:
+		fmt.Println(styleErr.Render(fmt.Sprintf("%d problem(s) found", failed)), styleDim.Render(fmt.Sprintf("(%d warning(s))", warned)))
+		fmt.Println()
+		return errSilent
+	case warned > 0:
+		fmt.Println(styleOk.Render("No problems found"), styleDim.Render(fmt.Sprintf("(%d warning(s))", warned)))
+	default:
+		fmt.Println(styleOk.Render("Everything looks good"))
+	}
+	fmt.Println()
+	return nil
+}
+
+func checkRoot(root string, err error) doctorCheck {
+	if err != nil {
+		return doctorCheck{name: "monorepo", level: checkFail, detail: "not found",
+			fix: "run ellie from inside the ellie checkout, or set ELLIE_ROOT to its path"}
+	}
+	return doctorCheck{name: "monorepo", level: checkOK, detail: root}
+}
+
+// checkToolchain verifies node, bun and turbo against the versions the
+// monorepo's package.json asks for.
+func checkToolchain(root string) []doctorCheck {
+	var manifest struct {
+		PackageManager string `json:"packageManager"`
+		Engines        struct {
+			Node string `json:"node"`
+		} `json:"engines"`
+	}
+	if root != "" {
+		if data, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil {
+			_ = json.Unmarshal(data, &manifest)
+		}
+	}
+
+	nodeMin := strings.TrimPrefix(manifest.Engines.Node, ">=")
+	bunWant := ""
+	if name, version, ok := strings.Cut(manifest.PackageManager, "@"); ok && name == "bun" {
+		bunWant = version
+	}
+
+	return []doctorCheck{
+		checkTool("node", root, nodeMin, "install Node.js "+orAny(manifest.Engines.Node)+" from https://nodejs.org"),
+		checkTool("bun", root, bunWant, "install bun with: curl -fsSL https://bun.sh/install | bash"),
+		checkTool("turbo", root, "", "run bun install in the monorepo root"),
+	}
+}
+
+func orAny(constraint string) string {
+	if constraint == "" {
+		return "(any recent version)"
+	}
+	return constraint
+}
+
+// checkTool locates a binary, reads its version and compares it with min
+// (empty means any version is fine).
+func checkTool(name, root, min, fix string) doctorCheck {
+	version, path, err := toolVersion(name, root)
+	switch {
+	case path == "":
+		return doctorCheck{name: name, level: checkFail, detail: "not found", fix: fix}
+	case err != nil:
+		return doctorCheck{name: name, level: checkWarn, detail: "found at " + path + " but --version failed", fix: fix}
+	}
+
+	if min != "" && compareVersions(version, min) < 0 {
+		return doctorCheck{name: name, level: checkWarn,
+			detail: fmt.Sprintf("%s (project expects %s)", version, min),
+			fix:    fmt.Sprintf("upgrade %s to %s or newer", name, min)}
+	}
+	return doctorCheck{name: name, level: checkOK, detail: version + styleDim.Render("  "+path)}
+}
+
+// toolVersion locates a binary and reads its version from the first line
+// of its --version. path is empty when the binary isn't found.
+func toolVersion(name, root string) (version, path string, err error) {
+	path, err = findBin(name, root)
+	if err != nil {
+		return "", "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return "", path, err
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimPrefix(strings.TrimSpace(first), "v"), path, nil
+}
+
+// compareVersions compares dotted numeric versions, ignoring any
+// pre-release suffix. Missing components count as zero.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v, _, _ = strings.Cut(strings.TrimPrefix(v, "v"), "-")
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+func checkServer(base string) doctorCheck {
+	start := time.Now()
+	resp, err := httpClient.Get(base + "/api/status")
+	if err != nil {
+		return doctorCheck{name: "api", level: checkFail, detail: "unreachable at " + base,
+			fix: "start it with ellie dev or ellie start, or point --base-url or ELLIE_API_URL at a running server"}
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case 200:
+		return doctorCheck{name: "api", level: checkOK,
+			detail: fmt.Sprintf("%s (%dms)", base, time.Since(start).Milliseconds())}
+	case 403:
+		return doctorCheck{name: "api", level: checkWarn, detail: fmt.Sprintf("%s refuses non-loopback status requests", base),
+			fix: "run ellie on the server's machine or reach it through an SSH tunnel"}
+	default:
+		return doctorCheck{name: "api", level: checkFail, detail: fmt.Sprintf("%s returned HTTP %d", base, resp.StatusCode),
+			fix: "check the server output with ellie logs"}
+	}
+}
+
+// checkCredentials reports each provider's credential state. Only a
+// missing Anthropic credential is a failure — the others are optional.
+func checkCredentials(base string) []doctorCheck {
+	var checks []doctorCheck
+	for _, p := range authProviders {
+		c := doctorCheck{name: p.name}
+		resp, err := httpClient.Get(base + "/api/auth/" + p.slug + "/status")
+		if err != nil {
+			c.level, c.detail = checkWarn, "unknown (server unreachable)"
+			checks = append(checks, c)
+			continue
+		}
+		var s struct {
+			Mode       *string `json:"mode"`
+			Configured bool    `json:"configured"`
+			Expired    *bool   `json:"expired,omitempty"`
+			ExpiresAt  *int64  `json:"expires_at,omitempty"`
+		}
+		ok := resp.StatusCode == 200 && json.NewDecoder(resp.Body).Decode(&s) == nil
+		resp.Body.Close()
+
+		switch {
+		case !ok:
+			c.level, c.detail = checkWarn, fmt.Sprintf("unknown (HTTP %d)", resp.StatusCode)
+		case !s.Configured || s.Mode == nil:
+			c.level, c.detail = checkWarn, "not configured"
+			if p.slug == "anthropic" {
+				c.level = checkFail
+			}
+			c.fix = "run ellie auth to add " + p.name + " credentials"
+		case s.Expired != nil && *s.Expired:
+			c.level, c.detail = checkFail, "expired ("+*s.Mode+")"
+			c.fix = "run ellie auth to sign in to " + p.name + " again"
+		case s.ExpiresAt != nil:
+			left := time.Until(time.UnixMilli(*s.ExpiresAt))
+			if left <= 0 {
+				c.level, c.detail = checkFail, "expired ("+*s.Mode+")"
+				c.fix = "run ellie auth to sign in to " + p.name + " again"
+				break
+			}
+			c.level, c.detail = checkOK, fmt.Sprintf("%s, expires in %s", *s.Mode, formatUptime(left))
+			if left < 24*time.Hour {
+				c.level = checkWarn
+				c.fix = "the token expires soon — run ellie auth to renew it"
+			}
+		default:
+			c.level, c.detail = checkOK, *s.Mode
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// checkStateDir verifies the CLI can write its logs, pidfiles and caches.
+func checkStateDir() doctorCheck {
+	dir, err := paths.State()
+	if err != nil {
+		return doctorCheck{name: "state dir", level: checkFail, detail: err.Error(), fix: "set ELLIE_STATE_DIR to a writable directory"}
+	}
+	fix := fmt.Sprintf("make %s writable, or set ELLIE_STATE_DIR to a writable directory", dir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return doctorCheck{name: "state dir", level: checkFail, detail: "cannot create " + dir, fix: fix}
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return doctorCheck{name: "state dir", level: checkFail, detail: dir + " is not writable", fix: fix}
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return doctorCheck{name: "state dir", level: checkOK, detail: dir}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/buildinfo"
+)
+
+var editorInfoCmd = &cobra.Command{
+	Use:   "editor-info",
+	Short: "Print what editor plugins need to integrate with ellie, as JSON",
+	Long: `Print a JSON document that editor plugins read to integrate with this
+ellie setup, instead of parsing what ellie prints for people:
+
+  {
+    "handshake": 1,
+    "version": "1.8.0",
+    "apiUrl": "http://localhost:3000",
+    "socket": "/run/ellie/ellie.sock",
+    "authMode": "sso",
+    "server": {"reachable": true, "version": "1.8.2", "compatibility": "ok"},
+    "capabilities": ["lsp-proxy", "ellie/ask", "ellie/summarize", "ellie/reviewDiff", "chat-prompt", "json-output"]
+  }
+
+apiUrl is where to send HTTP requests, and socket, when set, the Unix
+socket to send them over. authMode is how ellie signs them: sso for a
+session from ellie login --sso, token for the selected environment's
+token, none otherwise — plugins that can't sign requests themselves
+should go through ellie lsp-proxy. Fields are only ever added while
+handshake stays 1; a change that breaks readers increments it.`,
+	Args: cobra.NoArgs,
+	RunE: runEditorInfo,
+}
+
+// editorHandshake is the version of the editor-info document.
+const editorHandshake = 1
+
+// editorInfo is the document ellie editor-info prints.
+type editorInfo struct {
+	Handshake    int              `json:"handshake"`
+	Version      string           `json:"version"`
+	APIURL       string           `json:"apiUrl"`
+	Socket       string           `json:"socket,omitempty"`
+	AuthMode     string           `json:"authMode"` // sso, token or none
+	Server       editorInfoServer `json:"server"`
+	Capabilities []string         `json:"capabilities"`
+}
+
+type editorInfoServer struct {
+	Reachable     bool   `json:"reachable"`
+	Version       string `json:"version,omitempty"`
+	Compatibility string `json:"compatibility,omitempty"` // ok, warn or block
+}
+
+// editorProbeTimeout bounds the reachability check, so a plugin that
+// runs editor-info on startup isn't held up by a server that is down.
+const editorProbeTimeout = 2 * time.Second
+
+func runEditorInfo(cmd *cobra.Command, args []string) error {
+	base := baseURL()
+	info := editorInfo{
+		Handshake:    editorHandshake,
+		Version:      buildinfo.Get().Version,
+		APIURL:       base,
+		Socket:       serverSocket,
+		AuthMode:     "none",
+		Capabilities: append(append([]string{"lsp-proxy"}, lspMethods...), "chat-prompt", "json-output"),
+	}
+
+	origin := serverOrigin(base)
+	if sessions, err := loadSessions(); err == nil && sessions.Servers[origin] != nil {
+		info.AuthMode = "sso"
+	} else if envToken(origin) != "" {
+		info.AuthMode = "token"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), editorProbeTimeout)
+	defer cancel()
+	if req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/api/status", nil); err == nil {
+		if resp, err := httpClient.Do(req); err == nil {
+			resp.Body.Close()
+			info.Server.Reachable = resp.StatusCode == http.StatusOK
+		}
+	}
+	info.Server.Version, info.Server.Compatibility = serverVersionInfo(base)
+	return printJSON(info)
+}
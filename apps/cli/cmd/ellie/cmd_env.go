@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/config"
+)
+
+// ── server environments ─────────────────────────────────────────────────────
+
+var (
+	envFlag     string
+	baseURLFlag string
+	envUseClear bool
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Switch between configured servers",
+	Long: `Named environments map to different servers, configured in ellie.toml:
+
+  [envs.local]
+  url = "http://localhost:3000"
+
+  [envs.staging]
+  url = "https://ellie-staging.example.com"
+  token_env = "ELLIE_STAGING_TOKEN"   # variable holding a bearer token
+
+or with 'ellie config set envs.staging.url https://...'. Select one for a
+single command with --env, for the shell with ELLIE_ENV, or until changed
+with 'ellie env use'. --env wins over ELLIE_API_URL, which wins over the
+other two; --base-url names a server directly and wins over them all. SSO sessions from 'ellie login --sso' are kept per server, so
+each environment keeps its own sign-in.`,
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configured environments",
+	Args:  cobra.NoArgs,
+	RunE:  runEnvList,
+}
+
+var envUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Select the environment later commands use",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if envUseClear {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var names []string
+		for _, e := range settings().Envs() {
+			names = append(names, e.Name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: runEnvUse,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&envFlag, "env", "", "Server environment from the config to use (default: ELLIE_ENV or 'ellie env use')")
+	rootCmd.PersistentFlags().StringVar(&baseURLFlag, "base-url", "", "URL of the server to talk to (http://, https:// or unix://), overriding --env, ELLIE_API_URL and the config")
+	envUseCmd.Flags().BoolVar(&envUseClear, "clear", false, "Stop selecting an environment and use server.url again")
+}
+
+// checkBaseURLFlag rejects a --base-url that isn't an http(s) or unix
+// URL, or is given together with --env.
+func checkBaseURLFlag() error {
+	if baseURLFlag == "" {
+		return nil
+	}
+	if envFlag != "" {
+		return fmt.Errorf("--base-url and --env both choose the server — pass one of them")
+	}
+	if _, ok := socketPath(baseURLFlag); ok {
+		return nil
+	}
+	u, err := url.Parse(baseURLFlag)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("--base-url %q is not an http, https or unix URL", baseURLFlag)
+	}
+	return nil
+}
+
+// selectedEnv returns the environment chosen with --env, ELLIE_ENV or the
+// env setting, if any.
+func selectedEnv() (config.Env, bool, error) {
+	name := envFlag
+	if name == "" {
+		name, _ = setting("env")
+	}
+	if name == "" {
+		return config.Env{}, false, nil
+	}
+	e, ok := settings().Env(name)
+	if !ok {
+		return e, false, fmt.Errorf("unknown environment %q — see 'ellie env list'", name)
+	}
+	return e, true, nil
+}
+
+// envToken returns the bearer token of the selected environment for
+// requests to origin, if it has one.
+func envToken(origin string) string {
+	e, ok, _ := selectedEnv()
+	if !ok || e.TokenEnv == "" || serverOrigin(e.URL) != origin {
+		return ""
+	}
+	return os.Getenv(e.TokenEnv)
+}
+
+func runEnvList(cmd *cobra.Command, args []string) error {
+	envs := settings().Envs()
+	if len(envs) == 0 {
+		fmt.Println(styleDim.Render("No environments configured — add one with 'ellie config set envs.<name>.url <url>'."))
+		return nil
+	}
+	active, _, _ := selectedEnv()
+
+	nameWidth, urlWidth := 0, 0
+	for _, e := range envs {
+		nameWidth = max(nameWidth, len(e.Name))
+		urlWidth = max(urlWidth, len(e.URL))
+	}
+	for _, e := range envs {
+		marker := "  "
+		if e.Name == active.Name {
+			marker = styleOk.Render("● ")
+		}
+		detail := e.Origin
+		if e.TokenEnv != "" {
+			detail += ", token from $" + e.TokenEnv
+			if os.Getenv(e.TokenEnv) == "" {
+				detail += " (unset)"
+			}
+		}
+		fmt.Printf("%s%-*s  %-*s  %s\n", marker, nameWidth, e.Name, urlWidth, e.URL, styleDim.Render(detail))
+	}
+	switch {
+	case active.Name != "" && baseURLFlag != "":
+		fmt.Println(styleDim.Render("--base-url overrides the selected environment."))
+	case active.Name != "" && envFlag == "" && os.Getenv("ELLIE_API_URL") != "":
+		fmt.Println(styleDim.Render("ELLIE_API_URL is set and overrides the selected environment."))
+	}
+	return nil
+}
+
+func runEnvUse(cmd *cobra.Command, args []string) error {
+	path, err := config.UserPath()
+	if err != nil {
+		return err
+	}
+	if envUseClear {
+		if _, err := config.UnsetFile(path, "env"); err != nil {
+			return err
+		}
+		u, _ := setting("server.url")
+		fmt.Println(styleOk.Render("✓"), "No environment selected", styleDim.Render("— using "+u))
+		return nil
+	}
+
+	e, ok := settings().Env(args[0])
+	if !ok {
+		return fmt.Errorf("unknown environment %q — see 'ellie env list'", args[0])
+	}
+	if err := config.SetFile(path, "env", e.Name); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Using", styleBold.Render(e.Name), styleDim.Render("("+e.URL+")"))
+	if v := os.Getenv("ELLIE_ENV"); v != "" && v != e.Name {
+		fmt.Println(styleDim.Render("  ELLIE_ENV=" + v + " still takes precedence in this shell"))
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"ellie/apps/cli/internal/chatui"
+	"ellie/apps/cli/internal/ctxindex"
+	"ellie/apps/cli/internal/termtext"
+)
+
+// ── explain ─────────────────────────────────────────────────────────────────
+
+var (
+	explainLines     int
+	explainNoContext bool
+	explainAsk       string
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [file|-]",
+	Short: "Ask the assistant to explain things",
+	Long: `Explain a command's output: pipe it in, or name a file that holds it.
+Colors and redrawn progress lines are cleaned up first. --ask asks a
+question about it instead of for an explanation:
+
+  bun test |& ellie explain
+  bun test 2>&1 | ellie explain --ask "why did the login test fail?"
+  ellie explain build.log --ask "which package failed to build?"
+
+Output too long for one prompt is read in parts, and the question is
+answered from what each part showed. Files the output mentions are looked
+up in the current repository and sent along, as with ellie explain error;
+--no-context leaves them out.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExplain,
+}
+
+var explainErrorCmd = &cobra.Command{
+	Use:   "error [file|-]",
+	Short: "Explain an error, stack trace or log",
+	Long: `Explain an error message, stack trace or log file: likely causes and the
+next debugging steps.
+
+Paste the error when prompted, pipe it in, or name a log file, of which
+the part around the last error is sent:
+
+  ellie explain error
+  bun test 2>&1 | ellie explain error
+  ellie explain error server.log
+
+Files the trace mentions are looked up in the current repository, even
+when the trace has paths from another machine or a container, and the
+lines around each are sent along; --no-context leaves them out.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExplainError,
+}
+
+func init() {
+	explainCmd.Flags().StringVar(&explainAsk, "ask", "", "Question to answer about the output")
+	explainCmd.Flags().BoolVar(&explainNoContext, "no-context", false, "Don't send the repository files the output mentions")
+	explainErrorCmd.Flags().IntVarP(&explainLines, "lines", "n", 200, "Most lines of a log to send")
+	explainErrorCmd.Flags().BoolVar(&explainNoContext, "no-context", false, "Don't send the repository files the trace mentions")
+}
+
+// maxExplainLocations bounds how many mentioned files are sent.
+const maxExplainLocations = 6
+
+// explainChunkSize is the most output, in bytes, sent in one prompt.
+const explainChunkSize = 48 << 10
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && term.IsTerminal(int(os.Stdin.Fd())) {
+		return cmd.Help()
+	}
+	text, err := readExplainInput(args)
+	if err != nil {
+		return err
+	}
+	text = strings.TrimSpace(termtext.Clean(text))
+	if text == "" {
+		return fmt.Errorf("nothing to explain — the input is empty")
+	}
+	question := explainAsk
+	if question == "" {
+		question = "Explain what this output shows. If something failed, say why, most likely cause first, and give concrete next steps."
+	}
+
+	client, base, err := explainClient()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	chunks := termtext.Chunk(text, explainChunkSize)
+	if len(chunks) == 1 {
+		b.WriteString("Here is the output of a command:\n\n")
+		b.WriteString(fenced("", text))
+	} else {
+		notes, err := explainChunks(client, base, chunks, question)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "A command's output was too long to send at once, so it was read in %d parts. ", len(chunks))
+		b.WriteString("Here are the notes taken on each, in order:\n")
+		for i, n := range notes {
+			fmt.Fprintf(&b, "\n## Part %d\n\n%s\n", i+1, n)
+		}
+	}
+	if !explainNoContext {
+		for _, s := range traceSnippets(text) {
+			b.WriteString("\n\n")
+			b.WriteString(fenced(s.Label(), s.Text))
+		}
+	}
+	b.WriteString("\n\n" + question)
+	return runOneShot(client, base, b.String(), "markdown", nil)
+}
+
+// explainChunks asks for notes on each part of output too long for one
+// prompt: what in it bears on question.
+func explainChunks(client *chatui.HTTPClient, base string, chunks []string, question string) ([]string, error) {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	current, err := client.GetAssistantCurrent(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve current branch: %w", err)
+	}
+	cfg := chatui.OneShotConfig{BaseURL: base, BranchID: current.BranchID, Format: "text"}
+
+	notes := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		prompt := fmt.Sprintf("This is part %d of %d of a command's output. Note briefly what in it bears on this question, quoting the lines that matter — errors, failing tests, stack frames — exactly: %s\n"+
+			"If nothing in it does, answer only \"Nothing relevant.\"\n\n%s", i+1, len(chunks), question, fenced("", chunk))
+		done := spinner(fmt.Sprintf("Reading part %d of %d of the output...", i+1, len(chunks)))
+		res, err := chatui.RunOneShot(ctx, cfg, prompt)
+		done()
+		if err != nil {
+			return nil, fmt.Errorf("reading part %d: %w", i+1, err)
+		}
+		if res.Error != "" {
+			return nil, fmt.Errorf("reading part %d: %s", i+1, res.Error)
+		}
+		notes[i] = strings.TrimSpace(res.Content)
+	}
+	return notes, nil
+}
+
+// explainClient connects to the server, reporting when it can't.
+func explainClient() (*chatui.HTTPClient, string, error) {
+	base := requireBaseURL()
+	client := chatui.NewHTTPClient(base)
+	if _, err := client.GetStatus(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, styleErr.Render("Cannot connect to server at "+base))
+		fmt.Fprintln(os.Stderr, styleDim.Render("Make sure the server is running (ellie dev or ellie start)"))
+		return nil, "", errSilent
+	}
+	return client, base, nil
+}
+
+func runExplainError(cmd *cobra.Command, args []string) error {
+	text, err := readExplainInput(args)
+	if err != nil {
+		return err
+	}
+	text = excerptLog(strings.TrimSpace(termtext.Clean(text)), explainLines)
+	if text == "" {
+		return fmt.Errorf("nothing to explain — the input is empty")
+	}
+
+	client, base, err := explainClient()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("Explain this error. List the most likely causes, most likely first, and concrete next debugging steps. ")
+	b.WriteString("Where the repository files below are relevant, refer to them by path and line.\n\n")
+	b.WriteString(fenced("", text))
+	if !explainNoContext {
+		for _, s := range traceSnippets(text) {
+			b.WriteString("\n\n")
+			b.WriteString(fenced(s.Label(), s.Text))
+		}
+	}
+	return runOneShot(client, base, b.String(), "markdown", nil)
+}
+
+// readExplainInput reads the named file, or stdin for "-" or no argument.
+// At a terminal it asks for the error to be pasted first.
+func readExplainInput(args []string) (string, error) {
+	if len(args) == 1 && args[0] != "-" {
+		data, err := os.ReadFile(expandHome(args[0]))
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintln(os.Stderr, styleDim.Render("Paste the error or stack trace, then press Ctrl-D on an empty line:"))
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("cannot read standard input: %w", err)
+	}
+	return string(data), nil
+}
+
+var errorLine = regexp.MustCompile(`(?i)\b(error|exception|panic|fatal|traceback|failed)\b`)
+
+// excerptLog keeps at most n lines of text: the end, or if the last line
+// that looks like an error comes before that, the lines around it.
+func excerptLog(text string, n int) string {
+	lines := strings.Split(text, "\n")
+	if n <= 0 || len(lines) <= n {
+		return text
+	}
+	last := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if errorLine.MatchString(lines[i]) {
+			last = i
+			break
+		}
+	}
+	start := len(lines) - n
+	if last >= 0 && last < start {
+		start = max(0, last-n/2)
+	}
+	return strings.Join(lines[start:start+n], "\n")
+}
+
+// traceSnippets resolves the files text mentions in the repository around
+// the current directory and returns the lines around each mention.
+func traceSnippets(text string) []ctxindex.Snippet {
+	locs := ctxindex.ParseLocations(text)
+	if len(locs) == 0 {
+		return nil
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	if top, err := gitOutput(root, "rev-parse", "--show-toplevel"); err == nil {
+		root = strings.TrimSpace(top)
+	}
+	files, err := ctxindex.ListFiles(root)
+	if err != nil {
+		return nil
+	}
+
+	var snippets []ctxindex.Snippet
+	var labels []string
+	for _, loc := range locs {
+		if len(snippets) == maxExplainLocations {
+			break
+		}
+		file, ok := ctxindex.Resolve(root, files, loc.Path)
+		if !ok {
+			continue
+		}
+		if s, ok := ctxindex.Around(root, file, loc.Line, 8); ok {
+			snippets = append(snippets, s)
+			labels = append(labels, fmt.Sprintf("%s:%d", file, loc.Line))
+		}
+	}
+	if len(snippets) > 0 {
+		fmt.Fprintln(os.Stderr, styleDim.Render("Including "+strings.Join(labels, ", ")))
+	}
+	return snippets
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/buildinfo"
+	"ellie/apps/cli/internal/redact"
+)
+
+var (
+	exportRedacted bool
+	exportOutput   string
+	exportYes      bool
+	exportLogLines int
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export ellie's setup to share it",
+}
+
+var exportConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Write a support bundle to attach to a bug report",
+	Long: `Write a support bundle — a .tar.gz to attach to a bug report — holding:
+
+  versions.json  the CLI's, the server's and the toolchain's versions
+  config.json    the effective settings and where each comes from
+  doctor.json    the ellie doctor report
+  logs/          the last lines of the ellie dev and ellie start logs
+  errors.txt     the last errors those logs recorded
+
+With --redacted, the default, API keys, tokens, passwords, credentials in
+URLs and email addresses are removed, and your home directory is shown as
+~. Before the bundle is written you can look at every file in it, exactly
+as it will be included; --yes writes it without asking.`,
+	Example: `  ellie export config --redacted
+  ellie export config -o bug-123.tar.gz --yes`,
+	Args: cobra.NoArgs,
+	RunE: runExportConfig,
+}
+
+func init() {
+	exportConfigCmd.Flags().BoolVar(&exportRedacted, "redacted", true, "Remove secrets and personal details from the bundle")
+	exportConfigCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File to write (default: ellie-support-<time>.tar.gz)")
+	exportConfigCmd.Flags().BoolVarP(&exportYes, "yes", "y", false, "Write the bundle without reviewing it")
+	exportConfigCmd.Flags().IntVar(&exportLogLines, "log-lines", 200, "Lines to include from each log")
+}
+
+// bundleFile is a file in a support bundle.
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+// bundleErrorLines is how many error lines errors.txt keeps.
+const bundleErrorLines = 50
+
+func runExportConfig(cmd *cobra.Command, args []string) error {
+	if !exportYes {
+		if err := noPrompt("reviewing the bundle", "pass --yes to write it without review"); err != nil {
+			return err
+		}
+	}
+	if exportLogLines < 0 {
+		return fmt.Errorf("--log-lines must not be negative")
+	}
+
+	stop := spinner("Collecting versions, settings, the doctor report and logs...")
+	files := collectBundle()
+	stop()
+
+	if !exportRedacted {
+		warn("the bundle is not redacted: it may contain API keys, tokens and other secrets")
+	}
+	if !exportYes {
+		ok, err := reviewBundle(files)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Cancelled.")
+			return errSilent
+		}
+	}
+
+	out := exportOutput
+	if out == "" {
+		out = "ellie-support-" + time.Now().Format("20060102-150405") + ".tar.gz"
+	}
+	if err := writeBundle(out, strings.TrimSuffix(filepath.Base(out), ".tar.gz"), files); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Wrote", styleBold.Render(out), styleDim.Render("— attach it to your bug report"))
+	return nil
+}
+
+// collectBundle gathers the bundle's files, redacted unless --redacted
+// is off. A part that can't be collected says why in its place.
+func collectBundle() []bundleFile {
+	scrub := func(s string) string { return s }
+	if exportRedacted {
+		home, _ := os.UserHomeDir()
+		scrub = func(s string) string {
+			s = redact.Text(s)
+			if home != "" {
+				s = strings.ReplaceAll(s, home, "~")
+			}
+			return s
+		}
+	}
+	jsonFile := func(name string, v any) bundleFile {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			data = []byte(err.Error())
+		}
+		return bundleFile{name: name, data: []byte(scrub(string(data)) + "\n")}
+	}
+
+	files := []bundleFile{
+		jsonFile("versions.json", versionReport{BuildInfo: buildinfo.Get(), Components: versionComponentsOf()}),
+		jsonFile("config.json", bundleSettings()),
+		jsonFile("doctor.json", newDoctorReport(runDoctorChecks())),
+	}
+
+	var errs []logRecord
+	for _, stream := range []string{"dev", "start"} {
+		records := lastLogRecords(stream, exportLogLines)
+		var b strings.Builder
+		for _, rec := range records {
+			fmt.Fprintf(&b, "%s\t%s\t%s\n", rec.Time.Format(time.RFC3339Nano), rec.App, scrub(rec.Text))
+			if errorLine.MatchString(rec.Text) {
+				errs = append(errs, rec)
+			}
+		}
+		if len(records) == 0 {
+			b.WriteString("(no log)\n")
+		}
+		files = append(files, bundleFile{name: "logs/" + stream + ".log", data: []byte(b.String())})
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Time.Before(errs[j].Time) })
+	if len(errs) > bundleErrorLines {
+		errs = errs[len(errs)-bundleErrorLines:]
+	}
+	var b strings.Builder
+	for _, rec := range errs {
+		fmt.Fprintf(&b, "%s  %-10s %s\n", rec.Time.Local().Format("2006-01-02 15:04:05"), rec.App, scrub(rec.Text))
+	}
+	if len(errs) == 0 {
+		b.WriteString("(no errors in the logs)\n")
+	}
+	return append(files, bundleFile{name: "errors.txt", data: []byte(b.String())})
+}
+
+// bundleSetting is a setting in config.json.
+type bundleSetting struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Origin string `json:"origin"`
+	Path   string `json:"path,omitempty"`
+}
+
+// bundleSettings lists the effective settings, as ellie config list
+// --origin shows them.
+func bundleSettings() []bundleSetting {
+	cfg := settings()
+	out := []bundleSetting{}
+	for _, k := range cfg.Keys() {
+		s, ok := cfg.Get(k)
+		if !ok {
+			continue
+		}
+		v := s.Value
+		if exportRedacted {
+			v = redact.Value(k, v)
+		}
+		out = append(out, bundleSetting{Key: k, Value: v, Origin: s.Origin, Path: s.Path})
+	}
+	return out
+}
+
+// lastLogRecords returns the last n records of a managed log and its
+// rotated files.
+func lastLogRecords(stream string, n int) []logRecord {
+	path, err := logPath(stream)
+	if err != nil || n == 0 {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var records []logRecord
+	_ = readLogHistory(f, func(rec logRecord) {
+		records = append(records, rec)
+		if len(records) > n {
+			records = records[1:]
+		}
+	})
+	return records
+}
+
+// reviewBundle lists the bundle's files and lets the user look at each
+// before deciding. It reports whether to write the bundle.
+func reviewBundle(files []bundleFile) (bool, error) {
+	fmt.Println()
+	fmt.Println(styleBold.Render("Support bundle"))
+	fmt.Println(strings.Repeat("─", 40))
+	for _, f := range files {
+		fmt.Printf("  %-18s %s\n", f.name, styleDim.Render(formatBytes(int64(len(f.data)))))
+	}
+	fmt.Println()
+
+	for {
+		var choice string
+		options := []huh.Option[string]{huh.NewOption("Write the bundle", "write")}
+		for _, f := range files {
+			options = append(options, huh.NewOption("Show "+f.name, f.name))
+		}
+		options = append(options, huh.NewOption("Cancel", "cancel"))
+		err := huh.NewSelect[string]().
+			Title("Review what the bundle will include").
+			Options(options...).
+			Value(&choice).
+			Run()
+		if errors.Is(err, huh.ErrUserAborted) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch choice {
+		case "write":
+			return true, nil
+		case "cancel":
+			return false, nil
+		}
+		for _, f := range files {
+			if f.name == choice {
+				fmt.Println(styleBold.Render("── " + f.name + " " + strings.Repeat("─", max(0, 36-len(f.name)))))
+				os.Stdout.Write(f.data)
+				fmt.Println()
+			}
+		}
+	}
+}
+
+// writeBundle writes files to a .tar.gz at path, under the directory dir.
+// The bundle may hold what redaction missed, so only the user can read it.
+func writeBundle(path, dir string, files []bundleFile) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, f := range files {
+		hdr := &tar.Header{Name: dir + "/" + f.name, Mode: 0o600, Size: int64(len(f.data)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagsDiffOnly   bool
+	flagsYes        bool
+	flagsProduction bool
+	flagsUsers      []string
+	flagsPercent    int
+	flagsClear      bool
+)
+
+var flagsCmd = &cobra.Command{
+	Use:   "flags",
+	Short: "Manage server feature flags",
+	Long: `List and change the server's feature flags.
+
+Every change is shown as a diff and confirmed before it is applied; use
+--diff to only preview. Servers that report their environment as
+production additionally require --production.
+
+Requires a server that exposes /api/flags.`,
+	Args: cobra.NoArgs,
+	RunE: runFlagsList,
+}
+
+var flagsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show all feature flags",
+	Args:  cobra.NoArgs,
+	RunE:  runFlagsList,
+}
+
+var flagsEnableCmd = &cobra.Command{
+	Use:   "enable <flag>...",
+	Short: "Turn feature flags on",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setFlagsEnabled(args, true)
+	},
+}
+
+var flagsDisableCmd = &cobra.Command{
+	Use:   "disable <flag>...",
+	Short: "Turn feature flags off",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setFlagsEnabled(args, false)
+	},
+}
+
+var flagsTargetCmd = &cobra.Command{
+	Use:   "target <flag>",
+	Short: "Limit a flag to specific users or a percentage rollout",
+	Long: `Set a flag's targeting rules. --users limits it to the listed users,
+--percent rolls it out to a share of everyone else, and --clear removes
+all targeting so the flag applies to everyone.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFlagsTarget,
+}
+
+var flagsToggleCmd = &cobra.Command{
+	Use:   "toggle",
+	Short: "Pick which flags are on interactively",
+	Args:  cobra.NoArgs,
+	RunE:  runFlagsToggle,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{flagsEnableCmd, flagsDisableCmd, flagsTargetCmd, flagsToggleCmd} {
+		c.Flags().BoolVar(&flagsDiffOnly, "diff", false, "Preview the changes without applying them")
+		c.Flags().BoolVarP(&flagsYes, "yes", "y", false, "Apply without asking for confirmation")
+		c.Flags().BoolVar(&flagsProduction, "production", false, "Allow changes on a production server")
+	}
+	flagsTargetCmd.Flags().StringSliceVar(&flagsUsers, "users", nil, "Comma-separated users the flag applies to")
+	flagsTargetCmd.Flags().IntVar(&flagsPercent, "percent", -1, "Percentage of users the flag is rolled out to (0-100)")
+	flagsTargetCmd.Flags().BoolVar(&flagsClear, "clear", false, "Remove all targeting rules")
+}
+
+// featureFlag mirrors a flag as returned by GET /api/flags.
+type featureFlag struct {
+	Key         string         `json:"key"`
+	Enabled     bool           `json:"enabled"`
+	Description string         `json:"description,omitempty"`
+	Targeting   *flagTargeting `json:"targeting,omitempty"`
+}
+
+type flagTargeting struct {
+	Users   []string `json:"users,omitempty"`
+	Percent *int     `json:"percent,omitempty"`
+}
+
+func (t *flagTargeting) String() string {
+	if t == nil || (len(t.Users) == 0 && t.Percent == nil) {
+		return "everyone"
+	}
+	var parts []string
+	if len(t.Users) > 0 {
+		parts = append(parts, "users "+strings.Join(t.Users, ","))
+	}
+	if t.Percent != nil {
+		parts = append(parts, fmt.Sprintf("%d%% rollout", *t.Percent))
+	}
+	return strings.Join(parts, ", ")
+}
+
+type flagsResponse struct {
+	Environment string        `json:"environment,omitempty"`
+	Flags       []featureFlag `json:"flags"`
+}
+
+// flagChange is one pending update to a flag.
+type flagChange struct {
+	before featureFlag
+	after  featureFlag
+}
+
+func fetchFlags() (*flagsResponse, error) {
+	resp, err := httpClient.Get(baseURL() + "/api/flags")
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == 404 {
+		return nil, fmt.Errorf("the server at %s does not support feature flags", baseURL())
+	}
+	if resp.StatusCode != 200 {
+		return nil, serverError(resp)
+	}
+	var out flagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	sort.Slice(out.Flags, func(i, j int) bool { return out.Flags[i].Key < out.Flags[j].Key })
+	return &out, nil
+}
+
+func runFlagsList(cmd *cobra.Command, args []string) error {
+	state, err := fetchFlags()
+	if err != nil {
+		return err
+	}
+	if jsonFlag {
+		return printJSON(state)
+	}
+
+	fmt.Println()
+	title := styleBold.Render("Feature Flags")
+	if state.Environment != "" {
+		title += styleDim.Render(" (" + state.Environment + ")")
+	}
+	fmt.Println(title)
+	fmt.Println(strings.Repeat("─", 40))
+	if len(state.Flags) == 0 {
+		fmt.Println(styleDim.Render("  No feature flags defined."))
+	}
+	for _, f := range state.Flags {
+		status := styleDim.Render("off")
+		if f.Enabled {
+			status = styleOk.Render("on ")
+		}
+		fmt.Printf("  %s  %-28s %s\n", status, f.Key, styleDim.Render(f.Targeting.String()))
+		if f.Description != "" {
+			fmt.Println(styleDim.Render("       " + f.Description))
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+func setFlagsEnabled(keys []string, enabled bool) error {
+	state, err := fetchFlags()
+	if err != nil {
+		return err
+	}
+	var changes []flagChange
+	for _, key := range keys {
+		f, ok := findFlag(state.Flags, key)
+		if !ok {
+			return fmt.Errorf("unknown flag %q — see ellie flags list", key)
+		}
+		after := f
+		after.Enabled = enabled
+		changes = append(changes, flagChange{before: f, after: after})
+	}
+	return applyFlagChanges(state, changes)
+}
+
+func runFlagsTarget(cmd *cobra.Command, args []string) error {
+	usersSet := cmd.Flags().Changed("users")
+	percentSet := cmd.Flags().Changed("percent")
+	if !flagsClear && !usersSet && !percentSet {
+		return fmt.Errorf("nothing to change — pass --users, --percent or --clear")
+	}
+	if flagsClear && (usersSet || percentSet) {
+		return fmt.Errorf("--clear cannot be combined with --users or --percent")
+	}
+	if percentSet && (flagsPercent < 0 || flagsPercent > 100) {
+		return fmt.Errorf("--percent must be between 0 and 100")
+	}
+
+	state, err := fetchFlags()
+	if err != nil {
+		return err
+	}
+	f, ok := findFlag(state.Flags, args[0])
+	if !ok {
+		return fmt.Errorf("unknown flag %q — see ellie flags list", args[0])
+	}
+
+	after := f
+	if flagsClear {
+		after.Targeting = nil
+	} else {
+		t := flagTargeting{}
+		if f.Targeting != nil {
+			t = *f.Targeting
+		}
+		if usersSet {
+			t.Users = flagsUsers
+		}
+		if percentSet {
+			p := flagsPercent
+			t.Percent = &p
+		}
+		after.Targeting = &t
+	}
+	return applyFlagChanges(state, []flagChange{{before: f, after: after}})
+}
+
+func runFlagsToggle(cmd *cobra.Command, args []string) error {
+	if err := noPrompt("ellie flags toggle", "use ellie flags enable or ellie flags disable"); err != nil {
+		return err
+	}
+	state, err := fetchFlags()
+	if err != nil {
+		return err
+	}
+	if len(state.Flags) == 0 {
+		fmt.Println(styleDim.Render("No feature flags defined."))
+		return nil
+	}
+
+	options := make([]huh.Option[string], 0, len(state.Flags))
+	for _, f := range state.Flags {
+		label := f.Key
+		if f.Description != "" {
+			label += styleDim.Render(" — " + f.Description)
+		}
+		options = append(options, huh.NewOption(label, f.Key).Selected(f.Enabled))
+	}
+	var selected []string
+	err = huh.NewMultiSelect[string]().
+		Title("Which flags should be on?").
+		Options(options...).
+		Value(&selected).
+		Run()
+	if err != nil {
+		return errSilent
+	}
+
+	on := map[string]bool{}
+	for _, k := range selected {
+		on[k] = true
+	}
+	var changes []flagChange
+	for _, f := range state.Flags {
+		if f.Enabled != on[f.Key] {
+			after := f
+			after.Enabled = on[f.Key]
+			changes = append(changes, flagChange{before: f, after: after})
+		}
+	}
+	return applyFlagChanges(state, changes)
+}
+
+func findFlag(flags []featureFlag, key string) (featureFlag, bool) {
+	for _, f := range flags {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return featureFlag{}, false
+}
+
+// applyFlagChanges previews changes as a diff, enforces the production
+// guardrail, confirms and then applies them one flag at a time.
+func applyFlagChanges(state *flagsResponse, changes []flagChange) error {
+	var effective []flagChange
+	for _, c := range changes {
+		if c.before.Enabled != c.after.Enabled || c.before.Targeting.String() != c.after.Targeting.String() {
+			effective = append(effective, c)
+		}
+	}
+	if len(effective) == 0 {
+		fmt.Println(styleDim.Render("No changes."))
+		return nil
+	}
+
+	fmt.Println()
+	printFlagDiff(effective)
+	if flagsDiffOnly {
+		return nil
+	}
+
+	production := strings.EqualFold(state.Environment, "production") || strings.EqualFold(state.Environment, "prod")
+	if production && !flagsProduction {
+		return fmt.Errorf("%s is a production server — re-run with --production to change its flags", baseURL())
+	}
+
+	if !flagsYes {
+		if err := noPrompt("confirming the changes", "pass --yes"); err != nil {
+			return err
+		}
+		title := fmt.Sprintf("Apply %d change(s)?", len(effective))
+		if production {
+			title = fmt.Sprintf("Apply %d change(s) to PRODUCTION (%s)?", len(effective), baseURL())
+		}
+		var confirm bool
+		err := huh.NewConfirm().
+			Title(title).
+			Affirmative("Apply").
+			Negative("Cancel").
+			Value(&confirm).
+			Run()
+		if err != nil || !confirm {
+			fmt.Println("Cancelled.")
+			return errSilent
+		}
+	}
+
+	for i, c := range effective {
+		if err := patchFlag(c.after); err != nil {
+			if i > 0 {
+				fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf("%d of %d change(s) were applied before the failure", i, len(effective))))
+			}
+			return fmt.Errorf("updating %s: %w", c.after.Key, err)
+		}
+		fmt.Println(styleOk.Render("✓"), c.after.Key)
+	}
+	return nil
+}
+
+func printFlagDiff(changes []flagChange) {
+	for _, c := range changes {
+		fmt.Println(styleBold.Render(c.after.Key))
+		if c.before.Enabled != c.after.Enabled {
+			fmt.Println(styleErr.Render(fmt.Sprintf("  - enabled: %t", c.before.Enabled)))
+			fmt.Println(styleOk.Render(fmt.Sprintf("  + enabled: %t", c.after.Enabled)))
+		}
+		if before, after := c.before.Targeting.String(), c.after.Targeting.String(); before != after {
+			fmt.Println(styleErr.Render("  - targeting: " + before))
+			fmt.Println(styleOk.Render("  + targeting: " + after))
+		}
+	}
+	fmt.Println()
+}
+
+func patchFlag(f featureFlag) error {
+	body, _ := json.Marshal(map[string]any{"enabled": f.Enabled, "targeting": f.Targeting})
+	req, err := http.NewRequest(http.MethodPatch, baseURL()+"/api/flags/"+url.PathEscape(f.Key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach server at %s", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return serverError(resp)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/chatui"
+	"ellie/apps/cli/internal/extract"
+	"ellie/apps/cli/internal/sessionstats"
+)
+
+// ── grep-sessions ───────────────────────────────────────────────────────────
+
+var (
+	grepCodeOnly   bool
+	grepLang       string
+	grepFile       string
+	grepIgnoreCase bool
+	grepLimit      int
+)
+
+var grepSessionsCmd = &cobra.Command{
+	Use:   "grep-sessions [pattern]",
+	Short: "Search the messages of every session",
+	Long: `Search the conversations on the server for a regular expression and
+print the matching lines with the session, turn and author they are from.
+
+--code-only searches the fenced code blocks instead and prints each
+matching block whole, ready to copy. Narrow it down with --lang, which
+knows common aliases (ts and typescript, sh and shell), and --file, a
+name or glob matched against the file a block is for — taken from the
+fence (` + "```go title=main.go" + `) or the line introducing it. Either one
+implies --code-only, and then the pattern is optional:
+
+  ellie grep-sessions --code-only 'func parse'
+  ellie grep-sessions --lang sql 'JOIN users'
+  ellie grep-sessions --file '*.tsx'
+
+Only each session's main branch is searched, newest session first.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGrepSessions,
+}
+
+func init() {
+	grepSessionsCmd.Flags().BoolVar(&grepCodeOnly, "code-only", false, "Search only fenced code blocks and print them whole")
+	grepSessionsCmd.Flags().StringVar(&grepLang, "lang", "", "Only code blocks in this language")
+	grepSessionsCmd.Flags().StringVar(&grepFile, "file", "", "Only code blocks for a file matching this name or glob")
+	grepSessionsCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "Match the pattern regardless of case")
+	grepSessionsCmd.Flags().IntVar(&grepLimit, "limit", 0, "Stop after this many matches (0 means no limit)")
+}
+
+// sessionMatch is a matching line, or with --code-only a matching code
+// block, and where it was said.
+type sessionMatch struct {
+	Session string    `json:"session"`
+	Title   string    `json:"title,omitempty"`
+	Branch  string    `json:"branch"`
+	Turn    int       `json:"turn"`
+	Role    string    `json:"role"`
+	Time    time.Time `json:"time"`
+	Lang    string    `json:"lang,omitempty"`
+	File    string    `json:"file,omitempty"`
+	Text    string    `json:"text"`
+}
+
+func runGrepSessions(cmd *cobra.Command, args []string) error {
+	codeOnly := grepCodeOnly || grepLang != "" || grepFile != ""
+	var re *regexp.Regexp
+	switch {
+	case len(args) == 1:
+		expr := args[0]
+		if grepIgnoreCase {
+			expr = "(?i)" + expr
+		}
+		var err error
+		if re, err = regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case !codeOnly:
+		return errors.New("give a pattern to search for, or search code blocks with --lang or --file")
+	}
+
+	threads, err := fetchThreads()
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(threads, func(i, j int) bool { return threads[i].UpdatedAt > threads[j].UpdatedAt })
+
+	matches := []sessionMatch{}
+	sessions := 0
+	for _, t := range threads {
+		branchID, err := mainBranch(t.ID)
+		if err != nil {
+			return err
+		}
+		if branchID == "" {
+			continue
+		}
+		msgs, err := fetchBranchMessages(branchID)
+		if err != nil {
+			return err
+		}
+		found := grepMessages(t, branchID, msgs, re, codeOnly)
+		if len(found) == 0 {
+			continue
+		}
+		if grepLimit > 0 && len(matches)+len(found) > grepLimit {
+			found = found[:grepLimit-len(matches)]
+		}
+		sessions++
+		matches = append(matches, found...)
+		if !jsonFlag {
+			printSessionMatches(found, re, codeOnly)
+		}
+		if grepLimit > 0 && len(matches) >= grepLimit {
+			break
+		}
+	}
+
+	if jsonFlag {
+		return printJSON(matches)
+	}
+	if len(matches) == 0 {
+		fmt.Println(styleDim.Render(fmt.Sprintf("No matches in %d sessions.", len(threads))))
+		return errSilent
+	}
+	fmt.Println(styleDim.Render(fmt.Sprintf("%d match(es) in %d session(s)", len(matches), sessions)))
+	return nil
+}
+
+// grepMessages finds the matches in one session's messages.
+func grepMessages(t chatui.ThreadEntry, branchID string, msgs []sessionstats.Message, re *regexp.Regexp, codeOnly bool) []sessionMatch {
+	var title string
+	if t.Title != nil {
+		title = *t.Title
+	}
+	var found []sessionMatch
+	turn := 0
+	for _, m := range msgs {
+		if m.Role == "user" {
+			turn++
+		}
+		if m.Role != "user" && m.Role != "assistant" {
+			continue
+		}
+		at := sessionMatch{Session: t.ID, Title: title, Branch: branchID, Turn: turn, Role: m.Role, Time: time.UnixMilli(int64(m.Timestamp))}
+		text := m.Text()
+		if !codeOnly {
+			for _, line := range strings.Split(text, "\n") {
+				if re.MatchString(line) {
+					at.Text = strings.TrimSpace(line)
+					found = append(found, at)
+				}
+			}
+			continue
+		}
+		for _, b := range extract.Blocks(text) {
+			if grepLang != "" && !extract.SameLang(b.Lang, grepLang) {
+				continue
+			}
+			if grepFile != "" && !matchFileHint(b.File, grepFile) {
+				continue
+			}
+			if re != nil && !re.MatchString(b.Code) {
+				continue
+			}
+			at.Lang, at.File, at.Text = b.Lang, b.File, b.Code
+			found = append(found, at)
+		}
+	}
+	return found
+}
+
+// matchFileHint matches a code block's file against --file: a glob for
+// the path or its base name, or else a part of the path.
+func matchFileHint(file, pattern string) bool {
+	if file == "" {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern, file); ok {
+		return true
+	}
+	if ok, _ := filepath.Match(pattern, filepath.Base(file)); ok {
+		return true
+	}
+	return strings.Contains(file, pattern)
+}
+
+// printSessionMatches prints one session's matches under its title.
+func printSessionMatches(found []sessionMatch, re *regexp.Regexp, codeOnly bool) {
+	highlight := func(s string) string {
+		if re == nil {
+			return s
+		}
+		return re.ReplaceAllStringFunc(s, func(m string) string { return styleBold.Render(m) })
+	}
+
+	header := styleBold.Render(found[0].Session)
+	if found[0].Title != "" {
+		header += " — " + found[0].Title
+	}
+	fmt.Println(header)
+	for _, m := range found {
+		where := fmt.Sprintf("turn %d, %s, %s", m.Turn, m.Role, m.Time.Local().Format("2006-01-02 15:04"))
+		if !codeOnly {
+			fmt.Printf("  %s  %s\n", styleDim.Render(fmt.Sprintf("%-30s", where)), highlight(truncate(m.Text, 160)))
+			continue
+		}
+		switch {
+		case m.File != "":
+			where = m.File + " · " + where
+		case m.Lang != "":
+			where = m.Lang + " · " + where
+		}
+		fmt.Println(styleDim.Render("  ── " + where))
+		for _, line := range strings.Split(m.Text, "\n") {
+			fmt.Println("    " + highlight(line))
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/jobs"
+	"ellie/apps/cli/internal/paths"
+)
+
+// ── jobs ────────────────────────────────────────────────────────────────────
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Manage background batch jobs",
+	Long: `Manage batch runs started with ellie batch --detach. Jobs run in a
+background process on this machine and keep going after the terminal
+closes; their state is kept in the CLI's state directory. A job ID may
+be shortened to any unique prefix.`,
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List batch jobs",
+	Args:  cobra.NoArgs,
+	RunE:  runJobsList,
+}
+
+var jobsStatusCmd = &cobra.Command{
+	Use:   "status <id>",
+	Short: "Show a job's progress and recent failures",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobsStatus,
+}
+
+var jobsAttachCmd = &cobra.Command{
+	Use:   "attach <id>",
+	Short: "Follow a job's progress until it finishes",
+	Long: `Print each result as the job produces it, until the job finishes.
+Ctrl-C stops following; the job keeps running.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobsAttach,
+}
+
+var jobsCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Stop a running job",
+	Long: `Stop a running job. Prompts already answered are kept, and
+ellie jobs resume runs the rest later.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobsCancel,
+}
+
+var jobsResumeCmd = &cobra.Command{
+	Use:   "resume <id>",
+	Short: "Run the unfinished prompts of a stopped job",
+	Long: `Start a new runner for a job that was cancelled or interrupted — by a
+reboot, say — or that finished with failures. Prompts that already have
+an answer are skipped; failed ones are tried again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobsResume,
+}
+
+// jobsRunCmd is the background runner that batch --detach and jobs
+// resume start.
+var jobsRunCmd = &cobra.Command{
+	Use:    "run <id>",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE:   runJobsRun,
+}
+
+func jobStore() (jobs.Store, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return jobs.Store{}, err
+	}
+	return jobs.Store{Dir: filepath.Join(dir, "jobs")}, nil
+}
+
+func loadJob(id string) (jobs.Store, *jobs.Job, error) {
+	store, err := jobStore()
+	if err != nil {
+		return store, nil, err
+	}
+	job, err := store.Load(id)
+	if errors.Is(err, jobs.ErrNotFound) {
+		return store, nil, fmt.Errorf("no job %s — see 'ellie jobs list'", id)
+	}
+	return store, job, err
+}
+
+// jobCounts returns how many of the job's prompts succeeded and failed.
+func jobCounts(job *jobs.Job) (ok, failed int, results map[string]jobs.Result) {
+	results, _ = jobs.ReadResults(job.Output)
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		} else {
+			ok++
+		}
+	}
+	return ok, failed, results
+}
+
+func styleJobState(state string) string {
+	switch state {
+	case jobs.StatusRunning, jobs.StatusDone:
+		return styleOk.Render(state)
+	case jobs.StatusFailed, jobs.StatusInterrupted:
+		return styleErr.Render(state)
+	}
+	return styleDim.Render(state)
+}
+
+func runJobsList(cmd *cobra.Command, args []string) error {
+	store, err := jobStore()
+	if err != nil {
+		return err
+	}
+	all, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if jsonFlag {
+		type listed struct {
+			*jobs.Job
+			State  string `json:"state"`
+			OK     int    `json:"ok"`
+			Failed int    `json:"failed"`
+		}
+		out := []listed{}
+		for _, j := range all {
+			ok, failed, _ := jobCounts(j)
+			out = append(out, listed{j, j.State(processAlive), ok, failed})
+		}
+		return printJSON(out)
+	}
+
+	fmt.Println(styleBold.Render("Batch Jobs"))
+	fmt.Println(strings.Repeat("─", 40))
+	if len(all) == 0 {
+		fmt.Println(styleDim.Render("No jobs. Start one with ellie batch --detach."))
+		return nil
+	}
+	for _, j := range all {
+		ok, failed, _ := jobCounts(j)
+		state := j.State(processAlive)
+		progress := fmt.Sprintf("%d/%d", ok+failed, j.Total)
+		if failed > 0 {
+			progress += fmt.Sprintf(" (%d failed)", failed)
+		}
+		fmt.Printf("%s  %s%*s  %-18s  %s\n", j.ID, styleJobState(state), 11-len(state), "", progress,
+			styleDim.Render(formatDuration(time.Since(j.Created))+" · "+filepath.Base(j.Input)))
+	}
+	return nil
+}
+
+func runJobsStatus(cmd *cobra.Command, args []string) error {
+	_, job, err := loadJob(args[0])
+	if err != nil {
+		return err
+	}
+	ok, failed, results := jobCounts(job)
+	state := job.State(processAlive)
+
+	fmt.Println(styleBold.Render("Job " + job.ID))
+	fmt.Println(strings.Repeat("─", 40))
+	fmt.Printf("  Status:    %s\n", styleJobState(state))
+	fmt.Printf("  Progress:  %d/%d done, %d failed\n", ok+failed, job.Total, failed)
+	fmt.Printf("  Prompts:   %s\n", job.Input)
+	fmt.Printf("  Results:   %s\n", job.Output)
+	fmt.Printf("  Server:    %s\n", job.Server)
+	fmt.Printf("  Parallel:  %d\n", job.Parallel)
+	fmt.Printf("  Started:   %s\n", job.Created.Local().Format("2006-01-02 15:04:05"))
+	if !job.Finished.IsZero() {
+		fmt.Printf("  Finished:  %s (took %s)\n", job.Finished.Local().Format("2006-01-02 15:04:05"), formatUptime(job.Finished.Sub(job.Created)))
+	}
+	if state == jobs.StatusRunning {
+		fmt.Printf("  Runner:    pid %d\n", job.PID)
+	}
+	if job.Error != "" {
+		fmt.Printf("  Error:     %s\n", styleErr.Render(job.Error))
+	}
+
+	if failed > 0 {
+		fmt.Println()
+		fmt.Println(styleBold.Render("Failures"))
+		shown := 0
+		for _, r := range results {
+			if r.Error == "" {
+				continue
+			}
+			if shown == 5 {
+				fmt.Println(styleDim.Render(fmt.Sprintf("  … and %d more", failed-shown)))
+				break
+			}
+			fmt.Printf("  %s  %s\n", r.ID, styleDim.Render(truncate(r.Error, 100)))
+			shown++
+		}
+	}
+	switch state {
+	case jobs.StatusInterrupted, jobs.StatusCancelled:
+		fmt.Println()
+		fmt.Println(styleDim.Render("Run the remaining prompts with: ellie jobs resume " + job.ID))
+	case jobs.StatusDone, jobs.StatusFailed:
+		if failed > 0 {
+			fmt.Println()
+			fmt.Println(styleDim.Render("Retry the failed prompts with: ellie jobs resume " + job.ID))
+		}
+	}
+	return nil
+}
+
+func runJobsAttach(cmd *cobra.Command, args []string) error {
+	store, job, err := loadJob(args[0])
+	if err != nil {
+		return err
+	}
+	ok, failed, _ := jobCounts(job)
+	done := ok + failed
+	if state := job.State(processAlive); state != jobs.StatusRunning {
+		fmt.Printf("Job %s is %s (%d/%d done, %d failed)\n", job.ID, state, done, job.Total, failed)
+		return nil
+	}
+	fmt.Println(styleDim.Render(fmt.Sprintf("Following job %s — %d/%d done. Ctrl-C stops following; the job keeps running.", job.ID, done, job.Total)))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var offset int64
+	if info, err := os.Stat(job.Output); err == nil {
+		offset = info.Size()
+	}
+	var partial []byte
+	tick := time.NewTicker(500 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return nil
+		case <-tick.C:
+		}
+
+		if data, err := readFrom(job.Output, offset); err == nil && len(data) > 0 {
+			offset += int64(len(data))
+			lines := bytes.Split(append(partial, data...), []byte("\n"))
+			partial = lines[len(lines)-1]
+			for _, line := range lines[:len(lines)-1] {
+				var r jobs.Result
+				if json.Unmarshal(line, &r) == nil && r.ID != "" {
+					done++
+					printBatchProgress(os.Stdout, min(done, job.Total), job.Total, r)
+				}
+			}
+		}
+
+		if job, err = store.Load(job.ID); err != nil {
+			return err
+		}
+		if state := job.State(processAlive); state != jobs.StatusRunning {
+			ok, failed, _ := jobCounts(job)
+			fmt.Printf("Job %s %s: %d/%d done, %d failed\n", job.ID, styleJobState(state), ok+failed, job.Total, failed)
+			return nil
+		}
+	}
+}
+
+// readFrom returns the contents of path from offset on.
+func readFrom(path string, offset int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, 0); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(f)
+	return buf.Bytes(), err
+}
+
+func runJobsCancel(cmd *cobra.Command, args []string) error {
+	store, job, err := loadJob(args[0])
+	if err != nil {
+		return err
+	}
+	if state := job.State(processAlive); state != jobs.StatusRunning {
+		fmt.Printf("Job %s is not running (%s).\n", job.ID, state)
+		return nil
+	}
+	if err := stopProcess(job.PID, 30*time.Second); err != nil {
+		return err
+	}
+	// A runner that had to be killed couldn't record the cancellation.
+	if job, err = store.Load(job.ID); err == nil && job.Status == jobs.StatusRunning {
+		job.Status, job.Finished = jobs.StatusCancelled, time.Now().UTC()
+		store.Save(job)
+	}
+	ok, failed, _ := jobCounts(job)
+	fmt.Println(styleOk.Render("✓"), "Cancelled job", job.ID, styleDim.Render(fmt.Sprintf("(%d/%d done)", ok+failed, job.Total)))
+	fmt.Println(styleDim.Render("  Run the rest later with: ellie jobs resume " + job.ID))
+	return nil
+}
+
+func runJobsResume(cmd *cobra.Command, args []string) error {
+	store, job, err := loadJob(args[0])
+	if err != nil {
+		return err
+	}
+	if job.State(processAlive) == jobs.StatusRunning {
+		return fmt.Errorf("job %s is still running — follow it with 'ellie jobs attach %s'", job.ID, job.ID)
+	}
+	items, err := jobs.ReadItems(store.InputPath(job.ID))
+	if err != nil {
+		return err
+	}
+	results, err := jobs.ReadResults(job.Output)
+	if err != nil {
+		return err
+	}
+	pending := jobs.Pending(items, results)
+	if len(pending) == 0 {
+		fmt.Printf("Job %s has nothing left to run.\n", job.ID)
+		return nil
+	}
+
+	job.Status, job.Error, job.PID, job.Finished = jobs.StatusRunning, "", 0, time.Time{}
+	if err := store.Save(job); err != nil {
+		return err
+	}
+	if err := spawnJobRunner(store, job); err != nil {
+		job.Status, job.Error, job.Finished = jobs.StatusFailed, err.Error(), time.Now().UTC()
+		store.Save(job)
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Resumed job", styleBold.Render(job.ID), styleDim.Render(fmt.Sprintf("(%d prompts left)", len(pending))))
+	fmt.Println(styleDim.Render("  Follow: ellie jobs attach " + job.ID))
+	return nil
+}
+
+// spawnJobRunner starts `ellie jobs run` for job, detached from the
+// terminal with its output going to the job's log, and waits for it to
+// take the job.
+func spawnJobRunner(store jobs.Store, job *jobs.Job) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate ellie executable: %w", err)
+	}
+	logf, err := os.OpenFile(store.LogPath(job.ID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer logf.Close()
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+
+	child := exec.Command(self, "jobs", "run", job.ID)
+	child.Stdin, child.Stdout, child.Stderr = devNull, logf, logf
+	child.SysProcAttr = detachAttr()
+	logCommand(child)
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("cannot start background runner: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- child.Wait() }()
+
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case <-exited:
+			if j, err := store.Load(job.ID); err == nil && j.PID != 0 {
+				return nil // already finished
+			}
+			return fmt.Errorf("background runner exited during startup — see %s", store.LogPath(job.ID))
+		case <-deadline:
+			return fmt.Errorf("background runner did not start — see %s", store.LogPath(job.ID))
+		case <-time.After(100 * time.Millisecond):
+		}
+		if j, err := store.Load(job.ID); err == nil && j.PID == child.Process.Pid {
+			*job = *j
+			return nil
+		}
+	}
+}
+
+func runJobsRun(cmd *cobra.Command, args []string) error {
+	store, job, err := loadJob(args[0])
+	if err != nil {
+		return err
+	}
+	if job.PID != 0 && job.PID != os.Getpid() && job.State(processAlive) == jobs.StatusRunning {
+		return fmt.Errorf("job %s already has a runner (pid %d)", job.ID, job.PID)
+	}
+	job.PID, job.Status = os.Getpid(), jobs.StatusRunning
+	if err := store.Save(job); err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		job.Status, job.Error, job.Finished = jobs.StatusFailed, err.Error(), time.Now().UTC()
+		store.Save(job)
+		return err
+	}
+
+	post, err := newPostProcessor(job.Extract, job.JQ)
+	if err != nil {
+		return fail(err)
+	}
+	items, err := jobs.ReadItems(store.InputPath(job.ID))
+	if err != nil {
+		return fail(err)
+	}
+	results, err := jobs.ReadResults(job.Output)
+	if err != nil {
+		return fail(err)
+	}
+	pending := jobs.Pending(items, results)
+	out, err := os.OpenFile(job.Output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fail(err)
+	}
+	defer out.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	fmt.Printf("%s runner %d: %d of %d prompts to run on %s\n", time.Now().Format(time.RFC3339), job.PID, len(pending), len(items), job.Server)
+	done := len(items) - len(pending)
+	runBatchItems(ctx, job.Server, pending, max(job.Parallel, 1), post, out, func(r jobs.Result) {
+		done++
+		printBatchProgress(os.Stdout, done, len(items), r)
+	})
+
+	job.Status, job.Finished = jobs.StatusDone, time.Now().UTC()
+	if ctx.Err() != nil {
+		job.Status = jobs.StatusCancelled
+	}
+	fmt.Printf("%s runner %d: %s\n", time.Now().Format(time.RFC3339), job.PID, job.Status)
+	return store.Save(job)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/sso"
+)
+
+// ── login / logout ──────────────────────────────────────────────────────────
+
+var (
+	loginSSO       bool
+	loginIssuer    string
+	loginClientID  string
+	loginNoBrowser bool
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Sign in to the ellie server with your organization's SSO",
+	Long: `Sign in to the ellie server with your organization's identity
+provider (OpenID Connect), using the device flow: ellie shows a code,
+you confirm it in the browser, and the CLI keeps the resulting session.
+
+Every request the CLI sends to that server then carries the session
+token. Sessions are stored per server in the CLI's state directory and are
+renewed automatically while the identity provider allows it; when one
+can't be renewed, commands tell you to sign in again.
+
+The identity provider is read from the server (GET /api/auth/sso). For
+servers that don't publish it, pass --issuer and --client-id, or set
+ELLIE_SSO_ISSUER and ELLIE_SSO_CLIENT_ID.
+
+This signs you in to the ellie server itself. Provider credentials for
+the server (Anthropic, Groq, ...) are managed with ellie auth.`,
+	Args: cobra.NoArgs,
+	RunE: runLogin,
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Sign out of the ellie server",
+	Long:  `Remove the SSO session saved by ellie login --sso for the configured server.`,
+	Args:  cobra.NoArgs,
+	RunE:  runLogout,
+}
+
+func init() {
+	loginCmd.Flags().BoolVar(&loginSSO, "sso", false, "Sign in through the organization's identity provider")
+	loginCmd.Flags().StringVar(&loginIssuer, "issuer", os.Getenv("ELLIE_SSO_ISSUER"), "OpenID Connect issuer URL (default: from the server)")
+	loginCmd.Flags().StringVar(&loginClientID, "client-id", os.Getenv("ELLIE_SSO_CLIENT_ID"), "OAuth client ID of the CLI (default: from the server)")
+	loginCmd.Flags().BoolVar(&loginNoBrowser, "no-browser", false, "Print the sign-in URL instead of opening a browser")
+}
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	if !loginSSO {
+		return fmt.Errorf("ellie login currently supports only --sso (for provider credentials, use ellie auth)")
+	}
+	base := baseURL()
+	origin := serverOrigin(base)
+
+	cfg, err := ssoConfig(base)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	idp := &http.Client{Timeout: 30 * time.Second}
+	client, err := sso.New(ctx, cfg, idp)
+	if err != nil {
+		return err
+	}
+	dc, err := client.StartDevice(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot start sign-in: %w", err)
+	}
+
+	link := dc.VerificationURIComplete
+	if link == "" {
+		link = dc.VerificationURI
+	}
+	fmt.Println()
+	fmt.Println("  Sign in to", styleBold.Render(base), "at:")
+	fmt.Println()
+	fmt.Println("    " + link)
+	fmt.Println()
+	fmt.Println("  and confirm the code", styleBold.Render(dc.UserCode))
+	fmt.Println()
+	if !loginNoBrowser {
+		if err := openBrowser(link); err != nil {
+			fmt.Println(styleDim.Render("  Could not open a browser — open the link above yourself."))
+		}
+	}
+	fmt.Println(styleDim.Render("  Waiting for sign-in... (Ctrl+C to cancel)"))
+
+	tok, err := client.Poll(ctx, dc)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return errSilent
+	case errors.Is(err, sso.ErrDenied), errors.Is(err, sso.ErrExpired):
+		return fmt.Errorf("%w — run ellie login --sso to try again", err)
+	case err != nil:
+		return fmt.Errorf("sign-in failed: %w", err)
+	}
+
+	session := sso.NewSession(origin, client, tok)
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	sessions, err := loadSessions()
+	if err != nil {
+		return err
+	}
+	sessions.Servers[origin] = session
+	if err := sessions.Save(); err != nil {
+		return fmt.Errorf("signed in, but cannot save the session: %w", err)
+	}
+
+	who := session.User
+	if who == "" {
+		who = "your SSO account"
+	}
+	fmt.Println()
+	fmt.Println(styleOk.Render("✓"), "Signed in to", base, "as", styleBold.Render(who))
+	if !session.ExpiresAt.IsZero() && session.RefreshToken == "" {
+		fmt.Println(styleDim.Render("  The session expires " + session.ExpiresAt.Local().Format("Jan 2 15:04") + " and can't be renewed automatically."))
+	}
+	return nil
+}
+
+// ssoConfig returns the identity provider to sign in with: the flags, filled
+// in from the server's published configuration.
+func ssoConfig(base string) (sso.Config, error) {
+	cfg := sso.Config{Issuer: loginIssuer, ClientID: loginClientID}
+	if cfg.Issuer != "" && cfg.ClientID != "" {
+		return cfg, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, base+"/api/auth/sso", nil)
+	if err != nil {
+		return cfg, err
+	}
+	// Ask anonymously: a stale session must not block signing in again.
+	// connectTransport, under the session layer, still has the socket,
+	// proxy and client certificate.
+	resp, err := (&http.Client{Timeout: 10 * time.Second, Transport: connectTransport}).Do(req)
+	if err != nil {
+		return cfg, fmt.Errorf("cannot reach server at %s: %w", base, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return cfg, fmt.Errorf("the server at %s doesn't publish an SSO configuration — pass --issuer and --client-id", base)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return cfg, serverError(resp)
+	}
+	var published sso.Config
+	if err := json.NewDecoder(resp.Body).Decode(&published); err != nil {
+		return cfg, fmt.Errorf("invalid SSO configuration from server: %w", err)
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = published.Issuer
+	}
+	if cfg.ClientID == "" {
+		cfg.ClientID = published.ClientID
+	}
+	cfg.Scopes, cfg.Audience = published.Scopes, published.Audience
+	if cfg.Issuer == "" || cfg.ClientID == "" {
+		return cfg, fmt.Errorf("the server's SSO configuration is incomplete — pass --issuer and --client-id")
+	}
+	return cfg, nil
+}
+
+func runLogout(cmd *cobra.Command, args []string) error {
+	base := baseURL()
+	origin := serverOrigin(base)
+
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	sessions, err := loadSessions()
+	if err != nil {
+		return err
+	}
+	if _, ok := sessions.Servers[origin]; !ok {
+		fmt.Println(styleDim.Render("Not signed in to " + base))
+		return nil
+	}
+	delete(sessions.Servers, origin)
+	if err := sessions.Save(); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Signed out of", base)
+	return nil
+}
+
+// currentSession returns the SSO session for base, or nil.
+func currentSession(base string) *sso.Session {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	sessions, err := loadSessions()
+	if err != nil {
+		return nil
+	}
+	return sessions.Servers[serverOrigin(base)]
+}
+
+// sessionSummary describes the SSO session for base, for ellie status, or
+// returns "" when there is none.
+func sessionSummary(base string) string {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	sessions, err := loadSessions()
+	if err != nil {
+		return styleErr.Render("unreadable") + styleDim.Render(" ("+err.Error()+")")
+	}
+	s := sessions.Servers[serverOrigin(base)]
+	if s == nil {
+		return ""
+	}
+	who := s.User
+	if who == "" {
+		who = "signed in"
+	}
+	switch {
+	case s.Expired(time.Now()) && s.RefreshToken == "":
+		return styleErr.Render("expired") + styleDim.Render(" ("+who+") — run ellie login --sso")
+	case s.ExpiresAt.IsZero() || s.RefreshToken != "":
+		return styleOk.Render("✓") + " " + who
+	}
+	return styleOk.Render("✓") + " " + who + styleDim.Render(" (expires in "+formatUptime(time.Until(s.ExpiresAt))+")")
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/logfilter"
+)
+
+var (
+	logsFollow bool
+	logsApp    string
+	logsSince  time.Duration
+	logsSource string
+	logsLines  int
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show output captured from ellie dev / ellie start",
+	Long: `Show the output ellie dev and ellie start captured, including that of
+servers running in the background.
+
+Each log is rotated once it grows past logs.max_size megabytes (10 by
+default); logs.keep rotated files are kept (5), and with logs.max_age
+those older are deleted too. ellie logs reads the rotated files first,
+and --follow carries on across a rotation.`,
+	RunE: runLogs,
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing new output as it arrives")
+	logsCmd.Flags().StringVar(&logsApp, "app", "", "Only show lines from this app (e.g. web, server)")
+	logsCmd.Flags().DurationVar(&logsSince, "since", 0, "Only show lines newer than this (e.g. 10m, 1h)")
+	logsCmd.Flags().StringVar(&logsSource, "source", "", "Log to read: dev or start (default: the daemon's, else the most recently written)")
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 200, "Number of lines to show before following (0 = all)")
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	path, err := resolveLogSource(logsSource)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no logs yet — run ellie dev or ellie start first")
+		}
+		return fmt.Errorf("cannot open log: %w", err)
+	}
+	defer f.Close()
+
+	var cutoff time.Time
+	if logsSince > 0 {
+		cutoff = time.Now().Add(-logsSince)
+	}
+	match := func(rec logRecord) bool {
+		if !cutoff.IsZero() && rec.Time.Before(cutoff) {
+			return false
+		}
+		return logsApp == "" || logfilter.MatchApp([]string{logsApp}, rec.App)
+	}
+
+	// Initial backlog, keeping only the last N matches.
+	var backlog []logRecord
+	if err := readLogHistory(f, func(rec logRecord) {
+		if !match(rec) {
+			return
+		}
+		backlog = append(backlog, rec)
+		if logsLines > 0 && len(backlog) > logsLines {
+			backlog = backlog[1:]
+		}
+	}); err != nil {
+		return fmt.Errorf("read log: %w", err)
+	}
+	for _, rec := range backlog {
+		printLogRecord(rec)
+	}
+
+	if !logsFollow {
+		return nil
+	}
+	return followLog(f, match)
+}
+
+// followLog polls f for appended records until interrupted. When the log
+// is rotated, what was left of it is read and then the new file from the
+// beginning; if it shrinks (truncated), reading restarts from the
+// beginning too.
+func followLog(f *os.File, match func(logRecord) bool) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	lw := &lineWriter{onLine: func(line string) {
+		if rec, ok := parseLogRecord(line); ok && match(rec) {
+			printLogRecord(rec)
+		}
+	}}
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sigCh:
+			return nil
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(f.Name())
+		if err != nil {
+			continue // rotated away; wait for it to reappear
+		}
+		if cur, err := f.Stat(); err == nil && !os.SameFile(cur, info) {
+			offset = readRest(f, offset, lw)
+			reopened, err := os.Open(f.Name())
+			if err != nil {
+				continue
+			}
+			f.Close()
+			f, offset = reopened, 0
+		} else if info.Size() < offset {
+			reopened, err := os.Open(f.Name())
+			if err != nil {
+				continue
+			}
+			f.Close()
+			f, offset = reopened, 0
+		}
+
+		offset = readRest(f, offset, lw)
+	}
+}
+
+// readRest writes what f holds past offset to w and returns the new
+// offset.
+func readRest(f *os.File, offset int64, w io.Writer) int64 {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.ReadAt(buf, offset)
+		if n > 0 {
+			offset += int64(n)
+			_, _ = w.Write(buf[:n])
+		}
+		if err != nil || n == 0 {
+			return offset
+		}
+	}
+}
+
+// resolveLogSource picks the requested stream, the running daemon's log,
+// or whichever managed log was written most recently.
+func resolveLogSource(source string) (string, error) {
+	if source == "" {
+		if st := daemonStatus(); st != nil && st.Log != "" {
+			return st.Log, nil
+		}
+	}
+	if source != "" {
+		if source != "dev" && source != "start" {
+			return "", fmt.Errorf("invalid --source %q: must be dev or start", source)
+		}
+		return logPath(source)
+	}
+
+	var newest string
+	var newestMod time.Time
+	for _, s := range []string{"dev", "start"} {
+		p, err := logPath(s)
+		if err != nil {
+			return "", err
+		}
+		if info, err := os.Stat(p); err == nil && info.ModTime().After(newestMod) {
+			newest, newestMod = p, info.ModTime()
+		}
+	}
+	if newest == "" {
+		return logPath("dev")
+	}
+	return newest, nil
+}
+
+// appPalette colors app prefixes so interleaved output stays readable.
+var appPalette = []string{"#00A66D", "#3B82F6", "#F59E0B", "#EC4899", "#8B5CF6", "#14B8A6", "#F97316"}
+
+func appStyle(app string) lipgloss.Style {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(app))
+	color := appPalette[h.Sum32()%uint32(len(appPalette))]
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+}
+
+func printLogRecord(rec logRecord) {
+	ts := styleDim.Render(rec.Time.Local().Format("15:04:05"))
+	app := appStyle(rec.App).Render(fmt.Sprintf("%-10s", strings.TrimSpace(rec.App)))
+	fmt.Printf("%s %s %s\n", ts, app, rec.Text)
+}
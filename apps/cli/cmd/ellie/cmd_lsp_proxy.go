@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/chatui"
+)
+
+var lspProxyListen string
+
+var lspProxyCmd = &cobra.Command{
+	Use:   "lsp-proxy",
+	Short: "JSON-RPC endpoint for editor plugins",
+	Long: `Serve a minimal JSON-RPC 2.0 endpoint that editor plugins can call to
+ask, summarize or review diffs through this CLI's server connection, so
+plugins don't need to handle credentials themselves.
+
+Messages use LSP-style framing (Content-Length headers), so existing
+LSP client libraries can talk to it. By default it speaks over
+stdin/stdout; use --listen to accept connections on a loopback port.
+
+Methods:
+  initialize                        → { serverInfo, methods }
+  ellie/ask        { prompt, context?, file? }
+  ellie/summarize  { text, file? }
+  ellie/reviewDiff { diff }
+  shutdown, exit, $/cancelRequest
+
+The ellie/* methods return { role, content, model, provider,
+promptTokens, completionTokens, totalCost, stopReason }.`,
+	Args: cobra.NoArgs,
+	RunE: runLSPProxy,
+}
+
+func init() {
+	lspProxyCmd.Flags().StringVar(&lspProxyListen, "listen", "", "Listen on a loopback address (e.g. 127.0.0.1:7777) instead of stdio")
+}
+
+// lspMethods are the ellie/* methods the endpoint serves.
+var lspMethods = []string{"ellie/ask", "ellie/summarize", "ellie/reviewDiff"}
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcRequestFailed  = -32000
+	rpcCancelled      = -32800
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"` // "null" is kept: success must carry a result
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func runLSPProxy(cmd *cobra.Command, args []string) error {
+	base := requireBaseURL()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if lspProxyListen == "" {
+		s := newRPCSession(base, os.Stdin, os.Stdout)
+		return s.serve(ctx)
+	}
+
+	host, _, err := net.SplitHostPort(lspProxyListen)
+	if err != nil {
+		return fmt.Errorf("invalid --listen address: %w", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("--listen must be a loopback address — the endpoint acts with this machine's credentials")
+	}
+
+	ln, err := net.Listen("tcp", lspProxyListen)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", lspProxyListen, err)
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	fmt.Fprintln(os.Stderr, styleOk.Render("✓"), "Editor endpoint listening on", styleBold.Render(ln.Addr().String()))
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			_ = newRPCSession(base, conn, conn).serve(ctx)
+		}()
+	}
+}
+
+// lspAgentMu serializes agent calls across all editor connections: they
+// share the assistant branch, and interleaved turns would mix their events.
+var lspAgentMu sync.Mutex
+
+// rpcSession serves one editor connection. Requests are handled
+// concurrently so cancel/shutdown stay responsive.
+type rpcSession struct {
+	base string
+	in   *bufio.Reader
+	out  io.Writer
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc
+}
+
+func newRPCSession(base string, in io.Reader, out io.Writer) *rpcSession {
+	return &rpcSession{
+		base:     base,
+		in:       bufio.NewReader(in),
+		out:      out,
+		inflight: map[string]context.CancelFunc{},
+	}
+}
+
+func (s *rpcSession) serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		body, err := readRPCFrame(s.in)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.reply(nil, nil, &rpcError{Code: rpcParseError, Message: err.Error()})
+			continue
+		}
+		if req.Method == "" {
+			s.reply(req.ID, nil, &rpcError{Code: rpcInvalidRequest, Message: "missing method"})
+			continue
+		}
+
+		switch req.Method {
+		case "exit":
+			return nil
+		case "$/cancelRequest":
+			var p struct {
+				ID json.RawMessage `json:"id"`
+			}
+			if json.Unmarshal(req.Params, &p) == nil {
+				s.cancel(p.ID)
+			}
+			continue
+		}
+
+		reqCtx, reqCancel := context.WithCancel(ctx)
+		s.track(req.ID, reqCancel)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.untrack(req.ID)
+			result, rerr := s.dispatch(reqCtx, req)
+			if req.ID != nil {
+				s.reply(req.ID, result, rerr)
+			}
+		}()
+	}
+}
+
+func (s *rpcSession) dispatch(ctx context.Context, req rpcRequest) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"serverInfo": map[string]string{"name": "ellie"},
+			"methods":    lspMethods,
+		}, nil
+	case "shutdown":
+		return nil, nil
+
+	case "ellie/ask":
+		var p struct {
+			Prompt  string `json:"prompt"`
+			Context string `json:"context"`
+			File    string `json:"file"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil || strings.TrimSpace(p.Prompt) == "" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "ellie/ask requires a non-empty prompt"}
+		}
+		prompt := p.Prompt
+		if p.Context != "" {
+			prompt = fmt.Sprintf("%s\n\n%s", p.Prompt, fenced(p.File, p.Context))
+		}
+		return s.runAgent(ctx, prompt)
+
+	case "ellie/summarize":
+		var p struct {
+			Text string `json:"text"`
+			File string `json:"file"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil || strings.TrimSpace(p.Text) == "" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "ellie/summarize requires non-empty text"}
+		}
+		return s.runAgent(ctx, "Summarize the following concisely.\n\n"+fenced(p.File, p.Text))
+
+	case "ellie/reviewDiff":
+		var p struct {
+			Diff string `json:"diff"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil || strings.TrimSpace(p.Diff) == "" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "ellie/reviewDiff requires a non-empty diff"}
+		}
+		return s.runAgent(ctx, "Review this diff. Point out bugs, risky changes and missing tests, "+
+			"citing file and line where possible.\n\n```diff\n"+p.Diff+"\n```")
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: "unknown method " + req.Method}
+}
+
+func (s *rpcSession) runAgent(ctx context.Context, prompt string) (any, *rpcError) {
+	lspAgentMu.Lock()
+	defer lspAgentMu.Unlock()
+	if ctx.Err() != nil {
+		return nil, &rpcError{Code: rpcCancelled, Message: "request cancelled"}
+	}
+
+	client := chatui.NewHTTPClient(s.base)
+	current, err := client.GetAssistantCurrent(ctx)
+	if err != nil {
+		return nil, &rpcError{Code: rpcRequestFailed, Message: fmt.Sprintf("cannot reach server at %s: %v", s.base, err)}
+	}
+
+	result, err := chatui.RunOneShot(ctx, chatui.OneShotConfig{BaseURL: s.base, BranchID: current.BranchID, Format: "json"}, prompt)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, &rpcError{Code: rpcCancelled, Message: "request cancelled"}
+		}
+		return nil, &rpcError{Code: rpcRequestFailed, Message: err.Error()}
+	}
+	if result.Error != "" && result.Content == "" {
+		return nil, &rpcError{Code: rpcRequestFailed, Message: result.Error}
+	}
+	return result, nil
+}
+
+func (s *rpcSession) reply(id json.RawMessage, result any, rerr *rpcError) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	resp := rpcResponse{JSONRPC: "2.0", ID: id, Error: rerr}
+	if rerr == nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &rpcError{Code: rpcRequestFailed, Message: err.Error()}
+		} else {
+			resp.Result = raw
+		}
+	}
+	data, _ := json.Marshal(resp)
+	s.write(data)
+}
+
+func (s *rpcSession) write(body []byte) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(body))
+	_, _ = s.out.Write(body)
+}
+
+func (s *rpcSession) track(id json.RawMessage, cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id != nil {
+		s.inflight[string(id)] = cancel
+	}
+}
+
+func (s *rpcSession) untrack(id json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.inflight[string(id)]; ok {
+		cancel()
+		delete(s.inflight, string(id))
+	}
+}
+
+func (s *rpcSession) cancel(id json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.inflight[string(id)]; ok {
+		cancel()
+	}
+}
+
+// readRPCFrame reads one Content-Length framed message body.
+func readRPCFrame(r *bufio.Reader) ([]byte, error) {
+	headers, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if len(headers) == 0 && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("read headers: %w", err)
+	}
+	n, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("missing or invalid Content-Length")
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	return body, nil
+}
+
+// fenced wraps text in a code fence labelled with its file name.
+func fenced(file, text string) string {
+	if file == "" {
+		return "```\n" + text + "\n```"
+	}
+	return file + ":\n```\n" + text + "\n```"
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/chatui"
+)
+
+// ── models ──────────────────────────────────────────────────────────────────
+
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "List the models the server can answer with",
+	Long: `The models the server can answer with, per provider. ellie chat and
+ellie ask ask for the one --model names, or else default_model, by id or
+provider/id:
+
+  ellie config set default_model anthropic/claude-sonnet-4
+  ellie ask -m gpt-4o "summarize this diff" < change.diff
+
+A model the server doesn't list is refused before anything is sent.
+Servers that don't list their models report only the one they use, and
+any model can be asked for.`,
+}
+
+var modelsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the available models per provider",
+	Args:  cobra.NoArgs,
+	RunE:  runModelsList,
+}
+
+func init() {
+	jsonCommands[modelsListCmd] = true
+}
+
+// listModels returns the server's models. partial is set when the server
+// doesn't list them, and only the model it uses is known.
+func listModels(client *chatui.HTTPClient) (models []chatui.ModelInfo, partial bool, err error) {
+	ctx := context.Background()
+	models, err = client.ListModels(ctx)
+	if !errors.Is(err, chatui.ErrModelsUnavailable) {
+		return models, false, err
+	}
+	caps, err := client.GetModelCapabilities(ctx)
+	if errors.Is(err, chatui.ErrCapabilitiesUnavailable) {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	return []chatui.ModelInfo{{ID: caps.ID, Provider: caps.Provider, ContextWindow: caps.ContextWindow, Current: true}}, true, nil
+}
+
+// requestModel returns the model to ask for: flag, or else default_model,
+// or "" to leave it to the server. A model the server doesn't list is an
+// error; on a server that doesn't list its models, it isn't checked.
+func requestModel(client *chatui.HTTPClient, flag string) (string, error) {
+	model := flag
+	if model == "" {
+		model, _ = setting("default_model")
+	}
+	if model == "" {
+		return "", nil
+	}
+	models, err := client.ListModels(context.Background())
+	if errors.Is(err, chatui.ErrModelsUnavailable) {
+		return model, nil
+	}
+	if err != nil {
+		return "", apiError(err)
+	}
+	if err := chatui.CheckModel(models, model); err != nil {
+		if flag == "" {
+			return "", fmt.Errorf("default_model: %w", err)
+		}
+		return "", err
+	}
+	return model, nil
+}
+
+func runModelsList(cmd *cobra.Command, args []string) error {
+	client := chatui.NewHTTPClient(requireBaseURL())
+	models, partial, err := listModels(client)
+	if err != nil {
+		return apiError(err)
+	}
+	if jsonFlag {
+		if models == nil {
+			models = []chatui.ModelInfo{}
+		}
+		return printJSON(models)
+	}
+
+	def, _ := setting("default_model")
+	fmt.Println()
+	fmt.Println(styleBold.Render("Models"))
+	fmt.Println(strings.Repeat("─", 40))
+	if len(models) == 0 {
+		fmt.Println(styleDim.Render("  This server doesn't report its models."))
+		fmt.Println()
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  PROVIDER\tMODEL\tCONTEXT\tNAME")
+	for _, m := range models {
+		mark := " "
+		if m.Current {
+			mark = "*"
+		}
+		if def != "" && m.Matches(def) {
+			mark = "+"
+		}
+		window := "-"
+		if m.ContextWindow > 0 {
+			window = fmt.Sprintf("%dk", m.ContextWindow/1000)
+		}
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\t%s\n", mark, m.Provider, m.ID, window, m.Name)
+	}
+	tw.Flush()
+	fmt.Println()
+	fmt.Println(styleDim.Render("  * the server's current model   + default_model"))
+	if partial {
+		fmt.Println(styleDim.Render("  This server lists only the model it uses; others can't be checked before asking."))
+	}
+	fmt.Println()
+	return nil
+}
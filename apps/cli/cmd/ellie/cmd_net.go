@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const anthropicAPIURL = "https://api.anthropic.com"
+
+var netTimeout time.Duration
+
+var netCmd = &cobra.Command{
+	Use:   "net",
+	Short: "Network diagnostics",
+}
+
+var netTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Check connectivity to the server, Anthropic, proxy and DNS",
+	RunE:  runNetTest,
+}
+
+func init() {
+	netTestCmd.Flags().DurationVar(&netTimeout, "timeout", 10*time.Second, "Per-check timeout")
+}
+
+// netResult is one row of the connectivity matrix.
+type netResult struct {
+	Check   string
+	Target  string
+	OK      bool
+	Skipped bool
+	Latency time.Duration
+	Detail  string
+}
+
+func runNetTest(cmd *cobra.Command, args []string) error {
+	base := baseURL()
+	var results []netResult
+
+	// DNS for every host we are about to contact
+	hosts := []string{hostOf(base), hostOf(anthropicAPIURL)}
+	if p := proxyFor(base); p != nil {
+		hosts = append(hosts, p.Hostname())
+	}
+	for _, h := range dedupe(hosts) {
+		results = append(results, probeDNS(h))
+	}
+
+	// Proxy reachability (only when one is configured for either target)
+	for _, target := range []string{base, anthropicAPIURL} {
+		if p := proxyFor(target); p != nil {
+			results = append(results, probeTCP("proxy", p.Host, target))
+		}
+	}
+
+	results = append(results,
+		probeHTTP("ellie server", base+"/api/status", connectTransport),
+		probeHTTP("anthropic (direct)", anthropicAPIURL+"/v1/models", http.DefaultTransport.(*http.Transport)),
+		probeAnthropicViaServer(base),
+	)
+
+	printNetResults(results)
+
+	for _, r := range results {
+		if !r.OK && !r.Skipped {
+			return errSilent
+		}
+	}
+	return nil
+}
+
+// probeDNS resolves host and reports the addresses found.
+func probeDNS(host string) netResult {
+	r := netResult{Check: "dns", Target: host}
+	if ip := net.ParseIP(host); ip != nil {
+		r.OK, r.Skipped, r.Detail = true, true, "literal IP"
+		return r
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), netTimeout)
+	defer cancel()
+
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	r.Latency = time.Since(start)
+	if err != nil {
+		r.Detail = err.Error()
+		return r
+	}
+	r.OK = true
+	r.Detail = strings.Join(addrs, ", ")
+	return r
+}
+
+// probeTCP opens a plain TCP connection to addr.
+func probeTCP(check, addr, via string) netResult {
+	r := netResult{Check: check, Target: addr, Detail: "for " + hostOf(via)}
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", addr, netTimeout)
+	r.Latency = time.Since(start)
+	if err != nil {
+		r.Detail = err.Error()
+		return r
+	}
+	conn.Close()
+	r.OK = true
+	return r
+}
+
+// probeHTTP performs a GET against rawURL on a copy of base, tracing each
+// phase of the request. Any HTTP response counts as reachable — a 401
+// from Anthropic still proves the network path works.
+func probeHTTP(check, rawURL string, base *http.Transport) netResult {
+	r := netResult{Check: check, Target: rawURL}
+
+	var (
+		dnsStart, connStart, tlsStart time.Time
+		dnsDur, connDur, tlsDur       time.Duration
+		reused                        bool
+	)
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { dnsDur = time.Since(dnsStart) },
+		ConnectStart:      func(string, string) { connStart = time.Now() },
+		ConnectDone:       func(string, string, error) { connDur = time.Since(connStart) },
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { tlsDur = time.Since(tlsStart) },
+		GotConn:           func(info httptrace.GotConnInfo) { reused = info.Reused },
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), netTimeout)
+	defer cancel()
+	ctx = httptrace.WithClientTrace(ctx, trace)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		r.Detail = err.Error()
+		return r
+	}
+
+	// Fresh transport per probe so connection reuse never hides a phase.
+	transport := base.Clone()
+	client := &http.Client{Transport: transport}
+	defer transport.CloseIdleConnections()
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	r.Latency = time.Since(start)
+	if err != nil {
+		r.Detail = err.Error()
+		return r
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	r.OK = resp.StatusCode < 500
+	parts := []string{resp.Proto, fmt.Sprintf("status %d", resp.StatusCode)}
+	if resp.TLS != nil {
+		parts = append(parts, tlsSummary(resp.TLS))
+	}
+	if !reused {
+		phases := fmt.Sprintf("dns %s, connect %s", roundMs(dnsDur), roundMs(connDur))
+		if resp.TLS != nil {
+			phases += ", tls " + roundMs(tlsDur)
+		}
+		parts = append(parts, phases)
+	}
+	if p := proxyFor(rawURL); p != nil {
+		parts = append(parts, "via proxy "+p.Host)
+	}
+	r.Detail = strings.Join(parts, " · ")
+	return r
+}
+
+// probeAnthropicViaServer asks the server to check its own upstream
+// connectivity. Older servers don't expose this route, so a 404 is
+// reported as skipped rather than failed.
+func probeAnthropicViaServer(base string) netResult {
+	r := netResult{Check: "anthropic (via server)", Target: base + "/api/diagnostics/anthropic"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), netTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.Target, nil)
+	if err != nil {
+		r.Detail = err.Error()
+		return r
+	}
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	r.Latency = time.Since(start)
+	if err != nil {
+		r.Skipped = true
+		r.Detail = "server unreachable"
+		return r
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		r.Skipped = true
+		r.Detail = "server does not expose an upstream probe"
+		return r
+	}
+	if resp.StatusCode != 200 {
+		r.Detail = serverError(resp).Error()
+		return r
+	}
+
+	var probe struct {
+		OK        bool    `json:"ok"`
+		Status    int     `json:"status"`
+		LatencyMs float64 `json:"latencyMs"`
+		Error     string  `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&probe); err != nil {
+		r.Detail = fmt.Sprintf("invalid response: %v", err)
+		return r
+	}
+	r.OK = probe.OK
+	if probe.LatencyMs > 0 {
+		r.Latency = time.Duration(probe.LatencyMs * float64(time.Millisecond))
+	}
+	if probe.Error != "" {
+		r.Detail = probe.Error
+	} else {
+		r.Detail = fmt.Sprintf("upstream status %d", probe.Status)
+	}
+	return r
+}
+
+func printNetResults(results []netResult) {
+	fmt.Println()
+	fmt.Println(styleBold.Render("Connectivity"))
+	fmt.Println(strings.Repeat("─", 72))
+	for _, r := range results {
+		mark := styleOk.Render("✓")
+		switch {
+		case r.Skipped:
+			mark = styleDim.Render("-")
+		case !r.OK:
+			mark = styleErr.Render("✗")
+		}
+		latency := ""
+		if r.Latency > 0 {
+			latency = roundMs(r.Latency)
+		}
+		fmt.Printf("  %s %-24s %-8s %s\n", mark, r.Check, latency, r.Target)
+		if r.Detail != "" {
+			fmt.Println("      " + styleDim.Render(r.Detail))
+		}
+	}
+	fmt.Println()
+}
+
+func tlsSummary(state *tls.ConnectionState) string {
+	s := tls.VersionName(state.Version) + " " + tls.CipherSuiteName(state.CipherSuite)
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		s += fmt.Sprintf(" · cert %s (issuer %s, expires %s)",
+			cert.Subject.CommonName, cert.Issuer.CommonName, cert.NotAfter.Format("2006-01-02"))
+	}
+	return s
+}
+
+// proxyFor returns the proxy ellie uses for rawURL.
+func proxyFor(rawURL string) *url.URL {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil
+	}
+	p, err := proxyOf(req)
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Hostname()
+}
+
+func roundMs(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+func dedupe(items []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range items {
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/paths"
+	"ellie/apps/cli/internal/permissions"
+)
+
+// ── tool permissions ────────────────────────────────────────────────────────
+
+var (
+	permissionsProfile string
+	permissionsAccess  string
+)
+
+var permissionsCmd = &cobra.Command{
+	Use:   "permissions",
+	Short: "Edit what the assistant's tools may read, write, run and fetch",
+	Long: `Edit the policy that decides what the assistant's tools may touch:
+files they read or write, commands they run and domains they fetch from.
+
+  ellie permissions allow path '~/projects/**'
+  ellie permissions deny path '~/projects/**/.env'
+  ellie permissions allow --access read path /etc/hosts
+  ellie permissions allow command git
+  ellie permissions deny command git push
+  ellie permissions allow domain '*.github.com'
+  ellie permissions test read /etc/passwd
+
+Path patterns are absolute or start with ~/; * matches within one path
+element and ** any number of them. Command patterns match the first words
+of a command line, the program by its base name, so "git push" covers
+git push --force. Domain patterns are host names, or *.host for the hosts
+under it. A deny rule wins over an allow rule, and what no rule matches
+gets the policy's default, which is deny until changed with
+'ellie permissions default allow'. Flags go before the kind, so that a
+command pattern can have flags of its own: deny command rm -rf '*'.
+
+Each profile has its own policy, kept in the permissions directory next to
+ellie.toml. Commands edit the profile named by --profile, else the
+permissions.profile setting (ELLIE_PERMISSIONS_PROFILE), else "default".`,
+}
+
+var permissionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show a profile's rules",
+	Args:  cobra.NoArgs,
+	RunE:  runPermissionsList,
+}
+
+var permissionsAllowCmd = &cobra.Command{
+	Use:       "allow <path|command|domain> <pattern>",
+	Short:     "Add a rule allowing what the pattern matches",
+	Args:      cobra.MinimumNArgs(2),
+	ValidArgs: permissions.Kinds,
+	RunE:      runPermissionsAdd(permissions.Allow),
+}
+
+var permissionsDenyCmd = &cobra.Command{
+	Use:       "deny <path|command|domain> <pattern>",
+	Short:     "Add a rule denying what the pattern matches",
+	Args:      cobra.MinimumNArgs(2),
+	ValidArgs: permissions.Kinds,
+	RunE:      runPermissionsAdd(permissions.Deny),
+}
+
+var permissionsRemoveCmd = &cobra.Command{
+	Use:       "remove <path|command|domain> <pattern>",
+	Short:     "Remove the allow and deny rules for a pattern",
+	Args:      cobra.MinimumNArgs(2),
+	ValidArgs: permissions.Kinds,
+	RunE:      runPermissionsRemove,
+}
+
+var permissionsDefaultCmd = &cobra.Command{
+	Use:       "default <allow|deny>",
+	Short:     "Set what happens to what no rule matches",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{permissions.Allow, permissions.Deny},
+	RunE:      runPermissionsDefault,
+}
+
+var permissionsTestCmd = &cobra.Command{
+	Use:   "test <read|write|exec|fetch> <target>",
+	Short: "Check whether a tool would be allowed to do something",
+	Long: `Check whether the policy allows an action, and which rule decides it:
+
+  ellie permissions test read /etc/passwd
+  ellie permissions test write ~/projects/app/.env
+  ellie permissions test exec git push origin main
+  ellie permissions test fetch https://api.github.com/repos
+
+Exits with status 1 when the action is denied.`,
+	Args:      cobra.MinimumNArgs(2),
+	ValidArgs: []string{"read", "write", "exec", "fetch"},
+	RunE:      runPermissionsTest,
+}
+
+var permissionsValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a profile's policy, or a policy file, for mistakes",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runPermissionsValidate,
+}
+
+var permissionsProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List the profiles with a policy",
+	Args:  cobra.NoArgs,
+	RunE:  runPermissionsProfiles,
+}
+
+func init() {
+	permissionsCmd.PersistentFlags().StringVar(&permissionsProfile, "profile", "", "Policy profile to use (default: the permissions.profile setting)")
+	for _, c := range []*cobra.Command{permissionsAllowCmd, permissionsDenyCmd, permissionsRemoveCmd, permissionsTestCmd} {
+		c.Flags().SetInterspersed(false)
+	}
+	for _, c := range []*cobra.Command{permissionsAllowCmd, permissionsDenyCmd} {
+		c.Flags().StringVar(&permissionsAccess, "access", "", "For a path rule, apply it only to reading or writing: read or write")
+	}
+	for _, c := range []*cobra.Command{permissionsListCmd, permissionsTestCmd, permissionsProfilesCmd} {
+		jsonCommands[c] = true
+	}
+}
+
+// permissionsDir is where the policies are kept, one file per profile.
+func permissionsDir() (string, error) {
+	dir, err := paths.Config()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "permissions"), nil
+}
+
+// currentPermissionsProfile returns the profile commands use: --profile,
+// else the permissions.profile setting.
+func currentPermissionsProfile() (string, error) {
+	name := permissionsProfile
+	if name == "" {
+		name, _ = setting("permissions.profile")
+	}
+	if name == "" {
+		name = "default"
+	}
+	return name, permissions.ValidProfile(name)
+}
+
+// loadPermissions returns the current profile's policy and its file.
+func loadPermissions() (*permissions.Policy, string, string, error) {
+	profile, err := currentPermissionsProfile()
+	if err != nil {
+		return nil, "", "", err
+	}
+	dir, err := permissionsDir()
+	if err != nil {
+		return nil, "", "", err
+	}
+	path := permissions.File(dir, profile)
+	p, err := permissions.Load(path)
+	return p, profile, path, err
+}
+
+// permissionsRule builds the rule named by a kind and pattern arguments.
+// A command pattern may be given as several arguments.
+func permissionsRule(effect string, args []string) (permissions.Rule, error) {
+	r := permissions.Rule{Kind: args[0], Effect: effect, Pattern: strings.Join(args[1:], " "), Access: permissionsAccess}
+	switch r.Kind {
+	case permissions.KindCommand:
+		r.Pattern = strings.Join(strings.Fields(r.Pattern), " ")
+	case permissions.KindDomain:
+		r.Pattern = strings.ToLower(r.Pattern)
+	}
+	return r, r.Validate()
+}
+
+func runPermissionsList(cmd *cobra.Command, args []string) error {
+	p, profile, path, err := loadPermissions()
+	if err != nil {
+		return err
+	}
+	if jsonFlag {
+		return printJSON(struct {
+			Profile string `json:"profile"`
+			File    string `json:"file"`
+			*permissions.Policy
+		}{profile, path, p})
+	}
+
+	def := p.Default
+	if def == "" {
+		def = permissions.Deny
+	}
+	fmt.Println(styleBold.Render("Profile "+profile), styleDim.Render("("+path+")"))
+	if len(p.Rules) == 0 {
+		fmt.Println(styleDim.Render("  No rules — add one with ellie permissions allow or deny."))
+	}
+	for i, r := range p.Rules {
+		effect := styleOk.Render(fmt.Sprintf("%-5s", r.Effect))
+		if r.Effect == permissions.Deny {
+			effect = styleErr.Render(fmt.Sprintf("%-5s", r.Effect))
+		}
+		line := fmt.Sprintf("  %2d  %s  %-7s  %s", i+1, effect, r.Kind, r.Pattern)
+		if r.Access != "" {
+			line += styleDim.Render("  (" + r.Access + " only)")
+		}
+		fmt.Println(line)
+	}
+	fmt.Println(styleDim.Render("  Anything else: " + def))
+	if err := p.Validate(); err != nil {
+		warn("the policy has mistakes, see ellie permissions validate")
+	}
+	return nil
+}
+
+func runPermissionsAdd(effect string) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		r, err := permissionsRule(effect, args)
+		if err != nil {
+			return err
+		}
+		p, profile, path, err := loadPermissions()
+		if err != nil {
+			return err
+		}
+		if !p.Add(r) {
+			fmt.Println(styleDim.Render("Profile " + profile + " already has: " + r.String()))
+			return nil
+		}
+		if err := p.Save(path); err != nil {
+			return err
+		}
+		fmt.Println(styleOk.Render("✓"), "Added", styleBold.Render(r.String()), styleDim.Render("to profile "+profile))
+		for _, other := range p.Rules {
+			if other.Kind == r.Kind && other.Pattern == r.Pattern && other.Effect != r.Effect {
+				fmt.Println(styleDim.Render("  The profile also has " + other.String() + "; deny rules win."))
+				break
+			}
+		}
+		return nil
+	}
+}
+
+func runPermissionsRemove(cmd *cobra.Command, args []string) error {
+	r, err := permissionsRule(permissions.Allow, args)
+	if err != nil {
+		return err
+	}
+	p, profile, path, err := loadPermissions()
+	if err != nil {
+		return err
+	}
+	n := p.Remove(r.Kind, r.Pattern)
+	if n == 0 {
+		return fmt.Errorf("profile %s has no %s rule for %s — see ellie permissions list", profile, r.Kind, r.Pattern)
+	}
+	if err := p.Save(path); err != nil {
+		return err
+	}
+	noun := "rule"
+	if n > 1 {
+		noun = "rules"
+	}
+	fmt.Println(styleOk.Render("✓"), fmt.Sprintf("Removed %d %s for %s %s", n, noun, r.Kind, r.Pattern), styleDim.Render("from profile "+profile))
+	return nil
+}
+
+func runPermissionsDefault(cmd *cobra.Command, args []string) error {
+	if args[0] != permissions.Allow && args[0] != permissions.Deny {
+		return fmt.Errorf("default must be allow or deny, not %q", args[0])
+	}
+	p, profile, path, err := loadPermissions()
+	if err != nil {
+		return err
+	}
+	p.Default = args[0]
+	if err := p.Save(path); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Profile", styleBold.Render(profile), "now", args[0]+"s", "what no rule matches")
+	return nil
+}
+
+func runPermissionsTest(cmd *cobra.Command, args []string) error {
+	p, profile, _, err := loadPermissions()
+	if err != nil {
+		return err
+	}
+	home, _ := os.UserHomeDir()
+	action, target := args[0], strings.Join(args[1:], " ")
+	d, err := p.Check(action, target, home)
+	if err != nil {
+		return err
+	}
+
+	if jsonFlag {
+		if err := printJSON(struct {
+			Profile string `json:"profile"`
+			Action  string `json:"action"`
+			Target  string `json:"target"`
+			permissions.Decision
+		}{profile, action, target, d}); err != nil {
+			return err
+		}
+	} else {
+		verdict := styleOk.Render("✓ allowed")
+		if !d.Allowed {
+			verdict = styleErr.Render("✗ denied")
+		}
+		why := "by the default"
+		if d.Rule != nil {
+			why = "by rule: " + d.Rule.String()
+		}
+		fmt.Println(verdict, action, target, styleDim.Render("— "+why+" (profile "+profile+")"))
+	}
+	if !d.Allowed {
+		return exitCodeError(1)
+	}
+	return nil
+}
+
+func runPermissionsValidate(cmd *cobra.Command, args []string) error {
+	var path, name string
+	if len(args) == 1 {
+		path, name = args[0], args[0]
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+	} else {
+		profile, err := currentPermissionsProfile()
+		if err != nil {
+			return err
+		}
+		dir, err := permissionsDir()
+		if err != nil {
+			return err
+		}
+		path, name = permissions.File(dir, profile), "Profile "+profile
+	}
+	p, err := permissions.Load(path)
+	if err != nil {
+		return err
+	}
+	if err := p.Validate(); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Println(styleErr.Render("✗"), line)
+		}
+		return errSilent
+	}
+	fmt.Println(styleOk.Render("✓"), name, "is valid", styleDim.Render(fmt.Sprintf("(%d rules)", len(p.Rules))))
+	return nil
+}
+
+func runPermissionsProfiles(cmd *cobra.Command, args []string) error {
+	dir, err := permissionsDir()
+	if err != nil {
+		return err
+	}
+	names, err := permissions.Profiles(dir)
+	if err != nil {
+		return err
+	}
+	current, _ := currentPermissionsProfile()
+	if jsonFlag {
+		return printJSON(struct {
+			Current  string   `json:"current"`
+			Profiles []string `json:"profiles"`
+		}{current, append([]string{}, names...)})
+	}
+	if len(names) == 0 {
+		fmt.Println(styleDim.Render("No policies yet — add a rule with ellie permissions allow or deny."))
+		return nil
+	}
+	for _, name := range names {
+		marker := "  "
+		if name == current {
+			marker = styleOk.Render("● ")
+		}
+		fmt.Println(marker + name)
+	}
+	return nil
+}
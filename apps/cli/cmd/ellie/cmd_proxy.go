@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/cassette"
+)
+
+// ── provider proxy ──────────────────────────────────────────────────────────
+
+// proxyProviders are the providers ellie proxy can stand in for: their
+// API and the environment variable that points the server's SDK elsewhere.
+var proxyProviders = map[string]struct{ upstream, env string }{
+	"anthropic": {"https://api.anthropic.com", "ANTHROPIC_BASE_URL"},
+}
+
+var (
+	proxyListen   string
+	proxyRecord   string
+	proxyReplay   string
+	proxyUpstream string
+)
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Record and replay the server's model provider traffic",
+}
+
+var proxyAnthropicCmd = &cobra.Command{
+	Use:   "anthropic",
+	Short: "Record or replay Anthropic API traffic as a cassette",
+	Long: `Stand in for the Anthropic API on a loopback port, so prompts and the
+parsing of responses can be tested deterministically without spending
+tokens.
+
+With --record, requests are forwarded to the API and each request and
+response is added to the cassette as it completes. API keys, auth headers
+and cookies are left out of it. With --replay, the cassette's responses
+are served and nothing reaches the API: a request gets the next recorded
+response to the same method, path and body (JSON compared by value), and
+a request that wasn't recorded gets a 404 error.
+
+Point the server at the proxy through the SDK's base URL:
+
+  ellie proxy anthropic --record fixtures/greeting.json
+  ANTHROPIC_BASE_URL=http://127.0.0.1:8791 ellie dev
+
+The proxy runs until interrupted.`,
+	Args: cobra.NoArgs,
+	RunE: runProxy,
+}
+
+func init() {
+	proxyAnthropicCmd.Flags().StringVar(&proxyListen, "listen", "127.0.0.1:8791", "Loopback address to serve on")
+	proxyAnthropicCmd.Flags().StringVar(&proxyRecord, "record", "", "Forward to the API and record to this cassette")
+	proxyAnthropicCmd.Flags().StringVar(&proxyReplay, "replay", "", "Serve responses from this cassette")
+	proxyAnthropicCmd.Flags().StringVar(&proxyUpstream, "upstream", "", "API to record from (default https://api.anthropic.com)")
+	proxyAnthropicCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	proxyAnthropicCmd.MarkFlagsOneRequired("record", "replay")
+}
+
+func runProxy(cmd *cobra.Command, args []string) error {
+	provider := proxyProviders[cmd.Name()]
+	upstream := provider.upstream
+	if proxyUpstream != "" {
+		upstream = proxyUpstream
+	}
+
+	host, _, err := net.SplitHostPort(proxyListen)
+	if err != nil {
+		return fmt.Errorf("invalid --listen address: %w", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("--listen must be a loopback address — when recording, the proxy forwards with the server's credentials")
+	}
+
+	var (
+		handler http.Handler
+		summary func() string
+	)
+	if proxyRecord != "" {
+		rec, err := cassette.NewRecorder(cmd.Name(), upstream, proxyRecord)
+		if err != nil {
+			return fmt.Errorf("cannot open cassette: %w", err)
+		}
+		before := rec.Len()
+		handler = rec
+		summary = func() string {
+			return fmt.Sprintf("Recorded %d interaction(s) to %s", rec.Len()-before, proxyRecord)
+		}
+	} else {
+		c, err := cassette.Load(proxyReplay)
+		if err != nil {
+			return fmt.Errorf("cannot open cassette: %w", err)
+		}
+		if c.Provider != cmd.Name() {
+			return fmt.Errorf("%s is a %s cassette", proxyReplay, c.Provider)
+		}
+		player := cassette.NewPlayer(c)
+		player.Missed = func(method, uri string) {
+			warn(fmt.Sprintf("no recorded response for %s %s", method, uri))
+		}
+		handler = player
+		summary = func() string {
+			served, missed := player.Stats()
+			return fmt.Sprintf("Replayed %d response(s) from %s, %d request(s) not recorded", served, proxyReplay, missed)
+		}
+	}
+
+	ln, err := net.Listen("tcp", proxyListen)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", proxyListen, err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Handler: handler}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	addr := "http://" + ln.Addr().String()
+	if proxyRecord != "" {
+		fmt.Fprintln(os.Stderr, styleOk.Render("✓"), "Recording", upstream, "to", styleBold.Render(proxyRecord))
+	} else {
+		fmt.Fprintln(os.Stderr, styleOk.Render("✓"), "Replaying", styleBold.Render(proxyReplay))
+	}
+	fmt.Fprintln(os.Stderr, styleDim.Render("  Point the server here: "+provider.env+"="+addr))
+
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, summary())
+	return nil
+}
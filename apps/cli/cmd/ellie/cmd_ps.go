@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var psCmd = &cobra.Command{
+	Use:   "ps",
+	Short: "List the ellie processes running in the background and the dev instances",
+	Long: `List the processes ellie manages — ellie dev and its instances, ellie
+start, the daemon and the credential agent — with their pid, the port
+their server binds, how long they have run and the checkout and branch
+they were started from, to tell dev instances apart:
+
+  INSTANCE  PROCESS  PID    PORT  UP   BRANCH   DIR
+  -         dev      4121   3000  2h   main     ~/src/ellie
+  blue      dev      4388   3001  12m  fix-sse  ~/src/ellie-fix-sse`,
+	Args: cobra.NoArgs,
+	RunE: runPs,
+}
+
+// psProcess is a managed process in ellie ps.
+type psProcess struct {
+	Instance string    `json:"instance,omitempty"`
+	Name     string    `json:"name"`
+	PID      int       `json:"pid"`
+	Port     int       `json:"port,omitempty"`
+	Since    time.Time `json:"since"`
+	Dir      string    `json:"dir,omitempty"`
+	Branch   string    `json:"branch,omitempty"`
+}
+
+// psNames are the managed processes of the default state directory; an
+// instance only has ellie dev.
+var psNames = []string{"dev", "start", "daemon", "agent"}
+
+func runPs(cmd *cobra.Command, args []string) error {
+	list := []psProcess{}
+	for _, inst := range append([]string{""}, devInstances()...) {
+		dir, err := instanceStateDir(inst)
+		if err != nil {
+			return err
+		}
+		names := psNames
+		if inst != "" {
+			names = names[:1]
+		}
+		for _, name := range names {
+			pid, since, ok := runningPidfile(pidPathIn(dir, name))
+			if !ok {
+				continue
+			}
+			p := psProcess{Instance: inst, Name: name, PID: pid, Since: since}
+			p.Port, _ = readServerPort(serverPortPathIn(dir, name))
+			if inv, err := loadInvocationAt(invocationPathIn(dir, name)); err == nil {
+				p.Dir = inv.Dir
+				if out, err := gitOutput(inv.Dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+					p.Branch = strings.TrimSpace(out)
+				}
+			}
+			list = append(list, p)
+		}
+	}
+
+	if jsonFlag {
+		return printJSON(list)
+	}
+	if len(list) == 0 {
+		fmt.Println(styleDim.Render("No ellie processes are running. Start one with ellie dev or ellie start."))
+		return nil
+	}
+
+	home, _ := os.UserHomeDir()
+	rows := [][]string{{"INSTANCE", "PROCESS", "PID", "PORT", "UP", "BRANCH", "DIR"}}
+	for _, p := range list {
+		row := []string{p.Instance, p.Name, strconv.Itoa(p.PID), strconv.Itoa(p.Port), formatUptime(time.Since(p.Since)), p.Branch, p.Dir}
+		if home != "" && strings.HasPrefix(p.Dir, home) {
+			row[6] = "~" + strings.TrimPrefix(p.Dir, home)
+		}
+		if p.Port == 0 {
+			row[3] = ""
+		}
+		for i, v := range row {
+			if v == "" {
+				row[i] = "-"
+			}
+		}
+		rows = append(rows, row)
+	}
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, v := range row {
+			widths[i] = max(widths[i], len(v))
+		}
+	}
+	for i, row := range rows {
+		var b strings.Builder
+		for j, v := range row {
+			if j < len(row)-1 {
+				v = fmt.Sprintf("%-*s  ", widths[j], v)
+			}
+			b.WriteString(v)
+		}
+		line := strings.TrimRight(b.String(), " ")
+		if i == 0 {
+			line = styleDim.Render(line)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
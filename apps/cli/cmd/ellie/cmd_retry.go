@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var retryCheck bool
+
+var retryCmd = &cobra.Command{
+	Use:   "retry",
+	Short: "Run a failed command again",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+var retryLastCmd = &cobra.Command{
+	Use:   "last",
+	Short: "Run the last command that failed again, with the same flags",
+	Long: `Run the last ellie command that failed again, with the same arguments
+and from the same directory, for failures that may not happen twice — a
+server that wasn't up yet, a network hiccup, a provider outage.
+
+--check first runs the doctor checks that bear on the command: the
+server, credentials and provider status for commands that talk to the
+server, the checkout and toolchain for ellie dev, start and build. When
+one fails, the command is not run and the fix is printed instead.`,
+	Args: cobra.NoArgs,
+	RunE: runRetryLast,
+}
+
+func init() {
+	retryLastCmd.Flags().BoolVar(&retryCheck, "check", false, "Run the relevant doctor checks first and stop if one fails")
+}
+
+// lastInvocation names the saved command line of the last failed command.
+const lastInvocation = "last"
+
+// ranCommand is the command this run executed, set once its flags are
+// parsed, so a failure can be offered for retry.
+var ranCommand *cobra.Command
+
+// offerRetry records the command line of a command that failed with err,
+// for ellie retry last, and says so. Commands stopped with Ctrl-C aren't
+// recorded, nor is ellie retry itself, which would replay itself.
+func offerRetry(err error) {
+	var ec exitCodeError
+	if ranCommand == nil || underCommand(ranCommand, retryCmd) || (errors.As(err, &ec) && ec == 130) {
+		return
+	}
+	if saveRetryInvocation() != nil {
+		return
+	}
+	if !ciMode() && !quiet && !jsonFlag {
+		fmt.Fprintln(os.Stderr, styleDim.Render("Run `ellie retry last` to try again."))
+	}
+}
+
+// saveRetryInvocation saves the command line like saveInvocation, but
+// readable only by the user: unlike a start, any command may have been
+// given a secret as an argument.
+func saveRetryInvocation() error {
+	if err := saveInvocation(lastInvocation); err != nil {
+		return err
+	}
+	path, err := invocationPath(lastInvocation)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(path, 0o600)
+}
+
+func runRetryLast(cmd *cobra.Command, args []string) error {
+	inv, err := loadInvocation(lastInvocation)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no failed command was recorded")
+	} else if err != nil {
+		return err
+	}
+	line := "ellie " + strings.Join(inv.Args, " ")
+	if wd, _ := os.Getwd(); inv.Dir != "" && filepath.Clean(wd) != filepath.Clean(inv.Dir) {
+		line += " (in " + inv.Dir + ")"
+	}
+
+	if retryCheck {
+		// Check what the command will use: the server its flags choose
+		// and the checkout it ran in.
+		target, rest, err := rootCmd.Find(inv.Args)
+		if err != nil {
+			target = rootCmd
+		} else if err := target.ParseFlags(rest); err == nil {
+			if err := applySocket(); err != nil {
+				return err
+			}
+		}
+		if inv.Dir != "" {
+			_ = os.Chdir(inv.Dir)
+		}
+		if failed := failedChecks(retrySections(target)); failed > 0 {
+			fmt.Fprintln(os.Stderr, styleErr.Render(fmt.Sprintf("%d check(s) failed — fix them, or retry without --check", failed)))
+			return errSilent
+		}
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate ellie executable: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, styleDim.Render("Running: "+line))
+	if exitCode := runProcess(self, inv.Args, inv.Dir); exitCode != 0 {
+		return exitCodeError(exitCode)
+	}
+	return nil
+}
+
+// retrySections returns the titles of the doctor sections that bear on
+// cmd: the checkout and toolchain for the commands that build or run the
+// server from it, the server and providers for the rest.
+func retrySections(cmd *cobra.Command) []string {
+	for _, local := range []*cobra.Command{devCmd, startCmd, restartCmd, buildCmd} {
+		if underCommand(cmd, local) {
+			return []string{"Project", "Toolchain", "Filesystem"}
+		}
+	}
+	return []string{"Server", "Credentials", "Upstream"}
+}
+
+// failedChecks runs the doctor checks of the sections titled titles,
+// prints those that fail with their fix, and returns how many did.
+func failedChecks(titles []string) int {
+	failed := 0
+	for _, s := range runDoctorChecks() {
+		if !slices.Contains(titles, s.title) {
+			continue
+		}
+		for _, c := range s.checks {
+			if c.level != checkFail {
+				continue
+			}
+			failed++
+			fmt.Fprintf(os.Stderr, "%s %s: %s\n", styleErr.Render("✗"), c.name, c.detail)
+			if c.fix != "" {
+				fmt.Fprintln(os.Stderr, styleDim.Render("  → "+c.fix))
+			}
+		}
+	}
+	return failed
+}
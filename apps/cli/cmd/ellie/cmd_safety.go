@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/audit"
+	"ellie/apps/cli/internal/chatui"
+	"ellie/apps/cli/internal/paths"
+	"ellie/apps/cli/internal/safety"
+)
+
+// ── safety ──────────────────────────────────────────────────────────────────
+
+// allowSensitive is --allow-sensitive: text the safety policy blocks is
+// sent anyway, and the override logged.
+var allowSensitive bool
+
+var safetyCmd = &cobra.Command{
+	Use:   "safety",
+	Short: "Check prompts and attachments for secrets and personal data",
+	Long: `Before a prompt or text attachment is sent to the model, ellie looks in it
+for secrets — API keys, tokens, passwords, private keys — and personal
+data — email addresses, card, social security and phone numbers.
+
+What it does with them is set per category by safety.secrets and
+safety.pii: block (nothing is sent), redact (each finding is replaced by
+[REDACTED kind]), warn, or off. By default secrets block and personal data
+warns.
+
+--allow-sensitive sends blocked text anyway; each override is recorded in
+a hash-chained log that ellie safety log shows.`,
+}
+
+var safetyScanCmd = &cobra.Command{
+	Use:   "scan [file]...",
+	Short: "Show the secrets and personal data in files, as chat would check them",
+	Long: `Show what the safety check finds in the files, or standard input when none
+or "-" is given, and what the policy does with it. Exits non-zero when
+something would be blocked, so it can guard a prompt file in a script or
+hook.`,
+	RunE: runSafetyScan,
+}
+
+var safetyLogVerify bool
+
+var safetyLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show the blocked prompts and attachments sent with --allow-sensitive",
+	Args:  cobra.NoArgs,
+	RunE:  runSafetyLog,
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&allowSensitive, "allow-sensitive", false,
+		"Send prompts and attachments the safety policy blocks, and log the override")
+	safetyLogCmd.Flags().BoolVar(&safetyLogVerify, "verify", false, "Only verify the hash chain")
+	jsonCommands[safetyScanCmd] = true
+	chatui.CheckOutgoing = checkOutgoing
+}
+
+// safetyPolicy is the policy from the safety settings.
+func safetyPolicy() safety.Policy {
+	p := safety.Policy{}
+	for c, key := range map[safety.Category]string{safety.Secret: "safety.secrets", safety.PII: "safety.pii"} {
+		v, _ := setting(key)
+		p[c] = safety.Action(v)
+	}
+	return p
+}
+
+// checkOutgoing applies the safety policy to text about to be sent.
+func checkOutgoing(name, text string) (string, string, error) {
+	r := safetyPolicy().Check(text)
+	var notes []string
+	if len(r.Blocked) > 0 {
+		if !allowSensitive {
+			return "", "", fmt.Errorf("%s contains %s; not sent (remove it, or pass --allow-sensitive)", name, safety.Summary(r.Blocked))
+		}
+		recordSafetyOverride(name, r.Blocked)
+		notes = append(notes, fmt.Sprintf("sent %s with %s (--allow-sensitive)", name, safety.Summary(r.Blocked)))
+	}
+	if len(r.Redacted) > 0 {
+		notes = append(notes, fmt.Sprintf("redacted %s from %s", safety.Summary(r.Redacted), name))
+	}
+	if len(r.Warned) > 0 {
+		notes = append(notes, fmt.Sprintf("%s contains %s", name, safety.Summary(r.Warned)))
+	}
+	return r.Text, strings.Join(notes, "; "), nil
+}
+
+// safetyAuditLog is the log of --allow-sensitive overrides.
+func safetyAuditLog() (*audit.Log, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return nil, err
+	}
+	return audit.Open(filepath.Join(dir, "audit", "safety.jsonl")), nil
+}
+
+// recordSafetyOverride logs that findings were sent despite the policy.
+// Only what was found is recorded, never the text. Like the other audit
+// logs, a failure to record only warns.
+func recordSafetyOverride(name string, findings []safety.Finding) {
+	// The entry reads "override notes.txt (1 api-key)".
+	e := audit.Entry{
+		Actor:    auditActor(),
+		Op:       "override",
+		Provider: name,
+		Method:   safety.Summary(findings),
+		Server:   baseURL(),
+		OK:       true,
+	}
+	log, err := safetyAuditLog()
+	if err == nil {
+		_, err = log.Append(e)
+	}
+	if err != nil {
+		warn("cannot write safety audit log: " + err.Error())
+	}
+}
+
+type safetyScanResult struct {
+	File string `json:"file"`
+	safety.Finding
+	Action safety.Action `json:"action"`
+}
+
+func runSafetyScan(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+	policy := safetyPolicy()
+	results := []safetyScanResult{}
+	for _, path := range args {
+		var data []byte
+		var err error
+		if path == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return err
+		}
+		for _, f := range safety.Scan(string(data)) {
+			if policy[f.Category] == safety.Off {
+				continue
+			}
+			results = append(results, safetyScanResult{File: path, Finding: f, Action: policy[f.Category]})
+		}
+	}
+
+	blocked := 0
+	for _, r := range results {
+		if r.Action == safety.Block {
+			blocked++
+		}
+	}
+	if jsonFlag {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			action := fmt.Sprintf("%-6s", r.Action)
+			if r.Action == safety.Block {
+				action = styleErr.Render(action)
+			}
+			fmt.Printf("%s:%d:%d  %-6s  %-14s %s  %s\n", r.File, r.Line, r.Column, r.Category, r.Kind, action, styleDim.Render(r.Preview))
+		}
+		if len(results) == 0 {
+			fmt.Println(styleOk.Render("✓"), "Nothing found")
+		}
+	}
+	if blocked > 0 {
+		return errSilent
+	}
+	return nil
+}
+
+func runSafetyLog(cmd *cobra.Command, args []string) error {
+	log, err := safetyAuditLog()
+	if err != nil {
+		return err
+	}
+	entries, err := log.Entries()
+	if err != nil {
+		return err
+	}
+	brokenAt, verr := audit.Verify(entries)
+	if safetyLogVerify {
+		if verr != nil {
+			fmt.Fprintln(os.Stderr, styleErr.Render("✗"), "Safety log chain is broken:", verr)
+			return errSilent
+		}
+		fmt.Println(styleOk.Render("✓"), fmt.Sprintf("Safety log intact (%d entries)", len(entries)))
+		return nil
+	}
+	printAuditEntries("Safety Overrides", "No overrides recorded.", entries, brokenAt)
+	if verr != nil {
+		fmt.Fprintln(os.Stderr, styleErr.Render("✗"), "Safety log chain is broken:", verr)
+		return errSilent
+	}
+	return nil
+}
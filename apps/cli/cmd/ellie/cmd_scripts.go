@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/paths"
+	"ellie/apps/cli/internal/recent"
+	"ellie/apps/cli/internal/workspace"
+)
+
+// ── workspace scripts ───────────────────────────────────────────────────────
+
+// recentScripts is how many scripts ellie scripts remembers per project.
+const recentScripts = 5
+
+var scriptsList bool
+
+var scriptsCmd = &cobra.Command{
+	Use:   "scripts [package] [script] [-- args...]",
+	Short: "Pick a workspace package's script and run it",
+	Long: `List the package.json scripts of every workspace package, pick one —
+type to filter — and run it with bun in the package's directory, with the
+environment ellie gives the servers it starts. The scripts last run in
+the project are listed first.
+
+Name the package (with or without its scope) and the script to run it
+without the picker; arguments after -- go to the script:
+
+  ellie scripts web build
+  ellie scripts server test -- --watch`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runScripts,
+}
+
+func init() {
+	scriptsCmd.Flags().BoolVar(&scriptsList, "list", false, "List the scripts instead of running one")
+}
+
+// workspaceScript is one package.json script.
+type workspaceScript struct {
+	Package string `json:"package"`
+	Dir     string `json:"dir"` // relative to the monorepo root
+	Script  string `json:"script"`
+	Command string `json:"command"`
+
+	absDir string
+}
+
+// key identifies s in the recent list.
+func (s workspaceScript) key() string { return s.Package + ":" + s.Script }
+
+func runScripts(cmd *cobra.Command, args []string) error {
+	root, err := findMonorepoRoot()
+	if err != nil {
+		return err
+	}
+	pkgs, err := workspace.Discover(root)
+	if err != nil {
+		return err
+	}
+	var scripts []workspaceScript
+	for _, p := range pkgs {
+		names := make([]string, 0, len(p.Scripts))
+		for name := range p.Scripts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			scripts = append(scripts, workspaceScript{Package: p.Name, Dir: p.RelDir, Script: name, Command: p.Scripts[name], absDir: p.Dir})
+		}
+	}
+
+	if scriptsList || jsonFlag {
+		return listScripts(scripts)
+	}
+
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 {
+		dash = len(args)
+	}
+	named, extra := args[:dash], args[dash:]
+
+	var chosen workspaceScript
+	switch len(named) {
+	case 2:
+		if chosen, err = findScript(scripts, named[0], named[1]); err != nil {
+			return err
+		}
+	case 0:
+		if err := noPrompt("choosing a script", "name it: ellie scripts <package> <script>"); err != nil {
+			return err
+		}
+		if chosen, err = pickScript(root, scripts); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("name both the package and the script: ellie scripts <package> <script>")
+	}
+
+	if store, err := scriptsStore(); err == nil {
+		if err := store.Add(root, chosen.key()); err != nil {
+			warn("cannot remember the script: " + err.Error())
+		}
+	}
+
+	bunPath, err := findBin("bun", root)
+	if err != nil {
+		return err
+	}
+	runArgs := []string{"run", chosen.Script}
+	if len(extra) > 0 {
+		runArgs = append(append(runArgs, "--"), extra...)
+	}
+	if err := applyTaskOutput(chosen.key()); err != nil {
+		return err
+	}
+	fmt.Println(styleDim.Render(fmt.Sprintf("Running %s in %s: %s", chosen.Script, chosen.Dir, chosen.Command)))
+	exitCode := runProcess(bunPath, runArgs, chosen.absDir)
+	closeTaskOutput(exitCode)
+	if exitCode != 0 {
+		return exitCodeError(exitCode)
+	}
+	return nil
+}
+
+func listScripts(scripts []workspaceScript) error {
+	if jsonFlag {
+		if scripts == nil {
+			scripts = []workspaceScript{}
+		}
+		return printJSON(scripts)
+	}
+	last := ""
+	for _, s := range scripts {
+		if s.Package != last {
+			if last != "" {
+				fmt.Println()
+			}
+			fmt.Println(styleBold.Render(s.Package) + " " + styleDim.Render(s.Dir))
+			last = s.Package
+		}
+		fmt.Printf("  %-20s %s\n", s.Script, styleDim.Render(s.Command))
+	}
+	return nil
+}
+
+// findScript returns pkg's script, where pkg may leave out the scope:
+// "web" finds "@ellie/web".
+func findScript(scripts []workspaceScript, pkg, script string) (workspaceScript, error) {
+	found := false
+	for _, s := range scripts {
+		if s.Package != pkg && s.Package[strings.LastIndex(s.Package, "/")+1:] != pkg {
+			continue
+		}
+		found = true
+		if s.Script == script {
+			return s, nil
+		}
+	}
+	if !found {
+		return workspaceScript{}, fmt.Errorf("no workspace package %q — see ellie scripts --list", pkg)
+	}
+	return workspaceScript{}, fmt.Errorf("%s has no script %q — see ellie scripts --list", pkg, script)
+}
+
+// pickScript asks for a script, the project's recent ones first.
+func pickScript(root string, scripts []workspaceScript) (workspaceScript, error) {
+	if len(scripts) == 0 {
+		return workspaceScript{}, fmt.Errorf("no workspace package has scripts")
+	}
+	byKey := map[string]workspaceScript{}
+	for _, s := range scripts {
+		byKey[s.key()] = s
+	}
+	label := func(s workspaceScript) string {
+		return fmt.Sprintf("%s › %s  %s", s.Package, s.Script, styleDim.Render(s.Command))
+	}
+
+	var options []huh.Option[string]
+	seen := map[string]bool{}
+	if store, err := scriptsStore(); err == nil {
+		keys, _ := store.List(root)
+		for _, k := range keys {
+			if s, ok := byKey[k]; ok {
+				options = append(options, huh.NewOption(label(s)+" "+styleOk.Render("recent"), k))
+				seen[k] = true
+			}
+		}
+	}
+	for _, s := range scripts {
+		if !seen[s.key()] {
+			options = append(options, huh.NewOption(label(s), s.key()))
+		}
+	}
+
+	var key string
+	err := huh.NewSelect[string]().
+		Title("Run a script").
+		Options(options...).
+		Filtering(true).
+		Height(min(len(options)+2, 20)).
+		Value(&key).
+		Run()
+	if err != nil {
+		return workspaceScript{}, errSilent
+	}
+	return byKey[key], nil
+}
+
+func scriptsStore() (recent.Store, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return recent.Store{}, err
+	}
+	return recent.Store{Path: filepath.Join(dir, "recent-scripts.json"), Max: recentScripts}, nil
+}
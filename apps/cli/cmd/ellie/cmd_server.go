@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollbackNoRestart bool
+	rollbackTimeout   time.Duration
+)
+
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Manage production builds and run server admin actions",
+}
+
+var serverReleasesCmd = &cobra.Command{
+	Use:   "releases",
+	Short: "List archived production builds",
+	Args:  cobra.NoArgs,
+	RunE:  runServerReleases,
+}
+
+var serverRollbackCmd = &cobra.Command{
+	Use:   "rollback [release-id]",
+	Short: "Restore a previous production build and restart",
+	Long: `Swap dist/release back to an archived build from dist/releases,
+restart the production server the way ellie restart does, and wait for it
+to report healthy.
+
+Without an argument, rolls back to the build before the active one.
+Data and credentials stored inside dist/release are kept.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runServerRollback,
+}
+
+func init() {
+	serverRollbackCmd.Flags().BoolVar(&rollbackNoRestart, "no-restart", false, "Swap the build without restarting the server")
+	serverRollbackCmd.Flags().DurationVar(&rollbackTimeout, "wait-timeout", 60*time.Second, "How long to wait for the server to stop, and then to become healthy again")
+}
+
+func runServerReleases(cmd *cobra.Command, args []string) error {
+	root, err := findMonorepoRoot()
+	if err != nil {
+		return err
+	}
+	releases, err := listReleases(root)
+	if err != nil {
+		return err
+	}
+	if len(releases) == 0 {
+		fmt.Println(styleDim.Render("No archived builds yet — ellie build keeps one per successful build."))
+		return nil
+	}
+
+	active, _ := activeRelease(root)
+	for _, r := range releases {
+		marker := "  "
+		if r.ID == active.ID {
+			marker = styleOk.Render("● ")
+		}
+		detail := r.CreatedAt.Local().Format("2006-01-02 15:04")
+		if r.Branch != "" {
+			detail += "  " + r.Branch
+		}
+		if r.Dirty {
+			detail += " (uncommitted changes)"
+		}
+		fmt.Printf("%s%-24s %s\n", marker, r.ID, styleDim.Render(detail))
+	}
+	return nil
+}
+
+func runServerRollback(cmd *cobra.Command, args []string) error {
+	root, err := findMonorepoRoot()
+	if err != nil {
+		return err
+	}
+	releases, err := listReleases(root)
+	if err != nil {
+		return err
+	}
+	active, hasActive := activeRelease(root)
+
+	target, err := pickRollbackTarget(releases, active, hasActive, args)
+	if err != nil {
+		return err
+	}
+
+	swap := func() error {
+		if err := swapRelease(root, target.ID); err != nil {
+			return err
+		}
+		fmt.Println(styleOk.Render("✓"), "Restored build", styleBold.Render(target.ID))
+		return nil
+	}
+
+	if rollbackNoRestart {
+		// A daemon would start a stopped server again, on the old build.
+		if pid, _, running := runningProcess("start"); running && daemonStatus() == nil {
+			fmt.Println(styleDim.Render(fmt.Sprintf("Stopping production server (pid %d)...", pid)))
+			if err := stopProcess(pid, rollbackTimeout); err != nil {
+				return err
+			}
+		}
+		return swap()
+	}
+	return restartProduction(rollbackTimeout, rollbackTimeout, swap)
+}
+
+// pickRollbackTarget resolves the requested build, or the newest build
+// older than the active one.
+func pickRollbackTarget(releases []releaseMeta, active releaseMeta, hasActive bool, args []string) (releaseMeta, error) {
+	if len(releases) == 0 {
+		return releaseMeta{}, fmt.Errorf("no archived builds in dist/releases — nothing to roll back to")
+	}
+
+	if len(args) == 1 {
+		var matches []releaseMeta
+		for _, r := range releases {
+			if r.ID == args[0] {
+				return r, nil
+			}
+			if strings.HasPrefix(r.ID, args[0]) || (r.Commit != "" && strings.HasPrefix(r.Commit, args[0])) {
+				matches = append(matches, r)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return releaseMeta{}, fmt.Errorf("no archived build matches %q — see ellie server releases", args[0])
+		case 1:
+			return matches[0], nil
+		default:
+			return releaseMeta{}, fmt.Errorf("%q matches %d builds — use the full id", args[0], len(matches))
+		}
+	}
+
+	if !hasActive {
+		return releases[0], nil
+	}
+	for _, r := range releases {
+		if r.CreatedAt.Before(active.CreatedAt) {
+			return r, nil
+		}
+	}
+	return releaseMeta{}, fmt.Errorf("no build older than the active one (%s)", active.ID)
+}
+
+// waitHealthy polls /api/status until it answers 200 or timeout elapses.
+func waitHealthy(base string, timeout time.Duration) bool {
+	return waitHealthyFunc(func() string { return base }, timeout)
+}
+
+// waitHealthyFunc is waitHealthy for a server whose URL may change while
+// it starts: base is asked before each poll.
+func waitHealthyFunc(base func() string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if serverHealthy(context.Background(), base(), 2*time.Second) {
+			return true
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return false
+}
+
+// serverHealthy reports whether base's /api/status answers 200 within
+// timeout. It goes through httpClient, so it reaches the server however
+// other requests do: over its socket, through the proxy, with the client
+// certificate.
+func serverHealthy(ctx context.Context, base string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/api/status", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"ellie/apps/cli/internal/audit"
+	"ellie/apps/cli/internal/paths"
+)
+
+// ── server admin actions ────────────────────────────────────────────────────
+
+type adminAction struct {
+	Name string
+	Doc  string
+}
+
+// adminActions are the sensitive endpoints under /api/admin that
+// exec-admin calls, one POST /api/admin/<name> each.
+var adminActions = []adminAction{
+	{"flush-caches", "drop the server's model, search and attachment caches"},
+	{"rotate-secrets", "rotate the server's signing and encryption secrets; paired clients must pair again"},
+	{"reindex", "rebuild the server's search index"},
+}
+
+var (
+	adminOTP     bool
+	adminCode    string
+	adminHistory bool
+)
+
+var serverExecAdminCmd = &cobra.Command{
+	Use:   "exec-admin <action>",
+	Short: "Run a sensitive server admin action",
+	Long: `Run a sensitive admin action on the server:
+
+` + adminActionList() + `
+Every action needs a second confirmation. By default you type a phrase
+naming the action and the server; --otp instead has the server issue a
+one-time code through its own channel, and --code passes a code you
+already have, for use without a terminal.
+
+Each attempt — confirmed, refused or failed — is appended to a local,
+hash-chained log; --history shows it.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if adminHistory {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var names []string
+		for _, a := range adminActions {
+			names = append(names, a.Name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: runServerExecAdmin,
+}
+
+func init() {
+	serverExecAdminCmd.Flags().BoolVar(&adminOTP, "otp", false, "Confirm with a one-time code issued by the server")
+	serverExecAdminCmd.Flags().StringVar(&adminCode, "code", "", "Confirm with this server-issued one-time code")
+	serverExecAdminCmd.Flags().BoolVar(&adminHistory, "history", false, "Show the log of admin actions run from this machine")
+	serverExecAdminCmd.MarkFlagsMutuallyExclusive("otp", "code")
+}
+
+func adminActionList() string {
+	var b strings.Builder
+	for _, a := range adminActions {
+		fmt.Fprintf(&b, "  %-15s %s\n", a.Name, a.Doc)
+	}
+	return b.String()
+}
+
+// adminConfirmation is the second factor sent along with the action.
+type adminConfirmation struct {
+	Method      string `json:"method"` // phrase or code
+	Phrase      string `json:"phrase,omitempty"`
+	Code        string `json:"code,omitempty"`
+	ChallengeID string `json:"challengeId,omitempty"`
+}
+
+func runServerExecAdmin(cmd *cobra.Command, args []string) error {
+	if adminHistory {
+		return showAdminHistory()
+	}
+	action := args[0]
+	known := false
+	for _, a := range adminActions {
+		known = known || a.Name == action
+	}
+	if !known {
+		return fmt.Errorf("unknown admin action %q — one of:\n%s", action, strings.TrimRight(adminActionList(), "\n"))
+	}
+
+	var conf adminConfirmation
+	var err error
+	switch {
+	case adminCode != "":
+		conf = adminConfirmation{Method: "code", Code: strings.TrimSpace(adminCode)}
+	case adminOTP:
+		conf, err = confirmAdminWithCode(action)
+	default:
+		conf, err = confirmAdminWithPhrase(action)
+	}
+	if err != nil {
+		if errors.Is(err, errAdminRefused) {
+			recordAdminAction(audit.Entry{Op: action, Method: conf.Method, Detail: "confirmation refused"})
+			fmt.Println("Cancelled.")
+			return errSilent
+		}
+		return err
+	}
+
+	body, _ := json.Marshal(map[string]any{"confirmation": conf})
+	resp, err := httpClient.Post(baseURL()+"/api/admin/"+action, "application/json", bytes.NewReader(body))
+	if err != nil {
+		recordAdminAction(audit.Entry{Op: action, Method: conf.Method, Detail: err.Error()})
+		return fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	}
+	defer resp.Body.Close()
+	recordAdminAction(audit.Entry{Op: action, Method: conf.Method, OK: resp.StatusCode == http.StatusOK, Detail: fmt.Sprintf("HTTP %d", resp.StatusCode)})
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("the server at %s does not support admin action %s", baseURL(), action)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("the server refused %s — the confirmation was not accepted, or this client may not run admin actions", action)
+	default:
+		return serverError(resp)
+	}
+
+	var result struct {
+		Message string `json:"message"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	fmt.Println(styleOk.Render("✓"), "Ran", action, "on", baseURL())
+	if result.Message != "" {
+		fmt.Println(styleDim.Render("  " + result.Message))
+	}
+	return nil
+}
+
+var errAdminRefused = errors.New("confirmation refused")
+
+// confirmAdminWithPhrase has the user type the action and the server's
+// host, so a command pasted from history can't hit the wrong server.
+func confirmAdminWithPhrase(action string) (adminConfirmation, error) {
+	conf := adminConfirmation{Method: "phrase"}
+	if err := noPrompt("confirming "+action, "use --code with a server-issued one-time code"); err != nil {
+		return conf, err
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return conf, fmt.Errorf("confirming %s needs a terminal — use --code with a server-issued one-time code instead", action)
+	}
+	host := baseURL()
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	want := action + " " + host
+
+	var typed string
+	err := huh.NewInput().
+		Title(fmt.Sprintf("Type '%s' to confirm", want)).
+		Value(&typed).
+		Run()
+	if err != nil || strings.TrimSpace(typed) != want {
+		return conf, errAdminRefused
+	}
+	conf.Phrase = want
+	return conf, nil
+}
+
+// confirmAdminWithCode asks the server to issue a one-time code for
+// action, which it delivers out of band, and prompts for it.
+func confirmAdminWithCode(action string) (adminConfirmation, error) {
+	conf := adminConfirmation{Method: "code"}
+	body, _ := json.Marshal(map[string]string{"action": action})
+	resp, err := httpClient.Post(baseURL()+"/api/admin/challenge", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return conf, fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return conf, fmt.Errorf("the server at %s does not issue one-time codes — confirm with the typed phrase instead", baseURL())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return conf, serverError(resp)
+	}
+	var challenge struct {
+		ID          string `json:"challengeId"`
+		DeliveredTo string `json:"deliveredTo"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&challenge); err != nil {
+		return conf, fmt.Errorf("invalid response: %w", err)
+	}
+	conf.ChallengeID = challenge.ID
+
+	where := "The server issued a one-time code"
+	if challenge.DeliveredTo != "" {
+		where += " and sent it to " + challenge.DeliveredTo
+	}
+	code, err := promptSecret("one-time code", where+".")
+	if err != nil || code == "" {
+		return conf, errAdminRefused
+	}
+	conf.Code = code
+	return conf, nil
+}
+
+// adminAuditLog is the log of admin actions, kept apart from credential
+// changes.
+func adminAuditLog() (*audit.Log, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return nil, err
+	}
+	return audit.Open(filepath.Join(dir, "audit", "admin.jsonl")), nil
+}
+
+// recordAdminAction appends e to the admin log. Like credential audit
+// entries, a failure to record only warns.
+func recordAdminAction(e audit.Entry) {
+	e.Actor = auditActor()
+	e.Server = baseURL()
+	log, err := adminAuditLog()
+	if err == nil {
+		_, err = log.Append(e)
+	}
+	if err != nil {
+		warn("cannot write admin audit log: " + err.Error())
+	}
+}
+
+func showAdminHistory() error {
+	log, err := adminAuditLog()
+	if err != nil {
+		return err
+	}
+	entries, err := log.Entries()
+	if err != nil {
+		return err
+	}
+	brokenAt, verr := audit.Verify(entries)
+	printAuditEntries("Admin Action Log", "No admin actions recorded.", entries, brokenAt)
+	if verr != nil {
+		fmt.Fprintln(os.Stderr, styleErr.Render("✗"), "Admin log chain is broken:", verr)
+		return errSilent
+	}
+	return nil
+}
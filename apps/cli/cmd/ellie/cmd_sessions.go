@@ -0,0 +1,603 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/chatui"
+	"ellie/apps/cli/internal/sessionstats"
+	"ellie/apps/cli/pkg/ellieapi"
+)
+
+var (
+	sessionsStatsChart bool
+	sessionsLimit      int
+	sessionsDeleteYes  bool
+	sessionsExportFmt  string
+	sessionsExportOut  string
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List, resume, delete and inspect conversations",
+	Long: `Sessions are the server's conversations, whether started here or in the
+web UI: ellie sessions resume continues one in the terminal, and what is
+said there shows up in the web UI too.
+
+Commands that take a session id also take a unique prefix of one, as
+shown by ellie sessions list.`,
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List sessions, most recently active first",
+	Args:  cobra.NoArgs,
+	RunE:  runSessionsList,
+}
+
+var sessionsResumeCmd = &cobra.Command{
+	Use:   "resume <id>",
+	Short: "Continue a session in the chat TUI",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSessionsResume,
+}
+
+var sessionsDeleteCmd = &cobra.Command{
+	Use:   "delete <id>...",
+	Short: "Delete sessions and their messages",
+	Long: `Delete sessions and all their messages from the server. Files attached
+in them are kept; ellie attachments delete --unlinked removes those no
+session uses any more.
+
+Servers that can't delete a session itself delete its messages instead,
+and keep listing it, empty.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSessionsDelete,
+}
+
+var sessionsExportCmd = &cobra.Command{
+	Use:   "export <id>",
+	Short: "Write a session's transcript as Markdown, JSON or HTML",
+	Long: `Write the conversation of a session, with its tool calls and their
+results and the time of each message, to standard output or the file
+--output names.
+
+--format is md (the default), json — the messages as ellie's chat shows
+them, for scripts — or html, one page with nothing to load besides. With
+--output and no --format, the format follows the file's extension:
+
+  ellie sessions export 3f2a -o review.html`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionsExport,
+}
+
+var sessionsStatsCmd = &cobra.Command{
+	Use:   "stats <id>",
+	Short: "Show tokens, cost, latency and tool calls per turn of a session",
+	Long: `Break a session down turn by turn: the models that answered, tokens in
+and out, cost, how long the response took and which tools it called,
+followed by the model switches over the whole conversation.
+
+The id is a session (thread) id, a unique prefix of one, or a branch id.
+For a session, its main branch is shown. --chart adds sparklines of
+tokens, cost and latency per turn, to spot the turns that burn budget.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionsStats,
+}
+
+func init() {
+	sessionsStatsCmd.Flags().BoolVar(&sessionsStatsChart, "chart", false, "Add sparklines of tokens, cost and latency per turn")
+	sessionsListCmd.Flags().IntVar(&sessionsLimit, "limit", 20, "Show only the N most recent sessions; 0 shows all")
+	sessionsResumeCmd.Flags().StringVar(&transcriptDir, "transcript-dir", ".", "Directory to save transcripts")
+	sessionsDeleteCmd.Flags().BoolVarP(&sessionsDeleteYes, "yes", "y", false, "Delete without asking for confirmation")
+	sessionsExportCmd.Flags().StringVar(&sessionsExportFmt, "format", "", "Transcript format: md, json or html (default md)")
+	sessionsExportCmd.Flags().StringVarP(&sessionsExportOut, "output", "o", "", "File to write the transcript to (default: standard output)")
+	jsonCommands[sessionsListCmd] = true
+}
+
+type threadBranch struct {
+	ID             string  `json:"id"`
+	ParentBranchID *string `json:"parentBranchId"`
+	UpdatedAt      int64   `json:"updatedAt"`
+}
+
+// resolveSession maps id to a branch, trying it as a thread id, then as a
+// thread id prefix, and finally as a branch id. thread is nil for the last.
+func resolveSession(id string) (thread *chatui.ThreadEntry, branchID string, err error) {
+	thread, err = fetchThread(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if thread == nil {
+		if thread, err = findThreadByPrefix(id); err != nil {
+			return nil, "", err
+		}
+	}
+	if thread == nil {
+		return nil, id, nil
+	}
+	branchID, err = mainBranch(thread.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if branchID == "" {
+		return nil, "", fmt.Errorf("session %s has no messages yet", thread.ID)
+	}
+	return thread, branchID, nil
+}
+
+// mainBranch returns the branch a thread started on, or "" when it has
+// no branches yet.
+func mainBranch(threadID string) (string, error) {
+	resp, err := httpClient.Get(baseURL() + "/api/threads/" + url.PathEscape(threadID) + "/branches")
+	if err != nil {
+		return "", fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", serverError(resp)
+	}
+	var branches []threadBranch
+	if err := json.NewDecoder(resp.Body).Decode(&branches); err != nil {
+		return "", fmt.Errorf("invalid response: %w", err)
+	}
+	for _, b := range branches {
+		if b.ParentBranchID == nil {
+			return b.ID, nil
+		}
+	}
+	if len(branches) == 0 {
+		return "", nil
+	}
+	return branches[0].ID, nil
+}
+
+// fetchThread returns nil, nil when the server has no thread with that id.
+func fetchThread(id string) (*chatui.ThreadEntry, error) {
+	resp, err := httpClient.Get(baseURL() + "/api/threads/" + url.PathEscape(id))
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case 200:
+	case 404:
+		return nil, nil
+	default:
+		return nil, serverError(resp)
+	}
+	var t chatui.ThreadEntry
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return &t, nil
+}
+
+func fetchThreads() ([]chatui.ThreadEntry, error) {
+	resp, err := httpClient.Get(baseURL() + "/api/threads")
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, serverError(resp)
+	}
+	var threads []chatui.ThreadEntry
+	if err := json.NewDecoder(resp.Body).Decode(&threads); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return threads, nil
+}
+
+func findThreadByPrefix(prefix string) (*chatui.ThreadEntry, error) {
+	threads, err := fetchThreads()
+	if err != nil {
+		return nil, err
+	}
+	var match *chatui.ThreadEntry
+	for i, t := range threads {
+		if !strings.HasPrefix(t.ID, prefix) {
+			continue
+		}
+		if match != nil {
+			return nil, fmt.Errorf("%q matches more than one session — give more of the id", prefix)
+		}
+		match = &threads[i]
+	}
+	return match, nil
+}
+
+func fetchBranchMessages(branchID string) ([]sessionstats.Message, error) {
+	resp, err := httpClient.Get(baseURL() + "/api/chat/branches/" + url.PathEscape(branchID) + "/messages")
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == 404 {
+		return nil, fmt.Errorf("no session or branch %s", branchID)
+	}
+	if resp.StatusCode != 200 {
+		return nil, serverError(resp)
+	}
+	return sessionstats.ParseMessages(resp.Body)
+}
+
+// exportBranchEvents streams the events of a branch, including those it
+// inherits from the branches it was forked from, into e as they arrive.
+func exportBranchEvents(branchID string, e *chatui.Exporter) error {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := apiClient().Download(context.Background(), "/api/chat/branches/"+url.PathEscape(branchID)+"/events", pw)
+		pw.CloseWithError(err)
+	}()
+	// Stops the download when decoding fails first.
+	defer pr.Close()
+
+	dec := json.NewDecoder(pr)
+	if _, err := dec.Token(); err != nil {
+		return branchEventsError(branchID, err)
+	}
+	for dec.More() {
+		var row chatui.EventRow
+		if err := dec.Decode(&row); err != nil {
+			return branchEventsError(branchID, err)
+		}
+		if err := e.Add(row); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return branchEventsError(branchID, err)
+	}
+	return nil
+}
+
+func branchEventsError(branchID string, err error) error {
+	var unreachable *ellieapi.UnreachableError
+	switch {
+	case ellieapi.IsStatus(err, http.StatusNotFound):
+		return fmt.Errorf("no session or branch %s", branchID)
+	case errors.As(err, &unreachable):
+		return fmt.Errorf("cannot reach server at %s — make sure the server is running", baseURL())
+	case errors.As(err, new(*ellieapi.Error)):
+		return apiError(err)
+	}
+	return fmt.Errorf("invalid response: %w", err)
+}
+
+func runSessionsStats(cmd *cobra.Command, args []string) error {
+	thread, branchID, err := resolveSession(args[0])
+	if err != nil {
+		return err
+	}
+	msgs, err := fetchBranchMessages(branchID)
+	if err != nil {
+		return err
+	}
+	stats := sessionstats.Compute(msgs)
+
+	if jsonFlag {
+		return printJSON(stats)
+	}
+
+	title := "Branch " + branchID
+	if thread != nil {
+		title = "Session " + thread.ID
+		if thread.Title != nil && *thread.Title != "" {
+			title += " — " + *thread.Title
+		}
+	}
+	fmt.Println(styleBold.Render(title))
+	fmt.Println(strings.Repeat("─", 40))
+	if len(stats.Turns) == 0 {
+		fmt.Println(styleDim.Render("No messages yet."))
+		return nil
+	}
+	fmt.Printf("%d turns over %s · %s tokens · %s\n\n",
+		len(stats.Turns), formatUptime(stats.Duration), sessionstats.FormatTokens(stats.Tokens()), sessionstats.FormatCost(stats.Cost))
+
+	if err := stats.WriteTable(os.Stdout); err != nil {
+		return err
+	}
+
+	if len(stats.ModelSwitches) > 0 {
+		fmt.Println()
+		fmt.Println(styleBold.Render("Model switches"))
+		for _, sw := range stats.ModelSwitches {
+			fmt.Printf("  turn %d: %s → %s\n", sw.Turn, sw.From, sw.To)
+		}
+	}
+	if stats.Errors > 0 {
+		fmt.Println()
+		fmt.Println(styleErr.Render(fmt.Sprintf("%d model call(s) ended in an error", stats.Errors)))
+	}
+
+	if sessionsStatsChart {
+		tokens := make([]float64, len(stats.Turns))
+		cost := make([]float64, len(stats.Turns))
+		latency := make([]float64, len(stats.Turns))
+		for i, t := range stats.Turns {
+			tokens[i] = float64(t.Tokens())
+			cost[i] = t.Cost
+			latency[i] = t.Latency.Seconds()
+		}
+		fmt.Println()
+		fmt.Printf("  %-8s %s  max %s\n", "Tokens", sessionstats.Sparkline(tokens), sessionstats.FormatTokens(int64(maxOf(tokens))))
+		fmt.Printf("  %-8s %s  max %s\n", "Cost", sessionstats.Sparkline(cost), sessionstats.FormatCost(maxOf(cost)))
+		fmt.Printf("  %-8s %s  max %.1fs\n", "Latency", sessionstats.Sparkline(latency), maxOf(latency))
+	}
+	return nil
+}
+
+func maxOf(values []float64) float64 {
+	var m float64
+	for _, v := range values {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+func runSessionsList(cmd *cobra.Command, args []string) error {
+	threads, err := fetchThreads()
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(threads, func(i, j int) bool { return threads[i].UpdatedAt > threads[j].UpdatedAt })
+	shown := threads
+	if sessionsLimit > 0 && len(shown) > sessionsLimit {
+		shown = shown[:sessionsLimit]
+	}
+	if jsonFlag {
+		return printJSON(shown)
+	}
+
+	var currentID string
+	if current, err := chatui.NewHTTPClient(baseURL()).GetAssistantCurrent(context.Background()); err == nil {
+		currentID = current.ThreadID
+	}
+	fmt.Println()
+	fmt.Println(styleBold.Render("Sessions"))
+	fmt.Println(strings.Repeat("─", 40))
+	if len(shown) == 0 {
+		fmt.Println(styleDim.Render("  No sessions."))
+		fmt.Println()
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	// The title goes last: styled text would throw the columns off.
+	fmt.Fprintln(tw, "  ID\tACTIVE\tAGENT\tTITLE")
+	for _, t := range shown {
+		mark := " "
+		if t.ID == currentID {
+			mark = "*"
+		}
+		title := styleDim.Render("(untitled)")
+		if t.Title != nil && *t.Title != "" {
+			title = truncate(*t.Title, 50)
+		}
+		if t.State == "view_only" {
+			title += styleDim.Render(" (read-only)")
+		}
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\t%s\n", mark, t.ID, formatDuration(time.Since(time.UnixMilli(t.UpdatedAt))), t.AgentType, title)
+	}
+	tw.Flush()
+	fmt.Println()
+	if len(shown) < len(threads) {
+		fmt.Println(styleDim.Render(fmt.Sprintf("  %d of %d sessions — pass --limit 0 to list all", len(shown), len(threads))))
+	}
+	if currentID != "" {
+		fmt.Println(styleDim.Render("  * the session ellie chat continues"))
+	}
+	fmt.Println()
+	return nil
+}
+
+func runSessionsResume(cmd *cobra.Command, args []string) error {
+	if err := noPrompt("resuming a session", "use ellie ask, or ellie chat --prompt"); err != nil {
+		return err
+	}
+	thread, branchID, err := resolveSession(args[0])
+	if err != nil {
+		return err
+	}
+	if thread == nil {
+		return fmt.Errorf("no session %q — see ellie sessions list", args[0])
+	}
+	if thread.State == "view_only" {
+		warn("session " + thread.ID + " is read-only: it can be read but not added to")
+	}
+	base := requireBaseURL()
+	if err := requireChatServer(chatui.NewHTTPClient(base), base); err != nil {
+		return err
+	}
+	return runChatTUI(base, branchID)
+}
+
+func runSessionsDelete(cmd *cobra.Command, args []string) error {
+	var targets []*chatui.ThreadEntry
+	for _, id := range args {
+		thread, err := fetchThread(id)
+		if err == nil && thread == nil {
+			thread, err = findThreadByPrefix(id)
+		}
+		if err != nil {
+			return err
+		}
+		if thread == nil {
+			return fmt.Errorf("no session %q — see ellie sessions list", id)
+		}
+		targets = append(targets, thread)
+	}
+
+	if !sessionsDeleteYes {
+		if err := noPrompt("confirming the deletion", "pass --yes"); err != nil {
+			return err
+		}
+		title := fmt.Sprintf("Delete %d sessions and all their messages?", len(targets))
+		if len(targets) == 1 {
+			name := targets[0].ID
+			if t := targets[0].Title; t != nil && *t != "" {
+				name = fmt.Sprintf("%q", truncate(*t, 50))
+			}
+			title = "Delete session " + name + " and all its messages?"
+		}
+		var confirm bool
+		err := huh.NewConfirm().
+			Title(title).
+			Affirmative("Delete").
+			Negative("Cancel").
+			Value(&confirm).
+			Run()
+		if err != nil || !confirm {
+			fmt.Println("Cancelled.")
+			return errSilent
+		}
+	}
+
+	for i, t := range targets {
+		kept, err := deleteSession(t.ID)
+		if err != nil {
+			if i > 0 {
+				fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf("%d of %d sessions were deleted before the failure", i, len(targets))))
+			}
+			return fmt.Errorf("deleting %s: %w", t.ID, err)
+		}
+		if kept {
+			fmt.Println(styleOk.Render("✓"), "Deleted the messages of", t.ID, styleDim.Render("— this server can't delete the session itself"))
+			continue
+		}
+		fmt.Println(styleOk.Render("✓"), "Deleted", t.ID)
+	}
+	return nil
+}
+
+// deleteSession deletes a thread. Servers without DELETE /api/threads/:id
+// answer 404 for a thread that exists; its branches are deleted instead,
+// and kept reports that the empty thread remains.
+func deleteSession(threadID string) (kept bool, err error) {
+	err = deleteRequest("/api/threads/" + url.PathEscape(threadID))
+	if !ellieapi.IsStatus(err, http.StatusNotFound) && !ellieapi.IsStatus(err, http.StatusMethodNotAllowed) {
+		return false, err
+	}
+	resp, err := httpClient.Get(baseURL() + "/api/threads/" + url.PathEscape(threadID) + "/branches")
+	if err != nil {
+		return false, fmt.Errorf("cannot reach server at %s", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return false, serverError(resp)
+	}
+	var branches []threadBranch
+	if err := json.NewDecoder(resp.Body).Decode(&branches); err != nil {
+		return false, fmt.Errorf("invalid response: %w", err)
+	}
+	for _, b := range branches {
+		if err := deleteRequest("/api/chat/branches/" + url.PathEscape(b.ID) + "/messages"); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// deleteRequest sends DELETE path to the server, returning an
+// *ellieapi.Error for an error status.
+func deleteRequest(path string) error {
+	req, err := http.NewRequest(http.MethodDelete, baseURL()+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach server at %s", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 && resp.StatusCode != 204 {
+		return serverError(resp)
+	}
+	return nil
+}
+
+func runSessionsExport(cmd *cobra.Command, args []string) error {
+	format := sessionsExportFmt
+	if format == "" {
+		format = "md"
+		switch strings.ToLower(filepath.Ext(sessionsExportOut)) {
+		case ".json":
+			format = "json"
+		case ".html", ".htm":
+			format = "html"
+		}
+	}
+	if !slices.Contains(chatui.ExportFormats, format) {
+		return fmt.Errorf("unknown format %q — use md, json or html", format)
+	}
+
+	thread, branchID, err := resolveSession(args[0])
+	if err != nil {
+		return err
+	}
+	info := chatui.ExportInfo{BranchID: branchID, Exported: time.Now()}
+	if thread != nil {
+		info.ThreadID = thread.ID
+		if thread.Title != nil {
+			info.Title = *thread.Title
+		}
+	}
+	export := func(w io.Writer) (int, error) {
+		e, err := chatui.NewExporter(w, format, info)
+		if err != nil {
+			return 0, err
+		}
+		if err := exportBranchEvents(branchID, e); err != nil {
+			return 0, err
+		}
+		err = e.Close()
+		return e.Messages(), err
+	}
+
+	if sessionsExportOut == "" {
+		_, err := export(os.Stdout)
+		return err
+	}
+	// Write beside the file and rename, so a failure leaves any earlier
+	// export whole.
+	f, err := os.CreateTemp(filepath.Dir(sessionsExportOut), "."+filepath.Base(sessionsExportOut)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	n, err := export(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), sessionsExportOut)
+	}
+	if err != nil {
+		return fmt.Errorf("write %s: %w", sessionsExportOut, err)
+	}
+	fmt.Fprintln(os.Stderr, styleOk.Render("✓"), fmt.Sprintf("Exported %d messages to %s", n, sessionsExportOut))
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/activeterm"
+	"github.com/charmbracelet/wish/logging"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/chatui"
+	"ellie/apps/cli/internal/paths"
+)
+
+var (
+	sshServeHost           string
+	sshServePort           int
+	sshServeHostKey        string
+	sshServeAuthorizedKeys string
+)
+
+var sshServeCmd = &cobra.Command{
+	Use:   "ssh-serve",
+	Short: "Serve the chat TUI over SSH",
+	Long: `Serve the chat TUI over SSH so teammates can attach to this ellie
+instance with a plain ssh client:
+
+  ssh -p 2222 localhost
+
+Every session gets its own chat view on the server's current branch.
+Only keys listed in the authorized keys file are accepted.`,
+	RunE: runSSHServe,
+}
+
+func init() {
+	sshServeCmd.Flags().StringVar(&sshServeHost, "host", "0.0.0.0", "Address to listen on")
+	sshServeCmd.Flags().IntVar(&sshServePort, "port", 2222, "Port to listen on")
+	sshServeCmd.Flags().StringVar(&sshServeHostKey, "host-key", "", "Host key path (generated if missing)")
+	sshServeCmd.Flags().StringVar(&sshServeAuthorizedKeys, "authorized-keys", "", "Authorized keys file (default ~/.ssh/authorized_keys)")
+}
+
+func runSSHServe(cmd *cobra.Command, args []string) error {
+	base := requireBaseURL()
+
+	client := chatui.NewHTTPClient(base)
+	if _, err := client.GetStatus(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, styleErr.Render("Cannot connect to server at "+base))
+		fmt.Fprintln(os.Stderr, styleDim.Render("Make sure the server is running (ellie dev or ellie start)"))
+		return errSilent
+	}
+
+	hostKey := sshServeHostKey
+	if hostKey == "" {
+		dir, err := paths.Config()
+		if err != nil {
+			return err
+		}
+		hostKey = filepath.Join(dir, "ssh_host_ed25519")
+	}
+	if err := os.MkdirAll(filepath.Dir(hostKey), 0o700); err != nil {
+		return fmt.Errorf("cannot create host key directory: %w", err)
+	}
+
+	authorizedKeys := sshServeAuthorizedKeys
+	if authorizedKeys == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("cannot determine home directory: %w", err)
+		}
+		authorizedKeys = filepath.Join(home, ".ssh", "authorized_keys")
+	}
+	if _, err := os.Stat(authorizedKeys); err != nil {
+		return fmt.Errorf("authorized keys file %s not found — pass --authorized-keys", authorizedKeys)
+	}
+
+	addr := net.JoinHostPort(sshServeHost, strconv.Itoa(sshServePort))
+	srv, err := wish.NewServer(
+		wish.WithAddress(addr),
+		wish.WithHostKeyPath(hostKey),
+		wish.WithAuthorizedKeys(authorizedKeys),
+		wish.WithMiddleware(
+			chatSessionMiddleware(base),
+			activeterm.Middleware(),
+			logging.Middleware(),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("cannot create SSH server: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	fmt.Println(styleOk.Render("✓"), "Serving chat over SSH on", styleBold.Render(addr))
+	fmt.Println(styleDim.Render(fmt.Sprintf("  Connect with: ssh -p %d %s", sshServePort, sshServeHost)))
+
+	select {
+	case err := <-errCh:
+		if err != nil && !errors.Is(err, ssh.ErrServerClosed) {
+			return fmt.Errorf("SSH server: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	fmt.Println(styleDim.Render("Shutting down SSH server..."))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
+		return fmt.Errorf("SSH shutdown: %w", err)
+	}
+	return nil
+}
+
+// chatSessionMiddleware runs a chat TUI per SSH session. It drives the
+// same chatui.Model as `ellie chat`, wired to the session's I/O instead
+// of the local terminal, since wish's bubbletea middleware targets the
+// v1 API.
+func chatSessionMiddleware(base string) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			pty, winCh, ok := s.Pty()
+			if !ok {
+				wish.Fatalln(s, "ellie ssh-serve requires an interactive terminal (ssh -t)")
+				return
+			}
+
+			ctx, cancel := context.WithCancel(s.Context())
+			defer cancel()
+
+			current, err := chatui.NewHTTPClient(base).GetAssistantCurrent(ctx)
+			if err != nil {
+				wish.Fatalln(s, "Cannot resolve current branch: "+err.Error())
+				return
+			}
+
+			model := chatui.NewModel(base, current.BranchID, os.TempDir())
+			p := tea.NewProgram(model,
+				tea.WithContext(ctx),
+				tea.WithInput(s),
+				tea.WithOutput(s),
+				tea.WithEnvironment(append(s.Environ(), "TERM="+pty.Term)),
+				tea.WithWindowSize(pty.Window.Width, pty.Window.Height),
+				tea.WithoutSignalHandler(),
+			)
+
+			go model.StartSSELoop(ctx, p.Send)
+			go func() {
+				for w := range winCh {
+					p.Send(tea.WindowSizeMsg{Width: w.Width, Height: w.Height})
+				}
+			}()
+
+			if _, err := p.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+				wish.Errorln(s, err)
+			}
+			next(s)
+		}
+	}
+}
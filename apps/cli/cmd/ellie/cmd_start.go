@@ -2,16 +2,95 @@ package main
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/daemon"
+)
+
+// supervisedEnv marks the background `ellie start` spawned by --detach,
+// so it doesn't overwrite the invocation saved by its parent.
+const supervisedEnv = "ELLIE_START_SUPERVISED"
+
+var (
+	startDetach      bool
+	startLazy        bool
+	startOnlyChanged bool
+	startBaseBranch  string
+	startTimeout     time.Duration
+	stopTimeout      time.Duration
 )
 
 var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Run production server (requires build)",
-	RunE:  runStart,
+	Long: `Run the production server from the last ellie build.
+
+With --lazy, ellie listens on the server's port and only launches the
+server when the first request arrives, handing it the listening socket
+(systemd-style socket activation: LISTEN_FDS=1, the socket as fd 3). On a
+shared dev box an idle server then costs nothing until someone uses it.
+
+With --restart on-failure, a server that exits with an error is started
+again after 1s, then 2s, 4s… up to a minute; on-failure:5 gives up after
+five restarts. A server in a crash loop — 5 crashes within 2 minutes, or
+crash_loop.failures within crash_loop.window — is not restarted either;
+ellie shows its last error output instead. ellie status shows the
+restarts and the last exit code.
+
+--port sets the port the server binds (as PORT and in API_BASE_URL);
+--port auto takes the first free one from the configured port up and
+prints it. While the server runs, ellie commands find it there unless
+server.url is set.
+
+When the server exits with an error within 30s, ellie reads the end of
+its output for known causes — a port in use, a missing environment
+variable, failed migrations — and in a terminal offers one-key fixes
+and a retry.
+
+With --wait, the server runs in the background as with --detach, and
+ellie returns once it accepts requests, or fails after --wait-timeout.
+
+With --only-changed, ellie first rebuilds the release when any package
+changed since --base (main by default), and otherwise starts the last
+build as it is.`,
+	RunE: runStart,
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Restart the production server with its previous flags",
+	Long: `Stop the running production server, wait for it to shut down, and
+start it again with the same flags as the last ellie start.`,
+	Args: cobra.NoArgs,
+	RunE: runRestart,
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the production server started with ellie start",
+	Args:  cobra.NoArgs,
+	RunE:  runStop,
+}
+
+func init() {
+	startCmd.Flags().BoolVarP(&startDetach, "detach", "d", false, "Run in the background (stop with ellie stop)")
+	startCmd.Flags().BoolVar(&startLazy, "lazy", false, "Start the server only when the first request arrives on its port")
+	startCmd.Flags().BoolVar(&startOnlyChanged, "only-changed", false, "Rebuild the release first if packages changed since --base")
+	startCmd.Flags().StringVar(&startBaseBranch, "base", "main", "Base branch for --only-changed")
+	startCmd.Flags().DurationVar(&startTimeout, "wait-timeout", 60*time.Second, "With --detach or --wait, how long to wait for the server to become healthy")
+	stopCmd.Flags().DurationVar(&stopTimeout, "wait-timeout", 15*time.Second, "How long to wait for a clean shutdown before killing")
+	restartCmd.Flags().DurationVar(&stopTimeout, "wait-timeout", 15*time.Second, "How long to wait for a clean shutdown before killing")
 }
 
 func runStart(cmd *cobra.Command, args []string) error {
@@ -20,16 +99,342 @@ func runStart(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	startScript := filepath.Join(root, "dist", "release", "start.sh")
-	if _, err := os.Stat(startScript); os.IsNotExist(err) {
-		return fmt.Errorf("no production build found at dist/release — build the project first")
+	if startOnlyChanged {
+		if err := rebuildChanged(root, startBaseBranch); err != nil {
+			return err
+		}
+	}
+
+	startScript, err := releaseStartScript(root)
+	if err != nil {
+		return err
+	}
+
+	wd, err := watchdogFor(cmd, "start")
+	if err != nil {
+		return err
+	}
+	policy, err := parseRestartPolicy(startRestart)
+	if err != nil {
+		return err
+	}
+	port, err := applyServerPort()
+	if err != nil {
+		return err
+	}
+
+	if pid, _, ok := runningProcess("start"); ok {
+		return fmt.Errorf("production server is already running (pid %d) — stop it with ellie stop", pid)
+	}
+	if st := daemonStatus(); st != nil {
+		return fmt.Errorf("production server is already running under ellie daemon (pid %d) — stop it with ellie daemon stop", st.PID)
+	}
+
+	if startWait {
+		startDetach = true
+	}
+
+	// Record the user's own command line; build --and-start also lands here.
+	if cmd.Name() == "start" && os.Getenv(supervisedEnv) == "" {
+		if err := saveInvocation("start"); err != nil {
+			warn("cannot record start flags for ellie restart: " + err.Error())
+		}
 	}
 
+	// A server that fails to come up gets triaged, unless it exited
+	// because it was told to stop. (The foreground server gets these
+	// signals anyway; a detached start is left to die of them.)
+	stop := make(chan os.Signal, 1)
+	if !startDetach {
+		signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(stop)
+	}
+	for {
+		started := time.Now()
+		if startDetach {
+			err = startDetached(root, wd, port)
+		} else {
+			err = startForeground(root, startScript, wd, policy, port)
+		}
+		if err == nil || len(stop) > 0 || !triageable(err, started, policy) {
+			return err
+		}
+		if !triageStart(root, started, startDetach) {
+			return err
+		}
+		if port, err = applyServerPort(); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+}
+
+// startForeground runs the server from startScript until it exits.
+func startForeground(root, startScript string, wd watchdogSettings, policy restartPolicy, port int) error {
 	fmt.Println(styleBold.Render("Starting production server..."))
 	fmt.Println()
 
-	if exitCode := runProcess(startScript, []string{}, root); exitCode != 0 {
+	var ln *net.TCPListener
+	var err error
+	if startLazy {
+		if ln, err = lazyListen(); err != nil {
+			return err
+		}
+	}
+
+	log, err := openLogStore("start", "server")
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	removePid, err := writePidfile("start", os.Getpid())
+	if err != nil {
+		return err
+	}
+	defer removePid()
+	if port != 0 {
+		removePort, err := writeServerPort("start", port)
+		if err != nil {
+			return err
+		}
+		defer removePort()
+	}
+	want := port
+	if want == 0 {
+		want, _ = urlPort(baseURL())
+	}
+	serverPortWatch = watchServerPort("start", want)
+	defer serverPortWatch.Close()
+
+	if ln != nil {
+		if started, err := lazyStart(ln); !started {
+			return err
+		}
+	}
+
+	if exitCode := runRestarting(startScript, []string{}, root, log, wd, policy); exitCode != 0 {
+		return exitCodeError(exitCode)
+	}
+	return nil
+}
+
+// rebuildChanged rebuilds the release when any package changed since base.
+func rebuildChanged(root, base string) error {
+	filters, err := affectedFilters(root, base)
+	if err != nil {
+		return err
+	}
+	if filters != nil && len(filters) == 0 {
+		fmt.Println(styleDim.Render("No packages changed since " + base + " — starting the last build."))
+		return nil
+	}
+	return buildRelease(root)
+}
+
+// releaseStartScript returns the start script of the last ellie build.
+func releaseStartScript(root string) (string, error) {
+	script := filepath.Join(root, "dist", "release", "start.sh")
+	if _, err := os.Stat(script); os.IsNotExist(err) {
+		return "", fmt.Errorf("no production build found at dist/release — run ellie build first (or ellie build --and-start)")
+	}
+	return script, nil
+}
+
+// startDetached re-runs `ellie start` in its own session with no
+// terminal. That process supervises the server exactly like a foreground
+// start (pidfile, log capture, signal forwarding). We wait until the
+// server answers or the child exits, then leave it running. The child
+// gets the watchdog settings as flags, since it can't see ours.
+func startDetached(root string, wd watchdogSettings, port int) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate ellie executable: %w", err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+
+	args := append([]string{"start"}, wd.flags()...)
+	args = append(args, "--restart="+startRestart)
+	if port != 0 {
+		args = append(args, "--port="+strconv.Itoa(port))
+	}
+	if startLazy {
+		args = append(args, "--lazy")
+	}
+	child := exec.Command(self, args...)
+	child.Dir = root
+	child.Env = append(os.Environ(), supervisedEnv+"=1")
+	child.Stdin, child.Stdout, child.Stderr = devNull, devNull, devNull
+	child.SysProcAttr = detachAttr()
+	logCommand(child)
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("cannot start background server: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- child.Wait() }()
+
+	healthy := make(chan bool, 1)
+	base := baseURL()
+	if startLazy {
+		// A health check would start the server; wait for the socket.
+		go func() { healthy <- waitListening(startTimeout) }()
+	} else {
+		go func() {
+			healthy <- waitHealthyFunc(func() string { return serverBaseOf("start", base) }, startTimeout)
+		}()
+	}
+
+	done := spinner(fmt.Sprintf("Starting production server in the background (pid %d)...", child.Process.Pid))
+	select {
+	case err := <-exited:
+		done()
+		fmt.Fprintln(os.Stderr, styleErr.Render("✗"), "Server exited during startup:", err)
+		fmt.Fprintln(os.Stderr, styleDim.Render("  See ellie logs --source start"))
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+			return exitCodeError(exitErr.ExitCode())
+		}
+		return errSilent
+	case ok := <-healthy:
+		done()
+		if !ok {
+			fmt.Fprintln(os.Stderr, styleErr.Render("✗"), fmt.Sprintf("Server did not become healthy within %s (still running, pid %d)", startTimeout, child.Process.Pid))
+			fmt.Fprintln(os.Stderr, styleDim.Render("  See ellie logs --source start, or stop it with ellie stop"))
+			return errSilent
+		}
+	}
+
+	if startLazy {
+		fmt.Println(styleOk.Render("✓"), "Listening at", styleBold.Render(base), styleDim.Render(fmt.Sprintf("(pid %d) — the server starts with the first request", child.Process.Pid)))
+	} else {
+		fmt.Println(styleOk.Render("✓"), "Production server running at", styleBold.Render(serverBaseOf("start", base)), styleDim.Render(fmt.Sprintf("(pid %d)", child.Process.Pid)))
+	}
+	fmt.Println(styleDim.Render("  Follow output with: ellie logs -f --source start"))
+	fmt.Println(styleDim.Render("  Stop it with:       ellie stop"))
+	return nil
+}
+
+func runStop(cmd *cobra.Command, args []string) error {
+	if stopDev {
+		return stopDevServer()
+	}
+	if st := daemonStatus(); st != nil {
+		fmt.Println(styleDim.Render(fmt.Sprintf("Stopping the daemon and its production server (pid %d)...", st.PID)))
+		if err := stopDaemon(st.PID, stopTimeout+10*time.Second); err != nil {
+			return err
+		}
+		fmt.Println(styleOk.Render("✓"), "Stopped")
+		return nil
+	}
+
+	pid, _, ok := runningProcess("start")
+	if !ok {
+		fmt.Println(styleDim.Render("Production server is not running."))
+		return nil
+	}
+
+	fmt.Println(styleDim.Render(fmt.Sprintf("Stopping production server (pid %d)...", pid)))
+	if err := stopProcess(pid, stopTimeout); err != nil {
+		return err
+	}
+	// The supervisor removes its own pidfile on a clean exit; clear it
+	// here too in case it had to be killed.
+	if path, err := pidPath("start"); err == nil {
+		_ = os.Remove(path)
+	}
+	fmt.Println(styleOk.Render("✓"), "Stopped")
+	return nil
+}
+
+func runRestart(cmd *cobra.Command, args []string) error {
+	if daemonStatus() == nil {
+		_, err := loadInvocation("start")
+		if _, _, running := runningProcess("start"); err != nil && !running {
+			return fmt.Errorf("production server is not running and no previous start was recorded — use ellie start")
+		}
+	}
+	return restartProduction(stopTimeout, 0, nil)
+}
+
+// restartProduction restarts the production server the way it was last
+// started: through the daemon when one supervises it, else by stopping it
+// and replaying the last ellie start. stop bounds the shutdown; wait,
+// when set, replaces the start's --wait-timeout. between, when set, runs
+// once the server is stopped, or before the daemon is asked to restart.
+func restartProduction(stop, wait time.Duration, between func() error) error {
+	if st := daemonStatus(); st != nil {
+		if between != nil {
+			if err := between(); err != nil {
+				return err
+			}
+		}
+		if wait == 0 {
+			wait = startTimeout
+		}
+		return restartUnderDaemon(st, stop+wait)
+	}
+
+	inv, err := loadInvocation("start")
+	if err != nil {
+		inv = invocation{Args: []string{"start"}}
+	}
+	if pid, _, running := runningProcess("start"); running {
+		fmt.Println(styleDim.Render(fmt.Sprintf("Stopping production server (pid %d)...", pid)))
+		if err := stopProcess(pid, stop); err != nil {
+			return err
+		}
+		if path, err := pidPath("start"); err == nil {
+			_ = os.Remove(path)
+		}
+	}
+	if between != nil {
+		if err := between(); err != nil {
+			return err
+		}
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate ellie executable: %w", err)
+	}
+	args := inv.Args
+	if wait != 0 {
+		args = append(slices.Clip(args), "--wait-timeout="+wait.String())
+	}
+	fmt.Println(styleDim.Render("Running: ellie " + strings.Join(args, " ")))
+	if exitCode := runProcess(self, args, inv.Dir); exitCode != 0 {
 		return exitCodeError(exitCode)
 	}
 	return nil
 }
+
+// restartUnderDaemon has the daemon restart its server, and waits up to
+// timeout for the new one to answer.
+func restartUnderDaemon(st *daemon.Status, timeout time.Duration) error {
+	fmt.Println(styleDim.Render(fmt.Sprintf("Restarting the production server under the daemon (pid %d)...", st.PID)))
+	if _, err := callDaemon(daemon.OpRestart); err != nil {
+		return fmt.Errorf("cannot reach the daemon: %w", err)
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		cur := daemonStatus()
+		if cur == nil {
+			return fmt.Errorf("the daemon exited during the restart — see ellie logs --source start")
+		}
+		if cur.ServerPID != 0 && cur.ServerPID != st.ServerPID {
+			if waitHealthy(baseURL(), time.Until(deadline)) {
+				fmt.Println(styleOk.Render("✓"), "Production server running at", styleBold.Render(baseURL()), styleDim.Render(fmt.Sprintf("(pid %d)", cur.ServerPID)))
+				return nil
+			}
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	fmt.Fprintln(os.Stderr, styleErr.Render("✗"), "Server did not come back within", timeout)
+	fmt.Fprintln(os.Stderr, styleDim.Render("  See ellie daemon status and ellie logs --source start"))
+	return errSilent
+}
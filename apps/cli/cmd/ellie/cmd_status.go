@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show server health and process state",
+	Long: `Show whether the dev or production server is running, whether the
+configured server URL responds, and which providers have credentials.
+
+Exits non-zero when the server is unreachable, so scripts can use it as
+a health check.`,
+	RunE: runStatus,
+}
+
+// authProviders are the providers reported by ellie status, in display order.
+var authProviders = []struct {
+	name string
+	slug string
+}{
+	{"Anthropic", "anthropic"},
+	{"Google Gemini", "gemini"},
+	{"Azure OpenAI", "azure"},
+	{"Groq", "groq"},
+	{"Brave Search", "brave"},
+	{"ElevenLabs", "elevenlabs"},
+	{"CivitAI", "civitai"},
+}
+
+// statusReport is the --json output of ellie status.
+type statusReport struct {
+	Processes []statusProcess      `json:"processes"`
+	Server    statusServer         `json:"server"`
+	Session   *statusSession       `json:"session,omitempty"`
+	Auth      map[string]authState `json:"auth,omitempty"` // by provider slug
+}
+
+type statusProcess struct {
+	Name      string    `json:"name"` // production, dev or daemon
+	PID       int       `json:"pid"`
+	Since     time.Time `json:"since"`
+	ServerPID int       `json:"serverPid,omitempty"` // the daemon's server
+	Restarts  int       `json:"restarts,omitempty"`
+	LastExit  *int      `json:"lastExit,omitempty"`
+}
+
+type statusServer struct {
+	URL            string `json:"url"`
+	State          string `json:"state"` // healthy, unreachable, incompatible or error
+	HTTPStatus     int    `json:"httpStatus,omitempty"`
+	LatencyMs      int64  `json:"latencyMs,omitempty"`
+	Version        string `json:"version,omitempty"`
+	Compatibility  string `json:"compatibility,omitempty"` // ok, warn or block
+	Clients        int    `json:"clients"`
+	NeedsBootstrap bool   `json:"needsBootstrap"`
+}
+
+type statusSession struct {
+	User      string     `json:"user,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Expired   bool       `json:"expired"`
+}
+
+// authState is a provider's credential state as the server reports it.
+type authState struct {
+	Configured bool   `json:"configured"`
+	Mode       string `json:"mode,omitempty"`
+	Expired    bool   `json:"expired"`
+	Error      string `json:"error,omitempty"` // why the state is unknown
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	base := baseURL()
+	addr := serverAddress(base)
+	report := statusReport{Processes: []statusProcess{}, Server: statusServer{URL: addr}}
+	// done prints the report with --json and passes err on, so scripts get
+	// both the document and the exit status.
+	done := func(err error) error {
+		if jsonFlag {
+			if jerr := printJSON(report); jerr != nil {
+				return jerr
+			}
+		}
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println(styleBold.Render("Ellie Status"))
+	fmt.Println(strings.Repeat("─", 40))
+
+	// Managed processes started by ellie dev / ellie start
+	fmt.Println()
+	for _, p := range []struct{ name, label string }{{"start", "production"}, {"dev", "dev"}} {
+		pid, since, ok := runningProcess(p.name)
+		if !ok {
+			continue
+		}
+		proc := statusProcess{Name: p.label, PID: pid, Since: since}
+		detail := fmt.Sprintf("pid %d, up %s", pid, formatUptime(time.Since(since)))
+		if st, ok := loadRestartState(p.name); ok {
+			proc.Restarts, proc.LastExit = st.Restarts, st.LastExit
+			if s := restartSummary(st.Restarts, st.LastExit); s != "" {
+				detail += ", " + s
+			}
+		}
+		report.Processes = append(report.Processes, proc)
+		fmt.Printf("  %-10s %s %s\n", "Process", styleOk.Render(p.label), styleDim.Render("("+detail+")"))
+	}
+	if st := daemonStatus(); st != nil {
+		report.Processes = append(report.Processes, statusProcess{Name: "daemon", PID: st.PID, Since: st.Since,
+			ServerPID: st.ServerPID, Restarts: st.Restarts, LastExit: st.LastExit})
+		detail := fmt.Sprintf("pid %d, up %s", st.PID, formatUptime(time.Since(st.Since)))
+		if st.ServerPID != 0 {
+			detail += fmt.Sprintf(", server pid %d", st.ServerPID)
+		} else {
+			detail += ", server " + st.State
+		}
+		if s := restartSummary(st.Restarts, st.LastExit); s != "" {
+			detail += ", " + s
+		}
+		fmt.Printf("  %-10s %s %s\n", "Process", styleOk.Render("daemon"), styleDim.Render("("+detail+")"))
+	}
+	if len(report.Processes) == 0 {
+		fmt.Printf("  %-10s %s\n", "Process", styleDim.Render("no managed server (ellie dev / ellie start)"))
+	}
+
+	// Server health
+	start := time.Now()
+	resp, err := httpClient.Get(base + "/api/status")
+	var skew *skewError
+	if errors.As(err, &skew) {
+		report.Server.State = "incompatible"
+		report.Server.Version, report.Server.Compatibility = serverVersionInfo(base)
+		fmt.Printf("  %-10s %s %s\n", "Server", styleErr.Render("✗ incompatible"), styleDim.Render(addr))
+		fmt.Println()
+		return done(errSilent)
+	}
+	if err != nil {
+		report.Server.State = "unreachable"
+		fmt.Printf("  %-10s %s %s\n", "Server", styleErr.Render("✗ unreachable"), styleDim.Render(addr))
+		fmt.Println()
+		return done(errSilent)
+	}
+	defer resp.Body.Close()
+	latency := time.Since(start)
+	report.Server.HTTPStatus = resp.StatusCode
+	report.Server.LatencyMs = latency.Milliseconds()
+
+	if resp.StatusCode != 200 {
+		report.Server.State = "error"
+		fmt.Printf("  %-10s %s %s\n", "Server", styleErr.Render(fmt.Sprintf("✗ HTTP %d", resp.StatusCode)), styleDim.Render(addr))
+		fmt.Println()
+		return done(errSilent)
+	}
+
+	var status struct {
+		ConnectedClients int  `json:"connectedClients"`
+		NeedsBootstrap   bool `json:"needsBootstrap"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&status)
+	report.Server.State = "healthy"
+	report.Server.Version, report.Server.Compatibility = serverVersionInfo(base)
+	report.Server.Clients = status.ConnectedClients
+	report.Server.NeedsBootstrap = status.NeedsBootstrap
+
+	fmt.Printf("  %-10s %s %s\n", "Server", styleOk.Render("✓ healthy"),
+		styleDim.Render(fmt.Sprintf("%s (%dms)", addr, latency.Milliseconds())))
+	if serverSocket != "" {
+		fmt.Printf("  %-10s %s\n", "Socket", serverSocket)
+	} else {
+		fmt.Printf("  %-10s %s\n", "Port", portOf(base))
+	}
+	fmt.Printf("  %-10s %s\n", "Version", serverVersionSummary(base))
+	fmt.Printf("  %-10s %d\n", "Clients", status.ConnectedClients)
+	if session := sessionSummary(base); session != "" {
+		fmt.Printf("  %-10s %s\n", "Session", session)
+	}
+	if s := currentSession(base); s != nil {
+		report.Session = &statusSession{User: s.User, Expired: s.Expired(time.Now()) && s.RefreshToken == ""}
+		if !s.ExpiresAt.IsZero() {
+			report.Session.ExpiresAt = &s.ExpiresAt
+		}
+	}
+	if status.NeedsBootstrap {
+		fmt.Printf("  %-10s %s\n", "Setup", styleErr.Render("needs bootstrap — open the web UI to finish setup"))
+	}
+
+	// Provider credentials
+	fmt.Println()
+	fmt.Println(styleBold.Render("  Auth"))
+	report.Auth = map[string]authState{}
+	for _, p := range authProviders {
+		state := fetchAuthState(base, p.slug)
+		report.Auth[p.slug] = state
+		fmt.Printf("    %-14s %s\n", p.name, authSummary(state))
+	}
+	fmt.Println()
+	return done(nil)
+}
+
+// fetchAuthState asks the server for a provider's credential state.
+func fetchAuthState(base, slug string) authState {
+	resp, err := httpClient.Get(base + "/api/auth/" + slug + "/status")
+	if err != nil {
+		return authState{Error: "server unreachable"}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return authState{Error: fmt.Sprintf("HTTP %d", resp.StatusCode)}
+	}
+
+	var s struct {
+		Mode       *string `json:"mode"`
+		Configured bool    `json:"configured"`
+		Expired    *bool   `json:"expired,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return authState{Error: "invalid response"}
+	}
+	if !s.Configured || s.Mode == nil {
+		return authState{}
+	}
+	return authState{Configured: true, Mode: *s.Mode, Expired: s.Expired != nil && *s.Expired}
+}
+
+// authSummary returns a one-line description of a provider's auth state.
+func authSummary(s authState) string {
+	switch {
+	case s.Error == "server unreachable":
+		return styleErr.Render("unknown")
+	case s.Error != "":
+		return styleDim.Render("unknown (" + s.Error + ")")
+	case !s.Configured:
+		return styleDim.Render("not configured")
+	case s.Expired:
+		return styleErr.Render("expired") + styleDim.Render(" ("+s.Mode+")")
+	}
+	return styleOk.Render("✓") + " " + s.Mode
+}
+
+// portOf returns the port of a base URL, filling in the scheme default.
+func portOf(base string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "?"
+	}
+	if p := u.Port(); p != "" {
+		return p
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+func formatUptime(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return d.String()
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
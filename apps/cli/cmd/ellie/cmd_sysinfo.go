@@ -11,7 +11,6 @@ import (
 
 var (
 	sysinfoSmall bool
-	sysinfoJSON  bool
 	sysinfoBars  bool
 	sysinfoShow  string
 )
@@ -34,7 +33,6 @@ Valid readout keys for --show:
 
 func init() {
 	sysinfoCmd.Flags().BoolVar(&sysinfoSmall, "small", false, "use small ASCII logo variant")
-	sysinfoCmd.Flags().BoolVar(&sysinfoJSON, "json", false, "output as JSON")
 	sysinfoCmd.Flags().BoolVar(&sysinfoBars, "bars", false, "show bar visualization for percentages")
 	sysinfoCmd.Flags().StringVar(&sysinfoShow, "show", "", "comma-separated list of readouts to display")
 }
@@ -54,12 +52,12 @@ func runSysinfo(cmd *cobra.Command, args []string) error {
 	info := sysinfo.Collect(keys)
 
 	// JSON output mode
-	if sysinfoJSON {
+	if jsonFlag {
 		out, err := info.ToJSON()
 		if err != nil {
 			return err
 		}
-		fmt.Println(out)
+		fmt.Fprintln(jsonOut, out)
 		return nil
 	}
 
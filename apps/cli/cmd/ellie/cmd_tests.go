@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	testOnlyChanged bool
+	testBaseBranch  string
+	testTurboFilter []string
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test [-- turbo args...]",
+	Short: "Run the workspace's tests with turbo",
+	Long: `Run the test task of every package with turbo.
+
+--filter picks the packages to test, as turbo's --filter does. With
+--only-changed, ellie tests only the packages affected by changes since
+--base (main by default): those whose files changed, committed or not,
+and the packages that depend on them. Arguments after -- are passed on
+to turbo:
+
+  ellie test --only-changed -- --concurrency=2`,
+	RunE: runTest,
+}
+
+func init() {
+	testCmd.Flags().BoolVar(&testOnlyChanged, "only-changed", false, "Only test packages affected by changes since --base")
+	testCmd.Flags().StringVar(&testBaseBranch, "base", "main", "Base branch for --only-changed")
+	testCmd.Flags().StringArrayVar(&testTurboFilter, "filter", nil, "Test only the packages turbo's filter selects (repeatable)")
+	testCmd.MarkFlagsMutuallyExclusive("filter", "only-changed")
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	root, err := findMonorepoRoot()
+	if err != nil {
+		return err
+	}
+	extra, err := turboPassthrough(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	turboArgs := []string{"run", "test"}
+	for _, f := range testTurboFilter {
+		turboArgs = append(turboArgs, "--filter="+f)
+	}
+	if testOnlyChanged {
+		filters, err := affectedFilters(root, testBaseBranch)
+		if err != nil {
+			return err
+		}
+		if filters != nil && len(filters) == 0 {
+			fmt.Println(styleDim.Render("No packages changed since " + testBaseBranch + " — nothing to test."))
+			return nil
+		}
+		turboArgs = append(turboArgs, filters...)
+	}
+	turboArgs = append(turboArgs, extra...)
+
+	turboPath, err := findBin("turbo", root)
+	if err != nil {
+		return err
+	}
+	if err := applyTaskOutput(""); err != nil {
+		return err
+	}
+	exitCode := runProcess(turboPath, turboArgs, root)
+	closeTaskOutput(exitCode)
+	if exitCode != 0 {
+		return exitCodeError(exitCode)
+	}
+	return nil
+}
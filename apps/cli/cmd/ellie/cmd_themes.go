@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/chatui"
+	"ellie/apps/cli/internal/config"
+	"ellie/apps/cli/internal/paths"
+	"ellie/apps/cli/internal/themes"
+)
+
+// ── themes ──────────────────────────────────────────────────────────────────
+
+// themeURLMaxAge is how long a theme loaded from a URL is used from the
+// cache before it is checked for changes.
+const themeURLMaxAge = 24 * time.Hour
+
+var (
+	themesName  string
+	themesUse   bool
+	themesForce bool
+)
+
+var themesCmd = &cobra.Command{
+	Use:   "themes",
+	Short: "Share color themes for the chat interface",
+	Long: `A theme is a JSON file that starts from the dark or light theme and
+replaces some of its colors:
+
+  {
+    "name": "ocean",
+    "base": "dark",
+    "colors": {"accent": "#1e90ff", "user": "#7fdbff", "background": "#001020"}
+  }
+
+The colors are accent, user, memory, system, muted, dim, subtle, surface
+and background. Export the theme you use to share it, import one a
+teammate shared, and select it with ui.theme:
+
+  ellie themes export ocean.json
+  ellie themes import ocean.json --use
+  ellie config set ui.theme ocean
+
+ui.theme can also be a theme's URL. It is downloaded when first used and
+checked for changes once a day, and the last copy is used while the
+server can't be reached.`,
+}
+
+var themesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the built-in and imported themes",
+	Args:  cobra.NoArgs,
+	RunE:  runThemesList,
+}
+
+var themesExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Write the theme in use to a file, or standard output",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runThemesExport,
+}
+
+var themesImportCmd = &cobra.Command{
+	Use:   "import <file|url>",
+	Short: "Add a theme from a file or URL",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runThemesImport,
+}
+
+func init() {
+	themesExportCmd.Flags().StringVar(&themesName, "name", "", "Name to give the exported theme (default: its own, or <built-in>-custom)")
+	themesImportCmd.Flags().StringVar(&themesName, "name", "", "Import the theme under this name instead of its own")
+	themesImportCmd.Flags().BoolVar(&themesUse, "use", false, "Also select the theme in your config")
+	themesImportCmd.Flags().BoolVar(&themesForce, "force", false, "Replace an imported theme with the same name")
+}
+
+func themeStore() (themes.Store, error) {
+	dir, err := paths.Config()
+	if err != nil {
+		return themes.Store{}, err
+	}
+	return themes.Store{Dir: filepath.Join(dir, "themes")}, nil
+}
+
+// loadTheme finds a theme by name or URL.
+func loadTheme(ref string) (themes.Theme, error) {
+	if themes.IsURL(ref) {
+		cache, err := paths.Cache()
+		if err != nil {
+			return themes.Theme{}, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return themes.Fetch(ctx, http.DefaultClient, ref, filepath.Join(cache, "themes"), themeURLMaxAge)
+	}
+	store, err := themeStore()
+	if err != nil {
+		return themes.Theme{}, err
+	}
+	t, err := store.Load(ref)
+	if err != nil {
+		return themes.Theme{}, fmt.Errorf("%w — see 'ellie themes list'", err)
+	}
+	return t, nil
+}
+
+func runThemesList(cmd *cobra.Command, args []string) error {
+	store, err := themeStore()
+	if err != nil {
+		return err
+	}
+	names, err := store.List()
+	if err != nil {
+		return err
+	}
+	active, _ := setting("ui.theme")
+
+	fmt.Println(styleBold.Render("Themes"))
+	fmt.Println(strings.Repeat("─", 40))
+	for _, name := range append([]string{themes.Dark, themes.Light}, names...) {
+		line := "  " + name
+		if name == active {
+			line = styleOk.Render("●") + " " + name
+		}
+		switch name {
+		case themes.Dark, themes.Light:
+			line += styleDim.Render("  built-in")
+		default:
+			if t, err := store.Load(name); err != nil {
+				line += styleErr.Render("  " + err.Error())
+			} else {
+				line += styleDim.Render("  based on " + t.Base)
+			}
+		}
+		fmt.Println(line)
+	}
+	if themes.IsURL(active) {
+		fmt.Println(styleOk.Render("●") + " " + active)
+	}
+	return nil
+}
+
+func runThemesExport(cmd *cobra.Command, args []string) error {
+	t := chatui.ActiveTheme()
+	switch {
+	case themesName != "":
+		t.Name = themesName
+	case t.Name == themes.Dark || t.Name == themes.Light:
+		t.Name += "-custom"
+	}
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	data, err := themes.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 || args[0] == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	path := expandHome(args[0])
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Exported theme", styleBold.Render(t.Name), "to", path)
+	return nil
+}
+
+func runThemesImport(cmd *cobra.Command, args []string) error {
+	var t themes.Theme
+	var err error
+	if themes.IsURL(args[0]) {
+		t, err = loadTheme(args[0])
+	} else {
+		var data []byte
+		if data, err = os.ReadFile(expandHome(args[0])); err == nil {
+			if t, err = themes.Parse(data); err != nil {
+				err = fmt.Errorf("%s: %w", args[0], err)
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if themesName != "" {
+		t.Name = themesName
+	}
+
+	store, err := themeStore()
+	if err != nil {
+		return err
+	}
+	if store.Exists(t.Name) && !themesForce {
+		return fmt.Errorf("a theme named %s is already imported — pass --force to replace it, or --name to import it as another", t.Name)
+	}
+	if err := store.Save(t); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Imported theme", styleBold.Render(t.Name), styleDim.Render("(based on "+t.Base+")"))
+
+	if !themesUse {
+		fmt.Println(styleDim.Render("  Use it with: ellie config set ui.theme " + t.Name))
+		return nil
+	}
+	path, err := config.UserPath()
+	if err != nil {
+		return err
+	}
+	if err := config.SetFile(path, "ui.theme", t.Name); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "ui.theme =", t.Name, styleDim.Render("in "+path))
+	return nil
+}
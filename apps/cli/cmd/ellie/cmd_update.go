@@ -1,21 +1,177 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"ellie/apps/cli/internal/buildinfo"
+	"ellie/apps/cli/internal/chatui"
+	"ellie/apps/cli/internal/selfupdate"
+)
+
+var (
+	updateCheck      bool
+	updateChannel    string
+	updateFromSource bool
+	updateForce      bool
+	updateChangelog  bool
 )
 
 var updateCmd = &cobra.Command{
 	Use:   "update",
-	Short: "Build and install the latest CLI from source",
-	RunE:  runUpdate,
+	Short: "Update the CLI to the latest release",
+	Long: `Download the latest released ellie binary for this platform, verify its
+checksum and replace the running executable.
+
+--channel picks stable releases (default) or beta, which includes
+prereleases. --check only reports whether an update is available.
+--changelog shows the release notes of every release newer than the
+installed one. --from-source instead pulls the monorepo and rebuilds the
+CLI with go install, which is what development builds should use.
+
+Once a day, the first command run at a terminal checks for a newer
+release in the background, and a later command mentions it in one line.
+Turn this off with 'ellie config set update.check false' or
+ELLIE_UPDATE_CHECK=false; it never runs in CI or for development builds.
+
+Set ELLIE_UPDATE_URL to use a different release endpoint.`,
+	Args: cobra.NoArgs,
+	RunE: runUpdate,
+}
+
+func init() {
+	updateCmd.Flags().BoolVar(&updateCheck, "check", false, "Only check whether a newer version is available")
+	updateCmd.Flags().StringVar(&updateChannel, "channel", "", "Release channel: stable or beta (default: the installed build's channel, or stable)")
+	updateCmd.Flags().BoolVar(&updateFromSource, "from-source", false, "Pull the monorepo and rebuild with go install")
+	updateCmd.Flags().BoolVar(&updateForce, "force", false, "Install the latest release even if it isn't newer")
+	updateCmd.Flags().BoolVar(&updateChangelog, "changelog", false, "Show the release notes of the releases newer than this one")
+	for _, f := range []string{"check", "from-source", "force"} {
+		updateCmd.MarkFlagsMutuallyExclusive("changelog", f)
+	}
+}
+
+// installedChannel is the release channel updates come from: --channel,
+// else the one the running build was released on, else stable.
+func installedChannel() string {
+	if updateChannel != "" {
+		return updateChannel
+	}
+	if ch := buildinfo.Get().Channel; ch != "" {
+		return ch
+	}
+	return selfupdate.ChannelStable
 }
 
 func runUpdate(cmd *cobra.Command, args []string) error {
+	if updateFromSource {
+		return updateFromCheckout()
+	}
+
+	info := buildinfo.Get()
+	channel := installedChannel()
+
+	ctx := context.Background()
+	updater := selfupdate.New(os.Getenv("ELLIE_UPDATE_URL"), &http.Client{Timeout: 5 * time.Minute})
+	if updateChangelog {
+		return showChangelog(ctx, updater, channel)
+	}
+
+	fmt.Println(styleDim.Render(fmt.Sprintf("Checking the %s channel...", channel)))
+	rel, err := updater.Latest(ctx, channel)
+	if err != nil {
+		return err
+	}
+
+	newer := info.IsDev() || selfupdate.CompareVersions(rel.Version, info.Version) > 0
+	if !newer && !updateForce {
+		fmt.Println(styleOk.Render("✓"), "ellie", info.Version, "is up to date")
+		return nil
+	}
+
+	if updateCheck {
+		fmt.Printf("%s ellie %s is available %s\n", styleBold.Render("↑"), styleBold.Render(rel.Version),
+			styleDim.Render("(installed: "+info.Version+")"))
+		fmt.Println(styleDim.Render("  Install it with: ellie update --channel " + channel))
+		return nil
+	}
+
+	if info.IsDev() && !updateForce {
+		return fmt.Errorf("this is a development build — use ellie update --from-source, or --force to replace it with release %s", rel.Version)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate ellie executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	fmt.Println(styleDim.Render(fmt.Sprintf("Downloading ellie %s (%s)...", rel.Version, rel.AssetName)))
+	if err := updater.Install(ctx, rel, exe); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Updated ellie", info.Version, "→", styleBold.Render(rel.Version))
+	return nil
+}
+
+// showChangelog prints the notes of the releases on channel newer than
+// the running build, newest first. A development build gets the latest
+// release's notes.
+func showChangelog(ctx context.Context, updater *selfupdate.Updater, channel string) error {
+	info := buildinfo.Get()
+	since := info.Version
+	if info.IsDev() {
+		since = "0"
+	}
+	done := spinner(fmt.Sprintf("Fetching release notes from the %s channel...", channel))
+	rels, err := updater.Changelog(ctx, channel, since)
+	done()
+	if err != nil {
+		return err
+	}
+	if len(rels) == 0 {
+		fmt.Println(styleOk.Render("✓"), "ellie", info.Version, "is up to date")
+		return nil
+	}
+	if info.IsDev() {
+		rels = rels[:1]
+	}
+
+	var md strings.Builder
+	for i, rel := range rels {
+		if i > 0 {
+			md.WriteString("\n\n")
+		}
+		fmt.Fprintf(&md, "# ellie %s\n\n", rel.Version)
+		if notes := strings.TrimSpace(rel.Notes); notes != "" {
+			md.WriteString(notes)
+		} else {
+			md.WriteString("*No release notes.*")
+		}
+	}
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Println(chatui.RenderMarkdown(md.String()))
+	} else {
+		fmt.Println(md.String())
+	}
+	if !info.IsDev() {
+		fmt.Println(styleDim.Render("  Install " + rels[0].Version + " with: ellie update"))
+	}
+	return nil
+}
+
+// updateFromCheckout pulls the monorepo and reinstalls the CLI from source.
+func updateFromCheckout() error {
 	root, err := findMonorepoRoot()
 	if err != nil {
 		return err
@@ -29,6 +185,7 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	pull.Dir = root
 	pull.Stdout = os.Stdout
 	pull.Stderr = os.Stderr
+	logCommand(pull)
 	if err := pull.Run(); err != nil {
 		return fmt.Errorf("git pull failed: %w", err)
 	}
@@ -40,6 +197,7 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	install.Dir = cliDir
 	install.Stdout = os.Stdout
 	install.Stderr = os.Stderr
+	logCommand(install)
 	if err := install.Run(); err != nil {
 		return fmt.Errorf("go install failed: %w", err)
 	}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/pkg/ellieapi"
+)
+
+var validateSpec string
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check that the CLI and the server agree",
+}
+
+var validateOpenAPICmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Check the server's API against the requests the CLI sends",
+	Long: `Fetch the server's OpenAPI document and check that every endpoint the
+CLI calls exists, accepts the method, takes the request body the CLI
+sends and returns a response the CLI can read — field by field, from the
+types of the CLI's API client. Each difference is reported with the
+endpoint and the field, so a breaking server change is caught before
+commands fail with it.
+
+Parts the document leaves undescribed, like a route without a response
+schema, are listed as unchecked. --spec checks a saved document or
+another URL instead of the server's.`,
+	Example: `  ellie validate openapi
+  ellie validate openapi --spec openapi.json --json`,
+	Args: cobra.NoArgs,
+	RunE: runValidateOpenAPI,
+}
+
+func init() {
+	validateOpenAPICmd.Flags().StringVar(&validateSpec, "spec", "", "OpenAPI document to check, a file or URL (default: the server's /openapi/json)")
+}
+
+// openAPIReport is the JSON output of ellie validate openapi.
+type openAPIReport struct {
+	Spec      string                    `json:"spec"`
+	OK        bool                      `json:"ok"`
+	Endpoints []ellieapi.EndpointReport `json:"endpoints"`
+}
+
+func runValidateOpenAPI(cmd *cobra.Command, args []string) error {
+	source := validateSpec
+	if source == "" {
+		source = baseURL() + "/openapi/json"
+	}
+	spec, err := readOpenAPISpec(source)
+	if err != nil {
+		return err
+	}
+	reports, err := ellieapi.CheckContract(spec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	report := openAPIReport{Spec: source, OK: true, Endpoints: reports}
+	failed, unchecked := 0, 0
+	for _, r := range reports {
+		if len(r.Problems) > 0 {
+			failed++
+		}
+		if len(r.Unchecked) > 0 {
+			unchecked++
+		}
+	}
+	report.OK = failed == 0
+	if jsonFlag {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+		if !report.OK {
+			return errSilent
+		}
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println(styleBold.Render("API contract"), styleDim.Render(source))
+	fmt.Println(strings.Repeat("─", 40))
+	for _, r := range reports {
+		mark := styleOk.Render("✓")
+		if len(r.Problems) > 0 {
+			mark = styleErr.Render("✗")
+		}
+		fmt.Printf("  %s %-6s %s\n", mark, r.Method, r.Path)
+		for _, p := range r.Problems {
+			fmt.Println("        " + p)
+		}
+		if len(r.Unchecked) > 0 {
+			fmt.Println(styleDim.Render("        not described: " + strings.Join(r.Unchecked, ", ")))
+		}
+	}
+	fmt.Println()
+	summary := fmt.Sprintf("%d endpoints, %d incompatible", len(reports), failed)
+	if unchecked > 0 {
+		summary += fmt.Sprintf(", %d partly unchecked", unchecked)
+	}
+	if !report.OK {
+		fmt.Println(styleErr.Render("✗"), summary)
+		return errSilent
+	}
+	fmt.Println(styleOk.Render("✓"), summary)
+	return nil
+}
+
+// readOpenAPISpec reads the document at source, a URL or a file.
+func readOpenAPISpec(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+	resp, err := httpClient.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach server at %s", baseURL())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s not found — the server does not publish an OpenAPI document there (pass --spec)", source)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, serverError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
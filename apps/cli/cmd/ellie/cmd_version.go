@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/buildinfo"
+)
+
+var versionComponents bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the CLI version and build metadata",
+	Long: `Show the CLI's version, commit and build date.
+
+With --components, also report the server's version and the toolchain
+ellie runs it with — turbo, node, bun and the package manager the
+project declares — for bug reports.`,
+	Args: cobra.NoArgs,
+	RunE: runVersion,
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionComponents, "components", false, "Also report the server, turbo, node, bun and package manager versions")
+}
+
+// versionReport is ellie version's JSON: the CLI's build metadata, and
+// with --components the rest.
+type versionReport struct {
+	buildinfo.BuildInfo
+	Components *versionComponentsReport `json:"components,omitempty"`
+}
+
+type versionComponentsReport struct {
+	Server         versionServer `json:"server"`
+	Tools          []versionTool `json:"tools"`
+	PackageManager string        `json:"packageManager,omitempty"` // as package.json declares it
+}
+
+type versionServer struct {
+	URL           string `json:"url"`
+	Version       string `json:"version,omitempty"`
+	Compatibility string `json:"compatibility,omitempty"` // ok, warn or block
+	Error         string `json:"error,omitempty"`
+}
+
+type versionTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Error   string `json:"error,omitempty"` // not found, or --version failed
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	report := versionReport{BuildInfo: buildinfo.Get()}
+	if versionComponents {
+		report.Components = versionComponentsOf()
+	}
+	if jsonFlag {
+		return printJSON(report)
+	}
+
+	info := report.BuildInfo
+	fmt.Println(styleBold.Render("ellie " + info.Version))
+	commit := info.Commit
+	if commit == "" {
+		commit = "unknown"
+	} else if info.Dirty {
+		commit += styleDim.Render(" (modified)")
+	}
+	fmt.Printf("  %-10s %s\n", "Commit", commit)
+	if info.Date != "" {
+		fmt.Printf("  %-10s %s\n", "Built", info.Date)
+	}
+	if info.Channel != "" {
+		fmt.Printf("  %-10s %s\n", "Channel", info.Channel)
+	}
+	fmt.Printf("  %-10s %s\n", "Go", info.GoVersion)
+	fmt.Printf("  %-10s %s/%s\n", "Platform", info.OS, info.Arch)
+
+	c := report.Components
+	if c == nil {
+		return nil
+	}
+	fmt.Println()
+	server := c.Server.Version
+	switch {
+	case c.Server.Error != "":
+		server = styleErr.Render(c.Server.Error) + " " + styleDim.Render(c.Server.URL)
+	case server == "":
+		server = styleDim.Render("unknown " + c.Server.URL)
+	default:
+		if c.Server.Compatibility == "warn" || c.Server.Compatibility == "block" {
+			server += " " + styleErr.Render("⚠ "+c.Server.Compatibility)
+		}
+		server += " " + styleDim.Render(c.Server.URL)
+	}
+	fmt.Printf("  %-10s %s\n", "Server", server)
+	for _, t := range c.Tools {
+		if t.Error != "" {
+			fmt.Printf("  %-10s %s\n", t.Name, styleDim.Render(t.Error))
+			continue
+		}
+		fmt.Printf("  %-10s %s %s\n", t.Name, t.Version, styleDim.Render(t.Path))
+	}
+	if c.PackageManager != "" {
+		fmt.Printf("  %-10s %s\n", "Packages", c.PackageManager)
+	}
+	return nil
+}
+
+// versionComponentsOf asks the server for its version and reads the
+// toolchain's. What can't be found is reported as such.
+func versionComponentsOf() *versionComponentsReport {
+	c := &versionComponentsReport{Tools: []versionTool{}}
+	base := baseURL()
+	c.Server.URL = base
+	client := *httpClient
+	client.Timeout = 3 * time.Second
+	if resp, err := client.Get(base + "/api/status"); err != nil {
+		if _, compatibility := serverVersionInfo(base); compatibility == "" {
+			c.Server.Error = "unreachable"
+		}
+	} else {
+		resp.Body.Close()
+	}
+	c.Server.Version, c.Server.Compatibility = serverVersionInfo(base)
+
+	root, _ := findMonorepoRoot()
+	for _, name := range []string{"turbo", "node", "bun"} {
+		t := versionTool{Name: name}
+		var err error
+		t.Version, t.Path, err = toolVersion(name, root)
+		switch {
+		case t.Path == "":
+			t.Error = "not found"
+		case err != nil:
+			t.Error = "--version failed"
+		}
+		c.Tools = append(c.Tools, t)
+	}
+
+	if root != "" {
+		var manifest struct {
+			PackageManager string `json:"packageManager"`
+		}
+		if data, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil {
+			_ = json.Unmarshal(data, &manifest)
+		}
+		c.PackageManager = manifest.PackageManager
+	}
+	return c
+}
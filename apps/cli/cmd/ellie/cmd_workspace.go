@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/workspace"
+)
+
+// ── workspace rename / move ─────────────────────────────────────────────────
+
+var (
+	workspaceDryRun bool
+	workspaceYes    bool
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Refactor monorepo workspace packages",
+}
+
+var workspaceRenameCmd = &cobra.Command{
+	Use:   "rename <package> <new-name>",
+	Short: "Rename a workspace package and update every reference to it",
+	Long: `Rename a workspace package. The package's own package.json name,
+dependency entries in other packages, import specifiers in source files,
+turbo task references, --filter arguments in scripts and tsconfig path
+aliases are all updated.
+
+The changes are shown as a diff and confirmed before anything is written;
+use --dry-run to only preview.
+
+  ellie workspace rename @repo/ui @repo/components`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWorkspaceRefactor(args[0], args[1], "")
+	},
+}
+
+var workspaceMoveCmd = &cobra.Command{
+	Use:   "move <package> <new-dir>",
+	Short: "Move a workspace package to another directory",
+	Long: `Move a workspace package to a new directory (relative to the monorepo
+root) and fix the relative paths in tsconfig files that point into or out
+of it. The new directory must match one of the root workspaces globs.
+
+  ellie workspace move @repo/ui apps/ui --dry-run`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWorkspaceRefactor(args[0], "", args[1])
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{workspaceRenameCmd, workspaceMoveCmd} {
+		c.Flags().BoolVar(&workspaceDryRun, "dry-run", false, "Preview the changes without applying them")
+		c.Flags().BoolVarP(&workspaceYes, "yes", "y", false, "Apply without asking for confirmation")
+	}
+}
+
+// runWorkspaceRefactor renames (newName) or moves (newDir) a package;
+// empty arguments keep the current value.
+func runWorkspaceRefactor(name, newName, newDir string) error {
+	root, err := findMonorepoRoot()
+	if err != nil {
+		return err
+	}
+	pkgs, err := workspace.Discover(root)
+	if err != nil {
+		return err
+	}
+	if newName == "" {
+		newName = name
+	}
+	if newDir == "" {
+		for _, p := range pkgs {
+			if p.Name == name {
+				newDir = p.RelDir
+			}
+		}
+	}
+
+	plan, err := workspace.PlanRefactor(root, pkgs, name, newName, newDir)
+	if err != nil {
+		return err
+	}
+
+	if plan.Renames() {
+		fmt.Printf("%s %s → %s\n", styleBold.Render("Rename"), plan.Package.Name, styleBold.Render(plan.NewName))
+	}
+	if plan.Moves() {
+		fmt.Printf("%s %s → %s\n", styleBold.Render("Move"), plan.Package.RelDir, styleBold.Render(plan.NewDir))
+	}
+	fmt.Println()
+	printWorkspaceDiff(plan.Diff())
+	fmt.Println(styleDim.Render(fmt.Sprintf("%d file(s) to update", len(plan.Edits))))
+
+	if workspaceDryRun {
+		return nil
+	}
+	if !workspaceYes {
+		if err := noPrompt("confirming the changes", "pass --yes, or --dry-run to only show them"); err != nil {
+			return err
+		}
+		var confirm bool
+		err := huh.NewConfirm().
+			Title("Apply these changes?").
+			Affirmative("Apply").
+			Negative("Cancel").
+			Value(&confirm).
+			Run()
+		if err != nil || !confirm {
+			fmt.Println("Cancelled.")
+			return errSilent
+		}
+	}
+
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+	fmt.Println(styleOk.Render("✓"), "Updated", len(plan.Edits), "file(s)")
+	fmt.Println(styleDim.Render("  Run bun install to refresh bun.lock and the workspace links."))
+	return nil
+}
+
+func printWorkspaceDiff(diff string) {
+	if diff == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+			fmt.Println(styleBold.Render(line))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Println(styleDim.Render(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Println(styleErr.Render(line))
+		case strings.HasPrefix(line, "+"):
+			fmt.Println(styleOk.Render(line))
+		default:
+			fmt.Println(line)
+		}
+	}
+	fmt.Println()
+}
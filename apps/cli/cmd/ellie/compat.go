@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"ellie/apps/cli/internal/buildinfo"
+	"ellie/apps/cli/internal/compat"
+)
+
+// versionTransport tells the server which CLI version is calling, and
+// checks once per process and server that the two versions work together.
+// Skew outside the server's supported range prints a warning; combinations
+// known to be broken print an error and fail every request with a
+// skewError. Set ELLIE_SKIP_VERSION_CHECK=1 to try anyway.
+type versionTransport struct {
+	base http.RoundTripper
+}
+
+// serverCompat is the handshake result for one server. A nil handshake
+// means the server doesn't have the endpoint or couldn't be asked.
+type serverCompat struct {
+	// ready is closed once the handshake is done and the fields below
+	// are set.
+	ready     chan struct{}
+	handshake *compat.Handshake
+	verdict   compat.Verdict
+}
+
+var (
+	compatMu    sync.Mutex
+	compatCache = map[string]*serverCompat{}
+)
+
+// skewError fails requests to a server this CLI is known not to work with.
+type skewError struct {
+	verdict compat.Verdict
+}
+
+func (e *skewError) Error() string {
+	return e.verdict.Message + " — " + compatFixHint(e.verdict.Fix)
+}
+
+func (t versionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(compat.Header, buildinfo.Get().Version)
+	if req.URL.Path != compat.HandshakePath {
+		sc := t.check(req.Context(), serverOrigin(req.URL.String()))
+		if sc.verdict.Level == compat.Block && os.Getenv("ELLIE_SKIP_VERSION_CHECK") == "" {
+			return nil, &skewError{sc.verdict}
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// check returns the cached handshake for origin, performing it and
+// printing any warning the first time. The handshake is sent without
+// holding compatMu, so requests to other servers aren't held up by it;
+// concurrent requests to origin wait for it, or for their own ctx.
+func (t versionTransport) check(ctx context.Context, origin string) *serverCompat {
+	compatMu.Lock()
+	sc, ok := compatCache[origin]
+	if !ok {
+		sc = &serverCompat{ready: make(chan struct{})}
+		compatCache[origin] = sc
+	}
+	compatMu.Unlock()
+	if ok {
+		select {
+		case <-sc.ready:
+			return sc
+		case <-ctx.Done():
+			return &serverCompat{}
+		}
+	}
+
+	sc.handshake = t.handshake(ctx, origin)
+	if sc.handshake != nil {
+		sc.verdict = compat.Check(buildinfo.Get().Version, *sc.handshake)
+	}
+	close(sc.ready)
+	// Commands often replace request errors with their own "cannot reach
+	// server", so the reason is printed here, once.
+	switch {
+	case sc.verdict.Level == compat.Warn:
+		warn(sc.verdict.Message + " — " + compatFixHint(sc.verdict.Fix))
+	case sc.verdict.Level == compat.Block && os.Getenv("ELLIE_SKIP_VERSION_CHECK") == "":
+		fmt.Fprintln(os.Stderr, styleErr.Render("✗")+" "+sc.verdict.Message+" — "+compatFixHint(sc.verdict.Fix))
+		fmt.Fprintln(os.Stderr, styleDim.Render("  Set ELLIE_SKIP_VERSION_CHECK=1 to try anyway."))
+	}
+	return sc
+}
+
+// cachedCompat returns the finished handshake with the server at base,
+// or nil when there is none yet.
+func cachedCompat(base string) *serverCompat {
+	compatMu.Lock()
+	sc := compatCache[serverOrigin(base)]
+	compatMu.Unlock()
+	if sc == nil {
+		return nil
+	}
+	select {
+	case <-sc.ready:
+		return sc
+	default:
+		return nil
+	}
+}
+
+func (t versionTransport) handshake(ctx context.Context, origin string) *compat.Handshake {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+compat.HandshakePath, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set(compat.Header, buildinfo.Get().Version)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	var h compat.Handshake
+	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+		return nil
+	}
+	return &h
+}
+
+// compatFixHint tells the user how to resolve a version mismatch.
+func compatFixHint(fix int) string {
+	switch fix {
+	case compat.FixUpdateCLI:
+		return "run ellie update"
+	case compat.FixUpgradeServer:
+		return "upgrade the server (git pull, then ellie build and ellie restart)"
+	}
+	return "check the server and CLI versions"
+}
+
+// serverVersionInfo returns the server's version and this CLI's
+// compatibility with it — "ok", "warn" or "block" — or empty strings when
+// the server didn't say.
+func serverVersionInfo(base string) (version, compatibility string) {
+	sc := cachedCompat(base)
+	if sc == nil || sc.handshake == nil {
+		return "", ""
+	}
+	switch sc.verdict.Level {
+	case compat.Warn:
+		return sc.handshake.Version, "warn"
+	case compat.Block:
+		return sc.handshake.Version, "block"
+	}
+	return sc.handshake.Version, "ok"
+}
+
+// serverVersionSummary describes the server's version and its
+// compatibility with this CLI, for ellie status.
+func serverVersionSummary(base string) string {
+	sc := cachedCompat(base)
+	if sc == nil || sc.handshake == nil {
+		return styleDim.Render("unknown (server has no " + compat.HandshakePath + ")")
+	}
+	v := sc.handshake.Version
+	if v == "" {
+		v = "unknown"
+	}
+	switch sc.verdict.Level {
+	case compat.Warn:
+		return v + " " + styleErr.Render("⚠ "+compatFixHint(sc.verdict.Fix))
+	case compat.Block:
+		return v + " " + styleErr.Render("✗ incompatible — "+compatFixHint(sc.verdict.Fix))
+	}
+	return v
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"ellie/apps/cli/pkg/ellieapi"
+)
+
+// compressTransport sits above verboseTransport in serverTransport, so
+// --verbose and --debug-http show the encoded requests and responses as
+// they travel.
+var compressTransport = &ellieapi.CompressTransport{Base: verboseTransport{base: connectTransport}}
+
+// minCompressedRequest is the smallest request body gzipped when
+// http.compress_requests is on; smaller ones gain little.
+const minCompressedRequest = 1 << 10
+
+// applyCompression turns request compression on when
+// http.compress_requests asks for it. The server doesn't decode gzip
+// bodies itself, so it is off by default.
+func applyCompression() {
+	if v, _ := setting("http.compress_requests"); v == "true" {
+		compressTransport.MinRequestSize = minCompressedRequest
+	}
+}
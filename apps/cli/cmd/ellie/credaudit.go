@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"ellie/apps/cli/internal/audit"
+	"ellie/apps/cli/internal/paths"
+)
+
+// auditTransport records every credential-changing request the CLI sends
+// in the local audit log. It sits under httpClient so no code path that
+// sets or clears a credential can skip it. Only the provider, operation
+// and outcome are recorded — never request bodies.
+type auditTransport struct {
+	base http.RoundTripper
+}
+
+var credentialTransport http.RoundTripper = auditTransport{base: serverTransport}
+
+type auditOpKey struct{}
+
+// withAuditOp overrides the operation recorded for requests made with
+// ctx, e.g. "rotate" when a set replaces an existing credential.
+func withAuditOp(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, auditOpKey{}, op)
+}
+
+func (t auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	op, provider, method := classifyCredentialRequest(req)
+	if op == "" {
+		return t.base.RoundTrip(req)
+	}
+	if o, ok := req.Context().Value(auditOpKey{}).(string); ok {
+		op = o
+	}
+
+	var remote string
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+				remote = host
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	resp, err := t.base.RoundTrip(req)
+
+	e := audit.Entry{
+		Actor:    auditActor(),
+		Op:       op,
+		Provider: provider,
+		Method:   method,
+		Server:   req.URL.Scheme + "://" + req.URL.Host,
+		ServerIP: remote,
+	}
+	switch {
+	case err != nil:
+		e.Detail = err.Error()
+	default:
+		e.OK = resp.StatusCode == http.StatusOK
+		e.Detail = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+	recordCredentialOp(e)
+	return resp, err
+}
+
+// classifyCredentialRequest maps a request to the credential operation it
+// performs. It returns an empty op for anything else.
+func classifyCredentialRequest(req *http.Request) (op, provider, method string) {
+	if req.Method != http.MethodPost {
+		return "", "", ""
+	}
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "api" {
+		return "", "", ""
+	}
+	switch {
+	case parts[1] == "auth" && len(parts) == 4:
+		switch parts[3] {
+		case "api-key", "token", "vertex", "bedrock":
+			return "set", parts[2], parts[3]
+		case "clear":
+			return "clear", parts[2], ""
+		case "refresh":
+			return "refresh", parts[2], ""
+		}
+	case parts[1] == "auth" && len(parts) == 5 && parts[3] == "oauth" && parts[4] == "exchange":
+		return "set", parts[2], "oauth"
+	case parts[1] == "channels" && len(parts) == 4 && parts[3] == "logout":
+		return "clear", parts[2], ""
+	case parts[1] == "channels" && len(parts) == 5 && parts[3] == "login" && parts[4] == "wait":
+		return "set", parts[2], "login"
+	}
+	return "", "", ""
+}
+
+var (
+	auditLogOnce sync.Once
+	auditLog     *audit.Log
+	auditLogErr  error
+)
+
+// credentialAuditLog returns the audit log under the CLI state dir.
+func credentialAuditLog() (*audit.Log, error) {
+	auditLogOnce.Do(func() {
+		dir, err := paths.State()
+		if err != nil {
+			auditLogErr = err
+			return
+		}
+		auditLog = audit.Open(filepath.Join(dir, "audit", "credentials.jsonl"))
+	})
+	return auditLog, auditLogErr
+}
+
+// recordCredentialOp appends e to the audit log. A failure to record is
+// reported but doesn't fail the operation itself.
+func recordCredentialOp(e audit.Entry) {
+	log, err := credentialAuditLog()
+	if err == nil {
+		_, err = log.Append(e)
+	}
+	if err != nil {
+		warn("cannot write credential audit log: " + err.Error())
+	}
+}
+
+func auditActor() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	if host == "" {
+		return name
+	}
+	return name + "@" + host
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ellie/apps/cli/internal/redact"
+)
+
+// ── HTTP tracing ────────────────────────────────────────────────────────────
+
+// debugHTTP is --debug-http: every request to the server is traced on
+// stderr, from name lookup and connecting to the response headers.
+var debugHTTP bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&debugHTTP, "debug-http", os.Getenv("ELLIE_DEBUG_HTTP") != "",
+		"Trace every request to the server on stderr: lookups, connects, headers (secrets masked), timing and retries (or set ELLIE_DEBUG_HTTP)")
+}
+
+// httpTraceSeq numbers the traced requests, to tell concurrent ones apart.
+var httpTraceSeq atomic.Int64
+
+// httpTracer prints the events of one request, each with the time since
+// it started.
+type httpTracer struct {
+	id    int64
+	start time.Time
+
+	mu       sync.Mutex
+	attempts int
+	dnsStart time.Time
+	conns    map[string]time.Time // connect start by address
+	tlsStart time.Time
+}
+
+func (t *httpTracer) printf(format string, args ...any) {
+	msg := fmt.Sprintf("http #%d +%s  ", t.id, traceSince(t.start)) + fmt.Sprintf(format, args...)
+	if ciMode() {
+		ciLog("debug", msg)
+		return
+	}
+	fmt.Fprintln(os.Stderr, styleDim.Render(msg))
+}
+
+// traceSince is the time since start, to the millisecond, or to the
+// microsecond when less: local connects take that long.
+func traceSince(start time.Time) time.Duration {
+	d := time.Since(start)
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+func (t *httpTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(hostPort string) {
+			t.mu.Lock()
+			t.attempts++
+			attempt := t.attempts
+			t.mu.Unlock()
+			// The transport asks for a connection again when it retries
+			// a request whose reused connection the server had closed.
+			if attempt > 1 {
+				t.printf("retrying on a new connection (attempt %d)", attempt)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				t.printf("reusing connection to %s (idle %s)", info.Conn.RemoteAddr(), info.IdleTime.Round(time.Millisecond))
+			}
+		},
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+			t.printf("looking up %s", info.Host)
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			d := traceSince(t.dnsStart)
+			t.mu.Unlock()
+			if info.Err != nil {
+				t.printf("lookup failed after %s: %v", d, info.Err)
+				return
+			}
+			addrs := make([]string, len(info.Addrs))
+			for i, a := range info.Addrs {
+				addrs[i] = a.String()
+			}
+			t.printf("resolved to %s (%s)", strings.Join(addrs, ", "), d)
+		},
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			t.conns[network+" "+addr] = time.Now()
+			t.mu.Unlock()
+			t.printf("connecting to %s %s", network, addr)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			d := traceSince(t.conns[network+" "+addr])
+			t.mu.Unlock()
+			if err != nil {
+				t.printf("connect to %s %s failed after %s: %v", network, addr, d, err)
+				return
+			}
+			t.printf("connected to %s %s (%s)", network, addr, d)
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			t.mu.Lock()
+			d := traceSince(t.tlsStart)
+			t.mu.Unlock()
+			if err != nil {
+				t.printf("TLS handshake failed after %s: %v", d, err)
+				return
+			}
+			t.printf("TLS handshake done: %s, %s (%s)", tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite), d)
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err != nil {
+				t.printf("sending the request failed: %v", info.Err)
+				return
+			}
+			t.printf("request sent, waiting for the response")
+		},
+	}
+}
+
+// debugRoundTrip sends req with base, tracing it.
+func debugRoundTrip(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	t := &httpTracer{id: httpTraceSeq.Add(1), start: time.Now(), conns: map[string]time.Time{}}
+	t.printf("→ %s %s %s", req.Method, redact.Text(req.URL.Redacted()), req.Proto)
+	for _, line := range headerLines(req.Header) {
+		t.printf("    %s", line)
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), t.clientTrace()))
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		t.printf("✗ %s %s failed: %v", req.Method, req.URL.Path, err)
+		return nil, err
+	}
+	t.printf("← %s %s", resp.Proto, resp.Status)
+	for _, line := range headerLines(resp.Header) {
+		t.printf("    %s", line)
+	}
+	return resp, nil
+}
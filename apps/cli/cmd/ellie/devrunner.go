@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/term"
+
+	"ellie/apps/cli/internal/workspace"
+)
+
+// ── multi-process dev runner ────────────────────────────────────────────────
+
+var devNoTurbo bool
+
+func init() {
+	devCmd.Flags().BoolVar(&devNoTurbo, "no-turbo", false, "Run each package's dev script directly, with prefixed output and keys to restart one")
+}
+
+// devApp is one package's dev script, run by runDevApps.
+type devApp struct {
+	pkg   string // package name, which the log and output filter use
+	label string // its name without the scope, shown before each line
+	dir   string
+	key   byte // restarts it; 0 past the ninth app
+
+	cmd      *exec.Cmd
+	exited   chan error
+	stopping bool
+}
+
+// devApps returns the packages with a dev script that filters — turbo
+// --filter arguments — select.
+func devApps(root string, filters []string) ([]*devApp, error) {
+	pkgs, err := workspace.Discover(root)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(filters))
+	for i, f := range filters {
+		names[i] = strings.TrimPrefix(f, "--filter=")
+	}
+	selected, err := workspace.Select(pkgs, names)
+	if err != nil {
+		return nil, fmt.Errorf("--no-turbo: %w", err)
+	}
+	var apps []*devApp
+	for _, p := range selected {
+		if p.Scripts["dev"] == "" {
+			continue
+		}
+		a := &devApp{pkg: p.Name, label: p.Name[strings.LastIndex(p.Name, "/")+1:], dir: p.Dir}
+		if len(apps) < 9 {
+			a.key = byte('1' + len(apps))
+		}
+		apps = append(apps, a)
+	}
+	if len(apps) == 0 {
+		return nil, fmt.Errorf("no selected package has a dev script")
+	}
+	return apps, nil
+}
+
+// devRunner runs apps side by side and multiplexes their output.
+type devRunner struct {
+	bun   string
+	log   *logStore
+	raw   bool // the terminal is in raw mode: lines end in \r\n
+	width int
+	apps  []*devApp
+
+	mu       sync.Mutex // guards the apps' processes, exitCode and the terminal
+	exitCode int
+	changed  chan struct{}
+}
+
+// runDevApps runs each app's dev script with bun until they have all
+// exited, or — when stdin is a terminal — until q. It returns the first
+// non-zero exit code of an app that stopped on its own.
+func runDevApps(root string, apps []*devApp, log *logStore) (int, error) {
+	bun, err := findBin("bun", root)
+	if err != nil {
+		return 0, err
+	}
+	r := &devRunner{bun: bun, log: log, apps: apps, changed: make(chan struct{}, 1)}
+	for _, a := range apps {
+		r.width = max(r.width, len(a.label))
+	}
+
+	keys := make(chan byte)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		old, err := term.MakeRaw(fd)
+		if err != nil {
+			return 0, err
+		}
+		defer term.Restore(fd, old)
+		r.raw = true
+		go func() {
+			var b [1]byte
+			for {
+				if _, err := os.Stdin.Read(b[:]); err != nil {
+					return
+				}
+				keys <- b[0]
+			}
+		}()
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	if serverPortWatch != nil {
+		serverPortWatch.notify = func(msg string) { r.println(styleDim.Render(msg)) }
+	}
+	if r.raw {
+		var hint []string
+		for _, a := range apps {
+			if a.key != 0 {
+				hint = append(hint, string(a.key)+" "+a.label)
+			}
+		}
+		r.println(styleDim.Render("Restart: " + strings.Join(hint, " · ") + " · a all — q quits"))
+	}
+	for _, a := range apps {
+		r.start(a)
+	}
+
+	for {
+		select {
+		case k := <-keys:
+			switch k {
+			case 'q', 3, 4: // Ctrl-C and Ctrl-D too: raw mode delivers them as keys
+				r.stopAll()
+				return 0, nil
+			case 'a':
+				for _, a := range apps {
+					r.restart(a)
+				}
+			default:
+				for _, a := range apps {
+					if a.key == k {
+						r.restart(a)
+					}
+				}
+			}
+		case <-sigCh:
+			r.stopAll()
+			return 0, nil
+		case <-r.changed:
+			r.mu.Lock()
+			running := false
+			for _, a := range apps {
+				running = running || a.cmd != nil
+			}
+			r.mu.Unlock()
+			if !running && !r.raw {
+				return r.exitCode, nil
+			}
+		}
+	}
+}
+
+// println writes a line of ellie's own to the terminal.
+func (r *devRunner) println(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.write(line)
+}
+
+// write writes line to the terminal; r.mu must be held.
+func (r *devRunner) write(line string) {
+	end := "\n"
+	if r.raw {
+		end = "\r\n"
+	}
+	io.WriteString(os.Stdout, line+end)
+}
+
+// output returns a writer for a's output that prefixes each line with
+// its name and records it in the dev log.
+func (r *devRunner) output(a *devApp) (io.Writer, func()) {
+	prefix := appStyle(a.pkg).Render(fmt.Sprintf("%-*s", r.width, a.label)) + styleDim.Render(" │ ")
+	lw := &lineWriter{onLine: func(line string) {
+		line = strings.TrimRight(line, "\r")
+		if r.log != nil {
+			r.log.writeLine(a.pkg + ":dev: " + line)
+		}
+		if serverPortWatch != nil {
+			serverPortWatch.line(line)
+		}
+		if devOutputFilter != nil && !devOutputFilter.Allow(a.pkg, ansi.Strip(line)) {
+			return
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if childOutput != nil {
+			childOutput.Line(a.pkg+":dev", termWriter{r}, prefix+line)
+			return
+		}
+		r.write(prefix + line)
+	}}
+	return lw, lw.flush
+}
+
+// termWriter writes to the terminal with r.write, for childOutput to
+// release held-back lines through. r.mu must be held.
+type termWriter struct{ r *devRunner }
+
+func (w termWriter) Write(p []byte) (int, error) {
+	w.r.write(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// notice writes a line about a to the terminal and the dev log.
+func (r *devRunner) notice(a *devApp, msg string) {
+	if r.log != nil {
+		r.log.writeLine(a.pkg + ":dev: ellie: " + msg)
+	}
+	r.write(appStyle(a.pkg).Render(fmt.Sprintf("%-*s", r.width, a.label)) + styleDim.Render(" │ ") + styleBold.Render(msg))
+}
+
+// start runs a's dev script.
+func (r *devRunner) start(a *devApp) {
+	cmd := exec.Command(r.bun, "run", "dev")
+	cmd.Dir = a.dir
+	cmd.SysProcAttr = childAttr()
+	stdout, flushOut := r.output(a)
+	stderr, flushErr := r.output(a)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	cmd.Env = append(os.Environ(), localCredentialEnv()...)
+	cmd.Env = append(cmd.Env, serverEnv...)
+	// Output goes through a pipe; keep colors in the terminal.
+	cmd.Env = append(cmd.Env, "FORCE_COLOR=1")
+	// Don't let a grandchild holding the output pipe keep a stopped
+	// child from being reaped.
+	cmd.WaitDelay = 5 * time.Second
+	logCommand(cmd)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := cmd.Start(); err != nil {
+		r.notice(a, "cannot start: "+err.Error())
+		return
+	}
+	exited := make(chan error, 1)
+	a.cmd, a.exited = cmd, exited
+	go func() {
+		err := cmd.Wait()
+		flushOut()
+		flushErr()
+		exited <- err
+
+		r.mu.Lock()
+		if a.cmd == cmd && !a.stopping {
+			msg := "exited"
+			if code := cmd.ProcessState.ExitCode(); code != 0 {
+				msg = fmt.Sprintf("exited with code %d", code)
+				if r.exitCode == 0 {
+					r.exitCode = code
+				}
+				if childOutput != nil {
+					childOutput.Fail(a.pkg + ":dev")
+				}
+			}
+			if r.raw && a.key != 0 {
+				msg += fmt.Sprintf(" — press %c to restart", a.key)
+			}
+			r.notice(a, msg)
+			a.cmd = nil
+		}
+		r.mu.Unlock()
+		select {
+		case r.changed <- struct{}{}:
+		default:
+		}
+	}()
+}
+
+// stop stops a's dev script and everything it started, if it is running.
+func (r *devRunner) stop(a *devApp) {
+	r.mu.Lock()
+	cmd, exited := a.cmd, a.exited
+	a.stopping = true
+	r.mu.Unlock()
+	if cmd != nil {
+		stopChild(cmd, exited)
+	}
+	r.mu.Lock()
+	a.cmd, a.stopping = nil, false
+	r.mu.Unlock()
+}
+
+func (r *devRunner) restart(a *devApp) {
+	r.stop(a)
+	r.println(styleDim.Render("Restarting " + a.label + "..."))
+	r.start(a)
+}
+
+// stopAll stops every app at once.
+func (r *devRunner) stopAll() {
+	r.println(styleDim.Render("Stopping..."))
+	var wg sync.WaitGroup
+	for _, a := range r.apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.stop(a)
+		}()
+	}
+	wg.Wait()
+}
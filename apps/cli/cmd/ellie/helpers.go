@@ -2,12 +2,12 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
-	"os/signal"
 	"path/filepath"
 	"runtime"
-	"syscall"
+	"time"
 )
 
 // findMonorepoRoot walks up from CWD looking for turbo.json.
@@ -57,35 +57,112 @@ func findBin(name string, root string) (string, error) {
 
 // runProcess spawns a child process, forwards signals, and returns its exit code.
 func runProcess(name string, args []string, dir string) int {
+	return runLoggedProcess(name, args, dir, nil)
+}
+
+// runLoggedProcess is runProcess with the child's stdout/stderr also
+// recorded to log (when non-nil) for later viewing with `ellie logs`.
+func runLoggedProcess(name string, args []string, dir string, log *logStore) int {
+	return runWatchedProcess(name, args, dir, log, watchdogSettings{})
+}
+
+// runChild runs one child process for runWatchedProcess, reporting
+// whether it was stopped for hanging.
+func runChild(name string, args []string, dir string, log *logStore, wd watchdogSettings) (exitCode int, hung bool) {
 	cmd := exec.Command(name, args...)
+	if serverListener != nil {
+		// LISTEN_PID must be the server's own pid, which only a shell
+		// that then execs it knows.
+		cmd = exec.Command("/bin/sh", append([]string{"-c", `LISTEN_PID=$$ exec "$0" "$@"`, name}, args...)...)
+		cmd.ExtraFiles = []*os.File{serverListener}
+	}
 	cmd.Dir = dir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = childAttr()
+	stdout, stderr := io.Writer(os.Stdout), io.Writer(os.Stderr)
+	if childOutput != nil {
+		var flushOut, flushErr func()
+		stdout, flushOut = childOutput.Writer(os.Stdout)
+		stderr, flushErr = childOutput.Writer(os.Stderr)
+		defer flushOut()
+		defer flushErr()
+	}
+	if devOutputFilter != nil {
+		var flushOut, flushErr func()
+		stdout, flushOut = filteredWriter(stdout, *devOutputFilter)
+		stderr, flushErr = filteredWriter(stderr, *devOutputFilter)
+		defer flushOut()
+		defer flushErr()
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 	cmd.Stdin = os.Stdin
-	cmd.Env = os.Environ()
+	// Servers started here pick up an Anthropic credential kept in the
+	// OS keyring (ellie auth --local).
+	cmd.Env = append(os.Environ(), localCredentialEnv()...)
+	cmd.Env = append(cmd.Env, serverEnv...)
+	if serverListener != nil {
+		cmd.Env = append(cmd.Env, "LISTEN_FDS=1", "LISTEN_FDNAMES=http")
+	}
+
+	var logw io.Writer
+	if log != nil {
+		w, flush := log.Writer()
+		defer flush()
+		logw = w
+		cmd.Stdout = io.MultiWriter(stdout, w)
+		cmd.Stderr = io.MultiWriter(stderr, w)
+		// Output now goes through a pipe; keep colors in the terminal.
+		cmd.Env = append(cmd.Env, "FORCE_COLOR=1")
+	}
+
+	if childStderrTail != nil {
+		w, flush := childStderrTail.Writer()
+		defer flush()
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, w)
+	}
+
+	if serverPortWatch != nil {
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, serverPortWatch.Writer())
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, serverPortWatch.Writer())
+	}
 
+	dog := wd.watchdog()
+	if dog != nil {
+		cmd.Stdout = dog.Writer(cmd.Stdout)
+		cmd.Stderr = dog.Writer(cmd.Stderr)
+		if log == nil {
+			cmd.Env = append(cmd.Env, "FORCE_COLOR=1")
+		}
+		// Don't let a grandchild holding the output pipe keep a stopped
+		// child from being reaped.
+		cmd.WaitDelay = 5 * time.Second
+	}
+
+	logCommand(cmd)
 	if err := cmd.Start(); err != nil {
 		fmt.Fprintln(os.Stderr, styleErr.Render("Error:"), err)
-		return 1
+		return 1, false
 	}
 
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		sig := <-sigCh
-		_ = cmd.Process.Signal(sig)
-	}()
+	stopForwarding := forwardSignals(cmd, filepath.Base(name))
 
-	err := cmd.Wait()
-	signal.Stop(sigCh)
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	var err error
+	if dog != nil {
+		hung, err = watchChild(cmd, dog, exited, wd, logw)
+	} else {
+		err = <-exited
+	}
+	stopForwarding()
 
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return exitErr.ExitCode()
+			return exitErr.ExitCode(), hung
 		}
-		return 1
+		return 1, hung
 	}
-	return 0
+	return 0, hung
 }
 
 func openBrowser(url string) error {
@@ -98,5 +175,6 @@ func openBrowser(url string) error {
 	default:
 		cmd = exec.Command("xdg-open", url)
 	}
+	logCommand(cmd)
 	return cmd.Start()
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+
+	"ellie/apps/cli/internal/termimage"
+)
+
+// ── inline images ───────────────────────────────────────────────────────────
+
+// maxImageCols caps how wide an image is drawn, in terminal cells.
+const maxImageCols = 80
+
+// imageProtocol returns the graphics protocol of ui.images, detecting the
+// terminal's when it is auto.
+func imageProtocol() termimage.Protocol {
+	switch p, _ := setting("ui.images"); p {
+	case "", "auto":
+		return termimage.Detect(os.Getenv)
+	default:
+		return termimage.Protocol(p)
+	}
+}
+
+// showImage draws the image data, called name, on f when f is a terminal
+// whose graphics protocol ellie speaks. Otherwise, or when the image
+// can't be decoded, it prints a line with its size and dimensions.
+func showImage(f *os.File, name string, data []byte) {
+	fd := int(f.Fd())
+	if p := imageProtocol(); p != termimage.None && term.IsTerminal(fd) {
+		cols := maxImageCols
+		if w, _, err := term.GetSize(fd); err == nil && w > 0 {
+			cols = min(cols, w)
+		}
+		if termimage.Render(f, data, p, cols) == nil {
+			return
+		}
+	}
+	desc := formatBytes(int64(len(data)))
+	if info, err := termimage.Describe(bytes.NewReader(data)); err == nil {
+		desc = info.String() + ", " + desc
+	}
+	fmt.Fprintln(f, styleDim.Render(fmt.Sprintf("▣ %s (%s)", name, desc)))
+}
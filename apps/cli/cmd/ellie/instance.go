@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"ellie/apps/cli/internal/config"
+	"ellie/apps/cli/internal/paths"
+)
+
+// ── dev instances ───────────────────────────────────────────────────────────
+
+var instanceFlag string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&instanceFlag, "instance", "",
+		"Dev instance to work with, as started by ellie dev --instance (default: ELLIE_INSTANCE)")
+}
+
+// instanceName matches the names instances may have; each is a directory.
+var instanceName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// currentInstance is the dev instance this command works with, or "".
+var currentInstance string
+
+// applyInstance moves the state directory to the instance's for the
+// instance given with --instance or ELLIE_INSTANCE, so that its
+// pidfiles, server port, logs and sessions are kept apart from the other
+// instances' and the default's. Commands then see only that instance's
+// dev server. The environment is set too, for the ellie commands the
+// dev servers run.
+func applyInstance() error {
+	name := instanceFlag
+	if name == "" {
+		name = os.Getenv("ELLIE_INSTANCE")
+	}
+	if name == "" {
+		return nil
+	}
+	if !instanceName.MatchString(name) {
+		return fmt.Errorf("invalid instance name %q: use lowercase letters, digits, - and _", name)
+	}
+	state, err := paths.State()
+	if err != nil {
+		return err
+	}
+	os.Setenv("ELLIE_STATE_DIR", filepath.Join(instancesDir(state), name))
+	os.Setenv("ELLIE_INSTANCE", name)
+	currentInstance = name
+	return nil
+}
+
+// instancesDir returns the directory of the instances of the state
+// directory state, which may be an instance's itself when ellie runs
+// under a dev instance.
+func instancesDir(state string) string {
+	if parent := filepath.Dir(state); filepath.Base(parent) == "instances" {
+		return parent
+	}
+	return filepath.Join(state, "instances")
+}
+
+// instanceArgs returns the flag that selects the current instance, for
+// the commands ellie suggests.
+func instanceArgs() string {
+	if currentInstance == "" {
+		return ""
+	}
+	return " --instance " + currentInstance
+}
+
+// instanceSetting returns dev.instances.<name>.<key> for the current
+// instance.
+func instanceSetting(key string) (config.Setting, bool) {
+	return settings().Get("dev.instances." + currentInstance + "." + key)
+}
+
+// instanceServerEnv returns what the current instance adds to its dev
+// servers' environment: its own DATA_DIR, so the server's database and
+// uploads are its own, and dev.instances.<name>.env.
+func instanceServerEnv() ([]string, error) {
+	state, err := paths.State()
+	if err != nil {
+		return nil, err
+	}
+	env := []string{"DATA_DIR=" + filepath.Join(state, "data")}
+	if s, ok := instanceSetting("env"); ok {
+		list, _ := s.Value.([]any)
+		for _, v := range list {
+			kv, _ := v.(string)
+			if k, _, ok := strings.Cut(kv, "="); !ok || k == "" {
+				return nil, fmt.Errorf("dev.instances.%s.env: %q is not NAME=value", currentInstance, kv)
+			}
+			env = append(env, kv)
+		}
+	}
+	return env, nil
+}
+
+// devInstances lists the instances that have a state directory, by name.
+func devInstances() []string {
+	state, err := paths.State()
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(instancesDir(state))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && instanceName.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// instanceStateDir returns the state directory of the instance name, or
+// of the default when name is "".
+func instanceStateDir(name string) (string, error) {
+	state, err := paths.State()
+	if err != nil {
+		return "", err
+	}
+	dir := instancesDir(state)
+	if name == "" {
+		return filepath.Dir(dir), nil
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// devInstanceRunning reports whether any instance's ellie dev is running.
+func devInstanceRunning() bool {
+	for _, name := range devInstances() {
+		dir, err := instanceStateDir(name)
+		if err != nil {
+			continue
+		}
+		if _, _, ok := runningPidfile(pidPathIn(dir, "dev")); ok {
+			return true
+		}
+	}
+	return false
+}
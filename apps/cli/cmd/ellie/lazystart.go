@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// ── lazy start ──────────────────────────────────────────────────────────────
+
+// serverListener, when set, is handed to server processes as fd 3 using
+// the systemd socket activation protocol (LISTEN_FDS=1), so the server
+// accepts on the socket ellie opened instead of binding its own.
+var serverListener *os.File
+
+// lazyListen opens the socket the server will inherit, on the port of
+// the configured server URL and all interfaces, as the server binds.
+func lazyListen() (*net.TCPListener, error) {
+	if runtime.GOOS == "windows" {
+		return nil, errors.New("--lazy needs socket activation, which Windows doesn't support")
+	}
+	if serverSocket != "" {
+		return nil, errors.New("--lazy listens on the server's port, but the server URL is a Unix socket")
+	}
+	u, err := url.Parse(baseURL())
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("cannot tell the server's port from %q", baseURL())
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	ln, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen on port %s: %w", port, err)
+	}
+	return ln.(*net.TCPListener), nil
+}
+
+// awaitFirstConnection blocks until a client connects to ln, returning
+// that connection, or until ellie is told to stop, returning nil. Only
+// this first connection is accepted here; once the server is started it
+// accepts the rest itself.
+func awaitFirstConnection(ln *net.TCPListener) (net.Conn, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	accepted := make(chan net.Conn, 1)
+	failed := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			failed <- err
+			return
+		}
+		accepted <- conn
+	}()
+	select {
+	case conn := <-accepted:
+		return conn, nil
+	case err := <-failed:
+		return nil, err
+	case <-ctx.Done():
+		ln.Close()
+		return nil, nil
+	}
+}
+
+// forwardToServer hands conn, accepted before the server existed, on to
+// it: the connection to the shared socket waits in its backlog until the
+// server starts accepting.
+func forwardToServer(conn net.Conn, addr net.Addr) {
+	defer conn.Close()
+	port := addr.(*net.TCPAddr).Port
+	server, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 10*time.Second)
+	if err != nil {
+		return
+	}
+	defer server.Close()
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(server, conn)
+		server.(*net.TCPConn).CloseWrite()
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, server)
+		conn.(*net.TCPConn).CloseWrite()
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+}
+
+// lazyStart waits for the first connection on ln and sets the server up
+// to inherit the socket, reporting false when ellie was stopped first.
+func lazyStart(ln *net.TCPListener) (bool, error) {
+	f, err := ln.File()
+	if err != nil {
+		ln.Close()
+		return false, err
+	}
+	fmt.Println(styleDim.Render(fmt.Sprintf("Listening on %s — the server starts with the first request", ln.Addr())))
+	conn, err := awaitFirstConnection(ln)
+	if err != nil || conn == nil {
+		f.Close()
+		return false, err
+	}
+	// The server gets the socket through f; this copy is done.
+	addr := ln.Addr()
+	ln.Close()
+	serverListener = f
+	go forwardToServer(conn, addr)
+	fmt.Println(styleDim.Render(fmt.Sprintf("First request from %s — starting the server", conn.RemoteAddr())))
+	return true, nil
+}
+
+// waitListening waits for a lazy `ellie start` to write its pidfile,
+// which it does once its socket is open.
+func waitListening(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if _, _, ok := runningProcess("start"); ok {
+			return true
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"ellie/apps/cli/internal/audit"
+	"ellie/apps/cli/internal/credentials"
+)
+
+// ── local (OS keyring) credentials ──────────────────────────────────────────
+
+// authLocal stores credentials in the OS keyring instead of on the server.
+var authLocal bool
+
+// localCredentials reads through the credential agent when one is running.
+var localCredentials = credentials.NewWith(agentKeyring{})
+
+// saveLocalCredential stores secret in the OS keyring and records it in
+// the credential audit log.
+func saveLocalCredential(provider, method, secret string) error {
+	err := localCredentials.Save(credentials.Credential{Provider: provider, Method: method, Secret: secret})
+	e := audit.Entry{
+		Actor:    auditActor(),
+		Op:       "set",
+		Provider: provider,
+		Method:   strings.ReplaceAll(method, "_", "-"), // as the server routes name it
+		Server:   "keyring",
+		OK:       err == nil,
+	}
+	if err != nil {
+		e.Detail = err.Error()
+	}
+	recordCredentialOp(e)
+	if err != nil {
+		return fmt.Errorf("cannot save to the OS keyring: %w", err)
+	}
+	return nil
+}
+
+// saveCredentialFallback stores an Anthropic credential in the OS keyring
+// after the server could not be reached. Other providers have no local
+// fallback and just report reachErr, as does a server that answered but
+// is incompatible with this CLI.
+func saveCredentialFallback(provider, method, secret string, reachErr error) error {
+	var skew *skewError
+	if errors.As(reachErr, &skew) {
+		return errSilent
+	}
+	if provider != "anthropic" {
+		return fmt.Errorf("cannot reach server: %w", reachErr)
+	}
+	fmt.Fprintln(os.Stderr, styleDim.Render("Server unreachable at "+baseURL()+" — storing the credential in the OS keyring instead."))
+	if err := saveLocalCredential(provider, method, secret); err != nil {
+		return fmt.Errorf("cannot reach server (%v), and %w", reachErr, err)
+	}
+	return nil
+}
+
+// clearLocalCredential removes a keyring credential, reporting whether
+// one was there.
+func clearLocalCredential(provider, name string) {
+	err := localCredentials.Delete(provider)
+	switch {
+	case err == nil:
+		recordCredentialOp(audit.Entry{Actor: auditActor(), Op: "clear", Provider: provider, Server: "keyring", OK: true})
+		fmt.Println(styleOk.Render(name + " keyring credential removed."))
+	case errors.Is(err, credentials.ErrNotFound), errors.Is(err, credentials.ErrUnsupported):
+	default:
+		warn("cannot clear keyring credential: " + err.Error())
+	}
+}
+
+// localCredentialEnv returns environment entries that hand the keyring
+// credential to a server started by the CLI. Variables already set in the
+// environment win.
+func localCredentialEnv() []string {
+	c, err := localCredentials.Load("anthropic")
+	if err != nil {
+		return nil
+	}
+	name := c.EnvVar()
+	if name == "" || os.Getenv(name) != "" {
+		return nil
+	}
+	return []string{name + "=" + c.Secret}
+}
+
+// printLocalCredentialStatus prints the keyring credential, if any, and
+// returns it.
+func printLocalCredentialStatus() *credentials.Credential {
+	c, err := localCredentials.Load("anthropic")
+	if err != nil {
+		if !errors.Is(err, credentials.ErrNotFound) && !errors.Is(err, credentials.ErrUnsupported) {
+			fmt.Println()
+			fmt.Println(styleDim.Render("  OS keyring: " + err.Error()))
+		}
+		return nil
+	}
+	fmt.Println()
+	fmt.Println(styleBold.Render("  Anthropic (OS keyring)"))
+	fmt.Println("    Mode:   ", c.Method)
+	fmt.Println("    Key:    ", c.Preview())
+	fmt.Println("    Saved:  ", c.SavedAt.Format("2006-01-02 15:04"))
+	fmt.Println(styleDim.Render("    Passed to servers started with ellie dev / ellie start"))
+	return &c
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"ellie/apps/cli/internal/logrotate"
+	"ellie/apps/cli/internal/paths"
+)
+
+// logPath returns the managed log file for a stream ("dev", "start").
+func logPath(stream string) (string, error) {
+	dir, err := paths.Logs()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, stream+".log"), nil
+}
+
+// turboPrefix matches turbo's stream-mode line prefix: "<package>:<task>: ".
+var turboPrefix = regexp.MustCompile(`^(\S+):([A-Za-z0-9_-]+): ?(.*)$`)
+
+// logRecord is one captured line of child output.
+type logRecord struct {
+	Time time.Time
+	App  string
+	Text string
+}
+
+// logPolicy returns the rotation of the managed logs: logs.max_size,
+// logs.keep and logs.max_age.
+func logPolicy() logrotate.Policy {
+	var p logrotate.Policy
+	if s, ok := settings().Get("logs.max_size"); ok {
+		if mb, ok := s.Value.(int64); ok && mb > 0 {
+			p.MaxSize = mb << 20
+		}
+	}
+	if s, ok := settings().Get("logs.keep"); ok {
+		if n, ok := s.Value.(int64); ok && n > 0 {
+			p.Keep = int(n)
+		}
+	}
+	if v, ok := setting("logs.max_age"); ok {
+		p.MaxAge, _ = time.ParseDuration(v)
+	}
+	return p
+}
+
+// logStore appends timestamped, ANSI-stripped child output to a stream's
+// log file, rotating it as logPolicy says. Each record is a single
+// tab-separated line:
+//
+//	<RFC3339Nano>\t<app>\t<text>
+type logStore struct {
+	mu         sync.Mutex
+	f          *logrotate.File
+	defaultApp string
+}
+
+// openLogStore opens (creating if needed) the log file for stream.
+// Lines without a turbo prefix are attributed to defaultApp.
+func openLogStore(stream, defaultApp string) (*logStore, error) {
+	path, err := logPath(stream)
+	if err != nil {
+		return nil, err
+	}
+	policy := logPolicy()
+	f, err := logrotate.Open(path, policy)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open log file: %w", err)
+	}
+	// Apply a lowered logs.keep or logs.max_age before the next rotation.
+	logrotate.Prune(path, policy)
+	return &logStore{f: f, defaultApp: defaultApp}, nil
+}
+
+func (s *logStore) Close() error {
+	return s.f.Close()
+}
+
+// writeLine records one line of output.
+func (s *logStore) writeLine(line string) {
+	line = strings.TrimRight(ansi.Strip(line), "\r")
+	app := s.defaultApp
+	if m := turboPrefix.FindStringSubmatch(line); m != nil {
+		app, line = m[1], m[3]
+	}
+	rec := fmt.Sprintf("%s\t%s\t%s\n", time.Now().UTC().Format(time.RFC3339Nano), app, line)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.f.Write([]byte(rec))
+}
+
+// Writer returns an io.Writer that splits written bytes into lines and
+// records each one. Call the returned flush func after the child exits
+// to record any trailing partial line.
+func (s *logStore) Writer() (io.Writer, func()) {
+	lw := &lineWriter{onLine: s.writeLine}
+	return lw, lw.flush
+}
+
+// lineWriter buffers partial writes and emits complete lines.
+type lineWriter struct {
+	mu     sync.Mutex
+	buf    []byte
+	onLine func(string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.onLine(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.onLine(string(w.buf))
+		w.buf = nil
+	}
+}
+
+// parseLogRecord parses a stored record; ok is false for malformed lines.
+func parseLogRecord(line string) (logRecord, bool) {
+	parts := strings.SplitN(line, "\t", 3)
+	if len(parts) != 3 {
+		return logRecord{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return logRecord{}, false
+	}
+	return logRecord{Time: t, App: parts[1], Text: parts[2]}, true
+}
+
+// readLogHistory calls fn for each record of the open log f, starting
+// with those of its rotated files.
+func readLogHistory(f *os.File, fn func(logRecord)) error {
+	for _, name := range logrotate.Files(f.Name()) {
+		if name == f.Name() {
+			continue
+		}
+		old, err := os.Open(name)
+		if err != nil {
+			continue // rotated away meanwhile
+		}
+		err = readLogRecords(old, fn)
+		old.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return readLogRecords(f, fn)
+}
+
+// readLogRecords scans r and calls fn for each well-formed record.
+func readLogRecords(r io.Reader, fn func(logRecord)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if rec, ok := parseLogRecord(scanner.Text()); ok {
+			fn(rec)
+		}
+	}
+	return scanner.Err()
+}
@@ -6,21 +6,21 @@ import (
 	"net/http"
 	"os"
 	"strings"
-	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/spf13/cobra"
-)
 
-const defaultBaseURL = "http://localhost:3000"
+	"ellie/apps/cli/internal/config"
+	"ellie/apps/cli/internal/paths"
+)
 
 var (
 	styleBold  = lipgloss.NewStyle().Bold(true)
 	styleOk    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00A66D"))
 	styleErr   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#EF4444"))
 	styleDim   = lipgloss.NewStyle().Foreground(lipgloss.Color("#A1A1AA"))
-	httpClient = &http.Client{Timeout: 10 * time.Second}
+	httpClient = &http.Client{Transport: deadlineTransport{base: credentialTransport}}
 )
 
 // errSilent signals a non-zero exit without additional output from main.
@@ -32,11 +32,34 @@ type exitCodeError int
 
 func (e exitCodeError) Error() string { return fmt.Sprintf("exit code %d", int(e)) }
 
+// serverURL is the server commands talk to: --base-url, else the
+// environment given with --env, else ELLIE_API_URL, else the environment
+// selected by ELLIE_ENV or the env setting, else server.url from the
+// config files, else http://localhost:3000 — on the port a running ellie
+// start or ellie dev was given with --port, if any.
+func serverURL() string {
+	if baseURLFlag != "" {
+		return strings.TrimRight(baseURLFlag, "/")
+	}
+	if e, ok, err := selectedEnv(); err == nil && ok && (envFlag != "" || os.Getenv("ELLIE_API_URL") == "") {
+		return strings.TrimRight(e.URL, "/")
+	}
+	s, _ := settings().Get("server.url")
+	u := config.FormatValue(s.Value)
+	if port, ok := managedServerPort(); ok && s.Origin == "default" {
+		u = withPort(u, port)
+	}
+	return strings.TrimRight(u, "/")
+}
+
+// baseURL is the URL requests to the server are built on: serverURL, or
+// for a server on a Unix socket a stand-in the transport sends there.
 func baseURL() string {
-	if u := os.Getenv("ELLIE_API_URL"); u != "" {
-		return strings.TrimRight(u, "/")
+	u := serverURL()
+	if _, ok := socketPath(u); ok {
+		return socketBaseURL
 	}
-	return defaultBaseURL
+	return u
 }
 
 // requireBaseURL returns the base URL or an error if ELLIE_API_URL is unset
@@ -49,12 +72,18 @@ func requireBaseURL() string {
 var rootCmd = &cobra.Command{
 	Use:   "ellie",
 	Short: "Ellie — AI personal assistant",
+	Long: `Ellie — AI personal assistant.
+
+In CI, set ELLIE_CI=1: nothing prompts or animates, and output has no colours.`,
 	CompletionOptions: cobra.CompletionOptions{
 		DisableDefaultCmd: true,
 	},
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := noPrompt("ellie without a command", "run ellie chat --prompt, or one of the commands in ellie --help"); err != nil {
+			return err
+		}
 		m := newInteractiveModel(cmd)
 		p := tea.NewProgram(m)
 		_, err := p.Run()
@@ -62,16 +91,112 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// prepareCommand runs before every command: it applies the global flags
+// and the settings for reaching the server.
+func prepareCommand(cmd *cobra.Command, args []string) error {
+	ranCommand = cmd
+	if err := prepareJSONOutput(cmd); err != nil {
+		return err
+	}
+	if err := prepareVerbosity(); err != nil {
+		return err
+	}
+	migratePaths()
+	if err := applyInstance(); err != nil {
+		return err
+	}
+	if err := applyProxy(); err != nil {
+		return err
+	}
+	if err := applyTLS(); err != nil {
+		return err
+	}
+	if err := checkBaseURLFlag(); err != nil {
+		return err
+	}
+	if err := applySocket(); err != nil {
+		return err
+	}
+	// The config commands are how a bad env setting gets fixed.
+	if !underCommand(cmd, configCmd) {
+		if _, _, err := selectedEnv(); err != nil {
+			return err
+		}
+	}
+	checkForUpdate(cmd)
+	applyCompression()
+	return applyRequestTimeout(cmd, args)
+}
+
+// underCommand reports whether cmd is parent or one of its subcommands.
+func underCommand(cmd, parent *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// migratePaths moves files from ~/.ellie into the platform directories.
+// A failure only warns; what wasn't moved is tried again next time.
+func migratePaths() {
+	moves, err := paths.Migrate()
+	if len(moves) > 0 {
+		state, _ := paths.State()
+		fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf("Moved %d old ellie files and directories to the platform's standard locations (state: %s)", len(moves), state)))
+	}
+	if err != nil {
+		warn("cannot move old ellie files: " + err.Error())
+	}
+}
+
 func init() {
+	rootCmd.PersistentPreRunE = prepareCommand
 	rootCmd.AddCommand(buildCmd)
 	rootCmd.AddCommand(chatCmd)
+	rootCmd.AddCommand(askCmd)
 	rootCmd.AddCommand(devCmd)
+	rootCmd.AddCommand(scriptsCmd)
+	rootCmd.AddCommand(testCmd)
 	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(restartCmd)
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStartCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonRunCmd)
+	rootCmd.AddCommand(agentCmd)
+	agentCmd.AddCommand(agentStartCmd)
+	agentCmd.AddCommand(agentStopCmd)
+	agentCmd.AddCommand(agentStatusCmd)
+	agentCmd.AddCommand(agentRefreshCmd)
+	agentCmd.AddCommand(agentRunCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(psCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportConfigCmd)
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.AddCommand(validateOpenAPICmd)
 	rootCmd.AddCommand(updateCmd)
+	updateCmd.AddCommand(updateBackgroundCmd)
 	rootCmd.AddCommand(sysinfoCmd)
+	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(authCmd)
 	authCmd.AddCommand(authStatusCmd)
 	authCmd.AddCommand(authClearCmd)
+	authCmd.AddCommand(authProvisionCmd)
+	authCmd.AddCommand(authAuditCmd)
+	authCmd.AddCommand(authAPIKeyCmd)
+	authCmd.AddCommand(authTokenCmd)
+	authCmd.AddCommand(authUseCmd)
+	authCmd.AddCommand(authRefreshCmd)
+	authCmd.AddCommand(authVertexCmd)
+	authCmd.AddCommand(authBedrockCmd)
+	authCmd.AddCommand(authAzureCmd)
 
 	rootCmd.AddCommand(pairCmd)
 	pairCmd.AddCommand(pairListCmd)
@@ -82,19 +207,106 @@ func init() {
 	allowCmd.AddCommand(allowAddCmd)
 	allowCmd.AddCommand(allowRemoveCmd)
 
+	rootCmd.AddCommand(permissionsCmd)
+	permissionsCmd.AddCommand(permissionsListCmd)
+	permissionsCmd.AddCommand(permissionsAllowCmd)
+	permissionsCmd.AddCommand(permissionsDenyCmd)
+	permissionsCmd.AddCommand(permissionsRemoveCmd)
+	permissionsCmd.AddCommand(permissionsDefaultCmd)
+	permissionsCmd.AddCommand(permissionsTestCmd)
+	permissionsCmd.AddCommand(permissionsValidateCmd)
+	permissionsCmd.AddCommand(permissionsProfilesCmd)
+
+	rootCmd.AddCommand(safetyCmd)
+	safetyCmd.AddCommand(safetyScanCmd)
+	safetyCmd.AddCommand(safetyLogCmd)
+
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(configWhatsAppCmd)
+	configCmd.AddCommand(configSyncCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configDoctorCmd)
+
+	rootCmd.AddCommand(sshServeCmd)
+	rootCmd.AddCommand(lspProxyCmd)
+	rootCmd.AddCommand(editorInfoCmd)
+	rootCmd.AddCommand(proxyCmd)
+	proxyCmd.AddCommand(proxyAnthropicCmd)
+
+	rootCmd.AddCommand(serverCmd)
+	serverCmd.AddCommand(serverReleasesCmd)
+	serverCmd.AddCommand(serverRollbackCmd)
+	serverCmd.AddCommand(serverExecAdminCmd)
+	rootCmd.AddCommand(netCmd)
+	netCmd.AddCommand(netTestCmd)
+	rootCmd.AddCommand(flagsCmd)
+	flagsCmd.AddCommand(flagsListCmd)
+	flagsCmd.AddCommand(flagsEnableCmd)
+	flagsCmd.AddCommand(flagsDisableCmd)
+	flagsCmd.AddCommand(flagsTargetCmd)
+	flagsCmd.AddCommand(flagsToggleCmd)
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceRenameCmd)
+	workspaceCmd.AddCommand(workspaceMoveCmd)
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+	rootCmd.AddCommand(benchCmd)
+	benchCmd.AddCommand(benchBuildCmd)
+	rootCmd.AddCommand(attachmentsCmd)
+	attachmentsCmd.AddCommand(attachmentsListCmd)
+	attachmentsCmd.AddCommand(attachmentsDeleteCmd)
+	rootCmd.AddCommand(modelsCmd)
+	modelsCmd.AddCommand(modelsListCmd)
+	rootCmd.AddCommand(sessionsCmd)
+	sessionsCmd.AddCommand(sessionsListCmd)
+	sessionsCmd.AddCommand(sessionsResumeCmd)
+	sessionsCmd.AddCommand(sessionsDeleteCmd)
+	sessionsCmd.AddCommand(sessionsExportCmd)
+	sessionsCmd.AddCommand(sessionsStatsCmd)
+	rootCmd.AddCommand(grepSessionsCmd)
+	rootCmd.AddCommand(explainCmd)
+	explainCmd.AddCommand(explainErrorCmd)
+	rootCmd.AddCommand(envCmd)
+	envCmd.AddCommand(envListCmd)
+	envCmd.AddCommand(envUseCmd)
+	rootCmd.AddCommand(batchCmd)
+	rootCmd.AddCommand(jobsCmd)
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsCmd.AddCommand(jobsStatusCmd)
+	jobsCmd.AddCommand(jobsAttachCmd)
+	jobsCmd.AddCommand(jobsCancelCmd)
+	jobsCmd.AddCommand(jobsResumeCmd)
+	jobsCmd.AddCommand(jobsRunCmd)
+	rootCmd.AddCommand(themesCmd)
+	themesCmd.AddCommand(themesListCmd)
+	themesCmd.AddCommand(themesExportCmd)
+	themesCmd.AddCommand(themesImportCmd)
+	rootCmd.AddCommand(retryCmd)
+	retryCmd.AddCommand(retryLastCmd)
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if err == nil {
+		err = strictFailure()
+	}
+	finishQuiet()
+	if err != nil {
 		var ec exitCodeError
 		if errors.As(err, &ec) {
+			offerRetry(err)
 			os.Exit(int(ec))
 		}
-		if !errors.Is(err, errSilent) {
-			fmt.Fprintln(os.Stderr, styleErr.Render("Error:"), err)
+		var skew *skewError
+		if !errors.Is(err, errSilent) && !errors.As(err, &skew) {
+			printError(err)
 		}
+		offerRetry(err)
 		os.Exit(1)
 	}
 }
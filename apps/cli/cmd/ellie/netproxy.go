@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ── outbound proxy ──────────────────────────────────────────────────────────
+
+// proxyOf picks the proxy for a request ellie sends, to the server or to
+// anyone else: the proxy setting when it is set, else HTTPS_PROXY or
+// HTTP_PROXY. Hosts in NO_PROXY, and loopback addresses, are reached
+// directly either way.
+var proxyOf = http.ProxyFromEnvironment
+
+// applyProxy resolves the proxy setting and routes every client through
+// it: those built on http.DefaultTransport and those on serverTransport.
+func applyProxy() error {
+	cfg := httpproxy.FromEnvironment()
+	if p, ok := setting("proxy"); ok && p != "" {
+		u, err := url.Parse(p)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("proxy = %q is not a proxy URL (use e.g. http://proxy.corp:3128 or socks5://localhost:1080)", p)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("proxy = %q: ellie can use http, https and socks5 proxies, not %s", p, u.Scheme)
+		}
+		cfg.HTTPProxy, cfg.HTTPSProxy = p, p
+	}
+	fn := cfg.ProxyFunc()
+	proxyOf = func(req *http.Request) (*url.URL, error) { return fn(req.URL) }
+
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.Proxy = proxyOf
+	}
+	connectTransport.Proxy = proxyOf
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// ── JSON output ─────────────────────────────────────────────────────────────
+
+// jsonFlag is the global --json. A command that supports it prints one
+// JSON document to standard output, and everything meant for people —
+// progress, warnings, hints — to standard error.
+var jsonFlag bool
+
+// jsonOut is the real standard output. With --json, os.Stdout is pointed
+// at standard error so that only printJSON writes here.
+var jsonOut io.Writer = os.Stdout
+
+// jsonCommands are the commands with JSON output. The shapes are part of
+// ellie's interface: fields may be added, but not renamed or removed.
+var jsonCommands = map[*cobra.Command]bool{}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&jsonFlag, "json", false, "Print the result as JSON on standard output, and other text on standard error")
+	for _, c := range []*cobra.Command{
+		versionCmd, statusCmd, doctorCmd, authStatusCmd, authAuditCmd,
+		attachmentsCmd, attachmentsListCmd, benchBuildCmd, configListCmd,
+		flagsCmd, flagsListCmd, jobsListCmd, sessionsStatsCmd, grepSessionsCmd, sysinfoCmd,
+		daemonStatusCmd, agentStatusCmd, scriptsCmd, psCmd, validateOpenAPICmd,
+	} {
+		jsonCommands[c] = true
+	}
+}
+
+// prepareJSONOutput checks that cmd supports --json and moves human output
+// to standard error.
+func prepareJSONOutput(cmd *cobra.Command) error {
+	if !jsonFlag {
+		return nil
+	}
+	if !jsonCommands[cmd] {
+		return fmt.Errorf("%s has no JSON output", cmd.CommandPath())
+	}
+	jsonOut = os.Stdout
+	os.Stdout = os.Stderr
+	return nil
+}
+
+// printJSON writes v to standard output as indented JSON.
+func printJSON(v any) error {
+	enc := json.NewEncoder(jsonOut)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
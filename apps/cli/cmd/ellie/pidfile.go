@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ellie/apps/cli/internal/paths"
+	"ellie/apps/cli/internal/proc"
+)
+
+// pidPath returns the pidfile for a managed process ("dev", "start").
+func pidPath(name string) (string, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return "", err
+	}
+	return pidPathIn(dir, name), nil
+}
+
+// pidPathIn returns the pidfile for name under the state directory dir,
+// such as a dev instance's.
+func pidPathIn(dir, name string) string {
+	return filepath.Join(dir, "run", name+".pid")
+}
+
+// writePidfile records pid for name. The returned func removes the
+// pidfile, but only if it still holds pid.
+func writePidfile(name string, pid int) (func(), error) {
+	path, err := pidPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create state directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(pid)+"\n"), 0o644); err != nil {
+		return nil, fmt.Errorf("cannot write pidfile: %w", err)
+	}
+	return func() {
+		if cur, _, err := readPidfile(name); err == nil && cur == pid {
+			_ = os.Remove(path)
+		}
+	}, nil
+}
+
+// readPidfile returns the recorded pid and when it was written.
+// A missing pidfile is reported as os.ErrNotExist.
+func readPidfile(name string) (int, time.Time, error) {
+	path, err := pidPath(name)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return readPidfileAt(path)
+}
+
+func readPidfileAt(path string) (int, time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, time.Time{}, fmt.Errorf("malformed pidfile %s", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return pid, info.ModTime(), nil
+}
+
+// processAlive reports whether a process with pid exists.
+func processAlive(pid int) bool {
+	return proc.Alive(pid)
+}
+
+// runningProcess returns the live pid and start time for name, cleaning
+// up a stale pidfile left behind by a crash.
+func runningProcess(name string) (pid int, since time.Time, ok bool) {
+	path, err := pidPath(name)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return runningPidfile(path)
+}
+
+// runningPidfile is runningProcess for the pidfile at path.
+func runningPidfile(path string) (pid int, since time.Time, ok bool) {
+	pid, since, err := readPidfileAt(path)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	if !processAlive(pid) {
+		_ = os.Remove(path)
+		return 0, time.Time{}, false
+	}
+	return pid, since, true
+}
+
+// stopProcess sends SIGTERM to pid and waits up to timeout for it to
+// exit, escalating to SIGKILL if it doesn't. Windows can't ask a
+// background process to exit, so there it and its children are killed.
+func stopProcess(pid int, timeout time.Duration) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if err := askToStop(p); err == nil {
+		deadline := time.Now().Add(timeout)
+		for time.Now().Before(deadline) {
+			if !processAlive(pid) {
+				return nil
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	} else if !processAlive(pid) {
+		return nil
+	}
+	if err := forceStop(p); err != nil && processAlive(pid) {
+		return fmt.Errorf("cannot kill pid %d: %w", pid, err)
+	}
+	return nil
+}
+
+// invocation is the command line a managed process was started with,
+// saved so ellie restart can replay it.
+type invocation struct {
+	Args    []string  `json:"args"` // os.Args[1:], e.g. ["start", "--detach"]
+	Dir     string    `json:"dir"`  // working directory, for monorepo root detection
+	SavedAt time.Time `json:"savedAt"`
+}
+
+func invocationPath(name string) (string, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return "", err
+	}
+	return invocationPathIn(dir, name), nil
+}
+
+// invocationPathIn returns invocationPath under the state directory dir.
+func invocationPathIn(dir, name string) string {
+	return filepath.Join(dir, "run", name+".args.json")
+}
+
+// saveInvocation records the current command line for name.
+func saveInvocation(name string) error {
+	path, err := invocationPath(name)
+	if err != nil {
+		return err
+	}
+	wd, _ := os.Getwd()
+	data, err := json.MarshalIndent(invocation{Args: os.Args[1:], Dir: wd, SavedAt: time.Now().UTC()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("cannot create state directory: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// loadInvocation returns the last saved command line for name.
+func loadInvocation(name string) (invocation, error) {
+	path, err := invocationPath(name)
+	if err != nil {
+		return invocation{}, err
+	}
+	return loadInvocationAt(path)
+}
+
+func loadInvocationAt(path string) (invocation, error) {
+	var inv invocation
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return inv, err
+	}
+	if err := json.Unmarshal(data, &inv); err != nil || len(inv.Args) == 0 {
+		return inv, fmt.Errorf("malformed %s", path)
+	}
+	return inv, nil
+}
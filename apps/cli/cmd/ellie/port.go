@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/paths"
+)
+
+// ── server port ─────────────────────────────────────────────────────────────
+
+// autoPortRange is how many ports above the configured one --port auto
+// tries before asking the OS for any free port.
+const autoPortRange = 100
+
+var serverPortFlag string
+
+func init() {
+	for _, c := range []*cobra.Command{devCmd, startCmd} {
+		c.Flags().StringVar(&serverPortFlag, "port", "",
+			"Port for the server to bind, or auto for the first free one from the configured port up")
+	}
+}
+
+// serverEnv is added to the environment of the server runChild starts.
+var serverEnv []string
+
+// applyServerPort resolves --port. The server binds the port of its
+// API_BASE_URL, so that is passed to it with the chosen port, as is PORT;
+// ellie then talks to the server there for the rest of the command. It
+// returns 0 when --port wasn't given.
+func applyServerPort() (int, error) {
+	if serverPortFlag == "" {
+		return 0, nil
+	}
+	var port int
+	if serverPortFlag == "auto" {
+		want, err := urlPort(baseURL())
+		if err != nil {
+			return 0, err
+		}
+		if port, err = freePort(want); err != nil {
+			return 0, err
+		}
+		if port != want {
+			fmt.Println(styleDim.Render(fmt.Sprintf("Port %d is taken — using port %d", want, port)))
+		} else {
+			fmt.Println(styleDim.Render(fmt.Sprintf("Using port %d", port)))
+		}
+	} else {
+		p, err := strconv.Atoi(serverPortFlag)
+		if err != nil || p < 1 || p > 65535 {
+			return 0, fmt.Errorf("invalid --port %q: use a port number or auto", serverPortFlag)
+		}
+		port = p
+	}
+
+	apiBase := "http://localhost"
+	if v := os.Getenv("API_BASE_URL"); v != "" {
+		apiBase = v
+	}
+	serverEnv = []string{"PORT=" + strconv.Itoa(port), "API_BASE_URL=" + withPort(apiBase, port)}
+	baseURLFlag = withPort(baseURL(), port)
+	return port, nil
+}
+
+// urlPort returns the port of base, explicit or implied by its scheme.
+func urlPort(base string) (int, error) {
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return 0, fmt.Errorf("cannot tell the server's port from %q", base)
+	}
+	return strconv.Atoi(portOf(base))
+}
+
+// withPort returns base with its port replaced.
+func withPort(base string, port int) string {
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return base
+	}
+	u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(port))
+	return strings.TrimRight(u.String(), "/")
+}
+
+// freePort returns the first port from want up that nothing listens on,
+// or any free port when those are all taken.
+func freePort(want int) (int, error) {
+	for p := want; p < want+autoPortRange && p <= 65535; p++ {
+		if ln, err := net.Listen("tcp", ":"+strconv.Itoa(p)); err == nil {
+			ln.Close()
+			return p, nil
+		}
+	}
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, fmt.Errorf("cannot find a free port: %w", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+func serverPortPath(name string) (string, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return "", err
+	}
+	return serverPortPathIn(dir, name), nil
+}
+
+// serverPortPathIn returns serverPortPath under the state directory dir.
+func serverPortPathIn(dir, name string) string {
+	return filepath.Join(dir, "run", name+".port")
+}
+
+// writeServerPort records the port the server managed as name binds, for
+// the commands run while it is up. The returned func removes the record.
+func writeServerPort(name string, port int) (func(), error) {
+	path, err := serverPortPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(port)+"\n"), 0o644); err != nil {
+		return nil, fmt.Errorf("cannot record the server port: %w", err)
+	}
+	return func() { _ = os.Remove(path) }, nil
+}
+
+// managedServerPort is the port recorded by a running ellie start or ellie
+// dev, production first.
+var managedServerPort = sync.OnceValues(func() (int, bool) {
+	for _, name := range []string{"start", "dev"} {
+		if port, ok := recordedServerPort(name); ok {
+			return port, true
+		}
+	}
+	return 0, false
+})
+
+// recordedServerPort returns the port recorded by the running ellie
+// managed as name.
+func recordedServerPort(name string) (int, bool) {
+	if _, _, ok := runningProcess(name); !ok {
+		return 0, false
+	}
+	path, err := serverPortPath(name)
+	if err != nil {
+		return 0, false
+	}
+	return readServerPort(path)
+}
+
+// readServerPort returns the port recorded in the file at path.
+func readServerPort(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return port, err == nil
+}
+
+// serverBaseOf returns base, moved to the port the server managed as name
+// announced once it has. For waiting on a server started in the
+// background, before this process's baseURL could know.
+func serverBaseOf(name, base string) string {
+	if port, ok := recordedServerPort(name); ok {
+		return withPort(base, port)
+	}
+	return base
+}
+
+// serverReadyLine matches the line the server logs once it listens.
+var serverReadyLine = regexp.MustCompile(`\[server\].*\bready on port (\d+)`)
+
+// serverPortWatch, when set, watches the output of the server runChild
+// starts for the port it binds.
+var serverPortWatch *portWatch
+
+// portWatch records the port a managed server says it listens on, for
+// the commands run while it is up. That can differ from the one ellie
+// expects, as when API_BASE_URL in the server's .env names another.
+type portWatch struct {
+	name   string // the managed process: dev or start
+	want   int
+	notify func(string)
+
+	mu   sync.Mutex
+	port int
+}
+
+// watchServerPort watches for the port of the server managed as name,
+// where want is the one expected.
+func watchServerPort(name string, want int) *portWatch {
+	return &portWatch{name: name, want: want, notify: func(msg string) {
+		fmt.Fprintln(os.Stderr, styleDim.Render(msg))
+	}}
+}
+
+// Writer returns a writer for the server's output.
+func (w *portWatch) Writer() io.Writer {
+	return &lineWriter{onLine: w.line}
+}
+
+func (w *portWatch) line(line string) {
+	m := serverReadyLine.FindStringSubmatch(ansi.Strip(line))
+	if m == nil {
+		return
+	}
+	port, err := strconv.Atoi(m[1])
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if port == w.port {
+		return
+	}
+	w.port = port
+	if _, err := writeServerPort(w.name, port); err != nil {
+		warn(err.Error())
+		return
+	}
+	if w.want != 0 && port != w.want {
+		w.notify(fmt.Sprintf("The server is listening on port %d, not %d — ellie commands use it while it runs.", port, w.want))
+	}
+}
+
+// Close forgets the recorded port.
+func (w *portWatch) Close() {
+	if path, err := serverPortPath(w.name); err == nil {
+		_ = os.Remove(path)
+	}
+}
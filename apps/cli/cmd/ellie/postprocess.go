@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"ellie/apps/cli/internal/extract"
+)
+
+// postProcessor pulls the part of a one-shot answer a script asked for
+// with --extract or --jq.
+type postProcessor struct {
+	mode  string // "code" or "json"
+	lang  string // code block language for code:<lang>
+	query *extract.Query
+}
+
+// newPostProcessor validates --extract and --jq up front, so a bad filter
+// fails before the prompt is sent. It returns nil when neither is set.
+func newPostProcessor(mode, jq string) (*postProcessor, error) {
+	if mode == "" && jq == "" {
+		return nil, nil
+	}
+	p := &postProcessor{}
+	kind, lang, _ := strings.Cut(mode, ":")
+	switch kind {
+	case "code":
+		p.mode, p.lang = "code", lang
+	case "json", "":
+		p.mode = "json"
+	default:
+		return nil, fmt.Errorf("invalid --extract %q: use code, code:<lang> or json", mode)
+	}
+	if jq != "" {
+		if p.mode == "code" {
+			return nil, fmt.Errorf("--jq works on JSON and cannot be combined with --extract code")
+		}
+		q, err := extract.Parse(jq)
+		if err != nil {
+			return nil, err
+		}
+		p.query = q
+	}
+	return p, nil
+}
+
+func (p *postProcessor) apply(content string) (string, error) {
+	if p.mode == "code" {
+		return extract.Code(content, p.lang)
+	}
+
+	doc, err := extract.JSON(content)
+	if err != nil {
+		return "", err
+	}
+	if p.query == nil {
+		return extract.Format(doc)
+	}
+	results, err := p.query.Run(doc)
+	if err != nil {
+		return "", fmt.Errorf("--jq %s: %w", p.query, err)
+	}
+	lines := make([]string, 0, len(results))
+	for _, v := range results {
+		s, err := extract.Format(v)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, s)
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,40 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// childAttr is how runChild starts a server: in ellie's process group,
+// where the terminal's Ctrl-C reaches it too.
+func childAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+// interruptChild passes sig on to cmd.
+func interruptChild(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Signal(sig)
+}
+
+// terminateTree asks cmd and pids, everything it started, to exit.
+func terminateTree(cmd *exec.Cmd, pids []int) {
+	signalPids(pids, syscall.SIGTERM)
+}
+
+// killTree kills pids, a child and everything it started.
+func killTree(pids []int) {
+	signalPids(pids, os.Kill)
+}
+
+// askToStop asks p to exit.
+func askToStop(p *os.Process) error {
+	return p.Signal(syscall.SIGTERM)
+}
+
+// forceStop kills p.
+func forceStop(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,65 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// childAttr starts a server in a process group of its own: the only
+// group a console Ctrl-Break can be sent to without ellie getting it too.
+func childAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP}
+}
+
+// interruptChild asks cmd's process group to exit. Windows has no
+// signals to pass on, so it gets a Ctrl-Break, which node and bun treat
+// as SIGBREAK and exit on.
+func interruptChild(cmd *exec.Cmd, sig os.Signal) error {
+	return windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(cmd.Process.Pid))
+}
+
+// terminateTree asks cmd and everything it started to exit, killing them
+// when they can't be asked.
+func terminateTree(cmd *exec.Cmd, pids []int) {
+	if interruptChild(cmd, os.Interrupt) != nil {
+		killTree(pids)
+	}
+}
+
+// killTree kills pids, a child and everything it started. taskkill /T
+// also finds processes whose parent already exited.
+func killTree(pids []int) {
+	if len(pids) == 0 {
+		return
+	}
+	if exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pids[0])).Run() == nil {
+		return
+	}
+	for _, pid := range pids {
+		if p, err := os.FindProcess(pid); err == nil {
+			p.Kill()
+		}
+	}
+}
+
+// askToStop can't ask p to exit: a process started without a console,
+// as background ones are, takes no console events, and taskkill without
+// /F only reaches processes with windows.
+func askToStop(p *os.Process) error {
+	return errors.New("not supported on Windows")
+}
+
+// forceStop kills p and everything it started.
+func forceStop(p *os.Process) error {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(p.Pid)).Run(); err != nil {
+		return p.Kill()
+	}
+	return nil
+}
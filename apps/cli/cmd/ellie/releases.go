@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// releaseMetaFile is written into dist/release and every snapshot so the
+// active build can be matched against dist/releases.
+const releaseMetaFile = "release.json"
+
+// releaseStateEntries live inside dist/release at runtime (start.sh
+// defaults DATA_DIR and CREDENTIALS_PATH there). They belong to the
+// installation, not the build, so snapshots skip them and swaps carry
+// them over.
+var releaseStateEntries = []string{"data", ".credentials.json"}
+
+// releaseMeta describes one archived production build.
+type releaseMeta struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Commit    string    `json:"commit,omitempty"`
+	Branch    string    `json:"branch,omitempty"`
+	Dirty     bool      `json:"dirty,omitempty"`
+}
+
+func releasesDir(root string) string {
+	return filepath.Join(root, "dist", "releases")
+}
+
+// snapshotRelease stamps dist/release with metadata, copies it into
+// dist/releases/<id>, and prunes all but the newest keep snapshots.
+func snapshotRelease(root string, keep int) (releaseMeta, error) {
+	meta := releaseMeta{CreatedAt: time.Now().UTC()}
+	if out, err := gitOutput(root, "rev-parse", "--short", "HEAD"); err == nil {
+		meta.Commit = strings.TrimSpace(out)
+	}
+	if out, err := gitOutput(root, "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+		meta.Branch = strings.TrimSpace(out)
+	}
+	if out, err := gitOutput(root, "status", "--porcelain", "--untracked-files=no"); err == nil {
+		meta.Dirty = strings.TrimSpace(out) != ""
+	}
+	meta.ID = meta.CreatedAt.Format("20060102-150405")
+	if meta.Commit != "" {
+		meta.ID += "-" + meta.Commit
+	}
+
+	active := filepath.Join(root, "dist", "release")
+	if err := writeReleaseMeta(active, meta); err != nil {
+		return meta, err
+	}
+
+	dest := filepath.Join(releasesDir(root), meta.ID)
+	if err := copyTree(active, dest, releaseStateEntries); err != nil {
+		_ = os.RemoveAll(dest)
+		return meta, fmt.Errorf("cannot snapshot release: %w", err)
+	}
+
+	if keep > 0 {
+		all, err := listReleases(root)
+		if err != nil {
+			return meta, err
+		}
+		for _, old := range all[min(keep, len(all)):] {
+			_ = os.RemoveAll(filepath.Join(releasesDir(root), old.ID))
+		}
+	}
+	return meta, nil
+}
+
+// listReleases returns archived builds, newest first.
+func listReleases(root string) ([]releaseMeta, error) {
+	entries, err := os.ReadDir(releasesDir(root))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []releaseMeta
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		meta, err := readReleaseMeta(filepath.Join(releasesDir(root), e.Name()))
+		if err != nil {
+			continue // not a snapshot we wrote
+		}
+		out = append(out, meta)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// activeRelease returns the metadata of the build in dist/release, if any.
+func activeRelease(root string) (releaseMeta, bool) {
+	meta, err := readReleaseMeta(filepath.Join(root, "dist", "release"))
+	return meta, err == nil
+}
+
+// swapRelease replaces dist/release with the snapshot id, carrying the
+// installation's data and credentials over from the current build. On
+// failure they are moved back into dist/release.
+func swapRelease(root, id string) error {
+	src := filepath.Join(releasesDir(root), id)
+	if _, err := readReleaseMeta(src); err != nil {
+		return fmt.Errorf("release %s not found in dist/releases", id)
+	}
+
+	active := filepath.Join(root, "dist", "release")
+	staging := active + ".incoming"
+	outgoing := active + ".outgoing"
+	if err := recoverSwap(active, staging, outgoing); err != nil {
+		return err
+	}
+	_ = os.RemoveAll(staging)
+	if err := copyTree(src, staging, nil); err != nil {
+		_ = os.RemoveAll(staging)
+		return fmt.Errorf("cannot stage release %s: %w", id, err)
+	}
+
+	var moved []string
+	// undo moves the state back and drops the staged build.
+	undo := func() {
+		for _, name := range moved {
+			_ = os.Rename(filepath.Join(staging, name), filepath.Join(active, name))
+		}
+		removeStaging(staging)
+	}
+	for _, name := range releaseStateEntries {
+		from := filepath.Join(active, name)
+		if _, err := os.Lstat(from); err != nil {
+			continue
+		}
+		if err := os.Rename(from, filepath.Join(staging, name)); err != nil {
+			undo()
+			return fmt.Errorf("cannot move %s into release %s: %w", name, id, err)
+		}
+		moved = append(moved, name)
+	}
+
+	_ = os.RemoveAll(outgoing)
+	if err := os.Rename(active, outgoing); err != nil && !os.IsNotExist(err) {
+		undo()
+		return fmt.Errorf("cannot replace dist/release: %w", err)
+	}
+	if err := os.Rename(staging, active); err != nil {
+		_ = os.Rename(outgoing, active)
+		undo()
+		return fmt.Errorf("cannot replace dist/release: %w", err)
+	}
+	return os.RemoveAll(outgoing)
+}
+
+// recoverSwap puts back what an interrupted swap left aside: dist/release
+// itself, or the state moved into the staging dir.
+func recoverSwap(active, staging, outgoing string) error {
+	if _, err := os.Lstat(active); os.IsNotExist(err) {
+		if err := os.Rename(outgoing, active); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot restore dist/release from %s: %w", outgoing, err)
+		}
+	}
+	for _, name := range releaseStateEntries {
+		from := filepath.Join(staging, name)
+		if _, err := os.Lstat(from); err != nil {
+			continue
+		}
+		to := filepath.Join(active, name)
+		if _, err := os.Lstat(to); err == nil {
+			return fmt.Errorf("both %s and %s exist — keep one, then try again", from, to)
+		}
+		if err := os.Rename(from, to); err != nil {
+			return fmt.Errorf("cannot move %s back into dist/release: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// removeStaging removes the staging dir unless it still holds state, which
+// the next swap's recoverSwap moves back.
+func removeStaging(staging string) {
+	for _, name := range releaseStateEntries {
+		if _, err := os.Lstat(filepath.Join(staging, name)); err == nil {
+			return
+		}
+	}
+	_ = os.RemoveAll(staging)
+}
+
+func readReleaseMeta(dir string) (releaseMeta, error) {
+	var meta releaseMeta
+	data, err := os.ReadFile(filepath.Join(dir, releaseMetaFile))
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, err
+	}
+	if meta.ID == "" {
+		return meta, fmt.Errorf("%s: missing id", filepath.Join(dir, releaseMetaFile))
+	}
+	return meta, nil
+}
+
+func writeReleaseMeta(dir string, meta releaseMeta) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, releaseMetaFile), append(data, '\n'), 0o644)
+}
+
+// copyTree copies src to dst preserving file modes and symlinks. Top-level
+// entries named in skip are left out.
+func copyTree(src, dst string, skip []string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		for _, s := range skip {
+			if rel == s {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"ellie/apps/cli/internal/daemon"
+	"ellie/apps/cli/internal/paths"
+	"ellie/apps/cli/internal/termtext"
+)
+
+// ── crash restart policy ────────────────────────────────────────────────────
+
+var startRestart string
+
+func init() {
+	startCmd.Flags().StringVar(&startRestart, "restart", "no",
+		"Relaunch the server when it exits with an error: no, or on-failure[:max] to give up after max restarts (or on a crash loop, see crash_loop.failures)")
+}
+
+// restartPolicy is ellie start's --restart.
+type restartPolicy struct {
+	OnFailure bool
+	Max       int // restarts before giving up; 0 for no limit
+}
+
+func parseRestartPolicy(s string) (restartPolicy, error) {
+	name, max, hasMax := strings.Cut(s, ":")
+	switch {
+	case name == "no" && !hasMax:
+		return restartPolicy{}, nil
+	case name != "on-failure":
+		return restartPolicy{}, fmt.Errorf("invalid --restart %q: use no or on-failure[:max]", s)
+	case !hasMax:
+		return restartPolicy{OnFailure: true}, nil
+	}
+	n, err := strconv.Atoi(max)
+	if err != nil || n < 1 {
+		return restartPolicy{}, fmt.Errorf("invalid --restart %q: max must be a positive number of restarts", s)
+	}
+	return restartPolicy{OnFailure: true, Max: n}, nil
+}
+
+// restartState is what a server's supervisor records about its crash
+// restarts, for ellie status.
+type restartState struct {
+	Restarts int  `json:"restarts"`
+	LastExit *int `json:"lastExit,omitempty"`
+}
+
+func restartStatePath(name string) (string, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "run", name+".restarts.json"), nil
+}
+
+func saveRestartState(name string, st restartState) error {
+	path, err := restartStatePath(name)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// loadRestartState returns the restarts recorded for name, which are
+// only meaningful while its pidfile is current.
+func loadRestartState(name string) (restartState, bool) {
+	var st restartState
+	path, err := restartStatePath(name)
+	if err != nil {
+		return st, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &st) != nil {
+		return st, false
+	}
+	return st, true
+}
+
+// crashTailLines is how many of a crashed server's last stderr lines a
+// crash loop report shows.
+const crashTailLines = 10
+
+// childStderrTail, when set, keeps the last lines runChild's child wrote
+// to stderr.
+var childStderrTail *outputTail
+
+// outputTail keeps the last lines written to it, without colors.
+type outputTail struct {
+	mu    sync.Mutex
+	max   int
+	lines []string
+}
+
+func newOutputTail(max int) *outputTail {
+	return &outputTail{max: max}
+}
+
+// Writer returns a writer adding each line written to the tail, and a
+// func adding an unterminated last line.
+func (t *outputTail) Writer() (io.Writer, func()) {
+	w := &lineWriter{onLine: t.add}
+	return w, w.flush
+}
+
+func (t *outputTail) add(line string) {
+	line = strings.TrimRight(termtext.Clean(line), " \t")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = append(t.lines, line)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+}
+
+// Lines returns the lines kept, oldest first.
+func (t *outputTail) Lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.lines)
+}
+
+// Reset forgets the lines kept.
+func (t *outputTail) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = nil
+}
+
+// crashLoop returns the crash loop detector configured by
+// crash_loop.failures and crash_loop.window.
+func crashLoop() *daemon.CrashLoop {
+	c := &daemon.CrashLoop{Failures: 5, Window: 2 * time.Minute}
+	if s, ok := settings().Get("crash_loop.failures"); ok {
+		if n, ok := s.Value.(int64); ok && n >= 0 {
+			c.Failures = int(n)
+		}
+	}
+	if v, ok := setting("crash_loop.window"); ok {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			c.Window = d
+		}
+	}
+	return c
+}
+
+// crashLoopReport explains that the server is no longer restarted,
+// shows the last lines it wrote to stderr and suggests ellie doctor.
+func crashLoopReport(loop *daemon.CrashLoop, code int, stderr []string) []string {
+	lines := []string{fmt.Sprintf("The server crashed %d times in %s (last exit code %d); not restarting it again.",
+		loop.Failures, loop.Span().Round(time.Second), code)}
+	if len(stderr) > 0 {
+		lines = append(lines, "Its last error output:")
+		for _, l := range stderr {
+			lines = append(lines, "  │ "+l)
+		}
+	} else {
+		lines = append(lines, "It wrote nothing to stderr.")
+	}
+	return append(lines, "Run ellie doctor to look for the cause, then start the server again.")
+}
+
+// runRestarting is runWatchedProcess under policy: a server that exits
+// with an error, other than because ellie was told to stop, is launched
+// again after a backoff of 1s, doubling up to a minute, until it crashes
+// often enough to be in a crash loop.
+func runRestarting(name string, args []string, dir string, log *logStore, wd watchdogSettings, policy restartPolicy) int {
+	if !policy.OnFailure {
+		return runWatchedProcess(name, args, dir, log, wd)
+	}
+	path, err := restartStatePath("start")
+	if err == nil {
+		_ = os.Remove(path)
+		defer os.Remove(path)
+	}
+
+	// runChild forwards these to the server; here they only mean
+	// that its exit is not a crash.
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	childStderrTail = newOutputTail(crashTailLines)
+	defer func() { childStderrTail = nil }()
+
+	backoff := daemon.Backoff{Min: time.Second, Max: time.Minute, Reset: time.Minute}
+	loop := crashLoop()
+	var st restartState
+	for {
+		started := time.Now()
+		childStderrTail.Reset()
+		code := runWatchedProcess(name, args, dir, log, wd)
+		select {
+		case <-stop:
+			return code
+		default:
+		}
+		if code == 0 {
+			return 0
+		}
+		st.LastExit = &code
+		if policy.Max > 0 && st.Restarts >= policy.Max {
+			restartNotice(log, fmt.Sprintf("The server exited with code %d; giving up after %d restart(s).", code, st.Restarts))
+			_ = saveRestartState("start", st)
+			return code
+		}
+		if loop.Crash(time.Now()) {
+			report := crashLoopReport(loop, code, childStderrTail.Lines())
+			restartNotice(log, report[0])
+			for _, line := range report[1:] {
+				fmt.Fprintln(os.Stderr, "  "+styleDim.Render(line))
+				if log != nil {
+					log.writeLine("restart: " + line)
+				}
+			}
+			_ = saveRestartState("start", st)
+			return code
+		}
+		st.Restarts++
+		if err := saveRestartState("start", st); err != nil {
+			warn("cannot record restarts for ellie status: " + err.Error())
+		}
+		delay := backoff.Next(time.Since(started))
+		restartNotice(log, fmt.Sprintf("The server exited with code %d; restarting in %s (restart %d)...", code, delay, st.Restarts))
+		select {
+		case <-stop:
+			return code
+		case <-time.After(delay):
+		}
+	}
+}
+
+// restartNotice prints a message about a crash restart to the terminal
+// and the process log.
+func restartNotice(log *logStore, msg string) {
+	fmt.Fprintln(os.Stderr, styleErr.Render("!")+" "+styleBold.Render("restart:")+" "+msg)
+	if log != nil {
+		log.writeLine("restart: " + msg)
+	}
+}
+
+// restartSummary describes a server's crash restarts for ellie status,
+// or returns "" when there were none.
+func restartSummary(restarts int, lastExit *int) string {
+	var parts []string
+	switch {
+	case restarts == 1:
+		parts = append(parts, "1 restart")
+	case restarts > 1:
+		parts = append(parts, fmt.Sprintf("%d restarts", restarts))
+	}
+	if lastExit != nil {
+		parts = append(parts, fmt.Sprintf("last exit %d", *lastExit))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/shirou/gopsutil/v3/process"
+	"golang.org/x/term"
+
+	"ellie/apps/cli/internal/secretinput"
+)
+
+// ── secret prompts ──────────────────────────────────────────────────────────
+
+// Ways of reading a secret, selectable with ELLIE_SECRET_INPUT.
+const (
+	secretInputTUI   = "tui"   // huh password field
+	secretInputRaw   = "raw"   // masked raw-mode line reader
+	secretInputStdin = "stdin" // first line of stdin, without echo on a terminal
+)
+
+// authStdinSecret makes every auth prompt for a key or token read it from
+// standard input instead.
+var authStdinSecret bool
+
+func init() {
+	authCmd.PersistentFlags().BoolVar(&authStdinSecret, "stdin-secret", false, "Read keys and tokens from standard input instead of prompting for them")
+}
+
+// promptSecret asks for a secret such as "Groq API key" and returns it
+// trimmed. huh's password field drops or repeats keystrokes over mosh and
+// in some Windows terminals, so those get the raw-mode reader instead, as
+// does any terminal where the field fails to start.
+func promptSecret(what, description string) (string, error) {
+	mode := secretInputMode()
+	if mode != secretInputStdin {
+		if err := noPrompt("entering your "+what, "pipe it to standard input with --stdin-secret or ELLIE_SECRET_INPUT=stdin"); err != nil {
+			return "", err
+		}
+	}
+	if mode == secretInputTUI {
+		var secret string
+		err := huh.NewInput().
+			Title("Enter your " + what).
+			Description(description).
+			EchoMode(huh.EchoModePassword).
+			Value(&secret).
+			Run()
+		if err == nil || errors.Is(err, huh.ErrUserAborted) {
+			return strings.TrimSpace(secret), err
+		}
+		fmt.Fprintln(os.Stderr, styleDim.Render("Falling back to a plain prompt ("+err.Error()+")"))
+		mode = secretInputRaw
+	}
+
+	fd := int(os.Stdin.Fd())
+	if mode == secretInputStdin || !term.IsTerminal(fd) {
+		return readSecret(!term.IsTerminal(fd), what)
+	}
+	if description != "" {
+		fmt.Fprintln(os.Stderr, styleDim.Render(description))
+	}
+	secret, err := secretinput.ReadTerminal(os.Stdin, os.Stderr, "Enter your "+what+": ")
+	return strings.TrimSpace(secret), err
+}
+
+// secretInputMode picks how promptSecret reads: --stdin-secret, then
+// ELLIE_SECRET_INPUT, then the raw reader on terminals known to break
+// huh's password field.
+func secretInputMode() string {
+	if authStdinSecret {
+		return secretInputStdin
+	}
+	switch m := os.Getenv("ELLIE_SECRET_INPUT"); m {
+	case secretInputTUI, secretInputRaw, secretInputStdin:
+		return m
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return secretInputStdin
+	}
+	if fragileTerminal() {
+		return secretInputRaw
+	}
+	return secretInputTUI
+}
+
+// fragileTerminal reports whether the CLI runs in mintty (Git Bash, MSYS2)
+// on Windows or under mosh.
+func fragileTerminal() bool {
+	if runtime.GOOS == "windows" && (os.Getenv("MSYSTEM") != "" || os.Getenv("TERM_PROGRAM") == "mintty") {
+		return true
+	}
+	// mosh sets no environment variable; look for mosh-server among the
+	// shell's ancestors.
+	pid := int32(os.Getppid())
+	for i := 0; i < 8 && pid > 1; i++ {
+		p, err := process.NewProcess(pid)
+		if err != nil {
+			return false
+		}
+		if name, _ := p.Name(); strings.HasPrefix(name, "mosh-server") {
+			return true
+		}
+		if pid, err = p.Ppid(); err != nil {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ellie/apps/cli/internal/chatui"
+	"ellie/apps/cli/internal/paths"
+	"ellie/apps/cli/internal/sso"
+)
+
+// sessionTransport signs requests to an ellie server with the SSO session
+// saved by ellie login --sso for that server, or else with the token of
+// the selected environment. Requests that already carry an Authorization
+// header, and servers with neither, pass through untouched. It sits under every client that talks to the server
+// (httpClient and chatui.Transport).
+type sessionTransport struct {
+	base http.RoundTripper
+}
+
+var serverTransport http.RoundTripper = autoRefreshTransport{
+	base: sessionTransport{base: versionTransport{base: compressTransport}},
+}
+
+func init() {
+	chatui.Transport = serverTransport
+}
+
+var (
+	sessionMu       sync.Mutex
+	sessionsCache   *sso.Sessions
+	sessionWarnOnce sync.Once
+)
+
+func (t sessionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+	origin := serverOrigin(req.URL.String())
+	s := activeSession(req.Context(), origin)
+	if s == nil {
+		if tok := envToken(origin); tok != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", "Bearer "+tok)
+		}
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+s.Bearer())
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		warnSession("The server rejected your SSO session — run ellie login --sso to sign in again.")
+	}
+	return resp, err
+}
+
+// activeSession returns the usable session for origin, refreshing it when
+// it has expired. It returns nil when there is no session or it cannot
+// be refreshed.
+func activeSession(ctx context.Context, origin string) *sso.Session {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+
+	sessions, err := loadSessions()
+	if err != nil {
+		return nil
+	}
+	s := sessions.Servers[origin]
+	if s == nil || !s.Expired(time.Now()) {
+		return s
+	}
+	if s.RefreshToken == "" || s.TokenEndpoint == "" {
+		warnSession("Your SSO session for " + origin + " has expired — run ellie login --sso.")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	tok, err := sso.Refresh(ctx, &http.Client{}, s.TokenEndpoint, s.ClientID, s.RefreshToken)
+	if err != nil {
+		warnSession("Your SSO session for " + origin + " has expired and could not be renewed (" + err.Error() + ") — run ellie login --sso.")
+		return nil
+	}
+	s.Update(tok)
+	if err := sessions.Save(); err != nil {
+		warn("cannot save renewed SSO session: " + err.Error())
+	}
+	return s
+}
+
+// loadSessions reads the session file once per process. Callers hold
+// sessionMu.
+func loadSessions() (*sso.Sessions, error) {
+	if sessionsCache != nil {
+		return sessionsCache, nil
+	}
+	dir, err := paths.State()
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := sso.LoadSessions(filepath.Join(dir, "sessions.json"))
+	if err != nil {
+		return nil, err
+	}
+	sessionsCache = sessions
+	return sessions, nil
+}
+
+// warnSession prints a session problem once per process, so a command that
+// makes many requests doesn't repeat it.
+func warnSession(msg string) {
+	sessionWarnOnce.Do(func() {
+		fmt.Fprintln(os.Stderr, styleErr.Render("!")+" "+msg)
+	})
+}
+
+// serverOrigin reduces a URL to the scheme and host sessions are keyed by.
+func serverOrigin(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(raw, "/")
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
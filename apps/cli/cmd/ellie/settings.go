@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"ellie/apps/cli/internal/chatui"
+	"ellie/apps/cli/internal/config"
+	"ellie/apps/cli/internal/paths"
+	"ellie/apps/cli/internal/themes"
+)
+
+// ── layered settings ────────────────────────────────────────────────────────
+
+// teamConfigMaxAge is how long a synced team config is trusted before
+// commands start suggesting `ellie config sync`.
+const teamConfigMaxAge = 7 * 24 * time.Hour
+
+type loadedSettings struct {
+	*config.Config
+	team *config.TeamState
+}
+
+// settings returns the layered settings — team, user and project config
+// files — loaded on first use. A file that fails to parse is reported
+// and ignored rather than failing every command.
+var settings = sync.OnceValue(func() loadedSettings {
+	teamDir, err := teamConfigDir()
+	if err != nil {
+		return loadedSettings{Config: &config.Config{}}
+	}
+	cwd, _ := os.Getwd()
+	cfg, team, errs := config.Load(teamDir, cwd)
+	for _, err := range errs {
+		warn("ignoring config: " + err.Error())
+	}
+	if deps := cfg.Deprecations(); len(deps) > 0 {
+		for _, msg := range deps {
+			warn(msg)
+		}
+		if !ciMode() {
+			fmt.Fprintln(os.Stderr, styleDim.Render("  ellie config doctor shows what replaces them and can update your config."))
+		}
+	}
+	return loadedSettings{Config: cfg, team: team}
+})
+
+// setting returns a setting formatted as a string, and whether it is set
+// or has a default.
+func setting(key string) (string, bool) {
+	return settings().String(key)
+}
+
+// teamConfigDir is where `ellie config sync` keeps the team config.
+func teamConfigDir() (string, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "team"), nil
+}
+
+func init() {
+	cobra.OnInitialize(warnStaleTeamConfig, applyConfiguredTheme)
+}
+
+// applyConfiguredTheme switches the chat interface to ui.theme. A theme
+// that can't be loaded is reported, and the dark theme used instead.
+func applyConfiguredTheme() {
+	switch theme, _ := setting("ui.theme"); theme {
+	case themes.Dark:
+	case themes.Light:
+		chatui.ApplyTheme(chatui.ThemeLight)
+	default:
+		t, err := loadTheme(theme)
+		if err != nil {
+			warn("ui.theme: " + err.Error())
+			return
+		}
+		chatui.ApplyCustomTheme(t)
+	}
+}
+
+// warnStaleTeamConfig reminds people at a terminal to re-sync a team
+// config that has not been refreshed in a while.
+func warnStaleTeamConfig() {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return
+	}
+	st := settings().team
+	if st == nil || time.Since(st.SyncedAt) < teamConfigMaxAge {
+		return
+	}
+	fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf(
+		"Team config last synced %s — run 'ellie config sync' to pick up changes.", formatDuration(time.Since(st.SyncedAt)))))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
+
+	"ellie/apps/cli/internal/watchdog"
+)
+
+// ── graceful shutdown ───────────────────────────────────────────────────────
+
+// defaultShutdownGrace applies when shutdown.grace is unset.
+const defaultShutdownGrace = 10 * time.Second
+
+// shutdownGrace is how long a child ellie signals to stop gets to exit
+// before it and everything it started are killed: shutdown.grace.
+func shutdownGrace() time.Duration {
+	if v, ok := setting("shutdown.grace"); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return defaultShutdownGrace
+}
+
+// forwardSignals passes SIGINT and SIGTERM on to cmd, named name — on
+// Windows as a Ctrl-Break to its process group. If it
+// is still running after the shutdown grace period, or a second signal
+// comes, it and everything it started are killed. Call the returned func
+// once cmd has exited.
+func forwardSignals(cmd *exec.Cmd, name string) (stop func()) {
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		var sig os.Signal
+		select {
+		case sig = <-sigCh:
+		case <-done:
+			return
+		}
+		_ = interruptChild(cmd, sig)
+
+		grace := shutdownGrace()
+		if term.IsTerminal(int(os.Stderr.Fd())) {
+			fmt.Fprintln(os.Stderr, styleDim.Render(fmt.Sprintf("Waiting up to %s for %s to exit — press Ctrl-C again to kill it now", grace, name)))
+		}
+		var why string
+		select {
+		case <-done:
+			return
+		case <-sigCh:
+			why = "on a second signal"
+		case <-time.After(grace):
+			why = fmt.Sprintf("still running %s after %s", grace, signalName(sig))
+		}
+		// Report first: once they are killed, ellie exits.
+		pids := watchdog.Tree(cmd.Process.Pid)
+		what := name
+		if n := len(pids) - 1; n > 0 {
+			what = fmt.Sprintf("%s and the %d processes it started", name, n)
+		}
+		fmt.Fprintf(os.Stderr, "%s Killing %s, %s\n", styleErr.Render("!"), what, why)
+		killTree(pids)
+	}()
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
+
+// signalPids sends sig to each of pids, killing those it can't signal.
+func signalPids(pids []int, sig os.Signal) {
+	for _, pid := range pids {
+		if p, err := os.FindProcess(pid); err == nil {
+			if p.Signal(sig) != nil {
+				p.Kill()
+			}
+		}
+	}
+}
+
+func signalName(sig os.Signal) string {
+	if sig == syscall.SIGTERM {
+		return "SIGTERM"
+	}
+	return "SIGINT"
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/taskoutput"
+)
+
+// ── task output ─────────────────────────────────────────────────────────────
+
+var taskOutputFlag string
+
+func init() {
+	for _, c := range []*cobra.Command{devCmd, buildCmd, scriptsCmd, testCmd} {
+		c.Flags().StringVar(&taskOutputFlag, "output", string(taskoutput.Full),
+			"How much of the tasks' output to show: full, summary (a line per task) or errors-only")
+	}
+}
+
+// childOutput, when set, groups the output of the children runChild and
+// the dev runner start by task, and holds back what --output hides.
+var childOutput *taskoutput.Mux
+
+// applyTaskOutput resolves --output. Output lines without turbo's task
+// prefix belong to the task named task.
+func applyTaskOutput(task string) error {
+	mode, err := taskoutput.ParseMode(taskOutputFlag)
+	if err != nil {
+		return err
+	}
+	if mode != taskoutput.Full {
+		childOutput = taskoutput.New(mode, task)
+	}
+	return nil
+}
+
+// closeTaskOutput ends the grouping once the children have exited with
+// exitCode, showing what was held back of a failed run.
+func closeTaskOutput(exitCode int) {
+	if childOutput != nil {
+		childOutput.Close(exitCode)
+		childOutput = nil
+	}
+}
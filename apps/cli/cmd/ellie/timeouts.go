@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/chatui"
+)
+
+// ── request timeouts ────────────────────────────────────────────────────────
+
+// defaultRequestTimeout bounds each request to the server unless the
+// command has its own.
+const defaultRequestTimeout = 10 * time.Second
+
+// commandTimeouts are built-in timeouts for commands whose requests
+// legitimately take longer, keyed by command path without "ellie". A
+// command inherits its parent's entry.
+var commandTimeouts = map[string]time.Duration{
+	// The server checks new credentials and refreshes tokens with the
+	// provider before answering.
+	"auth": 30 * time.Second,
+	// Reindexing and rotating secrets run to completion before the
+	// server replies.
+	"server exec-admin": 5 * time.Minute,
+}
+
+var requestTimeout time.Duration
+
+func init() {
+	// Run every PersistentPreRun from the root down, so commands like auth
+	// can add their own without replacing this one.
+	cobra.EnableTraverseRunHooks = true
+	rootCmd.PersistentFlags().DurationVar(&requestTimeout, "timeout", 0,
+		"Timeout for each request to the server, e.g. 30s or 5m; 0 disables it (default: ELLIE_TIMEOUT_<COMMAND>, ELLIE_TIMEOUT, the timeouts config, or the command's own, usually 10s)")
+}
+
+// applyRequestTimeout sets the timeouts of the clients that talk to the
+// server for the command about to run.
+func applyRequestTimeout(cmd *cobra.Command, args []string) error {
+	d, err := commandTimeout(cmd)
+	if err != nil {
+		return err
+	}
+	serverRequestTimeout = d
+	chatui.RequestTimeout = d
+
+	connect := defaultConnectTimeout
+	if v, ok := setting("timeouts.connect"); ok {
+		if connect, err = time.ParseDuration(v); err != nil || connect < 0 {
+			return fmt.Errorf("timeouts.connect = %q is not a valid timeout (use e.g. 30s or 5m)", v)
+		}
+	}
+	serverDialer.Timeout = connect
+	connectTransport.TLSHandshakeTimeout = connect
+	return nil
+}
+
+// defaultConnectTimeout bounds connecting to the server, TLS handshake
+// included, unless timeouts.connect says otherwise.
+const defaultConnectTimeout = 10 * time.Second
+
+// serverRequestTimeout bounds each request httpClient sends, from
+// connecting until its body is read, unless it is streamed.
+var serverRequestTimeout = defaultRequestTimeout
+
+var serverDialer = &net.Dialer{Timeout: defaultConnectTimeout, KeepAlive: 30 * time.Second}
+
+// connectTransport is the bottom of serverTransport. It only bounds
+// connecting and the TLS handshake, so a streamed response, like a chat
+// reply, can take as long as it takes.
+var connectTransport = func() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = serverDialer.DialContext
+	t.TLSHandshakeTimeout = defaultConnectTimeout
+	return t
+}()
+
+// deadlineTransport gives each request httpClient sends its own deadline,
+// serverRequestTimeout, in place of a client-wide timeout that would cut
+// streams short. A request that accepts an event stream has none: only
+// the connect timeout and its context bound it.
+type deadlineTransport struct {
+	base http.RoundTripper
+}
+
+func (t deadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if serverRequestTimeout <= 0 || strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), serverRequestTimeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The deadline covers reading the body too; closing it ends both.
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// commandTimeout resolves the request timeout for cmd: --timeout, then
+// ELLIE_TIMEOUT_<COMMAND> for the command or its parents (e.g.
+// ELLIE_TIMEOUT_AUTH_STATUS, then ELLIE_TIMEOUT_AUTH), then ELLIE_TIMEOUT,
+// then the same from the config files (timeouts."auth status",
+// timeouts.auth, timeouts.default), then the built-in commandTimeouts,
+// then defaultRequestTimeout.
+func commandTimeout(cmd *cobra.Command) (time.Duration, error) {
+	// net test bounds each check with its own --timeout, which bounds its
+	// requests too. How long start, stop and the like wait is
+	// --wait-timeout, which this doesn't read.
+	if f := cmd.Flags().Lookup("timeout"); f != nil && f.Changed {
+		return cmd.Flags().GetDuration("timeout")
+	}
+
+	path := strings.Fields(strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()))
+	for n := len(path); n > 0; n-- {
+		key := "ELLIE_TIMEOUT_" + strings.ToUpper(strings.ReplaceAll(strings.Join(path[:n], "_"), "-", "_"))
+		if d, ok, err := envDuration(key); ok || err != nil {
+			return d, err
+		}
+	}
+	if d, ok, err := envDuration("ELLIE_TIMEOUT"); ok || err != nil {
+		return d, err
+	}
+	for n := len(path); n >= 0; n-- {
+		key := "timeouts." + strings.Join(path[:n], " ")
+		if n == 0 {
+			key = "timeouts.default"
+		}
+		if v, ok := setting(key); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return 0, fmt.Errorf("%s = %q is not a valid timeout (use e.g. 30s or 5m)", key, v)
+			}
+			return d, nil
+		}
+	}
+	for n := len(path); n > 0; n-- {
+		if d, ok := commandTimeouts[strings.Join(path[:n], " ")]; ok {
+			return d, nil
+		}
+	}
+	return defaultRequestTimeout, nil
+}
+
+// envDuration reads a duration from the environment, reporting whether it
+// was set.
+func envDuration(key string) (time.Duration, bool, error) {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return 0, false, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, true, fmt.Errorf("%s=%s is not a valid timeout (use e.g. 30s or 5m)", key, v)
+	}
+	return d, true, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// ── custom CA and client certificates ───────────────────────────────────────
+
+// applyTLS loads tls.ca_file, tls.client_cert and tls.client_key. The CA
+// bundle is trusted on top of the system's by every client, since a
+// proxy that intercepts TLS intercepts the providers too; the client
+// certificate is only presented to the server, by connectTransport, which
+// every request to the server goes through.
+func applyTLS() error {
+	caFile := settingPath("tls.ca_file")
+	certFile := settingPath("tls.client_cert")
+	keyFile := settingPath("tls.client_key")
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil
+	}
+
+	var roots *x509.CertPool
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("tls.ca_file: %w", err)
+		}
+		if roots, err = x509.SystemCertPool(); err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls.ca_file: no PEM certificates in %s", caFile)
+		}
+		if t, ok := http.DefaultTransport.(*http.Transport); ok {
+			t.TLSClientConfig = &tls.Config{RootCAs: roots}
+		}
+	}
+
+	cfg := &tls.Config{RootCAs: roots}
+	switch {
+	case certFile == "" && keyFile == "":
+	case certFile == "" || keyFile == "":
+		return fmt.Errorf("tls.client_cert and tls.client_key must be set together")
+	default:
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	connectTransport.TLSClientConfig = cfg
+	return nil
+}
+
+// settingPath returns the path the setting key names, with a leading ~
+// expanded, or "" when it is unset.
+func settingPath(key string) string {
+	v, ok := setting(key)
+	if !ok || v == "" {
+		return ""
+	}
+	return expandHome(v)
+}
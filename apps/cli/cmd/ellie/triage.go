@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"golang.org/x/term"
+
+	"ellie/apps/cli/internal/triage"
+)
+
+// ── crash triage ────────────────────────────────────────────────────────────
+
+const (
+	// triageWindow is how soon after launch a server exiting with an
+	// error counts as having failed to start.
+	triageWindow = 30 * time.Second
+	// triageLines is how much of the failed server's output is read.
+	triageLines = 40
+)
+
+// triageable reports whether err from ellie start is a server failing
+// to start, which ellie can help recover from. A server restarted on
+// failure has already had its chances, and a lazy one started late.
+func triageable(err error, started time.Time, policy restartPolicy) bool {
+	var ec exitCodeError
+	return errors.As(err, &ec) && time.Since(started) < triageWindow &&
+		!policy.OnFailure && !startLazy && os.Getenv(supervisedEnv) == ""
+}
+
+// startOutput returns up to n of the last lines the start log recorded
+// since since.
+func startOutput(since time.Time, n int) []string {
+	path, err := logPath("start")
+	if err != nil {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var lines []string
+	_ = readLogRecords(f, func(rec logRecord) {
+		if rec.Time.Before(since) {
+			return
+		}
+		lines = append(lines, rec.Text)
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	})
+	return lines
+}
+
+// triageFix is an entry in the triage menu, chosen with its key.
+type triageFix struct {
+	key   byte
+	label string
+	// apply prepares the next attempt; false means don't retry. The
+	// entry without one shows the server's output.
+	apply func() bool
+}
+
+// triageStart explains why the server started at started failed, and in
+// a terminal offers fixes for what it recognizes. It reports whether to
+// start the server again. A detached server's output isn't on screen, so
+// with detached its last lines are shown.
+func triageStart(root string, started time.Time, detached bool) bool {
+	lines := startOutput(started, triageLines)
+	findings := triage.Classify(lines)
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, styleErr.Render("✗"), styleBold.Render("The server failed to start."))
+	if detached && len(lines) > 0 {
+		fmt.Fprintln(os.Stderr, styleDim.Render("  Last output:"))
+		for _, line := range lines[max(0, len(lines)-10):] {
+			fmt.Fprintln(os.Stderr, styleDim.Render("    "+line))
+		}
+	}
+	for _, f := range findings {
+		fmt.Fprintln(os.Stderr, "  "+styleErr.Render("•"), f.Summary())
+		fmt.Fprintln(os.Stderr, styleDim.Render("    "+strings.TrimSpace(f.Line)))
+	}
+	if len(findings) == 0 {
+		fmt.Fprintln(os.Stderr, styleDim.Render("  No known cause recognized in its output."))
+	}
+
+	if ciMode() || quiet || !term.IsTerminal(int(os.Stdin.Fd())) {
+		for _, f := range findings {
+			fmt.Fprintln(os.Stderr, styleDim.Render("  "+triageHint(f)))
+		}
+		if !detached { // startDetached said so
+			fmt.Fprintln(os.Stderr, styleDim.Render("  See ellie logs --source start"))
+		}
+		return false
+	}
+
+	fixes := triageFixes(root, findings)
+	for {
+		fmt.Fprintln(os.Stderr)
+		for _, fix := range fixes {
+			fmt.Fprintf(os.Stderr, "  %s %s\n", styleBold.Render("["+string(fix.key)+"]"), fix.label)
+		}
+		fmt.Fprint(os.Stderr, styleDim.Render("  Press a key: "))
+		key, err := readKey()
+		fmt.Fprintln(os.Stderr)
+		if err != nil || key == 3 || key == 4 { // Ctrl-C, Ctrl-D
+			return false
+		}
+		for _, fix := range fixes {
+			switch {
+			case fix.key != key|0x20: // either case
+			case fix.apply == nil:
+				for _, line := range startOutput(started, 200) {
+					fmt.Fprintln(os.Stderr, "  "+line)
+				}
+			default:
+				return fix.apply()
+			}
+		}
+	}
+}
+
+// triageFixes returns the menu for findings: their fixes, then retrying,
+// showing the output and quitting.
+func triageFixes(root string, findings []triage.Finding) []triageFix {
+	var fixes []triageFix
+	for _, f := range findings {
+		switch f.Kind {
+		case triage.PortInUse:
+			if f.Port != 0 {
+				port := strconv.Itoa(f.Port)
+				fixes = append(fixes, triageFix{'k', "Stop what is listening on port " + port + " and retry", func() bool {
+					killPort(port)
+					time.Sleep(500 * time.Millisecond)
+					return true
+				}})
+			}
+			fixes = append(fixes, triageFix{'p', "Retry on a free port (--port auto)", func() bool {
+				serverPortFlag = "auto"
+				return true
+			}})
+		case triage.MissingEnv:
+			fixes = append(fixes, triageFix{'e', "Set " + f.Var + " for this start and retry", func() bool {
+				return promptEnv(f.Var)
+			}})
+		case triage.Migration:
+			fixes = append(fixes, triageFix{'b', "Rebuild the release (ellie build) and retry", func() bool {
+				self, err := os.Executable()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, styleErr.Render("Error:"), err)
+					return false
+				}
+				return runProcess(self, []string{"build"}, root) == 0
+			}})
+		}
+	}
+	return append(fixes,
+		triageFix{'r', "Retry", func() bool { return true }},
+		triageFix{'l', "Show the full output", nil},
+		triageFix{'q', "Quit", func() bool { return false }},
+	)
+}
+
+// triageHint says how to fix f by hand.
+func triageHint(f triage.Finding) string {
+	switch f.Kind {
+	case triage.PortInUse:
+		return "Stop what holds the port, or start on a free one with ellie start --port auto"
+	case triage.MissingEnv:
+		return "Set " + f.Var + " in the environment ellie start runs in"
+	case triage.Migration:
+		return "Rebuild with ellie build, or go back to the last good build with ellie server rollback"
+	}
+	return ""
+}
+
+var secretEnvName = regexp.MustCompile(`KEY|TOKEN|SECRET|PASSWORD`)
+
+// promptEnv asks for name's value and sets it for the servers this
+// ellie starts. It reports whether a value was given.
+func promptEnv(name string) bool {
+	var value string
+	input := huh.NewInput().
+		Title("Value for " + name).
+		Description("Set for this start only — export it in your shell to keep it").
+		Value(&value)
+	if secretEnvName.MatchString(name) {
+		input = input.EchoMode(huh.EchoModePassword)
+	}
+	if err := input.Run(); err != nil || strings.TrimSpace(value) == "" {
+		return false
+	}
+	os.Setenv(name, strings.TrimSpace(value))
+	return true
+}
+
+// readKey reads one keystroke from the terminal.
+func readKey() (byte, error) {
+	fd := int(os.Stdin.Fd())
+	old, err := term.MakeRaw(fd)
+	if err != nil {
+		return 0, err
+	}
+	defer term.Restore(fd, old)
+	var b [1]byte
+	if _, err := os.Stdin.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ── unix socket ─────────────────────────────────────────────────────────────
+
+// socketBaseURL is what requests to a server on a Unix socket are built
+// on. The host is only a stand-in: connectTransport dials the socket
+// whatever the address, and localhost keeps such requests off proxies.
+const socketBaseURL = "http://localhost"
+
+// serverSocket is the path of the server's Unix socket, when the server
+// URL is unix:///path/ellie.sock.
+var serverSocket string
+
+// socketPath returns the socket of a unix:// URL.
+func socketPath(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "unix" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+	return u.Path, true
+}
+
+// applySocket sends the requests to the server over its Unix socket when
+// the server URL is a unix:// URL.
+func applySocket() error {
+	raw := serverURL()
+	if !strings.HasPrefix(raw, "unix:") {
+		return nil
+	}
+	path, ok := socketPath(raw)
+	if !ok {
+		return fmt.Errorf("server URL %q is not a socket URL (use e.g. unix:///run/ellie/ellie.sock)", raw)
+	}
+	serverSocket = path
+	connectTransport.Proxy = nil
+	connectTransport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return serverDialer.DialContext(ctx, "unix", path)
+	}
+	return nil
+}
+
+// serverAddress is the server as ellie shows it: its socket URL when it
+// is on a Unix socket, else base.
+func serverAddress(base string) string {
+	if serverSocket != "" && base == socketBaseURL {
+		return "unix://" + serverSocket
+	}
+	return base
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"ellie/apps/cli/internal/buildinfo"
+	"ellie/apps/cli/internal/paths"
+	"ellie/apps/cli/internal/selfupdate"
+)
+
+// ── background update check ─────────────────────────────────────────────────
+
+// updateCheckTimeout bounds the background request for the release list.
+const updateCheckTimeout = 30 * time.Second
+
+// updateBackgroundCmd is the check checkForUpdate starts in the
+// background.
+var updateBackgroundCmd = &cobra.Command{
+	Use:    "background-check",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runUpdateBackgroundCheck,
+}
+
+func init() {
+	updateBackgroundCmd.Flags().StringVar(&updateChannel, "channel", "", "Release channel to check")
+}
+
+func updateCheckPath() (string, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "update-check.json"), nil
+}
+
+// updateCheckEnabled reports whether cmd may announce updates and start
+// the background check: only at a terminal, outside CI, for a released
+// build, with update.check on, and not for ellie update itself.
+func updateCheckEnabled(cmd *cobra.Command) bool {
+	if v, _ := setting("update.check"); v == "false" {
+		return false
+	}
+	if ciMode() || quiet || jsonFlag || buildinfo.Get().IsDev() || underCommand(cmd, updateCmd) {
+		return false
+	}
+	return term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// checkForUpdate runs before every command. It mentions a newer release
+// the background check found, at most once a day per release, and starts
+// the check again when the last one is a day old.
+func checkForUpdate(cmd *cobra.Command) {
+	if !updateCheckEnabled(cmd) {
+		return
+	}
+	path, err := updateCheckPath()
+	if err != nil {
+		return
+	}
+	info := buildinfo.Get()
+	channel := installedChannel()
+	now := time.Now()
+	s := selfupdate.LoadCheckState(path)
+
+	if s.Channel == channel && s.Announce(info.Version, now) {
+		fmt.Fprintln(os.Stderr, styleBold.Render("↑"), "ellie", styleBold.Render(s.Latest), "is available",
+			styleDim.Render("(installed: "+info.Version+") — see what's new with ellie update --changelog"))
+		s.NotifiedAt, s.NotifiedVersion = now, s.Latest
+		_ = s.Save(path)
+	}
+	if !s.Due(channel, now) {
+		return
+	}
+	// Claim the check first, so commands started meanwhile don't start
+	// another one.
+	if s.Channel != channel {
+		s.Latest = ""
+	}
+	s.CheckedAt, s.Channel = now, channel
+	if s.Save(path) == nil {
+		startUpdateCheck()
+	}
+}
+
+// startUpdateCheck runs ellie update background-check detached, so that
+// it finishes even when this command doesn't wait for it.
+func startUpdateCheck() {
+	self, err := os.Executable()
+	if err != nil {
+		return
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return
+	}
+	defer devNull.Close()
+
+	child := exec.Command(self, "update", "background-check", "--channel", installedChannel())
+	child.Stdin, child.Stdout, child.Stderr = devNull, devNull, devNull
+	child.SysProcAttr = detachAttr()
+	if child.Start() == nil {
+		_ = child.Process.Release()
+	}
+}
+
+func runUpdateBackgroundCheck(cmd *cobra.Command, args []string) error {
+	path, err := updateCheckPath()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	defer cancel()
+
+	channel := installedChannel()
+	updater := selfupdate.New(os.Getenv("ELLIE_UPDATE_URL"), &http.Client{Timeout: updateCheckTimeout})
+	rel, err := updater.Latest(ctx, channel)
+	if err != nil {
+		return err
+	}
+	s := selfupdate.LoadCheckState(path)
+	s.CheckedAt, s.Channel, s.Latest = time.Now(), channel, rel.Version
+	return s.Save(path)
+}
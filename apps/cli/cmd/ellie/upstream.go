@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ellie/apps/cli/internal/chatui"
+	"ellie/apps/cli/internal/statuspage"
+)
+
+// ── upstream status ─────────────────────────────────────────────────────────
+
+// upstreamStatusTimeout bounds the status page check, which runs after a
+// request has already failed and shouldn't hold up the error for long.
+const upstreamStatusTimeout = 3 * time.Second
+
+// upstreamStatus reads the status page set in upstream.status_url. It
+// returns nil, nil when the check is turned off.
+func upstreamStatus() (*statuspage.Summary, error) {
+	url, _ := setting("upstream.status_url")
+	if url == "" {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), upstreamStatusTimeout)
+	defer cancel()
+	return statuspage.Fetch(ctx, http.DefaultClient, url)
+}
+
+func statusPageName(s *statuspage.Summary) string {
+	if s.Page.Name != "" {
+		return s.Page.Name + "'s status page"
+	}
+	return "The provider's status page"
+}
+
+func init() {
+	chatui.ProviderErrorHint = func(provider string) string {
+		hint, _ := upstreamHint(provider)
+		return hint
+	}
+}
+
+// upstreamHint says what the provider's status page makes of a failed
+// model request: whether an incident there is the likely cause, or the
+// local setup. It returns "" for providers the page doesn't cover.
+func upstreamHint(provider string) (hint string, incident bool) {
+	if provider != "" && provider != "anthropic" {
+		return "", false
+	}
+	s, err := upstreamStatus()
+	if err != nil {
+		return "Couldn't check for a provider outage: " + err.Error(), false
+	}
+	if s == nil {
+		return "", false
+	}
+	active := s.Active()
+	if len(active) == 0 {
+		return statusPageName(s) + " reports no incidents, so the cause is likely local — check ellie auth status and ellie doctor", false
+	}
+	inc := active[0]
+	hint = fmt.Sprintf("%s reports an incident, so the cause is likely upstream: %s (%s) %s", statusPageName(s), inc.Name, inc.Impact, s.Link(inc))
+	if len(active) > 1 {
+		hint += fmt.Sprintf(", and %d more", len(active)-1)
+	}
+	return hint, true
+}
+
+// checkUpstream reports the provider's status page for ellie doctor.
+func checkUpstream() []doctorCheck {
+	s, err := upstreamStatus()
+	switch {
+	case err != nil:
+		return []doctorCheck{{name: "status page", level: checkWarn, detail: err.Error(),
+			fix: "set upstream.status_url to a reachable status page, or to \"\" to skip the check"}}
+	case s == nil:
+		return []doctorCheck{{name: "status page", level: checkOK, detail: "not checked (upstream.status_url is empty)"}}
+	}
+	active := s.Active()
+	if len(active) == 0 {
+		detail := s.Status.Description
+		if detail == "" {
+			detail = "no incidents"
+		}
+		return []doctorCheck{{name: "status page", level: checkOK, detail: detail}}
+	}
+	var checks []doctorCheck
+	for _, inc := range active {
+		checks = append(checks, doctorCheck{name: "incident", level: checkWarn,
+			detail: fmt.Sprintf("%s (%s, %s)", inc.Name, inc.Impact, inc.Status),
+			fix:    "requests may fail until it is resolved — follow it at " + s.Link(inc)})
+	}
+	return checks
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"ellie/apps/cli/internal/redact"
+)
+
+// ── verbosity ───────────────────────────────────────────────────────────────
+
+var (
+	// verbosity is how many times -v was given: at 1 ellie prints the
+	// requests it sends to the server and the commands it runs, at 2 also
+	// the requests' headers.
+	verbosity int
+	// quiet leaves out headings, rules and blank lines, so that what's
+	// printed is the result alone.
+	quiet bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Print requests to the server and the commands ellie runs (-vv adds headers)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Leave out headings, rules and blank lines, for scripts")
+}
+
+// prepareVerbosity checks --quiet and -v and, with --quiet, starts
+// filtering decoration out of standard output.
+func prepareVerbosity() error {
+	if !quiet {
+		return nil
+	}
+	if verbosity > 0 {
+		return errors.New("--quiet and --verbose can't be used together")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	os.Stdout = w
+	quietDone = make(chan struct{})
+	go func() {
+		filterDecoration(r, out)
+		close(quietDone)
+	}()
+	return nil
+}
+
+// quietDone is closed once everything written with --quiet is through
+// the filter.
+var quietDone chan struct{}
+
+// finishQuiet waits for the --quiet filter to write the last of the
+// output. It must run before ellie exits.
+func finishQuiet() {
+	if quietDone == nil {
+		return
+	}
+	os.Stdout.Close()
+	<-quietDone
+}
+
+// filterDecoration copies r to w without blank lines, "────" rules and the
+// heading line above each rule. A line is held back until the next one
+// shows whether it is a heading, or briefly when no next line comes, so
+// long-running commands still print as they go.
+func filterDecoration(r io.Reader, w io.Writer) {
+	lines := make(chan string)
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadString('\n')
+			if line != "" {
+				lines <- line
+			}
+			if err != nil {
+				close(lines)
+				return
+			}
+		}
+	}()
+
+	var held string
+	flush := func() {
+		if held != "" {
+			io.WriteString(w, held)
+			held = ""
+		}
+	}
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				flush()
+				return
+			}
+			text := strings.TrimSpace(ansi.Strip(line))
+			switch {
+			case text == "":
+			case strings.Trim(text, "─") == "":
+				held = ""
+			default:
+				flush()
+				held = line
+				timer.Reset(100 * time.Millisecond)
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// debugf prints a diagnostic line to stderr when -v was given at least
+// level times.
+func debugf(level int, format string, args ...any) {
+	if verbosity < level {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if ciMode() {
+		ciLog("debug", msg)
+		return
+	}
+	fmt.Fprintln(os.Stderr, styleDim.Render(msg))
+}
+
+// logCommand prints the command line of a process ellie is about to run,
+// at -v.
+func logCommand(c *exec.Cmd) {
+	if verbosity < 1 {
+		return
+	}
+	line := "$ " + shellQuote(c.Args)
+	if c.Dir != "" {
+		line += "  (in " + c.Dir + ")"
+	}
+	debugf(1, "%s", line)
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote joins args the way they would be typed into a POSIX shell.
+func shellQuote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if shellSafe.MatchString(a) {
+			quoted[i] = a
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// verboseTransport prints each request sent to the server and its
+// outcome at -v, and their headers at -vv; with --debug-http it traces
+// them. It sits at the bottom of serverTransport, so it shows what
+// actually goes over the wire — credentials masked.
+type verboseTransport struct {
+	base http.RoundTripper
+}
+
+func (t verboseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if debugHTTP {
+		return debugRoundTrip(t.base, req)
+	}
+	if verbosity < 1 {
+		return t.base.RoundTrip(req)
+	}
+	debugf(1, "→ %s %s", req.Method, req.URL.Redacted())
+	logHeaders(req.Header)
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		debugf(1, "← %s %s failed after %s: %v", req.Method, req.URL.Path, elapsed, err)
+		return nil, err
+	}
+	debugf(1, "← %s %s %s (%s)", req.Method, req.URL.Path, resp.Status, elapsed)
+	logHeaders(resp.Header)
+	return resp, nil
+}
+
+// secretHeaders are masked when headers are printed.
+var secretHeaders = map[string]bool{"Authorization": true, "Cookie": true, "Set-Cookie": true, "X-Api-Key": true}
+
+func logHeaders(h http.Header) {
+	if verbosity < 2 {
+		return
+	}
+	for _, line := range headerLines(h) {
+		debugf(2, "    %s", line)
+	}
+}
+
+// headerLines returns h as "Name: value" lines sorted by name, with the
+// values of secretHeaders masked and secrets in the others redacted.
+func headerLines(h http.Header) []string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		for _, v := range h[name] {
+			if secretHeaders[name] {
+				if scheme, _, ok := strings.Cut(v, " "); ok {
+					v = scheme + " ***"
+				} else {
+					v = "***"
+				}
+			} else {
+				v = redact.Text(v)
+			}
+			lines = append(lines, name+": "+v)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// ── readiness wait ──────────────────────────────────────────────────────────
+
+var (
+	startWait  bool
+	devWait    bool
+	devTimeout time.Duration
+	stopDev    bool
+)
+
+func init() {
+	startCmd.Flags().BoolVar(&startWait, "wait", false, "Run in the background and return once the server accepts requests (implies --detach)")
+	devCmd.Flags().BoolVar(&devWait, "wait", false, "Run in the background and return once the dev server accepts requests")
+	devCmd.Flags().DurationVar(&devTimeout, "wait-timeout", 60*time.Second, "With --wait, how long to wait for the dev server to become healthy")
+	stopCmd.Flags().BoolVar(&stopDev, "dev", false, "Stop the background ellie dev started with --wait instead")
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinner shows title behind a spinner on a terminal until the returned
+// func is called. Elsewhere it prints title once.
+func spinner(title string) func() {
+	if quiet || !term.IsTerminal(int(os.Stderr.Fd())) {
+		fmt.Println(styleDim.Render(title))
+		return func() {}
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tick := time.NewTicker(80 * time.Millisecond)
+		defer tick.Stop()
+		for i := 0; ; i++ {
+			fmt.Fprintf(os.Stderr, "\r%s %s", styleBold.Render(spinnerFrames[i%len(spinnerFrames)]), styleDim.Render(title))
+			select {
+			case <-done:
+				fmt.Fprint(os.Stderr, "\r\033[2K")
+				return
+			case <-tick.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// childArgs returns cmd's command line for running it again: its name
+// and the flags given, less those named in skip.
+func childArgs(cmd *cobra.Command, skip ...string) []string {
+	args := []string{cmd.Name()}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if slices.Contains(skip, f.Name) {
+			return
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			for _, v := range sv.GetSlice() {
+				args = append(args, "--"+f.Name+"="+v)
+			}
+			return
+		}
+		args = append(args, "--"+f.Name+"="+f.Value.String())
+	})
+	return args
+}
+
+// devDetached runs ellie dev again in its own session with no terminal,
+// given port when not 0 and turbo's extra arguments, and waits until the server answers or the
+// child exits. The child records the dev pidfile and log as usual.
+func devDetached(cmd *cobra.Command, root string, port int, extra []string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate ellie executable: %w", err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+
+	args := childArgs(cmd, "wait", "wait-timeout", "port")
+	if port != 0 {
+		args = append(args, "--port="+strconv.Itoa(port))
+	}
+	if len(extra) > 0 {
+		args = append(append(args, "--"), extra...)
+	}
+	child := exec.Command(self, args...)
+	child.Dir = root
+	child.Stdin, child.Stdout, child.Stderr = devNull, devNull, devNull
+	child.SysProcAttr = detachAttr()
+	logCommand(child)
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("cannot start background dev server: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- child.Wait() }()
+	healthy := make(chan bool, 1)
+	base := baseURL()
+	go func() {
+		healthy <- waitHealthyFunc(func() string { return serverBaseOf("dev", base) }, devTimeout)
+	}()
+
+	done := spinner(fmt.Sprintf("Starting dev server in the background (pid %d)...", child.Process.Pid))
+	select {
+	case err := <-exited:
+		done()
+		if err == nil {
+			fmt.Fprintln(os.Stderr, styleErr.Render("✗"), "ellie dev finished before the server accepted requests")
+		} else {
+			fmt.Fprintln(os.Stderr, styleErr.Render("✗"), "Dev server exited during startup:", err)
+		}
+		fmt.Fprintln(os.Stderr, styleDim.Render("  See ellie logs --source dev"+instanceArgs()))
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+			return exitCodeError(exitErr.ExitCode())
+		}
+		return errSilent
+	case ok := <-healthy:
+		done()
+		if !ok {
+			fmt.Fprintln(os.Stderr, styleErr.Render("✗"), fmt.Sprintf("Dev server did not become healthy within %s (still running, pid %d)", devTimeout, child.Process.Pid))
+			fmt.Fprintln(os.Stderr, styleDim.Render("  See ellie logs --source dev"+instanceArgs()+", or stop it with ellie stop --dev"+instanceArgs()))
+			return errSilent
+		}
+	}
+
+	fmt.Println(styleOk.Render("✓"), "Dev server running at", styleBold.Render(serverBaseOf("dev", base)), styleDim.Render(fmt.Sprintf("(pid %d)", child.Process.Pid)))
+	fmt.Println(styleDim.Render("  Follow output with: ellie logs -f --source dev" + instanceArgs()))
+	fmt.Println(styleDim.Render("  Stop it with:       ellie stop --dev" + instanceArgs()))
+	return nil
+}
+
+// stopDevServer stops a background ellie dev.
+func stopDevServer() error {
+	pid, _, ok := runningProcess("dev")
+	if !ok {
+		fmt.Println(styleDim.Render("Dev server is not running."))
+		return nil
+	}
+	fmt.Println(styleDim.Render(fmt.Sprintf("Stopping dev server (pid %d)...", pid)))
+	if err := stopProcess(pid, stopTimeout); err != nil {
+		return err
+	}
+	if path, err := pidPath("dev"); err == nil {
+		_ = os.Remove(path)
+	}
+	fmt.Println(styleOk.Render("✓"), "Stopped")
+	return nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ellie/apps/cli/internal/paths"
+	"ellie/apps/cli/internal/watchdog"
+)
+
+// ── hung server watchdog ────────────────────────────────────────────────────
+
+var (
+	watchdogTimeout time.Duration
+	watchdogRestart bool
+)
+
+func init() {
+	for _, c := range []*cobra.Command{devCmd, startCmd} {
+		c.Flags().DurationVar(&watchdogTimeout, "watchdog", 0,
+			"Call the server hung after this long with no output and no healthy status check, e.g. 2m; 0 disables (default: watchdog.timeout)")
+		c.Flags().BoolVar(&watchdogRestart, "watchdog-restart", false, "Restart the server when the watchdog finds it hung (default: watchdog.restart)")
+	}
+}
+
+// watchdogSettings configure the watchdog over a dev or start server.
+type watchdogSettings struct {
+	Profile string // dev or start
+	Timeout time.Duration
+	Restart bool
+	Dump    string
+}
+
+// watchdogFor resolves the watchdog for profile: the command's flags,
+// then watchdog.<profile>.*, then watchdog.*.
+func watchdogFor(cmd *cobra.Command, profile string) (watchdogSettings, error) {
+	wd := watchdogSettings{Profile: profile, Dump: watchdog.DumpStacks}
+	lookup := func(name string) (string, string, bool) {
+		for _, key := range []string{"watchdog." + profile + "." + name, "watchdog." + name} {
+			if v, ok := setting(key); ok {
+				return key, v, true
+			}
+		}
+		return "", "", false
+	}
+	if key, v, ok := lookup("timeout"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return wd, fmt.Errorf("%s = %q is not a valid duration (use e.g. 2m)", key, v)
+		}
+		wd.Timeout = d
+	}
+	if _, v, ok := lookup("restart"); ok {
+		wd.Restart = v == "true"
+	}
+	if _, v, ok := lookup("dump"); ok {
+		wd.Dump = v
+	}
+	if cmd.Flags().Changed("watchdog") {
+		wd.Timeout = watchdogTimeout
+	}
+	if cmd.Flags().Changed("watchdog-restart") {
+		wd.Restart = watchdogRestart
+	}
+	return wd, nil
+}
+
+// flags renders the settings as flags, for a supervising child process.
+func (wd watchdogSettings) flags() []string {
+	return []string{"--watchdog=" + wd.Timeout.String(), fmt.Sprintf("--watchdog-restart=%t", wd.Restart)}
+}
+
+// watchdog returns a watchdog that also polls the server's status, or
+// nil when the watchdog is off.
+func (wd watchdogSettings) watchdog() *watchdog.Watchdog {
+	if wd.Timeout <= 0 {
+		return nil
+	}
+	base := baseURL()
+	return watchdog.New(wd.Timeout, func(ctx context.Context) bool {
+		return serverHealthy(ctx, base, 5*time.Second)
+	})
+}
+
+// runWatchedProcess is runLoggedProcess under the watchdog in wd, which
+// restarts the child when it hangs if wd.Restart is set.
+func runWatchedProcess(name string, args []string, dir string, log *logStore, wd watchdogSettings) int {
+	for restarts := 0; ; restarts++ {
+		code, hung := runChild(name, args, dir, log, wd)
+		if !hung {
+			return code
+		}
+		msg := fmt.Sprintf("Restarting the %s server (restart %d)...", wd.Profile, restarts+1)
+		if log != nil {
+			w, flush := log.Writer()
+			watchdogNotice(w, msg)
+			flush()
+		} else {
+			watchdogNotice(nil, msg)
+		}
+	}
+}
+
+// watchChild waits for cmd to exit while dog watches it. A hang is
+// reported with a dump of the process tree; with wd.Restart the child is
+// then stopped and watchChild reports it hung.
+func watchChild(cmd *exec.Cmd, dog *watchdog.Watchdog, exited <-chan error, wd watchdogSettings, logw io.Writer) (bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hangs := make(chan time.Duration)
+	go func() {
+		for {
+			quiet, err := dog.Wait(ctx)
+			if err != nil {
+				return
+			}
+			select {
+			case hangs <- quiet:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case err := <-exited:
+			return false, err
+		case quiet := <-hangs:
+			msg := fmt.Sprintf("The %s server has been unresponsive for %s (no output, status checks failing).", wd.Profile, quiet.Round(time.Second))
+			watchdogNotice(logw, msg)
+			dumpHungChild(cmd.Process.Pid, wd, logw)
+			if !wd.Restart {
+				watchdogNotice(logw, "Leaving it running — pass --watchdog-restart or set watchdog.restart to restart it automatically.")
+				continue
+			}
+			stopChild(cmd, exited)
+			return true, nil
+		}
+	}
+}
+
+// dumpHungChild captures the state of the hung process tree under the
+// state dir.
+func dumpHungChild(pid int, wd watchdogSettings, logw io.Writer) {
+	if wd.Dump == watchdog.DumpNone {
+		return
+	}
+	base, err := paths.State()
+	if err != nil {
+		return
+	}
+	dir := filepath.Join(base, "dumps", wd.Profile+"-"+time.Now().Format("20060102-150405"))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	files, err := watchdog.Dump(ctx, dir, pid, wd.Dump)
+	if len(files) > 0 {
+		watchdogNotice(logw, "Captured process state in "+dir)
+	}
+	if err != nil {
+		watchdogNotice(logw, "Dump incomplete: "+err.Error())
+	}
+}
+
+// stopChild stops a child and everything it started: SIGTERM, then a
+// kill for whatever is still running after the shutdown grace period.
+func stopChild(cmd *exec.Cmd, exited <-chan error) {
+	pids := watchdog.Tree(cmd.Process.Pid)
+	terminateTree(cmd, pids)
+	select {
+	case <-exited:
+	case <-time.After(shutdownGrace()):
+		killTree(pids)
+		<-exited
+	}
+}
+
+// watchdogNotice prints a watchdog message to the terminal and, when
+// there is one, the process log.
+func watchdogNotice(logw io.Writer, msg string) {
+	fmt.Fprintln(os.Stderr, styleErr.Render("!")+" "+styleBold.Render("watchdog:")+" "+msg)
+	if logw != nil {
+		fmt.Fprintln(logw, "watchdog: "+strings.TrimSpace(msg))
+	}
+}
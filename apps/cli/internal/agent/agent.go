@@ -0,0 +1,198 @@
+// Package agent is the protocol of ellie's credential agent, a process
+// that holds keyring secrets once they are unlocked and hands them to
+// ellie commands over a unix socket, like ssh-agent does with keys. Only
+// processes of the user running the agent may connect: the agent asks
+// the kernel who is on the other end of every connection.
+//
+// As with the daemon's control socket, each connection carries one JSON
+// request and one JSON response.
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operations.
+const (
+	OpGet     = "get"     // a keyring secret
+	OpForget  = "forget"  // drop a cached secret, after it changed
+	OpStatus  = "status"  // the agent's Status
+	OpRefresh = "refresh" // check the OAuth token now
+	OpStop    = "stop"    // exit
+)
+
+// Error codes a Response carries for errors the client maps back to its
+// own.
+const (
+	CodeNotFound    = "not_found"
+	CodeUnsupported = "unsupported"
+	CodeDenied      = "denied"
+)
+
+// Request is sent to the agent.
+type Request struct {
+	Op      string `json:"op"`
+	Service string `json:"service,omitempty"`
+	Account string `json:"account,omitempty"`
+}
+
+// Response is the agent's answer.
+type Response struct {
+	Error  string  `json:"error,omitempty"`
+	Code   string  `json:"code,omitempty"`
+	Secret string  `json:"secret,omitempty"`
+	Status *Status `json:"status,omitempty"`
+}
+
+// Status is what the agent reports about itself.
+type Status struct {
+	PID     int       `json:"pid"`
+	Since   time.Time `json:"since"`
+	Cached  int       `json:"cached"` // secrets held
+	Served  int       `json:"served"` // requests answered from the cache
+	Refresh Refresh   `json:"refresh"`
+}
+
+// Refresh is the state of the agent's OAuth token refreshes.
+type Refresh struct {
+	Before    time.Duration `json:"before"`              // how long before expiry the token is refreshed
+	CheckedAt time.Time     `json:"checkedAt,omitzero"`  // last look at the token
+	Expires   time.Time     `json:"expires,omitzero"`    // the token's expiry then
+	LastAt    time.Time     `json:"lastAt,omitzero"`     // last refresh
+	LastError string        `json:"lastError,omitempty"` // of the last check or refresh
+	OAuth     bool          `json:"oauth"`               // whether the server signs in with OAuth
+}
+
+// ErrNotRunning is returned by Call when nothing is listening on the
+// socket.
+var ErrNotRunning = errors.New("the agent is not running")
+
+// ErrPeerUnsupported is returned by PeerUID where the socket can't tell
+// who connected.
+var ErrPeerUnsupported = errors.New("this system cannot identify the process on the other end of a unix socket")
+
+// Serve answers requests on ln with handle until ln is closed. A
+// connection from a process of a user other than uid is refused.
+func Serve(ln net.Listener, uid int, handle func(Request) Response) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(10 * time.Second))
+			if peer, err := PeerUID(conn); err != nil || peer != uid {
+				json.NewEncoder(conn).Encode(Response{Error: "permission denied", Code: CodeDenied})
+				return
+			}
+			var req Request
+			if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+				return
+			}
+			json.NewEncoder(conn).Encode(handle(req))
+		}()
+	}
+}
+
+// Call sends req to the agent listening on socket. An error the agent
+// answers with is returned as a *RemoteError.
+func Call(ctx context.Context, socket string, req Request) (*Response, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socket)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("cannot reach the agent: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("invalid response from the agent: %w", err)
+	}
+	if resp.Error != "" {
+		return &resp, &RemoteError{Code: resp.Code, Message: resp.Error}
+	}
+	return &resp, nil
+}
+
+// RemoteError is an error the agent answered with.
+type RemoteError struct {
+	Code    string
+	Message string
+}
+
+func (e *RemoteError) Error() string { return "agent: " + e.Message }
+
+// Cache holds keyring secrets read through load, so that each is read —
+// and the keyring unlocked — once. Misses are not cached: a secret saved
+// later is found on the next Get.
+type Cache struct {
+	load func(service, account string) (string, error)
+
+	mu      sync.Mutex
+	secrets map[string]string
+	served  int
+}
+
+// NewCache returns a cache reading secrets with load.
+func NewCache(load func(service, account string) (string, error)) *Cache {
+	return &Cache{load: load, secrets: map[string]string{}}
+}
+
+// Get returns the secret for service and account, reading it through
+// load the first time.
+func (c *Cache) Get(service, account string) (string, error) {
+	key := service + "\x00" + account
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.secrets[key]; ok {
+		c.served++
+		return s, nil
+	}
+	s, err := c.load(service, account)
+	if err != nil {
+		return "", err
+	}
+	c.secrets[key] = s
+	return s, nil
+}
+
+// Forget drops the secret for service and account; an empty account
+// drops all of service's.
+func (c *Cache) Forget(service, account string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if account != "" {
+		delete(c.secrets, service+"\x00"+account)
+		return
+	}
+	for key := range c.secrets {
+		if s, _, _ := strings.Cut(key, "\x00"); s == service {
+			delete(c.secrets, key)
+		}
+	}
+}
+
+// Stats returns how many secrets are held and how many requests they
+// answered.
+func (c *Cache) Stats() (cached, served int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.secrets), c.served
+}
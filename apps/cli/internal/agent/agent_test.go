@@ -0,0 +1,91 @@
+//go:build linux || darwin || freebsd
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServe(t *testing.T) {
+	dir, err := os.MkdirTemp("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	serve := func(name string, uid int) string {
+		socket := filepath.Join(dir, name)
+		ln, err := net.Listen("unix", socket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { ln.Close() })
+		go Serve(ln, uid, func(req Request) Response {
+			if req.Op != OpGet {
+				return Response{Error: "unknown op", Code: "bad"}
+			}
+			return Response{Secret: "secret for " + req.Account}
+		})
+		return socket
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mine := serve("mine.sock", os.Getuid())
+	resp, err := Call(ctx, mine, Request{Op: OpGet, Service: "ellie", Account: "anthropic"})
+	if err != nil || resp.Secret != "secret for anthropic" {
+		t.Errorf("Call = %+v, %v", resp, err)
+	}
+	var remote *RemoteError
+	if _, err := Call(ctx, mine, Request{Op: "nope"}); !errors.As(err, &remote) || remote.Code != "bad" {
+		t.Errorf("unknown op: %v", err)
+	}
+
+	theirs := serve("theirs.sock", os.Getuid()+1)
+	if _, err := Call(ctx, theirs, Request{Op: OpGet, Account: "anthropic"}); !errors.As(err, &remote) || remote.Code != CodeDenied {
+		t.Errorf("another user's agent answered: %v", err)
+	}
+
+	if _, err := Call(ctx, filepath.Join(dir, "none.sock"), Request{Op: OpStatus}); err != ErrNotRunning {
+		t.Errorf("Call without an agent: %v", err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	loads := 0
+	c := NewCache(func(service, account string) (string, error) {
+		loads++
+		if account == "missing" {
+			return "", errors.New("not found")
+		}
+		return service + "/" + account, nil
+	})
+	for range 3 {
+		if s, err := c.Get("ellie", "anthropic"); err != nil || s != "ellie/anthropic" {
+			t.Fatalf("Get = %q, %v", s, err)
+		}
+	}
+	c.Get("ellie", "anthropic@work")
+	c.Get("other", "x")
+	if _, err := c.Get("ellie", "missing"); err == nil {
+		t.Error("a missing secret was found")
+	}
+	if cached, served := c.Stats(); loads != 4 || cached != 3 || served != 2 {
+		t.Errorf("after 6 Gets: %d loads, %d cached, %d served", loads, cached, served)
+	}
+
+	c.Forget("ellie", "anthropic")
+	if cached, _ := c.Stats(); cached != 2 {
+		t.Errorf("Forget of one left %d", cached)
+	}
+	c.Forget("ellie", "")
+	if cached, _ := c.Stats(); cached != 1 {
+		t.Errorf("Forget of a service left %d", cached)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !freebsd
+
+package agent
+
+import "net"
+
+// PeerUID always fails here: without it the agent would hand secrets to
+// anyone who can reach its socket, so it doesn't run.
+func PeerUID(conn net.Conn) (int, error) {
+	return -1, ErrPeerUnsupported
+}
+
+// Supported reports whether PeerUID works on this system, which the
+// agent needs to run.
+func Supported() bool { return false }
@@ -0,0 +1,153 @@
+// Package audit keeps an append-only, hash-chained log of credential
+// operations. Each entry commits to the hash of the one before it, so
+// editing, reordering or deleting any entry breaks the chain from that
+// point on and is reported by Verify.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is a single recorded credential operation. Secrets are never
+// recorded — only which provider was touched, how, and by whom.
+type Entry struct {
+	Seq      int       `json:"seq"`
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor"`              // local user@host that ran the CLI
+	Op       string    `json:"op"`                 // set, rotate, clear, refresh, or an admin action
+	Provider string    `json:"provider"`           // e.g. anthropic, groq, whatsapp
+	Method   string    `json:"method,omitempty"`   // api-key, token, oauth
+	Server   string    `json:"server"`             // server base URL
+	ServerIP string    `json:"serverIp,omitempty"` // remote address the request went to
+	OK       bool      `json:"ok"`
+	Detail   string    `json:"detail,omitempty"` // HTTP status or error
+	PrevHash string    `json:"prevHash"`
+	Hash     string    `json:"hash"`
+}
+
+// computeHash hashes the entry with its Hash field cleared.
+func (e Entry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Log is an audit log stored as one JSON entry per line.
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Open returns the log at path. The file is created on first Append.
+func Open(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the log file location.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append chains e onto the log, filling in Seq, PrevHash and Hash, and
+// returns the stored entry.
+func (l *Log) Append(e Entry) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries, err := l.read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return e, err
+	}
+	e.Seq = 1
+	e.PrevHash = ""
+	if n := len(entries); n > 0 {
+		e.Seq = entries[n-1].Seq + 1
+		e.PrevHash = entries[n-1].Hash
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	e.Hash = e.computeHash()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return e, err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return e, fmt.Errorf("cannot create audit directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return e, fmt.Errorf("cannot open audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return e, fmt.Errorf("cannot write audit log: %w", err)
+	}
+	return e, f.Close()
+}
+
+// Entries returns every entry in order. A missing log is empty.
+func (l *Log) Entries() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries, err := l.read()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return entries, err
+}
+
+func (l *Log) read() ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("audit log line %d is corrupt: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Verify checks the hash chain. It returns the sequence number of the
+// first entry that doesn't verify (0 when the whole chain is intact)
+// along with the reason.
+func Verify(entries []Entry) (int, error) {
+	prev := ""
+	for i, e := range entries {
+		if e.Seq != i+1 {
+			return e.Seq, fmt.Errorf("entry %d: expected sequence %d (entries removed or reordered)", e.Seq, i+1)
+		}
+		if e.PrevHash != prev {
+			return e.Seq, fmt.Errorf("entry %d: does not chain to the previous entry", e.Seq)
+		}
+		if e.computeHash() != e.Hash {
+			return e.Seq, fmt.Errorf("entry %d: contents do not match its hash (modified)", e.Seq)
+		}
+		prev = e.Hash
+	}
+	return 0, nil
+}
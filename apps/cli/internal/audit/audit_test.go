@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func appendN(t *testing.T, l *Log, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := l.Append(Entry{Actor: "me@host", Op: "set", Provider: "groq", Server: "http://localhost:3000", OK: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAppendChains(t *testing.T) {
+	l := Open(filepath.Join(t.TempDir(), "audit", "credentials.jsonl"))
+	appendN(t, l, 3)
+
+	entries, err := l.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	if entries[0].PrevHash != "" || entries[1].PrevHash != entries[0].Hash || entries[2].Seq != 3 {
+		t.Errorf("entries not chained: %+v", entries)
+	}
+	if seq, err := Verify(entries); seq != 0 || err != nil {
+		t.Errorf("Verify = %d, %v", seq, err)
+	}
+
+	info, err := os.Stat(l.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("log mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	l := Open(filepath.Join(t.TempDir(), "credentials.jsonl"))
+	appendN(t, l, 4)
+
+	data, err := os.ReadFile(l.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	// Modify an entry in place.
+	edited := append([]string{}, lines...)
+	edited[1] = strings.Replace(edited[1], `"provider":"groq"`, `"provider":"brave"`, 1)
+	if err := os.WriteFile(l.Path(), []byte(strings.Join(edited, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := l.Entries()
+	if seq, err := Verify(entries); seq != 2 || err == nil {
+		t.Errorf("modified entry: Verify = %d, %v", seq, err)
+	}
+
+	// Delete an entry.
+	removed := append(append([]string{}, lines[:2]...), lines[3:]...)
+	if err := os.WriteFile(l.Path(), []byte(strings.Join(removed, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = l.Entries()
+	if seq, err := Verify(entries); seq != 4 || err == nil {
+		t.Errorf("deleted entry: Verify = %d, %v", seq, err)
+	}
+}
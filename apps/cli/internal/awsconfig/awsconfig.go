@@ -0,0 +1,167 @@
+// Package awsconfig reads the profiles in the AWS shared config and
+// credentials files, so the CLI can offer them for Bedrock without
+// depending on the AWS SDK.
+package awsconfig
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Profile is a named profile from the shared config files.
+type Profile struct {
+	Name    string
+	Region  string
+	RoleARN string // role_arn, when the profile assumes a role itself
+	SSO     bool   // signs in through IAM Identity Center
+}
+
+// ConfigPath returns the shared config file: AWS_CONFIG_FILE or ~/.aws/config.
+func ConfigPath() string {
+	return fileFromEnv("AWS_CONFIG_FILE", "config")
+}
+
+// CredentialsPath returns the shared credentials file:
+// AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials.
+func CredentialsPath() string {
+	return fileFromEnv("AWS_SHARED_CREDENTIALS_FILE", "credentials")
+}
+
+func fileFromEnv(env, name string) string {
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", name)
+}
+
+// Profiles returns the profiles defined in either file, sorted by name
+// with "default" first. Missing files are not an error.
+func Profiles() ([]Profile, error) {
+	byName := map[string]*Profile{}
+	get := func(name string) *Profile {
+		if p, ok := byName[name]; ok {
+			return p
+		}
+		p := &Profile{Name: name}
+		byName[name] = p
+		return p
+	}
+
+	config, err := parseINI(ConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	for section, keys := range config {
+		// The config file names sections "profile x", except for default.
+		name, ok := strings.CutPrefix(section, "profile ")
+		if !ok && section != "default" {
+			continue
+		}
+		p := get(strings.TrimSpace(name))
+		p.Region = keys["region"]
+		p.RoleARN = keys["role_arn"]
+		p.SSO = keys["sso_session"] != "" || keys["sso_start_url"] != ""
+	}
+
+	creds, err := parseINI(CredentialsPath())
+	if err != nil {
+		return nil, err
+	}
+	for section := range creds {
+		get(section)
+	}
+
+	out := make([]Profile, 0, len(byName))
+	for _, p := range byName {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if (out[i].Name == "default") != (out[j].Name == "default") {
+			return out[i].Name == "default"
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// Find returns the named profile.
+func Find(name string) (Profile, bool) {
+	profiles, err := Profiles()
+	if err != nil {
+		return Profile{}, false
+	}
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// DefaultProfile returns the profile the AWS tools use when none is named.
+func DefaultProfile() string {
+	if p := os.Getenv("AWS_PROFILE"); p != "" {
+		return p
+	}
+	return "default"
+}
+
+// DefaultRegion returns the region from the environment, falling back to
+// the given profile's region.
+func DefaultRegion(p Profile) string {
+	for _, k := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return p.Region
+}
+
+// parseINI reads the sections and keys of an AWS-style INI file. Nested
+// values (indented lines under a key such as s3 =) are skipped.
+func parseINI(path string) (map[string]map[string]string, error) {
+	out := map[string]map[string]string{}
+	if path == "" {
+		return out, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var section map[string]string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		raw := sc.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if out[name] == nil {
+				out[name] = map[string]string{}
+			}
+			section = out[name]
+			continue
+		}
+		if section == nil || raw[0] == ' ' || raw[0] == '\t' {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok {
+			section[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return out, sc.Err()
+}
@@ -0,0 +1,106 @@
+package awsconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const config = `# comment
+[default]
+region = us-east-1
+
+[profile bedrock]
+region=eu-central-1
+role_arn = arn:aws:iam::123456789012:role/ellie-bedrock
+source_profile = default
+s3 =
+  max_concurrent_requests = 4
+
+[profile sso-dev]
+sso_session = corp
+region = us-west-2
+
+[sso-session corp]
+sso_region = us-east-1
+`
+
+const credentials = `[default]
+aws_access_key_id = AKIA...
+[ci]
+aws_access_key_id = AKIA...
+`
+
+func setup(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "config")
+	creds := filepath.Join(dir, "credentials")
+	if err := os.WriteFile(cfg, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(creds, []byte(credentials), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", cfg)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", creds)
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_PROFILE", "")
+}
+
+func TestProfiles(t *testing.T) {
+	setup(t)
+	profiles, err := Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "default,bedrock,ci,sso-dev" {
+		t.Fatalf("profiles = %v", names)
+	}
+
+	p, ok := Find("bedrock")
+	if !ok || p.Region != "eu-central-1" || p.RoleARN != "arn:aws:iam::123456789012:role/ellie-bedrock" || p.SSO {
+		t.Errorf("bedrock = %+v", p)
+	}
+	if p, _ := Find("sso-dev"); !p.SSO {
+		t.Errorf("sso-dev should be an SSO profile: %+v", p)
+	}
+	if _, ok := Find("corp"); ok {
+		t.Error("sso-session sections are not profiles")
+	}
+}
+
+func TestMissingFiles(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "none"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "none"))
+	profiles, err := Profiles()
+	if err != nil || len(profiles) != 0 {
+		t.Errorf("Profiles() = %v, %v", profiles, err)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	setup(t)
+	if DefaultProfile() != "default" {
+		t.Errorf("DefaultProfile() = %s", DefaultProfile())
+	}
+	t.Setenv("AWS_PROFILE", "ci")
+	if DefaultProfile() != "ci" {
+		t.Errorf("DefaultProfile() = %s, want AWS_PROFILE", DefaultProfile())
+	}
+
+	p, _ := Find("bedrock")
+	if DefaultRegion(p) != "eu-central-1" {
+		t.Errorf("DefaultRegion = %s, want the profile's", DefaultRegion(p))
+	}
+	t.Setenv("AWS_DEFAULT_REGION", "ap-south-1")
+	if DefaultRegion(p) != "ap-south-1" {
+		t.Errorf("DefaultRegion = %s, want AWS_DEFAULT_REGION", DefaultRegion(p))
+	}
+}
@@ -0,0 +1,105 @@
+// Package azureopenai checks an Azure OpenAI resource — endpoint,
+// deployment and key — before the configuration is stored.
+package azureopenai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultAPIVersion is the GA data-plane API version requests are made with.
+const DefaultAPIVersion = "2024-10-21"
+
+var (
+	// ErrUnauthorized is returned when the endpoint rejects the key.
+	ErrUnauthorized = errors.New("Azure rejected the API key")
+	// ErrDeploymentNotFound is returned when the resource has no such deployment.
+	ErrDeploymentNotFound = errors.New("no such deployment")
+)
+
+// Config identifies a chat deployment on an Azure OpenAI resource.
+type Config struct {
+	Endpoint   string `json:"endpoint"`
+	Deployment string `json:"deployment"`
+	APIVersion string `json:"api_version"`
+	Key        string `json:"key"`
+}
+
+// NormalizeEndpoint checks that s is an https URL and reduces it to the
+// resource's origin, so a URL copied from the portal or from a request
+// (…/openai/deployments/…) works too.
+func NormalizeEndpoint(s string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("%q is not a URL (e.g. https://my-resource.openai.azure.com)", s)
+	}
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("the endpoint must use https: %s", s)
+	}
+	return "https://" + u.Host, nil
+}
+
+// Validate checks cfg against the endpoint without generating anything:
+// it sends a chat completion request with no messages, which Azure
+// rejects as invalid only once the key and deployment have been accepted.
+func Validate(ctx context.Context, client *http.Client, cfg Config) error {
+	version := cfg.APIVersion
+	if version == "" {
+		version = DefaultAPIVersion
+	}
+	u := cfg.Endpoint + "/openai/deployments/" + url.PathEscape(cfg.Deployment) +
+		"/chat/completions?api-version=" + url.QueryEscape(version)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader([]byte(`{"messages":[],"max_tokens":1}`)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("api-key", cfg.Key)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach %s: %w", cfg.Endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	code, msg := errorDetail(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusOK, resp.StatusCode == http.StatusBadRequest:
+		// 400 is the expected answer to the empty request.
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case resp.StatusCode == http.StatusNotFound && code == "DeploymentNotFound":
+		return fmt.Errorf("%w %q on %s", ErrDeploymentNotFound, cfg.Deployment, cfg.Endpoint)
+	case resp.StatusCode == http.StatusNotFound:
+		if msg == "" {
+			msg = "not found"
+		}
+		return fmt.Errorf("%s: %s — check the endpoint and API version %s", cfg.Endpoint, msg, version)
+	}
+	if msg == "" {
+		msg = resp.Status
+	}
+	return fmt.Errorf("%s returned %d: %s", cfg.Endpoint, resp.StatusCode, msg)
+}
+
+// errorDetail extracts the code and message of an Azure error response.
+func errorDetail(r io.Reader) (code, msg string) {
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.NewDecoder(io.LimitReader(r, 64<<10)).Decode(&body) != nil {
+		return "", ""
+	}
+	return body.Error.Code, body.Error.Message
+}
@@ -0,0 +1,74 @@
+package azureopenai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeEndpoint(t *testing.T) {
+	for in, want := range map[string]string{
+		"https://acme.openai.azure.com":  "https://acme.openai.azure.com",
+		"https://acme.openai.azure.com/": "https://acme.openai.azure.com",
+		" https://acme.cognitiveservices.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-10-21 ": "https://acme.cognitiveservices.azure.com",
+	} {
+		if got, err := NormalizeEndpoint(in); err != nil || got != want {
+			t.Errorf("NormalizeEndpoint(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"acme.openai.azure.com", "http://acme.openai.azure.com", ""} {
+		if _, err := NormalizeEndpoint(in); err == nil {
+			t.Errorf("NormalizeEndpoint(%q) should fail", in)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api-version") != DefaultAPIVersion {
+			t.Errorf("api-version = %q", r.URL.Query().Get("api-version"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Header.Get("api-key") != "good":
+			w.WriteHeader(401)
+			w.Write([]byte(`{"error":{"code":"401","message":"Access denied due to invalid subscription key"}}`))
+		case r.URL.Path == "/openai/deployments/gpt-4o/chat/completions":
+			w.WriteHeader(400)
+			w.Write([]byte(`{"error":{"code":"BadRequest","message":"'$.messages' is an invalid empty array."}}`))
+		case r.URL.Path == "/openai/deployments/broken/chat/completions":
+			w.WriteHeader(500)
+			w.Write([]byte(`{"error":{"code":"InternalServerError","message":"The server had an error"}}`))
+		default:
+			w.WriteHeader(404)
+			w.Write([]byte(`{"error":{"code":"DeploymentNotFound","message":"The API deployment for this resource does not exist."}}`))
+		}
+	}))
+	defer srv.Close()
+
+	cfg := Config{Endpoint: srv.URL, Deployment: "gpt-4o", Key: "good"}
+	if err := Validate(context.Background(), srv.Client(), cfg); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+
+	bad := cfg
+	bad.Key = "bad"
+	if err := Validate(context.Background(), srv.Client(), bad); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("bad key: err = %v, want ErrUnauthorized", err)
+	}
+
+	missing := cfg
+	missing.Deployment = "gpt-5"
+	if err := Validate(context.Background(), srv.Client(), missing); !errors.Is(err, ErrDeploymentNotFound) {
+		t.Errorf("missing deployment: err = %v, want ErrDeploymentNotFound", err)
+	}
+
+	broken := cfg
+	broken.Deployment = "broken"
+	if err := Validate(context.Background(), srv.Client(), broken); err == nil || !strings.Contains(err.Error(), "The server had an error") {
+		t.Errorf("server error: err = %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+// Package bench collects build timings from turbo run summaries and
+// compares them across cold-cache and warm-cache runs.
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Benchmark modes.
+const (
+	Cold = "cold"
+	Warm = "warm"
+)
+
+// TaskTiming is one task's execution in one run.
+type TaskTiming struct {
+	Task     string        `json:"task"`
+	Duration time.Duration `json:"-"`
+	Cache    string        `json:"cache"` // HIT or MISS
+}
+
+// MarshalJSON reports the duration in milliseconds.
+func (t TaskTiming) MarshalJSON() ([]byte, error) {
+	type plain TaskTiming
+	return json.Marshal(struct {
+		plain
+		DurationMS int64 `json:"duration_ms"`
+	}{plain(t), t.Duration.Milliseconds()})
+}
+
+// Run is one measured build.
+type Run struct {
+	Mode      string        `json:"mode"`
+	Iteration int           `json:"iteration"`
+	Wall      time.Duration `json:"-"`
+	Tasks     []TaskTiming  `json:"tasks"`
+}
+
+// MarshalJSON reports the wall time in milliseconds.
+func (r Run) MarshalJSON() ([]byte, error) {
+	type plain Run
+	return json.Marshal(struct {
+		plain
+		WallMS int64 `json:"wall_ms"`
+	}{plain(r), r.Wall.Milliseconds()})
+}
+
+// ParseSummary reads the task timings from a turbo run summary
+// (turbo run --summarize, written to .turbo/runs).
+func ParseSummary(r io.Reader) ([]TaskTiming, error) {
+	var s struct {
+		Tasks []struct {
+			TaskID string `json:"taskId"`
+			Cache  struct {
+				Status string `json:"status"`
+			} `json:"cache"`
+			Execution *struct {
+				StartTime int64 `json:"startTime"`
+				EndTime   int64 `json:"endTime"`
+			} `json:"execution"`
+		} `json:"tasks"`
+	}
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid turbo run summary: %w", err)
+	}
+	var out []TaskTiming
+	for _, t := range s.Tasks {
+		tt := TaskTiming{Task: t.TaskID, Cache: strings.ToUpper(t.Cache.Status)}
+		if t.Execution != nil && t.Execution.EndTime >= t.Execution.StartTime {
+			tt.Duration = time.Duration(t.Execution.EndTime-t.Execution.StartTime) * time.Millisecond
+		}
+		out = append(out, tt)
+	}
+	return out, nil
+}
+
+// Stats summarizes durations across iterations.
+type Stats struct {
+	Mean, Min, Max, Stddev time.Duration
+	Runs, CacheHits        int
+}
+
+// MarshalJSON reports durations in milliseconds.
+func (s Stats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		MeanMS    int64 `json:"mean_ms"`
+		MinMS     int64 `json:"min_ms"`
+		MaxMS     int64 `json:"max_ms"`
+		StddevMS  int64 `json:"stddev_ms"`
+		Runs      int   `json:"runs"`
+		CacheHits int   `json:"cache_hits"`
+	}{s.Mean.Milliseconds(), s.Min.Milliseconds(), s.Max.Milliseconds(), s.Stddev.Milliseconds(), s.Runs, s.CacheHits})
+}
+
+func (s *Stats) add(d time.Duration, hit bool) {
+	if s.Runs == 0 || d < s.Min {
+		s.Min = d
+	}
+	if d > s.Max {
+		s.Max = d
+	}
+	s.Runs++
+	if hit {
+		s.CacheHits++
+	}
+}
+
+// Report is the outcome of a benchmark.
+type Report struct {
+	Modes []string                    `json:"modes"`
+	Wall  map[string]Stats            `json:"wall"`
+	Tasks map[string]map[string]Stats `json:"tasks"`
+	Runs  []Run                       `json:"runs"`
+}
+
+// Summarize aggregates runs per mode and per task.
+func Summarize(runs []Run) Report {
+	r := Report{Wall: map[string]Stats{}, Tasks: map[string]map[string]Stats{}, Runs: runs}
+	walls := map[string][]time.Duration{}
+	tasks := map[string]map[string][]time.Duration{}
+	for _, run := range runs {
+		if _, ok := walls[run.Mode]; !ok {
+			r.Modes = append(r.Modes, run.Mode)
+		}
+		walls[run.Mode] = append(walls[run.Mode], run.Wall)
+		w := r.Wall[run.Mode]
+		w.add(run.Wall, false)
+		r.Wall[run.Mode] = w
+
+		for _, t := range run.Tasks {
+			if tasks[t.Task] == nil {
+				tasks[t.Task] = map[string][]time.Duration{}
+				r.Tasks[t.Task] = map[string]Stats{}
+			}
+			tasks[t.Task][run.Mode] = append(tasks[t.Task][run.Mode], t.Duration)
+			s := r.Tasks[t.Task][run.Mode]
+			s.add(t.Duration, t.Cache == "HIT")
+			r.Tasks[t.Task][run.Mode] = s
+		}
+	}
+	for mode, ds := range walls {
+		r.Wall[mode] = withSpread(r.Wall[mode], ds)
+	}
+	for task, modes := range tasks {
+		for mode, ds := range modes {
+			r.Tasks[task][mode] = withSpread(r.Tasks[task][mode], ds)
+		}
+	}
+	return r
+}
+
+func withSpread(s Stats, ds []time.Duration) Stats {
+	var sum float64
+	for _, d := range ds {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(ds))
+	var sq float64
+	for _, d := range ds {
+		sq += (float64(d) - mean) * (float64(d) - mean)
+	}
+	s.Mean = time.Duration(mean)
+	s.Stddev = time.Duration(math.Sqrt(sq / float64(len(ds))))
+	return s
+}
+
+// TaskNames returns the tasks in the report, slowest first by their mean
+// in the first mode.
+func (r Report) TaskNames() []string {
+	names := make([]string, 0, len(r.Tasks))
+	for name := range r.Tasks {
+		names = append(names, name)
+	}
+	first := ""
+	if len(r.Modes) > 0 {
+		first = r.Modes[0]
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := r.Tasks[names[i]][first].Mean, r.Tasks[names[j]][first].Mean
+		if a != b {
+			return a > b
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// WriteTable prints the report. With both modes it compares their means;
+// with one it shows the spread.
+func (r Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	if r.compares() {
+		fmt.Fprintln(tw, "TASK\tCOLD\tWARM\tSPEEDUP\tWARM HITS")
+		for _, name := range r.TaskNames() {
+			c, wm := r.Tasks[name][Cold], r.Tasks[name][Warm]
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d/%d\n", name, Format(c.Mean), Format(wm.Mean), speedup(c.Mean, wm.Mean), wm.CacheHits, wm.Runs)
+		}
+		c, wm := r.Wall[Cold], r.Wall[Warm]
+		fmt.Fprintf(tw, "total (wall)\t%s\t%s\t%s\t\n", Format(c.Mean), Format(wm.Mean), speedup(c.Mean, wm.Mean))
+		return tw.Flush()
+	}
+
+	fmt.Fprintln(tw, "TASK\tMEAN\tMIN\tMAX\tSTDDEV\tCACHE HITS")
+	for _, mode := range r.Modes {
+		for _, name := range r.TaskNames() {
+			s, ok := r.Tasks[name][mode]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d/%d\n", name, Format(s.Mean), Format(s.Min), Format(s.Max), Format(s.Stddev), s.CacheHits, s.Runs)
+		}
+		s := r.Wall[mode]
+		fmt.Fprintf(tw, "total (wall)\t%s\t%s\t%s\t%s\t\n", Format(s.Mean), Format(s.Min), Format(s.Max), Format(s.Stddev))
+	}
+	return tw.Flush()
+}
+
+func (r Report) compares() bool {
+	_, cold := r.Wall[Cold]
+	_, warm := r.Wall[Warm]
+	return cold && warm
+}
+
+func speedup(before, after time.Duration) string {
+	if after <= 0 || before <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fx", float64(before)/float64(after))
+}
+
+// Format renders a duration at a precision suited to build timings.
+func Format(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -0,0 +1,128 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+const summary = `{
+  "id": "2x8",
+  "execution": {"startTime": 1000, "endTime": 9000, "exitCode": 0},
+  "tasks": [
+    {"taskId": "web#build", "cache": {"status": "MISS"}, "execution": {"startTime": 1000, "endTime": 7000, "exitCode": 0}},
+    {"taskId": "@ellie/db#build", "cache": {"status": "HIT"}, "execution": {"startTime": 1000, "endTime": 1050, "exitCode": 0}},
+    {"taskId": "docs#build", "cache": {"status": "MISS"}}
+  ]
+}`
+
+func TestParseSummary(t *testing.T) {
+	tasks, err := ParseSummary(strings.NewReader(summary))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TaskTiming{
+		{"web#build", 6 * time.Second, "MISS"},
+		{"@ellie/db#build", 50 * time.Millisecond, "HIT"},
+		{"docs#build", 0, "MISS"},
+	}
+	if len(tasks) != len(want) {
+		t.Fatalf("got %d tasks, want %d", len(tasks), len(want))
+	}
+	for i := range want {
+		if tasks[i] != want[i] {
+			t.Errorf("task %d = %+v, want %+v", i, tasks[i], want[i])
+		}
+	}
+
+	if _, err := ParseSummary(strings.NewReader("not json")); err == nil {
+		t.Error("ParseSummary should reject invalid input")
+	}
+}
+
+func runs() []Run {
+	return []Run{
+		{Mode: Cold, Iteration: 1, Wall: 10 * time.Second, Tasks: []TaskTiming{{"web#build", 8 * time.Second, "MISS"}, {"db#build", 2 * time.Second, "MISS"}}},
+		{Mode: Cold, Iteration: 2, Wall: 12 * time.Second, Tasks: []TaskTiming{{"web#build", 10 * time.Second, "MISS"}, {"db#build", 2 * time.Second, "MISS"}}},
+		{Mode: Warm, Iteration: 1, Wall: time.Second, Tasks: []TaskTiming{{"web#build", 100 * time.Millisecond, "HIT"}, {"db#build", 50 * time.Millisecond, "HIT"}}},
+		{Mode: Warm, Iteration: 2, Wall: 3 * time.Second, Tasks: []TaskTiming{{"web#build", 2 * time.Second, "MISS"}, {"db#build", 50 * time.Millisecond, "HIT"}}},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	r := Summarize(runs())
+	if got := strings.Join(r.Modes, ","); got != "cold,warm" {
+		t.Errorf("Modes = %s", got)
+	}
+	cold := r.Wall[Cold]
+	if cold.Mean != 11*time.Second || cold.Min != 10*time.Second || cold.Max != 12*time.Second || cold.Stddev != time.Second {
+		t.Errorf("cold wall = %+v", cold)
+	}
+	web := r.Tasks["web#build"][Warm]
+	if web.Mean != 1050*time.Millisecond || web.CacheHits != 1 || web.Runs != 2 {
+		t.Errorf("warm web#build = %+v", web)
+	}
+	if got := strings.Join(r.TaskNames(), ","); got != "web#build,db#build" {
+		t.Errorf("TaskNames = %s", got)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Summarize(runs()).WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"SPEEDUP", "web#build", "9.0s", "1.1s", "8.6x", "1/2", "total (wall)", "5.5x"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("comparison table missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := Summarize(runs()[:2]).WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "STDDEV") || strings.Contains(out, "SPEEDUP") {
+		t.Errorf("single-mode table:\n%s", out)
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	data, err := json.Marshal(Summarize(runs()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Wall map[string]struct {
+			MeanMS int64 `json:"mean_ms"`
+		} `json:"wall"`
+		Runs []struct {
+			WallMS int64 `json:"wall_ms"`
+			Tasks  []struct {
+				DurationMS int64  `json:"duration_ms"`
+				Cache      string `json:"cache"`
+			} `json:"tasks"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Wall[Cold].MeanMS != 11000 || out.Runs[0].WallMS != 10000 || out.Runs[2].Tasks[0].DurationMS != 100 {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		450 * time.Millisecond:   "450ms",
+		12340 * time.Millisecond: "12.3s",
+		125 * time.Second:        "2m05s",
+	} {
+		if got := Format(d); got != want {
+			t.Errorf("Format(%v) = %s, want %s", d, got, want)
+		}
+	}
+}
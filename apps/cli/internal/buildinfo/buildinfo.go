@@ -0,0 +1,120 @@
+// Package buildinfo describes the running ellie binary. Release builds
+// inject the version, commit and build date with -ldflags:
+//
+//	go build -ldflags "-X ellie/apps/cli/internal/buildinfo.version=1.2.0 \
+//	  -X ellie/apps/cli/internal/buildinfo.commit=$(git rev-parse HEAD) \
+//	  -X ellie/apps/cli/internal/buildinfo.date=2026-01-02T15:04:05Z" ./cmd/ellie
+//
+// Anything not injected is filled in from the VCS data the Go toolchain
+// embeds, so plain `go build` and `go install` binaries still report
+// their commit.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// Set via -ldflags -X.
+var (
+	version string
+	commit  string
+	date    string
+	channel string
+)
+
+// DevVersion is reported by binaries built without a version.
+const DevVersion = "dev"
+
+// BuildInfo is the build metadata of the running binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"` // build date, or commit time when not injected
+	Dirty     bool   `json:"dirty,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+	GoVersion string `json:"goVersion"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+var (
+	once sync.Once
+	info BuildInfo
+)
+
+// Get returns the build metadata, resolved once per process.
+func Get() BuildInfo {
+	once.Do(func() {
+		bi, _ := debug.ReadBuildInfo()
+		info = resolve(bi)
+	})
+	return info
+}
+
+func resolve(bi *debug.BuildInfo) BuildInfo {
+	out := BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		Channel:   channel,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	if bi != nil {
+		if out.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			out.Version = strings.TrimPrefix(bi.Main.Version, "v")
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if out.Commit == "" {
+					out.Commit = s.Value
+				}
+			case "vcs.time":
+				if out.Date == "" {
+					out.Date = s.Value
+				}
+			case "vcs.modified":
+				out.Dirty = s.Value == "true"
+			}
+		}
+	}
+	if out.Version == "" {
+		out.Version = DevVersion
+	}
+	return out
+}
+
+// ShortCommit returns the first 12 characters of the commit hash.
+func (b BuildInfo) ShortCommit() string {
+	if len(b.Commit) > 12 {
+		return b.Commit[:12]
+	}
+	return b.Commit
+}
+
+// IsDev reports whether this is an unversioned development build.
+func (b BuildInfo) IsDev() bool {
+	return b.Version == DevVersion
+}
+
+// String returns a one-line description, e.g.
+// "1.2.0 (abc123def456, 2026-01-02T15:04:05Z, go1.26 linux/amd64)".
+func (b BuildInfo) String() string {
+	details := []string{}
+	if c := b.ShortCommit(); c != "" {
+		if b.Dirty {
+			c += "-dirty"
+		}
+		details = append(details, c)
+	}
+	if b.Date != "" {
+		details = append(details, b.Date)
+	}
+	details = append(details, b.GoVersion+" "+b.OS+"/"+b.Arch)
+	return b.Version + " (" + strings.Join(details, ", ") + ")"
+}
@@ -0,0 +1,48 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestResolveFallsBackToVCS(t *testing.T) {
+	bi := &debug.BuildInfo{
+		Main: debug.Module{Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.time", Value: "2026-01-02T15:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	got := resolve(bi)
+	if got.Version != DevVersion || !got.IsDev() {
+		t.Errorf("Version = %q, want %q", got.Version, DevVersion)
+	}
+	if got.Commit != "0123456789abcdef0123" || got.Date != "2026-01-02T15:04:05Z" || !got.Dirty {
+		t.Errorf("VCS fields not used: %+v", got)
+	}
+	if s := got.String(); !strings.HasPrefix(s, "dev (0123456789ab-dirty, 2026-01-02T15:04:05Z, go") {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestResolvePrefersLdflags(t *testing.T) {
+	version, commit = "1.2.0", "feedface"
+	defer func() { version, commit = "", "" }()
+
+	got := resolve(&debug.BuildInfo{
+		Main:     debug.Module{Version: "v0.9.0"},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "0123456789abcdef"}},
+	})
+	if got.Version != "1.2.0" || got.Commit != "feedface" {
+		t.Errorf("got %+v, want injected version and commit", got)
+	}
+}
+
+func TestResolveModuleVersion(t *testing.T) {
+	got := resolve(&debug.BuildInfo{Main: debug.Module{Version: "v0.9.0"}})
+	if got.Version != "0.9.0" {
+		t.Errorf("Version = %q, want 0.9.0", got.Version)
+	}
+}
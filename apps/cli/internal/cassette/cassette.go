@@ -0,0 +1,282 @@
+// Package cassette records HTTP exchanges between the server and a model
+// provider into a file, and plays them back, so prompts and response
+// parsing can be tested deterministically without spending tokens.
+//
+// A cassette is JSON. Credentials and cookies are left out of it, so it
+// can be committed next to the tests that use it.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cassette is a recording of a provider's traffic.
+type Cassette struct {
+	Provider     string        `json:"provider"`
+	Upstream     string        `json:"upstream"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one request and the response to it.
+type Interaction struct {
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	URI    string      `json:"uri"` // path and query
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response. A streamed response is kept whole.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// secretHeaders are never written to a cassette.
+var secretHeaders = []string{"Authorization", "X-Api-Key", "Cookie", "Set-Cookie", "Anthropic-Organization-Id"}
+
+// hopHeaders belong to one connection and aren't forwarded or recorded.
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Connection", "Transfer-Encoding", "Upgrade", "Te", "Trailer"}
+
+// Sanitize returns a copy of h without credentials, cookies and
+// connection headers.
+func Sanitize(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range append(secretHeaders, hopHeaders...) {
+		out.Del(name)
+	}
+	out.Del("Content-Length")
+	out.Del("Accept-Encoding")
+	return out
+}
+
+// Load reads a cassette.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("malformed cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes c to path, replacing it at once so an interrupted save
+// doesn't leave half a cassette.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// key identifies a request for replay: its method, path and query, and
+// its body, with JSON put in a canonical form.
+func key(method, uri, body string) string {
+	var v any
+	if json.Unmarshal([]byte(body), &v) == nil {
+		if canon, err := json.Marshal(v); err == nil {
+			body = string(canon)
+		}
+	}
+	return method + " " + uri + "\n" + body
+}
+
+// Recorder is a proxy that forwards requests to Upstream and records each
+// exchange, saving the cassette at Path after every one.
+type Recorder struct {
+	Upstream string
+	Path     string
+	Client   *http.Client
+
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+// NewRecorder returns a recorder for provider's traffic to upstream. An
+// existing cassette at path is added to.
+func NewRecorder(provider, upstream, path string) (*Recorder, error) {
+	c, err := Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		c, err = &Cassette{Provider: provider, Upstream: upstream}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{Upstream: strings.TrimRight(upstream, "/"), Path: path, Client: &http.Client{}, cassette: c}, nil
+}
+
+// Len returns how many interactions the cassette holds.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cassette.Interactions)
+}
+
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	out, err := http.NewRequestWithContext(req.Context(), req.Method, r.Upstream+req.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	out.Header = req.Header.Clone()
+	for _, name := range hopHeaders {
+		out.Header.Del(name)
+	}
+	// Let the transport negotiate compression, so bodies are recorded
+	// as plain text.
+	out.Header.Del("Accept-Encoding")
+
+	resp, err := r.Client.Do(out)
+	if err != nil {
+		http.Error(w, "upstream: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for name, values := range Sanitize(resp.Header) {
+		w.Header()[name] = values
+	}
+	for _, name := range []string{"Set-Cookie", "Anthropic-Organization-Id"} {
+		if v := resp.Header.Values(name); len(v) > 0 {
+			w.Header()[name] = v
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	// Pass streamed responses on as they arrive.
+	var recorded bytes.Buffer
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			recorded.Write(buf[:n])
+			w.Write(buf[:n])
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:    Request{Method: req.Method, URI: req.URL.RequestURI(), Header: Sanitize(req.Header), Body: string(body)},
+		Response:   Response{Status: resp.StatusCode, Header: Sanitize(resp.Header), Body: recorded.String()},
+		RecordedAt: time.Now().UTC(),
+	})
+	if err := r.cassette.Save(r.Path); err != nil {
+		fmt.Fprintln(os.Stderr, "cannot save cassette:", err)
+	}
+}
+
+// Player serves the responses in a cassette. Each request gets the first
+// recorded response to the same request it hasn't had yet, or the last
+// one once they are used up.
+type Player struct {
+	// Missed is called for a request the cassette has no response to.
+	Missed func(method, uri string)
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+	served   int
+	misses   int
+}
+
+// NewPlayer returns a player for c.
+func NewPlayer(c *Cassette) *Player {
+	return &Player{cassette: c, used: make([]bool, len(c.Interactions))}
+}
+
+// Stats returns how many requests were answered from the cassette and
+// how many were not.
+func (p *Player) Stats() (served, missed int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.served, p.misses
+}
+
+func (p *Player) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	want := key(req.Method, req.URL.RequestURI(), string(body))
+
+	p.mu.Lock()
+	match, last := -1, -1
+	for i, in := range p.cassette.Interactions {
+		if key(in.Request.Method, in.Request.URI, in.Request.Body) != want {
+			continue
+		}
+		last = i
+		if !p.used[i] {
+			match = i
+			break
+		}
+	}
+	if match < 0 {
+		match = last
+	}
+	if match < 0 {
+		p.misses++
+		p.mu.Unlock()
+		if p.Missed != nil {
+			p.Missed(req.Method, req.URL.RequestURI())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{
+			"type":  "error",
+			"error": map[string]string{"type": "cassette_miss", "message": "no recorded response for " + req.Method + " " + req.URL.RequestURI()},
+		})
+		return
+	}
+	p.used[match] = true
+	p.served++
+	resp := p.cassette.Interactions[match].Response
+	p.mu.Unlock()
+
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.Status)
+	io.WriteString(w, resp.Body)
+}
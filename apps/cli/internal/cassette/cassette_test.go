@@ -0,0 +1,107 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func post(t *testing.T, url, body string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest("POST", url+"/v1/messages", strings.NewReader(body))
+	req.Header.Set("X-Api-Key", "sk-secret")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("X-Api-Key") != "sk-secret" {
+			t.Error("the API key was not forwarded")
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "event: reply "+string(body)+"\n\n")
+	}))
+	defer upstream.Close()
+
+	path := filepath.Join(t.TempDir(), "fixtures", "chat.json")
+	rec, err := NewRecorder("anthropic", upstream.URL, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httptest.NewServer(rec)
+	post(t, proxy.URL, `{"model":"m","n":"01"}`)
+	post(t, proxy.URL, `{"model":"m","n":"02"}`)
+	post(t, proxy.URL, `{"model":"m","n":"01"}`)
+	proxy.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"sk-secret", "session=abc"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("the cassette contains %q", secret)
+		}
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Interactions) != 3 || c.Provider != "anthropic" {
+		t.Fatalf("cassette = %+v", c)
+	}
+	player := NewPlayer(c)
+	replay := httptest.NewServer(player)
+	defer replay.Close()
+
+	// Keys are compared as JSON values, so key order doesn't matter.
+	if _, body := post(t, replay.URL, `{"n":"02", "model":"m"}`); body != "event: reply {\"model\":\"m\",\"n\":\"02\"}\n\n" {
+		t.Errorf("replayed %q", body)
+	}
+	for range 3 {
+		if _, body := post(t, replay.URL, `{"model":"m","n":"01"}`); body != "event: reply {\"model\":\"m\",\"n\":\"01\"}\n\n" {
+			t.Errorf("replayed %q", body)
+		}
+	}
+	if code, _ := post(t, replay.URL, `{"model":"m","n":"03"}`); code != http.StatusNotFound {
+		t.Errorf("an unrecorded request got %d", code)
+	}
+	if served, missed := player.Stats(); served != 4 || missed != 1 {
+		t.Errorf("Stats = %d, %d", served, missed)
+	}
+	if calls != 3 {
+		t.Errorf("upstream saw %d requests, want 3", calls)
+	}
+}
+
+func TestPlayerOrder(t *testing.T) {
+	in := func(body string) Interaction {
+		return Interaction{
+			Request:  Request{Method: "POST", URI: "/v1/messages", Body: `{"a":1}`},
+			Response: Response{Status: 200, Body: body},
+		}
+	}
+	replay := httptest.NewServer(NewPlayer(&Cassette{Interactions: []Interaction{in("first"), in("second")}}))
+	defer replay.Close()
+	for _, want := range []string{"first", "second", "second"} {
+		if _, got := post(t, replay.URL, `{"a":1}`); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
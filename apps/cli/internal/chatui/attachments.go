@@ -1,10 +1,13 @@
 package chatui
 
 import (
+	"fmt"
 	"mime"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"ellie/apps/cli/internal/termimage"
 )
 
 // extraMimeTypes maps file extensions not covered by Go's mime package
@@ -227,13 +230,34 @@ func newPendingAttachment(filePath string) PendingAttachment {
 		size = info.Size()
 	}
 
-	return PendingAttachment{
+	a := PendingAttachment{
 		FilePath: absPath,
 		Name:     name,
 		Mime:     m,
 		Size:     size,
 		Category: cat,
 	}
+	if cat == "image" {
+		if f, err := os.Open(absPath); err == nil {
+			if info, err := termimage.Describe(f); err == nil {
+				a.Image = info.String()
+			}
+			f.Close()
+		}
+	}
+	return a
+}
+
+// AttachFile prepares the file at path to be sent with a prompt.
+func AttachFile(path string) (PendingAttachment, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return PendingAttachment{}, err
+	}
+	if info.IsDir() {
+		return PendingAttachment{}, fmt.Errorf("%s is a directory", path)
+	}
+	return newPendingAttachment(path), nil
 }
 
 // attachmentPillLabel returns the display label for an attachment pill.
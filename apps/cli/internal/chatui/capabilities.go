@@ -0,0 +1,233 @@
+package chatui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"ellie/apps/cli/internal/paths"
+)
+
+// capabilitiesTTL is how long cached model capabilities are trusted.
+const capabilitiesTTL = time.Hour
+
+// ErrCapabilitiesUnavailable means the server doesn't expose model
+// capabilities; callers should skip client-side validation.
+var ErrCapabilitiesUnavailable = errors.New("server does not report model capabilities")
+
+// ModelCapabilities describes the limits of the model the server will use
+// for the next request, as reported by GET /api/models/current.
+type ModelCapabilities struct {
+	ID               string   `json:"id"`
+	Provider         string   `json:"provider"`
+	ContextWindow    int      `json:"contextWindow"`
+	MaxOutputTokens  int      `json:"maxOutputTokens"`
+	SupportsThinking bool     `json:"supportsThinking"`
+	SupportsVision   bool     `json:"supportsVision"`
+	AttachmentMimes  []string `json:"attachmentMimes,omitempty"` // extra accepted MIME prefixes, e.g. "application/pdf"
+
+	// limitsOnly marks capabilities taken from the model list, which
+	// gives the limits but not what the model accepts; those aren't
+	// checked.
+	limitsOnly bool
+}
+
+// RequestParams are the parts of an outgoing request that depend on
+// model capabilities.
+type RequestParams struct {
+	Prompt      string
+	MaxTokens   int
+	Thinking    bool
+	Attachments []PendingAttachment
+}
+
+// Validate checks p against the model's limits and returns a message
+// naming the exact parameter and limit that would be exceeded.
+func (c ModelCapabilities) Validate(p RequestParams) error {
+	name := c.ID
+	if name == "" {
+		name = "the current model"
+	}
+
+	if c.MaxOutputTokens > 0 && p.MaxTokens > c.MaxOutputTokens {
+		return fmt.Errorf("max tokens %d exceeds %s's output limit of %d", p.MaxTokens, name, c.MaxOutputTokens)
+	}
+	// Rough estimate (~4 chars per token) — only catches prompts that
+	// can't possibly fit.
+	if est := len(p.Prompt) / 4; c.ContextWindow > 0 && est > c.ContextWindow {
+		return fmt.Errorf("prompt is ~%d tokens, over %s's context window of %d", est, name, c.ContextWindow)
+	}
+	if c.limitsOnly {
+		return nil
+	}
+	if p.Thinking && !c.SupportsThinking {
+		return fmt.Errorf("%s does not support extended thinking", name)
+	}
+	for _, a := range p.Attachments {
+		switch a.Category {
+		case "image":
+			if !c.SupportsVision {
+				return fmt.Errorf("%s does not accept images (%s)", name, a.Name)
+			}
+		case "text":
+			// Inlined as text by the server; always accepted.
+		default:
+			if !c.acceptsMime(a.Mime) {
+				return fmt.Errorf("%s does not accept %s attachments (%s)", name, a.Mime, a.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (c ModelCapabilities) acceptsMime(mime string) bool {
+	for _, prefix := range c.AttachmentMimes {
+		if strings.HasPrefix(mime, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestCapabilities returns what a request for model is checked
+// against: the server's current model, from cache, when model is "",
+// else the limits GET /api/models lists for model. It returns nil when
+// there is nothing to check against.
+func requestCapabilities(ctx context.Context, client *HTTPClient, cache *CapabilitiesCache, model string) *ModelCapabilities {
+	if model == "" {
+		if cache == nil {
+			return nil
+		}
+		caps, _ := cache.Get(ctx, client)
+		return caps
+	}
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		return nil
+	}
+	for _, m := range models {
+		if m.Matches(model) {
+			return &ModelCapabilities{ID: m.ID, Provider: m.Provider, ContextWindow: m.ContextWindow, MaxOutputTokens: m.MaxOutputTokens, limitsOnly: true}
+		}
+	}
+	return nil
+}
+
+// GetModelCapabilities fetches GET /api/models/current. It returns
+// ErrCapabilitiesUnavailable when the server has no such endpoint.
+func (c *HTTPClient) GetModelCapabilities(ctx context.Context) (*ModelCapabilities, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/models/current", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create capabilities request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("capabilities request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrCapabilitiesUnavailable
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("capabilities returned %d", resp.StatusCode)
+	}
+	var out ModelCapabilities
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode capabilities: %w", err)
+	}
+	return &out, nil
+}
+
+// ─── Cache ────────────────────────────────────────────────────────
+
+type cachedCapabilities struct {
+	FetchedAt    time.Time          `json:"fetchedAt"`
+	Capabilities *ModelCapabilities `json:"capabilities"`
+}
+
+// CapabilitiesCache keeps fetched capabilities on disk per server so
+// every prompt doesn't cost an extra round trip.
+type CapabilitiesCache struct {
+	path string
+	now  func() time.Time
+}
+
+// NewCapabilitiesCache returns a cache stored at path.
+func NewCapabilitiesCache(path string) *CapabilitiesCache {
+	return &CapabilitiesCache{path: path, now: time.Now}
+}
+
+// DefaultCapabilitiesCachePath returns the cache file under the CLI cache dir.
+func DefaultCapabilitiesCachePath() (string, error) {
+	dir, err := paths.Cache()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "model-capabilities.json"), nil
+}
+
+// Get returns cached capabilities for the client's server, fetching and
+// storing them when missing or stale. Cache write failures are ignored.
+func (cc *CapabilitiesCache) Get(ctx context.Context, client *HTTPClient) (*ModelCapabilities, error) {
+	entries := map[string]cachedCapabilities{}
+	_ = readJSON(cc.path, &entries)
+
+	if e, ok := entries[client.baseURL]; ok && e.Capabilities != nil && cc.now().Sub(e.FetchedAt) < capabilitiesTTL {
+		return e.Capabilities, nil
+	}
+
+	caps, err := client.GetModelCapabilities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries[client.baseURL] = cachedCapabilities{FetchedAt: cc.now(), Capabilities: caps}
+	_ = writeJSON(cc.path, entries)
+	return caps, nil
+}
+
+// Invalidate drops the cached entry for baseURL, e.g. after the server
+// rejected a request the cached limits allowed.
+func (cc *CapabilitiesCache) Invalidate(baseURL string) {
+	entries := map[string]cachedCapabilities{}
+	if err := readJSON(cc.path, &entries); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			_ = os.Remove(cc.path)
+		}
+		return
+	}
+	delete(entries, baseURL)
+	_ = writeJSON(cc.path, entries)
+}
+
+// ─── TUI integration ──────────────────────────────────────────────
+
+type capabilitiesLoadedMsg struct {
+	caps *ModelCapabilities
+}
+
+func (m Model) loadCapabilities() tea.Cmd {
+	if m.capsCache == nil && m.requestModel == "" {
+		return nil
+	}
+	cache, client, model := m.capsCache, m.httpClient, m.requestModel
+	return func() tea.Msg {
+		return capabilitiesLoadedMsg{caps: requestCapabilities(context.Background(), client, cache, model)}
+	}
+}
+
+// validateRequest checks the pending message against the model's limits.
+// Without capabilities everything passes and the server decides.
+func (m Model) validateRequest(text string) error {
+	if m.capabilities == nil {
+		return nil
+	}
+	return m.capabilities.Validate(RequestParams{Prompt: text, Attachments: m.attachments})
+}
@@ -0,0 +1,140 @@
+package chatui
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModelCapabilities_Validate(t *testing.T) {
+	caps := ModelCapabilities{
+		ID:              "claude-test",
+		ContextWindow:   100,
+		MaxOutputTokens: 4096,
+		AttachmentMimes: []string{"application/pdf"},
+	}
+
+	cases := []struct {
+		name    string
+		params  RequestParams
+		wantErr string
+	}{
+		{"ok", RequestParams{Prompt: "hi", MaxTokens: 1024}, ""},
+		{"max tokens", RequestParams{MaxTokens: 8192}, "output limit of 4096"},
+		{"thinking", RequestParams{Thinking: true}, "extended thinking"},
+		{"context", RequestParams{Prompt: strings.Repeat("x", 800)}, "context window of 100"},
+		{"image", RequestParams{Attachments: []PendingAttachment{{Name: "a.png", Mime: "image/png", Category: "image"}}}, "does not accept images (a.png)"},
+		{"pdf", RequestParams{Attachments: []PendingAttachment{{Name: "a.pdf", Mime: "application/pdf", Category: "file"}}}, ""},
+		{"zip", RequestParams{Attachments: []PendingAttachment{{Name: "a.zip", Mime: "application/zip", Category: "file"}}}, "application/zip attachments"},
+	}
+	for _, tc := range cases {
+		err := caps.Validate(tc.params)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: got %v, want error containing %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestCapabilitiesCache(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte(`{"id":"claude-test","supportsVision":true}`))
+	}))
+	defer srv.Close()
+
+	cache := NewCapabilitiesCache(filepath.Join(t.TempDir(), "caps.json"))
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	client := NewHTTPClient(srv.URL)
+
+	for range 2 {
+		caps, err := cache.Get(context.Background(), client)
+		if err != nil || caps.ID != "claude-test" || !caps.SupportsVision {
+			t.Fatalf("Get = %+v, %v", caps, err)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("expected one fetch while fresh, got %d", hits)
+	}
+
+	now = now.Add(capabilitiesTTL + time.Minute)
+	_, _ = cache.Get(context.Background(), client)
+	if hits != 2 {
+		t.Errorf("expected refetch after TTL, got %d fetches", hits)
+	}
+
+	cache.Invalidate(srv.URL)
+	_, _ = cache.Get(context.Background(), client)
+	if hits != 3 {
+		t.Errorf("expected refetch after invalidate, got %d fetches", hits)
+	}
+}
+
+func TestCapabilitiesUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := NewHTTPClient(srv.URL).GetModelCapabilities(context.Background())
+	if !errors.Is(err, ErrCapabilitiesUnavailable) {
+		t.Errorf("got %v, want ErrCapabilitiesUnavailable", err)
+	}
+}
+
+func TestRequestCapabilities(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/models/current":
+			w.Write([]byte(`{"id":"text-only","contextWindow":100000}`))
+		case "/api/models":
+			w.Write([]byte(`[{"id":"text-only","provider":"acme","contextWindow":100000},{"id":"vision-small","provider":"acme","contextWindow":10,"maxOutputTokens":512}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient(srv.URL)
+	cache := NewCapabilitiesCache(filepath.Join(t.TempDir(), "caps.json"))
+	image := RequestParams{Attachments: []PendingAttachment{{Name: "a.png", Mime: "image/png", Category: "image"}}}
+
+	caps := requestCapabilities(context.Background(), client, cache, "")
+	if caps == nil || caps.ID != "text-only" {
+		t.Fatalf("current model: got %+v", caps)
+	}
+	if err := caps.Validate(image); err == nil {
+		t.Error("current model accepted an image it doesn't support")
+	}
+
+	caps = requestCapabilities(context.Background(), client, cache, "acme/vision-small")
+	if caps == nil || caps.ID != "vision-small" {
+		t.Fatalf("requested model: got %+v", caps)
+	}
+	if err := caps.Validate(image); err != nil {
+		t.Errorf("requested model refused an image on the current model's limits: %v", err)
+	}
+	if err := caps.Validate(RequestParams{Prompt: strings.Repeat("x", 100)}); err == nil || !strings.Contains(err.Error(), "context window of 10") {
+		t.Errorf("got %v, want the requested model's context window", err)
+	}
+	if err := caps.Validate(RequestParams{MaxTokens: 1024}); err == nil || !strings.Contains(err.Error(), "output limit of 512") {
+		t.Errorf("got %v, want the requested model's output limit", err)
+	}
+	if err := caps.Validate(RequestParams{Thinking: true}); err != nil {
+		t.Errorf("thinking refused on a model the list doesn't describe: %v", err)
+	}
+
+	if caps := requestCapabilities(context.Background(), client, cache, "unlisted"); caps != nil {
+		t.Errorf("unlisted model: got %+v, want nil", caps)
+	}
+}
@@ -12,8 +12,18 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"ellie/apps/cli/pkg/ellieapi"
 )
 
+// Transport carries every request chatui makes to the server. The CLI
+// sets it to attach its login session; nil uses http.DefaultTransport.
+var Transport http.RoundTripper
+
+// RequestTimeout bounds each REST call made by an HTTPClient created
+// afterwards. Uploads and the SSE stream are bounded by their context.
+var RequestTimeout = 10 * time.Second
+
 // HTTPClient handles REST API calls to the Ellie server.
 type HTTPClient struct {
 	baseURL      string
@@ -25,8 +35,8 @@ type HTTPClient struct {
 func NewHTTPClient(baseURL string) *HTTPClient {
 	return &HTTPClient{
 		baseURL:      baseURL,
-		client:       &http.Client{Timeout: 10 * time.Second},
-		uploadClient: &http.Client{},
+		client:       &http.Client{Timeout: RequestTimeout, Transport: Transport},
+		uploadClient: &http.Client{Transport: Transport},
 	}
 }
 
@@ -72,6 +82,35 @@ func (c *HTTPClient) ListThreads(ctx context.Context) ([]ThreadEntry, error) {
 	return out, nil
 }
 
+// CreateAssistantThread creates a separate assistant conversation via
+// POST /api/threads, for prompts that shouldn't see each other.
+func (c *HTTPClient) CreateAssistantThread(ctx context.Context, title string) (*CreatedThread, error) {
+	body, _ := json.Marshal(map[string]string{
+		"agentId":     "assistant",
+		"agentType":   "assistant",
+		"workspaceId": "main",
+		"title":       title,
+	})
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/threads", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create thread request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("create thread failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("create thread: %w", ellieapi.ResponseError(resp))
+	}
+	var out CreatedThread
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode created thread: %w", err)
+	}
+	return &out, nil
+}
+
 // GetAssistantCurrent fetches GET /api/assistant/current (resolves to the current assistant thread+branch).
 func (c *HTTPClient) GetAssistantCurrent(ctx context.Context) (*AssistantCurrent, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/assistant/current", nil)
@@ -84,8 +123,7 @@ func (c *HTTPClient) GetAssistantCurrent(ctx context.Context) (*AssistantCurrent
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("get assistant current returned %d: %s", resp.StatusCode, body)
+		return nil, fmt.Errorf("get assistant current: %w", ellieapi.ResponseError(resp))
 	}
 	var out AssistantCurrent
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
@@ -94,14 +132,44 @@ func (c *HTTPClient) GetAssistantCurrent(ctx context.Context) (*AssistantCurrent
 	return &out, nil
 }
 
+// MessageOptions are optional fields of a message. A server that doesn't
+// know one ignores it.
+type MessageOptions struct {
+	// Model asks for the model to answer the message.
+	Model string
+	// System is a system prompt for the answer to the message.
+	System string
+	// MaxTokens caps the length of the answer; 0 leaves it to the server.
+	MaxTokens int
+	// Thinking asks for extended thinking before the answer.
+	Thinking bool
+}
+
 // SendMessage posts a user message to the given branch, optionally with attachments.
 func (c *HTTPClient) SendMessage(ctx context.Context, branchID, content string, attachments []AttachmentResult) error {
+	return c.SendMessageWithOptions(ctx, branchID, content, attachments, MessageOptions{})
+}
+
+// SendMessageWithOptions is SendMessage with the optional fields in opts.
+func (c *HTTPClient) SendMessageWithOptions(ctx context.Context, branchID, content string, attachments []AttachmentResult, opts MessageOptions) error {
 	payload := map[string]interface{}{
 		"content": content,
 	}
 	if len(attachments) > 0 {
 		payload["attachments"] = attachments
 	}
+	if opts.Model != "" {
+		payload["model"] = opts.Model
+	}
+	if opts.System != "" {
+		payload["system"] = opts.System
+	}
+	if opts.MaxTokens > 0 {
+		payload["maxTokens"] = opts.MaxTokens
+	}
+	if opts.Thinking {
+		payload["thinking"] = true
+	}
 	body, _ := json.Marshal(payload)
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat/branches/"+branchID+"/messages", bytes.NewReader(body))
 	if err != nil {
@@ -114,8 +182,7 @@ func (c *HTTPClient) SendMessage(ctx context.Context, branchID, content string,
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("send message returned %d: %s", resp.StatusCode, respBody)
+		return fmt.Errorf("send message: %w", ellieapi.ResponseError(resp))
 	}
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -285,3 +286,66 @@ func TestSSEClient_ResetRetry(t *testing.T) {
 		t.Errorf("expected 0 attempts after reset, got %d", client.reconnectAttempts)
 	}
 }
+
+func TestSSEReadEvents_ResumesAfterDrop(t *testing.T) {
+	row := func(seq int) EventRow {
+		return EventRow{ID: seq, Seq: seq, Type: "user_message", Payload: mustJSON(map[string]interface{}{"content": "x"})}
+	}
+	var conns int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conns++
+		w.Header().Set("Content-Type", "text/event-stream")
+		if conns == 1 {
+			snap, _ := json.Marshal([]EventRow{row(1)})
+			appended, _ := json.Marshal(row(2))
+			fmt.Fprintf(w, "retry:1\nevent:snapshot\ndata:%s\n\nevent:append\ndata:%s\n\n", snap, appended)
+			return // the connection drops
+		}
+		snap, _ := json.Marshal([]EventRow{row(1), row(2), row(3)})
+		fmt.Fprintf(w, "event:snapshot\ndata:%s\n\n", snap)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	ch := make(chan sseEvent, 16)
+	go sseReadEvents(ctx, srv.URL, "b1", ch)
+
+	var got []string
+	for ev := range ch {
+		if ev.Err != nil {
+			t.Fatal(ev.Err)
+		}
+		if ev.Kind == "snapshot" {
+			got = append(got, fmt.Sprintf("snapshot(%d)", len(ev.Rows)))
+		} else {
+			got = append(got, fmt.Sprintf("%s(%d)", ev.Kind, ev.Row.Seq))
+		}
+		if len(got) == 5 {
+			cancel()
+		}
+	}
+	want := "[snapshot(1) append(2) update(1) update(2) append(3)]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("events = %v, want %s", got, want)
+	}
+}
+
+func TestTextStream(t *testing.T) {
+	msg := func(id int, text string) EventRow {
+		return EventRow{ID: id, Seq: id, Type: "assistant_message", Payload: mustJSON(map[string]interface{}{"message": map[string]interface{}{"content": text}})}
+	}
+	var got strings.Builder
+	s := textStream{fn: func(text string) { got.WriteString(text) }}
+	for _, row := range []EventRow{
+		msg(1, "Let me"), msg(1, "Let me look."), msg(1, "Let me look."),
+		msg(2, ""), msg(2, "Found it"), msg(1, "Rewritten"), msg(2, "Found it: a typo."),
+	} {
+		s.update(row)
+	}
+	if want := "Let me look.\n\nFound it: a typo."; got.String() != want {
+		t.Errorf("streamed %q, want %q", got.String(), want)
+	}
+}
@@ -0,0 +1,217 @@
+package chatui
+
+import (
+	"context"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+
+	"ellie/apps/cli/internal/extract"
+)
+
+// continuationMarker starts every prompt that asks the model to continue
+// a truncated answer, so the TUI can recognize it, hide it and show the
+// two halves as one message.
+const continuationMarker = "[continue]"
+
+// MaxContinuations caps how many times a single answer is continued.
+const MaxContinuations = 5
+
+// minStitchOverlap is the shortest repeated text treated as the model
+// restating where it left off rather than a coincidence.
+const minStitchOverlap = 8
+
+// IsTruncated reports whether a stop reason means the answer was cut off
+// at the output token limit.
+func IsTruncated(stopReason string) bool {
+	return stopReason == "length" || stopReason == "max_tokens"
+}
+
+// IsContinuationPrompt reports whether text was built by ContinuationPrompt.
+func IsContinuationPrompt(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), continuationMarker)
+}
+
+// ContinuationPrompt asks the model to pick up exactly where partial
+// stopped. When partial ends inside a code block the model is told not to
+// open a new one, so the stitched answer keeps a single fence.
+func ContinuationPrompt(partial string) string {
+	var b strings.Builder
+	b.WriteString(continuationMarker)
+	b.WriteString(" Your previous answer was cut off at the output limit. ")
+	b.WriteString("Continue exactly where it stopped — do not repeat any earlier text, do not summarize, and do not add an introduction.")
+	if open, lang := extract.OpenFence(partial); open {
+		b.WriteString(" It stopped inside a ")
+		if lang != "" {
+			b.WriteString(lang + " ")
+		}
+		b.WriteString("code block: continue the code directly without starting a new ``` fence, and close the block when the code is done.")
+	}
+	return b.String()
+}
+
+// StitchContinuation joins a truncated answer and its continuation. It
+// drops a code fence the continuation re-opens while the first part is
+// still inside one, and any text the continuation repeats from the end of
+// the first part.
+func StitchContinuation(prev, next string) string {
+	reopened := false
+	if open, _ := extract.OpenFence(prev); open {
+		stripped := dropLeadingFence(next)
+		reopened = stripped != next
+		next = stripped
+	}
+	if strings.HasSuffix(prev, "\n") {
+		next = strings.TrimLeft(next, "\n")
+	}
+	if n := stitchOverlap(prev, next); n > 0 {
+		return prev + next[n:]
+	}
+	// Code after a re-opened fence starts on a fresh line.
+	if reopened && !strings.HasSuffix(prev, "\n") {
+		return prev + "\n" + next
+	}
+	return prev + next
+}
+
+// dropLeadingFence removes an opening fence line from the start of text.
+func dropLeadingFence(text string) string {
+	rest := strings.TrimLeft(text, "\n")
+	line, after, _ := strings.Cut(rest, "\n")
+	if extract.FenceMarker(strings.TrimSpace(line)) == "" {
+		return text
+	}
+	return after
+}
+
+// stitchOverlap returns how many leading bytes of next repeat the end of
+// prev.
+func stitchOverlap(prev, next string) int {
+	limit := min(len(prev), len(next), 400)
+	for n := limit; n >= minStitchOverlap; n-- {
+		if strings.HasSuffix(prev, next[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// ─── One-shot ─────────────────────────────────────────────────────
+
+// ContinueOneShot keeps asking for more while result was cut off at the
+// token limit, up to MaxContinuations times, and returns the stitched
+// answer with token counts and cost summed over every round.
+func ContinueOneShot(ctx context.Context, cfg OneShotConfig, result *OneShotResult) (*OneShotResult, error) {
+	out := *result
+	for out.Continuations < MaxContinuations && IsTruncated(out.StopReason) && out.Error == "" {
+		next, err := RunOneShot(ctx, cfg, ContinuationPrompt(out.Content))
+		if err != nil {
+			return &out, err
+		}
+		out.Content = StitchContinuation(out.Content, next.Content)
+		out.PromptTokens += next.PromptTokens
+		out.CompletionTokens += next.CompletionTokens
+		out.TotalCost += next.TotalCost
+		out.Images = append(out.Images, next.Images...)
+		out.StopReason = next.StopReason
+		out.Error = next.Error
+		out.Continuations++
+	}
+	return &out, nil
+}
+
+// ─── TUI integration ──────────────────────────────────────────────
+
+// stitchContinuations folds continuation prompts and the replies to them
+// into the truncated message they continue, so a continued answer renders
+// as one message. The streaming message is folded in too when it is such
+// a reply; the returned streaming message is nil in that case.
+func stitchContinuations(msgs []StoredMessage, streaming *StoredMessage) ([]StoredMessage, *StoredMessage) {
+	out := make([]StoredMessage, 0, len(msgs))
+	awaiting := false // a continuation prompt follows the last message in out
+
+	for _, msg := range msgs {
+		last := len(out) - 1
+		switch {
+		case msg.Sender == SenderUser && IsContinuationPrompt(msg.Text) && last >= 0 &&
+			out[last].EventType == "assistant_message" && IsTruncated(out[last].StopReason):
+			awaiting = true
+			continue
+		case awaiting && msg.EventType == "assistant_message":
+			out[last] = mergeContinuation(out[last], msg)
+			awaiting = false
+			continue
+		}
+		awaiting = false
+		out = append(out, msg)
+	}
+
+	if awaiting && streaming != nil && streaming.EventType == "assistant_message" {
+		merged := mergeContinuation(out[len(out)-1], *streaming)
+		out = out[:len(out)-1]
+		return out, &merged
+	}
+	return out, streaming
+}
+
+// mergeContinuation appends next's content to prev, stitching the last
+// text part of prev to the first text part of next.
+func mergeContinuation(prev, next StoredMessage) StoredMessage {
+	merged := prev
+	merged.Text = StitchContinuation(prev.Text, next.Text)
+	merged.StopReason = next.StopReason
+	merged.IsStreaming = next.IsStreaming
+	merged.continuedBy = next.ID
+
+	merged.Parts = append([]ContentPart(nil), prev.Parts...)
+	lastText := -1
+	for i, p := range merged.Parts {
+		if p.Type == PartText {
+			lastText = i
+		}
+	}
+	for _, p := range next.Parts {
+		if p.Type == PartText && lastText >= 0 {
+			merged.Parts[lastText].Text = StitchContinuation(merged.Parts[lastText].Text, p.Text)
+			lastText = -1 // only the first text part continues the old one
+			continue
+		}
+		merged.Parts = append(merged.Parts, p)
+	}
+	return merged
+}
+
+// truncatedReply returns the last assistant message when it was cut off
+// and nothing has been sent since.
+func (m Model) truncatedReply() (StoredMessage, bool) {
+	if m.isAgentRunning || m.streamingMsg != nil {
+		return StoredMessage{}, false
+	}
+	msgs, _ := stitchContinuations(m.messages, nil)
+	for i := len(msgs) - 1; i >= 0; i-- {
+		msg := msgs[i]
+		if msg.EventType == "assistant_message" {
+			return msg, IsTruncated(msg.StopReason)
+		}
+		if msg.Sender == SenderUser {
+			return StoredMessage{}, false
+		}
+	}
+	return StoredMessage{}, false
+}
+
+// continueAnswer asks the agent to continue the last answer if it was
+// cut off.
+func (m Model) continueAnswer() (tea.Model, tea.Cmd) {
+	reply, ok := m.truncatedReply()
+	if !ok {
+		m.notice = "nothing to continue — the last answer was not cut off"
+		return m, nil
+	}
+	if m.connState != StateConnected {
+		return m, nil
+	}
+	m.notice = ""
+	m.autoScroll = true
+	return m, m.sendMessage(&outgoing{prompt: ContinuationPrompt(reply.Text)})
+}
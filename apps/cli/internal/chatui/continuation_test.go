@@ -0,0 +1,84 @@
+package chatui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStitchContinuation(t *testing.T) {
+	tests := []struct {
+		name, prev, next, want string
+	}{
+		{
+			name: "plain text mid-word",
+			prev: "The quick brown fo",
+			next: "x jumps over the lazy dog.",
+			want: "The quick brown fox jumps over the lazy dog.",
+		},
+		{
+			name: "reopened code fence is dropped",
+			prev: "Here:\n\n```go\nfunc main() {\n",
+			next: "```go\n\tfmt.Println(1)\n}\n```\n",
+			want: "Here:\n\n```go\nfunc main() {\n\tfmt.Println(1)\n}\n```\n",
+		},
+		{
+			name: "repeated tail is removed",
+			prev: "step one\nstep two is to run the",
+			next: "step two is to run the tests.",
+			want: "step one\nstep two is to run the tests.",
+		},
+		{
+			name: "closed fence keeps a new fence",
+			prev: "```sh\nls\n```\n",
+			next: "```sh\npwd\n```",
+			want: "```sh\nls\n```\n```sh\npwd\n```",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StitchContinuation(tt.prev, tt.next); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContinuationPromptMentionsOpenBlock(t *testing.T) {
+	p := ContinuationPrompt("text\n```python\ndef f():\n")
+	if !IsContinuationPrompt(p) || !strings.Contains(p, "python code block") {
+		t.Errorf("prompt = %q", p)
+	}
+	if p := ContinuationPrompt("```\nx\n```\ndone"); strings.Contains(p, "code block") {
+		t.Errorf("closed block reported as open: %q", p)
+	}
+}
+
+func TestStitchContinuationsMergesMessages(t *testing.T) {
+	msgs := []StoredMessage{
+		{ID: "1", Sender: SenderUser, EventType: "user_message", Text: "write it"},
+		{ID: "2", Sender: SenderAgent, EventType: "assistant_message", StopReason: "length",
+			Text: "```go\nfunc a() {", Parts: []ContentPart{{Type: PartText, Text: "```go\nfunc a() {"}}},
+		{ID: "3", Sender: SenderUser, EventType: "user_message", Text: ContinuationPrompt("```go\nfunc a() {")},
+	}
+	streaming := &StoredMessage{ID: "4", Sender: SenderAgent, EventType: "assistant_message", IsStreaming: true,
+		Text: "```go\n}\n```", Parts: []ContentPart{{Type: PartText, Text: "```go\n}\n```"}}}
+
+	out, stream := stitchContinuations(msgs, streaming)
+	if len(out) != 1 || out[0].ID != "1" {
+		t.Fatalf("out = %+v", out)
+	}
+	if stream == nil || stream.ID != "2" || !stream.IsStreaming {
+		t.Fatalf("streaming continuation not folded into message 2: %+v", stream)
+	}
+	if want := "```go\nfunc a() {\n}\n```"; stream.Parts[0].Text != want {
+		t.Errorf("stitched text = %q, want %q", stream.Parts[0].Text, want)
+	}
+
+	// Once finished, the reply folds into the truncated message.
+	done := *streaming
+	done.IsStreaming, done.StopReason = false, "stop"
+	out, _ = stitchContinuations(append(msgs, done), nil)
+	if len(out) != 2 || out[1].ID != "2" || out[1].StopReason != "stop" || out[1].continuedBy != "4" {
+		t.Errorf("out = %+v", out)
+	}
+}
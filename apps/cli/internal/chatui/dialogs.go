@@ -26,6 +26,8 @@ const (
 	ActionClearBranch
 	ActionSelectCommand
 	ActionRetry
+	ActionRestoreDraft
+	ActionDeleteDraft
 )
 
 // dialogStyles holds shared dialog styling — rebuilt by rebuildDialogStyles() on theme change.
@@ -357,6 +359,119 @@ func (d *ClearConfirmDialog) View(width, height int) string {
 	return dialogBorder.Width(maxW).Render(b.String())
 }
 
+// ─── Drafts Dialog ────────────────────────────────────────────────
+
+// DraftsDialog lists saved drafts so unsent messages can be recovered.
+type DraftsDialog struct {
+	drafts    []Draft
+	currentID string
+	cursor    int
+	loading   bool
+	selected  *Draft
+}
+
+// NewDraftsDialog creates a drafts browser.
+func NewDraftsDialog(currentID string) *DraftsDialog {
+	return &DraftsDialog{
+		currentID: currentID,
+		loading:   true,
+	}
+}
+
+// SetDrafts updates the dialog with loaded drafts.
+func (d *DraftsDialog) SetDrafts(drafts []Draft) {
+	d.drafts = drafts
+	d.loading = false
+	if d.cursor >= len(drafts) {
+		d.cursor = max(0, len(drafts)-1)
+	}
+}
+
+func (d *DraftsDialog) Update(msg tea.Msg, keys KeyMap) (Dialog, DialogAction) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return d, ActionNone
+	}
+
+	switch {
+	case key.Matches(km, keys.Escape):
+		return nil, ActionClose
+	case key.Matches(km, keys.Editor.Send):
+		if d.cursor < len(d.drafts) {
+			d.selected = &d.drafts[d.cursor]
+			return nil, ActionRestoreDraft
+		}
+	case key.Matches(km, keys.Attachments.Remove):
+		if d.cursor < len(d.drafts) {
+			d.selected = &d.drafts[d.cursor]
+			return d, ActionDeleteDraft
+		}
+	case key.Matches(km, keys.Chat.Up):
+		if d.cursor > 0 {
+			d.cursor--
+		}
+	case key.Matches(km, keys.Chat.Down):
+		if d.cursor < len(d.drafts)-1 {
+			d.cursor++
+		}
+	}
+	return d, ActionNone
+}
+
+func (d *DraftsDialog) View(width, height int) string {
+	var b strings.Builder
+	b.WriteString(dialogTitle.Render("Drafts"))
+	b.WriteString("\n\n")
+
+	maxW := clampDialogWidth(width, 80)
+	if d.loading {
+		b.WriteString(dialogDim.Render("  Loading..."))
+		return dialogBorder.Width(maxW).Render(b.String())
+	}
+	if len(d.drafts) == 0 {
+		b.WriteString(dialogDim.Render("  No saved drafts"))
+		return dialogBorder.Width(maxW).Render(b.String())
+	}
+
+	// Border and padding (6), prefix (2), timestamp and gap (14), marker (~12).
+	previewW := max(10, maxW-34)
+	for i, dr := range d.drafts {
+		prefix := "  "
+		if i == d.cursor {
+			prefix = dialogHighlight.Render("> ")
+		}
+
+		when := dr.UpdatedAt.Local().Format("Jan 02 15:04")
+		preview := strings.Join(strings.Fields(dr.Text), " ")
+		if len([]rune(preview)) > previewW {
+			preview = string([]rune(preview)[:previewW-1]) + "…"
+		}
+		if i == d.cursor {
+			preview = dialogHighlight.Render(preview)
+		}
+
+		marker := ""
+		switch {
+		case dr.Archived:
+			marker = dialogDim.Render(" (discarded)")
+		case dr.BranchID == d.currentID:
+			marker = dialogHighlight.Render(" (current)")
+		default:
+			marker = dialogDim.Render(" " + truncateID(dr.BranchID))
+		}
+		b.WriteString(fmt.Sprintf("%s%s  %s%s\n", prefix, dialogDim.Render(when), preview, marker))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dialogDim.Render("enter restore · del delete · esc close"))
+	return dialogBorder.Width(maxW).Render(b.String())
+}
+
+// Selected returns the draft picked by the user, if any.
+func (d *DraftsDialog) Selected() *Draft {
+	return d.selected
+}
+
 // ─── Helpers ──────────────────────────────────────────────────────
 
 // clampDialogWidth returns a safe dialog width: at least 10, at most limit,
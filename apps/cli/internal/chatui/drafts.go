@@ -0,0 +1,278 @@
+package chatui
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"ellie/apps/cli/internal/paths"
+)
+
+// draftSaveDelay debounces autosave so typing doesn't write on every key.
+const draftSaveDelay = 500 * time.Millisecond
+
+// maxArchivedDrafts caps how many discarded drafts are kept for recovery.
+const maxArchivedDrafts = 50
+
+// Draft is an unsent message, either the live draft of a branch or an
+// archived one that was cleared or replaced without being sent.
+type Draft struct {
+	BranchID  string    `json:"branchId"`
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Archived  bool      `json:"archived,omitempty"`
+}
+
+// DraftStore persists composer drafts on disk: one file per branch for
+// the live draft, plus a capped archive of older unsent text.
+type DraftStore struct {
+	dir string
+	now func() time.Time
+}
+
+// DefaultDraftsDir returns the drafts directory under the CLI state dir.
+func DefaultDraftsDir() (string, error) {
+	dir, err := paths.State()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "drafts"), nil
+}
+
+// NewDraftStore creates a store rooted at dir. The directory is created
+// lazily on first save.
+func NewDraftStore(dir string) *DraftStore {
+	return &DraftStore{dir: dir, now: time.Now}
+}
+
+func (s *DraftStore) branchPath(branchID string) string {
+	// Branch IDs are ULIDs, but guard against path separators anyway.
+	return filepath.Join(s.dir, filepath.Base(branchID)+".json")
+}
+
+func (s *DraftStore) archivePath() string {
+	return filepath.Join(s.dir, "archive.json")
+}
+
+// Load returns the live draft for a branch, or "" if there is none.
+func (s *DraftStore) Load(branchID string) (string, error) {
+	var d Draft
+	if err := readJSON(s.branchPath(branchID), &d); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	return d.Text, nil
+}
+
+// Save records text as the live draft for a branch. Saving blank text
+// moves the previous draft into the archive, so text the user cleared
+// without sending can still be recovered from the drafts browser.
+func (s *DraftStore) Save(branchID, text string) error {
+	if strings.TrimSpace(text) == "" {
+		prev, err := s.Load(branchID)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(prev) != "" {
+			if err := s.archive(Draft{BranchID: branchID, Text: prev}); err != nil {
+				return err
+			}
+		}
+		return s.Discard(branchID)
+	}
+	return writeJSON(s.branchPath(branchID), Draft{
+		BranchID:  branchID,
+		Text:      text,
+		UpdatedAt: s.now(),
+	})
+}
+
+// Discard removes the live draft for a branch without archiving it,
+// e.g. after the message was sent.
+func (s *DraftStore) Discard(branchID string) error {
+	err := os.Remove(s.branchPath(branchID))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List returns the live drafts of every branch followed by archived
+// drafts, each group newest first.
+func (s *DraftStore) List() ([]Draft, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var live []Draft
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || name == "archive.json" || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		var d Draft
+		if err := readJSON(filepath.Join(s.dir, name), &d); err != nil || d.Text == "" {
+			continue
+		}
+		live = append(live, d)
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].UpdatedAt.After(live[j].UpdatedAt) })
+
+	archived, err := s.loadArchive()
+	if err != nil {
+		return nil, err
+	}
+	return append(live, archived...), nil
+}
+
+// RemoveArchived deletes an archived draft (matched by time and text).
+func (s *DraftStore) RemoveArchived(d Draft) error {
+	archived, err := s.loadArchive()
+	if err != nil {
+		return err
+	}
+	out := archived[:0]
+	for _, a := range archived {
+		if a.UpdatedAt.Equal(d.UpdatedAt) && a.Text == d.Text {
+			continue
+		}
+		out = append(out, a)
+	}
+	return writeJSON(s.archivePath(), out)
+}
+
+func (s *DraftStore) archive(d Draft) error {
+	archived, err := s.loadArchive()
+	if err != nil {
+		return err
+	}
+	d.UpdatedAt = s.now()
+	d.Archived = true
+	archived = append([]Draft{d}, archived...)
+	if len(archived) > maxArchivedDrafts {
+		archived = archived[:maxArchivedDrafts]
+	}
+	return writeJSON(s.archivePath(), archived)
+}
+
+func (s *DraftStore) loadArchive() ([]Draft, error) {
+	var archived []Draft
+	if err := readJSON(s.archivePath(), &archived); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return archived, nil
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON writes atomically so a crash mid-write never leaves a
+// truncated draft behind.
+func writeJSON(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ─── Autosave ─────────────────────────────────────────────────────
+
+type draftSaveMsg struct{ id int }
+
+type draftsLoadedMsg struct {
+	drafts []Draft
+}
+
+// scheduleDraftSave debounces a save of the current editor contents.
+func (m *Model) scheduleDraftSave() tea.Cmd {
+	if m.drafts == nil {
+		return nil
+	}
+	m.draftSaveID++
+	id := m.draftSaveID
+	return tea.Tick(draftSaveDelay, func(_ time.Time) tea.Msg {
+		return draftSaveMsg{id: id}
+	})
+}
+
+// saveDraft writes the editor contents immediately. Errors are ignored:
+// drafts are best-effort and must never interrupt the chat.
+func (m *Model) saveDraft() {
+	if m.drafts == nil {
+		return
+	}
+	_ = m.drafts.Save(m.branchID, m.textarea.Value())
+}
+
+// discardDraft drops the live draft once its text has been consumed
+// (sent or run as a slash command), cancelling any pending autosave.
+func (m *Model) discardDraft() {
+	if m.drafts == nil {
+		return
+	}
+	m.draftSaveID++
+	_ = m.drafts.Discard(m.branchID)
+}
+
+func (m Model) loadDrafts() tea.Cmd {
+	store := m.drafts
+	return func() tea.Msg {
+		drafts, _ := store.List()
+		return draftsLoadedMsg{drafts: drafts}
+	}
+}
+
+func (m Model) openDraftsDialog() (tea.Model, tea.Cmd) {
+	if m.drafts == nil {
+		return m, nil
+	}
+	// Flush first so the current branch's draft shows up as-is.
+	m.saveDraft()
+	dlg := NewDraftsDialog(m.branchID)
+	m.dialog = dlg
+	return m, m.loadDrafts()
+}
+
+// restoreDraft replaces the editor contents with d. Any unsent text in
+// the editor is archived first so nothing is lost by restoring.
+func (m Model) restoreDraft(d Draft) (tea.Model, tea.Cmd) {
+	if strings.TrimSpace(m.textarea.Value()) != "" && m.textarea.Value() != d.Text {
+		_ = m.drafts.Save(m.branchID, "")
+	}
+	if d.Archived {
+		_ = m.drafts.RemoveArchived(d)
+	}
+	m.textarea.Reset()
+	m.textarea.SetValue(d.Text)
+	m.history.Reset()
+	m.focus = focusEditor
+	m.textarea.Focus()
+	m.adjustTextareaHeight()
+	m.saveDraft()
+	return m, nil
+}
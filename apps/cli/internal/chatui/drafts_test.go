@@ -0,0 +1,83 @@
+package chatui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDraftStore_SaveLoadDiscard(t *testing.T) {
+	s := NewDraftStore(t.TempDir())
+
+	if text, err := s.Load("b1"); err != nil || text != "" {
+		t.Fatalf("empty store: got %q, %v", text, err)
+	}
+
+	if err := s.Save("b1", "half-written thought"); err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := s.Load("b1"); text != "half-written thought" {
+		t.Errorf("Load = %q", text)
+	}
+
+	// Discard (after send) must not archive.
+	if err := s.Discard("b1"); err != nil {
+		t.Fatal(err)
+	}
+	drafts, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drafts) != 0 {
+		t.Errorf("expected no drafts after discard, got %+v", drafts)
+	}
+}
+
+func TestDraftStore_ClearingArchives(t *testing.T) {
+	s := NewDraftStore(t.TempDir())
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { now = now.Add(time.Minute); return now }
+
+	_ = s.Save("b1", "first idea")
+	_ = s.Save("b1", "") // cleared without sending
+	_ = s.Save("b2", "other branch")
+
+	drafts, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drafts) != 2 {
+		t.Fatalf("expected 2 drafts, got %+v", drafts)
+	}
+	if drafts[0].BranchID != "b2" || drafts[0].Archived {
+		t.Errorf("live drafts should come first: %+v", drafts[0])
+	}
+	if drafts[1].Text != "first idea" || !drafts[1].Archived {
+		t.Errorf("expected archived 'first idea', got %+v", drafts[1])
+	}
+	if text, _ := s.Load("b1"); text != "" {
+		t.Errorf("b1 live draft should be gone, got %q", text)
+	}
+
+	if err := s.RemoveArchived(drafts[1]); err != nil {
+		t.Fatal(err)
+	}
+	drafts, _ = s.List()
+	if len(drafts) != 1 {
+		t.Errorf("expected archived draft removed, got %+v", drafts)
+	}
+}
+
+func TestDraftStore_ArchiveCap(t *testing.T) {
+	s := NewDraftStore(t.TempDir())
+	for i := 0; i < maxArchivedDrafts+5; i++ {
+		_ = s.Save("b1", "draft")
+		_ = s.Save("b1", "")
+	}
+	archived, err := s.loadArchive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archived) != maxArchivedDrafts {
+		t.Errorf("archive len = %d, want %d", len(archived), maxArchivedDrafts)
+	}
+}
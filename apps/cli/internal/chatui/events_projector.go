@@ -58,6 +58,7 @@ func EventToStored(row EventRow) StoredMessage {
 	parsed := parsePayload(row.Payload)
 
 	var parts []ContentPart
+	var stopReason string
 
 	// Check if the assistant_message is still streaming
 	isMessageStreaming := false
@@ -69,6 +70,7 @@ func EventToStored(row EventRow) StoredMessage {
 	case "assistant_message":
 		msg := jsonObj(parsed, "message")
 		parts = extractMessageParts(msg)
+		stopReason = jsonStr(msg, "stopReason")
 		// During streaming, surface toolCall blocks as streaming tool-call parts.
 		// The raw toolCall format uses "id" and "arguments" (not "toolCallId"/"args"),
 		// so we extract from the raw content array.
@@ -192,6 +194,7 @@ func EventToStored(row EventRow) StoredMessage {
 		EventType:       row.Type,
 		ParentMessageID: parentMessageID,
 		RunID:           runID,
+		StopReason:      stopReason,
 	}
 }
 
@@ -0,0 +1,374 @@
+package chatui
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ExportFormats are the formats an Exporter writes.
+var ExportFormats = []string{"md", "json", "html"}
+
+// ExportInfo describes the conversation being exported.
+type ExportInfo struct {
+	Title    string    `json:"title,omitempty"`
+	ThreadID string    `json:"threadId,omitempty"`
+	BranchID string    `json:"branchId"`
+	Exported time.Time `json:"exportedAt"`
+}
+
+// Exporter writes a transcript as the rows of a branch are added, holding
+// no more than the message a continuation may still be stitched into.
+// Nothing is written before the first row, so a branch that can't be read
+// leaves w untouched.
+type Exporter struct {
+	w      io.Writer
+	format string
+	info   ExportInfo
+	// last is the message written next, unless a continuation of it
+	// follows; awaiting is set when a continuation prompt did.
+	last     *StoredMessage
+	awaiting bool
+	started  bool
+	written  int
+}
+
+// NewExporter starts a Markdown ("md"), JSON or standalone HTML
+// transcript on w.
+func NewExporter(w io.Writer, format string, info ExportInfo) (*Exporter, error) {
+	if !slices.Contains(ExportFormats, format) {
+		return nil, fmt.Errorf("unknown export format %q (want %s)", format, strings.Join(ExportFormats, ", "))
+	}
+	return &Exporter{w: w, format: format, info: info}, nil
+}
+
+// start writes the header of the transcript.
+func (e *Exporter) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	var head string
+	switch e.format {
+	case "md":
+		head = fmt.Sprintf("# %s\n\n%s\n", exportTitle(e.info), exportSubtitle(e.info, func(s string) string { return "`" + s + "`" }))
+	case "json":
+		data, err := json.MarshalIndent(e.info, "", "  ")
+		if err != nil {
+			return err
+		}
+		head = strings.TrimSuffix(string(data), "\n}") + ",\n  \"messages\": ["
+	case "html":
+		head = htmlExportHead(e.info)
+	}
+	_, err := io.WriteString(e.w, head)
+	return err
+}
+
+// Messages returns how many messages were written.
+func (e *Exporter) Messages() int {
+	return e.written
+}
+
+// Add projects row to a message as the TUI shows it, stitching continued
+// answers into one, and writes the message before it once that is
+// complete. Rows that aren't messages are skipped. Unlike the TUI's, a
+// finished tool call keeps its arguments along with its result.
+func (e *Exporter) Add(row EventRow) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	if !IsRenderable(row.Type) {
+		return nil
+	}
+	msg := EventToStored(row)
+	if row.Type == "tool_execution" && len(msg.Parts) == 1 && msg.Parts[0].Args == nil {
+		msg.Parts[0].Args = extractToolCallParts(parsePayload(row.Payload))[0].Args
+	}
+	if len(msg.Parts) == 0 && msg.Text == "" {
+		return nil
+	}
+	// As in stitchContinuations.
+	switch {
+	case msg.Sender == SenderUser && IsContinuationPrompt(msg.Text) && e.last != nil &&
+		e.last.EventType == "assistant_message" && IsTruncated(e.last.StopReason):
+		e.awaiting = true
+		return nil
+	case e.awaiting && msg.EventType == "assistant_message":
+		merged := mergeContinuation(*e.last, msg)
+		e.last = &merged
+		e.awaiting = false
+		return nil
+	}
+	e.awaiting = false
+	err := e.flush()
+	e.last = &msg
+	return err
+}
+
+// Close writes the last message and ends the transcript.
+func (e *Exporter) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	if err := e.flush(); err != nil {
+		return err
+	}
+	var tail string
+	switch e.format {
+	case "json":
+		if e.written > 0 {
+			tail = "\n  ]\n}\n"
+		} else {
+			tail = "]\n}\n"
+		}
+	case "html":
+		tail = "</body>\n</html>\n"
+	}
+	_, err := io.WriteString(e.w, tail)
+	return err
+}
+
+func (e *Exporter) flush() error {
+	if e.last == nil {
+		return nil
+	}
+	msg := *e.last
+	e.last = nil
+	var out string
+	switch e.format {
+	case "md":
+		out = markdownMessage(msg)
+	case "json":
+		data, err := json.MarshalIndent(msg, "    ", "  ")
+		if err != nil {
+			return err
+		}
+		out = "\n    " + string(data)
+		if e.written > 0 {
+			out = "," + out
+		}
+	case "html":
+		out = htmlMessage(msg)
+	}
+	e.written++
+	_, err := io.WriteString(e.w, out)
+	return err
+}
+
+// exportTitle is the heading of an export.
+func exportTitle(info ExportInfo) string {
+	if info.Title != "" {
+		return info.Title
+	}
+	if info.ThreadID != "" {
+		return "Session " + info.ThreadID
+	}
+	return "Branch " + info.BranchID
+}
+
+// exportSubtitle lists the ids and export time under the heading.
+func exportSubtitle(info ExportInfo, code func(string) string) string {
+	var items []string
+	if info.ThreadID != "" {
+		items = append(items, "Session "+code(info.ThreadID))
+	}
+	items = append(items, "branch "+code(info.BranchID))
+	items = append(items, "exported "+info.Exported.UTC().Format(exportTimeLayout))
+	return strings.Join(items, " · ")
+}
+
+const exportTimeLayout = "2006-01-02 15:04:05 UTC"
+
+// exportTime formats a message timestamp for display, or returns it as
+// is when it isn't RFC 3339.
+func exportTime(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.UTC().Format(exportTimeLayout)
+}
+
+// exportRole is the label of a message's sender.
+func exportRole(msg StoredMessage) string {
+	if msg.EventType == "tool_execution" {
+		return "Tool"
+	}
+	switch resolveRoleFromSender(msg.Sender) {
+	case "assistant":
+		return "Assistant"
+	case "memory":
+		return "Memory"
+	case "system":
+		return "System"
+	default:
+		return "User"
+	}
+}
+
+// exportParts returns msg's parts, with its thinking first, or its text
+// when it has no parts.
+func exportParts(msg StoredMessage) []ContentPart {
+	var parts []ContentPart
+	if msg.Thinking != "" {
+		parts = append(parts, ContentPart{Type: PartThinking, Text: msg.Thinking})
+	}
+	parts = append(parts, msg.Parts...)
+	if len(parts) == 0 && msg.Text != "" {
+		parts = append(parts, ContentPart{Type: PartText, Text: msg.Text})
+	}
+	return parts
+}
+
+// toolArgsJSON formats tool call arguments as indented JSON.
+func toolArgsJSON(args map[string]interface{}) string {
+	if len(args) == 0 {
+		return "{}"
+	}
+	data, err := json.MarshalIndent(args, "", "  ")
+	if err != nil {
+		return formatArgs(args)
+	}
+	return string(data)
+}
+
+// hasToolResult reports whether the tool call p finished: a finished
+// call carries its result.
+func hasToolResult(p ContentPart) bool {
+	return p.Result != "" || p.ElapsedMs > 0
+}
+
+// toolResultLabel names the tool of a result and how long the call took.
+func toolResultLabel(name string, p ContentPart) string {
+	label := name
+	if p.ElapsedMs > 0 {
+		label += fmt.Sprintf(" · %.1fs", float64(p.ElapsedMs)/1000)
+	}
+	return label
+}
+
+// ── markdown ────────────────────────────────────────────────────────────────
+
+func markdownMessage(msg StoredMessage) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## %s · %s\n", exportRole(msg), exportTime(msg.Timestamp))
+	for _, p := range exportParts(msg) {
+		b.WriteString("\n")
+		b.WriteString(markdownPart(p))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func markdownPart(p ContentPart) string {
+	switch p.Type {
+	case PartText:
+		return p.Text
+	case PartThinking:
+		return "<details>\n<summary>Thinking</summary>\n\n" + p.Text + "\n\n</details>"
+	case PartToolCall:
+		call := fmt.Sprintf("**Tool call:** `%s`\n\n%s", p.Name, markdownFence(toolArgsJSON(p.Args), "json"))
+		if !hasToolResult(p) {
+			return call
+		}
+		return call + "\n\n" + markdownToolResult(toolResultLabel(p.Name, p), p.Result)
+	case PartToolResult:
+		return markdownToolResult(toolResultLabel(p.ToolName, p), p.Result)
+	case PartArtifact:
+		title := p.Title
+		if title == "" {
+			title = p.Filename
+		}
+		return fmt.Sprintf("**Artifact:** %s\n\n%s", title, markdownFence(p.Content, ""))
+	default:
+		return markdownFence(formatPart(p), "")
+	}
+}
+
+func markdownToolResult(label, result string) string {
+	return fmt.Sprintf("**Tool result:** `%s`\n\n%s", label, markdownFence(result, ""))
+}
+
+// markdownFence fences text in more backticks than any run in it.
+func markdownFence(text, lang string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + fence
+}
+
+// ── html ────────────────────────────────────────────────────────────────────
+
+const exportCSS = `body{font:15px/1.5 system-ui,sans-serif;max-width:48rem;margin:2rem auto;padding:0 1rem;color:#1f2328;background:#fff}
+header p{color:#656d76}
+article{border-top:1px solid #d0d7de;padding:.5rem 0}
+h2{font-size:1rem;margin:.5rem 0}
+h2 time{font-weight:normal;color:#656d76;margin-left:.5rem}
+.assistant h2{color:#8250df}
+.text{white-space:pre-wrap}
+pre{background:#f6f8fa;padding:.75rem;overflow-x:auto;border-radius:6px}
+details{margin:.5rem 0}
+summary{cursor:pointer;color:#656d76}
+@media (prefers-color-scheme:dark){body{color:#e6edf3;background:#0d1117}pre{background:#161b22}article{border-color:#30363d}.assistant h2{color:#d2a8ff}}`
+
+func htmlExportHead(info ExportInfo) string {
+	esc := html.EscapeString
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", esc(exportTitle(info)), exportCSS)
+	fmt.Fprintf(&b, "<header>\n<h1>%s</h1>\n<p>%s</p>\n</header>\n", esc(exportTitle(info)),
+		exportSubtitle(info, func(s string) string { return "<code>" + esc(s) + "</code>" }))
+	return b.String()
+}
+
+func htmlMessage(msg StoredMessage) string {
+	esc := html.EscapeString
+	var b strings.Builder
+	role := exportRole(msg)
+	fmt.Fprintf(&b, "<article class=\"%s\">\n<h2>%s<time datetime=\"%s\">%s</time></h2>\n",
+		strings.ToLower(role), role, esc(msg.Timestamp), esc(exportTime(msg.Timestamp)))
+	for _, p := range exportParts(msg) {
+		b.WriteString(htmlPart(p))
+		b.WriteString("\n")
+	}
+	b.WriteString("</article>\n")
+	return b.String()
+}
+
+func htmlPart(p ContentPart) string {
+	esc := html.EscapeString
+	switch p.Type {
+	case PartText:
+		return "<div class=\"text\">" + esc(p.Text) + "</div>"
+	case PartThinking:
+		return "<details><summary>Thinking</summary><div class=\"text\">" + esc(p.Text) + "</div></details>"
+	case PartToolCall:
+		call := fmt.Sprintf("<details open><summary>Tool call: <code>%s</code></summary><pre>%s</pre></details>",
+			esc(p.Name), esc(toolArgsJSON(p.Args)))
+		if !hasToolResult(p) {
+			return call
+		}
+		return call + "\n" + htmlToolResult(toolResultLabel(p.Name, p), p.Result)
+	case PartToolResult:
+		return htmlToolResult(toolResultLabel(p.ToolName, p), p.Result)
+	default:
+		return "<pre>" + esc(formatPart(p)) + "</pre>"
+	}
+}
+
+func htmlToolResult(label, result string) string {
+	esc := html.EscapeString
+	return fmt.Sprintf("<details><summary>Tool result: <code>%s</code></summary><pre>%s</pre></details>", esc(label), esc(result))
+}
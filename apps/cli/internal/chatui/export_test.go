@@ -0,0 +1,192 @@
+package chatui
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportRows() []EventRow {
+	return []EventRow{
+		{
+			ID:        1,
+			Seq:       1,
+			Type:      "user_message",
+			Payload:   mustJSON(map[string]interface{}{"content": "List the files", "role": "user"}),
+			CreatedAt: 1700000000000,
+		},
+		{
+			ID:   2,
+			Seq:  2,
+			Type: "tool_execution",
+			Payload: mustJSON(map[string]interface{}{
+				"status":     "complete",
+				"toolName":   "ls",
+				"toolCallId": "tc-1",
+				"args":       map[string]interface{}{"path": "."},
+				"result":     map[string]interface{}{"content": []interface{}{map[string]interface{}{"type": "text", "text": "a.go\n<b>.go"}}},
+				"elapsedMs":  1500,
+			}),
+			CreatedAt: 1700000001000,
+		},
+		{
+			ID:   3,
+			Seq:  3,
+			Type: "assistant_message",
+			Payload: mustJSON(map[string]interface{}{
+				"message": map[string]interface{}{
+					"content": []interface{}{
+						map[string]interface{}{"type": "thinking", "text": "Two files."},
+						map[string]interface{}{"type": "text", "text": "There are two: ```a.go``` and b.go."},
+					},
+				},
+			}),
+			CreatedAt: 1700000002000,
+		},
+		{ID: 4, Seq: 4, Type: "run_closed", Payload: mustJSON(map[string]interface{}{}), CreatedAt: 1700000003000},
+	}
+}
+
+var exportInfo = ExportInfo{
+	Title:    "Files",
+	ThreadID: "th-1",
+	BranchID: "br-1",
+	Exported: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+}
+
+// export writes rows to a transcript in format.
+func export(t *testing.T, format string, rows []EventRow) string {
+	t.Helper()
+	var buf bytes.Buffer
+	e, err := NewExporter(&buf, format, exportInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := e.Add(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func exportMessages(t *testing.T, rows []EventRow) []StoredMessage {
+	t.Helper()
+	var doc struct {
+		ThreadID string          `json:"threadId"`
+		Messages []StoredMessage `json:"messages"`
+	}
+	out := export(t, "json", rows)
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("%v:\n%s", err, out)
+	}
+	if doc.ThreadID != "th-1" {
+		t.Errorf("threadId = %q", doc.ThreadID)
+	}
+	return doc.Messages
+}
+
+func TestExporterMessages(t *testing.T) {
+	msgs := exportMessages(t, exportRows())
+	if len(msgs) != 3 {
+		t.Fatalf("got %d messages, want 3", len(msgs))
+	}
+	tool := msgs[1].Parts
+	if len(tool) != 1 || tool[0].Type != PartToolCall {
+		t.Fatalf("tool execution parts = %+v, want one tool call", tool)
+	}
+	if tool[0].Args["path"] != "." || tool[0].Result == "" {
+		t.Errorf("tool call = %+v, want its args and result", tool[0])
+	}
+}
+
+func TestExporterStitchesContinuations(t *testing.T) {
+	answer := func(id int, text, stop string) EventRow {
+		return EventRow{
+			ID:   id,
+			Seq:  id,
+			Type: "assistant_message",
+			Payload: mustJSON(map[string]interface{}{
+				"message": map[string]interface{}{
+					"content":    []interface{}{map[string]interface{}{"type": "text", "text": text}},
+					"stopReason": stop,
+				},
+			}),
+			CreatedAt: 1700000000000 + int64(id)*1000,
+		}
+	}
+	rows := []EventRow{
+		exportRows()[0],
+		answer(2, "The first half", "length"),
+		{ID: 3, Seq: 3, Type: "user_message", Payload: mustJSON(map[string]interface{}{"content": ContinuationPrompt("The first half")}), CreatedAt: 1700000003000},
+		answer(4, " and the second.", "stop"),
+	}
+	msgs := exportMessages(t, rows)
+	if len(msgs) != 2 || msgs[1].Text != "The first half and the second." {
+		t.Errorf("got %+v, want the continued answer as one message", msgs)
+	}
+}
+
+func TestExporterMarkdown(t *testing.T) {
+	out := export(t, "md", exportRows())
+	for _, want := range []string{
+		"# Files\n",
+		"Session `th-1` · branch `br-1` · exported 2026-01-02 03:04:05 UTC",
+		"## User · 2023-11-14 22:13:20 UTC\n\nList the files\n",
+		"## Tool · 2023-11-14 22:13:21 UTC",
+		"**Tool call:** `ls`\n\n```json\n{\n  \"path\": \".\"\n}\n```",
+		"**Tool result:** `ls · 1.5s`\n\n```\na.go\n<b>.go\n```",
+		"<summary>Thinking</summary>\n\nTwo files.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMarkdownFence(t *testing.T) {
+	if got := markdownFence("a ``` b", ""); got != "````\na ``` b\n````" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestExporterJSONEmpty(t *testing.T) {
+	var doc struct {
+		Messages json.RawMessage `json:"messages"`
+	}
+	out := export(t, "json", nil)
+	if err := json.Unmarshal([]byte(out), &doc); err != nil || string(doc.Messages) != "[]" {
+		t.Errorf("got %s (%v)", out, err)
+	}
+}
+
+func TestExporterHTML(t *testing.T) {
+	out := export(t, "html", exportRows())
+	if !strings.HasPrefix(out, "<!DOCTYPE html>") || !strings.HasSuffix(out, "</html>\n") {
+		t.Error("not a whole document")
+	}
+	if strings.Contains(out, "<b>.go") || !strings.Contains(out, "&lt;b&gt;.go") {
+		t.Error("tool result not escaped")
+	}
+	if !strings.Contains(out, `<time datetime="2023-11-14T22:13:22Z">`) {
+		t.Error("missing message timestamp")
+	}
+}
+
+func TestExporterWritesNothingBeforeRows(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewExporter(&buf, "html", exportInfo); err != nil || buf.Len() != 0 {
+		t.Errorf("NewExporter wrote %q, %v", buf.String(), err)
+	}
+}
+
+func TestExporterUnknownFormat(t *testing.T) {
+	if _, err := NewExporter(&bytes.Buffer{}, "pdf", exportInfo); err == nil {
+		t.Error("expected an error")
+	}
+}
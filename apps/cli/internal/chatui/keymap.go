@@ -38,6 +38,7 @@ type KeyMap struct {
 	Info     key.Binding
 	Theme    key.Binding
 	Retry    key.Binding
+	Continue key.Binding
 	Escape   key.Binding
 }
 
@@ -68,6 +69,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("r"),
 			key.WithHelp("r", "retry"),
 		),
+		Continue: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "continue answer"),
+		),
 		Escape: key.NewBinding(
 			key.WithKeys("esc"),
 			key.WithHelp("esc", "back"),
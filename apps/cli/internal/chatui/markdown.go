@@ -31,6 +31,12 @@ func renderMarkdown(content string, width int) string {
 	return strings.TrimSuffix(result, "\n")
 }
 
+// RenderMarkdown renders content for standard output, at the terminal's
+// width up to the width of chat messages.
+func RenderMarkdown(content string) string {
+	return renderMarkdown(content, min(termWidth(), maxMessageWidth))
+}
+
 func sp(s string) *string { return &s }
 func bp(b bool) *bool     { return &b }
 func up(u uint) *uint     { return &u }
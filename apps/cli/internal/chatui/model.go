@@ -40,6 +40,10 @@ type Model struct {
 	connState ConnectionState
 	connError string
 
+	// notice is a transient problem with the pending message (e.g. an
+	// attachment the model can't take), shown in the status line.
+	notice string
+
 	// Chat state
 	messages       []StoredMessage
 	streamingMsg   *StoredMessage
@@ -61,6 +65,18 @@ type Model struct {
 	// History
 	history *PromptHistory
 
+	// Draft autosave (nil when the drafts dir is unavailable).
+	drafts      *DraftStore
+	draftSaveID int
+
+	// Model limits for client-side validation; nil until loaded or when
+	// the server doesn't report them.
+	capabilities *ModelCapabilities
+	capsCache    *CapabilitiesCache
+
+	// Model to ask for with each message; "" leaves it to the server.
+	requestModel string
+
 	// Auto-scroll
 	autoScroll bool
 
@@ -129,6 +145,20 @@ func NewModel(baseURL, branchID, transcriptDir string) Model {
 	vp := viewport.New()
 	vp.SetContent("")
 
+	// Restore the unsent draft from the last session on this branch.
+	var drafts *DraftStore
+	if dir, err := DefaultDraftsDir(); err == nil {
+		drafts = NewDraftStore(dir)
+		if text, err := drafts.Load(branchID); err == nil && text != "" {
+			ta.SetValue(text)
+		}
+	}
+
+	var capsCache *CapabilitiesCache
+	if path, err := DefaultCapabilitiesCachePath(); err == nil {
+		capsCache = NewCapabilitiesCache(path)
+	}
+
 	return Model{
 		baseURL:        baseURL,
 		branchID:       branchID,
@@ -140,6 +170,8 @@ func NewModel(baseURL, branchID, transcriptDir string) Model {
 		viewport:       vp,
 		focus:          focusEditor,
 		history:        NewPromptHistory(),
+		drafts:         drafts,
+		capsCache:      capsCache,
 		autoScroll:     true,
 		msgRenderCache: make(map[string]string),
 		activeAnims:    make(map[string]*chatAnim),
@@ -176,6 +208,7 @@ func (m Model) Init() tea.Cmd {
 		textarea.Blink,
 		m.sseClient.Subscribe(),
 		m.healthPollTick(),
+		m.loadCapabilities(),
 	)
 }
 
@@ -202,6 +235,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			for _, p := range paths {
 				m.attachments = append(m.attachments, newPendingAttachment(p))
 			}
+			// Flag unsupported attachments now rather than at send time.
+			if err := m.validateRequest(""); err != nil {
+				m.notice = err.Error()
+			}
 			m.resizeComponents()
 			return m, nil
 		}
@@ -210,12 +247,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.textarea.InsertString(msg.Content)
 			m.history.UpdateDraft(m.textarea.Value())
 			m.adjustTextareaHeight()
+			return m, m.scheduleDraftSave()
 		}
 		return m, nil
 
 	case tea.KeyPressMsg:
 		// Quit
 		if key.Matches(msg, m.keys.Quit) {
+			m.saveDraft()
 			m.cleanup()
 			return m, tea.Quit
 		}
@@ -265,6 +304,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
+		case key.Matches(msg, m.keys.Continue):
+			return m.continueAnswer()
+
 		case key.Matches(msg, m.keys.Retry):
 			if m.connState == StateError {
 				m.sseClient.ResetRetry()
@@ -346,6 +388,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// ── Async results ─────────────────────────────────────────
 
+	case draftSaveMsg:
+		if msg.id == m.draftSaveID {
+			m.saveDraft()
+		}
+		return m, nil
+
+	case draftsLoadedMsg:
+		if d, ok := m.dialog.(*DraftsDialog); ok {
+			d.SetDrafts(msg.drafts)
+		}
+		return m, nil
+
 	case threadsLoadedMsg:
 		if d, ok := m.dialog.(*ThreadListDialog); ok {
 			d.SetThreads(msg.threads)
@@ -371,12 +425,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case providerHintMsg:
+		if msg != "" {
+			m.notice = string(msg)
+		}
+		return m, nil
+
 	case sendDoneMsg:
 		if msg.err != nil {
 			m.connError = "Send failed: " + msg.err.Error()
+			// Cached limits may be stale (e.g. the model was switched).
+			if m.capsCache != nil {
+				m.capsCache.Invalidate(m.baseURL)
+				return m, m.loadCapabilities()
+			}
 		}
 		return m, nil
 
+	case capabilitiesLoadedMsg:
+		m.capabilities = msg.caps
+		return m, nil
+
 	case transcriptDoneMsg:
 		if msg.err != nil {
 			m.connError = "Transcript failed: " + msg.err.Error()
@@ -539,6 +608,7 @@ func (m Model) updateEditor(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 	// Backspace on empty input removes last attachment
 	if key.Matches(msg, m.keys.Attachments.Remove) && m.textarea.Value() == "" && len(m.attachments) > 0 {
 		m.attachments = m.attachments[:len(m.attachments)-1]
+		m.notice = ""
 		m.resizeComponents()
 		return m, nil
 	}
@@ -548,10 +618,12 @@ func (m Model) updateEditor(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	m.textarea, cmd = m.textarea.Update(msg)
 
-	// Track draft changes for history
+	// Track draft changes for history and autosave
 	if m.textarea.Value() != oldVal {
+		m.notice = ""
 		m.history.UpdateDraft(m.textarea.Value())
 		m.adjustTextareaHeight()
+		cmd = tea.Batch(cmd, m.scheduleDraftSave())
 	}
 
 	m.updateGhost()
@@ -582,6 +654,7 @@ func (m Model) handleSend() (tea.Model, tea.Cmd) {
 			m.textarea.Reset()
 			m.history.Reset()
 			m.ghostSuggestion = ""
+			m.discardDraft()
 			return m.executeCommand(cmd)
 		}
 	}
@@ -591,15 +664,27 @@ func (m Model) handleSend() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Reject what the model can't handle before uploading anything.
+	if err := m.validateRequest(text); err != nil {
+		m.notice = err.Error()
+		return m, nil
+	}
+	out, err := checkOutgoing(text, m.attachments)
+	if err != nil {
+		m.notice = err.Error()
+		return m, nil
+	}
+	m.notice = strings.Join(out.notes, "; ")
+
 	// Save to history and clear
 	if text != "" {
 		m.history.Add(text)
 	}
+	m.discardDraft()
 	m.textarea.Reset()
 	m.textarea.SetHeight(1)
 
-	// Grab attachments and clear them
-	pendingFiles := m.attachments
+	// Clear the attachments; out holds what is sent.
 	m.attachments = nil
 	m.attachmentCursor = 0
 	m.focus = focusEditor
@@ -608,7 +693,7 @@ func (m Model) handleSend() (tea.Model, tea.Cmd) {
 	m.resizeComponents()
 	m.autoScroll = true
 
-	return m, m.sendMessage(text, pendingFiles...)
+	return m, m.sendMessage(out)
 }
 
 // handleHistoryUp navigates up in prompt history.
@@ -661,6 +746,7 @@ func (m Model) updateAttachments(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, m.keys.Attachments.Remove):
 		if len(m.attachments) > 0 {
 			m.attachments = append(m.attachments[:m.attachmentCursor], m.attachments[m.attachmentCursor+1:]...)
+			m.notice = ""
 			if len(m.attachments) == 0 {
 				// No attachments left — return to editor
 				m.attachmentCursor = 0
@@ -749,6 +835,27 @@ func (m Model) updateDialog(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 	case ActionRetry:
 		m.sseClient.ResetRetry()
 		return m, m.startSSE()
+
+	case ActionRestoreDraft:
+		m.dialog = nil
+		if dd, ok := prev.(*DraftsDialog); ok {
+			if sel := dd.Selected(); sel != nil {
+				return m.restoreDraft(*sel)
+			}
+		}
+		return m, nil
+
+	case ActionDeleteDraft:
+		if dd, ok := prev.(*DraftsDialog); ok {
+			if sel := dd.Selected(); sel != nil {
+				if sel.Archived {
+					_ = m.drafts.RemoveArchived(*sel)
+				} else {
+					_ = m.drafts.Discard(sel.BranchID)
+				}
+			}
+		}
+		return m, m.loadDrafts()
 	}
 
 	return m, nil
@@ -894,6 +1001,10 @@ func (m Model) handleUpdate(ev EventRow) (tea.Model, tea.Cmd) {
 
 			// Recompute stats from all events for accuracy.
 			m.stats = ComputeStatsFromEvents(m.allEvents)
+			if cmd := providerErrorHint(ev); cmd != nil {
+				m.refreshViewport()
+				return m, cmd
+			}
 		}
 		m.refreshViewport()
 		return m, nil
@@ -957,6 +1068,10 @@ func (m Model) executeCommand(cmd *SlashCommand) (tea.Model, tea.Cmd) {
 		return m.openInfoDialog()
 	case "transcript":
 		return m, m.saveTranscript()
+	case "drafts":
+		return m.openDraftsDialog()
+	case "continue":
+		return m.continueAnswer()
 	case "theme":
 		return m.toggleTheme()
 	default:
@@ -989,6 +1104,22 @@ func (m Model) openInfoDialog() (tea.Model, tea.Cmd) {
 
 // ─── Async commands (tea.Cmd) ─────────────────────────────────────
 
+// ProviderErrorHint, when set, is asked what the provider's status page
+// says after a model request fails. It may block for a few seconds, and
+// returns "" when it has nothing to add.
+var ProviderErrorHint func(provider string) string
+
+// providerErrorHint looks up ProviderErrorHint for an assistant message
+// that ended in an error.
+func providerErrorHint(ev EventRow) tea.Cmd {
+	msg := jsonObj(parsePayload(ev.Payload), "message")
+	if ProviderErrorHint == nil || msg["stopReason"] != "error" {
+		return nil
+	}
+	provider, _ := msg["provider"].(string)
+	return func() tea.Msg { return providerHintMsg(ProviderErrorHint(provider)) }
+}
+
 type threadsLoadedMsg struct {
 	threads []ThreadEntry
 }
@@ -1000,6 +1131,7 @@ type branchInfoLoadedMsg struct {
 
 type clearDoneMsg struct{ err error }
 type sendDoneMsg struct{ err error }
+type providerHintMsg string
 type transcriptDoneMsg struct {
 	path string
 	err  error
@@ -1035,15 +1167,22 @@ func (m *Model) StartSSELoop(ctx context.Context, send func(tea.Msg)) {
 	m.sseClient.RunLoop(ctx, send)
 }
 
-func (m Model) sendMessage(text string, files ...PendingAttachment) tea.Cmd {
+// SetRequestModel asks for the model id with each message sent.
+func (m *Model) SetRequestModel(id string) {
+	m.requestModel = id
+}
+
+func (m Model) sendMessage(out *outgoing) tea.Cmd {
 	httpClient := m.httpClient
 	branchID := m.branchID
+	opts := MessageOptions{Model: m.requestModel}
 	return func() tea.Msg {
 		ctx := context.Background()
+		defer out.cleanup()
 
 		// Upload files via TUS
 		var results []AttachmentResult
-		for _, f := range files {
+		for _, f := range out.files {
 			r, err := httpClient.UploadFile(ctx, f.FilePath)
 			if err != nil {
 				return sendDoneMsg{err: fmt.Errorf("upload %s: %w", f.Name, err)}
@@ -1051,7 +1190,7 @@ func (m Model) sendMessage(text string, files ...PendingAttachment) tea.Cmd {
 			results = append(results, r)
 		}
 
-		err := httpClient.SendMessage(ctx, branchID, text, results)
+		err := httpClient.SendMessageWithOptions(ctx, branchID, out.prompt, results, opts)
 		return sendDoneMsg{err: err}
 	}
 }
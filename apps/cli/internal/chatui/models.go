@@ -0,0 +1,83 @@
+package chatui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrModelsUnavailable means the server doesn't list its models; callers
+// should skip checking the model they ask for.
+var ErrModelsUnavailable = errors.New("server does not list its models")
+
+// ModelInfo is a model the server can answer with, as listed by
+// GET /api/models.
+type ModelInfo struct {
+	ID              string `json:"id"`
+	Provider        string `json:"provider"`
+	Name            string `json:"name,omitempty"`
+	ContextWindow   int    `json:"contextWindow,omitempty"`
+	MaxOutputTokens int    `json:"maxOutputTokens,omitempty"`
+	// Current marks the model the server answers with when none is asked for.
+	Current bool `json:"current,omitempty"`
+}
+
+// ListModels fetches GET /api/models, sorted by provider and id. It
+// returns ErrModelsUnavailable when the server has no such endpoint.
+func (c *HTTPClient) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create models request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("models request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrModelsUnavailable
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("models returned %d", resp.StatusCode)
+	}
+	var out []ModelInfo
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode models: %w", err)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// Matches reports whether name names m: its id, or provider/id.
+func (m ModelInfo) Matches(name string) bool {
+	return strings.EqualFold(name, m.ID) || strings.EqualFold(name, m.Provider+"/"+m.ID)
+}
+
+// CheckModel returns an error when name is none of models, suggesting
+// the ones whose id contains it.
+func CheckModel(models []ModelInfo, name string) error {
+	for _, m := range models {
+		if m.Matches(name) {
+			return nil
+		}
+	}
+	var similar []string
+	for _, m := range models {
+		if strings.Contains(strings.ToLower(m.Provider+"/"+m.ID), strings.ToLower(name)) {
+			similar = append(similar, m.Provider+"/"+m.ID)
+		}
+	}
+	if len(similar) > 0 && len(similar) <= 5 {
+		return fmt.Errorf("the server has no model %q — did you mean %s?", name, strings.Join(similar, ", "))
+	}
+	return fmt.Errorf("the server has no model %q — see ellie models list", name)
+}
@@ -0,0 +1,61 @@
+package chatui
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/models" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"id":"gpt-4o","provider":"openai"},{"id":"claude-sonnet-4","provider":"anthropic","current":true}]`))
+	}))
+	defer srv.Close()
+
+	models, err := NewHTTPClient(srv.URL).ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 || models[0].Provider != "anthropic" || !models[0].Current {
+		t.Errorf("got %+v, want anthropic's model first and current", models)
+	}
+}
+
+func TestListModelsUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := NewHTTPClient(srv.URL).ListModels(context.Background())
+	if !errors.Is(err, ErrModelsUnavailable) {
+		t.Errorf("got %v, want ErrModelsUnavailable", err)
+	}
+}
+
+func TestCheckModel(t *testing.T) {
+	models := []ModelInfo{
+		{ID: "claude-sonnet-4", Provider: "anthropic"},
+		{ID: "claude-opus-4", Provider: "anthropic"},
+		{ID: "gpt-4o", Provider: "openai"},
+	}
+	for _, name := range []string{"gpt-4o", "openai/gpt-4o", "Claude-Opus-4"} {
+		if err := CheckModel(models, name); err != nil {
+			t.Errorf("CheckModel(%q) = %v", name, err)
+		}
+	}
+
+	err := CheckModel(models, "claude")
+	if err == nil || !strings.Contains(err.Error(), "anthropic/claude-sonnet-4, anthropic/claude-opus-4") {
+		t.Errorf("got %v, want the claude models suggested", err)
+	}
+	err = CheckModel(models, "llama")
+	if err == nil || !strings.Contains(err.Error(), "ellie models list") {
+		t.Errorf("got %v, want a pointer to ellie models list", err)
+	}
+}
@@ -4,42 +4,88 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"golang.org/x/term"
+
+	"ellie/apps/cli/pkg/ellieapi"
 )
 
 // OneShotConfig configures a non-interactive one-shot chat.
 type OneShotConfig struct {
-	BaseURL   string
+	BaseURL  string
 	BranchID string
-	Format    string // "text", "markdown", "json"
+	Format   string // "text", "markdown", "json"
+	// Model asks for a model to answer, System gives a system prompt,
+	// MaxTokens caps the answer and Thinking asks for extended thinking;
+	// see MessageOptions.
+	Model     string
+	System    string
+	MaxTokens int
+	Thinking  bool
+	// OnText, when set, is called with each new piece of the answer as
+	// it arrives.
+	OnText func(string)
 }
 
 // OneShotResult holds the response from a one-shot chat.
 type OneShotResult struct {
-	Role             string  `json:"role"`
-	Content          string  `json:"content"`
-	Model            *string `json:"model,omitempty"`
-	Provider         *string `json:"provider,omitempty"`
-	PromptTokens     int     `json:"promptTokens"`
-	CompletionTokens int     `json:"completionTokens"`
-	TotalCost        float64 `json:"totalCost"`
-	StopReason       string  `json:"stopReason,omitempty"`
-	Error            string  `json:"error,omitempty"`
+	Role             string        `json:"role"`
+	Content          string        `json:"content"`
+	Model            *string       `json:"model,omitempty"`
+	Provider         *string       `json:"provider,omitempty"`
+	PromptTokens     int           `json:"promptTokens"`
+	CompletionTokens int           `json:"completionTokens"`
+	TotalCost        float64       `json:"totalCost"`
+	StopReason       string        `json:"stopReason,omitempty"`
+	Continuations    int           `json:"continuations,omitempty"`
+	Images           []ContentPart `json:"images,omitempty"`
+	Error            string        `json:"error,omitempty"`
 }
 
 const oneShotTimeout = 5 * time.Minute
 
-// RunOneShot sends a single prompt and waits for the agent to complete.
-// It bypasses the Bubble Tea TUI entirely.
-func RunOneShot(ctx context.Context, cfg OneShotConfig, prompt string) (*OneShotResult, error) {
+// RunOneShot sends a single prompt, with files attached, and waits for
+// the agent to complete. It bypasses the Bubble Tea TUI entirely.
+func RunOneShot(ctx context.Context, cfg OneShotConfig, prompt string, files ...PendingAttachment) (*OneShotResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, oneShotTimeout)
 	defer cancel()
 
 	client := NewHTTPClient(cfg.BaseURL)
 
+	// Reject prompts the model can't take before opening the stream.
+	var cache *CapabilitiesCache
+	if path, err := DefaultCapabilitiesCachePath(); err == nil {
+		cache = NewCapabilitiesCache(path)
+	}
+	if caps := requestCapabilities(ctx, client, cache, cfg.Model); caps != nil {
+		if err := caps.Validate(RequestParams{Prompt: prompt, MaxTokens: cfg.MaxTokens, Thinking: cfg.Thinking, Attachments: files}); err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := checkOutgoing(prompt, files)
+	if err != nil {
+		return nil, err
+	}
+	defer out.cleanup()
+	for _, note := range out.notes {
+		fmt.Fprintln(os.Stderr, note)
+	}
+	prompt, files = out.prompt, out.files
+
+	var uploads []AttachmentResult
+	for _, f := range files {
+		r, err := client.UploadFile(ctx, f.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("upload %s: %w", f.Name, err)
+		}
+		uploads = append(uploads, r)
+	}
+
 	// Start SSE reader in background.
 	eventCh := make(chan sseEvent, 64)
 	go sseReadEvents(ctx, cfg.BaseURL, cfg.BranchID, eventCh)
@@ -67,7 +113,7 @@ func RunOneShot(ctx context.Context, cfg OneShotConfig, prompt string) (*OneShot
 	}
 
 	// Send the user message.
-	if err := client.SendMessage(ctx, cfg.BranchID, prompt, nil); err != nil {
+	if err := client.SendMessageWithOptions(ctx, cfg.BranchID, prompt, uploads, MessageOptions{Model: cfg.Model, System: cfg.System, MaxTokens: cfg.MaxTokens, Thinking: cfg.Thinking}); err != nil {
 		return nil, fmt.Errorf("send message: %w", err)
 	}
 
@@ -78,6 +124,7 @@ func RunOneShot(ctx context.Context, cfg OneShotConfig, prompt string) (*OneShot
 		finalAssistantEv *EventRow
 		errorMessage     string
 		turnEvents       []EventRow
+		stream           = textStream{fn: cfg.OnText}
 	)
 
 	for ev := range eventCh {
@@ -102,6 +149,7 @@ func RunOneShot(ctx context.Context, cfg OneShotConfig, prompt string) (*OneShot
 			}
 			if row.Type == "assistant_message" {
 				finalAssistantEv = &row
+				stream.update(row)
 			}
 			if row.Type == "error" {
 				stored := EventToStored(row)
@@ -110,6 +158,9 @@ func RunOneShot(ctx context.Context, cfg OneShotConfig, prompt string) (*OneShot
 
 		case "update":
 			row := ev.Row
+			if row.Seq > 0 && row.Seq <= snapshotMaxSeq {
+				continue
+			}
 			// Upsert into turnEvents.
 			found := false
 			for i, e := range turnEvents {
@@ -125,6 +176,7 @@ func RunOneShot(ctx context.Context, cfg OneShotConfig, prompt string) (*OneShot
 
 			if row.Type == "assistant_message" {
 				finalAssistantEv = &row
+				stream.update(row)
 			}
 		}
 	}
@@ -140,6 +192,36 @@ func RunOneShot(ctx context.Context, cfg OneShotConfig, prompt string) (*OneShot
 	return nil, fmt.Errorf("SSE stream ended without a response")
 }
 
+// textStream passes the answer's text to fn as it grows. An answer may
+// span several assistant messages, with tool calls between them; their
+// texts are separated by a blank line.
+type textStream struct {
+	fn      func(string)
+	sent    map[int]string // text passed on, by message
+	current int            // the message text was last passed on from
+}
+
+func (s *textStream) update(row EventRow) {
+	if s.fn == nil {
+		return
+	}
+	if s.sent == nil {
+		s.sent = map[int]string{}
+	}
+	text := EventToStored(row).Text
+	sent := s.sent[row.ID]
+	// Text that was rewritten rather than added to can't be taken back.
+	if len(text) <= len(sent) || !strings.HasPrefix(text, sent) {
+		return
+	}
+	if len(s.sent) > 0 && row.ID != s.current {
+		s.fn("\n\n")
+	}
+	s.fn(text[len(sent):])
+	s.sent[row.ID] = text
+	s.current = row.ID
+}
+
 func buildResult(assistantEv *EventRow, allEvents []EventRow, errorMsg string) *OneShotResult {
 	result := &OneShotResult{Role: "assistant", Error: errorMsg}
 
@@ -147,8 +229,8 @@ func buildResult(assistantEv *EventRow, allEvents []EventRow, errorMsg string) *
 		stored := EventToStored(*assistantEv)
 		result.Content = stored.Text
 
-		// Extract stopReason from payload.
-		parsed := parsePayload(assistantEv.Payload)
+		// Extract stopReason from the message.
+		parsed := jsonObj(parsePayload(assistantEv.Payload), "message")
 		if sr, ok := parsed["stopReason"].(string); ok {
 			result.StopReason = sr
 			if sr == "error" {
@@ -159,6 +241,22 @@ func buildResult(assistantEv *EventRow, allEvents []EventRow, errorMsg string) *
 		}
 	}
 
+	// Images the agent produced during the turn, e.g. generated images
+	// or rendered diagrams.
+	seen := map[string]bool{}
+	for _, ev := range allEvents {
+		msg := EventToStored(ev)
+		if msg.Sender != SenderAgent {
+			continue
+		}
+		for _, p := range msg.Parts {
+			if p.Type == PartImage && p.UploadID != "" && !seen[p.UploadID] {
+				seen[p.UploadID] = true
+				result.Images = append(result.Images, p)
+			}
+		}
+	}
+
 	stats := ComputeStatsFromEvents(allEvents)
 	result.Model = stats.Model
 	result.Provider = stats.Provider
@@ -175,11 +273,7 @@ func FormatResult(result *OneShotResult, format string) (string, error) {
 	case "text":
 		return result.Content, nil
 	case "markdown":
-		w := termWidth()
-		if w > maxMessageWidth {
-			w = maxMessageWidth
-		}
-		return renderMarkdown(result.Content, w), nil
+		return RenderMarkdown(result.Content), nil
 	case "json":
 		b, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
@@ -198,22 +292,43 @@ func termWidth() int {
 	return 80
 }
 
-// sseReadEvents opens a single SSE connection and sends parsed events to ch.
-// It closes ch when done. No reconnection — fails fast for one-shot use.
+// sseReadEvents follows the branch's SSE stream and sends parsed events
+// to ch, closing it when done. A stream that drops is reconnected; the
+// snapshot a reconnection starts with is passed on as appends of the
+// events not seen yet and updates of the rest, so none are lost.
 func sseReadEvents(ctx context.Context, baseURL, branchID string, ch chan<- sseEvent) {
 	defer close(ch)
-
-	body, err := connectSSE(ctx, baseURL, branchID)
-	if err != nil {
-		ch <- sseEvent{Err: err}
-		return
+	send := func(ev sseEvent) {
+		select {
+		case ch <- ev:
+		case <-ctx.Done():
+		}
 	}
-	defer body.Close()
 
-	readErr := readSSEStream(ctx, body, func(ev sseEvent) {
-		ch <- ev
+	client := ellieapi.New(baseURL, &http.Client{Transport: Transport})
+	path := fmt.Sprintf("/api/chat/branches/%s/events/sse", branchID)
+	maxSeq, snapshotted := 0, false
+	err := client.Stream(ctx, path, ellieapi.StreamOptions{}, func(e ellieapi.Event) error {
+		dispatchSSEEvent(e.Type, e.Data, func(ev sseEvent) {
+			if ev.Kind == "snapshot" && snapshotted {
+				for _, row := range ev.Rows {
+					kind := "update"
+					if row.Seq > maxSeq {
+						kind, maxSeq = "append", row.Seq
+					}
+					send(sseEvent{Kind: kind, Row: row})
+				}
+				return
+			}
+			for _, row := range append(ev.Rows, ev.Row) {
+				maxSeq = max(maxSeq, row.Seq)
+			}
+			snapshotted = snapshotted || ev.Kind == "snapshot"
+			send(ev)
+		})
+		return nil
 	})
-	if readErr != nil {
-		ch <- sseEvent{Err: readErr}
+	if err != nil && ctx.Err() == nil {
+		send(sseEvent{Err: err})
 	}
 }
@@ -0,0 +1,122 @@
+package chatui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// CheckOutgoing, when set, checks text about to be sent to the model — the
+// prompt, or the contents of an attached text file, named by name — and
+// returns the text to send in its place and a note on what it found or
+// changed, or an error to send nothing. The CLI sets it to its safety
+// policy.
+var CheckOutgoing func(name, text string) (string, string, error)
+
+// maxCheckedFile bounds the attachments CheckOutgoing reads; larger ones
+// are sent unchecked.
+const maxCheckedFile = 10 << 20
+
+// outgoing is a message that passed CheckOutgoing.
+type outgoing struct {
+	prompt string
+	files  []PendingAttachment
+	notes  []string
+	// dir holds the changed copies of attachments, removed once sent.
+	dir string
+}
+
+// checkOutgoing runs CheckOutgoing on prompt and on the text files among
+// files. An attachment it changes is replaced by a changed copy.
+func checkOutgoing(prompt string, files []PendingAttachment) (*outgoing, error) {
+	out := &outgoing{prompt: prompt, files: files}
+	if CheckOutgoing == nil {
+		return out, nil
+	}
+	if prompt != "" {
+		text, note, err := CheckOutgoing("the prompt", prompt)
+		if err != nil {
+			return nil, err
+		}
+		out.prompt = text
+		out.addNote(note)
+	}
+	out.files = make([]PendingAttachment, len(files))
+	for i, f := range files {
+		out.files[i] = f
+		data, ok := readText(f)
+		if !ok {
+			continue
+		}
+		text, note, err := CheckOutgoing(f.Name, string(data))
+		if err != nil {
+			out.cleanup()
+			return nil, err
+		}
+		out.addNote(note)
+		if text == string(data) {
+			continue
+		}
+		copied, err := out.writeCopy(f, text)
+		if err != nil {
+			out.cleanup()
+			return nil, err
+		}
+		out.files[i] = copied
+	}
+	return out, nil
+}
+
+func (o *outgoing) addNote(note string) {
+	if note != "" {
+		o.notes = append(o.notes, note)
+	}
+}
+
+// writeCopy writes text to a file named like f in o.dir.
+func (o *outgoing) writeCopy(f PendingAttachment, text string) (PendingAttachment, error) {
+	if o.dir == "" {
+		dir, err := os.MkdirTemp("", "ellie-outgoing-")
+		if err != nil {
+			return f, err
+		}
+		o.dir = dir
+	}
+	// Attachments may share a name; give each copy a directory.
+	dir, err := os.MkdirTemp(o.dir, "")
+	if err != nil {
+		return f, err
+	}
+	path := filepath.Join(dir, f.Name)
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		return f, err
+	}
+	f.FilePath = path
+	f.Size = int64(len(text))
+	return f, nil
+}
+
+// cleanup removes the changed copies.
+func (o *outgoing) cleanup() {
+	if o.dir != "" {
+		os.RemoveAll(o.dir)
+	}
+}
+
+// readText returns the contents of f when it is text: UTF-8 without NUL
+// bytes, and small enough to check.
+func readText(f PendingAttachment) ([]byte, bool) {
+	switch f.Category {
+	case "image", "video", "audio":
+		return nil, false
+	}
+	if f.Size > maxCheckedFile {
+		return nil, false
+	}
+	data, err := os.ReadFile(f.FilePath)
+	if err != nil || len(data) > maxCheckedFile || bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return nil, false
+	}
+	return data, true
+}
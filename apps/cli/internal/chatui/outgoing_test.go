@@ -0,0 +1,60 @@
+package chatui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckOutgoing(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) PendingAttachment {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return PendingAttachment{FilePath: path, Name: name, Size: int64(len(content)), Category: "text"}
+	}
+	env := write(".env", "KEY=secret")
+	notes := write("notes.md", "nothing here")
+	bin := write("data.bin", "KEY=\x00secret")
+
+	defer func() { CheckOutgoing = nil }()
+	CheckOutgoing = func(name, text string) (string, string, error) {
+		if strings.Contains(text, "block") {
+			return "", "", errors.New("blocked")
+		}
+		if !strings.Contains(text, "secret") {
+			return text, "", nil
+		}
+		return strings.ReplaceAll(text, "secret", "[REDACTED]"), "redacted " + name, nil
+	}
+
+	out, err := checkOutgoing("my secret", []PendingAttachment{env, notes, bin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.prompt != "my [REDACTED]" || strings.Join(out.notes, "; ") != "redacted the prompt; redacted .env" {
+		t.Errorf("prompt = %q, notes = %q", out.prompt, out.notes)
+	}
+	if out.files[1] != notes || out.files[2] != bin {
+		t.Error("unchanged attachments were copied")
+	}
+	copied := out.files[0]
+	if data, _ := os.ReadFile(copied.FilePath); string(data) != "KEY=[REDACTED]" || copied.Name != ".env" {
+		t.Errorf("copy of .env at %s holds %q", copied.FilePath, data)
+	}
+	if data, _ := os.ReadFile(env.FilePath); string(data) != "KEY=secret" {
+		t.Error("the attached file itself was changed")
+	}
+	out.cleanup()
+	if _, err := os.Stat(copied.FilePath); !os.IsNotExist(err) {
+		t.Error("cleanup left the copy")
+	}
+
+	if _, err := checkOutgoing("please block", nil); err == nil {
+		t.Error("a blocked prompt was let through")
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/x/exp/charmtone"
+
+	"ellie/apps/cli/internal/themes"
 )
 
 // ThemeMode selects between dark and light color schemes.
@@ -18,7 +20,7 @@ const (
 // CurrentTheme is the active theme mode.
 var CurrentTheme ThemeMode
 
-// Ellie TUI palette — all colors sourced from charmtone.
+// Ellie TUI palette — built-in colors sourced from charmtone.
 // These are set by ApplyTheme and read by style builders.
 var (
 	colorAccent  color.Color
@@ -37,27 +39,76 @@ func init() {
 
 // ApplyTheme sets all palette colors for the given mode and rebuilds styles.
 func ApplyTheme(mode ThemeMode) {
-	CurrentTheme = mode
-	switch mode {
-	case ThemeLight:
-		colorAccent = lipgloss.Color(charmtone.Pickle.Hex())  // darker teal
-		colorUser = lipgloss.Color(charmtone.Oceania.Hex())   // darker blue
-		colorMemory = lipgloss.Color(charmtone.Prince.Hex())  // darker purple
-		colorSystem = lipgloss.Color(charmtone.Tang.Hex())    // warm orange (same)
-		colorMuted = lipgloss.Color(charmtone.Squid.Hex())    // gray (same)
-		colorDim = lipgloss.Color(charmtone.Smoke.Hex())      // lighter gray
-		colorSubtle = lipgloss.Color(charmtone.Ash.Hex())     // light gray (borders)
-		colorSurface = lipgloss.Color(charmtone.Salt.Hex())   // very light surface
-	default: // ThemeDark
-		colorAccent = lipgloss.Color(charmtone.Guac.Hex())    // teal primary
-		colorUser = lipgloss.Color(charmtone.Anchovy.Hex())   // blue
-		colorMemory = lipgloss.Color(charmtone.Orchid.Hex())  // purple
-		colorSystem = lipgloss.Color(charmtone.Tang.Hex())    // warm orange
-		colorMuted = lipgloss.Color(charmtone.Squid.Hex())    // gray (labels, thinking)
-		colorDim = lipgloss.Color(charmtone.Oyster.Hex())     // dim gray (results, status)
-		colorSubtle = lipgloss.Color(charmtone.Iron.Hex())    // darker gray (borders, scrollbar)
-		colorSurface = lipgloss.Color(charmtone.Charcoal.Hex()) // dark surface (input border)
+	name := themes.Dark
+	if mode == ThemeLight {
+		name = themes.Light
+	}
+	applyColors(themes.Theme{Name: name, Base: name, Colors: builtinColors(mode)})
+}
+
+// ApplyCustomTheme applies a theme's colors over those of its base mode.
+func ApplyCustomTheme(t themes.Theme) {
+	t.Colors = builtinColors(themeMode(t.Base)).Merge(t.Colors)
+	applyColors(t)
+}
+
+// ActiveTheme returns the theme in use, with every color filled in.
+func ActiveTheme() themes.Theme {
+	return activeTheme
+}
+
+// activeTheme is the last theme applied.
+var activeTheme themes.Theme
+
+func themeMode(base string) ThemeMode {
+	if base == themes.Light {
+		return ThemeLight
+	}
+	return ThemeDark
+}
+
+// builtinColors returns the colors of the dark or light theme.
+func builtinColors(mode ThemeMode) themes.Colors {
+	if mode == ThemeLight {
+		return themes.Colors{
+			Accent:     charmtone.Pickle.Hex(),  // darker teal
+			User:       charmtone.Oceania.Hex(), // darker blue
+			Memory:     charmtone.Prince.Hex(),  // darker purple
+			System:     charmtone.Tang.Hex(),    // warm orange (same)
+			Muted:      charmtone.Squid.Hex(),   // gray (same)
+			Dim:        charmtone.Smoke.Hex(),   // lighter gray
+			Subtle:     charmtone.Ash.Hex(),     // light gray (borders)
+			Surface:    charmtone.Salt.Hex(),    // very light surface
+			Background: "#f5f5f4",               // stone-100 — matches FE light bg
+		}
 	}
+	return themes.Colors{
+		Accent:     charmtone.Guac.Hex(),     // teal primary
+		User:       charmtone.Anchovy.Hex(),  // blue
+		Memory:     charmtone.Orchid.Hex(),   // purple
+		System:     charmtone.Tang.Hex(),     // warm orange
+		Muted:      charmtone.Squid.Hex(),    // gray (labels, thinking)
+		Dim:        charmtone.Oyster.Hex(),   // dim gray (results, status)
+		Subtle:     charmtone.Iron.Hex(),     // darker gray (borders, scrollbar)
+		Surface:    charmtone.Charcoal.Hex(), // dark surface (input border)
+		Background: "#0a0a0a",                // neutral-950 — matches FE dark bg
+	}
+}
+
+// applyColors sets the palette from a theme with every color filled in
+// and rebuilds styles.
+func applyColors(t themes.Theme) {
+	activeTheme = t
+	CurrentTheme = themeMode(t.Base)
+	c := t.Colors
+	colorAccent = lipgloss.Color(c.Accent)
+	colorUser = lipgloss.Color(c.User)
+	colorMemory = lipgloss.Color(c.Memory)
+	colorSystem = lipgloss.Color(c.System)
+	colorMuted = lipgloss.Color(c.Muted)
+	colorDim = lipgloss.Color(c.Dim)
+	colorSubtle = lipgloss.Color(c.Subtle)
+	colorSurface = lipgloss.Color(c.Surface)
 	rebuildViewStyles()
 	rebuildDialogStyles()
 	rebuildAnimStyles()
@@ -76,8 +127,5 @@ func ToggleTheme() {
 // ThemeBgHex returns the background color hex for the current theme,
 // used for OSC 11 terminal background signaling.
 func ThemeBgHex() string {
-	if CurrentTheme == ThemeLight {
-		return "#f5f5f4" // stone-100 — matches FE light bg
-	}
-	return "#0a0a0a" // neutral-950 — matches FE dark bg
+	return activeTheme.Colors.Background
 }
@@ -1,13 +1,13 @@
 package chatui
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
+
+	"ellie/apps/cli/pkg/ellieapi"
 )
 
 // sseEvent is a parsed SSE event used by both the TUI SSE client and
@@ -32,7 +32,7 @@ func connectSSE(ctx context.Context, baseURL, branchID string) (io.ReadCloser, e
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Cache-Control", "no-cache")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := (&http.Client{Transport: Transport}).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("SSE connect: %w", err)
 	}
@@ -49,42 +49,20 @@ func connectSSE(ctx context.Context, baseURL, branchID string) (io.ReadCloser, e
 // each parsed event. It blocks until the stream ends, ctx is cancelled,
 // or an error occurs. Returns nil on clean EOF.
 func readSSEStream(ctx context.Context, body io.Reader, onEvent func(sseEvent)) error {
-	scanner := bufio.NewScanner(body)
-	scanner.Buffer(make([]byte, 0, 1024*1024), 10*1024*1024)
-
-	var eventType string
-	var dataLines []string
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if line == "" {
-			// End of event — dispatch
-			if eventType != "" && len(dataLines) > 0 {
-				data := strings.Join(dataLines, "\n")
-				dispatchSSEEvent(eventType, data, onEvent)
-			}
-			eventType = ""
-			dataLines = dataLines[:0]
-			continue
+	events := ellieapi.NewEventReader(body)
+	for {
+		ev, err := events.Next()
+		if err == io.EOF {
+			return nil // clean EOF
 		}
-
-		if strings.HasPrefix(line, "event:") {
-			eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
-		} else if strings.HasPrefix(line, "data:") {
-			dataLines = append(dataLines, strings.TrimPrefix(line, "data:"))
-		}
-		// Ignore id:, retry:, comments (:)
-	}
-
-	if err := scanner.Err(); err != nil {
-		if ctx.Err() != nil {
-			return nil // context cancelled — clean shutdown
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil // context cancelled — clean shutdown
+			}
+			return fmt.Errorf("SSE read: %w", err)
 		}
-		return fmt.Errorf("SSE read: %w", err)
+		dispatchSSEEvent(ev.Type, ev.Data, onEvent)
 	}
-
-	return nil // clean EOF
 }
 
 // snapshotData matches the server's snapshot envelope:
@@ -110,6 +110,9 @@ type StoredMessage struct {
 	EventType       string        `json:"eventType,omitempty"`
 	ParentMessageID string        `json:"parentMessageId,omitempty"`
 	RunID           string        `json:"runId,omitempty"`
+	StopReason      string        `json:"stopReason,omitempty"`
+
+	continuedBy string // ID of the last continuation stitched into this message
 }
 
 // EventRow mirrors FE EventRow from the SSE stream.
@@ -153,6 +156,12 @@ type AssistantCurrent struct {
 	BranchID string `json:"branchId"`
 }
 
+// CreatedThread is the POST /api/threads response shape.
+type CreatedThread struct {
+	ThreadID string `json:"threadId"`
+	BranchID string `json:"branchId"`
+}
+
 // StatusResponse is the /api/status response shape.
 type StatusResponse struct {
 	ConnectedClients int  `json:"connectedClients"`
@@ -166,6 +175,7 @@ type PendingAttachment struct {
 	Mime     string // MIME type (from extension)
 	Size     int64  // file size in bytes
 	Category string // "image", "video", "audio", "text", "file"
+	Image    string // dimensions and format of an image, e.g. "1024×768 PNG"
 }
 
 // AttachmentResult is the upload result sent alongside the message.
@@ -185,6 +195,7 @@ const (
 	DialogThreads
 	DialogBranchInfo
 	DialogClearConfirm
+	DialogDrafts
 )
 
 // SlashCommand defines a chat command.
@@ -198,5 +209,7 @@ var Commands = []SlashCommand{
 	{Name: "threads", Description: "List all threads"},
 	{Name: "info", Description: "Show current branch info"},
 	{Name: "transcript", Description: "Save branch transcript"},
+	{Name: "drafts", Description: "Recover unsent drafts"},
+	{Name: "continue", Description: "Continue an answer cut off at the token limit"},
 	{Name: "theme", Description: "Toggle light/dark mode"},
 }
@@ -90,6 +90,11 @@ func renderStatusLine(m *Model, width int) string {
 	switch m.connState {
 	case StateConnected:
 		connIndicator = connectedStyle.Render("● connected")
+		if m.notice != "" {
+			connIndicator += errorStyle.Render(" — " + m.notice)
+		} else if _, ok := m.truncatedReply(); ok {
+			connIndicator += dimStyle.Render(" — answer cut off, ctrl+o to continue")
+		}
 	case StateConnecting:
 		connIndicator = connectingStyle.Render("◌ connecting...")
 	case StateError:
@@ -102,6 +107,9 @@ func renderStatusLine(m *Model, width int) string {
 	}
 
 	branchInfo := dimStyle.Render(fmt.Sprintf("branch: %s", truncateID(m.branchID)))
+	if m.capabilities != nil && m.capabilities.ID != "" {
+		branchInfo = dimStyle.Render(m.capabilities.ID+" · ") + branchInfo
+	}
 
 	left := connIndicator
 	right := branchInfo
@@ -127,6 +135,7 @@ func renderMessages(m *Model, width int) string {
 	}
 
 	tg := ComputeToolGrouping(m.messages, m.streamingMsg)
+	messages, streamingMsg := stitchContinuations(m.messages, m.streamingMsg)
 
 	var b strings.Builder
 
@@ -134,7 +143,7 @@ func renderMessages(m *Model, width int) string {
 	// under a single sender label (mirrors FE assistant-turn grouping).
 	activeRunID := ""
 
-	for _, msg := range m.messages {
+	for _, msg := range messages {
 		if tg.HiddenMessageIDs[msg.ID] {
 			continue
 		}
@@ -156,6 +165,9 @@ func renderMessages(m *Model, width int) string {
 		// Cache key includes showSender state since the same message renders differently
 		// depending on whether it's the first in a run group.
 		cacheKey := msg.ID
+		if msg.continuedBy != "" {
+			cacheKey += "+" + msg.continuedBy
+		}
 		if !showSender {
 			cacheKey += ":nosender"
 		}
@@ -176,12 +188,12 @@ func renderMessages(m *Model, width int) string {
 	}
 
 	// Render streaming message (never cached).
-	if m.streamingMsg != nil {
+	if streamingMsg != nil {
 		streamShowSender := true
-		if m.streamingMsg.RunID != "" && m.streamingMsg.RunID == activeRunID {
+		if streamingMsg.RunID != "" && streamingMsg.RunID == activeRunID {
 			streamShowSender = false
 		}
-		b.WriteString(renderMessage(*m.streamingMsg, tg, contentWidth, m.activeAnims, m.audioPlayingID, streamShowSender))
+		b.WriteString(renderMessage(*streamingMsg, tg, contentWidth, m.activeAnims, m.audioPlayingID, streamShowSender))
 		b.WriteString("\n")
 	}
 
@@ -379,6 +391,13 @@ func renderPart(part ContentPart, tg ToolGrouping, width int, anims map[string]*
 		}
 		return dimStyle.Render("  \u266b ") + dimStyle.Render(label) + " " + toolCallStyle.Render("["+"\u25b6"+" Play]")
 
+	case PartImage:
+		label := "Image"
+		if part.Mime != "" {
+			label += " (" + part.Mime + ")"
+		}
+		return dimStyle.Render("  \u25a3 " + label)
+
 	case PartThinking:
 		return "" // Already rendered at message level
 
@@ -444,6 +463,9 @@ func renderAttachmentBar(attachments []PendingAttachment, cursor int, selected b
 	var b strings.Builder
 	for i, p := range pills {
 		text := fmt.Sprintf("[%s #%d]", p.label, p.num)
+		if img := attachments[i].Image; img != "" {
+			text = fmt.Sprintf("[%s #%d %s]", p.label, p.num, img)
+		}
 		if selected && i == cursor {
 			b.WriteString(attachmentSelectedStyle.Render(text))
 		} else {
@@ -0,0 +1,162 @@
+// Package compat decides whether this CLI can work with an ellie server,
+// from the version ranges the server advertises in its handshake and the
+// server versions the CLI itself knows to be broken.
+package compat
+
+import (
+	"fmt"
+	"strings"
+
+	"ellie/apps/cli/internal/selfupdate"
+)
+
+// Header carries the CLI version on every request to the server.
+const Header = "X-Ellie-CLI-Version"
+
+// HandshakePath is the server endpoint that describes which CLI versions
+// it supports.
+const HandshakePath = "/api/version"
+
+// Handshake is the server's answer on HandshakePath.
+type Handshake struct {
+	// Version is the server's own version.
+	Version string `json:"version"`
+	CLI     struct {
+		// Min and Max bound the CLI versions the server supports, both
+		// inclusive. Either may be empty.
+		Min    string   `json:"min,omitempty"`
+		Max    string   `json:"max,omitempty"`
+		Broken []Broken `json:"broken,omitempty"`
+	} `json:"cli"`
+}
+
+// Broken marks a range of versions as unusable with the other side.
+type Broken struct {
+	// Versions is a constraint such as "1.4.0" or ">=1.4.0 <1.4.3".
+	Versions string `json:"versions"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// KnownBroken lists server versions this CLI is known not to work with.
+// Add an entry when a release breaks against an older or newer server in
+// a way the server's handshake can't describe.
+var KnownBroken []Broken
+
+// Level is how serious a version mismatch is.
+type Level int
+
+const (
+	// OK means the versions are compatible, or can't be compared.
+	OK Level = iota
+	// Warn means the versions are outside the supported range; most
+	// commands will likely still work.
+	Warn
+	// Block means the combination is known to be broken.
+	Block
+)
+
+// Fixes suggested by a Verdict.
+const (
+	FixNone = iota
+	FixUpdateCLI
+	FixUpgradeServer
+)
+
+// Verdict is the outcome of Check.
+type Verdict struct {
+	Level   Level
+	Message string
+	Fix     int
+}
+
+// Check compares the CLI version cli with the server's handshake.
+// Development builds of either side are never flagged.
+func Check(cli string, h Handshake) Verdict {
+	cliKnown := isRelease(cli)
+	serverKnown := isRelease(h.Version)
+	server := h.Version
+	if server == "" {
+		server = "unknown"
+	}
+
+	if cliKnown {
+		for _, b := range h.CLI.Broken {
+			if ok, err := Matches(b.Versions, cli); err == nil && ok {
+				return Verdict{Block, withReason(fmt.Sprintf("ellie %s does not work with server %s", cli, server), b.Reason), FixUpdateCLI}
+			}
+		}
+	}
+	if serverKnown {
+		for _, b := range KnownBroken {
+			if ok, err := Matches(b.Versions, h.Version); err == nil && ok {
+				return Verdict{Block, withReason(fmt.Sprintf("ellie %s does not work with server %s", cli, server), b.Reason), FixUpgradeServer}
+			}
+		}
+	}
+	if !cliKnown {
+		return Verdict{}
+	}
+	if h.CLI.Min != "" && selfupdate.CompareVersions(cli, h.CLI.Min) < 0 {
+		return Verdict{Warn, fmt.Sprintf("ellie %s is older than server %s supports (%s or newer)", cli, server, h.CLI.Min), FixUpdateCLI}
+	}
+	if h.CLI.Max != "" && selfupdate.CompareVersions(cli, h.CLI.Max) > 0 {
+		return Verdict{Warn, fmt.Sprintf("ellie %s is newer than server %s supports (up to %s)", cli, server, h.CLI.Max), FixUpgradeServer}
+	}
+	return Verdict{}
+}
+
+// Matches reports whether version v satisfies constraint: space-separated
+// terms that must all hold, each a version optionally prefixed by one of
+// =, <, <=, > or >=.
+func Matches(constraint, v string) (bool, error) {
+	terms := strings.Fields(constraint)
+	if len(terms) == 0 {
+		return false, fmt.Errorf("empty version constraint")
+	}
+	for _, term := range terms {
+		op, want := splitOp(term)
+		if want == "" {
+			return false, fmt.Errorf("invalid version constraint %q", constraint)
+		}
+		c := selfupdate.CompareVersions(v, want)
+		var ok bool
+		switch op {
+		case "", "=":
+			ok = c == 0
+		case "<":
+			ok = c < 0
+		case "<=":
+			ok = c <= 0
+		case ">":
+			ok = c > 0
+		case ">=":
+			ok = c >= 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func splitOp(term string) (op, version string) {
+	for _, op := range []string{"<=", ">=", "<", ">", "="} {
+		if v, ok := strings.CutPrefix(term, op); ok {
+			return op, v
+		}
+	}
+	return "", term
+}
+
+// isRelease reports whether v is a release version rather than a
+// development build.
+func isRelease(v string) bool {
+	return v != "" && v != "dev"
+}
+
+func withReason(msg, reason string) string {
+	if reason == "" {
+		return msg
+	}
+	return msg + ": " + reason
+}
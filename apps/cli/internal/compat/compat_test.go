@@ -0,0 +1,71 @@
+package compat
+
+import "testing"
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		constraint, v string
+		want          bool
+	}{
+		{"1.4.0", "1.4.0", true},
+		{"=1.4.0", "1.4.1", false},
+		{">=1.4.0 <1.4.3", "1.4.2", true},
+		{">=1.4.0 <1.4.3", "1.4.3", false},
+		{"<=1.2", "1.2.0", true},
+		{">1.2.0", "1.3.0-beta.1", true},
+	}
+	for _, tt := range tests {
+		got, err := Matches(tt.constraint, tt.v)
+		if err != nil {
+			t.Fatalf("Matches(%q, %q): %v", tt.constraint, tt.v, err)
+		}
+		if got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.constraint, tt.v, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "   ", ">="} {
+		if _, err := Matches(bad, "1.0.0"); err == nil {
+			t.Errorf("Matches(%q) should fail", bad)
+		}
+	}
+}
+
+func handshake(server, min, max string, broken ...Broken) Handshake {
+	var h Handshake
+	h.Version = server
+	h.CLI.Min, h.CLI.Max, h.CLI.Broken = min, max, broken
+	return h
+}
+
+func TestCheck(t *testing.T) {
+	defer func(old []Broken) { KnownBroken = old }(KnownBroken)
+	KnownBroken = []Broken{{Versions: "<1.0.0", Reason: "no streaming API"}}
+
+	tests := []struct {
+		name  string
+		cli   string
+		h     Handshake
+		level Level
+		fix   int
+	}{
+		{"in range", "1.2.0", handshake("1.5.0", "1.0.0", "1.3.0"), OK, FixNone},
+		{"no bounds", "1.2.0", handshake("1.5.0", "", ""), OK, FixNone},
+		{"too old", "0.9.0", handshake("1.5.0", "1.0.0", ""), Warn, FixUpdateCLI},
+		{"too new", "2.0.0", handshake("1.5.0", "", "1.9.9"), Warn, FixUpgradeServer},
+		{"broken cli", "1.2.1", handshake("1.5.0", "1.0.0", "", Broken{Versions: ">=1.2.0 <1.2.2"}), Block, FixUpdateCLI},
+		{"broken server", "1.2.0", handshake("0.8.0", "", ""), Block, FixUpgradeServer},
+		{"dev cli", "dev", handshake("1.5.0", "1.0.0", "1.1.0", Broken{Versions: "<9"}), OK, FixNone},
+		{"dev server", "1.2.0", handshake("dev", "", ""), OK, FixNone},
+		{"bad constraint ignored", "1.2.0", handshake("1.5.0", "", "", Broken{Versions: ">="}), OK, FixNone},
+	}
+	for _, tt := range tests {
+		v := Check(tt.cli, tt.h)
+		if v.Level != tt.level || v.Fix != tt.fix {
+			t.Errorf("%s: Check = %+v, want level %d fix %d", tt.name, v, tt.level, tt.fix)
+		}
+		if v.Level != OK && v.Message == "" {
+			t.Errorf("%s: verdict has no message", tt.name)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// EncodeValue renders v as a TOML value.
+func EncodeValue(v any) string {
+	switch x := v.(type) {
+	case string:
+		return strconv.Quote(x)
+	case []any:
+		parts := make([]string, len(x))
+		for i, e := range x {
+			parts[i] = EncodeValue(e)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+// encodeKey renders a dotted key, quoting the parts that need it.
+func encodeKey(parts []string) string {
+	out := make([]string, len(parts))
+	for i, p := range parts {
+		out[i] = p
+		for j := 0; j < len(p); j++ {
+			if !isBareKeyChar(p[j]) {
+				out[i] = strconv.Quote(p)
+				break
+			}
+		}
+	}
+	return strings.Join(out, ".")
+}
+
+// SetInFile returns data with key set to v. An existing assignment is
+// rewritten in place; otherwise the key is added to its table, which is
+// created at the end of the file if needed. Comments and layout are kept.
+func SetInFile(data []byte, key string, v any) ([]byte, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	lines := splitLines(data)
+
+	if e, ok := doc.entries[key]; ok {
+		local := strings.TrimPrefix(key, e.table)
+		local = strings.TrimPrefix(local, ".")
+		indent := leadingSpace(lines[e.startLine-1])
+		repl := indent + encodeKey(strings.Split(local, ".")) + " = " + EncodeValue(v) + "\n"
+		lines = append(lines[:e.startLine-1], append([]string{repl}, lines[e.endLine:]...)...)
+		return []byte(strings.Join(lines, "")), nil
+	}
+
+	// The deepest existing table the key belongs in.
+	best := -1
+	for i, t := range doc.tables {
+		if strings.HasPrefix(key, t.name+".") && (best < 0 || len(t.name) > len(doc.tables[best].name)) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		t := doc.tables[best]
+		at := t.line // insert after the header...
+		for k, e := range doc.entries {
+			if e.table == t.name && e.endLine > at && strings.HasPrefix(k, t.name+".") {
+				at = e.endLine // ...or after the table's last assignment
+			}
+		}
+		local := strings.Split(strings.TrimPrefix(key, t.name+"."), ".")
+		return insertLine(lines, at, encodeKey(local)+" = "+EncodeValue(v)+"\n"), nil
+	}
+
+	parts := strings.Split(key, ".")
+	if len(parts) == 1 {
+		// Top-level keys must come before the first table.
+		at := 0
+		for _, e := range doc.entries {
+			if e.table == "" && e.endLine > at {
+				at = e.endLine
+			}
+		}
+		if at == 0 && len(doc.tables) > 0 {
+			line := encodeKey(parts) + " = " + EncodeValue(v) + "\n\n"
+			return insertLine(lines, doc.tables[0].line-1, line), nil
+		}
+		if at == 0 {
+			at = len(lines)
+		}
+		return insertLine(lines, at, encodeKey(parts)+" = "+EncodeValue(v)+"\n"), nil
+	}
+
+	out := strings.Join(lines, "")
+	if out != "" {
+		if !strings.HasSuffix(out, "\n") {
+			out += "\n"
+		}
+		out += "\n"
+	}
+	out += "[" + encodeKey(parts[:len(parts)-1]) + "]\n" + encodeKey(parts[len(parts)-1:]) + " = " + EncodeValue(v) + "\n"
+	return []byte(out), nil
+}
+
+// UnsetInFile removes key's assignment from data, reporting whether it
+// was there.
+func UnsetInFile(data []byte, key string) ([]byte, bool, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return nil, false, err
+	}
+	e, ok := doc.entries[key]
+	if !ok {
+		return data, false, nil
+	}
+	lines := splitLines(data)
+	lines = append(lines[:e.startLine-1], lines[e.endLine:]...)
+	return []byte(strings.Join(lines, "")), true, nil
+}
+
+// splitLines splits data into lines that keep their newline; the last
+// line gets one if it lacks it.
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if last := len(lines) - 1; !strings.HasSuffix(lines[last], "\n") {
+		lines[last] += "\n"
+	}
+	return lines
+}
+
+// insertLine inserts text after the first n lines.
+func insertLine(lines []string, n int, text string) []byte {
+	out := append([]string{}, lines[:n]...)
+	out = append(out, text)
+	out = append(out, lines[n:]...)
+	return []byte(strings.Join(out, ""))
+}
+
+func leadingSpace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+// SetFile sets key to v in the config file at path, creating the file
+// and its directory if needed.
+func SetFile(path, key string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	out, err := SetInFile(data, key, v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return WriteFile(path, out)
+}
+
+// WriteFile replaces the config file at path with data, creating its
+// directory if needed.
+func WriteFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// UnsetFile removes key from the config file at path, reporting whether
+// it was set there.
+func UnsetFile(path, key string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	out, ok, err := UnsetInFile(data, key)
+	if err != nil || !ok {
+		if err != nil {
+			err = fmt.Errorf("%s: %w", path, err)
+		}
+		return false, err
+	}
+	return true, writeFileAtomic(path, out)
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSetInFile(t *testing.T) {
+	base := `# my settings
+default_model = "claude-sonnet"
+
+[server]
+url = "http://localhost:3000" # local
+
+[timeouts]
+auth = "30s"
+`
+	for _, tc := range []struct {
+		name, in, key string
+		val           any
+		want          string
+	}{
+		{"replace", base, "server.url", "https://ellie.example.com", `# my settings
+default_model = "claude-sonnet"
+
+[server]
+url = "https://ellie.example.com"
+
+[timeouts]
+auth = "30s"
+`},
+		{"add to table", base, "timeouts.auth status", "1m", `# my settings
+default_model = "claude-sonnet"
+
+[server]
+url = "http://localhost:3000" # local
+
+[timeouts]
+auth = "30s"
+"auth status" = "1m"
+`},
+		{"add top-level", base, "extra", true, `# my settings
+default_model = "claude-sonnet"
+extra = true
+
+[server]
+url = "http://localhost:3000" # local
+
+[timeouts]
+auth = "30s"
+`},
+		{"new table", base, "dev.filters", []any{"!cli", "web"}, base + `
+[dev]
+filters = ["!cli", "web"]
+`},
+		{"empty file", "", "ui.theme", "light", "[ui]\ntheme = \"light\"\n"},
+		{"top-level before tables", "[ui]\ntheme = \"dark\"\n", "default_model", "x", "default_model = \"x\"\n\n[ui]\ntheme = \"dark\"\n"},
+		{"multi-line array", "[dev]\nfilters = [\n  \"a\",\n  \"b\",\n]\nother = 1\n", "dev.filters", []any{"c"}, "[dev]\nfilters = [\"c\"]\nother = 1\n"},
+		{"no trailing newline", "a = 1", "b", int64(2), "a = 1\nb = 2\n"},
+	} {
+		got, err := SetInFile([]byte(tc.in), tc.key, tc.val)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if string(got) != tc.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tc.name, got, tc.want)
+		}
+		values, err := Parse(got)
+		if err != nil {
+			t.Errorf("%s: result does not parse: %v", tc.name, err)
+		} else if FormatValue(values[tc.key]) != FormatValue(tc.val) {
+			t.Errorf("%s: %s = %v after set", tc.name, tc.key, values[tc.key])
+		}
+	}
+}
+
+func TestUnsetInFile(t *testing.T) {
+	in := "a = 1\n[t]\nb = [\n 1,\n]\nc = 2\n"
+	got, ok, err := UnsetInFile([]byte(in), "t.b")
+	if err != nil || !ok || string(got) != "a = 1\n[t]\nc = 2\n" {
+		t.Errorf("UnsetInFile = %q, %v, %v", got, ok, err)
+	}
+	if _, ok, _ := UnsetInFile([]byte(in), "t.zz"); ok {
+		t.Error("unset of a missing key reported true")
+	}
+}
+
+func TestSetFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ellie", FileName)
+	if err := SetFile(path, "ui.theme", "light"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetFile(path, "timeouts.auth status", "1m"); err != nil {
+		t.Fatal(err)
+	}
+	values, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["ui.theme"] != "light" || values["timeouts.auth status"] != "1m" {
+		t.Errorf("values = %v", values)
+	}
+	if ok, err := UnsetFile(path, "ui.theme"); !ok || err != nil {
+		t.Errorf("UnsetFile = %v, %v", ok, err)
+	}
+	if ok, err := UnsetFile(filepath.Join(t.TempDir(), FileName), "ui.theme"); ok || err != nil {
+		t.Errorf("UnsetFile of a missing file = %v, %v", ok, err)
+	}
+}
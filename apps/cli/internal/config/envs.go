@@ -0,0 +1,49 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// Env is a named server environment, configured as an [envs.<name>]
+// table.
+type Env struct {
+	Name     string
+	URL      string
+	TokenEnv string // environment variable holding a bearer token, if any
+	Origin   string // layer the url came from
+}
+
+// Envs lists the configured environments by name.
+func (c *Config) Envs() []Env {
+	var names []string
+	for _, k := range c.Keys() {
+		rest, ok := strings.CutPrefix(k, "envs.")
+		if !ok {
+			continue
+		}
+		if name, ok := strings.CutSuffix(rest, ".url"); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	envs := make([]Env, 0, len(names))
+	for _, name := range names {
+		e, _ := c.Env(name)
+		envs = append(envs, e)
+	}
+	return envs
+}
+
+// Env returns the environment called name, which must have a url.
+func (c *Config) Env(name string) (Env, bool) {
+	url, ok := c.Get("envs." + name + ".url")
+	if !ok {
+		return Env{}, false
+	}
+	e := Env{Name: name, URL: FormatValue(url.Value), Origin: url.Origin}
+	if s, ok := c.String("envs." + name + ".token_env"); ok {
+		e.TokenEnv = s
+	}
+	return e, true
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrUnsetVariable is returned when a value refers to an environment
+// variable with ${VAR}, without a default, and VAR is unset or empty.
+var ErrUnsetVariable = errors.New("is not set")
+
+// Expand replaces environment variable references in s. ${VAR} is VAR's
+// value and must be set; ${VAR:-default} falls back to default when VAR
+// is unset or empty. $$ is a literal $, and any other $ is kept as is.
+func Expand(s string, getenv func(string) string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case '{':
+		default:
+			b.WriteByte('$')
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated %q", s[i:])
+		}
+		ref := s[i+2 : i+end]
+		name, def, hasDef := strings.Cut(ref, ":-")
+		if !validVarName(name) {
+			return "", fmt.Errorf("bad variable reference ${%s}", ref)
+		}
+		v := getenv(name)
+		switch {
+		case v != "":
+		case hasDef:
+			v = def
+		default:
+			return "", fmt.Errorf("${%s} %w", name, ErrUnsetVariable)
+		}
+		b.WriteString(v)
+		i += end
+	}
+	return b.String(), nil
+}
+
+func validVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// expandValue expands the references in v, a string or list of strings,
+// and converts the result to the type of the setting name, so that a
+// bool or number can come from the environment too.
+func expandValue(name string, v any, getenv func(string) string) (any, error) {
+	switch v := v.(type) {
+	case string:
+		if !strings.Contains(v, "$") {
+			return v, nil
+		}
+		s, err := Expand(v, getenv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if k, ok := Lookup(name); ok {
+			return k.Parse(s)
+		}
+		return s, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				out[i] = e
+				continue
+			}
+			var err error
+			if out[i], err = Expand(s, getenv); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// expand interpolates environment variables into the layer's values. A
+// setting that can't be expanded is dropped and reported.
+func (l *Layer) expand(getenv func(string) string) []error {
+	names := make([]string, 0, len(l.Values))
+	for k := range l.Values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, k := range names {
+		v, err := expandValue(k, l.Values[k], getenv)
+		if err != nil {
+			delete(l.Values, k)
+			errs = append(errs, fmt.Errorf("%s: %w", l.Path, err))
+			continue
+		}
+		l.Values[k] = v
+	}
+	return errs
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	env := map[string]string{"HOST": "api.example", "EMPTY": ""}
+	getenv := func(k string) string { return env[k] }
+	tests := []struct {
+		in, want, err string
+	}{
+		{in: "http://${HOST}:3000", want: "http://api.example:3000"},
+		{in: "${PORT:-3000}", want: "3000"},
+		{in: "${EMPTY:-fallback}", want: "fallback"},
+		{in: "${HOST:-unused}", want: "api.example"},
+		{in: "pa$$word $5 end$", want: "pa$word $5 end$"},
+		{in: "${MISSING}", err: "${MISSING} is not set"},
+		{in: "${EMPTY}", err: "${EMPTY} is not set"},
+		{in: "${1BAD}", err: "bad variable reference ${1BAD}"},
+		{in: "x ${HOST", err: `unterminated "${HOST"`},
+	}
+	for _, tt := range tests {
+		got, err := Expand(tt.in, getenv)
+		switch {
+		case tt.err != "" && (err == nil || err.Error() != tt.err):
+			t.Errorf("Expand(%q) error = %v, want %q", tt.in, err, tt.err)
+		case tt.err == "" && (err != nil || got != tt.want):
+			t.Errorf("Expand(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestLayerExpand(t *testing.T) {
+	env := map[string]string{"HOST": "h", "RESTART": "true", "FILTER": "web"}
+	l := Layer{Path: "ellie.toml", Values: Values{
+		"server.url":       "http://${HOST}",
+		"watchdog.restart": "${RESTART}",
+		"dev.filters":      []any{"${FILTER}", "!cli"},
+		"default_model":    "${MODEL}",
+		"watchdog.dump":    "${DUMP:-blue}",
+	}}
+	errs := l.expand(func(k string) string { return env[k] })
+	if len(errs) != 2 {
+		t.Fatalf("errs = %v", errs)
+	}
+	if !errors.Is(errs[0], ErrUnsetVariable) || errs[0].Error() != "ellie.toml: default_model: ${MODEL} is not set" {
+		t.Errorf("errs[0] = %v", errs[0])
+	}
+	if errs[1].Error() != `ellie.toml: watchdog.dump must be one of none, stacks, core, not "blue"` {
+		t.Errorf("errs[1] = %v", errs[1])
+	}
+	if _, ok := l.Values["default_model"]; ok {
+		t.Error("a setting that failed to expand was kept")
+	}
+	if l.Values["server.url"] != "http://h" || l.Values["watchdog.restart"] != true {
+		t.Errorf("values = %v", l.Values)
+	}
+	if f := l.Values["dev.filters"].([]any); f[0] != "web" || f[1] != "!cli" {
+		t.Errorf("dev.filters = %v", f)
+	}
+}
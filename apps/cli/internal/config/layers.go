@@ -0,0 +1,268 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ellie/apps/cli/internal/paths"
+)
+
+// FileName is the name of user and project config files.
+const FileName = "ellie.toml"
+
+// RCFileName is a per-repository override file, read above the project's
+// ellie.toml.
+const RCFileName = ".ellierc"
+
+// ToolTable holds ellie's settings in a project ellie.toml shared with
+// other tools.
+const ToolTable = "tool.ellie"
+
+// Layer is one source of settings.
+type Layer struct {
+	Name   string // team, user, project or ellierc
+	Path   string
+	Values Values
+	Prefix string // "tool.ellie." when the file keeps its settings there
+}
+
+// Config is a stack of layers, lowest precedence first. Environment
+// variables named in the schema override every layer.
+type Config struct {
+	Layers []Layer
+	Getenv func(string) string // os.Getenv when nil
+}
+
+// Setting is a resolved value and where it came from: "default", a
+// layer name, or "env NAME".
+type Setting struct {
+	Key    string
+	Value  any
+	Origin string
+	Path   string // file of the layer, if any
+}
+
+// Get resolves name: the environment, then the layers from the top, then
+// the schema default.
+func (c *Config) Get(name string) (Setting, bool) {
+	key, known := Lookup(name)
+	if known && key.Env != "" {
+		getenv := c.Getenv
+		if getenv == nil {
+			getenv = os.Getenv
+		}
+		for _, env := range append([]string{key.Env}, key.OldEnv...) {
+			if v := getenv(env); v != "" {
+				if parsed, err := key.Parse(v); err == nil {
+					return Setting{Key: name, Value: parsed, Origin: "env " + env}, true
+				}
+			}
+		}
+	}
+	for i := len(c.Layers) - 1; i >= 0; i-- {
+		l := c.Layers[i]
+		if v, ok := l.Values[name]; ok {
+			return Setting{Key: name, Value: v, Origin: l.Name, Path: l.Path}, true
+		}
+		if v, ok := l.replaced(name); ok {
+			return Setting{Key: name, Value: v, Origin: l.Name, Path: l.Path}, true
+		}
+	}
+	if known && key.Default != nil {
+		return Setting{Key: name, Value: key.Default, Origin: "default"}, true
+	}
+	return Setting{Key: name}, false
+}
+
+// replaced returns the value of a deprecated setting in l that name
+// replaces.
+func (l Layer) replaced(name string) (any, bool) {
+	olds := make([]string, 0, len(l.Values))
+	for old := range l.Values {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	for _, old := range olds {
+		if k, ok := Lookup(old); ok && k.ReplacedBy == name {
+			return l.Values[old], true
+		}
+	}
+	return nil, false
+}
+
+// String resolves name and formats its value.
+func (c *Config) String(name string) (string, bool) {
+	s, ok := c.Get(name)
+	if !ok {
+		return "", false
+	}
+	return FormatValue(s.Value), true
+}
+
+// Layer returns the highest-precedence layer with that name, or nil.
+func (c *Config) Layer(name string) *Layer {
+	for i := len(c.Layers) - 1; i >= 0; i-- {
+		if c.Layers[i].Name == name {
+			return &c.Layers[i]
+		}
+	}
+	return nil
+}
+
+// Keys lists the schema's keys and every key set in a layer, sorted.
+func (c *Config) Keys() []string {
+	seen := map[string]bool{}
+	var keys []string
+	add := func(k string) {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range Schema {
+		if !strings.Contains(k.Name, "*") {
+			add(k.Name)
+		}
+	}
+	for _, l := range c.Layers {
+		for k := range l.Values {
+			add(k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// UserPath returns the personal config file: ELLIE_CONFIG, else
+// ellie.toml in the platform config directory (see paths.Config).
+func UserPath() (string, error) {
+	if p := os.Getenv("ELLIE_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := paths.Config()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// FindProject returns the project config files — ellie.toml, then
+// .ellierc, lowest precedence first — in dir or the nearest parent that
+// has either.
+func FindProject(dir string) []string {
+	for {
+		var found []string
+		for _, name := range []string{FileName, RCFileName} {
+			p := filepath.Join(dir, name)
+			if info, err := os.Stat(p); err == nil && info.Mode().IsRegular() {
+				found = append(found, p)
+			}
+		}
+		if len(found) > 0 {
+			return found
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+// LoadProject loads a project config file. When it has a [tool.ellie]
+// table, those settings override the top-level ones and other tools'
+// tables are ignored.
+func LoadProject(path string) (Layer, error) {
+	values, err := LoadFile(path)
+	if err != nil {
+		return Layer{}, err
+	}
+	l := Layer{Name: "project", Path: path, Values: values}
+	if filepath.Base(path) == RCFileName {
+		l.Name = "ellierc"
+	}
+	prefix := ToolTable + "."
+	for k := range values {
+		if strings.HasPrefix(k, prefix) {
+			l.Prefix = prefix
+			break
+		}
+	}
+	if l.Prefix == "" {
+		return l, nil
+	}
+	l.Values = Values{}
+	for k, v := range values {
+		if !strings.HasPrefix(k, "tool.") {
+			l.Values[k] = v
+		}
+	}
+	for k, v := range values {
+		if local, ok := strings.CutPrefix(k, prefix); ok {
+			l.Values[local] = v
+		}
+	}
+	return l, nil
+}
+
+// LoadFile parses a config file; a missing file is empty.
+func LoadFile(path string) (Values, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Values{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	values, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// Load stacks the synced team config in teamDir, the user's config and
+// the project's ellie.toml and .ellierc found from cwd, and expands the
+// environment variables their values refer to. Layers that fail to load
+// and settings that fail to expand are left out, and their errors
+// returned alongside.
+func Load(teamDir, cwd string) (*Config, *TeamState, []error) {
+	c := &Config{}
+	var errs []error
+
+	var team *TeamState
+	if values, st, err := LoadTeam(teamDir); err == nil {
+		c.Layers = append(c.Layers, Layer{Name: "team", Path: filepath.Join(teamDir, teamFile), Values: values})
+		team = st
+	} else if !errors.Is(err, ErrNoTeamConfig) {
+		errs = append(errs, err)
+	}
+
+	user, err := UserPath()
+	if err == nil {
+		if values, err := LoadFile(user); err == nil {
+			c.Layers = append(c.Layers, Layer{Name: "user", Path: user, Values: values})
+		} else {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, project := range FindProject(cwd) {
+		if project == user {
+			continue
+		}
+		if l, err := LoadProject(project); err == nil {
+			c.Layers = append(c.Layers, l)
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	for i := range c.Layers {
+		errs = append(errs, c.Layers[i].expand(os.Getenv)...)
+	}
+	return c, team, errs
+}
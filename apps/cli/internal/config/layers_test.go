@@ -0,0 +1,179 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLookupAndParse(t *testing.T) {
+	k, ok := Lookup("timeouts.auth status")
+	if !ok || k.Kind != KindDuration || k.Name != "timeouts.auth status" {
+		t.Fatalf("Lookup(timeouts.auth status) = %+v, %v", k, ok)
+	}
+	if _, ok := Lookup("timeouts."); ok {
+		t.Error("Lookup matched an empty pattern suffix")
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Error("Lookup(nope) should fail")
+	}
+	if k, ok := Lookup("watchdog.dev.restart"); !ok || k.Kind != KindBool {
+		t.Errorf("Lookup(watchdog.dev.restart) = %+v, %v", k, ok)
+	}
+	for _, bad := range []string{"watchdog.dev", "watchdog.dev.x.restart", "envs..url"} {
+		if k, ok := Lookup(bad); ok {
+			t.Errorf("Lookup(%s) matched %+v", bad, k)
+		}
+	}
+
+	if _, err := k.Parse("soon"); err == nil {
+		t.Error("bad duration accepted")
+	}
+	dump, _ := Lookup("watchdog.dump")
+	if _, err := dump.Parse("purple"); err == nil || !strings.Contains(err.Error(), "none, stacks, core") {
+		t.Errorf("dump check: %v", err)
+	}
+	filters, _ := Lookup("dev.filters")
+	if v, err := filters.Parse("!cli, web ,"); err != nil || FormatValue(v) != `["!cli", "web"]` {
+		t.Errorf("list parse = %v, %v", v, err)
+	}
+	if err := filters.Check([]any{int64(1)}); err == nil {
+		t.Error("list of ints accepted")
+	}
+}
+
+func TestConfigGet(t *testing.T) {
+	env := map[string]string{}
+	c := &Config{
+		Layers: []Layer{
+			{Name: "team", Values: Values{"server.url": "https://team", "default_model": "team-model"}},
+			{Name: "user", Path: "/u/ellie.toml", Values: Values{"default_model": "user-model"}},
+		},
+		Getenv: func(k string) string { return env[k] },
+	}
+
+	for key, want := range map[string]string{
+		"server.url":    "https://team team",
+		"default_model": "user-model user",
+		"ui.theme":      "dark default",
+	} {
+		s, ok := c.Get(key)
+		if got := FormatValue(s.Value) + " " + s.Origin; !ok || got != want {
+			t.Errorf("Get(%s) = %q, want %q", key, got, want)
+		}
+	}
+	if _, ok := c.Get("timeouts.default"); ok {
+		t.Error("timeouts.default should be unset")
+	}
+
+	env["ELLIE_API_URL"] = "http://env"
+	if s, _ := c.Get("server.url"); s.Value != "http://env" || s.Origin != "env ELLIE_API_URL" {
+		t.Errorf("env override = %+v", s)
+	}
+
+	keys := strings.Join(c.Keys(), " ")
+	if keys != "agent.refresh_before context.embed_url crash_loop.failures crash_loop.window default_model dev.filters env http.compress_requests logs.keep logs.max_age logs.max_size permissions.profile proxy safety.pii safety.secrets server.url shutdown.grace timeouts.connect timeouts.default tls.ca_file tls.client_cert tls.client_key ui.images ui.theme update.check upstream.status_url watchdog.dump watchdog.restart watchdog.timeout" {
+		t.Errorf("Keys = %s", keys)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "xdg"))
+	t.Setenv("ELLIE_CONFIG", "")
+	user := filepath.Join(tmp, "xdg", "ellie", FileName)
+	os.MkdirAll(filepath.Dir(user), 0o755)
+	os.WriteFile(user, []byte("default_model = \"mine\"\n[ui]\ntheme = \"light\"\n"), 0o644)
+
+	project := filepath.Join(tmp, "repo")
+	os.MkdirAll(filepath.Join(project, "pkg", "sub"), 0o755)
+	os.WriteFile(filepath.Join(project, FileName), []byte("[ui]\ntheme = \"dark\"\n"), 0o644)
+
+	teamDir := filepath.Join(tmp, "team")
+	SaveTeam(teamDir, []byte("default_model = \"team\"\n[server]\nurl = \"https://team\"\n"), TeamState{Source: TeamSource{URL: SourceServer}, SyncedAt: time.Now()})
+
+	c, team, errs := Load(teamDir, filepath.Join(project, "pkg", "sub"))
+	if len(errs) > 0 || team == nil {
+		t.Fatalf("Load: %v, team %v", errs, team)
+	}
+	if len(c.Layers) != 3 || c.Layers[2].Path != filepath.Join(project, FileName) {
+		t.Fatalf("layers = %+v", c.Layers)
+	}
+	for key, want := range map[string]string{"default_model": "mine", "ui.theme": "dark", "server.url": "https://team"} {
+		if got, _ := c.String(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	os.WriteFile(user, []byte("broken ="), 0o644)
+	c, _, errs = Load(teamDir, tmp)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), user) || c.Layer("user") != nil || c.Layer("team") == nil {
+		t.Errorf("broken user config: errs %v, layers %+v", errs, c.Layers)
+	}
+}
+
+func TestLoadProjectOverrides(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("ELLIE_CONFIG", filepath.Join(tmp, "none.toml"))
+	t.Setenv("ELLIE_API_URL", "")
+	repo := filepath.Join(tmp, "repo")
+	os.MkdirAll(filepath.Join(repo, "apps", "web"), 0o755)
+	os.WriteFile(filepath.Join(repo, FileName), []byte(`default_model = "top"
+[ui]
+theme = "light"
+
+[tool.ellie]
+default_model = "tool"
+[tool.ellie.dev]
+filters = ["web"]
+
+[tool.other]
+x = 1
+`), 0o644)
+	os.WriteFile(filepath.Join(repo, RCFileName), []byte("[server]\nurl = \"http://localhost:4000\"\n[ui]\ntheme = \"dark\"\n"), 0o644)
+
+	c, _, errs := Load(filepath.Join(tmp, "team"), filepath.Join(repo, "apps", "web"))
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	p := c.Layer("project")
+	if p == nil || p.Prefix != "tool.ellie." || c.Layer("ellierc") == nil {
+		t.Fatalf("layers = %+v", c.Layers)
+	}
+	if _, ok := p.Values["tool.other.x"]; ok {
+		t.Error("another tool's table leaked into the settings")
+	}
+	for key, want := range map[string]string{
+		"default_model": "tool project",
+		"dev.filters":   `["web"] project`,
+		"ui.theme":      "dark ellierc",
+		"server.url":    "http://localhost:4000 ellierc",
+	} {
+		s, _ := c.Get(key)
+		if got := FormatValue(s.Value) + " " + s.Origin; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestEnvs(t *testing.T) {
+	c := &Config{
+		Layers: []Layer{
+			{Name: "team", Values: Values{"envs.staging.url": "https://staging", "envs.staging.token_env": "STAGING_TOKEN"}},
+			{Name: "user", Values: Values{"envs.local.url": "http://localhost:3000", "envs.broken.token_env": "X"}},
+		},
+		Getenv: func(string) string { return "" },
+	}
+	envs := c.Envs()
+	if len(envs) != 2 || envs[0].Name != "local" || envs[1].Name != "staging" {
+		t.Fatalf("Envs = %+v", envs)
+	}
+	if e := envs[1]; e.URL != "https://staging" || e.TokenEnv != "STAGING_TOKEN" || e.Origin != "team" {
+		t.Errorf("staging = %+v", e)
+	}
+	if _, ok := c.Env("broken"); ok {
+		t.Error("an environment without a url was accepted")
+	}
+}
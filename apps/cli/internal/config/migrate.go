@@ -0,0 +1,104 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// Migration is a deprecated setting in a config file.
+type Migration struct {
+	Line int
+	Key  string // as written, e.g. tool.ellie.ui.colour
+	Name string // the setting, e.g. ui.colour
+	// Replacement is the setting to move the value to, or "" when the
+	// change has to be made by hand.
+	Replacement string
+	Note        string // the schema's deprecation message
+}
+
+// To returns the key the value moves to, written the way Key is, or "".
+func (m Migration) To() string {
+	if m.Replacement == "" {
+		return ""
+	}
+	return strings.TrimSuffix(m.Key, m.Name) + m.Replacement
+}
+
+// Migrations lists the deprecated settings in a config file, in line
+// order. Settings under [tool.ellie] are found like top-level ones.
+func Migrations(data []byte) ([]Migration, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	var out []Migration
+	for key, e := range doc.entries {
+		name := key
+		if local, ok := strings.CutPrefix(key, ToolTable+"."); ok {
+			name = local
+		} else if strings.HasPrefix(key, "tool.") {
+			continue
+		}
+		k, ok := Lookup(name)
+		if !ok || k.Deprecated == "" {
+			continue
+		}
+		out = append(out, Migration{Line: e.startLine, Key: key, Name: name, Replacement: k.ReplacedBy, Note: k.Deprecated})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Line < out[j].Line })
+	return out, nil
+}
+
+// Migrate moves the value of every deprecated setting in a config file
+// that has a replacement to the replacement, keeping comments and layout.
+// Where the replacement is already set, the old setting is only removed:
+// it was being ignored. It returns the new file and the migrations made.
+func Migrate(data []byte) ([]byte, []Migration, error) {
+	migrations, err := Migrations(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var done []Migration
+	for _, m := range migrations {
+		if m.Replacement == "" {
+			continue
+		}
+		to := m.To()
+		if _, set := doc.entries[to]; !set {
+			if data, err = SetInFile(data, to, doc.values[m.Key]); err != nil {
+				return nil, nil, err
+			}
+			doc.entries[to] = entry{} // so a second old name for it is only removed
+		}
+		if data, _, err = UnsetInFile(data, m.Key); err != nil {
+			return nil, nil, err
+		}
+		done = append(done, m)
+	}
+	return data, done, nil
+}
+
+// EnvDeprecation is a deprecated environment variable that is set.
+type EnvDeprecation struct {
+	Var         string
+	Replacement string // the variable to set instead
+	Key         string // the setting both override
+}
+
+// EnvDeprecations lists the deprecated environment variables that are
+// set.
+func EnvDeprecations(getenv func(string) string) []EnvDeprecation {
+	var out []EnvDeprecation
+	for _, k := range Schema {
+		for _, old := range k.OldEnv {
+			if getenv(old) != "" {
+				out = append(out, EnvDeprecation{Var: old, Replacement: k.Env, Key: k.Name})
+			}
+		}
+	}
+	return out
+}
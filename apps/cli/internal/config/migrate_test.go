@@ -0,0 +1,97 @@
+package config
+
+import "testing"
+
+func TestMigrate(t *testing.T) {
+	saved := Schema
+	t.Cleanup(func() { Schema = saved })
+	Schema = append(Schema[:len(Schema):len(Schema)],
+		Key{Name: "ui.colour", Kind: KindString, Deprecated: "use ui.theme", ReplacedBy: "ui.theme"},
+		Key{Name: "hang.*", Kind: KindDuration, Deprecated: "use watchdog.*.timeout", ReplacedBy: "watchdog.*.timeout"},
+		Key{Name: "ui.blink", Kind: KindBool, Deprecated: "blinking was removed"},
+	)
+
+	data := []byte(`# personal settings
+[ui]
+colour = "light" # the old name
+blink = true
+
+[hang]
+dev = "2m"
+start = "5m"
+
+[watchdog.start]
+timeout = "1m"
+`)
+	migrations, err := Migrations(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 4 || migrations[0].Name != "ui.colour" || migrations[1].Replacement != "" ||
+		migrations[2].Replacement != "watchdog.dev.timeout" || migrations[3].Line != 8 {
+		t.Fatalf("Migrations = %+v", migrations)
+	}
+
+	out, done, err := Migrate(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 3 {
+		t.Errorf("Migrate made %+v", done)
+	}
+	want := `# personal settings
+[ui]
+blink = true
+theme = "light"
+
+[hang]
+
+[watchdog.start]
+timeout = "1m"
+
+[watchdog.dev]
+timeout = "2m"
+`
+	if string(out) != want {
+		t.Errorf("Migrate =\n%s\nwant\n%s", out, want)
+	}
+
+	out, done, err = Migrate([]byte("[tool.ellie]\nui.colour = \"light\"\n"))
+	if err != nil || len(done) != 1 || string(out) != "[tool.ellie]\nui.theme = \"light\"\n" {
+		t.Errorf("Migrate under [tool.ellie] = %q, %+v, %v", out, done, err)
+	}
+}
+
+func TestReplacedSettings(t *testing.T) {
+	saved := Schema
+	t.Cleanup(func() { Schema = saved })
+	Schema = append(Schema[:len(Schema):len(Schema)],
+		Key{Name: "ui.colour", Kind: KindString, Deprecated: "use ui.theme", ReplacedBy: "ui.theme"},
+		Key{Name: "status.url", Kind: KindString, Env: "ELLIE_STATUS_PAGE", OldEnv: []string{"ELLIE_STATUSPAGE"}},
+	)
+
+	env := map[string]string{"ELLIE_STATUSPAGE": "https://old.example.com"}
+	c := &Config{
+		Layers: []Layer{{Name: "user", Path: "u.toml", Values: Values{"ui.colour": "light"}}},
+		Getenv: func(k string) string { return env[k] },
+	}
+	if s, _ := c.Get("ui.theme"); s.Value != "light" || s.Origin != "user" {
+		t.Errorf("ui.theme from the old key = %+v", s)
+	}
+	if s, _ := c.Get("status.url"); s.Value != "https://old.example.com" || s.Origin != "env ELLIE_STATUSPAGE" {
+		t.Errorf("status.url from the old variable = %+v", s)
+	}
+	env["ELLIE_STATUS_PAGE"] = "https://new.example.com"
+	if s, _ := c.Get("status.url"); s.Origin != "env ELLIE_STATUS_PAGE" {
+		t.Errorf("status.url with both variables = %+v", s)
+	}
+
+	deps := EnvDeprecations(c.Getenv)
+	if len(deps) != 1 || deps[0].Var != "ELLIE_STATUSPAGE" || deps[0].Replacement != "ELLIE_STATUS_PAGE" {
+		t.Errorf("EnvDeprecations = %+v", deps)
+	}
+	got := c.Deprecations()
+	if len(got) != 2 || got[0] != "environment: ELLIE_STATUSPAGE is deprecated: use ELLIE_STATUS_PAGE" {
+		t.Errorf("Deprecations = %q", got)
+	}
+}
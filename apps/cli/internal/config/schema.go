@@ -0,0 +1,279 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of a setting's value.
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindDuration
+	KindBool
+	KindInt
+	KindList // list of strings
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindDuration:
+		return "duration"
+	case KindBool:
+		return "bool"
+	case KindInt:
+		return "int"
+	case KindList:
+		return "list"
+	}
+	return "string"
+}
+
+// Key describes a setting. A "*" in its Name stands for any one part of
+// a dotted key, or at the end for the rest of it: "watchdog.*.timeout"
+// covers watchdog.dev.timeout, and "timeouts.*" every key under timeouts.
+type Key struct {
+	Name    string
+	Kind    Kind
+	Default any      // nil when unset by default
+	Env     string   // environment variable that overrides the setting
+	Values  []string // allowed values of a string setting, if restricted
+	Doc     string
+	// Deprecated, when set, says what to use instead. The setting still
+	// works, but validation warns about it.
+	Deprecated string
+	// ReplacedBy names the setting that took over from a deprecated one,
+	// read in its place until the config is migrated. A "*" stands for
+	// the part of the name the same "*" in Name matched.
+	ReplacedBy string
+	// OldEnv are earlier names of Env. They are still read, after Env,
+	// but deprecated.
+	OldEnv []string
+}
+
+// Schema lists the settings ellie reads.
+var Schema = []Key{
+	{Name: "server.url", Kind: KindString, Default: "http://localhost:3000", Env: "ELLIE_API_URL",
+		Doc: "Base URL of the ellie server; unix:///path/to/ellie.sock for one on a Unix socket"},
+	{Name: "default_model", Kind: KindString,
+		Doc: "Model to ask for in chat and one-shot prompts"},
+	{Name: "context.embed_url", Kind: KindString, Default: "http://localhost:8080", Env: "ELLIE_EMBED_URL",
+		Doc: "Text Embeddings Inference server --context-index ranks files with, such as the one ellie's server starts for memory; empty ranks by keywords alone"},
+	{Name: "ui.theme", Kind: KindString, Default: "dark",
+		Doc: "Color theme of the chat interface: dark, light, a theme added with ellie themes import, or a theme's URL"},
+	{Name: "ui.images", Kind: KindString, Default: "auto", Env: "ELLIE_IMAGES", Values: []string{"auto", "kitty", "iterm2", "sixel", "none"},
+		Doc: "Graphics protocol for showing images in the terminal; auto picks the terminal's, none shows only their size and format"},
+	{Name: "timeouts.default", Kind: KindDuration,
+		Doc: "Timeout for each request to the server, replacing the built-in 10s"},
+	{Name: "timeouts.connect", Kind: KindDuration, Default: "10s", Env: "ELLIE_CONNECT_TIMEOUT",
+		Doc: "How long connecting to the server, TLS handshake included, may take; streamed responses are bounded by this alone"},
+	{Name: "timeouts.*", Kind: KindDuration,
+		Doc: `Request timeout for one command and its subcommands, e.g. timeouts.auth or timeouts."auth status"`},
+	{Name: "env", Kind: KindString, Env: "ELLIE_ENV",
+		Doc: "Environment from [envs] to use when --env is not given"},
+	{Name: "envs.*.url", Kind: KindString,
+		Doc: "Server of a named environment, selected with --env or ellie env use"},
+	{Name: "envs.*.token_env", Kind: KindString,
+		Doc: "Environment variable holding the bearer token of a named environment"},
+	{Name: "proxy", Kind: KindString, Env: "ELLIE_PROXY",
+		Doc: "Proxy for all of ellie's requests, http://, https:// or socks5://; unset uses HTTPS_PROXY and HTTP_PROXY. NO_PROXY hosts are reached directly"},
+	{Name: "tls.ca_file", Kind: KindString, Env: "ELLIE_CA_FILE",
+		Doc: "PEM file of CA certificates trusted besides the system's, for a TLS-intercepting proxy or a server with a private CA"},
+	{Name: "tls.client_cert", Kind: KindString, Env: "ELLIE_CLIENT_CERT",
+		Doc: "PEM client certificate presented to a server that requires mutual TLS; needs tls.client_key"},
+	{Name: "tls.client_key", Kind: KindString, Env: "ELLIE_CLIENT_KEY",
+		Doc: "PEM private key of tls.client_cert"},
+	{Name: "http.compress_requests", Kind: KindBool, Default: false, Env: "ELLIE_COMPRESS_REQUESTS",
+		Doc: "Gzip request bodies of 1 KiB or more; only for a server, or a proxy in front of it, that accepts gzip bodies. Responses are always accepted compressed"},
+	{Name: "update.check", Kind: KindBool, Default: true, Env: "ELLIE_UPDATE_CHECK",
+		Doc: "Check for a newer ellie release once a day in the background, and mention it"},
+	{Name: "upstream.status_url", Kind: KindString, Default: "https://status.anthropic.com/api/v2/summary.json", Env: "ELLIE_STATUS_URL",
+		Doc: "Statuspage summary checked when a model request fails, to tell a provider outage from a setup problem; empty disables the check"},
+	{Name: "dev.filters", Kind: KindList, Default: []any{"!cli"},
+		Doc: "turbo --filter arguments for ellie dev"},
+	{Name: "dev.instances.*.port", Kind: KindInt,
+		Doc: "Port the server of ellie dev --instance <name> binds; unset takes the first free one"},
+	{Name: "dev.instances.*.env", Kind: KindList,
+		Doc: "NAME=value variables added to the dev servers' environment by ellie dev --instance <name>"},
+	{Name: "logs.max_size", Kind: KindInt, Default: int64(10),
+		Doc: "Megabytes the ellie dev and ellie start logs grow to before they are rotated; 0 never rotates them"},
+	{Name: "logs.keep", Kind: KindInt, Default: int64(5),
+		Doc: "How many rotated logs to keep for each of ellie dev and ellie start"},
+	{Name: "logs.max_age", Kind: KindDuration,
+		Doc: "Delete rotated logs older than this; unset keeps them by count only"},
+	{Name: "permissions.profile", Kind: KindString, Default: "default", Env: "ELLIE_PERMISSIONS_PROFILE",
+		Doc: "Tool permission policy ellie permissions edits when --profile is not given"},
+	{Name: "safety.secrets", Kind: KindString, Default: "block", Env: "ELLIE_SAFETY_SECRETS", Values: []string{"block", "redact", "warn", "off"},
+		Doc: "What to do with API keys, tokens, passwords and private keys found in a prompt or attachment before it is sent"},
+	{Name: "safety.pii", Kind: KindString, Default: "warn", Env: "ELLIE_SAFETY_PII", Values: []string{"block", "redact", "warn", "off"},
+		Doc: "What to do with email addresses and card, social security and phone numbers found in a prompt or attachment before it is sent"},
+	{Name: "agent.refresh_before", Kind: KindDuration, Default: "10m",
+		Doc: "How long before the server's Anthropic OAuth token expires ellie agent refreshes it"},
+	{Name: "crash_loop.failures", Kind: KindInt, Default: int64(5),
+		Doc: "Crashes within crash_loop.window after which ellie start --restart and ellie daemon stop restarting the server; 0 restarts it forever"},
+	{Name: "crash_loop.window", Kind: KindDuration, Default: "2m",
+		Doc: "Period crash_loop.failures crashes must happen in to count as a crash loop"},
+	{Name: "shutdown.grace", Kind: KindDuration, Default: "10s",
+		Doc: "How long ellie dev and start give the server to exit after Ctrl-C or SIGTERM before killing it and everything it started"},
+	{Name: "watchdog.timeout", Kind: KindDuration,
+		Doc: "How long ellie dev and start wait for output or a health check before calling the server hung; unset disables the watchdog"},
+	{Name: "watchdog.restart", Kind: KindBool, Default: false,
+		Doc: "Restart a hung server instead of only warning"},
+	{Name: "watchdog.dump", Kind: KindString, Default: "stacks", Values: []string{"none", "stacks", "core"},
+		Doc: "What to capture from a hung server: process and thread states, plus core files with gcore"},
+	{Name: "watchdog.*.timeout", Kind: KindDuration,
+		Doc: "watchdog.timeout for one profile, dev or start"},
+	{Name: "watchdog.*.restart", Kind: KindBool,
+		Doc: "watchdog.restart for one profile, dev or start"},
+	{Name: "watchdog.*.dump", Kind: KindString, Values: []string{"none", "stacks", "core"},
+		Doc: "watchdog.dump for one profile, dev or start"},
+}
+
+// Lookup finds the schema entry for name, matching "*" patterns too.
+func Lookup(name string) (Key, bool) {
+	for _, k := range Schema {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	for _, k := range Schema {
+		if strings.Contains(k.Name, "*") && match(k.Name, name) {
+			k.ReplacedBy = fill(k.Name, k.ReplacedBy, name)
+			k.Name = name
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+// match reports whether name fits pattern.
+func match(pattern, name string) bool {
+	p := strings.Split(pattern, ".")
+	n := strings.Split(name, ".")
+	for i, seg := range p {
+		if i >= len(n) {
+			return false
+		}
+		switch {
+		case seg == "*" && i == len(p)-1:
+			return strings.Join(n[i:], ".") != ""
+		case seg == "*":
+			if n[i] == "" {
+				return false
+			}
+		case seg != n[i]:
+			return false
+		}
+	}
+	return len(n) == len(p)
+}
+
+// fill returns repl with each "*" replaced by what the matching "*" in
+// pattern matched in name.
+func fill(pattern, repl, name string) string {
+	if repl == "" {
+		return ""
+	}
+	p := strings.Split(pattern, ".")
+	n := strings.Split(name, ".")
+	var parts []string
+	for i, seg := range p {
+		switch {
+		case seg == "*" && i == len(p)-1:
+			parts = append(parts, strings.Join(n[i:], "."))
+		case seg == "*":
+			parts = append(parts, n[i])
+		}
+	}
+	r := strings.Split(repl, ".")
+	for i, seg := range r {
+		if seg == "*" && len(parts) > 0 {
+			r[i], parts = parts[0], parts[1:]
+		}
+	}
+	return strings.Join(r, ".")
+}
+
+// Parse converts text given on the command line to the key's type.
+// Lists are comma-separated.
+func (k Key) Parse(s string) (any, error) {
+	var v any
+	switch k.Kind {
+	case KindString, KindDuration:
+		v = s
+	case KindBool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", k.Name)
+		}
+		v = b
+	case KindInt:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number", k.Name)
+		}
+		v = n
+	case KindList:
+		list := []any{}
+		for _, part := range strings.Split(s, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				list = append(list, part)
+			}
+		}
+		v = list
+	}
+	return v, k.Check(v)
+}
+
+// Check reports whether v is a valid value for the key.
+func (k Key) Check(v any) error {
+	switch k.Kind {
+	case KindString:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", k.Name)
+		}
+		if len(k.Values) > 0 && !contains(k.Values, s) {
+			return fmt.Errorf("%s must be one of %s, not %q", k.Name, strings.Join(k.Values, ", "), s)
+		}
+	case KindDuration:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a duration string such as \"30s\"", k.Name)
+		}
+		if d, err := time.ParseDuration(s); err != nil || d < 0 {
+			return fmt.Errorf("%s: %q is not a duration (use e.g. 30s or 5m)", k.Name, s)
+		}
+	case KindBool:
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s must be true or false", k.Name)
+		}
+	case KindInt:
+		if _, ok := v.(int64); !ok {
+			return fmt.Errorf("%s must be a whole number", k.Name)
+		}
+	case KindList:
+		list, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s must be a list of strings", k.Name)
+		}
+		for _, e := range list {
+			if _, ok := e.(string); !ok {
+				return fmt.Errorf("%s must be a list of strings", k.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SourceServer is the TeamSource.URL meaning the ellie server's own
+// /api/config/team route rather than a git repository.
+const SourceServer = "server"
+
+// DefaultTeamPath is the file read from a team config repository.
+const DefaultTeamPath = "ellie.toml"
+
+// ErrNoTeamConfig is returned when no team config has been synced.
+var ErrNoTeamConfig = errors.New("no team config has been synced")
+
+// TeamSource is where the team config comes from: a git repository (URL,
+// optional branch or tag and file path) or SourceServer.
+type TeamSource struct {
+	URL  string `json:"url"`
+	Ref  string `json:"ref,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// IsServer reports whether the config is served by the ellie server.
+func (s TeamSource) IsServer() bool { return s.URL == SourceServer }
+
+func (s TeamSource) String() string {
+	if s.IsServer() {
+		return "the ellie server"
+	}
+	out := s.URL
+	if s.Ref != "" {
+		out += "@" + s.Ref
+	}
+	if s.Path != "" && s.Path != DefaultTeamPath {
+		out += ":" + s.Path
+	}
+	return out
+}
+
+// TeamState records the last sync, stored next to the synced file.
+type TeamState struct {
+	Source   TeamSource `json:"source"`
+	Revision string     `json:"revision"`
+	SyncedAt time.Time  `json:"synced_at"`
+}
+
+const (
+	teamFile  = "ellie.toml"
+	stateFile = "state.json"
+)
+
+// LoadTeam reads the synced team config from dir, returning
+// ErrNoTeamConfig when nothing has been synced.
+func LoadTeam(dir string) (Values, *TeamState, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrNoTeamConfig
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var st TeamState
+	if err := json.Unmarshal(raw, &st); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", filepath.Join(dir, stateFile), err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, teamFile))
+	if err != nil {
+		return nil, &st, err
+	}
+	values, err := Parse(data)
+	if err != nil {
+		return nil, &st, fmt.Errorf("team config: %w", err)
+	}
+	return values, &st, nil
+}
+
+// SaveTeam stores a synced team config and its state in dir.
+func SaveTeam(dir string, data []byte, st TeamState) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, teamFile), data); err != nil {
+		return err
+	}
+	raw, _ := json.MarshalIndent(st, "", "  ")
+	return writeFileAtomic(filepath.Join(dir, stateFile), append(raw, '\n'))
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ContentRevision identifies config served without a version of its own.
+func ContentRevision(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// FetchGit clones the source repository shallowly and returns the config
+// file and the commit it was read at.
+func FetchGit(ctx context.Context, src TeamSource) (data []byte, revision string, err error) {
+	tmp, err := os.MkdirTemp("", "ellie-team-*")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	args := []string{"clone", "--quiet", "--depth", "1", "--no-tags"}
+	if src.Ref != "" {
+		args = append(args, "--branch", src.Ref)
+	}
+	if _, err := git(ctx, "", append(args, "--", src.URL, tmp)...); err != nil {
+		return nil, "", err
+	}
+	revision, err = git(ctx, tmp, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, "", err
+	}
+	path := src.Path
+	if path == "" {
+		path = DefaultTeamPath
+	}
+	data, err = os.ReadFile(filepath.Join(tmp, filepath.FromSlash(path)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", fmt.Errorf("%s has no %s", src, path)
+	}
+	return data, revision, err
+}
+
+// RemoteRevision asks the repository for the commit its ref points at,
+// without fetching it.
+func RemoteRevision(ctx context.Context, src TeamSource) (string, error) {
+	ref := src.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	out, err := git(ctx, "", "ls-remote", "--", src.URL, ref)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%s has no ref %s", src.URL, ref)
+	}
+	return fields[0], nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Fail instead of prompting for credentials; private repositories
+	// need a credential helper or an SSH key.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Change is a key whose value differs between two configs. Old or New is
+// nil when the key was added or removed.
+type Change struct {
+	Key string
+	Old any
+	New any
+}
+
+// Diff lists the keys that differ between old and new, sorted by key.
+func Diff(old, new Values) []Change {
+	var changes []Change
+	seen := map[string]bool{}
+	for _, k := range append(old.Keys(), new.Keys()...) {
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		o, inOld := old[k]
+		n, inNew := new[k]
+		if inOld && inNew && reflect.DeepEqual(o, n) {
+			continue
+		}
+		c := Change{Key: k}
+		if inOld {
+			c.Old = o
+		}
+		if inNew {
+			c.New = n
+		}
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gitRepo creates a repository with an ellie.toml holding content and
+// returns its path.
+func gitRepo(t *testing.T, content string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "--quiet", "--initial-branch=main")
+	commitFile(t, dir, content)
+	return dir
+}
+
+func commitFile(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "ellie.toml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"add", "ellie.toml"}, {"commit", "--quiet", "-m", "update"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestFetchGit(t *testing.T) {
+	repo := gitRepo(t, "model = \"claude-sonnet\"\n")
+	src := TeamSource{URL: repo}
+	ctx := context.Background()
+
+	data, rev, err := FetchGit(ctx, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "model = \"claude-sonnet\"\n" || len(rev) != 40 {
+		t.Errorf("FetchGit = %q, %q", data, rev)
+	}
+	remote, err := RemoteRevision(ctx, src)
+	if err != nil || remote != rev {
+		t.Errorf("RemoteRevision = %q, %v; want %q", remote, err, rev)
+	}
+
+	commitFile(t, repo, "model = \"claude-opus\"\n")
+	if remote, _ := RemoteRevision(ctx, src); remote == rev {
+		t.Error("RemoteRevision did not change after a new commit")
+	}
+
+	if _, _, err := FetchGit(ctx, TeamSource{URL: repo, Path: "team/missing.toml"}); err == nil || !strings.Contains(err.Error(), "has no team/missing.toml") {
+		t.Errorf("missing path: err = %v", err)
+	}
+	if _, _, err := FetchGit(ctx, TeamSource{URL: repo, Ref: "nope"}); err == nil {
+		t.Error("unknown ref should fail")
+	}
+}
+
+func TestSaveLoadTeam(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "team")
+	if _, _, err := LoadTeam(dir); !errors.Is(err, ErrNoTeamConfig) {
+		t.Fatalf("LoadTeam before sync: err = %v", err)
+	}
+
+	st := TeamState{Source: TeamSource{URL: SourceServer}, Revision: "abc", SyncedAt: time.Unix(1700000000, 0).UTC()}
+	if err := SaveTeam(dir, []byte("[server]\nurl = \"https://x\"\n"), st); err != nil {
+		t.Fatal(err)
+	}
+	values, got, err := LoadTeam(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != st || values["server.url"] != "https://x" {
+		t.Errorf("LoadTeam = %v, %+v", values, got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := Values{"a": int64(1), "b": "x", "c": []any{"p"}}
+	new := Values{"a": int64(1), "b": "y", "d": true, "c": []any{"p"}}
+	got := Diff(old, new)
+	if len(got) != 2 || got[0].Key != "b" || got[0].Old != "x" || got[0].New != "y" ||
+		got[1].Key != "d" || got[1].Old != nil || got[1].New != true {
+		t.Errorf("Diff = %+v", got)
+	}
+}
@@ -0,0 +1,403 @@
+// Package config reads ellie's layered settings: a team-shared base
+// synced from a git repository or the server, beneath personal settings.
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Values are settings keyed by their dotted path, e.g. "server.url". A
+// value is a string, int64, float64, bool or []any of those.
+type Values map[string]any
+
+// Keys returns the keys in sorted order.
+func (v Values) Keys() []string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String returns the value at key formatted for display, and whether it
+// is set.
+func (v Values) String(key string) (string, bool) {
+	val, ok := v[key]
+	if !ok {
+		return "", false
+	}
+	return FormatValue(val), true
+}
+
+// FormatValue renders a value as it would appear in a TOML file, except
+// that strings are left unquoted.
+func FormatValue(val any) string {
+	switch x := val.(type) {
+	case string:
+		return x
+	case []any:
+		parts := make([]string, len(x))
+		for i, e := range x {
+			if s, ok := e.(string); ok {
+				parts[i] = strconv.Quote(s)
+			} else {
+				parts[i] = FormatValue(e)
+			}
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprint(val)
+}
+
+// Parse reads the subset of TOML ellie's config files use: tables,
+// dotted keys, strings, integers, floats, booleans and arrays of those.
+// Inline tables, arrays of tables and dates are rejected.
+func Parse(data []byte) (Values, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return doc.values, nil
+}
+
+// document is a parsed file with where each part of it is, for editing.
+type document struct {
+	values  Values
+	entries map[string]entry
+	tables  []table
+}
+
+// entry locates a key = value assignment.
+type entry struct {
+	table     string // enclosing [table], "" at the top level
+	startLine int    // 1-based
+	endLine   int    // last line, for arrays spanning several
+}
+
+type table struct {
+	name string
+	line int
+}
+
+func parse(data []byte) (*document, error) {
+	p := &parser{src: string(data), line: 1}
+	doc := &document{values: Values{}, entries: map[string]entry{}}
+	current := ""
+	for {
+		p.skipSpaceAndComments(true)
+		if p.eof() {
+			return doc, nil
+		}
+		if p.peek() == '[' {
+			line := p.line
+			p.pos++
+			if p.peek() == '[' {
+				return nil, p.errorf("arrays of tables are not supported")
+			}
+			key, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if p.peek() != ']' {
+				return nil, p.errorf("expected ] after table name")
+			}
+			p.pos++
+			for _, t := range doc.tables {
+				if t.name == key {
+					return nil, p.errorf("[%s] is defined twice, first on line %d", key, t.line)
+				}
+			}
+			current = key
+			doc.tables = append(doc.tables, table{name: key, line: line})
+		} else {
+			line := p.line
+			key, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if p.peek() != '=' {
+				return nil, p.errorf("expected = after %s", key)
+			}
+			p.pos++
+			p.skipSpace()
+			val, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			if current != "" {
+				key = current + "." + key
+			}
+			if _, dup := doc.values[key]; dup {
+				return nil, p.errorf("%s is set twice", key)
+			}
+			doc.values[key] = val
+			doc.entries[key] = entry{table: current, startLine: line, endLine: p.line}
+		}
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipSpaceAndComments skips blanks and comments, and newlines too when
+// newlines is set.
+func (p *parser) skipSpaceAndComments(newlines bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\n' && newlines:
+			p.pos++
+			p.line++
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) endOfLine() error {
+	p.skipSpaceAndComments(false)
+	if p.eof() {
+		return nil
+	}
+	if p.peek() != '\n' {
+		return p.errorf("unexpected %q", p.rest())
+	}
+	return nil
+}
+
+func (p *parser) rest() string {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end < 0 {
+		return p.src[p.pos:]
+	}
+	return strings.TrimSpace(p.src[p.pos : p.pos+end])
+}
+
+// key reads a possibly dotted key, bare or quoted.
+func (p *parser) key() (string, error) {
+	var parts []string
+	for {
+		p.skipSpace()
+		var part string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.str()
+			if err != nil {
+				return "", err
+			}
+			part = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if p.pos == start {
+				return "", p.errorf("expected a key, found %q", p.rest())
+			}
+			part = p.src[start:p.pos]
+		}
+		parts = append(parts, part)
+		p.skipSpace()
+		if p.peek() != '.' {
+			return strings.Join(parts, "."), nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *parser) value() (any, error) {
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.str()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return nil, p.errorf("inline tables are not supported")
+	}
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n#,]", rune(p.peek())) {
+		p.pos++
+	}
+	tok := p.src[start:p.pos]
+	switch tok {
+	case "":
+		return nil, p.errorf("missing value")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if n, ok := parseInt(tok); ok {
+		return n, nil
+	}
+	if f, ok := parseFloat(tok); ok {
+		return f, nil
+	}
+	if leadingZero.MatchString(tok) {
+		return nil, p.errorf("invalid number %q: leading zeros are not allowed (octal is written 0o17)", tok)
+	}
+	return nil, p.errorf("invalid value %q (strings must be quoted)", tok)
+}
+
+var (
+	decimalInt   = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	prefixedInt  = regexp.MustCompile(`^0(x[0-9A-Fa-f](_?[0-9A-Fa-f])*|o[0-7](_?[0-7])*|b[01](_?[01])*)$`)
+	decimalFloat = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`)
+	leadingZero  = regexp.MustCompile(`^[+-]?0[0-9_]`)
+)
+
+// parseInt reads a TOML integer: decimal without leading zeros, or 0x, 0o
+// or 0b prefixed, with underscores only between digits.
+func parseInt(tok string) (int64, bool) {
+	clean := strings.ReplaceAll(tok, "_", "")
+	switch {
+	case decimalInt.MatchString(tok):
+		n, err := strconv.ParseInt(clean, 10, 64)
+		return n, err == nil
+	case prefixedInt.MatchString(tok):
+		base := map[byte]int{'x': 16, 'o': 8, 'b': 2}[clean[1]]
+		n, err := strconv.ParseInt(clean[2:], base, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// parseFloat reads a TOML float, including inf and nan.
+func parseFloat(tok string) (float64, bool) {
+	switch strings.TrimLeft(tok, "+-") {
+	case "inf", "nan":
+	default:
+		if !decimalFloat.MatchString(tok) {
+			return 0, false
+		}
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(tok, "_", ""), 64)
+	return f, err == nil
+}
+
+func (p *parser) array() ([]any, error) {
+	p.pos++ // [
+	out := []any{}
+	for {
+		p.skipSpaceAndComments(true)
+		if p.peek() == ']' {
+			p.pos++
+			return out, nil
+		}
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		p.skipSpaceAndComments(true)
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("expected , or ] in array")
+		}
+	}
+}
+
+// str reads a basic ("...") or literal ('...') single-line string.
+func (p *parser) str() (string, error) {
+	quote := p.peek()
+	p.pos++
+	if strings.HasPrefix(p.src[p.pos:], string([]byte{quote, quote})) {
+		return "", p.errorf("multi-line strings are not supported")
+	}
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		p.pos++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && quote == '"':
+			if p.eof() {
+				return "", p.errorf("unterminated string")
+			}
+			e := p.peek()
+			p.pos++
+			switch e {
+			case '"', '\\':
+				b.WriteByte(e)
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'u', 'U':
+				n := 4
+				if e == 'U' {
+					n = 8
+				}
+				if p.pos+n > len(p.src) {
+					return "", p.errorf("invalid \\%c escape", e)
+				}
+				r, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid \\%c escape", e)
+				}
+				b.WriteRune(rune(r))
+				p.pos += n
+			default:
+				return "", p.errorf("invalid escape \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
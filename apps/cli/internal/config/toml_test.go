@@ -0,0 +1,104 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `# team defaults
+model = "claude-sonnet"   # trailing comment
+retries = 3
+ratio = 0.5
+verbose = false
+
+[server]
+url = 'https://ellie.example.com'
+"timeout" = "30s"
+
+[dev.filter]
+include = [
+  "web",   # the app
+  "server",
+]
+name = "tab\there é"
+`
+	got, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Values{
+		"model":              "claude-sonnet",
+		"retries":            int64(3),
+		"ratio":              0.5,
+		"verbose":            false,
+		"server.url":         "https://ellie.example.com",
+		"server.timeout":     "30s",
+		"dev.filter.include": []any{"web", "server"},
+		"dev.filter.name":    "tab\there é",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for src, want := range map[string]string{
+		"a = b":                "line 1: invalid value \"b\" (strings must be quoted)",
+		"a = 1\na = 2":         "line 2: a is set twice",
+		"[t]\nx = {y = 1}":     "line 2: inline tables are not supported",
+		"[[servers]]":          "line 1: arrays of tables are not supported",
+		"a = \"open":           "line 1: unterminated string",
+		"a = 1 b":              "line 1: unexpected \"b\"",
+		"a =":                  "line 1: missing value",
+		"a = [1, 2":            "unterminated array",
+		"\n\n[server\nurl = 1": "line 3: expected ] after table name",
+		"[a]\nx = 1\n[b]\n[a]": "line 4: [a] is defined twice, first on line 1",
+		"mode = 0755":          "line 1: invalid number \"0755\": leading zeros are not allowed",
+		"a = 1__000":           "invalid value \"1__000\"",
+		"a = 0x_ff":            "invalid value \"0x_ff\"",
+		"a = 1.":               "invalid value \"1.\"",
+	} {
+		_, err := Parse([]byte(src))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) error = %v, want %q", src, err, want)
+		}
+	}
+}
+
+func TestParseNumbers(t *testing.T) {
+	for tok, want := range map[string]any{
+		"0":        int64(0),
+		"-17":      int64(-17),
+		"1_000":    int64(1000),
+		"0xff":     int64(255),
+		"0o755":    int64(0o755),
+		"0b101":    int64(5),
+		"3.25":     3.25,
+		"-1e3":     -1000.0,
+		"6.02_2e2": 602.2,
+		"0.5":      0.5,
+	} {
+		got, err := Parse([]byte("n = " + tok))
+		if err != nil || got["n"] != want {
+			t.Errorf("n = %s parsed as %#v, %v; want %#v", tok, got["n"], err, want)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for _, tc := range []struct {
+		in   any
+		want string
+	}{
+		{"plain", "plain"},
+		{int64(42), "42"},
+		{true, "true"},
+		{[]any{"a", int64(1)}, `["a", 1]`},
+	} {
+		if got := FormatValue(tc.in); got != tc.want {
+			t.Errorf("FormatValue(%#v) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Problem is a setting in a config file that doesn't fit the schema.
+type Problem struct {
+	Line int
+	Key  string
+	Msg  string
+	// Deprecated marks a warning about a setting that still works.
+	Deprecated bool
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Msg)
+}
+
+// Errors returns the problems that aren't only deprecation warnings.
+func Errors(problems []Problem) []Problem {
+	var errs []Problem
+	for _, p := range problems {
+		if !p.Deprecated {
+			errs = append(errs, p)
+		}
+	}
+	return errs
+}
+
+// Validate checks a config file against the schema. A syntax error is
+// returned as err; otherwise every unknown setting, bad value and
+// deprecated setting is reported, in line order. Settings under [tool.ellie] are checked like
+// top-level ones, and other tools' tables are skipped. Values are checked
+// after expanding environment variables; a value that refers to an unset
+// variable is only checked when it is loaded.
+func Validate(data []byte) ([]Problem, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	var problems []Problem
+	for key, e := range doc.entries {
+		name := key
+		if local, ok := strings.CutPrefix(key, ToolTable+"."); ok {
+			name = local
+		} else if strings.HasPrefix(key, "tool.") {
+			continue
+		}
+		k, ok := Lookup(name)
+		if !ok {
+			problems = append(problems, Problem{Line: e.startLine, Key: key, Msg: "unknown setting " + name})
+			continue
+		}
+		if k.Deprecated != "" {
+			problems = append(problems, Problem{Line: e.startLine, Key: key, Msg: name + " is deprecated: " + k.Deprecated, Deprecated: true})
+		}
+		v, err := expandValue(name, doc.values[key], os.Getenv)
+		if errors.Is(err, ErrUnsetVariable) {
+			continue
+		}
+		if err == nil {
+			err = k.Check(v)
+		}
+		if err != nil {
+			problems = append(problems, Problem{Line: e.startLine, Key: key, Msg: err.Error()})
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems, nil
+}
+
+// EnvProblems reports the environment variables named in the schema whose
+// values don't fit their setting. Get ignores such a value and falls back
+// to the config files.
+func EnvProblems(getenv func(string) string) []error {
+	var errs []error
+	for _, k := range Schema {
+		if k.Env == "" {
+			continue
+		}
+		for _, env := range append([]string{k.Env}, k.OldEnv...) {
+			if v := getenv(env); v != "" {
+				if _, err := k.Parse(v); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", env, err))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// Deprecations lists the deprecated settings set in c's layers and the
+// deprecated environment variables set.
+func (c *Config) Deprecations() []string {
+	getenv := c.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	var out []string
+	for _, d := range EnvDeprecations(getenv) {
+		out = append(out, fmt.Sprintf("environment: %s is deprecated: use %s", d.Var, d.Replacement))
+	}
+	for _, l := range c.Layers {
+		names := make([]string, 0, len(l.Values))
+		for name := range l.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if k, ok := Lookup(name); ok && k.Deprecated != "" {
+				out = append(out, fmt.Sprintf("%s: %s is deprecated: %s", l.Path, name, k.Deprecated))
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package config
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	problems, err := Validate([]byte(`default_model = "x"
+colour = "red"
+
+[watchdog]
+dump = "neon"
+
+[timeouts]
+auth = 30
+default = "${ELLIE_TEST_UNSET_TIMEOUT}"
+chat = "${ELLIE_TEST_UNSET_TIMEOUT:-soon}"
+
+[tool.ellie]
+dev.filters = ["!cli"]
+[tool.black]
+line-length = 88
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"line 2: unknown setting colour",
+		`line 5: watchdog.dump must be one of none, stacks, core, not "neon"`,
+		`line 8: timeouts.auth must be a duration string such as "30s"`,
+		`line 10: timeouts.chat: "soon" is not a duration (use e.g. 30s or 5m)`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems = %v", problems)
+	}
+	for i, p := range problems {
+		if p.Error() != want[i] {
+			t.Errorf("problem %d = %q, want %q", i, p.Error(), want[i])
+		}
+	}
+
+	if _, err := Validate([]byte("a = \n")); err == nil {
+		t.Error("syntax error not reported")
+	}
+}
+
+func TestValidateDeprecated(t *testing.T) {
+	saved := Schema
+	t.Cleanup(func() { Schema = saved })
+	Schema = append(Schema[:len(Schema):len(Schema)], Key{Name: "ui.colour", Kind: KindString, Deprecated: "use ui.theme"})
+
+	problems, err := Validate([]byte("[ui]\ncolour = 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"line 2: ui.colour is deprecated: use ui.theme",
+		"line 2: ui.colour must be a string",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems = %v", problems)
+	}
+	for i, p := range problems {
+		if p.Error() != want[i] {
+			t.Errorf("problem %d = %q, want %q", i, p.Error(), want[i])
+		}
+	}
+	if errs := Errors(problems); len(errs) != 1 || errs[0].Deprecated {
+		t.Errorf("Errors = %v", errs)
+	}
+
+	c := &Config{Layers: []Layer{{Name: "user", Path: "u.toml", Values: Values{"ui.colour": "red", "ui.theme": "dark"}}}}
+	if got := c.Deprecations(); len(got) != 1 || got[0] != "u.toml: ui.colour is deprecated: use ui.theme" {
+		t.Errorf("Deprecations = %q", got)
+	}
+}
+
+func TestEnvProblems(t *testing.T) {
+	saved := Schema
+	t.Cleanup(func() { Schema = saved })
+	Schema = append(Schema[:len(Schema):len(Schema)], Key{Name: "watchdog.verbose", Kind: KindBool, Env: "ELLIE_TEST_VERBOSE"})
+
+	env := map[string]string{"ELLIE_API_URL": "http://x", "ELLIE_TEST_VERBOSE": "loud"}
+	errs := EnvProblems(func(k string) string { return env[k] })
+	if len(errs) != 1 || errs[0].Error() != "ELLIE_TEST_VERBOSE: watchdog.verbose must be true or false" {
+		t.Errorf("EnvProblems = %v", errs)
+	}
+}
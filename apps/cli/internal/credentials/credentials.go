@@ -0,0 +1,147 @@
+// Package credentials stores provider credentials in the OS keyring (macOS
+// Keychain, Windows Credential Manager, or the Secret Service on Linux and
+// other Unixes) for use when no ellie server is available to hold them.
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Service is the keyring service every credential is stored under.
+const Service = "ellie"
+
+// Credential methods, matching the server's auth modes.
+const (
+	MethodAPIKey  = "api_key"
+	MethodToken   = "token"
+	MethodOAuth   = "oauth"
+	MethodBedrock = "bedrock" // AWS profile and region; no secret
+)
+
+var (
+	// ErrNotFound is returned when no credential is stored for a provider.
+	ErrNotFound = errors.New("no credential in the OS keyring")
+	// ErrUnsupported is returned when this system has no usable keyring.
+	ErrUnsupported = errors.New("no OS keyring available")
+)
+
+// Credential is a secret stored for one provider, or for one named
+// profile of a provider.
+type Credential struct {
+	Provider string    `json:"provider"`
+	Profile  string    `json:"profile,omitempty"`
+	Method   string    `json:"method"`
+	Secret   string    `json:"secret"`
+	SavedAt  time.Time `json:"saved_at"`
+}
+
+// Preview returns a masked form of the secret that is safe to print.
+func (c Credential) Preview() string {
+	if len(c.Secret) <= 12 {
+		return "****"
+	}
+	return c.Secret[:7] + "..." + c.Secret[len(c.Secret)-4:]
+}
+
+// EnvVar returns the environment variable the server reads this
+// credential from, or "" when the server has none for it.
+func (c Credential) EnvVar() string {
+	if c.Provider != "anthropic" {
+		return ""
+	}
+	switch c.Method {
+	case MethodAPIKey:
+		return "ANTHROPIC_API_KEY"
+	case MethodToken:
+		return "ANTHROPIC_BEARER_TOKEN"
+	}
+	return ""
+}
+
+// account is the keyring account the credential is stored under.
+func (c Credential) account() string {
+	return account(c.Provider, c.Profile)
+}
+
+func account(provider, profile string) string {
+	if profile == "" {
+		return provider
+	}
+	return provider + "@" + profile
+}
+
+// Keyring is an OS secret store.
+type Keyring interface {
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+	Delete(service, account string) error
+}
+
+// Store reads and writes credentials in a keyring.
+type Store struct {
+	ring Keyring
+}
+
+// New returns a Store backed by the OS keyring.
+func New() *Store {
+	return &Store{ring: systemKeyring{}}
+}
+
+// NewWith returns a Store backed by ring.
+func NewWith(ring Keyring) *Store {
+	return &Store{ring: ring}
+}
+
+// System returns the OS keyring.
+func System() Keyring {
+	return systemKeyring{}
+}
+
+// Save stores c, replacing any credential saved for the same provider.
+func (s *Store) Save(c Credential) error {
+	if c.Provider == "" || c.Secret == "" {
+		return fmt.Errorf("credential needs a provider and a secret")
+	}
+	if c.SavedAt.IsZero() {
+		c.SavedAt = time.Now()
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return s.ring.Set(Service, c.account(), string(data))
+}
+
+// Load returns the credential stored for provider, or ErrNotFound.
+func (s *Store) Load(provider string) (Credential, error) {
+	return s.LoadProfile(provider, "")
+}
+
+// LoadProfile returns the credential stored for a named profile of
+// provider, or ErrNotFound.
+func (s *Store) LoadProfile(provider, profile string) (Credential, error) {
+	acct := account(provider, profile)
+	raw, err := s.ring.Get(Service, acct)
+	if err != nil {
+		return Credential{}, err
+	}
+	var c Credential
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		return Credential{}, fmt.Errorf("keyring entry for %s is corrupt: %w", acct, err)
+	}
+	return c, nil
+}
+
+// Delete removes the credential stored for provider. It returns
+// ErrNotFound when there was none.
+func (s *Store) Delete(provider string) error {
+	return s.DeleteProfile(provider, "")
+}
+
+// DeleteProfile removes the credential stored for a named profile.
+func (s *Store) DeleteProfile(provider, profile string) error {
+	return s.ring.Delete(Service, account(provider, profile))
+}
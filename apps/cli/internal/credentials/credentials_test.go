@@ -0,0 +1,113 @@
+package credentials
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// memKeyring is an in-memory Keyring for tests.
+type memKeyring map[string]string
+
+func (m memKeyring) Get(service, account string) (string, error) {
+	s, ok := m[service+"/"+account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return s, nil
+}
+
+func (m memKeyring) Set(service, account, secret string) error {
+	m[service+"/"+account] = secret
+	return nil
+}
+
+func (m memKeyring) Delete(service, account string) error {
+	if _, ok := m[service+"/"+account]; !ok {
+		return ErrNotFound
+	}
+	delete(m, service+"/"+account)
+	return nil
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	s := &Store{ring: memKeyring{}}
+	if _, err := s.Load("anthropic"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Load on empty keyring: %v", err)
+	}
+
+	if err := s.Save(Credential{Provider: "anthropic", Method: MethodToken, Secret: "sk-ant-oat01-abcdefghijkl"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Load("anthropic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Secret != "sk-ant-oat01-abcdefghijkl" || c.SavedAt.IsZero() {
+		t.Errorf("loaded %+v", c)
+	}
+	if got := c.EnvVar(); got != "ANTHROPIC_BEARER_TOKEN" {
+		t.Errorf("EnvVar = %q", got)
+	}
+	if got := c.Preview(); got != "sk-ant-...ijkl" {
+		t.Errorf("Preview = %q", got)
+	}
+
+	if err := s.Delete("anthropic"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("anthropic"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete: %v", err)
+	}
+}
+
+func TestSaveRejectsEmptySecret(t *testing.T) {
+	s := &Store{ring: memKeyring{}}
+	if err := s.Save(Credential{Provider: "anthropic", Method: MethodAPIKey}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestProfilesKeepSeparateSecrets(t *testing.T) {
+	s := &Store{ring: memKeyring{}}
+	for _, c := range []Credential{
+		{Provider: "anthropic", Secret: "default"},
+		{Provider: "anthropic", Profile: "work", Method: MethodOAuth, Secret: `{"type":"oauth"}`},
+	} {
+		if err := s.Save(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c, _ := s.Load("anthropic"); c.Secret != "default" {
+		t.Errorf("default credential = %q", c.Secret)
+	}
+	if c, _ := s.LoadProfile("anthropic", "work"); c.Secret != `{"type":"oauth"}` || c.Profile != "work" {
+		t.Errorf("work profile = %+v", c)
+	}
+}
+
+func TestProfilesIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	p, err := LoadProfiles(path)
+	if err != nil || len(p.Profiles) != 0 {
+		t.Fatalf("LoadProfiles on missing file: %+v, %v", p, err)
+	}
+	p.Profiles["work"] = Profile{Provider: "anthropic", Method: MethodAPIKey}
+	p.Profiles["personal"] = Profile{Provider: "anthropic", Method: MethodOAuth}
+	p.Active = "work"
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = LoadProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Active != "work" || !reflect.DeepEqual(p.Names(), []string{"personal", "work"}) {
+		t.Errorf("reloaded %+v", p)
+	}
+	if err := ValidProfileName("../x"); err == nil {
+		t.Error("path-like profile name accepted")
+	}
+}
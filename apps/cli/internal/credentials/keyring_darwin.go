@@ -0,0 +1,57 @@
+package credentials
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// systemKeyring uses the login Keychain through the security tool.
+type systemKeyring struct{}
+
+// errSecItemNotFound is the exit status of security when no item matches.
+const errSecItemNotFound = 44
+
+func (systemKeyring) Get(service, account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		return "", keychainError(err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (systemKeyring) Set(service, account, secret string) error {
+	// The secret goes through stdin in interactive mode so it never shows
+	// up in the process list. Interactive mode exits 0 even when the
+	// command fails, so failures are detected from the output.
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		service, account, hex.EncodeToString([]byte(secret))))
+	if out, err := cmd.CombinedOutput(); err != nil || strings.Contains(string(out), "security: ") {
+		if err == nil {
+			err = errors.New(strings.TrimSpace(string(out)))
+		}
+		return fmt.Errorf("keychain: %w", keychainError(err))
+	}
+	return nil
+}
+
+func (systemKeyring) Delete(service, account string) error {
+	if err := exec.Command("security", "delete-generic-password", "-s", service, "-a", account).Run(); err != nil {
+		return keychainError(err)
+	}
+	return nil
+}
+
+func keychainError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound {
+		return ErrNotFound
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return ErrUnsupported
+	}
+	return err
+}
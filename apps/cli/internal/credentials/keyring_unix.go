@@ -0,0 +1,70 @@
+//go:build !darwin && !windows
+
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// systemKeyring uses the freedesktop Secret Service (GNOME Keyring,
+// KWallet) through libsecret's secret-tool.
+type systemKeyring struct{}
+
+func (systemKeyring) Get(service, account string) (string, error) {
+	if err := hasSecretTool(); err != nil {
+		return "", err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// lookup exits 1 with no output when nothing matches.
+		if stderr.Len() == 0 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("secret service: %s", strings.TrimSpace(stderr.String()))
+	}
+	if len(out) == 0 {
+		return "", ErrNotFound
+	}
+	return string(out), nil
+}
+
+func (systemKeyring) Set(service, account, secret string) error {
+	if err := hasSecretTool(); err != nil {
+		return err
+	}
+	cmd := exec.Command("secret-tool", "store", "--label", service+" "+account,
+		"service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("secret service: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (k systemKeyring) Delete(service, account string) error {
+	// clear succeeds whether or not anything matched, so look first.
+	if _, err := k.Get(service, account); err != nil {
+		return err
+	}
+	if out, err := exec.Command("secret-tool", "clear", "service", service, "account", account).CombinedOutput(); err != nil {
+		return fmt.Errorf("secret service: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func hasSecretTool() error {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("%w (install libsecret-tools for secret-tool)", ErrUnsupported)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Profile describes a named credential saved for switching between
+// accounts. The secret itself is kept in the keyring.
+type Profile struct {
+	Provider string    `json:"provider"`
+	Method   string    `json:"method"`
+	Preview  string    `json:"preview,omitempty"`
+	SavedAt  time.Time `json:"saved_at"`
+}
+
+// Profiles is the on-disk index of saved profiles and the active one.
+type Profiles struct {
+	Active   string             `json:"active,omitempty"`
+	Profiles map[string]Profile `json:"profiles"`
+
+	path string
+}
+
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidProfileName reports whether name can be used for a profile.
+func ValidProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use letters, digits, -, _ and .)", name)
+	}
+	return nil
+}
+
+// LoadProfiles reads the profile index at path. A missing file is an
+// empty index.
+func LoadProfiles(path string) (*Profiles, error) {
+	p := &Profiles{Profiles: map[string]Profile{}, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.Profiles == nil {
+		p.Profiles = map[string]Profile{}
+	}
+	return p, nil
+}
+
+// Save writes the index back to the file it was loaded from.
+func (p *Profiles) Save() error {
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p.path, append(data, '\n'), 0o600)
+}
+
+// Names returns the profile names in sorted order.
+func (p *Profiles) Names() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
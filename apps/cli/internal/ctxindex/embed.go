@@ -0,0 +1,201 @@
+package ctxindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// embedBatch is how many chunks go in one embedding request; TEI's own
+// default maximum client batch is 32.
+const embedBatch = 32
+
+// Embedder turns texts into vectors of one length.
+type Embedder interface {
+	// Model names the embedding model: vectors of different models don't
+	// compare.
+	Model(ctx context.Context) (string, error)
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// TEI embeds texts with a Text Embeddings Inference server, such as the
+// one the ellie server starts for its memory.
+type TEI struct {
+	URL string
+	// HTTP sends the requests; nil uses a client with a 30s timeout.
+	HTTP *http.Client
+}
+
+func (t *TEI) client() *http.Client {
+	if t.HTTP != nil {
+		return t.HTTP
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// Model returns the model_id GET /info reports.
+func (t *TEI) Model(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(t.URL, "/")+"/info", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := t.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("embeddings info returned %d", resp.StatusCode)
+	}
+	var info struct {
+		ModelID string `json:"model_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", fmt.Errorf("decode embeddings info: %w", err)
+	}
+	return info.ModelID, nil
+}
+
+// Embed posts texts to /embed, truncated to what the model takes.
+func (t *TEI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, _ := json.Marshal(map[string]interface{}{"inputs": texts, "truncate": true})
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(t.URL, "/")+"/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embed returned %d", resp.StatusCode)
+	}
+	var out [][]float32
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode embeddings: %w", err)
+	}
+	if len(out) != len(texts) {
+		return nil, fmt.Errorf("embed returned %d vectors for %d texts", len(out), len(texts))
+	}
+	return out, nil
+}
+
+// Embed gives the chunks of files under root that have none a vector
+// from e, and returns how many it embedded. Vectors of another model are
+// dropped first.
+func (ix *Index) Embed(ctx context.Context, root string, files []string, e Embedder) (int, error) {
+	model, err := e.Model(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if model != ix.EmbedModel {
+		for _, f := range ix.Files {
+			for i := range f.Chunks {
+				f.Chunks[i].Vector = nil
+			}
+		}
+		ix.EmbedModel = model
+	}
+
+	var todo []*Chunk
+	var texts []string
+	for _, f := range files {
+		entry, ok := ix.Files[f]
+		if !ok {
+			continue
+		}
+		var lines []string
+		for i := range entry.Chunks {
+			c := &entry.Chunks[i]
+			if c.Vector != nil {
+				continue
+			}
+			if lines == nil {
+				data, _ := os.ReadFile(filepath.Join(root, filepath.FromSlash(f)))
+				lines = strings.SplitAfter(string(data), "\n")
+			}
+			end := min(c.End, len(lines))
+			if c.Start-1 >= end {
+				continue
+			}
+			// The path tells the model what the code is about, as the
+			// path terms do for BM25.
+			todo = append(todo, c)
+			texts = append(texts, f+"\n"+strings.Join(lines[c.Start-1:end], ""))
+		}
+	}
+
+	done := 0
+	for start := 0; start < len(todo); start += embedBatch {
+		end := min(start+embedBatch, len(todo))
+		vectors, err := e.Embed(ctx, texts[start:end])
+		if err != nil {
+			return done, err
+		}
+		for i, v := range vectors {
+			todo[start+i].Vector = normalize(v)
+		}
+		done += end - start
+	}
+	return done, nil
+}
+
+// SearchVector ranks the embedded chunks of files (all files when files
+// is nil) by cosine similarity to query, a vector of the index's model,
+// best first.
+func (ix *Index) SearchVector(query []float32, files []string) []Hit {
+	q := normalize(query)
+	var hits []Hit
+	for _, c := range ix.chunks(files) {
+		if len(c.Vector) != len(q) {
+			continue
+		}
+		var dot float64
+		for i, x := range c.Vector {
+			dot += float64(x) * float64(q[i])
+		}
+		hits = append(hits, Hit{Chunk: c, Score: dot})
+	}
+	sortHits(hits)
+	return hits
+}
+
+// normalize scales v to unit length, so a dot product is the cosine.
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+// sortHits orders hits best first, then by path and line.
+func sortHits(hits []Hit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Chunk.Path != hits[j].Chunk.Path {
+			return hits[i].Chunk.Path < hits[j].Chunk.Path
+		}
+		return hits[i].Chunk.Start < hits[j].Chunk.Start
+	})
+}
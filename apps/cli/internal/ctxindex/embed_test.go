@@ -0,0 +1,98 @@
+package ctxindex
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// topicEmbedder embeds a text as how often it mentions each topic.
+type topicEmbedder struct {
+	model  string
+	calls  int
+	topics []string
+}
+
+func (e *topicEmbedder) Model(context.Context) (string, error) { return e.model, nil }
+
+func (e *topicEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, len(e.topics))
+		for j, topic := range e.topics {
+			v[j] = float32(strings.Count(strings.ToLower(text), topic))
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func TestIndexEmbedSearchVector(t *testing.T) {
+	root := writeRepo(t, map[string]string{
+		"auth/token.go": "package auth\n\n// refreshToken renews an expired OAuth token.\nfunc refreshToken() {}\n",
+		"ui/render.go":  "package ui\n\n// render draws the chat view.\nfunc render() {}\n",
+	})
+	files := []string{"auth/token.go", "ui/render.go"}
+	ix := New()
+	ix.Update(root, files)
+
+	e := &topicEmbedder{model: "topics-1", topics: []string{"token", "render"}}
+	if n, err := ix.Embed(context.Background(), root, files, e); err != nil || n != 2 {
+		t.Fatalf("Embed = %d, %v; want 2 chunks", n, err)
+	}
+	query, _ := e.Embed(context.Background(), []string{"credentials token expiry"})
+	hits := ix.SearchVector(query[0], nil)
+	if len(hits) != 2 || hits[0].Chunk.Path != "auth/token.go" {
+		t.Fatalf("hits = %+v", hits)
+	}
+
+	// Embedded chunks aren't embedded again, unless the model changes.
+	if n, _ := ix.Embed(context.Background(), root, files, e); n != 0 {
+		t.Errorf("second Embed embedded %d chunks, want 0", n)
+	}
+	e.model = "topics-2"
+	if n, _ := ix.Embed(context.Background(), root, files, e); n != 2 || ix.EmbedModel != "topics-2" {
+		t.Errorf("Embed after a model change embedded %d chunks with %q", n, ix.EmbedModel)
+	}
+}
+
+func TestTEI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info":
+			w.Write([]byte(`{"model_id":"BAAI/bge-small-en-v1.5"}`))
+		case "/embed":
+			var req struct {
+				Inputs   []string `json:"inputs"`
+				Truncate bool     `json:"truncate"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			if !req.Truncate {
+				http.Error(w, "input too long", http.StatusRequestEntityTooLarge)
+				return
+			}
+			out := make([][]float32, len(req.Inputs))
+			for i := range out {
+				out[i] = []float32{1, float32(i)}
+			}
+			json.NewEncoder(w).Encode(out)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tei := &TEI{URL: srv.URL + "/"}
+	model, err := tei.Model(context.Background())
+	if err != nil || model != "BAAI/bge-small-en-v1.5" {
+		t.Errorf("Model = %q, %v", model, err)
+	}
+	vectors, err := tei.Embed(context.Background(), []string{"a", "b"})
+	if err != nil || len(vectors) != 2 || vectors[1][1] != 1 {
+		t.Errorf("Embed = %v, %v", vectors, err)
+	}
+}
@@ -0,0 +1,145 @@
+// Package ctxindex picks the parts of a repository to send with a prompt.
+// Files are split into chunks, each embedded by an Embedder; a prompt is
+// matched against the chunks by cosine similarity and the best ones are
+// packed into a token budget. Each chunk also keeps a vector of hashed
+// term counts, for BM25 ranking when no embeddings server is available.
+// The index is cached per repository and only files that changed are
+// re-read and re-embedded.
+package ctxindex
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MaxFileSize is the largest file indexed or included; bigger files are
+// almost always generated or data.
+const MaxFileSize = 256 << 10
+
+// skipDirs are never walked when the root is not a git checkout.
+var skipDirs = map[string]bool{
+	"node_modules": true, "dist": true, "build": true, "vendor": true, "target": true,
+}
+
+// ListFiles returns the files under root, relative and slash-separated.
+// In a git checkout these are the tracked and untracked-but-not-ignored
+// files; elsewhere hidden and dependency directories are skipped.
+func ListFiles(root string) ([]string, error) {
+	cmd := exec.Command("git", "ls-files", "--cached", "--others", "--exclude-standard", "-z")
+	cmd.Dir = root
+	if out, err := cmd.Output(); err == nil {
+		var files []string
+		for _, f := range strings.Split(string(out), "\x00") {
+			if f != "" {
+				files = append(files, f)
+			}
+		}
+		return files, nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != root && (strings.HasPrefix(name, ".") || skipDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasPrefix(name, ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
+
+// Match reports whether the slash-separated path matches pattern, where
+// "**" matches any number of directories and other elements follow
+// path.Match. A pattern without a slash matches the base name anywhere,
+// as in .gitignore.
+func Match(pattern, name string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchParts(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchParts(pat, parts []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchParts(pat[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], parts[0]); !ok {
+			return false
+		}
+		pat, parts = pat[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// Filter keeps the files matching any of the patterns. A pattern naming
+// a directory selects everything beneath it.
+func Filter(files, patterns []string) []string {
+	var out []string
+	for _, f := range files {
+		for _, p := range patterns {
+			dir := strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(p), "./"), "/")
+			if Match(p, f) || dir == "." || strings.HasPrefix(f, dir+"/") {
+				out = append(out, f)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// readText reads a file for indexing, reporting false for files that are
+// too big or look binary.
+func readText(name string) ([]byte, bool) {
+	info, err := os.Stat(name)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > MaxFileSize {
+		return nil, false
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, false
+	}
+	head := data
+	if len(head) > 8<<10 {
+		head = head[:8<<10]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, false
+	}
+	return data, true
+}
+
+// EstimateTokens approximates the tokens text costs (about four bytes a
+// token for code and English).
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
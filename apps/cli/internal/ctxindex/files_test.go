@@ -0,0 +1,39 @@
+package ctxindex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.go", "cmd/ellie/main.go", true},
+		{"*.go", "main.ts", false},
+		{"cmd/**/*.go", "cmd/ellie/main.go", true},
+		{"cmd/**/*.go", "cmd/main.go", true},
+		{"cmd/**/*.go", "internal/x.go", false},
+		{"**/auth*", "a/b/auth_test.go", true},
+		{"./internal/*/x.go", "internal/a/x.go", true},
+		{"internal/*/x.go", "internal/a/b/x.go", false},
+		{"src/**", "src/a/b.ts", true},
+	} {
+		if got := Match(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	files := []string{"README.md", "cmd/ellie/main.go", "internal/a/a.go", "internal/a/a_test.go", "web/app.ts"}
+	got := Filter(files, []string{"internal/", "*.md"})
+	want := []string{"README.md", "internal/a/a.go", "internal/a/a_test.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter = %v, want %v", got, want)
+	}
+	if got := Filter(files, []string{"."}); len(got) != len(files) {
+		t.Errorf("Filter(.) = %v", got)
+	}
+}
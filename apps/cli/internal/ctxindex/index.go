@@ -0,0 +1,278 @@
+package ctxindex
+
+import (
+	"encoding/gob"
+	"errors"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// indexVersion changes whenever chunking, hashing or what is stored does, invalidating
+// cached indexes.
+const indexVersion = 2
+
+const (
+	minChunkLines = 40 // a chunk ends at the first blank line after this many
+	maxChunkLines = 80 // and is cut here regardless
+	hashBuckets   = 1 << 20
+	pathWeight    = 2 // path terms count this many times per chunk
+)
+
+// Term is a hashed term and how often it occurs in a chunk.
+type Term struct {
+	Hash  uint32
+	Count uint16
+}
+
+// Chunk is a run of lines in a file, its term vector and its embedding.
+type Chunk struct {
+	Path   string
+	Start  int // first line, 1-based
+	End    int // last line, inclusive
+	Tokens int // estimated prompt tokens of the text
+	Length int // number of terms, for BM25 length normalization
+	Terms  []Term
+	Vector []float32 // unit-length embedding; nil until embedded
+}
+
+type fileEntry struct {
+	ModTime int64
+	Size    int64
+	Chunks  []Chunk
+}
+
+// Index holds the chunk vectors of a repository's files.
+type Index struct {
+	Version int
+	Files   map[string]*fileEntry
+	// EmbedModel is the model the chunk vectors are from.
+	EmbedModel string
+}
+
+// New returns an empty index.
+func New() *Index {
+	return &Index{Version: indexVersion, Files: map[string]*fileEntry{}}
+}
+
+// Load reads a cached index, returning an empty one when there is none
+// or it was written by another version.
+func Load(name string) (*Index, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var ix Index
+	if err := gob.NewDecoder(f).Decode(&ix); err != nil || ix.Version != indexVersion {
+		return New(), nil
+	}
+	if ix.Files == nil {
+		ix.Files = map[string]*fileEntry{}
+	}
+	return &ix, nil
+}
+
+// Save writes the index to name, replacing any previous one.
+func (ix *Index) Save(name string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".index-*")
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(tmp).Encode(ix); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Update brings the index in line with files under root: new and
+// modified files are chunked in parallel, entries for files no longer
+// listed are dropped. It returns how many files were (re)indexed.
+func (ix *Index) Update(root string, files []string) int {
+	listed := make(map[string]bool, len(files))
+	var stale []string
+	for _, f := range files {
+		listed[f] = true
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(f)))
+		if err != nil || !info.Mode().IsRegular() {
+			delete(ix.Files, f)
+			continue
+		}
+		if e, ok := ix.Files[f]; ok && e.ModTime == info.ModTime().UnixNano() && e.Size == info.Size() {
+			continue
+		}
+		ix.Files[f] = &fileEntry{ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+		stale = append(stale, f)
+	}
+	for f := range ix.Files {
+		if !listed[f] {
+			delete(ix.Files, f)
+		}
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				var chunks []Chunk
+				if data, ok := readText(filepath.Join(root, filepath.FromSlash(f))); ok {
+					chunks = chunkFile(f, string(data))
+				}
+				mu.Lock()
+				ix.Files[f].Chunks = chunks
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, f := range stale {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+	return len(stale)
+}
+
+// Chunks returns the number of chunks indexed.
+func (ix *Index) Chunks() int {
+	n := 0
+	for _, e := range ix.Files {
+		n += len(e.Chunks)
+	}
+	return n
+}
+
+// chunkFile splits text into chunks, preferring to cut at blank lines.
+func chunkFile(name, text string) []Chunk {
+	lines := strings.SplitAfter(text, "\n")
+	if n := len(lines); n > 0 && lines[n-1] == "" {
+		lines = lines[:n-1]
+	}
+	pathTerms := terms(name)
+
+	var chunks []Chunk
+	start := 0
+	for i := range lines {
+		n := i - start + 1
+		last := i == len(lines)-1
+		if !last && n < maxChunkLines && (n < minChunkLines || strings.TrimSpace(lines[i]) != "") {
+			continue
+		}
+		body := strings.Join(lines[start:i+1], "")
+		if strings.TrimSpace(body) != "" {
+			chunks = append(chunks, newChunk(name, start+1, i+1, body, pathTerms))
+		}
+		start = i + 1
+	}
+	return chunks
+}
+
+func newChunk(name string, start, end int, body string, pathTerms []string) Chunk {
+	counts := map[uint32]int{}
+	length := 0
+	for _, t := range terms(body) {
+		counts[hashTerm(t)]++
+		length++
+	}
+	for _, t := range pathTerms {
+		counts[hashTerm(t)] += pathWeight
+		length += pathWeight
+	}
+	c := Chunk{Path: name, Start: start, End: end, Tokens: EstimateTokens(body), Length: length}
+	c.Terms = make([]Term, 0, len(counts))
+	for h, n := range counts {
+		if n > 0xFFFF {
+			n = 0xFFFF
+		}
+		c.Terms = append(c.Terms, Term{Hash: h, Count: uint16(n)})
+	}
+	return c
+}
+
+func hashTerm(t string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(t))
+	return h.Sum32() % hashBuckets
+}
+
+// stopWords are too common in code and prose to say anything about
+// relevance.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true, "from": true,
+	"are": true, "was": true, "not": true, "but": true, "you": true, "all": true, "can": true,
+	"if": true, "else": true, "return": true, "func": true, "function": true, "const": true,
+	"var": true, "let": true, "nil": true, "null": true, "true": true, "false": true, "import": true,
+	"export": true, "type": true, "string": true, "int": true, "err": true, "to": true, "of": true,
+	"in": true, "is": true, "it": true, "be": true, "on": true, "or": true, "as": true, "an": true,
+}
+
+// terms splits text into lower-case words, breaking identifiers at case
+// changes, digits and underscores; "parseHTTPHeader" yields
+// parsehttpheader, parse, http and header.
+func terms(text string) []string {
+	var out []string
+	add := func(t string) {
+		t = strings.ToLower(t)
+		if len(t) > 1 && len(t) <= 40 && !stopWords[t] {
+			out = append(out, t)
+		}
+	}
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			add(strings.ReplaceAll(word, "_", ""))
+		}
+		for _, p := range parts {
+			add(p)
+		}
+	}
+	return out
+}
+
+func splitIdentifier(word string) []string {
+	var parts []string
+	runes := []rune(word)
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			parts = append(parts, string(runes[start:end]))
+		}
+		start = end
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '_':
+			flush(i)
+			start = i + 1
+		case i > start && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])):
+			flush(i)
+		case i > start && unicode.IsDigit(r) != unicode.IsDigit(runes[i-1]):
+			flush(i)
+		}
+	}
+	flush(len(runes))
+	return parts
+}
@@ -0,0 +1,139 @@
+package ctxindex
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTerms(t *testing.T) {
+	got := terms("parseHTTPHeader(user_id, v2Token) // the Base64 thing")
+	want := []string{"parsehttpheader", "parse", "http", "header", "userid", "user", "id", "v2token", "token", "base64", "base", "64", "thing"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("terms =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestChunkFile(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 100; i++ {
+		if i == 45 {
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(&b, "line%d\n", i)
+	}
+	chunks := chunkFile("a.txt", b.String())
+	if len(chunks) != 2 || chunks[0].Start != 1 || chunks[0].End != 45 || chunks[1].Start != 46 || chunks[1].End != 100 {
+		for _, c := range chunks {
+			t.Logf("%d-%d", c.Start, c.End)
+		}
+		t.Fatalf("got %d chunks", len(chunks))
+	}
+
+	long := strings.Repeat("x\n", 200)
+	chunks = chunkFile("b.txt", long)
+	if len(chunks) != 3 || chunks[0].End != maxChunkLines || chunks[2].End != 200 {
+		t.Errorf("unbroken file chunks = %d", len(chunks))
+	}
+}
+
+func writeRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestIndexSearchPack(t *testing.T) {
+	root := writeRepo(t, map[string]string{
+		"auth/token.go":   "package auth\n\n// refreshToken renews an expired OAuth token.\nfunc refreshToken(tok Token) Token { return tok }\n",
+		"ui/render.go":    "package ui\n\n// render draws the chat view.\nfunc render() {}\n",
+		"docs/readme.md":  "# Project\n\nGeneral notes about the chat interface.\n",
+		"assets/logo.bin": "\x00\x01\x02",
+	})
+	files, err := ListFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := New()
+	if n := ix.Update(root, files); n != 4 {
+		t.Errorf("first Update indexed %d files, want 4", n)
+	}
+	if ix.Chunks() != 3 {
+		t.Errorf("Chunks = %d, want 3 (binary file skipped)", ix.Chunks())
+	}
+
+	hits := ix.Search("why does the OAuth token refresh fail?", nil)
+	if len(hits) == 0 || hits[0].Chunk.Path != "auth/token.go" {
+		t.Fatalf("hits = %+v", hits)
+	}
+	if hits := ix.Search("chat view", []string{"docs/readme.md"}); len(hits) != 1 || hits[0].Chunk.Path != "docs/readme.md" {
+		t.Errorf("restricted search = %+v", hits)
+	}
+
+	snippets, used := Pack(root, hits[:1], 1000)
+	if len(snippets) != 1 || snippets[0].Label() != "auth/token.go:1-4" || !strings.Contains(snippets[0].Text, "refreshToken") || used == 0 {
+		t.Errorf("Pack = %+v, %d", snippets, used)
+	}
+	if snippets, _ := Pack(root, hits, 1); len(snippets) != 0 {
+		t.Errorf("Pack over budget = %+v", snippets)
+	}
+
+	// Only the modified file is re-read; the deleted one is dropped.
+	later := time.Now().Add(time.Minute)
+	os.WriteFile(filepath.Join(root, "ui/render.go"), []byte("package ui\n\nfunc paint() {}\n"), 0o644)
+	os.Chtimes(filepath.Join(root, "ui/render.go"), later, later)
+	os.Remove(filepath.Join(root, "docs/readme.md"))
+	files, _ = ListFiles(root)
+	if n := ix.Update(root, files); n != 1 {
+		t.Errorf("second Update indexed %d files, want 1", n)
+	}
+	if _, ok := ix.Files["docs/readme.md"]; ok {
+		t.Error("deleted file still indexed")
+	}
+
+	cache := filepath.Join(t.TempDir(), "idx", "repo.gob")
+	if err := ix.Save(cache); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := loaded.Update(root, files); n != 0 || loaded.Chunks() != ix.Chunks() {
+		t.Errorf("reloaded index re-read %d files, has %d chunks", n, loaded.Chunks())
+	}
+}
+
+func TestPackMergesAdjacentChunks(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		b.WriteString("needle\n")
+	}
+	root := writeRepo(t, map[string]string{"big.txt": b.String()})
+	ix := New()
+	ix.Update(root, []string{"big.txt"})
+	snippets, _ := Pack(root, ix.Search("needle", nil), 10000)
+	if len(snippets) != 1 || snippets[0].Start != 1 || snippets[0].End != 200 {
+		t.Errorf("snippets = %+v", snippets)
+	}
+}
+
+func TestWhole(t *testing.T) {
+	root := writeRepo(t, map[string]string{"a.txt": strings.Repeat("a", 400), "b.txt": strings.Repeat("b", 400), "c.bin": "\x00"})
+	snippets, used, skipped := Whole(root, []string{"a.txt", "c.bin", "b.txt"}, 150)
+	if len(snippets) != 1 || snippets[0].Label() != "a.txt" || used != 100 || !reflect.DeepEqual(skipped, []string{"c.bin", "b.txt"}) {
+		t.Errorf("Whole = %+v, %d, %v", snippets, used, skipped)
+	}
+}
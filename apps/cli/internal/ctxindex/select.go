@@ -0,0 +1,169 @@
+package ctxindex
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BM25 parameters, at their usual values.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Hit is a chunk and how well it matches a query.
+type Hit struct {
+	Chunk *Chunk
+	Score float64
+}
+
+// Search ranks the chunks of the given files (all files when files is
+// nil) against query with BM25, best first. Chunks sharing no term with the query
+// are left out.
+func (ix *Index) Search(query string, files []string) []Hit {
+	chunks := ix.chunks(files)
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	queryTerms := map[uint32]bool{}
+	for _, t := range terms(query) {
+		queryTerms[hashTerm(t)] = true
+	}
+	df := map[uint32]int{}
+	total := 0
+	for _, c := range chunks {
+		total += c.Length
+		for _, t := range c.Terms {
+			if queryTerms[t.Hash] {
+				df[t.Hash]++
+			}
+		}
+	}
+	n := float64(len(chunks))
+	avgLen := float64(total) / n
+
+	var hits []Hit
+	for _, c := range chunks {
+		score := 0.0
+		for _, t := range c.Terms {
+			if !queryTerms[t.Hash] {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[t.Hash])+0.5)/(float64(df[t.Hash])+0.5))
+			tf := float64(t.Count)
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(c.Length)/avgLen))
+		}
+		if score > 0 {
+			hits = append(hits, Hit{Chunk: c, Score: score})
+		}
+	}
+	sortHits(hits)
+	return hits
+}
+
+// chunks returns the chunks of files, or of all files when files is nil.
+func (ix *Index) chunks(files []string) []*Chunk {
+	var chunks []*Chunk
+	collect := func(e *fileEntry) {
+		for i := range e.Chunks {
+			chunks = append(chunks, &e.Chunks[i])
+		}
+	}
+	if files == nil {
+		for _, e := range ix.Files {
+			collect(e)
+		}
+	} else {
+		for _, f := range files {
+			if e, ok := ix.Files[f]; ok {
+				collect(e)
+			}
+		}
+	}
+	return chunks
+}
+
+// Snippet is a span of a file sent as context.
+type Snippet struct {
+	Path  string
+	Start int // first line, 1-based; 0 for a whole file
+	End   int
+	Text  string
+}
+
+// Label names the snippet as path or path:start-end.
+func (s Snippet) Label() string {
+	if s.Start == 0 {
+		return s.Path
+	}
+	return fmt.Sprintf("%s:%d-%d", s.Path, s.Start, s.End)
+}
+
+// Pack takes the best hits that fit in budget tokens, merges adjacent
+// chunks of a file and reads their text from root. Snippets are ordered
+// by file and line so related code reads top to bottom.
+func Pack(root string, hits []Hit, budget int) ([]Snippet, int) {
+	used := 0
+	var picked []*Chunk
+	for _, h := range hits {
+		if used+h.Chunk.Tokens > budget {
+			continue
+		}
+		picked = append(picked, h.Chunk)
+		used += h.Chunk.Tokens
+	}
+	sort.Slice(picked, func(i, j int) bool {
+		if picked[i].Path != picked[j].Path {
+			return picked[i].Path < picked[j].Path
+		}
+		return picked[i].Start < picked[j].Start
+	})
+
+	var snippets []Snippet
+	lines := map[string][]string{}
+	for _, c := range picked {
+		if _, ok := lines[c.Path]; !ok {
+			data, _ := os.ReadFile(filepath.Join(root, filepath.FromSlash(c.Path)))
+			lines[c.Path] = strings.SplitAfter(string(data), "\n")
+		}
+		if n := len(snippets); n > 0 && snippets[n-1].Path == c.Path && snippets[n-1].End+1 == c.Start {
+			snippets[n-1].End = c.End
+			continue
+		}
+		snippets = append(snippets, Snippet{Path: c.Path, Start: c.Start, End: c.End})
+	}
+	for i := range snippets {
+		s := &snippets[i]
+		l := lines[s.Path]
+		end := min(s.End, len(l))
+		if s.Start-1 < end {
+			s.Text = strings.TrimRight(strings.Join(l[s.Start-1:end], ""), "\n")
+		}
+	}
+	return snippets, used
+}
+
+// Whole includes files in full, in order, while they fit in budget
+// tokens. It returns the snippets, the tokens used and the files left
+// out for lack of room or because they are not text.
+func Whole(root string, files []string, budget int) ([]Snippet, int, []string) {
+	var snippets []Snippet
+	var skipped []string
+	used := 0
+	for _, f := range files {
+		data, ok := readText(filepath.Join(root, filepath.FromSlash(f)))
+		tokens := EstimateTokens(string(data))
+		if !ok || used+tokens > budget {
+			skipped = append(skipped, f)
+			continue
+		}
+		snippets = append(snippets, Snippet{Path: f, Text: strings.TrimRight(string(data), "\n")})
+		used += tokens
+	}
+	return snippets, used, skipped
+}
@@ -0,0 +1,112 @@
+package ctxindex
+
+import (
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Location is a file and line mentioned in a stack trace or log.
+type Location struct {
+	Path string
+	Line int
+}
+
+var locationPatterns = []*regexp.Regexp{
+	// Python: File "/app/main.py", line 12, in handler
+	regexp.MustCompile(`File "([^"]+)", line (\d+)`),
+	// JS/TS, Go, Rust, gcc and most others: path/to/file.ts:12 or :12:5,
+	// possibly inside "at fn (...)" or as a file:// URL.
+	regexp.MustCompile(`((?:file://)?(?:[A-Za-z]:)?[\w.~@/\\-]*[\w-]\.[A-Za-z]\w{0,5}):(\d+)`),
+}
+
+// ParseLocations finds the file locations in a stack trace or log, in
+// order of first mention, without duplicates.
+func ParseLocations(text string) []Location {
+	type match struct {
+		at  int
+		loc Location
+	}
+	var matches []match
+	for _, re := range locationPatterns {
+		for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+			path := text[m[2]:m[3]]
+			line, err := strconv.Atoi(text[m[4]:m[5]])
+			if err != nil || line == 0 {
+				continue
+			}
+			if u, err := url.Parse(path); err == nil && u.Scheme == "file" {
+				path = u.Path
+			}
+			matches = append(matches, match{m[0], Location{Path: path, Line: line}})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].at < matches[j].at })
+	seen := map[Location]bool{}
+	var locs []Location
+	for _, m := range matches {
+		if !seen[m.loc] {
+			seen[m.loc] = true
+			locs = append(locs, m.loc)
+		}
+	}
+	return locs
+}
+
+// Resolve maps a path from a trace — absolute, from another machine or
+// container, or relative to some other directory — to one of files, the
+// slash-separated paths of the repository at root. It picks the file
+// sharing the longest run of trailing path elements, and fails when
+// there is none or a tie.
+func Resolve(root string, files []string, path string) (string, bool) {
+	path = filepath.ToSlash(path)
+	if rel, err := filepath.Rel(root, filepath.FromSlash(path)); err == nil && filepath.IsAbs(filepath.FromSlash(path)) && !strings.HasPrefix(rel, "..") {
+		path = filepath.ToSlash(rel)
+	}
+	want := strings.Split(strings.TrimPrefix(path, "./"), "/")
+	best, bestScore, tie := "", 0, false
+	for _, f := range files {
+		have := strings.Split(f, "/")
+		score := 0
+		for score < len(want) && score < len(have) && want[len(want)-1-score] == have[len(have)-1-score] {
+			score++
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = f, score, false
+		case score == bestScore && score > 0:
+			tie = true
+		}
+	}
+	if bestScore == 0 || tie {
+		return "", false
+	}
+	return best, true
+}
+
+// Around returns the lines of file within radius of line, with that line
+// marked, or false if the file can't be read as text or is too short.
+func Around(root, file string, line, radius int) (Snippet, bool) {
+	data, ok := readText(filepath.Join(root, filepath.FromSlash(file)))
+	if !ok {
+		return Snippet{}, false
+	}
+	lines := strings.Split(string(data), "\n")
+	if line < 1 || line > len(lines) {
+		return Snippet{}, false
+	}
+	start := max(1, line-radius)
+	end := min(len(lines), line+radius)
+	var b strings.Builder
+	for n := start; n <= end; n++ {
+		mark := "  "
+		if n == line {
+			mark = "> "
+		}
+		b.WriteString(mark + lines[n-1] + "\n")
+	}
+	return Snippet{Path: file, Start: start, End: end, Text: b.String()}, true
+}
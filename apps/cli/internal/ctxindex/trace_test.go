@@ -0,0 +1,60 @@
+package ctxindex
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseLocations(t *testing.T) {
+	trace := `TypeError: Cannot read properties of undefined (reading 'id')
+    at resolveBranch (/app/apps/server/src/routes/chat.ts:42:17)
+    at file:///app/apps/server/src/server.ts:120:5
+    at resolveBranch (/app/apps/server/src/routes/chat.ts:42:17)
+Traceback (most recent call last):
+  File "/srv/tools/sync.py", line 8, in <module>
+panic: runtime error
+	/home/me/ellie/apps/cli/cmd/ellie/main.go:91 +0x1d
+see https://example.com/docs for more (v1.2.3)`
+	got := ParseLocations(trace)
+	want := []string{
+		"/app/apps/server/src/routes/chat.ts:42",
+		"/app/apps/server/src/server.ts:120",
+		"/srv/tools/sync.py:8",
+		"/home/me/ellie/apps/cli/cmd/ellie/main.go:91",
+	}
+	var have []string
+	for _, l := range got {
+		have = append(have, fmt.Sprintf("%s:%d", l.Path, l.Line))
+	}
+	if strings.Join(have, " ") != strings.Join(want, " ") {
+		t.Errorf("ParseLocations =\n%s\nwant\n%s", strings.Join(have, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestResolve(t *testing.T) {
+	files := []string{"apps/server/src/routes/chat.ts", "apps/web/src/routes/chat.ts", "apps/server/src/server.ts", "README.md"}
+	for path, want := range map[string]string{
+		"/app/apps/server/src/routes/chat.ts": "apps/server/src/routes/chat.ts",
+		"/repo/server.ts":                     "apps/server/src/server.ts",
+		"/repo/apps/server/src/server.ts":     "apps/server/src/server.ts",
+		"routes/chat.ts":                      "", // ambiguous
+		"/elsewhere/missing.go":               "",
+	} {
+		got, ok := Resolve("/repo", files, path)
+		if got != want || ok != (want != "") {
+			t.Errorf("Resolve(%s) = %q, %v; want %q", path, got, ok, want)
+		}
+	}
+}
+
+func TestAround(t *testing.T) {
+	root := writeRepo(t, map[string]string{"a.go": "1\n2\n3\n4\n5\n6\n"})
+	s, ok := Around(root, "a.go", 3, 1)
+	if !ok || s.Start != 2 || s.End != 4 || s.Text != "  2\n> 3\n  4\n" {
+		t.Errorf("Around = %+v, %v", s, ok)
+	}
+	if _, ok := Around(root, "a.go", 99, 1); ok {
+		t.Error("Around past the end succeeded")
+	}
+}
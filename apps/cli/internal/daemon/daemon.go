@@ -0,0 +1,167 @@
+// Package daemon holds the parts of ellie's background server supervisor
+// that don't depend on the CLI: the backoff between restarts of a server
+// that keeps crashing, telling when to stop restarting it, and the
+// control protocol spoken over the
+// supervisor's unix socket — one JSON request and one JSON response per
+// connection.
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Backoff spaces out restarts of a crashing server: Min after the first
+// crash, doubling up to Max. A server that stayed up for at least Reset
+// before crashing starts again from Min.
+type Backoff struct {
+	Min, Max, Reset time.Duration
+
+	n int
+}
+
+// Next returns how long to wait before restarting a server that ran for
+// ran before exiting.
+func (b *Backoff) Next(ran time.Duration) time.Duration {
+	if ran >= b.Reset {
+		b.n = 0
+	}
+	d := b.Min << b.n
+	if d > b.Max || d <= 0 {
+		d = b.Max
+	} else {
+		b.n++
+	}
+	return d
+}
+
+// Clear starts the next Next from Min again.
+func (b *Backoff) Clear() {
+	b.n = 0
+}
+
+// CrashLoop tells a server that keeps crashing from one that crashes now
+// and then: it is in a crash loop once it has crashed Failures times
+// within Window. A zero Failures never reports a loop.
+type CrashLoop struct {
+	Failures int
+	Window   time.Duration
+
+	crashes []time.Time
+}
+
+// Crash records a crash at t and reports whether the server is now in a
+// crash loop.
+func (c *CrashLoop) Crash(t time.Time) bool {
+	if c.Failures <= 0 {
+		return false
+	}
+	c.crashes = append(c.crashes, t)
+	for len(c.crashes) > 0 && t.Sub(c.crashes[0]) > c.Window {
+		c.crashes = c.crashes[1:]
+	}
+	return len(c.crashes) >= c.Failures
+}
+
+// Span returns how long the crashes Crash counted took, from the first
+// to the last.
+func (c *CrashLoop) Span() time.Duration {
+	if len(c.crashes) == 0 {
+		return 0
+	}
+	return c.crashes[len(c.crashes)-1].Sub(c.crashes[0])
+}
+
+// Supervisor states.
+const (
+	StateRunning  = "running"  // the server is up
+	StateBackoff  = "backoff"  // the server crashed and will be started again
+	StateStopping = "stopping" // the supervisor is shutting down
+)
+
+// Status is what the supervisor reports about itself and its server.
+type Status struct {
+	PID         int       `json:"pid"`
+	Since       time.Time `json:"since"`
+	State       string    `json:"state"`
+	ServerPID   int       `json:"serverPid,omitempty"`
+	ServerSince time.Time `json:"serverSince,omitzero"`
+	Restarts    int       `json:"restarts"`           // after crashes, not on request
+	LastExit    *int      `json:"lastExit,omitempty"` // the server's last exit code
+	NextStart   time.Time `json:"nextStart,omitzero"` // in backoff, when the server is started again
+	Log         string    `json:"log"`
+}
+
+// Control operations.
+const (
+	OpStatus  = "status"
+	OpRestart = "restart" // restart the server now, ending any backoff
+	OpStop    = "stop"    // stop the server and the supervisor
+)
+
+// Request is sent to the supervisor.
+type Request struct {
+	Op string `json:"op"`
+}
+
+// Response is the supervisor's answer. Status is set for every
+// successful request.
+type Response struct {
+	Error  string  `json:"error,omitempty"`
+	Status *Status `json:"status,omitempty"`
+}
+
+// Serve answers requests on ln with handle until ln is closed.
+func Serve(ln net.Listener, handle func(Request) Response) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(10 * time.Second))
+			var req Request
+			if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+				return
+			}
+			json.NewEncoder(conn).Encode(handle(req))
+		}()
+	}
+}
+
+// ErrNotRunning is returned by Call when nothing is listening on the
+// socket.
+var ErrNotRunning = errors.New("the daemon is not running")
+
+// Call sends req to the supervisor listening on socket.
+func Call(ctx context.Context, socket string, req Request) (*Response, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socket)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("cannot reach the daemon: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("invalid response from the daemon: %w", err)
+	}
+	if resp.Error != "" {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,92 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Min: time.Second, Max: 10 * time.Second, Reset: time.Minute}
+	var got []time.Duration
+	for range 5 {
+		got = append(got, b.Next(time.Second))
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Next = %v, want %v", got, want)
+		}
+	}
+	if d := b.Next(2 * time.Minute); d != time.Second {
+		t.Errorf("Next after a long run = %v, want the minimum", d)
+	}
+	b.Next(time.Second)
+	b.Clear()
+	if d := b.Next(time.Second); d != time.Second {
+		t.Errorf("Next after Clear = %v, want the minimum", d)
+	}
+}
+
+func TestCrashLoop(t *testing.T) {
+	c := CrashLoop{Failures: 3, Window: time.Minute}
+	start := time.Now()
+	for i, at := range []time.Duration{0, 50 * time.Second, 70 * time.Second, 120 * time.Second} {
+		if c.Crash(start.Add(at)) {
+			t.Fatalf("crash %d at %v reported a loop", i+1, at)
+		}
+	}
+	if !c.Crash(start.Add(125 * time.Second)) {
+		t.Error("three crashes within a minute were not a loop")
+	}
+	if span := c.Span(); span != 55*time.Second {
+		t.Errorf("Span = %v, want 55s", span)
+	}
+
+	off := CrashLoop{Window: time.Minute}
+	for range 10 {
+		if off.Crash(start) {
+			t.Fatal("a zero Failures reported a loop")
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "d.sock")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := Call(ctx, socket, Request{Op: OpStatus}); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("Call with no daemon = %v, want ErrNotRunning", err)
+	}
+
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skip("unix sockets unavailable:", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ln, func(req Request) Response {
+			if req.Op != OpStatus {
+				return Response{Error: "unknown operation " + req.Op}
+			}
+			return Response{Status: &Status{PID: 7, State: StateRunning, Restarts: 2}}
+		})
+	}()
+
+	resp, err := Call(ctx, socket, Request{Op: OpStatus})
+	if err != nil || resp.Status == nil || resp.Status.PID != 7 || resp.Status.Restarts != 2 {
+		t.Fatalf("Call status = %+v, %v", resp, err)
+	}
+	if _, err := Call(ctx, socket, Request{Op: "bogus"}); err == nil || err.Error() != "unknown operation bogus" {
+		t.Errorf("Call bogus = %v", err)
+	}
+
+	ln.Close()
+	if err := <-done; err != nil {
+		t.Errorf("Serve = %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+// Package extract pulls structured content out of model responses: fenced
+// code blocks, JSON values, and fields selected with a jq-style filter.
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrNoCode is returned when a response contains no code block.
+	ErrNoCode = errors.New("no code block in the response")
+	// ErrNoJSON is returned when a response contains no valid JSON value.
+	ErrNoJSON = errors.New("no JSON object or array in the response")
+)
+
+// Block is a fenced code block.
+type Block struct {
+	Lang string
+	File string // the file the block is from, when the fence or the line before it names one
+	Code string
+}
+
+// Blocks returns every fenced code block in text, in order. A block left
+// open at the end of text runs to the end.
+func Blocks(text string) []Block {
+	blocks, _ := scanBlocks(text)
+	return blocks
+}
+
+// OpenFence reports whether text ends inside a fenced code block, and the
+// block's language.
+func OpenFence(text string) (open bool, lang string) {
+	blocks, open := scanBlocks(text)
+	if !open {
+		return false, ""
+	}
+	return true, blocks[len(blocks)-1].Lang
+}
+
+// scanBlocks returns the fenced code blocks of text, and whether the last
+// one is left open.
+func scanBlocks(text string) (blocks []Block, open bool) {
+	var fence, prev string
+	var cur Block
+	var body []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		marker := FenceMarker(trimmed)
+		switch {
+		case fence == "" && marker != "":
+			fence = marker
+			cur = Block{}
+			cur.Lang, cur.File = parseInfo(strings.TrimSpace(strings.TrimLeft(trimmed, marker[:1])), prev)
+			body = body[:0]
+		case fence != "" && strings.HasPrefix(trimmed, fence) && strings.TrimLeft(trimmed, fence[:1]) == "":
+			cur.Code = strings.Join(body, "\n")
+			blocks = append(blocks, cur)
+			fence = ""
+		case fence != "":
+			body = append(body, line)
+		}
+		if fence == "" && trimmed != "" {
+			prev = trimmed
+		}
+	}
+	if fence != "" {
+		cur.Code = strings.Join(body, "\n")
+		blocks = append(blocks, cur)
+	}
+	return blocks, fence != ""
+}
+
+// FenceMarker returns the run of ``` or ~~~ a line starts with, or "" when
+// it doesn't open or close a fence.
+func FenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// Code returns the first code block in text. With lang set, only blocks
+// tagged with that language (see SameLang) are considered.
+func Code(text, lang string) (string, error) {
+	for _, b := range Blocks(text) {
+		if lang == "" || SameLang(b.Lang, lang) {
+			return b.Code, nil
+		}
+	}
+	if lang != "" {
+		return "", errors.New("no " + lang + " code block in the response")
+	}
+	return "", ErrNoCode
+}
+
+// JSON returns the first valid JSON object or array in text, preferring
+// the contents of a json code block, then any code block, then a JSON
+// value embedded in the prose.
+func JSON(text string) (json.RawMessage, error) {
+	blocks := Blocks(text)
+	for _, b := range blocks {
+		if strings.EqualFold(b.Lang, "json") && json.Valid([]byte(b.Code)) {
+			return json.RawMessage(strings.TrimSpace(b.Code)), nil
+		}
+	}
+	for _, b := range blocks {
+		if v, ok := firstJSON(b.Code); ok {
+			return v, nil
+		}
+	}
+	if v, ok := firstJSON(text); ok {
+		return v, nil
+	}
+	return nil, ErrNoJSON
+}
+
+// firstJSON finds the first '{' or '[' in text that starts a complete,
+// valid JSON value.
+func firstJSON(text string) (json.RawMessage, bool) {
+	for i := 0; i < len(text); i++ {
+		if text[i] != '{' && text[i] != '[' {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(text[i:]))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == nil {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
+// Format renders a value the way jq does: strings raw (as with jq -r)
+// and everything else as indented JSON.
+func Format(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
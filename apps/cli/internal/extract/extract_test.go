@@ -0,0 +1,153 @@
+package extract
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const response = "Here is the script:\n\n```python\nprint('hi')\n```\n\nAnd the config:\n\n```json\n{\"name\": \"ellie\", \"tags\": [\"a\", \"b\"], \"n\": 2}\n```\n"
+
+func TestCode(t *testing.T) {
+	if got, err := Code(response, ""); err != nil || got != "print('hi')" {
+		t.Errorf("Code = %q, %v", got, err)
+	}
+	if got, err := Code(response, "JSON"); err != nil || got != `{"name": "ellie", "tags": ["a", "b"], "n": 2}` {
+		t.Errorf("Code(json) = %q, %v", got, err)
+	}
+	if _, err := Code("no code here", ""); !errors.Is(err, ErrNoCode) {
+		t.Errorf("err = %v", err)
+	}
+	// An unterminated block (truncated answer) still yields its code.
+	if got, _ := Code("```go\nfunc main() {", "go"); got != "func main() {" {
+		t.Errorf("open block = %q", got)
+	}
+}
+
+func TestBlocksFile(t *testing.T) {
+	text := "Update `src/app.ts`:\n\n```ts\nexport {}\n```\n\n" +
+		"```go title=\"cmd/main.go\"\npackage main\n```\n\n" +
+		"```py:tools/run.py\nprint()\n```\n\n" +
+		"```Dockerfile\nFROM alpine\n```\n\n" +
+		"Here is a Node.js script:\n\n```\nconsole.log(1)\n```\n\n" +
+		"**lib/util.rb**\n```\nputs 1\n```\n"
+	var got []Block
+	for _, b := range Blocks(text) {
+		got = append(got, Block{Lang: b.Lang, File: b.File})
+	}
+	want := []Block{
+		{Lang: "ts", File: "src/app.ts"},
+		{Lang: "go", File: "cmd/main.go"},
+		{Lang: "py", File: "tools/run.py"},
+		{Lang: "Dockerfile"},
+		{},
+		{Lang: "ruby", File: "lib/util.rb"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestOpenFence(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		open bool
+		lang string
+	}{
+		{"prose", false, ""},
+		{"```go\nfunc main() {}\n```\n", false, ""},
+		{"```go title=main.go\nfunc main() {", true, "go"},
+		{"````md\n```\nnested", true, "md"},
+		{"~~~\nplain", true, ""},
+	} {
+		if open, lang := OpenFence(tc.text); open != tc.open || lang != tc.lang {
+			t.Errorf("OpenFence(%q) = %v, %q; want %v, %q", tc.text, open, lang, tc.open, tc.lang)
+		}
+	}
+}
+
+func TestSameLang(t *testing.T) {
+	for _, pair := range [][2]string{{"ts", "TypeScript"}, {"golang", "go"}, {"shell", "sh"}, {"Dockerfile", "docker"}} {
+		if !SameLang(pair[0], pair[1]) {
+			t.Errorf("SameLang(%q, %q) = false", pair[0], pair[1])
+		}
+	}
+	if SameLang("java", "javascript") {
+		t.Error("java and javascript are different languages")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct{ name, in, want string }{
+		{"json block", response, `{"name": "ellie", "tags": ["a", "b"], "n": 2}`},
+		{"untagged block", "```\n[1, 2]\n```", `[1, 2]`},
+		{"inline in prose", `Sure! {"ok": true} Let me know.`, `{"ok": true}`},
+		{"skips invalid braces", `use {braces} like {"a": {"b": 1}}`, `{"a": {"b": 1}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSON(tt.in)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("JSON = %s, %v; want %s", got, err, tt.want)
+			}
+		})
+	}
+	if _, err := JSON("nothing"); !errors.Is(err, ErrNoJSON) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	doc := []byte(`{"name":"ellie","tags":["a","b"],"items":[{"id":1},{"id":2}],"meta":{"v":"1.0"}}`)
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{".name", []string{"ellie"}},
+		{".tags[1]", []string{"b"}},
+		{".tags[-1]", []string{"b"}},
+		{".items[].id", []string{"1", "2"}},
+		{".items | length", []string{"2"}},
+		{".meta | keys", []string{"[\n  \"v\"\n]"}},
+		{`.["meta"]."v"`, []string{"1.0"}},
+		{".name, .meta.v", []string{"ellie", "1.0"}},
+		{".missing.deeper", []string{"null"}},
+		{".name[0]?", nil},
+		{"(.items[0]) | .id", []string{"1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			q, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := q.Run(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, v := range out {
+				s, err := Format(v)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, s)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	for _, expr := range []string{"", ".foo |", ".[", "map(.x)", `."open`} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded", expr)
+		}
+	}
+	q, _ := Parse(".name[0]")
+	if _, err := q.Run([]byte(`{"name":"x"}`)); err == nil {
+		t.Error("indexing a string should fail")
+	}
+}
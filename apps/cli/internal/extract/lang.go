@@ -0,0 +1,109 @@
+package extract
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// langByExt maps file extensions to the language name fences use for them.
+var langByExt = map[string]string{
+	"c": "c", "h": "c", "cc": "cpp", "cpp": "cpp", "hpp": "cpp", "cs": "csharp",
+	"css": "css", "scss": "scss", "html": "html", "vue": "vue", "svelte": "svelte",
+	"go": "go", "rs": "rust", "zig": "zig", "swift": "swift", "kt": "kotlin", "java": "java", "scala": "scala",
+	"py": "python", "rb": "ruby", "php": "php", "lua": "lua", "pl": "perl", "ex": "elixir", "exs": "elixir", "hs": "haskell",
+	"js": "javascript", "mjs": "javascript", "cjs": "javascript", "jsx": "jsx", "ts": "typescript", "mts": "typescript", "tsx": "tsx",
+	"sh": "sh", "bash": "bash", "zsh": "zsh", "fish": "fish", "ps1": "powershell",
+	"sql": "sql", "json": "json", "jsonc": "json", "yaml": "yaml", "yml": "yaml", "toml": "toml", "ini": "ini", "xml": "xml",
+	"md": "markdown", "proto": "protobuf", "graphql": "graphql", "tf": "hcl", "nix": "nix",
+}
+
+// langByName maps files known by their name alone.
+var langByName = map[string]string{
+	"Dockerfile": "dockerfile", "Makefile": "make", "Justfile": "just", "Caddyfile": "caddy",
+}
+
+// langAliases folds the other names fences use for a language into one.
+var langAliases = map[string]string{
+	"golang": "go", "py": "python", "python3": "python", "rb": "ruby", "rs": "rust",
+	"js": "javascript", "node": "javascript", "ts": "typescript",
+	"shell": "sh", "console": "sh", "shellscript": "sh", "yml": "yaml", "md": "markdown",
+	"c++": "cpp", "cs": "csharp", "c#": "csharp", "kt": "kotlin", "docker": "dockerfile", "makefile": "make",
+}
+
+// SameLang reports whether two fence languages name the same language,
+// ignoring case and common aliases such as ts and typescript.
+func SameLang(a, b string) bool {
+	return canonicalLang(a) == canonicalLang(b)
+}
+
+func canonicalLang(lang string) string {
+	lang = strings.ToLower(lang)
+	if c, ok := langAliases[lang]; ok {
+		return c
+	}
+	return lang
+}
+
+// LangOf guesses a file's language from its name, or returns "".
+func LangOf(file string) string {
+	base := file[strings.LastIndexAny(file, "/\\")+1:]
+	if lang, ok := langByName[base]; ok {
+		return lang
+	}
+	ext := filepath.Ext(base)
+	if ext == "" || ext == base {
+		return ""
+	}
+	return langByExt[strings.ToLower(ext[1:])]
+}
+
+// parseInfo reads a fence's info string — "go", "go title=main.go",
+// "ts:src/app.ts" or just "main.go" — into the block's language and file.
+// When the fence names no file, prev, the line before it, may: answers
+// often introduce a block with "`src/app.ts`:" or "In **main.go**".
+func parseInfo(info, prev string) (lang, file string) {
+	for i, field := range strings.Fields(info) {
+		if k, v, ok := strings.Cut(field, "="); ok {
+			switch strings.ToLower(k) {
+			case "title", "file", "filename", "path":
+				file = strings.Trim(v, `"'`)
+			}
+			continue
+		}
+		if i > 0 {
+			continue
+		}
+		if l, f, ok := strings.Cut(field, ":"); ok && LangOf(f) != "" {
+			lang, file = l, f
+		} else if LangOf(field) != "" && strings.ContainsAny(field, "./") {
+			lang, file = LangOf(field), field
+		} else {
+			lang = field
+		}
+	}
+	if file == "" {
+		file = fileHint(prev)
+	}
+	if lang == "" && file != "" {
+		lang = LangOf(file)
+	}
+	return lang, file
+}
+
+// fileHint returns the last file name in a short line of prose. Only
+// names set off as code, in bold, with a path or before a colon count, so
+// that "a Node.js script" names no file.
+func fileHint(line string) string {
+	if len(line) > 120 {
+		return ""
+	}
+	var hint string
+	for _, raw := range strings.Fields(line) {
+		word := strings.TrimRight(strings.Trim(raw, "`*_\"'()[],:;"), ".")
+		marked := strings.ContainsAny(raw, "`*/") || strings.HasSuffix(raw, ":")
+		if marked && LangOf(word) != "" && !strings.Contains(word, "://") {
+			hint = word
+		}
+	}
+	return hint
+}
@@ -0,0 +1,443 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Query is a compiled jq-style filter. It supports the subset scripts
+// reach for when picking fields out of a response:
+//
+//	.                identity
+//	.foo  ."foo"     object field (null when missing)
+//	.[0]  .[-1]      array element
+//	.[]              every element of an array or value of an object
+//	.a.b[0].c[]      chained paths; a trailing ? suppresses errors
+//	f | g            pipe
+//	f, g             multiple outputs
+//	keys  length     builtins
+//	(f)              grouping
+type Query struct {
+	src    string
+	filter filter
+}
+
+// Parse compiles a filter expression.
+func Parse(expr string) (*Query, error) {
+	p := &parser{src: expr}
+	f, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:])
+	}
+	return &Query{src: expr, filter: f}, nil
+}
+
+// String returns the expression the query was parsed from.
+func (q *Query) String() string { return q.src }
+
+// Run applies the query to a JSON document and returns every output.
+func (q *Query) Run(doc json.RawMessage) ([]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return q.filter.eval(v)
+}
+
+// ─── Evaluation ───────────────────────────────────────────────────
+
+type filter interface {
+	eval(v any) ([]any, error)
+}
+
+type pipe struct{ left, right filter }
+
+func (f pipe) eval(v any) ([]any, error) {
+	in, err := f.left.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	var out []any
+	for _, x := range in {
+		res, err := f.right.eval(x)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, res...)
+	}
+	return out, nil
+}
+
+type comma []filter
+
+func (f comma) eval(v any) ([]any, error) {
+	var out []any
+	for _, g := range f {
+		res, err := g.eval(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, res...)
+	}
+	return out, nil
+}
+
+type builtin string
+
+func (f builtin) eval(v any) ([]any, error) {
+	switch f {
+	case "keys":
+		switch x := v.(type) {
+		case map[string]any:
+			keys := sortedKeys(x)
+			out := make([]any, len(keys))
+			for i, k := range keys {
+				out[i] = k
+			}
+			return []any{out}, nil
+		case []any:
+			out := make([]any, len(x))
+			for i := range x {
+				out[i] = json.Number(strconv.Itoa(i))
+			}
+			return []any{out}, nil
+		}
+		return nil, fmt.Errorf("%s has no keys", typeName(v))
+	case "length":
+		switch x := v.(type) {
+		case nil:
+			return []any{json.Number("0")}, nil
+		case string:
+			return []any{json.Number(strconv.Itoa(utf8.RuneCountInString(x)))}, nil
+		case []any:
+			return []any{json.Number(strconv.Itoa(len(x)))}, nil
+		case map[string]any:
+			return []any{json.Number(strconv.Itoa(len(x)))}, nil
+		case json.Number:
+			return []any{json.Number(strings.TrimPrefix(x.String(), "-"))}, nil
+		}
+		return nil, fmt.Errorf("%s has no length", typeName(v))
+	}
+	return nil, fmt.Errorf("unknown function %s", string(f))
+}
+
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepIndex
+	stepIterate
+)
+
+type step struct {
+	kind     stepKind
+	field    string
+	index    int
+	optional bool
+}
+
+// path is a chain of steps; an empty path is the identity.
+type path []step
+
+func (f path) eval(v any) ([]any, error) {
+	cur := []any{v}
+	for _, s := range f {
+		var next []any
+		for _, x := range cur {
+			res, err := s.apply(x)
+			if err != nil {
+				if s.optional {
+					continue
+				}
+				return nil, err
+			}
+			next = append(next, res...)
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+func (s step) apply(v any) ([]any, error) {
+	switch s.kind {
+	case stepField:
+		switch x := v.(type) {
+		case nil:
+			return []any{nil}, nil
+		case map[string]any:
+			return []any{x[s.field]}, nil
+		}
+		return nil, fmt.Errorf("cannot index %s with %q", typeName(v), s.field)
+	case stepIndex:
+		switch x := v.(type) {
+		case nil:
+			return []any{nil}, nil
+		case []any:
+			i := s.index
+			if i < 0 {
+				i += len(x)
+			}
+			if i < 0 || i >= len(x) {
+				return []any{nil}, nil
+			}
+			return []any{x[i]}, nil
+		}
+		return nil, fmt.Errorf("cannot index %s with a number", typeName(v))
+	default:
+		switch x := v.(type) {
+		case []any:
+			return x, nil
+		case map[string]any:
+			out := make([]any, 0, len(x))
+			for _, k := range sortedKeys(x) {
+				out = append(out, x[k])
+			}
+			return out, nil
+		}
+		return nil, fmt.Errorf("cannot iterate over %s", typeName(v))
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// ─── Parsing ──────────────────────────────────────────────────────
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("jq filter %q: %s", p.src, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) parsePipe() (filter, error) {
+	left, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.peek() != '|' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseComma()
+		if err != nil {
+			return nil, err
+		}
+		left = pipe{left, right}
+	}
+}
+
+func (p *parser) parseComma() (filter, error) {
+	first, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	terms := comma{first}
+	for {
+		p.skipSpace()
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+		t, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return terms, nil
+}
+
+func (p *parser) parseTerm() (filter, error) {
+	p.skipSpace()
+	switch c := p.peek(); {
+	case c == '.':
+		return p.parsePath()
+	case c == '(':
+		p.pos++
+		f, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.peek() != ')' {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		return f, nil
+	case isIdentStart(c):
+		name := p.ident()
+		switch name {
+		case "keys", "length":
+			return builtin(name), nil
+		}
+		return nil, p.errorf("unsupported function %s (supported: keys, length)", name)
+	case c == 0:
+		return nil, p.errorf("unexpected end of filter")
+	default:
+		return nil, p.errorf("unexpected %q", string(c))
+	}
+}
+
+func (p *parser) parsePath() (filter, error) {
+	var steps path
+	for {
+		switch p.peek() {
+		case '.':
+			p.pos++
+			switch c := p.peek(); {
+			case isIdentStart(c):
+				steps = append(steps, step{kind: stepField, field: p.ident()})
+			case c == '"':
+				s, err := p.quoted()
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, step{kind: stepField, field: s})
+			case c == '[':
+				// handled by the next iteration
+			default:
+				if len(steps) > 0 {
+					return nil, p.errorf("expected a field name after .")
+				}
+			}
+		case '[':
+			s, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		case '?':
+			if len(steps) == 0 {
+				return nil, p.errorf("nothing to make optional")
+			}
+			p.pos++
+			steps[len(steps)-1].optional = true
+		default:
+			return steps, nil
+		}
+	}
+}
+
+func (p *parser) bracket() (step, error) {
+	p.pos++ // [
+	p.skipSpace()
+	var s step
+	switch c := p.peek(); {
+	case c == ']':
+		s = step{kind: stepIterate}
+	case c == '"':
+		field, err := p.quoted()
+		if err != nil {
+			return step{}, err
+		}
+		s = step{kind: stepField, field: field}
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		n, err := strconv.Atoi(p.src[start:p.pos])
+		if err != nil {
+			return step{}, p.errorf("invalid index %q", p.src[start:p.pos])
+		}
+		s = step{kind: stepIndex, index: n}
+	default:
+		return step{}, p.errorf("expected ], a number or a string after [")
+	}
+	p.skipSpace()
+	if p.peek() != ']' {
+		return step{}, p.errorf("missing ]")
+	}
+	p.pos++
+	return s, nil
+}
+
+func (p *parser) quoted() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '"':
+			p.pos++
+			s, err := strconv.Unquote(p.src[start:p.pos])
+			if err != nil {
+				return "", p.errorf("invalid string %s", p.src[start:p.pos])
+			}
+			return s, nil
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) ident() string {
+	start := p.pos
+	for p.pos < len(p.src) && (isIdentStart(p.src[p.pos]) || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,135 @@
+// Package gcpcreds finds and checks Google Cloud credential files — service
+// account keys and Application Default Credentials (ADC) — before their
+// path is handed to the server for Vertex AI.
+package gcpcreds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Credential file types.
+const (
+	TypeServiceAccount  = "service_account"
+	TypeAuthorizedUser  = "authorized_user"  // gcloud auth application-default login
+	TypeExternalAccount = "external_account" // workload identity federation
+)
+
+// ErrNoADC is returned when no Application Default Credentials are set up.
+var ErrNoADC = errors.New("no Application Default Credentials — run gcloud auth application-default login, or set GOOGLE_APPLICATION_CREDENTIALS")
+
+// File is the part of a credential file the CLI checks and shows.
+type File struct {
+	Path           string `json:"-"`
+	Type           string `json:"type"`
+	ProjectID      string `json:"project_id"`
+	QuotaProjectID string `json:"quota_project_id"`
+	ClientEmail    string `json:"client_email"`
+	PrivateKey     string `json:"private_key"`
+	ClientID       string `json:"client_id"`
+	RefreshToken   string `json:"refresh_token"`
+	Audience       string `json:"audience"`
+}
+
+// Load reads and checks the credential file at path.
+func Load(path string) (*File, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s is not a Google credential file: %w", path, err)
+	}
+	f.Path = abs
+
+	var missing []string
+	need := func(field, value string) {
+		if value == "" {
+			missing = append(missing, field)
+		}
+	}
+	switch f.Type {
+	case TypeServiceAccount:
+		need("client_email", f.ClientEmail)
+		need("private_key", f.PrivateKey)
+		need("project_id", f.ProjectID)
+	case TypeAuthorizedUser:
+		need("client_id", f.ClientID)
+		need("refresh_token", f.RefreshToken)
+	case TypeExternalAccount:
+		need("audience", f.Audience)
+	case "":
+		return nil, fmt.Errorf("%s has no credential type — is it a service account key?", path)
+	default:
+		return nil, fmt.Errorf("%s has unsupported credential type %q", path, f.Type)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s is missing %s", path, strings.Join(missing, ", "))
+	}
+	return &f, nil
+}
+
+// Project returns the Google Cloud project the file belongs to, if any.
+func (f *File) Project() string {
+	if f.ProjectID != "" {
+		return f.ProjectID
+	}
+	return f.QuotaProjectID
+}
+
+// Account describes who the credentials act as.
+func (f *File) Account() string {
+	switch f.Type {
+	case TypeServiceAccount:
+		return f.ClientEmail
+	case TypeAuthorizedUser:
+		return "gcloud user credentials"
+	}
+	return f.Type
+}
+
+// ADCPath returns the Application Default Credentials file: the one named
+// by GOOGLE_APPLICATION_CREDENTIALS, or the well-known file gcloud writes.
+func ADCPath() (string, error) {
+	if p := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); p != "" {
+		return p, nil
+	}
+	dir := os.Getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+		} else {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, ".config", "gcloud")
+		}
+	}
+	p := filepath.Join(dir, "application_default_credentials.json")
+	if _, err := os.Stat(p); errors.Is(err, os.ErrNotExist) {
+		return "", ErrNoADC
+	}
+	return p, nil
+}
+
+// DefaultProject returns the project configured in the environment, as
+// the Google client libraries read it.
+func DefaultProject() string {
+	for _, k := range []string{"GOOGLE_CLOUD_PROJECT", "GCLOUD_PROJECT", "CLOUDSDK_CORE_PROJECT"} {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,259 @@
+// Package jobs keeps the state of batch jobs that run in the background,
+// so they can be listed, followed, cancelled and resumed from any
+// terminal. Each job is a directory holding job.json, the results as
+// JSON lines, and the runner's log.
+package jobs
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Job states. A job recorded as running whose runner has gone away is
+// reported as interrupted.
+const (
+	StatusRunning     = "running"
+	StatusDone        = "done"
+	StatusFailed      = "failed"
+	StatusCancelled   = "cancelled"
+	StatusInterrupted = "interrupted"
+)
+
+// Job is a batch of prompts run against a server.
+type Job struct {
+	ID       string    `json:"id"`
+	Input    string    `json:"input"`  // prompts file as given
+	Output   string    `json:"output"` // results file
+	Server   string    `json:"server"`
+	Parallel int       `json:"parallel"`
+	Extract  string    `json:"extract,omitempty"` // --extract of ellie batch
+	JQ       string    `json:"jq,omitempty"`      // --jq of ellie batch
+	Total    int       `json:"total"`
+	PID      int       `json:"pid,omitempty"`
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Created  time.Time `json:"created"`
+	Finished time.Time `json:"finished,omitzero"`
+}
+
+// State is the job's status, with a running job whose runner is no
+// longer alive reported as interrupted.
+func (j *Job) State(alive func(pid int) bool) string {
+	if j.Status == StatusRunning && (j.PID == 0 || !alive(j.PID)) {
+		return StatusInterrupted
+	}
+	return j.Status
+}
+
+// Store is a directory of jobs.
+type Store struct {
+	Dir string
+}
+
+// ErrNotFound is returned for an unknown job ID.
+var ErrNotFound = errors.New("no such job")
+
+// Create gives j a new ID and saves it.
+func (s Store) Create(j *Job) error {
+	var b [3]byte
+	rand.Read(b[:])
+	j.ID = time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b[:])
+	if j.Created.IsZero() {
+		j.Created = time.Now().UTC()
+	}
+	if err := os.MkdirAll(s.path(j.ID), 0o700); err != nil {
+		return err
+	}
+	return s.Save(j)
+}
+
+// Save writes j's state.
+func (s Store) Save(j *Job) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(s.path(j.ID), ".job.json.tmp")
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.path(j.ID), "job.json"))
+}
+
+// Load reads the job with the given ID or unique ID prefix.
+func (s Store) Load(id string) (*Job, error) {
+	all, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var found *Job
+	for _, j := range all {
+		if j.ID == id {
+			return j, nil
+		}
+		if strings.HasPrefix(j.ID, id) {
+			if found != nil {
+				return nil, fmt.Errorf("job ID %s is ambiguous", id)
+			}
+			found = j
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return found, nil
+}
+
+// List returns every job, newest first.
+func (s Store) List() ([]*Job, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*Job
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(s.Dir, e.Name(), "job.json"))
+		if err != nil {
+			continue
+		}
+		var j Job
+		if json.Unmarshal(data, &j) == nil && j.ID == e.Name() {
+			jobs = append(jobs, &j)
+		}
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].ID > jobs[b].ID })
+	return jobs, nil
+}
+
+// InputPath is where the job keeps its copy of the prompts, so the job
+// can be resumed after the original file changes or goes away.
+func (s Store) InputPath(id string) string { return filepath.Join(s.path(id), "prompts.jsonl") }
+
+// ResultsPath is where the job's results go unless it names another file.
+func (s Store) ResultsPath(id string) string { return filepath.Join(s.path(id), "results.jsonl") }
+
+// LogPath is where the job's runner writes its output.
+func (s Store) LogPath(id string) string { return filepath.Join(s.path(id), "runner.log") }
+
+func (s Store) path(id string) string { return filepath.Join(s.Dir, id) }
+
+// Item is one prompt of a batch.
+type Item struct {
+	ID     string `json:"id"`
+	Prompt string `json:"prompt"`
+}
+
+// ReadItems reads a prompts file: one prompt per line, identified by its
+// line number, or JSON lines of {"id": ..., "prompt": ...}. Blank lines
+// are skipped.
+func ReadItems(path string) ([]Item, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []Item
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		it := Item{ID: strconv.Itoa(n), Prompt: line}
+		if strings.HasPrefix(line, "{") {
+			if err := json.Unmarshal([]byte(line), &it); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+			}
+			if it.ID == "" {
+				it.ID = strconv.Itoa(n)
+			}
+			if strings.TrimSpace(it.Prompt) == "" {
+				return nil, fmt.Errorf("%s:%d: no prompt", path, n)
+			}
+		}
+		if seen[it.ID] {
+			return nil, fmt.Errorf("%s:%d: duplicate id %q", path, n, it.ID)
+		}
+		seen[it.ID] = true
+		items = append(items, it)
+	}
+	return items, sc.Err()
+}
+
+// WriteItems writes items as JSON lines, which ReadItems reads back.
+func WriteItems(path string, items []Item) error {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	for _, it := range items {
+		if err := enc.Encode(it); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o600)
+}
+
+// Result is the outcome of one prompt.
+type Result struct {
+	ID               string  `json:"id"`
+	Prompt           string  `json:"prompt"`
+	Content          string  `json:"content,omitempty"`
+	Output           string  `json:"output,omitempty"` // the part of Content --extract or --jq selected
+	Error            string  `json:"error,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	BranchID         string  `json:"branchId,omitempty"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	Cost             float64 `json:"cost"`
+	DurationMs       int64   `json:"durationMs"`
+}
+
+// ReadResults reads a results file, skipping a last line cut short by a
+// crash. A missing file has no results. When a prompt was retried, its
+// latest result wins.
+func ReadResults(path string) (map[string]Result, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]Result{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	results := map[string]Result{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		var r Result
+		if json.Unmarshal(sc.Bytes(), &r) == nil && r.ID != "" {
+			results[r.ID] = r
+		}
+	}
+	return results, sc.Err()
+}
+
+// Pending returns the items without a successful result, in order.
+func Pending(items []Item, results map[string]Result) []Item {
+	var out []Item
+	for _, it := range items {
+		if r, ok := results[it.ID]; !ok || r.Error != "" {
+			out = append(out, it)
+		}
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	s := Store{Dir: filepath.Join(t.TempDir(), "jobs")}
+	if jobs, err := s.List(); err != nil || len(jobs) != 0 {
+		t.Fatalf("empty List = %v, %v", jobs, err)
+	}
+	j := &Job{Input: "/p.txt", Status: StatusRunning, PID: 42}
+	if err := s.Create(j); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Load(j.ID[:10])
+	if err != nil || got.Input != "/p.txt" {
+		t.Fatalf("Load by prefix = %+v, %v", got, err)
+	}
+	if got.State(func(int) bool { return false }) != StatusInterrupted {
+		t.Error("a running job with a dead runner should be interrupted")
+	}
+	if got.State(func(int) bool { return true }) != StatusRunning {
+		t.Error("a running job with a live runner should be running")
+	}
+	if _, err := s.Load("nope"); err == nil {
+		t.Error("Load of an unknown job succeeded")
+	}
+}
+
+func TestItemsAndResults(t *testing.T) {
+	dir := t.TempDir()
+	prompts := filepath.Join(dir, "prompts.txt")
+	os.WriteFile(prompts, []byte("What is 2+2?\n\n{\"id\": \"cap\", \"prompt\": \"Capital of France?\"}\nName a color\n"), 0o644)
+	items, err := ReadItems(prompts)
+	if err != nil || len(items) != 3 || items[0].ID != "1" || items[1].ID != "cap" || items[2].ID != "4" {
+		t.Fatalf("ReadItems = %+v, %v", items, err)
+	}
+
+	copied := filepath.Join(dir, "copy.jsonl")
+	if err := WriteItems(copied, items); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := ReadItems(copied); err != nil || len(again) != 3 || again[2] != items[2] {
+		t.Errorf("WriteItems round trip = %+v, %v", again, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "dup.txt"), []byte("{\"id\":\"a\",\"prompt\":\"x\"}\n{\"id\":\"a\",\"prompt\":\"y\"}\n"), 0o644)
+	if _, err := ReadItems(filepath.Join(dir, "dup.txt")); err == nil {
+		t.Error("duplicate ids accepted")
+	}
+
+	results := filepath.Join(dir, "results.jsonl")
+	os.WriteFile(results, []byte(`{"id":"1","content":"4"}
+{"id":"cap","error":"timeout"}
+{"id":"4","content":"bl`), 0o644)
+	got, err := ReadResults(results)
+	if err != nil || len(got) != 2 {
+		t.Fatalf("ReadResults = %+v, %v", got, err)
+	}
+	pending := Pending(items, got)
+	if len(pending) != 2 || pending[0].ID != "cap" || pending[1].ID != "4" {
+		t.Errorf("Pending = %+v", pending)
+	}
+}
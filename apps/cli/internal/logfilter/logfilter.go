@@ -0,0 +1,159 @@
+// Package logfilter decides which lines of ellie dev's output reach the
+// terminal — by the app that wrote them and by their log level — and
+// remembers each project's choice between runs.
+package logfilter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Levels, least severe first. A line with no recognizable level is info.
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// Filter hides lines from the terminal. The zero Filter shows everything.
+type Filter struct {
+	Mute  []string `json:"mute,omitempty"`  // apps whose lines are hidden
+	Only  []string `json:"only,omitempty"`  // when set, the only apps shown
+	Level string   `json:"level,omitempty"` // the least severe level shown
+}
+
+// Empty reports whether f shows everything.
+func (f Filter) Empty() bool {
+	return len(f.Mute) == 0 && len(f.Only) == 0 && (f.Level == "" || f.Level == "debug")
+}
+
+// Validate checks the level.
+func (f Filter) Validate() error {
+	if f.Level != "" && !slices.Contains(Levels, f.Level) {
+		return fmt.Errorf("unknown log level %q: use one of %s", f.Level, strings.Join(Levels, ", "))
+	}
+	return nil
+}
+
+// String describes f for people.
+func (f Filter) String() string {
+	var parts []string
+	if len(f.Only) > 0 {
+		parts = append(parts, "only "+strings.Join(f.Only, ", "))
+	}
+	if len(f.Mute) > 0 {
+		parts = append(parts, "muting "+strings.Join(f.Mute, ", "))
+	}
+	if f.Level != "" && f.Level != "debug" {
+		parts = append(parts, f.Level+" and above")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Allow reports whether a line app wrote should be shown. Lines that no
+// app can be told for, such as turbo's own, are only filtered by level.
+func (f Filter) Allow(app, text string) bool {
+	if app != "" {
+		if len(f.Only) > 0 && !MatchApp(f.Only, app) {
+			return false
+		}
+		if MatchApp(f.Mute, app) {
+			return false
+		}
+	}
+	if f.Level == "" {
+		return true
+	}
+	return slices.Index(Levels, LevelOf(text)) >= slices.Index(Levels, f.Level)
+}
+
+// MatchApp reports whether app is one of names, which may leave out a
+// package scope: "web" matches "@ellie/web".
+func MatchApp(names []string, app string) bool {
+	short := app[strings.LastIndex(app, "/")+1:]
+	for _, n := range names {
+		if n == app || n == short {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	structuredLevel = regexp.MustCompile(`(?i)"?level"?\s*[:=]\s*"?([a-z]+)`)
+	wordLevel       = regexp.MustCompile(`(?i)\b(fatal|panic|error|err|warn|warning|debug|trace)\b`)
+)
+
+// LevelOf guesses the log level of a line: from a level field of a
+// structured line, else from a level word in it.
+func LevelOf(text string) string {
+	word := ""
+	if m := structuredLevel.FindStringSubmatch(text); m != nil {
+		word = m[1]
+	} else if m := wordLevel.FindStringSubmatch(text); m != nil {
+		word = m[1]
+	}
+	switch strings.ToLower(word) {
+	case "fatal", "panic", "error", "err":
+		return "error"
+	case "warn", "warning":
+		return "warn"
+	case "debug", "trace":
+		return "debug"
+	}
+	return "info"
+}
+
+// Store keeps each project's filter in a JSON file, keyed by the
+// project's root directory.
+type Store struct {
+	Path string
+}
+
+func (s Store) load() (map[string]Filter, error) {
+	all := map[string]Filter{}
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("malformed %s: %w", s.Path, err)
+	}
+	return all, nil
+}
+
+// Get returns the filter saved for root, and whether there is one.
+func (s Store) Get(root string) (Filter, bool, error) {
+	all, err := s.load()
+	if err != nil {
+		return Filter{}, false, err
+	}
+	f, ok := all[root]
+	return f, ok, nil
+}
+
+// Set saves f for root; an empty filter removes root's entry.
+func (s Store) Set(root string, f Filter) error {
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	if f.Empty() {
+		delete(all, root)
+	} else {
+		all[root] = f
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,93 @@
+package logfilter
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLevelOf(t *testing.T) {
+	for text, want := range map[string]string{
+		"listening on :3000":                         "info",
+		"[WARN] slow query":                          "warn",
+		"Error: ENOENT":                              "error",
+		`{"level":"debug","msg":"tick"}`:             "debug",
+		`level=warn msg="cache miss"`:                "warn",
+		"errors: 0":                                  "info",
+		`{"level":"info","msg":"no error handlers"}`: "info",
+	} {
+		if got := LevelOf(text); got != want {
+			t.Errorf("LevelOf(%q) = %s, want %s", text, got, want)
+		}
+	}
+}
+
+func TestAllow(t *testing.T) {
+	f := Filter{Mute: []string{"docs"}, Level: "warn"}
+	cases := []struct {
+		app, text string
+		want      bool
+	}{
+		{"@ellie/docs", "ERROR build failed", false},
+		{"server", "WARN slow", true},
+		{"server", "GET /api/status 200", false},
+		{"", "• Running dev in 4 packages", false},
+		{"", "ERROR  run failed", true},
+	}
+	for _, c := range cases {
+		if got := f.Allow(c.app, c.text); got != c.want {
+			t.Errorf("Allow(%q, %q) = %v, want %v", c.app, c.text, got, c.want)
+		}
+	}
+
+	only := Filter{Only: []string{"server"}}
+	if !only.Allow("server", "x") || only.Allow("web", "x") || !only.Allow("", "x") {
+		t.Error("Only filter")
+	}
+	if err := (Filter{Level: "loud"}).Validate(); err == nil {
+		t.Error("unknown level accepted")
+	}
+}
+
+func TestMatchApp(t *testing.T) {
+	for _, c := range []struct {
+		name, app string
+		want      bool
+	}{
+		{"web", "web", true},
+		{"web", "@ellie/web", true},
+		{"@ellie/web", "@ellie/web", true},
+		{"web", "webapp", false},
+		{"ellie/web", "@ellie/web", false},
+	} {
+		if got := MatchApp([]string{c.name}, c.app); got != c.want {
+			t.Errorf("MatchApp(%q, %q) = %v, want %v", c.name, c.app, got, c.want)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := Store{Path: filepath.Join(t.TempDir(), "state", "dev-filters.json")}
+	if _, ok, err := s.Get("/repo"); ok || err != nil {
+		t.Fatalf("Get from a missing store = %v, %v", ok, err)
+	}
+	want := Filter{Mute: []string{"docs"}, Level: "warn"}
+	if err := s.Set("/repo", want); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("/other", Filter{Only: []string{"web"}}); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := s.Get("/repo")
+	if err != nil || !ok || got.String() != want.String() {
+		t.Errorf("Get = %+v, %v, %v", got, ok, err)
+	}
+	if err := s.Set("/repo", Filter{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get("/repo"); ok {
+		t.Error("an empty filter was kept")
+	}
+	if _, ok, _ := s.Get("/other"); !ok {
+		t.Error("another project's filter was lost")
+	}
+}
@@ -0,0 +1,151 @@
+// Package logrotate writes a log file that is rotated once it grows past
+// a size: app.log moves to app.log.1, app.log.1 to app.log.2 and so on,
+// and the oldest beyond the number kept, or older than the age kept, are
+// deleted.
+package logrotate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy says when a log is rotated and which rotated logs are kept.
+type Policy struct {
+	MaxSize int64         // bytes; 0 never rotates
+	Keep    int           // rotated files kept; 0 keeps none
+	MaxAge  time.Duration // rotated files older than this are deleted; 0 keeps them by count only
+}
+
+// File is an append-only log file that rotates itself. Writes are never
+// split across files, so a caller writing whole lines keeps them whole.
+type File struct {
+	mu     sync.Mutex
+	path   string
+	policy Policy
+	f      *os.File
+	size   int64
+}
+
+// Open opens path for appending, creating it and its directory if
+// needed.
+func Open(path string, policy Policy) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	l := &File{path: path, policy: policy}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *File) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f, l.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, first rotating the log if p would take it past the
+// policy's size.
+func (l *File) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.policy.MaxSize > 0 && l.size > 0 && l.size+int64(len(p)) > l.policy.MaxSize {
+		if err := l.rotate(); err != nil {
+			return 0, fmt.Errorf("rotate %s: %w", l.path, err)
+		}
+	}
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+func (l *File) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if l.policy.Keep > 0 {
+		for i := l.policy.Keep - 1; i >= 1; i-- {
+			old := l.path + "." + strconv.Itoa(i)
+			if err := os.Rename(old, l.path+"."+strconv.Itoa(i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(l.path); err != nil {
+		return err
+	}
+	Prune(l.path, l.policy)
+	return l.open()
+}
+
+func (l *File) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// Prune deletes path's rotated files that policy does not keep.
+func Prune(path string, policy Policy) {
+	for i, name := range rotated(path) {
+		info, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		if i >= policy.Keep || policy.MaxAge > 0 && time.Since(info.ModTime()) > policy.MaxAge {
+			_ = os.Remove(name)
+		}
+	}
+}
+
+// Files returns path's rotated files, oldest first, then path itself.
+// Only files that exist are listed.
+func Files(path string) []string {
+	old := rotated(path)
+	var files []string
+	for i := len(old) - 1; i >= 0; i-- {
+		files = append(files, old[i])
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files
+}
+
+// rotated returns path's rotated files, newest first.
+func rotated(path string) []string {
+	matches, _ := filepath.Glob(path + ".*")
+	type file struct {
+		name string
+		n    int
+	}
+	var files []file
+	for _, m := range matches {
+		n, err := strconv.Atoi(strings.TrimPrefix(m, path+"."))
+		if err == nil && n > 0 {
+			files = append(files, file{m, n})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].n < files[j].n })
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.name
+	}
+	return names
+}
@@ -0,0 +1,91 @@
+package logrotate
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "dev.log")
+	l, err := Open(path, Policy{MaxSize: 10, Keep: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "a very long line\n", "six\n"} {
+		if _, err := l.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := read(t, path); got != "six\n" {
+		t.Errorf("current log = %q", got)
+	}
+	if got := read(t, path+".1"); got != "a very long line\n" {
+		t.Errorf("dev.log.1 = %q; a write larger than the limit goes in a file of its own", got)
+	}
+	if got := read(t, path+".2"); got != "four\n" {
+		t.Errorf("dev.log.2 = %q", got)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("dev.log.3 was kept: %v", err)
+	}
+	want := []string{path + ".2", path + ".1", path}
+	if got := Files(path); !slices.Equal(got, want) {
+		t.Errorf("Files = %v, want %v", got, want)
+	}
+}
+
+func TestReopenKeepsSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "start.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 8)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Open(path, Policy{MaxSize: 12, Keep: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if _, err := l.Write([]byte("next\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, path); got != "next\n" {
+		t.Errorf("after reopening, current log = %q; want the earlier content rotated out", got)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.log")
+	for _, name := range []string{path, path + ".1", path + ".2", path + ".3", path + ".x"} {
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(path+".2", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	Prune(path, Policy{Keep: 3, MaxAge: 24 * time.Hour})
+	want := []string{path + ".3", path + ".1", path}
+	if got := Files(path); !slices.Equal(got, want) {
+		t.Errorf("after pruning by age, Files = %v, want %v", got, want)
+	}
+	Prune(path, Policy{Keep: 1})
+	if got := Files(path); !slices.Equal(got, []string{path + ".1", path}) {
+		t.Errorf("after pruning by count, Files = %v", got)
+	}
+}
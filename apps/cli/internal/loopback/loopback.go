@@ -0,0 +1,118 @@
+// Package loopback receives OAuth authorization redirects on a temporary
+// HTTP listener bound to the loopback interface (RFC 8252, section 7.3),
+// so the user doesn't have to copy the code out of the browser.
+package loopback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Result is the authorization response carried by the redirect.
+type Result struct {
+	Code  string
+	State string
+}
+
+// Listener serves a single redirect on 127.0.0.1.
+type Listener struct {
+	path   string
+	ln     net.Listener
+	srv    *http.Server
+	result chan callback
+	once   sync.Once
+}
+
+type callback struct {
+	res Result
+	err error
+}
+
+// Listen starts a listener on a free loopback port that accepts the
+// redirect at path.
+func Listen(path string) (*Listener, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	l := &Listener{path: path, ln: ln, result: make(chan callback, 1)}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, l.handle)
+	l.srv = &http.Server{Handler: mux}
+	go l.srv.Serve(ln)
+	return l, nil
+}
+
+// RedirectURI is the redirect URI to register with the authorization
+// request. It names the loopback address the listener is bound to rather
+// than localhost, which may resolve to ::1 or be handled by a proxy
+// (RFC 8252 §7.3).
+func (l *Listener) RedirectURI() string {
+	return fmt.Sprintf("http://127.0.0.1:%d%s", l.ln.Addr().(*net.TCPAddr).Port, l.path)
+}
+
+// Wait blocks until the browser is redirected back with the given state,
+// the authorization server reports an error, or ctx is done.
+func (l *Listener) Wait(ctx context.Context, state string) (Result, error) {
+	select {
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	case cb := <-l.result:
+		if cb.err != nil {
+			return Result{}, cb.err
+		}
+		if state != "" && cb.res.State != state {
+			return Result{}, errors.New("authorization response has the wrong state")
+		}
+		return cb.res, nil
+	}
+}
+
+// Close stops the listener.
+func (l *Listener) Close() error {
+	return l.srv.Close()
+}
+
+func (l *Listener) handle(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var cb callback
+	switch {
+	case q.Get("error") != "":
+		msg := q.Get("error")
+		if d := q.Get("error_description"); d != "" {
+			msg += ": " + d
+		}
+		cb.err = fmt.Errorf("authorization failed: %s", msg)
+	case q.Get("code") == "":
+		http.Error(w, "missing code", http.StatusBadRequest)
+		return
+	default:
+		cb.res = Result{Code: q.Get("code"), State: q.Get("state")}
+	}
+
+	delivered := false
+	l.once.Do(func() {
+		l.result <- cb
+		delivered = true
+	})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	switch {
+	case !delivered:
+		fmt.Fprint(w, page("Already signed in", "This sign-in was already completed. You can close this tab."))
+	case cb.err != nil:
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, page("Sign-in failed", "Return to the terminal for details. You can close this tab."))
+	default:
+		fmt.Fprint(w, page("Signed in", "ellie received the authorization. You can close this tab and return to the terminal."))
+	}
+}
+
+func page(title, msg string) string {
+	return `<!doctype html><html><head><meta charset="utf-8"><title>ellie — ` + title + `</title></head>` +
+		`<body style="font-family:system-ui,sans-serif;max-width:32rem;margin:4rem auto;text-align:center">` +
+		`<h1>` + title + `</h1><p>` + msg + `</p></body></html>`
+}
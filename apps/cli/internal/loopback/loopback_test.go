@@ -0,0 +1,83 @@
+package loopback
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listen(t *testing.T) *Listener {
+	t.Helper()
+	l, err := Listen("/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	if !strings.HasPrefix(l.RedirectURI(), "http://127.0.0.1:") || !strings.HasSuffix(l.RedirectURI(), "/callback") {
+		t.Fatalf("RedirectURI = %q", l.RedirectURI())
+	}
+	return l
+}
+
+// redirect simulates the browser following the redirect.
+func redirect(t *testing.T, l *Listener, query string) int {
+	t.Helper()
+	resp, err := http.Get(l.RedirectURI() + "?" + query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestWaitReceivesCode(t *testing.T) {
+	l := listen(t)
+	if code := redirect(t, l, "code=abc&state=xyz"); code != http.StatusOK {
+		t.Fatalf("redirect returned %d", code)
+	}
+	res, err := l.Wait(context.Background(), "xyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != "abc" || res.State != "xyz" {
+		t.Errorf("Wait = %+v", res)
+	}
+
+	// A second redirect is answered but not delivered.
+	if code := redirect(t, l, "code=other&state=xyz"); code != http.StatusOK {
+		t.Errorf("second redirect returned %d", code)
+	}
+}
+
+func TestWaitRejectsWrongState(t *testing.T) {
+	l := listen(t)
+	redirect(t, l, "code=abc&state=forged")
+	if _, err := l.Wait(context.Background(), "xyz"); err == nil {
+		t.Fatal("Wait should reject a mismatched state")
+	}
+}
+
+func TestWaitReportsError(t *testing.T) {
+	l := listen(t)
+	if code := redirect(t, l, "error=access_denied&error_description=user+cancelled"); code != http.StatusBadRequest {
+		t.Errorf("redirect returned %d, want 400", code)
+	}
+	_, err := l.Wait(context.Background(), "")
+	if err == nil || !strings.Contains(err.Error(), "user cancelled") {
+		t.Fatalf("Wait error = %v", err)
+	}
+}
+
+func TestMissingCodeIsIgnored(t *testing.T) {
+	l := listen(t)
+	if code := redirect(t, l, "state=xyz"); code != http.StatusBadRequest {
+		t.Errorf("redirect returned %d, want 400", code)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := l.Wait(ctx, "xyz"); err != context.DeadlineExceeded {
+		t.Fatalf("Wait error = %v, want deadline exceeded", err)
+	}
+}
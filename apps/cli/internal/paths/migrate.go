@@ -0,0 +1,110 @@
+package paths
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Move is a file or directory Migrate moved.
+type Move struct {
+	From, To string
+}
+
+// Migrate moves what older versions kept in ~/.ellie into the platform
+// directories — logs to Logs, caches and search indexes to Cache, the
+// rest to State — and, on macOS, the config file from ~/.config/ellie.
+// Nothing already at the new location is overwritten; what can't be
+// moved stays where it was. It does nothing when ELLIE_STATE_DIR is set.
+func Migrate() ([]Move, error) {
+	if os.Getenv("ELLIE_STATE_DIR") != "" {
+		return nil, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return migrate(home, resolve(runtime.GOOS, os.Getenv, home), runtime.GOOS)
+}
+
+func migrate(home string, d Dirs, goos string) ([]Move, error) {
+	var moves []Move
+	var errs []error
+	moveTo := func(from, to string) {
+		if from == to {
+			return
+		}
+		m, err := move(from, to)
+		moves = append(moves, m...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	legacy := filepath.Join(home, "."+app)
+	if entries, err := os.ReadDir(legacy); err == nil && legacy != d.State {
+		for _, e := range entries {
+			from := filepath.Join(legacy, e.Name())
+			switch e.Name() {
+			case "logs":
+				moveTo(from, d.Logs)
+			case "cache":
+				moveTo(from, d.Cache)
+			case "index":
+				moveTo(from, filepath.Join(d.Cache, "index"))
+			default:
+				moveTo(from, filepath.Join(d.State, e.Name()))
+			}
+		}
+		os.Remove(legacy) // only once it's empty
+	}
+
+	if goos == "darwin" {
+		old := filepath.Join(home, ".config", app)
+		moveTo(filepath.Join(old, "ellie.toml"), filepath.Join(d.Config, "ellie.toml"))
+		os.Remove(old)
+	}
+	return moves, errors.Join(errs...)
+}
+
+// move renames from to to. When both are directories, their contents are
+// merged, leaving entries that exist in both in from.
+func move(from, to string) ([]Move, error) {
+	src, err := os.Lstat(from)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	dst, err := os.Lstat(to)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(to), 0o700); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(from, to); err != nil {
+			return nil, err
+		}
+		return []Move{{from, to}}, nil
+	}
+	if err != nil || !src.IsDir() || !dst.IsDir() {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(from)
+	if err != nil {
+		return nil, err
+	}
+	var moves []Move
+	var errs []error
+	for _, e := range entries {
+		m, err := move(filepath.Join(from, e.Name()), filepath.Join(to, e.Name()))
+		moves = append(moves, m...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	os.Remove(from)
+	return moves, errors.Join(errs...)
+}
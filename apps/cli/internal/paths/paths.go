@@ -0,0 +1,120 @@
+// Package paths resolves where the CLI keeps its files, following each
+// platform's conventions:
+//
+//	          Linux (XDG)          macOS                           Windows
+//	config    ~/.config/ellie      ~/Library/Application Support/  %AppData%\ellie
+//	state     ~/.local/state/ellie ~/Library/Application Support/  %LocalAppData%\ellie
+//	cache     ~/.cache/ellie       ~/Library/Caches/ellie          %LocalAppData%\ellie\cache
+//	logs      <state>/logs         ~/Library/Logs/ellie            <state>\logs
+//
+// The XDG_*_HOME variables are honoured on every platform. ELLIE_STATE_DIR
+// keeps state, cache and logs together under one directory, as older
+// versions did under ~/.ellie.
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const app = "ellie"
+
+// Dirs is one resolution of the CLI's directories.
+type Dirs struct {
+	Config string
+	State  string
+	Cache  string
+	Logs   string
+}
+
+// Config is the directory of the personal config file and other files
+// the user may edit or back up.
+func Config() (string, error) {
+	d, err := current()
+	return d.Config, err
+}
+
+// State is the directory of data the CLI keeps between runs: pidfiles,
+// sessions, audit logs, drafts and jobs.
+func State() (string, error) {
+	d, err := current()
+	return d.State, err
+}
+
+// Cache is the directory of data that can be rebuilt, like model
+// capabilities and search indexes.
+func Cache() (string, error) {
+	d, err := current()
+	return d.Cache, err
+}
+
+// Logs is the directory of server and process logs.
+func Logs() (string, error) {
+	d, err := current()
+	return d.Logs, err
+}
+
+func current() (Dirs, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Dirs{}, fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return resolve(runtime.GOOS, os.Getenv, home), nil
+}
+
+// resolve works out the directories for goos from the environment and
+// home directory.
+func resolve(goos string, getenv func(string) string, home string) Dirs {
+	xdg := func(name string) string {
+		if dir := getenv(name); dir != "" && filepath.IsAbs(dir) {
+			return filepath.Join(dir, app)
+		}
+		return ""
+	}
+	or := func(dirs ...string) string {
+		for _, d := range dirs {
+			if d != "" {
+				return d
+			}
+		}
+		return ""
+	}
+
+	var d Dirs
+	switch goos {
+	case "darwin":
+		support := filepath.Join(home, "Library", "Application Support", app)
+		d = Dirs{
+			Config: or(xdg("XDG_CONFIG_HOME"), support),
+			State:  or(xdg("XDG_STATE_HOME"), support),
+			Cache:  or(xdg("XDG_CACHE_HOME"), filepath.Join(home, "Library", "Caches", app)),
+			Logs:   filepath.Join(home, "Library", "Logs", app),
+		}
+	case "windows":
+		roaming := or(getenv("APPDATA"), filepath.Join(home, "AppData", "Roaming"))
+		local := or(getenv("LOCALAPPDATA"), filepath.Join(home, "AppData", "Local"))
+		d = Dirs{
+			Config: or(xdg("XDG_CONFIG_HOME"), filepath.Join(roaming, app)),
+			State:  or(xdg("XDG_STATE_HOME"), filepath.Join(local, app)),
+			Cache:  or(xdg("XDG_CACHE_HOME"), filepath.Join(local, app, "cache")),
+		}
+	default:
+		d = Dirs{
+			Config: or(xdg("XDG_CONFIG_HOME"), filepath.Join(home, ".config", app)),
+			State:  or(xdg("XDG_STATE_HOME"), filepath.Join(home, ".local", "state", app)),
+			Cache:  or(xdg("XDG_CACHE_HOME"), filepath.Join(home, ".cache", app)),
+		}
+	}
+	if d.Logs == "" || xdg("XDG_STATE_HOME") != "" {
+		d.Logs = filepath.Join(d.State, "logs")
+	}
+
+	if dir := getenv("ELLIE_STATE_DIR"); dir != "" {
+		d.State = dir
+		d.Cache = filepath.Join(dir, "cache")
+		d.Logs = filepath.Join(dir, "logs")
+	}
+	return d
+}
@@ -0,0 +1,110 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want Dirs
+	}{
+		{"linux", "linux", nil, Dirs{
+			Config: "/home/u/.config/ellie",
+			State:  "/home/u/.local/state/ellie",
+			Cache:  "/home/u/.cache/ellie",
+			Logs:   "/home/u/.local/state/ellie/logs",
+		}},
+		{"linux xdg", "linux", map[string]string{"XDG_CONFIG_HOME": "/x/c", "XDG_STATE_HOME": "/x/s", "XDG_CACHE_HOME": "relative"}, Dirs{
+			Config: "/x/c/ellie",
+			State:  "/x/s/ellie",
+			Cache:  "/home/u/.cache/ellie",
+			Logs:   "/x/s/ellie/logs",
+		}},
+		{"darwin", "darwin", nil, Dirs{
+			Config: "/home/u/Library/Application Support/ellie",
+			State:  "/home/u/Library/Application Support/ellie",
+			Cache:  "/home/u/Library/Caches/ellie",
+			Logs:   "/home/u/Library/Logs/ellie",
+		}},
+		{"windows", "windows", map[string]string{"APPDATA": "/r", "LOCALAPPDATA": "/l"}, Dirs{
+			Config: "/r/ellie",
+			State:  "/l/ellie",
+			Cache:  "/l/ellie/cache",
+			Logs:   "/l/ellie/logs",
+		}},
+		{"state dir override", "darwin", map[string]string{"ELLIE_STATE_DIR": "/tmp/st"}, Dirs{
+			Config: "/home/u/Library/Application Support/ellie",
+			State:  "/tmp/st",
+			Cache:  "/tmp/st/cache",
+			Logs:   "/tmp/st/logs",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolve(tt.goos, env(tt.env), "/home/u")
+			for _, p := range []*string{&got.Config, &got.State, &got.Cache, &got.Logs} {
+				*p = filepath.ToSlash(*p)
+			}
+			if got != tt.want {
+				t.Errorf("resolve = %+v\nwant      %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	home := t.TempDir()
+	write := func(rel, data string) {
+		p := filepath.Join(home, rel)
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		os.WriteFile(p, []byte(data), 0o644)
+	}
+	write(".ellie/logs/dev.log", "old log")
+	write(".ellie/run/dev.pid", "42")
+	write(".ellie/index/abc.gob", "idx")
+	write(".ellie/sessions.json", "old sessions")
+	write(".config/ellie/ellie.toml", "ui.theme = \"dark\"")
+	// Already migrated by hand; must not be overwritten.
+	write("state/sessions.json", "new sessions")
+
+	d := Dirs{
+		Config: filepath.Join(home, "config"),
+		State:  filepath.Join(home, "state"),
+		Cache:  filepath.Join(home, "cache"),
+		Logs:   filepath.Join(home, "logs"),
+	}
+	moves, err := migrate(home, d, "darwin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 4 {
+		t.Errorf("moves = %+v", moves)
+	}
+	for rel, want := range map[string]string{
+		"logs/dev.log":         "old log",
+		"state/run/dev.pid":    "42",
+		"cache/index/abc.gob":  "idx",
+		"state/sessions.json":  "new sessions",
+		"config/ellie.toml":    "ui.theme = \"dark\"",
+		".ellie/sessions.json": "old sessions",
+	} {
+		if data, err := os.ReadFile(filepath.Join(home, rel)); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", rel, data, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config", "ellie")); err == nil {
+		t.Error("empty ~/.config/ellie left behind")
+	}
+
+	if moves, err := migrate(home, d, "darwin"); err != nil || len(moves) != 0 {
+		t.Errorf("second migrate = %+v, %v", moves, err)
+	}
+}
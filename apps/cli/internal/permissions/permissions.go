@@ -0,0 +1,358 @@
+// Package permissions is the policy deciding what the assistant's tools
+// may touch: which files they may read or write, which commands they may
+// run and which domains they may fetch from. A policy is a list of allow
+// and deny rules and a default for what no rule matches; a deny rule
+// always wins over an allow rule.
+package permissions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of rule.
+const (
+	KindPath    = "path"
+	KindCommand = "command"
+	KindDomain  = "domain"
+)
+
+// Kinds lists the kinds of rule.
+var Kinds = []string{KindPath, KindCommand, KindDomain}
+
+// Effects of a rule.
+const (
+	Allow = "allow"
+	Deny  = "deny"
+)
+
+// Access a path rule applies to. An empty Access covers both.
+const (
+	Read  = "read"
+	Write = "write"
+)
+
+// Actions are what a tool asks to do, and the kind of rule each is
+// decided by.
+var Actions = map[string]string{
+	"read":  KindPath,
+	"write": KindPath,
+	"exec":  KindCommand,
+	"fetch": KindDomain,
+}
+
+// Rule allows or denies the targets its pattern matches.
+//
+// A path pattern is an absolute path or one starting with ~/, where *
+// matches within one path element and ** any number of them: /etc/**
+// covers everything under /etc. A command pattern is matched word by
+// word against the start of a command line, the program by its base
+// name: "git push" covers git push --force, "rm -rf *" any rm -rf. A
+// domain pattern is a host name, or *.host for any host under it.
+type Rule struct {
+	Kind    string `json:"kind"`
+	Effect  string `json:"effect"`
+	Pattern string `json:"pattern"`
+	Access  string `json:"access,omitempty"`
+}
+
+func (r Rule) String() string {
+	s := r.Effect + " " + r.Kind + " " + r.Pattern
+	if r.Access != "" {
+		s += " (" + r.Access + ")"
+	}
+	return s
+}
+
+// Policy is a profile's rules. Default decides what no rule matches; an
+// empty Default denies.
+type Policy struct {
+	Default string `json:"default,omitempty"`
+	Rules   []Rule `json:"rules"`
+}
+
+var hostRe = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// Validate reports what is wrong with r.
+func (r Rule) Validate() error {
+	if r.Effect != Allow && r.Effect != Deny {
+		return fmt.Errorf("effect %q is not allow or deny", r.Effect)
+	}
+	if strings.TrimSpace(r.Pattern) == "" {
+		return errors.New("empty pattern")
+	}
+	if r.Access != "" && r.Kind != KindPath {
+		return fmt.Errorf("access applies only to path rules, not %s", r.Kind)
+	}
+	switch r.Kind {
+	case KindPath:
+		if r.Access != "" && r.Access != Read && r.Access != Write {
+			return fmt.Errorf("access %q is not read or write", r.Access)
+		}
+		if !strings.HasPrefix(r.Pattern, "/") && !strings.HasPrefix(r.Pattern, "~/") && r.Pattern != "~" {
+			return fmt.Errorf("path %q is not absolute or under ~/", r.Pattern)
+		}
+		for _, elem := range strings.Split(r.Pattern, "/") {
+			if _, err := path.Match(elem, ""); err != nil {
+				return fmt.Errorf("bad pattern %q: %w", r.Pattern, err)
+			}
+		}
+	case KindCommand:
+		for _, word := range strings.Fields(r.Pattern) {
+			if _, err := path.Match(word, ""); err != nil {
+				return fmt.Errorf("bad pattern %q: %w", r.Pattern, err)
+			}
+		}
+	case KindDomain:
+		if !hostRe.MatchString(r.Pattern) {
+			return fmt.Errorf("%q is not a host name or *.host", r.Pattern)
+		}
+	default:
+		return fmt.Errorf("kind %q is not one of %s", r.Kind, strings.Join(Kinds, ", "))
+	}
+	return nil
+}
+
+// Validate reports every problem with p, each naming its rule.
+func (p *Policy) Validate() error {
+	var errs []error
+	if p.Default != "" && p.Default != Allow && p.Default != Deny {
+		errs = append(errs, fmt.Errorf("default %q is not allow or deny", p.Default))
+	}
+	for i, r := range p.Rules {
+		if err := r.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d (%s): %w", i+1, r, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Add appends r unless p already has it, and reports whether it did.
+func (p *Policy) Add(r Rule) bool {
+	for _, have := range p.Rules {
+		if have == r {
+			return false
+		}
+	}
+	p.Rules = append(p.Rules, r)
+	return true
+}
+
+// Remove deletes the rules of kind with pattern, of either effect and
+// any access, and returns how many it deleted.
+func (p *Policy) Remove(kind, pattern string) int {
+	kept := p.Rules[:0]
+	for _, r := range p.Rules {
+		if r.Kind != kind || r.Pattern != pattern {
+			kept = append(kept, r)
+		}
+	}
+	n := len(p.Rules) - len(kept)
+	p.Rules = kept
+	return n
+}
+
+// Decision is the outcome of Check.
+type Decision struct {
+	Allowed bool  `json:"allowed"`
+	Rule    *Rule `json:"rule,omitempty"` // nil when the default decided
+}
+
+// Check decides whether action — read, write, exec or fetch — may be
+// done to target: a file path, a command line, or a host name or URL.
+// home expands ~ in path patterns and targets.
+func (p *Policy) Check(action, target, home string) (Decision, error) {
+	kind, ok := Actions[action]
+	if !ok {
+		return Decision{}, fmt.Errorf("unknown action %q (use read, write, exec or fetch)", action)
+	}
+	switch kind {
+	case KindPath:
+		target = expandHome(target, home)
+		if !filepath.IsAbs(target) {
+			return Decision{}, fmt.Errorf("path %q is not absolute", target)
+		}
+		target = filepath.ToSlash(filepath.Clean(target))
+	case KindDomain:
+		target = hostOf(target)
+		if target == "" {
+			return Decision{}, errors.New("no host name to check")
+		}
+	}
+
+	var allow *Rule
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Kind != kind || r.Validate() != nil || !r.matches(action, target, home) {
+			continue
+		}
+		if r.Effect == Deny {
+			return Decision{Allowed: false, Rule: r}, nil
+		}
+		if allow == nil {
+			allow = r
+		}
+	}
+	if allow != nil {
+		return Decision{Allowed: true, Rule: allow}, nil
+	}
+	return Decision{Allowed: p.Default == Allow}, nil
+}
+
+func (r *Rule) matches(action, target, home string) bool {
+	switch r.Kind {
+	case KindPath:
+		if r.Access != "" && r.Access != action {
+			return false
+		}
+		return matchPath(strings.Split(filepath.ToSlash(expandHome(r.Pattern, home)), "/"), strings.Split(target, "/"))
+	case KindCommand:
+		words, cmd := strings.Fields(r.Pattern), strings.Fields(target)
+		if len(cmd) < len(words) {
+			return false
+		}
+		if len(cmd) > 0 {
+			cmd[0] = path.Base(filepath.ToSlash(cmd[0]))
+		}
+		for i, w := range words {
+			// * in a command matches slashes too, as in rm -rf *.
+			if ok, _ := path.Match(strings.ReplaceAll(w, "/", "\x00"), strings.ReplaceAll(cmd[i], "/", "\x00")); !ok {
+				return false
+			}
+		}
+		return true
+	case KindDomain:
+		if rest, ok := strings.CutPrefix(r.Pattern, "*."); ok {
+			return strings.HasSuffix(target, "."+rest)
+		}
+		return target == r.Pattern
+	}
+	return false
+}
+
+// matchPath matches path elements against pattern elements, where "**"
+// matches any number of elements.
+func matchPath(pattern, elems []string) bool {
+	if len(pattern) == 0 {
+		return len(elems) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(elems); i++ {
+			if matchPath(pattern[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elems) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+		return false
+	}
+	return matchPath(pattern[1:], elems[1:])
+}
+
+func expandHome(p, home string) string {
+	if home == "" {
+		return p
+	}
+	if p == "~" {
+		return home
+	}
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		return filepath.Join(home, rest)
+	}
+	return p
+}
+
+// hostOf returns the lowercased host of a URL or host name.
+func hostOf(target string) string {
+	if strings.Contains(target, "://") {
+		if u, err := url.Parse(target); err == nil {
+			return strings.ToLower(u.Hostname())
+		}
+		return ""
+	}
+	host, _, _ := strings.Cut(target, "/")
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// ── profiles ────────────────────────────────────────────────────────────────
+
+var profileRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidProfile reports whether name can be used for a profile.
+func ValidProfile(name string) error {
+	if !profileRe.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use letters, digits, -, _ and .)", name)
+	}
+	return nil
+}
+
+// File returns where profile's policy is kept in dir.
+func File(dir, profile string) string {
+	return filepath.Join(dir, profile+".json")
+}
+
+// Profiles returns the names of the profiles with a policy in dir.
+func Profiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() && ValidProfile(name) == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load reads the policy at path. A missing file is an empty policy,
+// which denies everything.
+func Load(path string) (*Policy, error) {
+	p := &Policy{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Save writes p to path, creating its directory if needed.
+func (p *Policy) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if p.Rules == nil {
+		p.Rules = []Rule{}
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
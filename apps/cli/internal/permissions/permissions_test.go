@@ -0,0 +1,132 @@
+package permissions
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	p := &Policy{Rules: []Rule{
+		{Kind: KindPath, Effect: Allow, Pattern: "~/projects/**"},
+		{Kind: KindPath, Effect: Deny, Pattern: "~/projects/**/.env"},
+		{Kind: KindPath, Effect: Allow, Pattern: "/etc/*", Access: Read},
+		{Kind: KindPath, Effect: Deny, Pattern: "/etc/shadow"},
+		{Kind: KindCommand, Effect: Allow, Pattern: "git"},
+		{Kind: KindCommand, Effect: Deny, Pattern: "git push"},
+		{Kind: KindCommand, Effect: Deny, Pattern: "rm -rf *"},
+		{Kind: KindCommand, Effect: Allow, Pattern: "rm"},
+		{Kind: KindDomain, Effect: Allow, Pattern: "*.github.com"},
+		{Kind: KindDomain, Effect: Allow, Pattern: "example.com"},
+	}}
+	for _, c := range []struct {
+		action, target string
+		allowed        bool
+		rule           string
+	}{
+		{"read", "/home/me/projects/app/main.go", true, "allow path ~/projects/**"},
+		{"write", "~/projects/app/main.go", true, "allow path ~/projects/**"},
+		{"read", "/home/me/projects/app/.env", false, "deny path ~/projects/**/.env"},
+		{"read", "/home/me/notes.txt", false, ""},
+		{"read", "/etc/passwd", true, "allow path /etc/* (read)"},
+		{"write", "/etc/passwd", false, ""},
+		{"read", "/etc/shadow", false, "deny path /etc/shadow"},
+		{"read", "/etc/ssh/sshd_config", false, ""},
+		{"exec", "/usr/bin/git status", true, "allow command git"},
+		{"exec", "git push --force", false, "deny command git push"},
+		{"exec", "rm -rf /", false, "deny command rm -rf *"},
+		{"exec", "rm notes.txt", true, "allow command rm"},
+		{"exec", "curl example.com", false, ""},
+		{"fetch", "https://api.github.com/repos", true, "allow domain *.github.com"},
+		{"fetch", "github.com", false, ""},
+		{"fetch", "Example.com:8080/path", true, "allow domain example.com"},
+		{"fetch", "www.example.com", false, ""},
+	} {
+		d, err := p.Check(c.action, c.target, "/home/me")
+		if err != nil {
+			t.Errorf("Check(%s, %s): %v", c.action, c.target, err)
+			continue
+		}
+		rule := ""
+		if d.Rule != nil {
+			rule = d.Rule.String()
+		}
+		if d.Allowed != c.allowed || rule != c.rule {
+			t.Errorf("Check(%s, %s) = %v by %q, want %v by %q", c.action, c.target, d.Allowed, rule, c.allowed, c.rule)
+		}
+	}
+
+	p.Default = Allow
+	if d, _ := p.Check("read", "/home/me/notes.txt", "/home/me"); !d.Allowed || d.Rule != nil {
+		t.Errorf("with default allow, an unmatched path = %+v", d)
+	}
+	if _, err := p.Check("delete", "/tmp/x", "/home/me"); err == nil {
+		t.Error("unknown action was accepted")
+	}
+	if _, err := p.Check("read", "relative/path", "/home/me"); err == nil {
+		t.Error("relative path was accepted")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	p := &Policy{Default: "maybe", Rules: []Rule{
+		{Kind: KindPath, Effect: Allow, Pattern: "/tmp/**"},
+		{Kind: KindPath, Effect: Allow, Pattern: "relative/**"},
+		{Kind: KindPath, Effect: Allow, Pattern: "/tmp/[", Access: Read},
+		{Kind: KindPath, Effect: Deny, Pattern: "/tmp", Access: "delete"},
+		{Kind: KindCommand, Effect: Allow, Pattern: "git", Access: Read},
+		{Kind: KindDomain, Effect: Allow, Pattern: "https://example.com"},
+		{Kind: KindDomain, Effect: "permit", Pattern: "example.com"},
+		{Kind: "network", Effect: Allow, Pattern: "x"},
+	}}
+	err := p.Validate()
+	if err == nil {
+		t.Fatal("invalid policy passed")
+	}
+	msgs := strings.Split(err.Error(), "\n")
+	if len(msgs) != 8 {
+		t.Errorf("got %d problems, want 8:\n%v", len(msgs), err)
+	}
+	if !strings.HasPrefix(msgs[1], "rule 2 ") {
+		t.Errorf("second problem = %q, want it to name rule 2", msgs[1])
+	}
+	if err := (&Policy{Default: Deny, Rules: p.Rules[:1]}).Validate(); err != nil {
+		t.Errorf("valid policy: %v", err)
+	}
+}
+
+func TestAddRemove(t *testing.T) {
+	p := &Policy{}
+	r := Rule{Kind: KindDomain, Effect: Allow, Pattern: "example.com"}
+	if !p.Add(r) || p.Add(r) {
+		t.Error("Add should add a rule once")
+	}
+	p.Add(Rule{Kind: KindDomain, Effect: Deny, Pattern: "example.com"})
+	p.Add(Rule{Kind: KindCommand, Effect: Allow, Pattern: "example.com"})
+	if n := p.Remove(KindDomain, "example.com"); n != 2 || len(p.Rules) != 1 {
+		t.Errorf("Remove deleted %d, left %v", n, p.Rules)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "permissions")
+	if p, err := Load(File(dir, "work")); err != nil || len(p.Rules) != 0 {
+		t.Fatalf("missing policy = %+v, %v", p, err)
+	}
+	p := &Policy{Default: Deny}
+	p.Add(Rule{Kind: KindPath, Effect: Allow, Pattern: "~/src/**", Access: Read})
+	if err := p.Save(File(dir, "work")); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Policy{}).Save(File(dir, "default")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(File(dir, "work"))
+	if err != nil || got.Default != Deny || !slices.Equal(got.Rules, p.Rules) {
+		t.Errorf("Load = %+v, %v", got, err)
+	}
+	if names, _ := Profiles(dir); !slices.Equal(names, []string{"default", "work"}) {
+		t.Errorf("Profiles = %v", names)
+	}
+}
@@ -0,0 +1,32 @@
+package proc
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+func TestAlive(t *testing.T) {
+	if !Alive(os.Getpid()) {
+		t.Error("Alive(own pid) = false")
+	}
+
+	name, args := "sleep", []string{"30"}
+	if runtime.GOOS == "windows" {
+		name, args = "ping", []string{"-n", "30", "127.0.0.1"}
+	}
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start %s: %v", name, err)
+	}
+	pid := cmd.Process.Pid
+	if !Alive(pid) {
+		t.Errorf("Alive(running child %d) = false", pid)
+	}
+	cmd.Process.Kill()
+	cmd.Wait()
+	if Alive(pid) {
+		t.Errorf("Alive(exited child %d) = true", pid)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package proc
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Alive reports whether a process with pid exists.
+func Alive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	// EPERM means the process exists but belongs to someone else.
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
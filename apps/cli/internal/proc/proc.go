@@ -0,0 +1,3 @@
+// Package proc tells whether a process is still running, which the CLI
+// needs on every platform to clean up pidfiles and stop its servers.
+package proc
@@ -0,0 +1,187 @@
+// Package provision reads the manifests ellie auth provision applies:
+// a YAML file with a default target and a list of credential entries.
+//
+// Only the part of YAML such a manifest needs is understood — top-level
+// scalars, and an "entries" list of flat maps of scalars, plain or
+// quoted. Anything else, such as nested maps, flow collections or block
+// scalars, is an error rather than read wrongly.
+package provision
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Manifest is a parsed manifest.
+type Manifest struct {
+	// Top holds the top-level scalars, such as the default "target".
+	Top map[string]string
+	// Entries are the maps of the "entries" list, in order.
+	Entries []map[string]string
+}
+
+// Parse reads a manifest. Errors name the line they are on.
+func Parse(r io.Reader) (*Manifest, error) {
+	m := &Manifest{Top: map[string]string{}}
+	inEntries := false
+	// itemIndent is the column the keys of the current entry start at.
+	itemIndent := -1
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("line %d: %s", lineNo, fmt.Sprintf(format, args...))
+		}
+		raw, err := stripComment(scanner.Text())
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		line := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(line, "\t") {
+			return nil, fail("indent with spaces, not tabs")
+		}
+		indent := len(raw) - len(line)
+		line = strings.TrimSpace(line)
+
+		if indent == 0 {
+			key, value, err := splitPair(line)
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			if key == "entries" {
+				if value != "" {
+					return nil, fail("entries must be a list of entries")
+				}
+				inEntries, itemIndent = true, -1
+				continue
+			}
+			inEntries = false
+			if _, dup := m.Top[key]; dup {
+				return nil, fail("%s is set twice", key)
+			}
+			m.Top[key] = value
+			continue
+		}
+
+		if !inEntries {
+			return nil, fail("unexpected indentation")
+		}
+		if line == "-" || strings.HasPrefix(line, "- ") {
+			m.Entries = append(m.Entries, map[string]string{})
+			rest := strings.TrimLeft(line[1:], " ")
+			itemIndent = indent + len(line) - len(rest)
+			if rest == "" {
+				itemIndent = -1
+				continue
+			}
+			line = rest
+		} else if len(m.Entries) == 0 {
+			return nil, fail("expected a list item (- key: value)")
+		} else if itemIndent < 0 {
+			// The first key of an item whose "-" stood alone.
+			itemIndent = indent
+		} else if indent > itemIndent {
+			return nil, fail("nested values are not supported")
+		} else if indent < itemIndent {
+			return nil, fail("unexpected indentation")
+		}
+		key, value, err := splitPair(line)
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+		item := m.Entries[len(m.Entries)-1]
+		if _, dup := item[key]; dup {
+			return nil, fail("%s is set twice in entry %d", key, len(m.Entries))
+		}
+		item[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// splitPair splits "key: value", unquoting value.
+func splitPair(line string) (key, value string, err error) {
+	if strings.HasPrefix(line, "- ") || line == "-" {
+		return "", "", fmt.Errorf("nested lists are not supported")
+	}
+	// As in YAML, the colon after a key is followed by a space or ends
+	// the line, so values like ssh://host keep theirs.
+	key, value, ok := strings.Cut(line, ": ")
+	if !ok && strings.HasSuffix(line, ":") {
+		key, ok = strings.TrimSuffix(line, ":"), true
+	}
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("expected key: value")
+	}
+	if strings.ContainsAny(key[:1], `"'`) {
+		return "", "", fmt.Errorf("quoted keys are not supported")
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return key, "", nil
+	}
+	switch value[0] {
+	case '"':
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid quoted value %s", value)
+		}
+		return key, s, nil
+	case '\'':
+		if len(value) < 2 || value[len(value)-1] != '\'' {
+			return "", "", fmt.Errorf("invalid quoted value %s", value)
+		}
+		inner := value[1 : len(value)-1]
+		if strings.Contains(strings.ReplaceAll(inner, "''", ""), "'") {
+			return "", "", fmt.Errorf("invalid quoted value %s", value)
+		}
+		return key, strings.ReplaceAll(inner, "''", "'"), nil
+	case '[', '{':
+		return "", "", fmt.Errorf("%s: flow collections are not supported", key)
+	case '|', '>':
+		return "", "", fmt.Errorf("%s: block scalars are not supported; quote the value", key)
+	case '&', '*', '!':
+		return "", "", fmt.Errorf("%s: anchors, aliases and tags are not supported", key)
+	}
+	return key, value, nil
+}
+
+// stripComment removes a "# comment" that isn't inside quotes. A quote
+// that is never closed is an error.
+func stripComment(line string) (string, error) {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			// Only a quote that starts a value opens a string, as in
+			// key: "value"; one inside a plain value is literal.
+			if i == 0 || line[i-1] == ' ' {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i], nil
+		}
+	}
+	if quote != 0 {
+		return "", fmt.Errorf("unterminated %c quote", quote)
+	}
+	return line, nil
+}
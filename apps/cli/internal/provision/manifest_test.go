@@ -0,0 +1,66 @@
+package provision
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `# lab credentials
+target: ssh://admin@lab-01   # default for entries without one
+entries:
+  - user: alice
+    target: "ssh://alice@lab-02?port=3000"
+    provider: anthropic
+    key_env: ALICE_ANTHROPIC_KEY
+  -
+    user: 'bob''s laptop'
+    provider: groq
+    key: "gsk_#not-a-comment\t"
+    validate: false
+  - provider: brave
+    key: abc#def
+`
+	m, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Top["target"] != "ssh://admin@lab-01" {
+		t.Errorf("target = %q", m.Top["target"])
+	}
+	want := []map[string]string{
+		{"user": "alice", "target": "ssh://alice@lab-02?port=3000", "provider": "anthropic", "key_env": "ALICE_ANTHROPIC_KEY"},
+		{"user": "bob's laptop", "provider": "groq", "key": "gsk_#not-a-comment\t", "validate": "false"},
+		{"provider": "brave", "key": "abc#def"},
+	}
+	if !reflect.DeepEqual(m.Entries, want) {
+		t.Errorf("entries =\n%#v\nwant\n%#v", m.Entries, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for src, want := range map[string]string{
+		"target ssh://lab":        "line 1: expected key: value",
+		"target: a\ntarget: b":    "line 2: target is set twice",
+		"  target: a":             "line 1: unexpected indentation",
+		"entries:\n  user: alice": "line 2: expected a list item",
+		"entries:\n  - user: alice\n    keys:\n      a: b": "line 4: nested values are not supported",
+		"entries:\n  - user: alice\n   provider: groq":     "line 3: unexpected indentation",
+		"entries:\n  - - user: alice":                      "line 2: nested lists are not supported",
+		"entries:\n  - user: alice\n    user: bob":         "line 3: user is set twice in entry 1",
+		"entries: none":                                      "line 1: entries must be a list",
+		"entries:\n  - key: {a: 1}":                          "line 2: key: flow collections are not supported",
+		"entries:\n  - key: |":                               "line 2: key: block scalars are not supported",
+		"entries:\n  - key: *secret":                         "line 2: key: anchors, aliases and tags are not supported",
+		"entries:\n  - key: \"open":                          "line 2: unterminated \" quote",
+		"entries:\n  - key: 'a'b'":                           "line 2: invalid quoted value",
+		"entries:\n\t- key: a":                               "line 2: indent with spaces, not tabs",
+		"entries:\n  - user: alice\nother: 1\n  - user: bob": "line 4: unexpected indentation",
+	} {
+		_, err := Parse(strings.NewReader(src))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) error = %v, want %q", src, err, want)
+		}
+	}
+}
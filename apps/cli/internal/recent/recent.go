@@ -0,0 +1,65 @@
+// Package recent remembers each project's most recently used items, such
+// as the scripts run with ellie scripts, in a JSON file keyed by the
+// project's root directory.
+package recent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Store keeps up to Max items per project, most recent first.
+type Store struct {
+	Path string
+	Max  int
+}
+
+func (s Store) load() (map[string][]string, error) {
+	all := map[string][]string{}
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("malformed %s: %w", s.Path, err)
+	}
+	return all, nil
+}
+
+// List returns root's items, most recent first.
+func (s Store) List(root string) ([]string, error) {
+	all, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return all[root], nil
+}
+
+// Add records item as root's most recent.
+func (s Store) Add(root, item string) error {
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	items := slices.DeleteFunc(all[root], func(v string) bool { return v == item })
+	items = append([]string{item}, items...)
+	if s.Max > 0 && len(items) > s.Max {
+		items = items[:s.Max]
+	}
+	all[root] = items
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path, append(data, '\n'), 0o644)
+}
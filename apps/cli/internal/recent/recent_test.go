@@ -0,0 +1,30 @@
+package recent
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	s := Store{Path: filepath.Join(t.TempDir(), "state", "recent.json"), Max: 3}
+	if items, err := s.List("/repo"); len(items) != 0 || err != nil {
+		t.Fatalf("List from a missing store = %v, %v", items, err)
+	}
+	for _, item := range []string{"web:build", "server:test", "web:dev", "server:test", "db:db:generate"} {
+		if err := s.Add("/repo", item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Add("/other", "web:lint"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.List("/repo")
+	if want := []string{"db:db:generate", "server:test", "web:dev"}; err != nil || !slices.Equal(got, want) {
+		t.Errorf("List = %v, %v; want %v", got, err, want)
+	}
+	if got, _ := s.List("/other"); !slices.Equal(got, []string{"web:lint"}) {
+		t.Errorf("another project's items = %v", got)
+	}
+}
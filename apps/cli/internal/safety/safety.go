@@ -0,0 +1,217 @@
+// Package safety checks text about to be sent to a model provider for
+// secrets — API keys, tokens, passwords, private keys — and obvious
+// personal data — email addresses, card, social security and phone
+// numbers — and applies a policy to what it finds: block the text,
+// redact the findings from it, warn, or let it through.
+package safety
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"ellie/apps/cli/internal/redact"
+)
+
+// Category groups findings for the policy.
+type Category string
+
+const (
+	Secret Category = "secret"
+	PII    Category = "pii"
+)
+
+// Finding is a secret or piece of personal data in a text.
+type Finding struct {
+	Category Category `json:"category"`
+	// Kind names what was found, e.g. "api-key", "private-key", "email".
+	Kind   string `json:"kind"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// Preview shows enough of the finding to locate it, never all of it.
+	Preview string `json:"preview"`
+
+	start, end int
+}
+
+var (
+	privateKeyRe = regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----(?s:.*?)(?:-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----|\z)`)
+	// Card numbers start with 2 to 6; that leaves out millisecond
+	// timestamps and most other long numbers.
+	cardRe  = regexp.MustCompile(`\b[2-6](?:[ -]?\d){12,18}\b`)
+	ssnRe   = regexp.MustCompile(`\b(\d{3})-(\d{2})-(\d{4})\b`)
+	phoneRe = regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)|\b\d{3})[ .-]\d{3}[ .-]\d{4}\b`)
+)
+
+// Scan returns what it finds in text, in order. Of overlapping findings,
+// the first to start is kept.
+func Scan(text string) []Finding {
+	var found []Finding
+	add := func(c Category, kind string, start, end int) {
+		found = append(found, Finding{Category: c, Kind: kind, start: start, end: end})
+	}
+	for _, m := range privateKeyRe.FindAllStringIndex(text, -1) {
+		add(Secret, "private-key", m[0], m[1])
+	}
+	for _, m := range redact.Find(text) {
+		if m.Kind == "auth-header" && !credentialLike(text[m.Start:m.End]) {
+			continue
+		}
+		c := Secret
+		if m.Kind == "email" {
+			c = PII
+		}
+		add(c, m.Kind, m.Start, m.End)
+	}
+	for _, m := range cardRe.FindAllStringIndex(text, -1) {
+		if luhn(text[m[0]:m[1]]) {
+			add(PII, "card-number", m[0], m[1])
+		}
+	}
+	for _, m := range ssnRe.FindAllStringSubmatchIndex(text, -1) {
+		area, group, serial := text[m[2]:m[3]], text[m[4]:m[5]], text[m[6]:m[7]]
+		if area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000" {
+			add(PII, "us-ssn", m[0], m[1])
+		}
+	}
+	for _, m := range phoneRe.FindAllStringIndex(text, -1) {
+		add(PII, "phone", m[0], m[1])
+	}
+
+	slices.SortStableFunc(found, func(a, b Finding) int { return a.start - b.start })
+	var out []Finding
+	for _, f := range found {
+		if len(out) > 0 && f.start < out[len(out)-1].end {
+			continue
+		}
+		f.Line, f.Column = position(text, f.start)
+		f.Preview = preview(text[f.start:f.end])
+		out = append(out, f)
+	}
+	return out
+}
+
+// credentialLike reports whether the word after Bearer, Basic or Token
+// looks like a credential rather than prose, as in "the token refreshed":
+// it has a digit, or is long and of mixed case.
+func credentialLike(s string) bool {
+	if strings.ContainsAny(s, "0123456789") {
+		return true
+	}
+	return len(s) >= 20 && strings.ToLower(s) != s && strings.ToUpper(s) != s
+}
+
+// luhn reports whether the digits in s pass the Luhn check card numbers
+// carry.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// position returns the 1-based line and column, in characters, of
+// offset in text.
+func position(text string, offset int) (line, col int) {
+	before := text[:offset]
+	line = strings.Count(before, "\n") + 1
+	return line, utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+}
+
+// preview shows the first characters of a finding and its length.
+func preview(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	n := utf8.RuneCountInString(s)
+	if n <= 8 {
+		return fmt.Sprintf("… (%d chars)", n)
+	}
+	return fmt.Sprintf("%s… (%d chars)", string([]rune(s)[:4]), n)
+}
+
+// Action is what a policy does with the findings of a category.
+type Action string
+
+const (
+	// Block stops the text from being sent.
+	Block Action = "block"
+	// Redact sends the text with the findings masked.
+	Redact Action = "redact"
+	// Warn sends the text as it is and says what was found.
+	Warn Action = "warn"
+	// Off doesn't look for the category.
+	Off Action = "off"
+)
+
+// Actions lists the actions, for settings and errors.
+var Actions = []string{string(Block), string(Redact), string(Warn), string(Off)}
+
+// Policy says what to do with the findings of each category.
+type Policy map[Category]Action
+
+// Result is a text checked against a policy.
+type Result struct {
+	// Text is the text to send: the one checked, with the findings the
+	// policy redacts masked.
+	Text string
+	// Blocked, Redacted and Warned are the findings by what the policy
+	// does with them.
+	Blocked, Redacted, Warned []Finding
+}
+
+// Check scans text and applies p to what it finds.
+func (p Policy) Check(text string) Result {
+	r := Result{Text: text}
+	var masked strings.Builder
+	last := 0
+	for _, f := range Scan(text) {
+		switch p[f.Category] {
+		case Block:
+			r.Blocked = append(r.Blocked, f)
+		case Redact:
+			r.Redacted = append(r.Redacted, f)
+			masked.WriteString(text[last:f.start])
+			masked.WriteString("[REDACTED " + f.Kind + "]")
+			last = f.end
+		case Warn:
+			r.Warned = append(r.Warned, f)
+		}
+	}
+	if len(r.Redacted) > 0 {
+		masked.WriteString(text[last:])
+		r.Text = masked.String()
+	}
+	return r
+}
+
+// Summary describes findings by kind, e.g. "2 api-key, 1 email".
+func Summary(findings []Finding) string {
+	counts := map[string]int{}
+	var kinds []string
+	for _, f := range findings {
+		if counts[f.Kind] == 0 {
+			kinds = append(kinds, f.Kind)
+		}
+		counts[f.Kind]++
+	}
+	parts := make([]string, len(kinds))
+	for i, k := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", counts[k], k)
+	}
+	return strings.Join(parts, ", ")
+}
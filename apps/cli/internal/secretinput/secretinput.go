@@ -0,0 +1,128 @@
+// Package secretinput reads a secret from a terminal in raw mode, masking
+// what is typed. It is the fallback for terminals where full-screen
+// password fields misbehave (mosh, mintty and some Windows consoles), so
+// it only relies on byte-at-a-time reads and plain backspaces.
+package secretinput
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupted is returned when the user presses Ctrl+C.
+var ErrInterrupted = errors.New("interrupted")
+
+const (
+	ctrlC     = 0x03
+	ctrlD     = 0x04
+	backspace = 0x08
+	ctrlU     = 0x15
+	esc       = 0x1b
+	del       = 0x7f
+)
+
+// Read reads one line from r, writing mask to w for each character typed
+// and erasing it again on backspace. Ctrl+U clears the line, Ctrl+C
+// aborts, and escape sequences (arrow keys, bracketed paste markers) are
+// ignored. Either CR or LF ends the line.
+func Read(r io.Reader, w io.Writer, mask string) (string, error) {
+	var secret []byte
+	buf := make([]byte, 1)
+	erase := func(n int) {
+		for ; n > 0; n-- {
+			_, r := utf8.DecodeLastRune(secret)
+			secret = secret[:len(secret)-r]
+			fmt.Fprint(w, "\b \b")
+		}
+	}
+
+	for {
+		n, err := r.Read(buf)
+		if n == 0 {
+			if err == io.EOF && len(secret) > 0 {
+				return string(secret), nil
+			}
+			if err == nil {
+				continue
+			}
+			return "", err
+		}
+
+		switch b := buf[0]; b {
+		case '\r', '\n':
+			return string(secret), nil
+		case ctrlC:
+			return "", ErrInterrupted
+		case ctrlD:
+			if len(secret) == 0 {
+				return "", io.EOF
+			}
+		case backspace, del:
+			if len(secret) > 0 {
+				erase(1)
+			}
+		case ctrlU:
+			erase(utf8.RuneCount(secret))
+		case esc:
+			if err := skipEscape(r); err != nil {
+				return "", err
+			}
+		default:
+			if b < 0x20 {
+				continue // other control keys
+			}
+			secret = append(secret, b)
+			if b < utf8.RuneSelf || b >= 0xc0 { // first byte of a character
+				fmt.Fprint(w, mask)
+			}
+		}
+	}
+}
+
+// skipEscape consumes the rest of an escape sequence: a CSI sequence
+// (ESC [ params final), such as the ESC[200~ and ESC[201~ that wrap a
+// bracketed paste, an SS3 key (ESC O x), or a two-byte sequence.
+func skipEscape(r io.Reader) error {
+	buf := make([]byte, 1)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+	switch buf[0] {
+	case 'O':
+		_, err := io.ReadFull(r, buf)
+		return err
+	case '[':
+	default:
+		return nil
+	}
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return err
+		}
+		if buf[0] >= 0x40 && buf[0] <= 0x7e {
+			return nil
+		}
+	}
+}
+
+// ReadTerminal prints prompt to w, switches the terminal tty to raw mode
+// and reads a secret, restoring the terminal afterwards.
+func ReadTerminal(tty *os.File, w io.Writer, prompt string) (string, error) {
+	fd := int(tty.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("cannot switch the terminal to raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	fmt.Fprint(w, prompt)
+	secret, err := Read(tty, w, "*")
+	// Raw mode does not translate \n, so return to the start of the line.
+	fmt.Fprint(w, "\r\n")
+	return secret, err
+}
@@ -0,0 +1,51 @@
+package secretinput
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	for _, tc := range []struct {
+		name, input, want, echo string
+	}{
+		{"enter", "sk-ant-123\r", "sk-ant-123", "**********"},
+		{"newline", "abc\nignored", "abc", "***"},
+		{"eof", "abc", "abc", "***"},
+		{"backspace", "abx\x7fc\x08d\r", "abd", "***\b \b*\b \b*"},
+		{"backspace on empty", "\x7fa\r", "a", "*"},
+		{"ctrl-u", "wrong\x15right\r", "right", "*****\b \b\b \b\b \b\b \b\b \b*****"},
+		{"bracketed paste", "\x1b[200~pasted\x1b[201~\r", "pasted", "******"},
+		{"arrow keys", "a\x1b[Db\x1bODc\r", "abc", "***"},
+		{"utf-8", "pä\x7fß\r", "pß", "**\b \b*"},
+		{"control chars", "a\tb\r", "ab", "**"},
+	} {
+		var echo bytes.Buffer
+		got, err := Read(strings.NewReader(tc.input), &echo, "*")
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+		if echo.String() != tc.echo {
+			t.Errorf("%s: echoed %q, want %q", tc.name, echo.String(), tc.echo)
+		}
+	}
+}
+
+func TestReadAborts(t *testing.T) {
+	if _, err := Read(strings.NewReader("abc\x03def\r"), io.Discard, "*"); !errors.Is(err, ErrInterrupted) {
+		t.Errorf("Ctrl+C: err = %v, want ErrInterrupted", err)
+	}
+	if _, err := Read(strings.NewReader("\x04"), io.Discard, "*"); err != io.EOF {
+		t.Errorf("Ctrl+D: err = %v, want io.EOF", err)
+	}
+	if _, err := Read(strings.NewReader(""), io.Discard, "*"); err != io.EOF {
+		t.Errorf("empty input: err = %v, want io.EOF", err)
+	}
+}
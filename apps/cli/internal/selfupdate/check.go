@@ -0,0 +1,59 @@
+package selfupdate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CheckInterval is how often the background check asks for the latest
+// release, and how often the same update is announced.
+const CheckInterval = 24 * time.Hour
+
+// CheckState is what the background update check remembers between
+// commands.
+type CheckState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Channel   string    `json:"channel,omitempty"`
+	Latest    string    `json:"latest,omitempty"`
+
+	NotifiedAt      time.Time `json:"notified_at,omitzero"`
+	NotifiedVersion string    `json:"notified_version,omitempty"`
+}
+
+// LoadCheckState reads the state at path. A missing or unreadable file
+// is a check that never ran.
+func LoadCheckState(path string) CheckState {
+	var s CheckState
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &s)
+	}
+	return s
+}
+
+// Save writes s to path, creating its directory if needed.
+func (s CheckState) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Due reports whether it is time to check channel again.
+func (s CheckState) Due(channel string, now time.Time) bool {
+	return s.Channel != channel || now.Sub(s.CheckedAt) >= CheckInterval
+}
+
+// Announce reports whether the latest release found is newer than
+// installed and has not been announced in the last CheckInterval.
+func (s CheckState) Announce(installed string, now time.Time) bool {
+	if s.Latest == "" || CompareVersions(s.Latest, installed) <= 0 {
+		return false
+	}
+	return s.NotifiedVersion != s.Latest || now.Sub(s.NotifiedAt) >= CheckInterval
+}
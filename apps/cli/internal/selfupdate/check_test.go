@@ -0,0 +1,49 @@
+package selfupdate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "update-check.json")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s := LoadCheckState(path)
+	if !s.Due(ChannelStable, now) {
+		t.Error("a check that never ran is not due")
+	}
+	s = CheckState{CheckedAt: now, Channel: ChannelStable, Latest: "1.3.0"}
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	s = LoadCheckState(path)
+	if s.Due(ChannelStable, now.Add(time.Hour)) {
+		t.Error("due an hour after checking")
+	}
+	if !s.Due(ChannelBeta, now.Add(time.Hour)) {
+		t.Error("not due after switching channel")
+	}
+	if !s.Due(ChannelStable, now.Add(CheckInterval)) {
+		t.Error("not due a day after checking")
+	}
+
+	if s.Announce("1.3.0", now) {
+		t.Error("announced the installed version")
+	}
+	if !s.Announce("1.2.0", now) {
+		t.Error("did not announce a newer version")
+	}
+	s.NotifiedAt, s.NotifiedVersion = now, "1.3.0"
+	if s.Announce("1.2.0", now.Add(time.Hour)) {
+		t.Error("announced the same version twice in a day")
+	}
+	if !s.Announce("1.2.0", now.Add(CheckInterval)) {
+		t.Error("did not announce again the next day")
+	}
+	s.Latest = "1.4.0"
+	if !s.Announce("1.2.0", now.Add(time.Hour)) {
+		t.Error("did not announce a release newer than the one announced")
+	}
+}
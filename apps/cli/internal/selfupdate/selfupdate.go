@@ -0,0 +1,333 @@
+// Package selfupdate finds, downloads and installs released ellie
+// binaries.
+//
+// Releases are read from the GitHub releases API. Each release carries
+// one binary per platform named ellie_<os>_<arch> (with .exe on Windows)
+// and a checksums.txt in `sha256sum` format; a binary is only installed
+// if its checksum matches.
+package selfupdate
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultReleasesURL lists the published CLI releases.
+const DefaultReleasesURL = "https://api.github.com/repos/ShaulLavo/ellie/releases"
+
+// Channels a release can be installed from.
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+// checksumsAsset is the release asset listing every binary's SHA-256.
+const checksumsAsset = "checksums.txt"
+
+// ErrNoRelease means the channel has no release for this platform.
+var ErrNoRelease = errors.New("no release found")
+
+// Release is a published version with the binary for this platform.
+type Release struct {
+	Version     string
+	Prerelease  bool
+	Notes       string
+	AssetName   string
+	AssetURL    string
+	ChecksumURL string
+}
+
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	Assets     []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// Updater talks to a release endpoint.
+type Updater struct {
+	ReleasesURL string
+	Client      *http.Client
+	GOOS        string
+	GOARCH      string
+}
+
+// New returns an Updater for the current platform.
+func New(releasesURL string, client *http.Client) *Updater {
+	if releasesURL == "" {
+		releasesURL = DefaultReleasesURL
+	}
+	return &Updater{ReleasesURL: releasesURL, Client: client, GOOS: runtime.GOOS, GOARCH: runtime.GOARCH}
+}
+
+// AssetName returns the binary name published for the updater's platform.
+func (u *Updater) AssetName() string {
+	name := "ellie_" + u.GOOS + "_" + u.GOARCH
+	if u.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Latest returns the newest release on channel that has a binary and a
+// checksum for this platform. The stable channel skips prereleases.
+func (u *Updater) Latest(ctx context.Context, channel string) (*Release, error) {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return nil, fmt.Errorf("unknown channel %q (use %s or %s)", channel, ChannelStable, ChannelBeta)
+	}
+
+	releases, err := u.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Release
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel == ChannelStable) {
+			continue
+		}
+		rel := Release{Version: strings.TrimPrefix(r.TagName, "v"), Prerelease: r.Prerelease, Notes: r.Body}
+		for _, a := range r.Assets {
+			switch a.Name {
+			case u.AssetName():
+				rel.AssetName, rel.AssetURL = a.Name, a.URL
+			case checksumsAsset:
+				rel.ChecksumURL = a.URL
+			}
+		}
+		if rel.AssetURL == "" || rel.ChecksumURL == "" {
+			continue
+		}
+		if best == nil || CompareVersions(rel.Version, best.Version) > 0 {
+			best = &rel
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w on the %s channel for %s/%s", ErrNoRelease, channel, u.GOOS, u.GOARCH)
+	}
+	return best, nil
+}
+
+// Changelog returns the releases on channel newer than version, newest
+// first, for their notes. Unlike Latest it includes releases without a
+// binary for this platform.
+func (u *Updater) Changelog(ctx context.Context, channel, version string) ([]Release, error) {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return nil, fmt.Errorf("unknown channel %q (use %s or %s)", channel, ChannelStable, ChannelBeta)
+	}
+	releases, err := u.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []Release
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel == ChannelStable) {
+			continue
+		}
+		rel := Release{Version: strings.TrimPrefix(r.TagName, "v"), Prerelease: r.Prerelease, Notes: r.Body}
+		if CompareVersions(rel.Version, version) > 0 {
+			out = append(out, rel)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return CompareVersions(out[i].Version, out[j].Version) > 0 })
+	return out, nil
+}
+
+func (u *Updater) list(ctx context.Context) ([]githubRelease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.ReleasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach release server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("release server returned %d", resp.StatusCode)
+	}
+	var releases []githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("invalid release list: %w", err)
+	}
+	return releases, nil
+}
+
+// Install downloads rel's binary, verifies its checksum and atomically
+// replaces the executable at exe with it.
+func (u *Updater) Install(ctx context.Context, rel *Release, exe string) error {
+	want, err := u.expectedChecksum(ctx, rel)
+	if err != nil {
+		return err
+	}
+
+	// Download next to the target so the final rename stays on one
+	// filesystem and is atomic.
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".ellie-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write next to %s: %w", exe, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	body, err := u.get(ctx, rel.AssetURL)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), body)
+	body.Close()
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", rel.AssetName, want, got)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+	return replaceFile(tmp.Name(), exe)
+}
+
+// replaceFile moves src over dst. The old file is moved aside first
+// because Windows refuses to overwrite a running executable; it can
+// still be renamed.
+func replaceFile(src, dst string) error {
+	old := dst + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(dst, old); err != nil {
+		return fmt.Errorf("cannot replace %s: %w", dst, err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		_ = os.Rename(old, dst)
+		return fmt.Errorf("cannot replace %s: %w", dst, err)
+	}
+	_ = os.Remove(old) // fails on Windows while the old binary runs; cleaned up next time
+	return nil
+}
+
+func (u *Updater) expectedChecksum(ctx context.Context, rel *Release) (string, error) {
+	body, err := u.get(ctx, rel.ChecksumURL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	sums, err := ParseChecksums(body)
+	if err != nil {
+		return "", err
+	}
+	sum, ok := sums[rel.AssetName]
+	if !ok {
+		return "", fmt.Errorf("%s does not list %s", checksumsAsset, rel.AssetName)
+	}
+	return sum, nil
+}
+
+func (u *Updater) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("download of %s returned %d", url, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// ParseChecksums reads `sha256sum` output ("<hex>  <name>" per line).
+func ParseChecksums(r io.Reader) (map[string]string, error) {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		sum, name := strings.ToLower(fields[0]), strings.TrimPrefix(fields[1], "*")
+		if len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid checksum for %s", name)
+		}
+		sums[name] = sum
+	}
+	return sums, scanner.Err()
+}
+
+// CompareVersions compares semantic versions ("1.2.3", "1.3.0-beta.2").
+// A prerelease sorts before the release it leads up to.
+func CompareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	coreA, preA, _ := strings.Cut(a, "-")
+	coreB, preB, _ := strings.Cut(b, "-")
+
+	if c := compareDotted(coreA, coreB); c != 0 {
+		return c
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return compareDotted(preA, preB)
+}
+
+// compareDotted compares dot-separated identifiers, numerically where
+// both sides are numbers.
+func compareDotted(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		x, y := "0", "0"
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		nx, errX := strconv.Atoi(x)
+		ny, errY := strconv.Atoi(y)
+		switch {
+		case errX == nil && errY == nil:
+			if nx != ny {
+				if nx < ny {
+					return -1
+				}
+				return 1
+			}
+		case x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,159 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.10", "1.2.9", 1},
+		{"1.2", "1.2.1", -1},
+		{"1.3.0-beta.1", "1.3.0", -1},
+		{"1.3.0-beta.10", "1.3.0-beta.9", 1},
+		{"1.3.0-beta.1", "1.2.9", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// releaseServer serves a release list with the given tags, each carrying
+// binary for linux/amd64 and a checksums file.
+func releaseServer(t *testing.T, binary string, tags map[string]bool) *httptest.Server {
+	t.Helper()
+	sum := sha256.Sum256([]byte(binary))
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc("/releases", func(w http.ResponseWriter, r *http.Request) {
+		var out []map[string]any
+		for tag, pre := range tags {
+			out = append(out, map[string]any{
+				"tag_name":   tag,
+				"prerelease": pre,
+				"assets": []map[string]string{
+					{"name": "ellie_linux_amd64", "browser_download_url": srv.URL + "/bin"},
+					{"name": "checksums.txt", "browser_download_url": srv.URL + "/sums"},
+				},
+			})
+		}
+		json.NewEncoder(w).Encode(out)
+	})
+	mux.HandleFunc("/bin", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, binary)
+	})
+	mux.HandleFunc("/sums", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  ellie_linux_amd64\n%s  ellie_darwin_arm64\n", hex.EncodeToString(sum[:]), strings.Repeat("0", 64))
+	})
+	return srv
+}
+
+func newTestUpdater(srv *httptest.Server) *Updater {
+	u := New(srv.URL+"/releases", srv.Client())
+	u.GOOS, u.GOARCH = "linux", "amd64"
+	return u
+}
+
+func TestLatestRespectsChannel(t *testing.T) {
+	srv := releaseServer(t, "bin", map[string]bool{"v1.1.0": false, "v1.2.0": false, "v1.3.0-beta.1": true})
+	u := newTestUpdater(srv)
+
+	rel, err := u.Latest(context.Background(), ChannelStable)
+	if err != nil || rel.Version != "1.2.0" {
+		t.Fatalf("stable: got %+v, %v", rel, err)
+	}
+	rel, err = u.Latest(context.Background(), ChannelBeta)
+	if err != nil || rel.Version != "1.3.0-beta.1" {
+		t.Fatalf("beta: got %+v, %v", rel, err)
+	}
+
+	u.GOOS = "plan9"
+	if _, err := u.Latest(context.Background(), ChannelStable); !errors.Is(err, ErrNoRelease) {
+		t.Errorf("unsupported platform: err = %v, want ErrNoRelease", err)
+	}
+}
+
+func TestInstallReplacesExecutable(t *testing.T) {
+	srv := releaseServer(t, "new binary", map[string]bool{"v2.0.0": false})
+	u := newTestUpdater(srv)
+	exe := filepath.Join(t.TempDir(), "ellie")
+	if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	rel, err := u.Latest(context.Background(), ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Install(context.Background(), rel, exe); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(exe)
+	if string(data) != "new binary" {
+		t.Errorf("executable = %q, want new binary", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(exe)); len(entries) != 1 {
+		t.Errorf("leftover files next to executable: %v", entries)
+	}
+}
+
+func TestInstallRejectsChecksumMismatch(t *testing.T) {
+	srv := releaseServer(t, "new binary", map[string]bool{"v2.0.0": false})
+	u := newTestUpdater(srv)
+	exe := filepath.Join(t.TempDir(), "ellie")
+	if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	rel, err := u.Latest(context.Background(), ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel.AssetName = "ellie_darwin_arm64" // listed with a different checksum
+	if err := u.Install(context.Background(), rel, exe); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Install err = %v, want checksum mismatch", err)
+	}
+	data, _ := os.ReadFile(exe)
+	if string(data) != "old binary" {
+		t.Errorf("executable was replaced despite checksum mismatch")
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	srv := releaseServer(t, "bin", map[string]bool{"v1.1.0": false, "v1.2.0": false, "v1.10.0": false, "v1.11.0-beta.1": true})
+	u := newTestUpdater(srv)
+
+	rels, err := u.Changelog(context.Background(), ChannelStable, "1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range rels {
+		got = append(got, r.Version)
+	}
+	if strings.Join(got, " ") != "1.10.0 1.2.0" {
+		t.Errorf("stable changelog since 1.1.0 = %v, want newest first without prereleases", got)
+	}
+	if rels, _ := u.Changelog(context.Background(), ChannelBeta, "1.10.0"); len(rels) != 1 || rels[0].Version != "1.11.0-beta.1" {
+		t.Errorf("beta changelog since 1.10.0 = %+v", rels)
+	}
+}
@@ -0,0 +1,350 @@
+// Package sessionstats computes per-turn analytics — tokens, cost, model
+// switches, latency and tool calls — from a conversation's messages as
+// returned by GET /api/chat/branches/:id/messages.
+package sessionstats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Message is the subset of an agent message the statistics need.
+type Message struct {
+	Role       string  `json:"role"` // user, assistant or toolResult
+	Content    []Block `json:"content"`
+	Provider   string  `json:"provider,omitempty"`
+	Model      string  `json:"model,omitempty"`
+	Usage      *Usage  `json:"usage,omitempty"`
+	StopReason string  `json:"stopReason,omitempty"`
+	ToolName   string  `json:"toolName,omitempty"`
+	IsError    bool    `json:"isError,omitempty"`
+	Timestamp  float64 `json:"timestamp"` // Unix ms
+}
+
+// Block is a content block: text, or a tool call and its name.
+type Block struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+// Text joins the message's text blocks.
+func (m Message) Text() string {
+	var parts []string
+	for _, b := range m.Content {
+		if b.Type == "text" && b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Usage is the token usage and cost of one model call.
+type Usage struct {
+	Input       int64 `json:"input"`
+	Output      int64 `json:"output"`
+	CacheRead   int64 `json:"cacheRead"`
+	CacheWrite  int64 `json:"cacheWrite"`
+	TotalTokens int64 `json:"totalTokens"`
+	Cost        struct {
+		Total float64 `json:"total"`
+	} `json:"cost"`
+}
+
+// ParseMessages decodes a message list.
+func ParseMessages(r io.Reader) ([]Message, error) {
+	var msgs []Message
+	if err := json.NewDecoder(r).Decode(&msgs); err != nil {
+		return nil, fmt.Errorf("invalid message list: %w", err)
+	}
+	return msgs, nil
+}
+
+// Turn is one user message and everything the agent did in response.
+type Turn struct {
+	Number     int            `json:"turn"`
+	Start      time.Time      `json:"start"`
+	Models     []string       `json:"models"`
+	ModelCalls int            `json:"model_calls"`
+	Input      int64          `json:"input_tokens"`
+	Output     int64          `json:"output_tokens"`
+	CacheRead  int64          `json:"cache_read_tokens"`
+	CacheWrite int64          `json:"cache_write_tokens"`
+	Cost       float64        `json:"cost_usd"`
+	Latency    time.Duration  `json:"-"`
+	ToolCalls  map[string]int `json:"tool_calls,omitempty"`
+	ToolErrors int            `json:"tool_errors,omitempty"`
+	Errors     int            `json:"errors,omitempty"` // model calls that ended in error
+}
+
+// Tokens is the turn's total token count.
+func (t Turn) Tokens() int64 {
+	return t.Input + t.Output + t.CacheRead + t.CacheWrite
+}
+
+// MarshalJSON adds the latency in milliseconds.
+func (t Turn) MarshalJSON() ([]byte, error) {
+	type plain Turn
+	return json.Marshal(struct {
+		plain
+		LatencyMS int64 `json:"latency_ms"`
+	}{plain(t), t.Latency.Milliseconds()})
+}
+
+// ModelSwitch records the model changing between consecutive calls.
+type ModelSwitch struct {
+	Turn int    `json:"turn"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Stats summarizes a conversation.
+type Stats struct {
+	Turns         []Turn         `json:"turns"`
+	Models        []string       `json:"models"` // in order of first use
+	ModelSwitches []ModelSwitch  `json:"model_switches"`
+	ModelCalls    int            `json:"model_calls"`
+	Input         int64          `json:"input_tokens"`
+	Output        int64          `json:"output_tokens"`
+	CacheRead     int64          `json:"cache_read_tokens"`
+	CacheWrite    int64          `json:"cache_write_tokens"`
+	Cost          float64        `json:"cost_usd"`
+	ToolCalls     map[string]int `json:"tool_calls"`
+	ToolErrors    int            `json:"tool_errors"`
+	Errors        int            `json:"errors"`
+	MeanLatency   time.Duration  `json:"-"`
+	MaxLatency    time.Duration  `json:"-"`
+	Duration      time.Duration  `json:"-"`
+}
+
+// MarshalJSON adds the durations in milliseconds.
+func (s Stats) MarshalJSON() ([]byte, error) {
+	type plain Stats
+	return json.Marshal(struct {
+		plain
+		MeanLatencyMS int64 `json:"mean_latency_ms"`
+		MaxLatencyMS  int64 `json:"max_latency_ms"`
+		DurationMS    int64 `json:"duration_ms"`
+	}{plain(s), s.MeanLatency.Milliseconds(), s.MaxLatency.Milliseconds(), s.Duration.Milliseconds()})
+}
+
+// Tokens is the conversation's total token count.
+func (s Stats) Tokens() int64 {
+	return s.Input + s.Output + s.CacheRead + s.CacheWrite
+}
+
+// Compute groups messages into turns and aggregates them. Messages before
+// the first user message (e.g. a greeting) form turn 0. A turn's latency
+// runs from the user message to the last assistant message answering it.
+func Compute(msgs []Message) Stats {
+	s := Stats{ToolCalls: map[string]int{}}
+	var cur *Turn
+	var lastAssistant time.Time
+	lastModel, userTurns := "", 0
+
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		if cur.Number > 0 && lastAssistant.After(cur.Start) {
+			cur.Latency = lastAssistant.Sub(cur.Start)
+		}
+		s.Turns = append(s.Turns, *cur)
+		cur = nil
+	}
+
+	for _, m := range msgs {
+		ts := time.UnixMilli(int64(m.Timestamp))
+		if m.Role == "user" {
+			flush()
+			userTurns++
+			cur = &Turn{Number: userTurns, Start: ts}
+			lastAssistant = time.Time{}
+			continue
+		}
+		if cur == nil {
+			cur = &Turn{Number: 0, Start: ts}
+		}
+		switch m.Role {
+		case "assistant":
+			lastAssistant = ts
+			cur.ModelCalls++
+			if m.StopReason == "error" {
+				cur.Errors++
+			}
+			if m.Model != "" {
+				if !contains(cur.Models, m.Model) {
+					cur.Models = append(cur.Models, m.Model)
+				}
+				if lastModel != "" && m.Model != lastModel {
+					s.ModelSwitches = append(s.ModelSwitches, ModelSwitch{Turn: cur.Number, From: lastModel, To: m.Model})
+				}
+				if !contains(s.Models, m.Model) {
+					s.Models = append(s.Models, m.Model)
+				}
+				lastModel = m.Model
+			}
+			if u := m.Usage; u != nil {
+				cur.Input += u.Input
+				cur.Output += u.Output
+				cur.CacheRead += u.CacheRead
+				cur.CacheWrite += u.CacheWrite
+				cur.Cost += u.Cost.Total
+			}
+			for _, b := range m.Content {
+				if b.Type == "toolCall" {
+					if cur.ToolCalls == nil {
+						cur.ToolCalls = map[string]int{}
+					}
+					cur.ToolCalls[b.Name]++
+				}
+			}
+		case "toolResult":
+			if m.IsError {
+				cur.ToolErrors++
+			}
+		}
+	}
+	flush()
+
+	var latencies, n time.Duration
+	for _, t := range s.Turns {
+		s.ModelCalls += t.ModelCalls
+		s.Input += t.Input
+		s.Output += t.Output
+		s.CacheRead += t.CacheRead
+		s.CacheWrite += t.CacheWrite
+		s.Cost += t.Cost
+		s.ToolErrors += t.ToolErrors
+		s.Errors += t.Errors
+		for name, c := range t.ToolCalls {
+			s.ToolCalls[name] += c
+		}
+		if t.Latency > 0 {
+			latencies += t.Latency
+			n++
+			if t.Latency > s.MaxLatency {
+				s.MaxLatency = t.Latency
+			}
+		}
+	}
+	if n > 0 {
+		s.MeanLatency = latencies / n
+	}
+	if len(msgs) > 1 {
+		first := time.UnixMilli(int64(msgs[0].Timestamp))
+		last := time.UnixMilli(int64(msgs[len(msgs)-1].Timestamp))
+		s.Duration = last.Sub(first)
+	}
+	return s
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteTable prints one row per turn followed by the totals.
+func (s Stats) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TURN\tMODEL\tCALLS\tINPUT\tOUTPUT\tCACHED\tCOST\tLATENCY\tTOOLS")
+	for _, t := range s.Turns {
+		model := strings.Join(t.Models, ", ")
+		if model == "" {
+			model = "-"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.Number, model, t.ModelCalls, FormatTokens(t.Input), FormatTokens(t.Output), FormatTokens(t.CacheRead+t.CacheWrite),
+			FormatCost(t.Cost), formatLatency(t.Latency), toolSummary(t.ToolCalls, t.ToolErrors))
+	}
+	fmt.Fprintf(tw, "total\t\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		s.ModelCalls, FormatTokens(s.Input), FormatTokens(s.Output), FormatTokens(s.CacheRead+s.CacheWrite),
+		FormatCost(s.Cost), formatLatency(s.MeanLatency)+" avg", toolSummary(s.ToolCalls, s.ToolErrors))
+	return tw.Flush()
+}
+
+// toolSummary renders tool call counts, busiest first.
+func toolSummary(calls map[string]int, errors int) string {
+	if len(calls) == 0 {
+		return "-"
+	}
+	names := make([]string, 0, len(calls))
+	for name := range calls {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if calls[names[i]] != calls[names[j]] {
+			return calls[names[i]] > calls[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s×%d", name, calls[name])
+	}
+	out := strings.Join(parts, " ")
+	if errors > 0 {
+		out += fmt.Sprintf(" (%d failed)", errors)
+	}
+	return out
+}
+
+// Sparkline renders values as a row of block characters scaled to the
+// largest value.
+func Sparkline(values []float64) string {
+	const bars = "▁▂▃▄▅▆▇█"
+	levels := []rune(bars)
+	var max float64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if max > 0 {
+			i = int(v / max * float64(len(levels)-1))
+		}
+		b.WriteRune(levels[i])
+	}
+	return b.String()
+}
+
+// FormatTokens abbreviates a token count (1234 → 1.2k).
+func FormatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// FormatCost renders a USD amount with precision suited to its size.
+func FormatCost(usd float64) string {
+	if usd >= 1 {
+		return fmt.Sprintf("$%.2f", usd)
+	}
+	return fmt.Sprintf("$%.4f", usd)
+}
+
+func formatLatency(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,149 @@
+package sessionstats
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+const transcript = `[
+  {"role":"assistant","content":[{"type":"text","text":"Hi"}],"model":"claude-haiku","usage":{"input":10,"output":5,"cacheRead":0,"cacheWrite":0,"totalTokens":15,"cost":{"total":0.0001}},"stopReason":"stop","timestamp":1000},
+  {"role":"user","content":[{"type":"text","text":"list files"}],"timestamp":2000},
+  {"role":"assistant","content":[{"type":"toolCall","id":"t1","name":"bash","arguments":{}},{"type":"toolCall","id":"t2","name":"read","arguments":{}}],"model":"claude-sonnet","usage":{"input":100,"output":20,"cacheRead":50,"cacheWrite":10,"totalTokens":180,"cost":{"total":0.01}},"stopReason":"toolUse","timestamp":3000},
+  {"role":"toolResult","toolCallId":"t1","toolName":"bash","isError":true,"timestamp":3100},
+  {"role":"toolResult","toolCallId":"t2","toolName":"read","isError":false,"timestamp":3200},
+  {"role":"assistant","content":[{"type":"toolCall","id":"t3","name":"bash","arguments":{}}],"model":"claude-sonnet","usage":{"input":200,"output":30,"cacheRead":0,"cacheWrite":0,"totalTokens":230,"cost":{"total":0.02}},"stopReason":"toolUse","timestamp":4000},
+  {"role":"toolResult","toolCallId":"t3","toolName":"bash","isError":false,"timestamp":4100},
+  {"role":"assistant","content":[{"type":"text","text":"Done"}],"model":"claude-sonnet","usage":{"input":300,"output":40,"cacheRead":0,"cacheWrite":0,"totalTokens":340,"cost":{"total":0.03}},"stopReason":"stop","timestamp":6500},
+  {"role":"user","content":[{"type":"text","text":"thanks"}],"timestamp":10000},
+  {"role":"assistant","content":[{"type":"text","text":""}],"model":"claude-haiku","stopReason":"error","errorMessage":"overloaded","timestamp":10500}
+]`
+
+func TestCompute(t *testing.T) {
+	msgs, err := ParseMessages(strings.NewReader(transcript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Compute(msgs)
+
+	if len(s.Turns) != 3 {
+		t.Fatalf("got %d turns, want 3", len(s.Turns))
+	}
+	greeting, work, last := s.Turns[0], s.Turns[1], s.Turns[2]
+
+	if greeting.Number != 0 || greeting.ModelCalls != 1 || greeting.Latency != 0 {
+		t.Errorf("greeting turn = %+v", greeting)
+	}
+	if work.Number != 1 || work.ModelCalls != 3 {
+		t.Errorf("work turn number/calls = %d/%d", work.Number, work.ModelCalls)
+	}
+	if work.Input != 600 || work.Output != 90 || work.CacheRead != 50 || work.CacheWrite != 10 || work.Tokens() != 750 {
+		t.Errorf("work turn tokens = %+v", work)
+	}
+	if work.Latency != 4500*time.Millisecond {
+		t.Errorf("work turn latency = %v, want 4.5s", work.Latency)
+	}
+	if work.ToolCalls["bash"] != 2 || work.ToolCalls["read"] != 1 || work.ToolErrors != 1 {
+		t.Errorf("work turn tools = %v, errors %d", work.ToolCalls, work.ToolErrors)
+	}
+	if last.Errors != 1 || last.Latency != 500*time.Millisecond {
+		t.Errorf("last turn = %+v", last)
+	}
+
+	if got := strings.Join(s.Models, ","); got != "claude-haiku,claude-sonnet" {
+		t.Errorf("models = %s", got)
+	}
+	want := []ModelSwitch{{Turn: 1, From: "claude-haiku", To: "claude-sonnet"}, {Turn: 2, From: "claude-sonnet", To: "claude-haiku"}}
+	if len(s.ModelSwitches) != len(want) || s.ModelSwitches[0] != want[0] || s.ModelSwitches[1] != want[1] {
+		t.Errorf("model switches = %+v", s.ModelSwitches)
+	}
+	if s.ModelCalls != 5 || s.Tokens() != 765 || s.Errors != 1 || s.ToolErrors != 1 {
+		t.Errorf("totals = %+v", s)
+	}
+	if s.Cost < 0.0600 || s.Cost > 0.0602 {
+		t.Errorf("cost = %v", s.Cost)
+	}
+	if s.MeanLatency != 2500*time.Millisecond || s.MaxLatency != 4500*time.Millisecond {
+		t.Errorf("latency mean/max = %v/%v", s.MeanLatency, s.MaxLatency)
+	}
+	if s.Duration != 9500*time.Millisecond {
+		t.Errorf("duration = %v", s.Duration)
+	}
+}
+
+func TestMessageText(t *testing.T) {
+	msgs, err := ParseMessages(strings.NewReader(`[{"role":"assistant","content":[{"type":"text","text":"one"},{"type":"toolCall","name":"bash"},{"type":"text","text":"two"}]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msgs[0].Text(); got != "one\n\ntwo" {
+		t.Errorf("Text = %q", got)
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	msgs, _ := ParseMessages(strings.NewReader(transcript))
+	data, err := json.Marshal(Compute(msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Turns []struct {
+			LatencyMS int64 `json:"latency_ms"`
+		} `json:"turns"`
+		MaxLatencyMS int64 `json:"max_latency_ms"`
+		Models       []string
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Turns) != 3 || out.Turns[1].LatencyMS != 4500 || out.MaxLatencyMS != 4500 || len(out.Models) != 2 {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	msgs, _ := ParseMessages(strings.NewReader(transcript))
+	var buf bytes.Buffer
+	if err := Compute(msgs).WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[2], "bash×2 read×1 (1 failed)") || !strings.Contains(lines[2], "4.5s") {
+		t.Errorf("work turn row = %q", lines[2])
+	}
+	if !strings.HasPrefix(lines[4], "total") || !strings.Contains(lines[4], "$0.0601") {
+		t.Errorf("total row = %q", lines[4])
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]float64{0, 1, 2, 4, 7}); got != "▁▂▃▅█" {
+		t.Errorf("Sparkline = %q", got)
+	}
+	if got := Sparkline([]float64{0, 0}); got != "▁▁" {
+		t.Errorf("Sparkline of zeros = %q", got)
+	}
+	if got := Sparkline(nil); got != "" {
+		t.Errorf("Sparkline(nil) = %q", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for n, want := range map[int64]string{0: "0", 999: "999", 1234: "1.2k", 2_500_000: "2.5M"} {
+		if got := FormatTokens(n); got != want {
+			t.Errorf("FormatTokens(%d) = %q, want %q", n, got, want)
+		}
+	}
+	if got := FormatCost(0.01234); got != "$0.0123" {
+		t.Errorf("FormatCost = %q", got)
+	}
+	if got := FormatCost(12.3); got != "$12.30" {
+		t.Errorf("FormatCost = %q", got)
+	}
+}
@@ -0,0 +1,119 @@
+package sso
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// expirySkew treats a session as expired slightly early, so a token is
+// never sent just as it runs out.
+const expirySkew = 30 * time.Second
+
+// Session is a signed-in CLI session for one ellie server.
+type Session struct {
+	Server        string    `json:"server"`
+	Issuer        string    `json:"issuer"`
+	ClientID      string    `json:"client_id"`
+	TokenEndpoint string    `json:"token_endpoint"`
+	AccessToken   string    `json:"access_token"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
+	IDToken       string    `json:"id_token,omitempty"`
+	User          string    `json:"user,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at,omitzero"`
+}
+
+// NewSession builds the session for server from a token response.
+func NewSession(server string, c *Client, tok *Token) *Session {
+	s := &Session{
+		Server:        server,
+		Issuer:        c.Config.Issuer,
+		ClientID:      c.Config.ClientID,
+		TokenEndpoint: c.TokenEndpoint(),
+	}
+	s.Update(tok)
+	return s
+}
+
+// Update applies a (possibly refreshed) token. Fields the identity
+// provider leaves out of a refresh response keep their old values.
+func (s *Session) Update(tok *Token) {
+	if tok.AccessToken != "" {
+		s.AccessToken = tok.AccessToken
+	}
+	if tok.RefreshToken != "" {
+		s.RefreshToken = tok.RefreshToken
+	}
+	if tok.IDToken != "" {
+		s.IDToken = tok.IDToken
+		if c, err := ParseClaims(tok.IDToken); err == nil {
+			s.User = c.Email
+			if s.User == "" {
+				s.User = c.Name
+			}
+			if s.User == "" {
+				s.User = c.Subject
+			}
+		}
+	}
+	s.ExpiresAt = time.Time{}
+	if tok.ExpiresIn > 0 {
+		s.ExpiresAt = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+}
+
+// Bearer returns the token sent to the server: the access token, or the
+// ID token for identity providers that issue no access token.
+func (s *Session) Bearer() string {
+	if s.AccessToken != "" {
+		return s.AccessToken
+	}
+	return s.IDToken
+}
+
+// Expired reports whether the session's token has run out at now.
+func (s *Session) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && now.After(s.ExpiresAt.Add(-expirySkew))
+}
+
+// Sessions is the on-disk set of sessions, one per server.
+type Sessions struct {
+	Servers map[string]*Session `json:"servers"`
+
+	path string
+}
+
+// LoadSessions reads the session file at path. A missing file has no
+// sessions.
+func LoadSessions(path string) (*Sessions, error) {
+	s := &Sessions{Servers: map[string]*Session{}, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Servers == nil {
+		s.Servers = map[string]*Session{}
+	}
+	return s, nil
+}
+
+// Save writes the sessions back, readable only by the current user.
+func (s *Sessions) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,248 @@
+// Package sso signs the CLI in to an ellie server through the
+// organization's OpenID Connect identity provider, using the OAuth 2.0
+// device authorization grant (RFC 8628), and keeps the resulting sessions.
+package sso
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// pollUnit scales the polling interval; tests shorten it.
+var pollUnit = time.Second
+
+// DefaultScopes are requested when the server doesn't name any.
+var DefaultScopes = []string{"openid", "profile", "email", "offline_access"}
+
+var (
+	// ErrDenied is returned when the user rejects the sign-in.
+	ErrDenied = errors.New("sign-in was denied")
+	// ErrExpired is returned when the device code expires before the
+	// user finishes signing in.
+	ErrExpired = errors.New("sign-in code expired")
+)
+
+// Config identifies the identity provider and the CLI's client there.
+type Config struct {
+	Issuer   string   `json:"issuer"`
+	ClientID string   `json:"client_id"`
+	Scopes   []string `json:"scopes,omitempty"`
+	Audience string   `json:"audience,omitempty"`
+}
+
+// Client runs the device flow against one identity provider.
+type Client struct {
+	Config Config
+	HTTP   *http.Client
+
+	deviceEndpoint string
+	tokenEndpoint  string
+}
+
+// DeviceCode is the identity provider's answer to a device authorization
+// request.
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// Token is a token endpoint response.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// New discovers the identity provider's endpoints from its OpenID
+// configuration.
+func New(ctx context.Context, cfg Config, client *http.Client) (*Client, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" {
+		return nil, fmt.Errorf("SSO needs an issuer and a client ID")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := &Client{Config: cfg, HTTP: client}
+
+	wellKnown := strings.TrimRight(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach identity provider: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenID discovery at %s returned %d", wellKnown, resp.StatusCode)
+	}
+	var doc struct {
+		DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+		TokenEndpoint               string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenID configuration: %w", err)
+	}
+	if doc.DeviceAuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("identity provider %s does not support the device flow", cfg.Issuer)
+	}
+	c.deviceEndpoint, c.tokenEndpoint = doc.DeviceAuthorizationEndpoint, doc.TokenEndpoint
+	return c, nil
+}
+
+// TokenEndpoint returns the discovered token endpoint, which is kept with
+// a session for refreshing it.
+func (c *Client) TokenEndpoint() string { return c.tokenEndpoint }
+
+// StartDevice requests a device and user code.
+func (c *Client) StartDevice(ctx context.Context) (*DeviceCode, error) {
+	scopes := c.Config.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+	form := url.Values{"client_id": {c.Config.ClientID}, "scope": {strings.Join(scopes, " ")}}
+	if c.Config.Audience != "" {
+		form.Set("audience", c.Config.Audience)
+	}
+	var dc DeviceCode
+	if err := c.post(ctx, c.deviceEndpoint, form, &dc); err != nil {
+		return nil, err
+	}
+	if dc.Interval <= 0 {
+		dc.Interval = 5
+	}
+	return &dc, nil
+}
+
+// Poll waits for the user to finish signing in and returns the token.
+func (c *Client) Poll(ctx context.Context, dc *DeviceCode) (*Token, error) {
+	interval := time.Duration(dc.Interval) * pollUnit
+	deadline := time.Now().Add(time.Duration(dc.ExpiresIn) * time.Second)
+	form := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {dc.DeviceCode},
+		"client_id":   {c.Config.ClientID},
+	}
+	for {
+		if dc.ExpiresIn > 0 && time.Now().After(deadline) {
+			return nil, ErrExpired
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var tok Token
+		err := c.post(ctx, c.tokenEndpoint, form, &tok)
+		var oe *oauthError
+		switch {
+		case err == nil:
+			return &tok, nil
+		case errors.As(err, &oe) && oe.Code == "authorization_pending":
+		case errors.As(err, &oe) && oe.Code == "slow_down":
+			interval += 5 * pollUnit
+		case errors.As(err, &oe) && oe.Code == "access_denied":
+			return nil, ErrDenied
+		case errors.As(err, &oe) && oe.Code == "expired_token":
+			return nil, ErrExpired
+		default:
+			return nil, err
+		}
+	}
+}
+
+// Refresh exchanges a refresh token for a new token.
+func Refresh(ctx context.Context, client *http.Client, tokenEndpoint, clientID, refreshToken string) (*Token, error) {
+	c := &Client{HTTP: client}
+	if c.HTTP == nil {
+		c.HTTP = http.DefaultClient
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {clientID},
+	}
+	var tok Token
+	if err := c.post(ctx, tokenEndpoint, form, &tok); err != nil {
+		return nil, err
+	}
+	return &tok, nil
+}
+
+// oauthError is an RFC 6749 error response.
+type oauthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *oauthError) Error() string {
+	if e.Description != "" {
+		return e.Code + ": " + e.Description
+	}
+	return e.Code
+}
+
+func (c *Client) post(ctx context.Context, endpoint string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach identity provider: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oe oauthError
+		if json.Unmarshal(body, &oe) == nil && oe.Code != "" {
+			return &oe
+		}
+		return fmt.Errorf("identity provider returned %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// Claims are the ID token claims shown to the user. They are read without
+// verifying the signature: the server verifies tokens, the CLI only
+// displays who is signed in.
+type Claims struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email"`
+	Name    string `json:"name"`
+}
+
+// ParseClaims decodes the payload of a JWT.
+func ParseClaims(jwt string) (Claims, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return Claims{}, fmt.Errorf("not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, err
+	}
+	var c Claims
+	return c, json.Unmarshal(payload, &c)
+}
@@ -0,0 +1,129 @@
+package sso
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeIdP is a device-flow identity provider that approves the sign-in
+// after pending polls.
+func fakeIdP(t *testing.T, pending int, finalError string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"device_authorization_endpoint": srv.URL + "/device",
+			"token_endpoint":                srv.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("client_id") != "cli" {
+			t.Errorf("client_id = %q", r.FormValue("client_id"))
+		}
+		json.NewEncoder(w).Encode(DeviceCode{DeviceCode: "dev-1", UserCode: "ABCD-EFGH",
+			VerificationURI: srv.URL + "/activate", ExpiresIn: 60, Interval: 1})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") == "refresh_token" {
+			json.NewEncoder(w).Encode(Token{AccessToken: "access-2", ExpiresIn: 3600})
+			return
+		}
+		if pending > 0 {
+			pending--
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+			return
+		}
+		if finalError != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": finalError})
+			return
+		}
+		payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"u1","email":"dev@example.com"}`))
+		json.NewEncoder(w).Encode(Token{AccessToken: "access-1", RefreshToken: "refresh-1",
+			IDToken: "h." + payload + ".s", ExpiresIn: 3600})
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDeviceFlow(t *testing.T) {
+	pollUnit = time.Millisecond
+	idp := fakeIdP(t, 2, "")
+	ctx := context.Background()
+
+	c, err := New(ctx, Config{Issuer: idp.URL, ClientID: "cli"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc, err := c.StartDevice(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dc.UserCode != "ABCD-EFGH" {
+		t.Errorf("user code = %q", dc.UserCode)
+	}
+	tok, err := c.Poll(ctx, dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSession("http://ellie.test", c, tok)
+	if s.User != "dev@example.com" || s.Bearer() != "access-1" || s.Expired(time.Now()) {
+		t.Errorf("session = %+v", s)
+	}
+	if !s.Expired(time.Now().Add(2 * time.Hour)) {
+		t.Error("session should expire")
+	}
+
+	refreshed, err := Refresh(ctx, nil, s.TokenEndpoint, s.ClientID, s.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Update(refreshed)
+	if s.Bearer() != "access-2" || s.RefreshToken != "refresh-1" || s.User != "dev@example.com" {
+		t.Errorf("refreshed session = %+v", s)
+	}
+}
+
+func TestDeviceFlowDenied(t *testing.T) {
+	pollUnit = time.Millisecond
+	idp := fakeIdP(t, 0, "access_denied")
+	ctx := context.Background()
+	c, err := New(ctx, Config{Issuer: idp.URL, ClientID: "cli"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc, err := c.StartDevice(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Poll(ctx, dc); !errors.Is(err, ErrDenied) {
+		t.Errorf("err = %v, want ErrDenied", err)
+	}
+}
+
+func TestSessionsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	s, err := LoadSessions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Servers["http://a"] = &Session{Server: "http://a", AccessToken: "x"}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = LoadSessions(path)
+	if err != nil || s.Servers["http://a"].AccessToken != "x" {
+		t.Errorf("reloaded %+v, %v", s, err)
+	}
+}
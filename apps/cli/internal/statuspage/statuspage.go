@@ -0,0 +1,100 @@
+// Package statuspage reads the public summary of a Statuspage-hosted
+// status page, such as status.anthropic.com, so a failed request can be
+// put down to an incident at the provider rather than the local setup.
+package statuspage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Summary is the part of /api/v2/summary.json ellie reads.
+type Summary struct {
+	Page struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"page"`
+	Status struct {
+		Indicator   string `json:"indicator"` // none, minor, major or critical
+		Description string `json:"description"`
+	} `json:"status"`
+	Incidents []Incident `json:"incidents"`
+}
+
+// Incident is an incident the page reports.
+type Incident struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Status     string      `json:"status"` // investigating, identified, monitoring, resolved or postmortem
+	Impact     string      `json:"impact"` // none, minor, major or critical
+	Shortlink  string      `json:"shortlink"`
+	StartedAt  time.Time   `json:"started_at"`
+	Components []Component `json:"components"`
+}
+
+// Component is a part of the service an incident affects.
+type Component struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// maxSummarySize bounds the summary read from the page.
+const maxSummarySize = 1 << 20
+
+// Fetch reads the summary at url.
+func Fetch(ctx context.Context, client *http.Client, url string) (*Summary, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach the status page: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status page returned %s", resp.Status)
+	}
+	var s Summary
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxSummarySize)).Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid status page summary: %w", err)
+	}
+	return &s, nil
+}
+
+var impactRank = map[string]int{"critical": 3, "major": 2, "minor": 1}
+
+// Active returns the incidents that aren't resolved, the most severe and
+// then the most recent first.
+func (s *Summary) Active() []Incident {
+	var active []Incident
+	for _, inc := range s.Incidents {
+		if inc.Status != "resolved" && inc.Status != "postmortem" {
+			active = append(active, inc)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		if a, b := impactRank[active[i].Impact], impactRank[active[j].Impact]; a != b {
+			return a > b
+		}
+		return active[i].StartedAt.After(active[j].StartedAt)
+	})
+	return active
+}
+
+// Link returns where people can follow an incident.
+func (s *Summary) Link(inc Incident) string {
+	if inc.Shortlink != "" {
+		return inc.Shortlink
+	}
+	if s.Page.URL != "" && inc.ID != "" {
+		return strings.TrimRight(s.Page.URL, "/") + "/incidents/" + inc.ID
+	}
+	return s.Page.URL
+}
@@ -0,0 +1,54 @@
+package statuspage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const summary = `{
+  "page": {"id": "p1", "name": "Anthropic", "url": "https://status.example.com"},
+  "status": {"indicator": "major", "description": "Partial System Outage"},
+  "components": [{"name": "API", "status": "partial_outage"}],
+  "incidents": [
+    {"id": "old", "name": "Slow console", "status": "resolved", "impact": "minor", "started_at": "2026-10-15T08:00:00Z"},
+    {"id": "a1", "name": "Elevated errors on Claude Haiku", "status": "identified", "impact": "minor", "started_at": "2026-10-15T10:00:00Z"},
+    {"id": "b2", "name": "Elevated errors on the API", "status": "investigating", "impact": "major", "shortlink": "https://stspg.io/b2", "started_at": "2026-10-15T09:00:00Z",
+     "components": [{"name": "API", "status": "partial_outage"}]}
+  ]
+}`
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/summary.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(summary))
+	}))
+	defer srv.Close()
+
+	s, err := Fetch(context.Background(), srv.Client(), srv.URL+"/api/v2/summary.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Status.Indicator != "major" || s.Page.Name != "Anthropic" {
+		t.Errorf("summary = %+v", s)
+	}
+
+	active := s.Active()
+	if len(active) != 2 || active[0].ID != "b2" || active[1].ID != "a1" {
+		t.Fatalf("Active = %+v", active)
+	}
+	if got := s.Link(active[0]); got != "https://stspg.io/b2" {
+		t.Errorf("Link with shortlink = %s", got)
+	}
+	if got := s.Link(active[1]); got != "https://status.example.com/incidents/a1" {
+		t.Errorf("Link without shortlink = %s", got)
+	}
+
+	if _, err := Fetch(context.Background(), srv.Client(), srv.URL+"/missing"); err == nil {
+		t.Error("Fetch of a missing page succeeded")
+	}
+}
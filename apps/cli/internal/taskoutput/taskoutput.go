@@ -0,0 +1,228 @@
+// Package taskoutput groups the interleaved output of a build or dev run
+// by task — turbo's "<package>:<task>: " prefixes, or the "$ command"
+// steps of a script — and decides how much of it reaches the terminal:
+// all of it, a line per task, or only that of the tasks that failed.
+package taskoutput
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Mode is how much of the output is shown.
+type Mode string
+
+const (
+	// Full shows every line as it is written.
+	Full Mode = "full"
+	// Summary shows the first line of each task — turbo's cache line, or
+	// the step's command — the runner's own lines, and the whole output
+	// of a task that fails.
+	Summary Mode = "summary"
+	// ErrorsOnly shows nothing but the output of the tasks that fail.
+	ErrorsOnly Mode = "errors-only"
+)
+
+// Modes lists the modes, for flag help and errors.
+var Modes = []Mode{Full, Summary, ErrorsOnly}
+
+// ParseMode parses a mode name; "" is Full.
+func ParseMode(s string) (Mode, error) {
+	if s == "" {
+		return Full, nil
+	}
+	if m := Mode(s); slices.Contains(Modes, m) {
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown output mode %q: use full, summary or errors-only", s)
+}
+
+// MaxBuffered is how many lines of a task are held back; past it the
+// oldest are dropped, and a failing task's output starts with a note of
+// how many.
+const MaxBuffered = 2000
+
+var (
+	// taskPrefix matches turbo's stream-mode prefix: "<package>:<task>: ".
+	taskPrefix = regexp.MustCompile(`^(\S+:[A-Za-z0-9_-]+): ?(.*)$`)
+	// stepLine is how a script announces the command it runs next.
+	stepLine = regexp.MustCompile(`^\$ (.+)$`)
+	// failLine is turbo's report of a task that exited non-zero.
+	failLine = regexp.MustCompile(`^ERROR:? +command finished with error`)
+)
+
+type line struct {
+	w    io.Writer
+	text string
+}
+
+type task struct {
+	name    string
+	lines   []line
+	dropped int
+	seen    bool
+	failed  bool
+}
+
+// Mux routes lines to their task and writes those its mode shows. Lines
+// of a task that are held back are written, in order and each to the
+// writer it came from, once the task fails. It is safe for concurrent use.
+type Mux struct {
+	mode Mode
+
+	mu      sync.Mutex
+	tasks   map[string]*task
+	runner  string // the task of unprefixed lines before any step
+	current string // the task unprefixed lines belong to
+}
+
+// New returns a Mux in mode. Lines without a task prefix belong to the
+// task named name until a "$ command" line starts a step.
+func New(mode Mode, name string) *Mux {
+	return &Mux{mode: mode, tasks: map[string]*task{}, runner: name, current: name}
+}
+
+// Mode returns the mode m shows output in.
+func (m *Mux) Mode() Mode { return m.mode }
+
+// Writer returns a writer whose lines are routed by their prefix and
+// written to w when shown. Call the returned func once the writer is
+// done with to route a last unterminated line.
+func (m *Mux) Writer(w io.Writer) (io.Writer, func()) {
+	lw := &lineWriter{onLine: func(text string) { m.route(w, text) }}
+	return lw, lw.flush
+}
+
+func (m *Mux) route(w io.Writer, text string) {
+	plain := strings.TrimRight(ansi.Strip(text), "\r")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p := taskPrefix.FindStringSubmatch(plain); p != nil {
+		m.add(p[1], w, text, failLine.MatchString(p[2]))
+		return
+	}
+	if s := stepLine.FindStringSubmatch(plain); s != nil {
+		m.current = s[1]
+	}
+	m.add(m.current, w, text, false)
+}
+
+// Line adds a line of name's output, to be written to w when shown.
+func (m *Mux) Line(name string, w io.Writer, text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.add(name, w, text, false)
+}
+
+// Fail marks the task name failed and writes what was held back of it.
+func (m *Mux) Fail(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fail(m.task(name))
+}
+
+// Close ends the run with the exit code of what produced the output. A
+// run that failed without a task reporting it fails the step that was
+// running last and the runner's own lines: standard error is read apart
+// from standard output, so the error may have been routed before the
+// step began.
+func (m *Mux) Close(exitCode int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if exitCode == 0 {
+		return
+	}
+	for _, t := range m.tasks {
+		if t.failed {
+			return
+		}
+	}
+	m.fail(m.task(m.runner))
+	m.fail(m.task(m.current))
+}
+
+func (m *Mux) task(name string) *task {
+	t := m.tasks[name]
+	if t == nil {
+		t = &task{name: name}
+		m.tasks[name] = t
+	}
+	return t
+}
+
+func (m *Mux) add(name string, w io.Writer, text string, failed bool) {
+	t := m.task(name)
+	first := !t.seen
+	t.seen = true
+	switch {
+	case m.mode == Full || t.failed:
+		writeLine(w, text)
+	case m.mode == Summary && (first || name == ""):
+		writeLine(w, text)
+	default:
+		if len(t.lines) == MaxBuffered {
+			t.lines = slices.Delete(t.lines, 0, 1)
+			t.dropped++
+		}
+		t.lines = append(t.lines, line{w, text})
+	}
+	if failed {
+		m.fail(t)
+	}
+}
+
+func (m *Mux) fail(t *task) {
+	if t.failed {
+		return
+	}
+	t.failed = true
+	if t.dropped > 0 && len(t.lines) > 0 {
+		writeLine(t.lines[0].w, fmt.Sprintf("… %d earlier lines of %s not shown", t.dropped, t.name))
+	}
+	for _, l := range t.lines {
+		writeLine(l.w, l.text)
+	}
+	t.lines, t.dropped = nil, 0
+}
+
+func writeLine(w io.Writer, text string) {
+	io.WriteString(w, text+"\n")
+}
+
+// lineWriter buffers partial writes and emits complete lines.
+type lineWriter struct {
+	mu     sync.Mutex
+	buf    []byte
+	onLine func(string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.onLine(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.onLine(string(w.buf))
+		w.buf = nil
+	}
+}
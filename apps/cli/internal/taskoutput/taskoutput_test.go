@@ -0,0 +1,98 @@
+package taskoutput
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+const turboRun = `• Packages in scope: web, server
+web:build: cache miss, executing 1a2b
+server:build: cache hit, replaying logs 3c4d
+web:build: vite v5 building for production...
+server:build: done
+web:build: src/main.ts(3,1): error TS2304: Cannot find name 'x'.
+web:build: ERROR: command finished with error: command exited (2)
+web:build: after the error
+ Tasks:    1 successful, 2 total
+`
+
+func run(t *testing.T, mode Mode, input string, exitCode int) string {
+	t.Helper()
+	var b strings.Builder
+	m := New(mode, "")
+	w, flush := m.Writer(&b)
+	io.WriteString(w, input)
+	flush()
+	m.Close(exitCode)
+	return b.String()
+}
+
+func TestModes(t *testing.T) {
+	if got := run(t, Full, turboRun, 1); got != turboRun {
+		t.Errorf("full changed the output:\n%s", got)
+	}
+
+	want := `web:build: vite v5 building for production...
+web:build: src/main.ts(3,1): error TS2304: Cannot find name 'x'.
+web:build: ERROR: command finished with error: command exited (2)
+web:build: after the error
+`
+	if got := run(t, ErrorsOnly, turboRun, 1); got != "web:build: cache miss, executing 1a2b\n"+want {
+		t.Errorf("errors-only:\n%s", got)
+	}
+
+	summary := `• Packages in scope: web, server
+web:build: cache miss, executing 1a2b
+server:build: cache hit, replaying logs 3c4d
+` + want + ` Tasks:    1 successful, 2 total
+`
+	if got := run(t, Summary, turboRun, 1); got != summary {
+		t.Errorf("summary:\n%s", got)
+	}
+}
+
+func TestCloseFailsLastStep(t *testing.T) {
+	input := "Building...\n$ bun build server.ts\nbundled\n$ cp -r web dist\ncp: web: No such file\n"
+	if got := run(t, ErrorsOnly, input, 0); got != "" {
+		t.Errorf("a successful run showed %q", got)
+	}
+	if got, want := run(t, ErrorsOnly, input, 1), "Building...\n$ cp -r web dist\ncp: web: No such file\n"; got != want {
+		t.Errorf("errors-only = %q, want %q", got, want)
+	}
+	if got, want := run(t, Summary, input, 0), "Building...\n$ bun build server.ts\n$ cp -r web dist\n"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+}
+
+func TestFailAndBufferLimit(t *testing.T) {
+	var out, errOut strings.Builder
+	m := New(ErrorsOnly, "")
+	for i := range MaxBuffered + 3 {
+		m.Line("web:dev", &out, fmt.Sprint(i))
+	}
+	m.Line("web:dev", &errOut, "boom")
+	m.Fail("web:dev")
+	m.Line("web:dev", &errOut, "later")
+
+	lines := strings.Split(out.String(), "\n")
+	if lines[0] != "… 4 earlier lines of web:dev not shown" || lines[1] != "4" {
+		t.Errorf("unexpected start of output: %q", lines[:2])
+	}
+	if errOut.String() != "boom\nlater\n" {
+		t.Errorf("stderr lines = %q", errOut.String())
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if m, err := ParseMode(""); err != nil || m != Full {
+		t.Errorf(`ParseMode("") = %q, %v`, m, err)
+	}
+	if m, err := ParseMode("errors-only"); err != nil || m != ErrorsOnly {
+		t.Errorf("ParseMode(errors-only) = %q, %v", m, err)
+	}
+	if _, err := ParseMode("quiet"); err == nil {
+		t.Error("an unknown mode was accepted")
+	}
+}
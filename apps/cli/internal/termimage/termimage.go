@@ -0,0 +1,245 @@
+// Package termimage shows images in the terminal with the kitty, iTerm2
+// or sixel graphics protocols, and describes them in a line of text where
+// the terminal has none of them.
+package termimage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Protocol is a way of drawing images in a terminal.
+type Protocol string
+
+const (
+	None   Protocol = "none"
+	Kitty  Protocol = "kitty"
+	ITerm2 Protocol = "iterm2"
+	Sixel  Protocol = "sixel"
+)
+
+// Detect guesses the protocol of the terminal ellie runs in from its
+// environment. Inside tmux and screen it returns None: they drop graphics
+// unless passthrough is set up, so a protocol must be chosen explicitly.
+func Detect(getenv func(string) string) Protocol {
+	term, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	switch {
+	case getenv("TMUX") != "" || strings.HasPrefix(term, "screen") || strings.HasPrefix(term, "tmux"):
+		return None
+	case term == "xterm-kitty" || getenv("KITTY_WINDOW_ID") != "" ||
+		term == "xterm-ghostty" || program == "ghostty":
+		return Kitty
+	case program == "iTerm.app" || getenv("LC_TERMINAL") == "iTerm2" ||
+		program == "WezTerm" || program == "mintty":
+		return ITerm2
+	case strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "mlterm") ||
+		strings.Contains(term, "sixel"):
+		return Sixel
+	}
+	return None
+}
+
+// Info is what the header of an image tells without decoding it.
+type Info struct {
+	Format        string // "png", "jpeg" or "gif"
+	Width, Height int
+}
+
+// String describes the image as e.g. "1024×768 PNG".
+func (i Info) String() string {
+	return fmt.Sprintf("%d×%d %s", i.Width, i.Height, strings.ToUpper(i.Format))
+}
+
+// Describe reads the dimensions and format of an image from its header.
+func Describe(r io.Reader) (Info, error) {
+	cfg, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{Format: format, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// cellWidth is the width of a terminal cell in pixels assumed when sizing
+// images; terminals don't report it without a round trip.
+const cellWidth = 10
+
+// Render draws the image in data at the cursor, at most cols cells wide
+// and never scaled up, and moves the cursor below it.
+func Render(w io.Writer, data []byte, p Protocol, cols int) error {
+	info, err := Describe(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	cols = max(1, min(cols, (info.Width+cellWidth-1)/cellWidth))
+
+	switch p {
+	case Kitty:
+		if info.Format != "png" {
+			if data, err = toPNG(data); err != nil {
+				return err
+			}
+		}
+		err = writeKitty(w, data, cols)
+	case ITerm2:
+		_, err = fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;width=%d;preserveAspectRatio=1:%s\a\n",
+			len(data), cols, base64.StdEncoding.EncodeToString(data))
+	case Sixel:
+		var img image.Image
+		if img, _, err = image.Decode(bytes.NewReader(data)); err != nil {
+			return err
+		}
+		if err = writeSixel(w, scale(img, cols*cellWidth)); err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "\n")
+	default:
+		return fmt.Errorf("no graphics protocol")
+	}
+	return err
+}
+
+// kittyChunk is the most base64 the kitty protocol takes in one escape.
+const kittyChunk = 4096
+
+// writeKitty transmits a PNG in chunks and displays it cols cells wide;
+// q=2 keeps the terminal from answering on standard input.
+func writeKitty(w io.Writer, png []byte, cols int) error {
+	enc := base64.StdEncoding.EncodeToString(png)
+	for first := true; first || enc != ""; first = false {
+		chunk := enc[:min(len(enc), kittyChunk)]
+		enc = enc[len(chunk):]
+		more := 0
+		if enc != "" {
+			more = 1
+		}
+		ctrl := fmt.Sprintf("m=%d", more)
+		if first {
+			ctrl = fmt.Sprintf("a=T,f=100,q=2,c=%d,%s", cols, ctrl)
+		}
+		if _, err := fmt.Fprintf(w, "\x1b_G%s;%s\x1b\\", ctrl, chunk); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func toPNG(data []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// scale shrinks img to width pixels, keeping its aspect ratio, by
+// nearest-neighbour sampling; an image no wider is returned as is.
+func scale(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= width {
+		return img
+	}
+	height := max(1, b.Dy()*width/b.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			dst.Set(x, y, img.At(b.Min.X+x*b.Dx()/width, b.Min.Y+y*b.Dy()/height))
+		}
+	}
+	return dst
+}
+
+// sixelPalette is the 6×6×6 colour cube; the image is dithered to it.
+var sixelPalette = func() color.Palette {
+	p := make(color.Palette, 0, 216)
+	for r := range 6 {
+		for g := range 6 {
+			for b := range 6 {
+				p = append(p, color.RGBA{uint8(r * 51), uint8(g * 51), uint8(b * 51), 0xff})
+			}
+		}
+	}
+	return p
+}()
+
+// writeSixel encodes img as sixels: bands six pixels high, each drawn
+// once per colour it uses, with runs of the same sixel compressed.
+// Transparent pixels are left undrawn.
+func writeSixel(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	pal := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), sixelPalette)
+	draw.FloydSteinberg.Draw(pal, pal.Bounds(), img, b.Min)
+	opaque := func(x, y int) bool {
+		_, _, _, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+		return a >= 0x8000
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "\x1bPq\"1;1;%d;%d", b.Dx(), b.Dy())
+	for i, c := range sixelPalette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&out, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+	row := make([]byte, b.Dx())
+	for top := 0; top < b.Dy(); top += 6 {
+		used := map[uint8]bool{}
+		for y := top; y < min(top+6, b.Dy()); y++ {
+			for x := range b.Dx() {
+				if opaque(x, y) {
+					used[pal.ColorIndexAt(x, y)] = true
+				}
+			}
+		}
+		for c := range len(sixelPalette) {
+			if !used[uint8(c)] {
+				continue
+			}
+			for x := range row {
+				bits := byte(0)
+				for dy := range 6 {
+					y := top + dy
+					if y < b.Dy() && pal.ColorIndexAt(x, y) == uint8(c) && opaque(x, y) {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = '?' + bits
+			}
+			fmt.Fprintf(&out, "#%d", c)
+			writeRuns(&out, row)
+			out.WriteByte('$')
+		}
+		out.WriteByte('-')
+	}
+	out.WriteString("\x1b\\")
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// writeRuns writes sixels, compressing runs of more than three as !n.
+func writeRuns(out *strings.Builder, row []byte) {
+	for i := 0; i < len(row); {
+		j := i
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(out, "!%d%c", n, row[i])
+		} else {
+			out.Write(row[i:j])
+		}
+		i = j
+	}
+}
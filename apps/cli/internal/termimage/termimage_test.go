@@ -0,0 +1,96 @@
+package termimage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := range w {
+		img.Set(x, 0, color.RGBA{0xff, 0, 0, 0xff})
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	for _, tt := range []struct {
+		env  map[string]string
+		want Protocol
+	}{
+		{map[string]string{"TERM": "xterm-kitty"}, Kitty},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "ghostty"}, Kitty},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, ITerm2},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "WezTerm"}, ITerm2},
+		{map[string]string{"TERM": "foot"}, Sixel},
+		{map[string]string{"TERM": "xterm-kitty", "TMUX": "/tmp/tmux-1000/default,1,0"}, None},
+		{map[string]string{"TERM": "xterm-256color"}, None},
+	} {
+		if got := Detect(func(k string) string { return tt.env[k] }); got != tt.want {
+			t.Errorf("Detect(%v) = %s, want %s", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	info, err := Describe(bytes.NewReader(testPNG(t, 40, 30)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.String(); got != "40×30 PNG" {
+		t.Errorf("Describe = %q", got)
+	}
+	if _, err := Describe(strings.NewReader("RIFF....WEBP")); err == nil {
+		t.Error("an unknown format was described")
+	}
+}
+
+func TestRender(t *testing.T) {
+	data := testPNG(t, 3000, 20)
+
+	var b bytes.Buffer
+	if err := Render(&b, data, Kitty, 40); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if !strings.HasPrefix(out, "\x1b_Ga=T,f=100,q=2,c=40,m=") || strings.Count(out, "m=0;") != 1 {
+		t.Errorf("kitty: unexpected framing %q…", out[:min(len(out), 60)])
+	}
+
+	b.Reset()
+	if err := Render(&b, testPNG(t, 20, 20), ITerm2, 40); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), ";width=2;") {
+		t.Errorf("iterm2: small image was scaled up: %q", b.String()[:40])
+	}
+
+	b.Reset()
+	if err := Render(&b, data, Sixel, 40); err != nil {
+		t.Fatal(err)
+	}
+	if out := b.String(); !strings.HasPrefix(out, "\x1bPq\"1;1;400;2#") || !strings.HasSuffix(out, "-\x1b\\\n") {
+		t.Errorf("sixel: unexpected framing %q", out[:min(len(out), 40)])
+	}
+
+	if err := Render(&b, data, None, 40); err == nil {
+		t.Error("rendered without a protocol")
+	}
+}
+
+func TestWriteRuns(t *testing.T) {
+	var b strings.Builder
+	writeRuns(&b, []byte("??~~~~~@@@"))
+	if got := b.String(); got != "??!5~@@@" {
+		t.Errorf("writeRuns = %q", got)
+	}
+}
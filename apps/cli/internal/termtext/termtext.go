@@ -0,0 +1,65 @@
+// Package termtext turns what a command wrote to a terminal into plain
+// text, and splits long text into pieces that fit a model's prompt.
+package termtext
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Clean strips ANSI escapes from s and applies what a terminal would do
+// with carriage returns and backspaces, so a progress bar redrawn in
+// place leaves only its last state.
+func Clean(s string) string {
+	lines := strings.Split(strings.ReplaceAll(ansi.Strip(s), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if j := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); j >= 0 {
+			line = line[j+1:]
+		}
+		line = strings.TrimRight(line, "\r")
+		if strings.Contains(line, "\b") {
+			var b []rune
+			for _, r := range line {
+				if r == '\b' {
+					if len(b) > 0 {
+						b = b[:len(b)-1]
+					}
+					continue
+				}
+				b = append(b, r)
+			}
+			line = string(b)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Chunk splits s into pieces of at most max bytes, between lines where
+// it can: only a line longer than max is cut.
+func Chunk(s string, max int) []string {
+	if max <= 0 || len(s) <= max {
+		return []string{s}
+	}
+	var chunks []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			chunks = append(chunks, strings.TrimSuffix(cur.String(), "\n"))
+			cur.Reset()
+		}
+	}
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if cur.Len()+len(line) > max {
+			flush()
+		}
+		for len(line) > max {
+			chunks = append(chunks, line[:max])
+			line = line[max:]
+		}
+		cur.WriteString(line)
+	}
+	flush()
+	return chunks
+}
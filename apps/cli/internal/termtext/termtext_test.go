@@ -0,0 +1,35 @@
+package termtext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	for in, want := range map[string]string{
+		"\x1b[31mFAIL\x1b[0m src/a.test.ts":         "FAIL src/a.test.ts",
+		"progress 10%\rprogress 50%\rprogress 100%": "progress 100%",
+		"windows line\r\nnext":                      "windows line\nnext",
+		"typo\b\b\bext":                             "text",
+	} {
+		if got := Clean(in); got != want {
+			t.Errorf("Clean(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestChunk(t *testing.T) {
+	for _, c := range []struct {
+		in   string
+		max  int
+		want []string
+	}{
+		{"short", 10, []string{"short"}},
+		{"aaa\nbbb\nccc\n", 8, []string{"aaa\nbbb", "ccc"}},
+		{"aaa\nbbbbbbbbbbbb\nc", 5, []string{"aaa", "bbbbb", "bbbbb", "bb\nc"}},
+	} {
+		if got := Chunk(c.in, c.max); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Chunk(%q, %d) = %q, want %q", c.in, c.max, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package themes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxThemeSize bounds a downloaded theme file.
+const maxThemeSize = 64 << 10
+
+// IsURL reports whether ref names a theme by URL rather than by name.
+func IsURL(ref string) bool {
+	return strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://")
+}
+
+// Fetch returns the theme at url. A copy fetched less than maxAge ago is
+// used from the cache in dir as is; an older one is revalidated with its
+// ETag, and used anyway if the server can't be reached.
+func Fetch(ctx context.Context, client *http.Client, url, dir string, maxAge time.Duration) (Theme, error) {
+	sum := sha256.Sum256([]byte(url))
+	base := filepath.Join(dir, hex.EncodeToString(sum[:8]))
+	cached, cacheErr := os.ReadFile(base + ".json")
+	if cacheErr == nil {
+		if info, err := os.Stat(base + ".json"); err == nil && time.Since(info.ModTime()) < maxAge {
+			return parseFetched(url, cached)
+		}
+	}
+
+	data, etag, err := download(ctx, client, url, cached, base+".etag")
+	switch {
+	case err != nil && cacheErr == nil:
+		return parseFetched(url, cached)
+	case err != nil:
+		return Theme{}, err
+	case data == nil: // not modified
+		now := time.Now()
+		os.Chtimes(base+".json", now, now)
+		return parseFetched(url, cached)
+	}
+
+	t, err := parseFetched(url, data)
+	if err != nil {
+		return Theme{}, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err == nil && writeFileAtomic(base+".json", data) == nil {
+		if etag != "" {
+			os.WriteFile(base+".etag", []byte(etag), 0o644)
+		} else {
+			os.Remove(base + ".etag")
+		}
+	}
+	return t, nil
+}
+
+// download gets url, sending the stored ETag when there is a cached copy.
+// It returns nil data when the server says the copy is current.
+func download(ctx context.Context, client *http.Client, url string, cached []byte, etagPath string) (data []byte, etag string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if cached != nil {
+		if etag, err := os.ReadFile(etagPath); err == nil {
+			req.Header.Set("If-None-Match", strings.TrimSpace(string(etag)))
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot fetch theme: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return nil, "", nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("cannot fetch theme from %s: %s", url, resp.Status)
+	}
+	data, err = io.ReadAll(io.LimitReader(resp.Body, maxThemeSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot fetch theme: %w", err)
+	}
+	if len(data) > maxThemeSize {
+		return nil, "", errors.New("theme file is too large")
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+func parseFetched(url string, data []byte) (Theme, error) {
+	t, err := Parse(data)
+	if err != nil {
+		return Theme{}, fmt.Errorf("%s: %w", url, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,223 @@
+// Package themes reads, writes and fetches color themes for the chat
+// interface, so people can share them: a theme is a small JSON file that
+// starts from the built-in dark or light palette and replaces some of its
+// colors.
+package themes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Built-in themes, which every custom theme is based on.
+const (
+	Dark  = "dark"
+	Light = "light"
+)
+
+// ErrNotFound is returned by Load when no theme has the name.
+var ErrNotFound = errors.New("no such theme")
+
+// Theme is a named palette.
+type Theme struct {
+	Name   string `json:"name"`
+	Base   string `json:"base"` // Dark or Light
+	Colors Colors `json:"colors"`
+}
+
+// Colors are the palette's colors as "#rrggbb". Empty ones keep the base
+// theme's color.
+type Colors struct {
+	Accent     string `json:"accent,omitempty"`     // headings, the assistant, focus
+	User       string `json:"user,omitempty"`       // your messages
+	Memory     string `json:"memory,omitempty"`     // recalled memories
+	System     string `json:"system,omitempty"`     // system notices
+	Muted      string `json:"muted,omitempty"`      // labels, thinking
+	Dim        string `json:"dim,omitempty"`        // tool results, status line
+	Subtle     string `json:"subtle,omitempty"`     // borders, scrollbar
+	Surface    string `json:"surface,omitempty"`    // input border
+	Background string `json:"background,omitempty"` // terminal background
+}
+
+// each calls f with every color's name and a pointer to it.
+func (c *Colors) each(f func(name string, v *string)) {
+	f("accent", &c.Accent)
+	f("user", &c.User)
+	f("memory", &c.Memory)
+	f("system", &c.System)
+	f("muted", &c.Muted)
+	f("dim", &c.Dim)
+	f("subtle", &c.Subtle)
+	f("surface", &c.Surface)
+	f("background", &c.Background)
+}
+
+// Merge returns c with the colors set in over replacing its own.
+func (c Colors) Merge(over Colors) Colors {
+	vals := map[string]string{}
+	over.each(func(name string, v *string) { vals[name] = *v })
+	c.each(func(name string, v *string) {
+		if vals[name] != "" {
+			*v = vals[name]
+		}
+	})
+	return c
+}
+
+// Validate checks the name, base and colors.
+func (t Theme) Validate() error {
+	if err := ValidName(t.Name); err != nil {
+		return err
+	}
+	if t.Base != Dark && t.Base != Light {
+		return fmt.Errorf("theme %s: base must be %s or %s, not %q", t.Name, Dark, Light, t.Base)
+	}
+	var err error
+	t.Colors.each(func(name string, v *string) {
+		if err == nil && *v != "" && !isHexColor(*v) {
+			err = fmt.Errorf("theme %s: %s must be a color like #1a2b3c, not %q", t.Name, name, *v)
+		}
+	})
+	return err
+}
+
+// ValidName checks that name can be a theme's name: letters, digits, "-"
+// and "_", and not one of the built-in themes.
+func ValidName(name string) error {
+	if name == Dark || name == Light {
+		return fmt.Errorf("%q is a built-in theme — choose another name", name)
+	}
+	if name == "" {
+		return errors.New("theme has no name")
+	}
+	for _, r := range name {
+		switch {
+		case r == '-', r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		default:
+			return fmt.Errorf("theme name %q may only have letters, digits, - and _", name)
+		}
+	}
+	return nil
+}
+
+func isHexColor(s string) bool {
+	if len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	for _, r := range strings.ToLower(s[1:]) {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse reads and validates a theme file.
+func Parse(data []byte) (Theme, error) {
+	var t Theme
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&t); err != nil {
+		return Theme{}, fmt.Errorf("not a theme file: %w", err)
+	}
+	return t, t.Validate()
+}
+
+// Marshal formats a theme file.
+func Marshal(t Theme) ([]byte, error) {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Store keeps imported themes in a directory, one <name>.json each.
+type Store struct {
+	Dir string
+}
+
+func (s Store) path(name string) string {
+	return filepath.Join(s.Dir, name+".json")
+}
+
+// Save writes t, replacing any theme with the same name.
+func (s Store) Save(t Theme) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	data, err := Marshal(t)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path(t.Name), data)
+}
+
+// Load reads the theme called name.
+func (s Store) Load(name string) (Theme, error) {
+	if err := ValidName(name); err != nil {
+		return Theme{}, err
+	}
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return Theme{}, fmt.Errorf("%w %q", ErrNotFound, name)
+	}
+	if err != nil {
+		return Theme{}, err
+	}
+	t, err := Parse(data)
+	if err != nil {
+		return Theme{}, fmt.Errorf("%s: %w", s.path(name), err)
+	}
+	return t, nil
+}
+
+// Exists reports whether a theme called name is stored.
+func (s Store) Exists(name string) bool {
+	_, err := os.Stat(s.path(name))
+	return err == nil
+}
+
+// List returns the names of the stored themes, sorted.
+func (s Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
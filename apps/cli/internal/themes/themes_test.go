@@ -0,0 +1,118 @@
+package themes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const oceanJSON = `{"name": "ocean", "base": "dark", "colors": {"accent": "#1E90FF", "background": "#001020"}}`
+
+func TestParse(t *testing.T) {
+	th, err := Parse([]byte(oceanJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.Name != "ocean" || th.Colors.Accent != "#1E90FF" || th.Colors.User != "" {
+		t.Errorf("Parse = %+v", th)
+	}
+
+	for _, tt := range []struct{ data, want string }{
+		{`{"name": "dark", "base": "dark"}`, "built-in theme"},
+		{`{"name": "my theme", "base": "dark"}`, "may only have"},
+		{`{"name": "x", "base": "blue"}`, "base must be"},
+		{`{"name": "x", "base": "light", "colors": {"user": "blue"}}`, "user must be a color"},
+		{`{"name": "x", "base": "light", "colours": {}}`, "not a theme file"},
+	} {
+		if _, err := Parse([]byte(tt.data)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%s) = %v, want %q", tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := Colors{Accent: "#000000", User: "#111111"}.Merge(Colors{User: "#222222"})
+	if got != (Colors{Accent: "#000000", User: "#222222"}) {
+		t.Errorf("Merge = %+v", got)
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := Store{Dir: filepath.Join(t.TempDir(), "themes")}
+	if names, err := s.List(); err != nil || len(names) != 0 {
+		t.Fatalf("List = %v, %v", names, err)
+	}
+	th, _ := Parse([]byte(oceanJSON))
+	if err := s.Save(th); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Load("ocean")
+	if err != nil || got != th {
+		t.Errorf("Load = %+v, %v", got, err)
+	}
+	if names, _ := s.List(); len(names) != 1 || names[0] != "ocean" {
+		t.Errorf("List = %v", names)
+	}
+	if _, err := s.Load("reef"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load missing = %v", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	var hits, notModified int
+	up := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		hits++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(oceanJSON))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	fetch := func(maxAge time.Duration) Theme {
+		t.Helper()
+		th, err := Fetch(ctx, srv.Client(), srv.URL+"/ocean.json", dir, maxAge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if th.Name != "ocean" {
+			t.Fatalf("theme = %+v", th)
+		}
+		return th
+	}
+	fetch(time.Hour)
+	fetch(time.Hour)
+	if hits != 1 {
+		t.Errorf("fresh cache not used: %d requests", hits)
+	}
+	fetch(0)
+	if hits != 2 || notModified != 1 {
+		t.Errorf("stale cache not revalidated: %d requests, %d not modified", hits, notModified)
+	}
+	up = false
+	fetch(0)
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("cache = %v", entries)
+	}
+	if _, err := Fetch(ctx, srv.Client(), srv.URL+"/other.json", dir, time.Hour); err == nil {
+		t.Error("uncached theme from a failing server succeeded")
+	}
+}
@@ -0,0 +1,81 @@
+// Package triage recognizes why a server that failed to start did so,
+// from the last lines of its output.
+package triage
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Kinds of startup failure.
+const (
+	PortInUse  = "port-in-use"
+	MissingEnv = "missing-env"
+	Migration  = "migration"
+)
+
+// Finding is a recognized failure.
+type Finding struct {
+	Kind string
+	Line string // the output line it was recognized in
+	Port int    // for PortInUse, when the line names it
+	Var  string // for MissingEnv
+}
+
+// Summary describes f in a sentence.
+func (f Finding) Summary() string {
+	switch f.Kind {
+	case PortInUse:
+		if f.Port != 0 {
+			return "Port " + strconv.Itoa(f.Port) + " is already in use"
+		}
+		return "The server's port is already in use"
+	case MissingEnv:
+		return "The environment variable " + f.Var + " is not set"
+	case Migration:
+		return "The database migrations failed or are pending"
+	}
+	return f.Line
+}
+
+var (
+	portInUse = regexp.MustCompile(`(?i)EADDRINUSE|address already in use|is port \d+ in use`)
+	portNum   = regexp.MustCompile(`(?i)(?:port |:)(\d{2,5})\b`)
+
+	// "Missing environment variable: FOO", "missing required env var FOO"
+	missingEnvBefore = regexp.MustCompile(`(?i)(?:missing|required|undefined)\s+(?:required\s+)?env(?:ironment)?(?:\s+var(?:iable)?)?s?[\s:"'=]+([A-Z][A-Z0-9_]{2,})\b`)
+	// "FOO is not set", "FOO must be set", "FOO is required"
+	missingEnvAfter = regexp.MustCompile(`\b([A-Z][A-Z0-9_]{2,})\b["']?\s+(?:is not set|must be set|is required|is missing|is not defined)`)
+
+	migration = regexp.MustCompile(`(?i)migrations? (?:failed|error|pending)|failed to (?:run|apply) migrations?|pending migrations?|no such (?:table|column)|DrizzleError`)
+)
+
+// Classify returns the failures recognized in lines, one per kind, in the
+// order they first appear.
+func Classify(lines []string) []Finding {
+	var found []Finding
+	seen := map[string]bool{}
+	add := func(f Finding) {
+		if !seen[f.Kind] {
+			seen[f.Kind] = true
+			found = append(found, f)
+		}
+	}
+	for _, line := range lines {
+		switch {
+		case portInUse.MatchString(line):
+			f := Finding{Kind: PortInUse, Line: line}
+			if m := portNum.FindAllStringSubmatch(line, -1); m != nil {
+				f.Port, _ = strconv.Atoi(m[len(m)-1][1])
+			}
+			add(f)
+		case missingEnvBefore.MatchString(line):
+			add(Finding{Kind: MissingEnv, Line: line, Var: missingEnvBefore.FindStringSubmatch(line)[1]})
+		case missingEnvAfter.MatchString(line):
+			add(Finding{Kind: MissingEnv, Line: line, Var: missingEnvAfter.FindStringSubmatch(line)[1]})
+		case migration.MatchString(line):
+			add(Finding{Kind: Migration, Line: line})
+		}
+	}
+	return found
+}
@@ -0,0 +1,41 @@
+package triage
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		line string
+		want Finding
+	}{
+		{"error: Failed to start server. Is port 3000 in use?", Finding{Kind: PortInUse, Port: 3000}},
+		{"Error: listen EADDRINUSE: address already in use :::3001", Finding{Kind: PortInUse, Port: 3001}},
+		{"Error: Missing environment variable: ANTHROPIC_API_KEY", Finding{Kind: MissingEnv, Var: "ANTHROPIC_API_KEY"}},
+		{"missing required env var DATA_DIR", Finding{Kind: MissingEnv, Var: "DATA_DIR"}},
+		{"CREDENTIALS_PATH is not set", Finding{Kind: MissingEnv, Var: "CREDENTIALS_PATH"}},
+		{"SQLiteError: no such table: events", Finding{Kind: Migration}},
+		{"DrizzleError: Failed to run the query", Finding{Kind: Migration}},
+	}
+	for _, c := range cases {
+		got := Classify([]string{"starting", c.line})
+		if len(got) != 1 {
+			t.Errorf("Classify(%q) = %+v", c.line, got)
+			continue
+		}
+		c.want.Line = c.line
+		if got[0] != c.want {
+			t.Errorf("Classify(%q) = %+v, want %+v", c.line, got[0], c.want)
+		}
+	}
+
+	if got := Classify([]string{"listening on :3000", "ERROR something else"}); len(got) != 0 {
+		t.Errorf("unrecognized output classified as %+v", got)
+	}
+	got := Classify([]string{
+		"no such table: events",
+		"Is port 3000 in use?",
+		"no such column: x",
+	})
+	if len(got) != 2 || got[0].Kind != Migration || got[1].Kind != PortInUse {
+		t.Errorf("Classify kept %+v", got)
+	}
+}
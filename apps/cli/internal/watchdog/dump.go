@@ -0,0 +1,130 @@
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Dump modes.
+const (
+	DumpNone   = "none"
+	DumpStacks = "stacks" // process tree, thread states and kernel stacks where readable
+	DumpCore   = "core"   // stacks plus a core file of each process, via gcore
+)
+
+// Dump records the state of pid and its descendants in dir, which it
+// creates, and returns the files written. What can be captured depends
+// on the platform and permissions; anything unavailable is skipped.
+func Dump(ctx context.Context, dir string, pid int, mode string) ([]string, error) {
+	if mode == DumpNone {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	procs := tree(int32(pid))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# process tree of %d at %s\n\n", pid, time.Now().Format(time.RFC3339))
+	for _, p := range procs {
+		describe(&b, p)
+	}
+	summary := filepath.Join(dir, "processes.txt")
+	if err := os.WriteFile(summary, []byte(b.String()), 0o600); err != nil {
+		return nil, err
+	}
+	files := []string{summary}
+
+	if mode == DumpCore {
+		gcore, err := exec.LookPath("gcore")
+		if err != nil {
+			return files, fmt.Errorf("core dumps need gcore (from gdb) on PATH")
+		}
+		for _, p := range procs {
+			prefix := filepath.Join(dir, "core")
+			cmd := exec.CommandContext(ctx, gcore, "-o", prefix, fmt.Sprint(p.Pid))
+			if err := cmd.Run(); err == nil {
+				files = append(files, fmt.Sprintf("%s.%d", prefix, p.Pid))
+			}
+		}
+	}
+	return files, nil
+}
+
+// Tree returns pid and the pids of its descendants, parents first.
+func Tree(pid int) []int {
+	var pids []int
+	for _, p := range tree(int32(pid)) {
+		pids = append(pids, int(p.Pid))
+	}
+	return pids
+}
+
+// tree returns pid and its descendants, parents first.
+func tree(pid int32) []*process.Process {
+	root, err := process.NewProcess(pid)
+	if err != nil {
+		return nil
+	}
+	out := []*process.Process{root}
+	for i := 0; i < len(out); i++ {
+		children, _ := out[i].Children()
+		out = append(out, children...)
+	}
+	return out
+}
+
+func describe(b *strings.Builder, p *process.Process) {
+	name, _ := p.Name()
+	ppid, _ := p.Ppid()
+	status, _ := p.Status()
+	threads, _ := p.NumThreads()
+	cmdline, _ := p.Cmdline()
+	fmt.Fprintf(b, "pid %d (%s) ppid %d status %s threads %d\n", p.Pid, name, ppid, strings.Join(status, ","), threads)
+	fmt.Fprintf(b, "  cmd: %s\n", cmdline)
+	if t, err := p.Times(); err == nil {
+		fmt.Fprintf(b, "  cpu: user %.2fs system %.2fs\n", t.User, t.System)
+	}
+	if m, err := p.MemoryInfo(); err == nil {
+		fmt.Fprintf(b, "  rss: %d bytes\n", m.RSS)
+	}
+	if runtime.GOOS == "linux" {
+		describeThreads(b, p.Pid)
+	}
+	b.WriteString("\n")
+}
+
+// describeThreads adds each thread's state, what it is blocked in and,
+// when readable (usually root only), its kernel stack.
+func describeThreads(b *strings.Builder, pid int32) {
+	tasks, err := os.ReadDir(fmt.Sprintf("/proc/%d/task", pid))
+	if err != nil {
+		return
+	}
+	for _, t := range tasks {
+		dir := fmt.Sprintf("/proc/%d/task/%s", pid, t.Name())
+		comm, _ := os.ReadFile(dir + "/comm")
+		wchan, _ := os.ReadFile(dir + "/wchan")
+		state := ""
+		if stat, err := os.ReadFile(dir + "/stat"); err == nil {
+			// The state follows the parenthesised command name.
+			if i := strings.LastIndexByte(string(stat), ')'); i >= 0 && i+2 < len(stat) {
+				state = string(stat[i+2])
+			}
+		}
+		fmt.Fprintf(b, "  thread %s (%s) state %s wchan %s\n", t.Name(), strings.TrimSpace(string(comm)), state, strings.TrimSpace(string(wchan)))
+		if stack, err := os.ReadFile(dir + "/stack"); err == nil && len(stack) > 0 {
+			for _, line := range strings.Split(strings.TrimSpace(string(stack)), "\n") {
+				fmt.Fprintf(b, "    %s\n", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Package watchdog notices a child process that has hung: it has written
+// no output and failed every health check for a while.
+package watchdog
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Watchdog tracks signs of life from a child process. Output written
+// through Writer and successful health checks both count.
+type Watchdog struct {
+	Timeout  time.Duration
+	Interval time.Duration              // how often to check; Timeout/10 when zero
+	Healthy  func(context.Context) bool // nil to go by output alone
+
+	mu       sync.Mutex
+	last     time.Time
+	reported time.Time
+}
+
+// New returns a watchdog that considers the child hung after timeout
+// without a sign of life.
+func New(timeout time.Duration, healthy func(context.Context) bool) *Watchdog {
+	return &Watchdog{Timeout: timeout, Healthy: healthy, last: time.Now()}
+}
+
+// Touch records a sign of life.
+func (w *Watchdog) Touch() {
+	w.mu.Lock()
+	w.last = time.Now()
+	w.mu.Unlock()
+}
+
+// Writer returns dst wrapped so every write is a sign of life.
+func (w *Watchdog) Writer(dst io.Writer) io.Writer {
+	return activityWriter{dst: dst, w: w}
+}
+
+type activityWriter struct {
+	dst io.Writer
+	w   *Watchdog
+}
+
+func (a activityWriter) Write(p []byte) (int, error) {
+	a.w.Touch()
+	return a.dst.Write(p)
+}
+
+// Wait blocks until the child has been quiet for Timeout and returns how
+// long that was, or returns ctx's error. Each hang is reported once: a
+// later Wait needs a new sign of life before it can fire again.
+func (w *Watchdog) Wait(ctx context.Context) (time.Duration, error) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = max(w.Timeout/10, 10*time.Millisecond)
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-t.C:
+		}
+		if w.Healthy != nil {
+			checkCtx, cancel := context.WithTimeout(ctx, max(interval, 2*time.Second))
+			ok := w.Healthy(checkCtx)
+			cancel()
+			if ok {
+				w.Touch()
+				continue
+			}
+		}
+		w.mu.Lock()
+		quiet := time.Since(w.last)
+		fire := quiet >= w.Timeout && !w.last.Equal(w.reported)
+		if fire {
+			w.reported = w.last
+		}
+		w.mu.Unlock()
+		if fire {
+			return quiet, nil
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitFiresWhenQuiet(t *testing.T) {
+	w := New(50*time.Millisecond, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	quiet, err := w.Wait(ctx)
+	if err != nil || quiet < 50*time.Millisecond {
+		t.Fatalf("Wait = %v, %v", quiet, err)
+	}
+
+	// The same hang is not reported twice...
+	short, cancel2 := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel2()
+	if _, err := w.Wait(short); err == nil {
+		t.Fatal("Wait fired again without new activity")
+	}
+	// ...but a new one after some output is.
+	w.Writer(io.Discard).Write([]byte("x"))
+	if _, err := w.Wait(ctx); err != nil {
+		t.Fatalf("Wait after activity: %v", err)
+	}
+}
+
+func TestOutputAndHealthKeepItAlive(t *testing.T) {
+	w := New(80*time.Millisecond, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	go func() {
+		out := w.Writer(io.Discard)
+		for ctx.Err() == nil {
+			out.Write([]byte("tick"))
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	if _, err := w.Wait(ctx); err == nil {
+		t.Error("fired while the child was writing output")
+	}
+
+	var checks atomic.Int32
+	w = New(80*time.Millisecond, func(context.Context) bool { checks.Add(1); return true })
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel2()
+	if _, err := w.Wait(ctx2); err == nil {
+		t.Error("fired while health checks passed")
+	}
+	if checks.Load() == 0 {
+		t.Error("health check never ran")
+	}
+}
+
+func TestDumpSelf(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dump")
+	files, err := Dump(context.Background(), dir, os.Getpid(), DumpStacks)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Dump = %v, %v", files, err)
+	}
+	data, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(data), fmt.Sprintf("pid %d ", os.Getpid())) {
+		t.Errorf("dump does not describe this process:\n%s", data)
+	}
+	if files, err := Dump(context.Background(), dir, os.Getpid(), DumpNone); files != nil || err != nil {
+		t.Errorf("DumpNone = %v, %v", files, err)
+	}
+}
@@ -0,0 +1,363 @@
+package workspace
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileEdit is a pending rewrite of one file.
+type FileEdit struct {
+	Path string // absolute path, as the file exists before the plan is applied
+	Old  string
+	New  string
+}
+
+// Plan is a rename and/or move of a workspace package with every
+// reference to it that has to change.
+type Plan struct {
+	Root    string
+	Package Package
+	NewName string
+	NewDir  string // new directory relative to the root, slash-separated
+	Edits   []FileEdit
+}
+
+// Moves reports whether the plan relocates the package directory.
+func (p *Plan) Moves() bool {
+	return p.NewDir != p.Package.RelDir
+}
+
+// Renames reports whether the plan changes the package name.
+func (p *Plan) Renames() bool {
+	return p.NewName != p.Package.Name
+}
+
+// sourceExts are the files whose import specifiers are rewritten.
+var sourceExts = map[string]bool{
+	".ts": true, ".tsx": true, ".mts": true, ".cts": true,
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".vue": true, ".svelte": true, ".astro": true,
+}
+
+// skipDirs are never descended into when scanning for references.
+var skipDirs = map[string]bool{
+	"node_modules": true, ".git": true, ".turbo": true, "dist": true, "build": true, ".next": true, "coverage": true,
+}
+
+// PlanRefactor works out every edit needed to give the package called name
+// the name newName and the directory newDir (relative to root). Either may
+// be left unchanged. Nothing is written until Apply.
+func PlanRefactor(root string, pkgs []Package, name, newName, newDir string) (*Plan, error) {
+	var pkg Package
+	found := false
+	for _, p := range pkgs {
+		if p.Name == name {
+			pkg, found = p, true
+		}
+		if newName != name && p.Name == newName {
+			return nil, fmt.Errorf("a package named %s already exists in %s", newName, p.RelDir)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no workspace package named %s", name)
+	}
+
+	newDir = path.Clean(filepath.ToSlash(newDir))
+	if newDir != pkg.RelDir {
+		if strings.HasPrefix(newDir, "../") || newDir == ".." || path.IsAbs(newDir) {
+			return nil, fmt.Errorf("%s is outside the monorepo", newDir)
+		}
+		if strings.HasPrefix(newDir+"/", pkg.RelDir+"/") {
+			return nil, fmt.Errorf("cannot move %s inside itself", pkg.RelDir)
+		}
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(newDir))); err == nil {
+			return nil, fmt.Errorf("%s already exists", newDir)
+		}
+		if !matchesWorkspaces(root, newDir) {
+			return nil, fmt.Errorf("%s is not covered by the root package.json workspaces", newDir)
+		}
+	}
+
+	plan := &Plan{Root: root, Package: pkg, NewName: newName, NewDir: newDir}
+	if !plan.Renames() && !plan.Moves() {
+		return nil, fmt.Errorf("nothing to do: %s is already named %s in %s", name, newName, newDir)
+	}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if skipDirs[d.Name()] && p != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		old := string(data)
+		updated := plan.rewrite(p, old)
+		if updated != old {
+			plan.Edits = append(plan.Edits, FileEdit{Path: p, Old: old, New: updated})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// rewrite returns content with references to the package updated for the
+// file at p.
+func (plan *Plan) rewrite(p, content string) string {
+	base := filepath.Base(p)
+	ext := filepath.Ext(p)
+	isPackageJSON := base == "package.json"
+	isTSConfig := strings.HasPrefix(base, "tsconfig") && ext == ".json"
+	isTurbo := base == "turbo.json"
+
+	if !isPackageJSON && !isTSConfig && !isTurbo && !sourceExts[ext] {
+		return content
+	}
+
+	out := content
+	if plan.Renames() {
+		old, renamed := plan.Package.Name, plan.NewName
+		if isPackageJSON && filepath.Dir(p) == plan.Package.Dir {
+			out = replaceNameField(out, old, renamed)
+		}
+		if isPackageJSON || isTSConfig {
+			out = replaceJSONKeys(out, old, renamed)
+		} else if sourceExts[ext] {
+			out = replaceSpecifiers(out, old, renamed)
+		}
+		if isPackageJSON || isTurbo {
+			out = replaceTaskRefs(out, old, renamed)
+			out = replaceFilters(out, old, renamed)
+		}
+	}
+	if plan.Moves() && isTSConfig {
+		out = plan.rewriteTSConfigPaths(p, out)
+	}
+	return out
+}
+
+var nameFieldRe = regexp.MustCompile(`("name"\s*:\s*")([^"]*)(")`)
+
+// replaceNameField rewrites the first "name" field, which is the package's own.
+func replaceNameField(content, old, renamed string) string {
+	loc := nameFieldRe.FindStringSubmatchIndex(content)
+	if loc == nil || content[loc[4]:loc[5]] != old {
+		return content
+	}
+	return content[:loc[4]] + renamed + content[loc[5]:]
+}
+
+// specifierRe matches the module specifier of an import or export ...
+// from, a side-effect import, and require() or import() of a string.
+var specifierRe = regexp.MustCompile("(\\b(?:import|export)\\b[^'\"`;]*?\\bfrom\\s*|\\bimport\\s*|\\b(?:require|import)\\s*\\(\\s*)(['\"`])([^'\"`\\s]+)(['\"`])")
+
+// jsonKeyRe matches a JSON object key, such as a dependency name or a
+// tsconfig path alias.
+var jsonKeyRe = regexp.MustCompile(`"([^"\s]+)"(\s*:)`)
+
+// replaceSpecifiers rewrites the module specifiers "old" and "old/sub" of
+// imports, exports and requires in a source file, leaving other strings
+// alone.
+func replaceSpecifiers(content, old, renamed string) string {
+	return specifierRe.ReplaceAllStringFunc(content, func(m string) string {
+		sub := specifierRe.FindStringSubmatch(m)
+		spec, ok := renameSpecifier(sub[3], old, renamed)
+		if !ok || sub[2] != sub[4] {
+			return m
+		}
+		return sub[1] + sub[2] + spec + sub[4]
+	})
+}
+
+// replaceJSONKeys rewrites the object keys "old" and "old/sub" of a
+// package.json or tsconfig.
+func replaceJSONKeys(content, old, renamed string) string {
+	return jsonKeyRe.ReplaceAllStringFunc(content, func(m string) string {
+		sub := jsonKeyRe.FindStringSubmatch(m)
+		key, ok := renameSpecifier(sub[1], old, renamed)
+		if !ok {
+			return m
+		}
+		return `"` + key + `"` + sub[2]
+	})
+}
+
+// renameSpecifier returns spec with the package old in it renamed, and
+// whether it names old at all.
+func renameSpecifier(spec, old, renamed string) (string, bool) {
+	if spec == old {
+		return renamed, true
+	}
+	if rest, ok := strings.CutPrefix(spec, old+"/"); ok {
+		return renamed + "/" + rest, true
+	}
+	return spec, false
+}
+
+// taskRefRe matches a turbo task reference such as "pkg#build" or
+// "^pkg#build".
+var taskRefRe = regexp.MustCompile(`(["^])([^"^#\s]+)#`)
+
+// replaceTaskRefs rewrites turbo task references ("old#build").
+func replaceTaskRefs(content, old, renamed string) string {
+	return taskRefRe.ReplaceAllStringFunc(content, func(m string) string {
+		sub := taskRefRe.FindStringSubmatch(m)
+		if sub[2] != old {
+			return m
+		}
+		return sub[1] + renamed + "#"
+	})
+}
+
+// filterRe matches a turbo --filter argument in a script: --filter=pkg,
+// --filter='!pkg', --filter pkg... and the like.
+var filterRe = regexp.MustCompile(`(--filter[= ]\\?['"]?!?)([^\s'"\\}!]+?)(\.\.\.|[\\'"\s}]|$)`)
+
+// replaceFilters rewrites turbo --filter arguments that select old.
+func replaceFilters(content, old, renamed string) string {
+	return filterRe.ReplaceAllStringFunc(content, func(m string) string {
+		sub := filterRe.FindStringSubmatch(m)
+		if sub[2] != old {
+			return m
+		}
+		return sub[1] + renamed + sub[3]
+	})
+}
+
+var jsonStringRe = regexp.MustCompile(`"((?:\.\.?/)[^"]*)"`)
+
+// rewriteTSConfigPaths fixes relative paths in a tsconfig after the move:
+// paths from other packages that point into the moved package, and paths
+// inside the moved package that point elsewhere.
+func (plan *Plan) rewriteTSConfigPaths(p, content string) string {
+	oldPkgDir := filepath.Join(plan.Root, filepath.FromSlash(plan.Package.RelDir))
+	newPkgDir := filepath.Join(plan.Root, filepath.FromSlash(plan.NewDir))
+
+	fileDir := filepath.Dir(p)
+	newFileDir := fileDir
+	if within(fileDir, oldPkgDir) {
+		rel, _ := filepath.Rel(oldPkgDir, fileDir)
+		newFileDir = filepath.Join(newPkgDir, rel)
+	}
+
+	return jsonStringRe.ReplaceAllStringFunc(content, func(m string) string {
+		ref := m[1 : len(m)-1]
+		target := filepath.Join(fileDir, filepath.FromSlash(ref))
+		if within(target, oldPkgDir) {
+			rel, _ := filepath.Rel(oldPkgDir, target)
+			target = filepath.Join(newPkgDir, rel)
+		}
+		if newFileDir == fileDir && target == filepath.Join(fileDir, filepath.FromSlash(ref)) {
+			return m
+		}
+		rel, err := filepath.Rel(newFileDir, target)
+		if err != nil {
+			return m
+		}
+		rel = filepath.ToSlash(rel)
+		if !strings.HasPrefix(rel, "../") && rel != ".." {
+			rel = "./" + rel
+		}
+		if strings.HasSuffix(ref, "/") && !strings.HasSuffix(rel, "/") {
+			rel += "/"
+		}
+		return `"` + rel + `"`
+	})
+}
+
+func within(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, dir+string(filepath.Separator))
+}
+
+// matchesWorkspaces reports whether relDir would be picked up by the
+// root package.json workspaces globs.
+func matchesWorkspaces(root, relDir string) bool {
+	rootPkg, err := readPackageJSON(filepath.Join(root, "package.json"))
+	if err != nil {
+		return false
+	}
+	for _, pattern := range rootPkg.Workspaces {
+		if ok, _ := path.Match(pattern, relDir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns a line diff of every edit, with paths relative to the root.
+// Edits only substitute within lines, so lines are compared pairwise.
+func (plan *Plan) Diff() string {
+	var b strings.Builder
+	for _, e := range plan.Edits {
+		rel, _ := filepath.Rel(plan.Root, e.Path)
+		fmt.Fprintf(&b, "--- %s\n+++ %s\n", filepath.ToSlash(rel), filepath.ToSlash(plan.newPath(e.Path, rel)))
+		oldLines := strings.Split(e.Old, "\n")
+		newLines := strings.Split(e.New, "\n")
+		for i := 0; i < len(oldLines) && i < len(newLines); i++ {
+			if oldLines[i] != newLines[i] {
+				fmt.Fprintf(&b, "@@ line %d @@\n-%s\n+%s\n", i+1, oldLines[i], newLines[i])
+			}
+		}
+	}
+	return b.String()
+}
+
+// newPath returns where a file ends up after the move.
+func (plan *Plan) newPath(abs, rel string) string {
+	if !plan.Moves() || !within(abs, plan.Package.Dir) {
+		return rel
+	}
+	inner, _ := filepath.Rel(plan.Package.Dir, abs)
+	return filepath.Join(filepath.FromSlash(plan.NewDir), inner)
+}
+
+// Apply writes every edit and then moves the package directory. If any
+// file changed since the plan was made, it writes nothing and returns an
+// error naming the first such file.
+func (plan *Plan) Apply() error {
+	for _, e := range plan.Edits {
+		current, err := os.ReadFile(e.Path)
+		if err != nil {
+			return err
+		}
+		if string(current) != e.Old {
+			return fmt.Errorf("%s changed since the preview — re-run the command", e.Path)
+		}
+	}
+	for _, e := range plan.Edits {
+		info, err := os.Stat(e.Path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(e.Path, []byte(e.New), info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if plan.Moves() {
+		dest := filepath.Join(plan.Root, filepath.FromSlash(plan.NewDir))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return err
+		}
+		if err := os.Rename(plan.Package.Dir, dest); err != nil {
+			return fmt.Errorf("move %s: %w", plan.Package.RelDir, err)
+		}
+	}
+	return nil
+}
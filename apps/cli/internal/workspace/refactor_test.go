@@ -0,0 +1,142 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestPlanRefactorRename(t *testing.T) {
+	root := testRepo(t)
+	writeFile(t, filepath.Join(root, "apps/web/src/app.ts"),
+		"import { a } from \"@ellie/utils\"\nimport { b } from '@ellie/utils/strings'\nimport c from \"@ellie/utils-extra\"\n")
+	writeFile(t, filepath.Join(root, "apps/web/src/lazy.ts"),
+		"import \"@ellie/utils/polyfill\"\nexport * from '@ellie/utils'\nconst d = await import(\"@ellie/utils\")\nconst e = require('@ellie/utils')\nconst label = \"@ellie/utils\"\n")
+	writeFile(t, filepath.Join(root, "package.json"),
+		`{"workspaces":["apps/*","packages/*"],"description":"@ellie/utils","scripts":{"utils":"turbo run build --filter=@ellie/utils... --filter=@ellie/utils-extra"}}`)
+	writeFile(t, filepath.Join(root, "apps/web/tsconfig.json"),
+		`{"compilerOptions":{"paths":{"@ellie/utils/*":["../../packages/utils/src/*"]}}}`)
+	writeFile(t, filepath.Join(root, "turbo.json"), `{"tasks":{"build":{"dependsOn":["@ellie/utils#build"]}}}`)
+	writeFile(t, filepath.Join(root, "apps/web/node_modules/x/index.js"), `require("@ellie/utils")`)
+
+	pkgs, err := Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := PlanRefactor(root, pkgs, "@ellie/utils", "@ellie/shared", "packages/utils")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Moves() {
+		t.Error("rename reported as a move")
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := map[string]string{
+		"packages/utils/package.json":      `{"name":"@ellie/shared"}`,
+		"packages/db/package.json":         `{"name":"@ellie/db","dependencies":{"@ellie/shared":"workspace:*","zod":"^3"}}`,
+		"apps/web/src/app.ts":              "import { a } from \"@ellie/shared\"\nimport { b } from '@ellie/shared/strings'\nimport c from \"@ellie/utils-extra\"\n",
+		"apps/web/tsconfig.json":           `{"compilerOptions":{"paths":{"@ellie/shared/*":["../../packages/utils/src/*"]}}}`,
+		"turbo.json":                       `{"tasks":{"build":{"dependsOn":["@ellie/shared#build"]}}}`,
+		"apps/web/node_modules/x/index.js": `require("@ellie/utils")`,
+		"apps/web/src/lazy.ts":             "import \"@ellie/shared/polyfill\"\nexport * from '@ellie/shared'\nconst d = await import(\"@ellie/shared\")\nconst e = require('@ellie/shared')\nconst label = \"@ellie/utils\"\n",
+		"package.json":                     `{"workspaces":["apps/*","packages/*"],"description":"@ellie/utils","scripts":{"utils":"turbo run build --filter=@ellie/shared... --filter=@ellie/utils-extra"}}`,
+	}
+	for rel, want := range checks {
+		if got := readFile(t, filepath.Join(root, rel)); got != want {
+			t.Errorf("%s = %s, want %s", rel, got, want)
+		}
+	}
+}
+
+func TestPlanRefactorMove(t *testing.T) {
+	root := testRepo(t)
+	writeFile(t, filepath.Join(root, "packages/utils/tsconfig.json"),
+		`{"extends":"../../tsconfig.base.json","include":["./src"]}`)
+	writeFile(t, filepath.Join(root, "apps/web/tsconfig.json"),
+		`{"references":[{"path":"../../packages/utils"}]}`)
+
+	pkgs, err := Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := PlanRefactor(root, pkgs, "@ellie/utils", "@ellie/utils", "apps/utils")
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := plan.Diff()
+	if strings.Contains(diff, "apps/utils/tsconfig.json") || !strings.Contains(diff, `+{"references":[{"path":"../utils"}]}`) {
+		t.Errorf("diff:\n%s", diff)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "packages/utils")); !os.IsNotExist(err) {
+		t.Error("old directory still exists")
+	}
+	if got, want := readFile(t, filepath.Join(root, "apps/utils/tsconfig.json")),
+		`{"extends":"../../tsconfig.base.json","include":["./src"]}`; got != want {
+		t.Errorf("moved tsconfig = %s, want %s", got, want)
+	}
+	if got, want := readFile(t, filepath.Join(root, "apps/web/tsconfig.json")),
+		`{"references":[{"path":"../utils"}]}`; got != want {
+		t.Errorf("web tsconfig = %s, want %s", got, want)
+	}
+}
+
+func TestPlanRefactorErrors(t *testing.T) {
+	root := testRepo(t)
+	pkgs, err := Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ name, pkg, newName, newDir string }{
+		{"unknown package", "@ellie/nope", "@ellie/x", "packages/nope"},
+		{"name taken", "@ellie/utils", "@ellie/db", "packages/utils"},
+		{"dir taken", "@ellie/utils", "@ellie/utils", "packages/db"},
+		{"outside workspaces", "@ellie/utils", "@ellie/utils", "tools/utils"},
+		{"outside repo", "@ellie/utils", "@ellie/utils", "../utils"},
+		{"no change", "@ellie/utils", "@ellie/utils", "packages/utils"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := PlanRefactor(root, pkgs, tt.pkg, tt.newName, tt.newDir); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestPlanApplyChangedFile(t *testing.T) {
+	root := testRepo(t)
+	pkgs, err := Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := PlanRefactor(root, pkgs, "@ellie/utils", "@ellie/shared", "packages/utils")
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := filepath.Join(root, "apps/web/package.json")
+	writeFile(t, web, `{"name":"web","devDependencies":{"@ellie/utils":"workspace:^"}}`)
+
+	if err := plan.Apply(); err == nil || !strings.Contains(err.Error(), "changed since the preview") {
+		t.Fatalf("Apply = %v, want a changed-file error", err)
+	}
+	if got := readFile(t, filepath.Join(root, "packages/utils/package.json")); got != `{"name":"@ellie/utils"}` {
+		t.Errorf("Apply wrote %s before failing", got)
+	}
+}
@@ -0,0 +1,207 @@
+// Package workspace discovers the packages of the bun/turbo monorepo and
+// the dependency edges between them.
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Package is a single workspace package (an entry under apps/* or packages/*).
+type Package struct {
+	Name    string            // package.json "name"
+	Dir     string            // absolute directory
+	RelDir  string            // directory relative to the monorepo root, slash-separated
+	Scripts map[string]string // package.json "scripts"
+	Deps    []string          // names of other workspace packages this one depends on
+}
+
+type packageJSON struct {
+	Name                 string            `json:"name"`
+	Workspaces           []string          `json:"workspaces"`
+	Scripts              map[string]string `json:"scripts"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// Discover reads the root package.json workspaces globs and loads every
+// package they match. Packages are returned sorted by name.
+func Discover(root string) ([]Package, error) {
+	rootPkg, err := readPackageJSON(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(rootPkg.Workspaces) == 0 {
+		return nil, fmt.Errorf("%s/package.json declares no workspaces", root)
+	}
+
+	var raw []packageJSON
+	var dirs []string
+	for _, pattern := range rootPkg.Workspaces {
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace pattern %q: %w", pattern, err)
+		}
+		for _, dir := range matches {
+			pj, err := readPackageJSON(filepath.Join(dir, "package.json"))
+			if err != nil || pj.Name == "" {
+				continue // directories without a package.json aren't packages
+			}
+			raw = append(raw, pj)
+			dirs = append(dirs, dir)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, pj := range raw {
+		names[pj.Name] = true
+	}
+
+	pkgs := make([]Package, 0, len(raw))
+	for i, pj := range raw {
+		rel, _ := filepath.Rel(root, dirs[i])
+		p := Package{
+			Name:    pj.Name,
+			Dir:     dirs[i],
+			RelDir:  filepath.ToSlash(rel),
+			Scripts: pj.Scripts,
+		}
+		seen := map[string]bool{}
+		for _, deps := range []map[string]string{pj.Dependencies, pj.DevDependencies, pj.PeerDependencies, pj.OptionalDependencies} {
+			for dep := range deps {
+				if names[dep] && !seen[dep] && dep != pj.Name {
+					seen[dep] = true
+					p.Deps = append(p.Deps, dep)
+				}
+			}
+		}
+		sort.Strings(p.Deps)
+		pkgs = append(pkgs, p)
+	}
+
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+	return pkgs, nil
+}
+
+// Owner returns the package whose directory contains relPath (a
+// slash-separated path relative to the monorepo root), if any.
+func Owner(pkgs []Package, relPath string) (Package, bool) {
+	var best Package
+	found := false
+	for _, p := range pkgs {
+		if relPath == p.RelDir || strings.HasPrefix(relPath, p.RelDir+"/") {
+			if !found || len(p.RelDir) > len(best.RelDir) {
+				best, found = p, true
+			}
+		}
+	}
+	return best, found
+}
+
+// Affected returns the names of packages touched by changedFiles plus
+// every package that transitively depends on them, sorted by name.
+// rootChanged reports whether any file outside all packages changed
+// (e.g. bun.lock or turbo.json), which usually means everything is
+// affected; that decision is left to the caller.
+func Affected(pkgs []Package, changedFiles []string) (names []string, rootChanged bool) {
+	dependents := map[string][]string{}
+	for _, p := range pkgs {
+		for _, dep := range p.Deps {
+			dependents[dep] = append(dependents[dep], p.Name)
+		}
+	}
+
+	affected := map[string]bool{}
+	var queue []string
+	for _, f := range changedFiles {
+		p, ok := Owner(pkgs, filepath.ToSlash(f))
+		if !ok {
+			rootChanged = true
+			continue
+		}
+		if !affected[p.Name] {
+			affected[p.Name] = true
+			queue = append(queue, p.Name)
+		}
+	}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, d := range dependents[name] {
+			if !affected[d] {
+				affected[d] = true
+				queue = append(queue, d)
+			}
+		}
+	}
+
+	for name := range affected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, rootChanged
+}
+
+// Select returns the packages turbo's --filter values would: those a
+// filter names, less those a "!" filter names, or every package but
+// those when there are only exclusions. A name may be a glob and may
+// leave out the scope, so "web" selects "@ellie/web". Filters by
+// directory, git range or dependency ("./apps/*", "[main]", "web...")
+// are not supported.
+func Select(pkgs []Package, filters []string) ([]Package, error) {
+	var include, exclude []string
+	for _, f := range filters {
+		name, negated := strings.CutPrefix(f, "!")
+		if name == "" || strings.ContainsAny(name, "{}[]") || strings.HasPrefix(name, ".") || strings.Contains(name, "...") {
+			return nil, fmt.Errorf("unsupported filter %q: only package names and !name are supported", f)
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, fmt.Errorf("bad filter %q: %w", f, err)
+		}
+		if negated {
+			exclude = append(exclude, name)
+		} else {
+			include = append(include, name)
+		}
+	}
+
+	matches := func(p Package, patterns []string) bool {
+		short := p.Name[strings.LastIndex(p.Name, "/")+1:]
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, p.Name); ok {
+				return true
+			}
+			if ok, _ := path.Match(pattern, short); ok && !strings.Contains(pattern, "/") {
+				return true
+			}
+		}
+		return false
+	}
+	var selected []Package
+	for _, p := range pkgs {
+		if (len(include) == 0 || matches(p, include)) && !matches(p, exclude) {
+			selected = append(selected, p)
+		}
+	}
+	return selected, nil
+}
+
+func readPackageJSON(path string) (packageJSON, error) {
+	var pj packageJSON
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pj, err
+	}
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return pj, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return pj, nil
+}
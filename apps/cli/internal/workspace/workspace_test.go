@@ -0,0 +1,114 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "package.json"), `{"workspaces":["apps/*","packages/*"]}`)
+	writeFile(t, filepath.Join(root, "packages/utils/package.json"), `{"name":"@ellie/utils"}`)
+	writeFile(t, filepath.Join(root, "packages/db/package.json"),
+		`{"name":"@ellie/db","dependencies":{"@ellie/utils":"workspace:*","zod":"^3"}}`)
+	writeFile(t, filepath.Join(root, "apps/server/package.json"),
+		`{"name":"server","dependencies":{"@ellie/db":"workspace:*"},"scripts":{"dev":"bun run --hot src/server.ts"}}`)
+	writeFile(t, filepath.Join(root, "apps/web/package.json"),
+		`{"name":"web","devDependencies":{"@ellie/utils":"workspace:*"}}`)
+	// Not a package: no package.json
+	if err := os.MkdirAll(filepath.Join(root, "packages/empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestDiscover(t *testing.T) {
+	pkgs, err := Discover(testRepo(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, p := range pkgs {
+		names = append(names, p.Name)
+	}
+	want := []string{"@ellie/db", "@ellie/utils", "server", "web"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+
+	if !reflect.DeepEqual(pkgs[0].Deps, []string{"@ellie/utils"}) {
+		t.Errorf("db deps = %v, want only workspace deps", pkgs[0].Deps)
+	}
+	if pkgs[2].RelDir != "apps/server" || pkgs[2].Scripts["dev"] == "" {
+		t.Errorf("unexpected server package: %+v", pkgs[2])
+	}
+}
+
+func TestAffected(t *testing.T) {
+	pkgs, err := Discover(testRepo(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, rootChanged := Affected(pkgs, []string{"packages/utils/src/index.ts"})
+	want := []string{"@ellie/db", "@ellie/utils", "server", "web"}
+	if !reflect.DeepEqual(names, want) || rootChanged {
+		t.Errorf("utils change: got %v (root=%v), want %v", names, rootChanged, want)
+	}
+
+	names, _ = Affected(pkgs, []string{"packages/db/schema.ts"})
+	want = []string{"@ellie/db", "server"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("db change: got %v, want %v", names, want)
+	}
+
+	names, rootChanged = Affected(pkgs, []string{"turbo.json", "apps/web/index.html"})
+	if !reflect.DeepEqual(names, []string{"web"}) || !rootChanged {
+		t.Errorf("root change: got %v (root=%v)", names, rootChanged)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	pkgs, err := Discover(testRepo(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		filters []string
+		want    []string
+	}{
+		{nil, []string{"@ellie/db", "@ellie/utils", "server", "web"}},
+		{[]string{"server", "utils"}, []string{"@ellie/utils", "server"}},
+		{[]string{"!web"}, []string{"@ellie/db", "@ellie/utils", "server"}},
+		{[]string{"@ellie/*", "!db"}, []string{"@ellie/utils"}},
+		{[]string{"nothing"}, nil},
+	} {
+		got, err := Select(pkgs, c.filters)
+		var names []string
+		for _, p := range got {
+			names = append(names, p.Name)
+		}
+		if err != nil || !reflect.DeepEqual(names, c.want) {
+			t.Errorf("Select(%q) = %v, %v; want %v", c.filters, names, err, c.want)
+		}
+	}
+	for _, f := range []string{"./apps/*", "web...", "[main]", "!"} {
+		if _, err := Select(pkgs, []string{f}); err == nil {
+			t.Errorf("Select(%q) succeeded, want an error", f)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package ellieapi
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ── compression ─────────────────────────────────────────────────────────────
+
+// CompressTransport asks for gzip or deflate responses and decodes them,
+// so callers read plain bodies, and can gzip request bodies. Unlike
+// http.Transport's own gzip support, it also takes deflate, and works
+// over any RoundTripper.
+type CompressTransport struct {
+	// Base sends the requests; nil uses http.DefaultTransport.
+	Base http.RoundTripper
+	// MinRequestSize is the size from which request bodies are gzipped;
+	// 0 never gzips them, as the server has to accept gzip bodies.
+	MinRequestSize int64
+}
+
+func (t *CompressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// A range of an encoded body can't be decoded on its own, and a
+	// caller that set Accept-Encoding wants the body as it is sent.
+	if req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" {
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	if err := t.compressBody(req); err != nil {
+		return nil, err
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeBody(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// compressBody gzips req's body when it is at least MinRequestSize and
+// not already encoded.
+func (t *CompressTransport) compressBody(req *http.Request) error {
+	if t.MinRequestSize <= 0 || req.Body == nil || req.GetBody == nil ||
+		req.ContentLength < t.MinRequestSize || req.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.Copy(zw, body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Encoding", "gzip")
+	return nil
+}
+
+// decodeBody replaces an encoded body of resp with the decoded one.
+func decodeBody(resp *http.Response) error {
+	if resp.StatusCode == http.StatusNoContent || resp.Request.Method == http.MethodHead {
+		return nil
+	}
+	var open func(*bufio.Reader) (io.Reader, error)
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		open = func(br *bufio.Reader) (io.Reader, error) {
+			if _, err := br.Peek(1); err == io.EOF {
+				// An empty body has nothing to decode.
+				return br, nil
+			}
+			zr, err := gzip.NewReader(br)
+			if err != nil {
+				return nil, fmt.Errorf("invalid gzip response: %w", err)
+			}
+			return zr, nil
+		}
+	case "deflate":
+		open = func(br *bufio.Reader) (io.Reader, error) {
+			// Deflate should be zlib-wrapped, but some servers send it raw.
+			head, err := br.Peek(2)
+			if err != nil || head[0]&0x0f != 8 || (uint16(head[0])<<8|uint16(head[1]))%31 != 0 {
+				return flate.NewReader(br), nil
+			}
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return nil, fmt.Errorf("invalid deflate response: %w", err)
+			}
+			return zr, nil
+		}
+	default:
+		return nil
+	}
+	resp.Body = &decodedBody{open: open, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// decodedBody reads a decoded body and closes the encoded one. The
+// decoder is opened on the first read, as it reads the body's header:
+// a stream's first bytes may be a while coming.
+type decodedBody struct {
+	open func(*bufio.Reader) (io.Reader, error)
+	r    io.Reader
+	err  error
+	body io.ReadCloser
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.r == nil && b.err == nil {
+		b.r, b.err = b.open(bufio.NewReader(b.body))
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.r.Read(p)
+}
+
+func (b *decodedBody) Close() error {
+	if c, ok := b.r.(io.Closer); ok {
+		c.Close()
+	}
+	return b.body.Close()
+}
+
+// ── downloads ───────────────────────────────────────────────────────────────
+
+// Download copies the body of a GET of path to w as it arrives, without
+// holding it in memory, and returns its size.
+func (c *Client) Download(ctx context.Context, path string, w io.Writer) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, &UnreachableError{BaseURL: c.BaseURL, Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, ResponseError(resp)
+	}
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return n, fmt.Errorf("download %s: %w", path, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,104 @@
+package ellieapi
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompressTransport(t *testing.T) {
+	body := strings.Repeat("ellie ", 500)
+	var gotEncoding, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEncoding = r.Header.Get("Content-Encoding")
+		if gotEncoding == "gzip" {
+			zr, _ := gzip.NewReader(r.Body)
+			data, _ := io.ReadAll(zr)
+			gotBody = string(data)
+		}
+		var zw io.WriteCloser
+		switch r.URL.Path {
+		case "/gzip":
+			zw = gzip.NewWriter(w)
+		case "/zlib":
+			zw = zlib.NewWriter(w)
+		case "/raw":
+			zw, _ = flate.NewWriter(w, flate.DefaultCompression)
+		default:
+			io.WriteString(w, body)
+			return
+		}
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "deflate") {
+			t.Errorf("Accept-Encoding = %q", r.Header.Get("Accept-Encoding"))
+		}
+		encoding := "deflate"
+		if r.URL.Path == "/gzip" {
+			encoding = "gzip"
+		}
+		w.Header().Set("Content-Encoding", encoding)
+		io.WriteString(zw, body)
+		zw.Close()
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &CompressTransport{MinRequestSize: 1024}}
+	for _, path := range []string{"/gzip", "/zlib", "/raw", "/plain"} {
+		resp, err := client.Post(srv.URL+path, "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(data) != body {
+			t.Errorf("%s: body = %.40q…", path, data)
+		}
+		if resp.Header.Get("Content-Encoding") != "" {
+			t.Errorf("%s: Content-Encoding left on the decoded response", path)
+		}
+		if gotEncoding != "gzip" || gotBody != body {
+			t.Errorf("%s: request sent with Content-Encoding %q", path, gotEncoding)
+		}
+	}
+
+	resp, err := client.Post(srv.URL+"/gzip", "text/plain", strings.NewReader("short"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if gotEncoding != "" {
+		t.Error("a body under MinRequestSize was gzipped")
+	}
+}
+
+func TestDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/export" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(bytes.Repeat([]byte("x"), 1<<20))
+	}))
+	defer srv.Close()
+	c := New(srv.URL, nil)
+
+	var buf bytes.Buffer
+	n, err := c.Download(context.Background(), "/export", &buf)
+	if err != nil || n != 1<<20 || buf.Len() != 1<<20 {
+		t.Fatalf("Download = %d, %v; wrote %d bytes", n, err, buf.Len())
+	}
+
+	buf.Reset()
+	if _, err := c.Download(context.Background(), "/missing", &buf); !IsStatus(err, http.StatusNotFound) {
+		t.Errorf("a 404 download returned %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("a failed download wrote %d bytes", buf.Len())
+	}
+}
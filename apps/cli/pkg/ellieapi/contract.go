@@ -0,0 +1,432 @@
+package ellieapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ── contract ────────────────────────────────────────────────────────────────
+
+// Endpoint is a route Client calls. Path is an OpenAPI path template;
+// Request and Response are the types it sends and decodes, nil for none.
+type Endpoint struct {
+	Method   string
+	Path     string
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+// Endpoints lists every route Client calls, with the types its methods
+// use, for checking them against the server's OpenAPI document.
+var Endpoints = []Endpoint{
+	{http.MethodGet, "/api/auth/{provider}/status", nil, reflect.TypeFor[AuthStatus]()},
+	{http.MethodPost, "/api/auth/{provider}/clear", nil, reflect.TypeFor[clearResponse]()},
+	{http.MethodPost, "/api/auth/{provider}/api-key", reflect.TypeFor[apiKeyRequest](), nil},
+	{http.MethodPost, "/api/auth/anthropic/token", reflect.TypeFor[tokenRequest](), nil},
+	{http.MethodPost, "/api/auth/anthropic/oauth/authorize", reflect.TypeFor[authorizeRequest](), reflect.TypeFor[OAuthAuthorization]()},
+	{http.MethodPost, "/api/auth/anthropic/oauth/exchange", reflect.TypeFor[OAuthExchange](), reflect.TypeFor[OAuthResult]()},
+	{http.MethodGet, "/api/channels", nil, reflect.TypeFor[[]Channel]()},
+	{http.MethodPost, "/api/channels/{channel}/logout", reflect.TypeFor[accountRequest](), nil},
+	{http.MethodPost, "/api/channels/whatsapp/login/start", reflect.TypeFor[loginStartRequest](), reflect.TypeFor[WhatsAppLogin]()},
+	{http.MethodPost, "/api/channels/whatsapp/login/wait", reflect.TypeFor[accountRequest](), nil},
+}
+
+// EndpointReport is how an endpoint the client calls compares with the
+// server's description of it.
+type EndpointReport struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Routes are the server's paths the endpoint matched: one, or one per
+	// provider or channel when the server lists them separately.
+	Routes []string `json:"routes,omitempty"`
+	// Problems are differences that would make the client's requests fail
+	// or its responses misread.
+	Problems []string `json:"problems,omitempty"`
+	// Unchecked names what the server's document doesn't describe, such
+	// as a response without a schema.
+	Unchecked []string `json:"unchecked,omitempty"`
+}
+
+// CheckContract compares Endpoints with the OpenAPI 3 document spec and
+// reports on each.
+func CheckContract(spec []byte) ([]EndpointReport, error) {
+	var doc openAPIDoc
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if len(doc.Paths) == 0 {
+		return nil, fmt.Errorf("invalid OpenAPI document: it has no paths")
+	}
+	reports := make([]EndpointReport, 0, len(Endpoints))
+	for _, e := range Endpoints {
+		reports = append(reports, doc.check(e))
+	}
+	return reports, nil
+}
+
+// ── OpenAPI documents ───────────────────────────────────────────────────────
+
+type openAPIDoc struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]*jsonSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type operation struct {
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema *jsonSchema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]struct {
+			Schema *jsonSchema `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+// jsonSchema is the part of a JSON schema the check understands.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 json.RawMessage        `json:"type"` // a name or a list of them
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	Items                *jsonSchema            `json:"items"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"` // false, true or a schema
+	AnyOf                []*jsonSchema          `json:"anyOf"`
+	OneOf                []*jsonSchema          `json:"oneOf"`
+	AllOf                []*jsonSchema          `json:"allOf"`
+	Const                any                    `json:"const"`
+	Enum                 []any                  `json:"enum"`
+}
+
+// check compares e with the server's paths matching its template.
+func (d *openAPIDoc) check(e Endpoint) EndpointReport {
+	r := EndpointReport{Method: e.Method, Path: e.Path}
+	var paths []string
+	for p := range d.Paths {
+		if pathMatches(e.Path, p) {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	if len(paths) == 0 {
+		r.Problems = append(r.Problems, "the server has no such path")
+		return r
+	}
+
+	for _, p := range paths {
+		raw, ok := d.Paths[p][strings.ToLower(e.Method)]
+		if !ok {
+			r.Problems = append(r.Problems, fmt.Sprintf("%s does not accept %s", p, e.Method))
+			continue
+		}
+		r.Routes = append(r.Routes, p)
+		var op operation
+		if err := json.Unmarshal(raw, &op); err != nil {
+			r.Problems = append(r.Problems, fmt.Sprintf("%s: invalid operation: %v", p, err))
+			continue
+		}
+		prefix := ""
+		if len(paths) > 1 {
+			prefix = p + ": "
+		}
+
+		if e.Request != nil {
+			var s *jsonSchema
+			if op.RequestBody != nil {
+				s = op.RequestBody.Content["application/json"].Schema
+			}
+			if s == nil {
+				r.Unchecked = append(r.Unchecked, prefix+"request body")
+			} else {
+				c := comparer{doc: d, request: true}
+				c.compare("request", e.Request, s)
+				r.Problems = append(r.Problems, prefixed(prefix, c.problems)...)
+			}
+		}
+		if e.Response != nil {
+			s := op.Responses["200"].Content["application/json"].Schema
+			if s == nil {
+				r.Unchecked = append(r.Unchecked, prefix+"response")
+			} else {
+				c := comparer{doc: d}
+				c.compare("response", e.Response, s)
+				r.Problems = append(r.Problems, prefixed(prefix, c.problems)...)
+			}
+		}
+	}
+	return r
+}
+
+// pathMatches reports whether the server's path p is an instance of
+// template, where a {param} stands for any one segment, named or not.
+func pathMatches(template, p string) bool {
+	ts, ps := strings.Split(template, "/"), strings.Split(p, "/")
+	if len(ts) != len(ps) {
+		return false
+	}
+	for i := range ts {
+		if ts[i] != ps[i] && !strings.HasPrefix(ts[i], "{") && !strings.HasPrefix(ps[i], "{") {
+			return false
+		}
+	}
+	return true
+}
+
+func prefixed(prefix string, list []string) []string {
+	out := make([]string, len(list))
+	for i, s := range list {
+		out[i] = prefix + s
+	}
+	return out
+}
+
+// ── shapes ──────────────────────────────────────────────────────────────────
+
+var rawMessageType = reflect.TypeFor[json.RawMessage]()
+
+// comparer checks a Go type against a schema. For a request the client
+// sends the type and the server must accept it; for a response the
+// server sends the schema and the client must be able to read it.
+type comparer struct {
+	doc      *openAPIDoc
+	request  bool
+	problems []string
+}
+
+func (c *comparer) problem(where, format string, args ...any) {
+	c.problems = append(c.problems, where+": "+fmt.Sprintf(format, args...))
+}
+
+// resolve follows $ref and merges allOf.
+func (c *comparer) resolve(s *jsonSchema) *jsonSchema {
+	for depth := 0; s != nil && s.Ref != "" && depth < 32; depth++ {
+		name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		if !ok {
+			return nil
+		}
+		s = c.doc.Components.Schemas[name]
+	}
+	if s == nil || len(s.AllOf) == 0 {
+		return s
+	}
+	merged := *s
+	merged.AllOf = nil
+	merged.Properties = map[string]*jsonSchema{}
+	for name, p := range s.Properties {
+		merged.Properties[name] = p
+	}
+	for _, part := range s.AllOf {
+		part = c.resolve(part)
+		if part == nil {
+			continue
+		}
+		for name, p := range part.Properties {
+			merged.Properties[name] = p
+		}
+		merged.Required = append(merged.Required, part.Required...)
+		if len(merged.Type) == 0 {
+			merged.Type = part.Type
+		}
+	}
+	return &merged
+}
+
+// types returns the JSON types s allows, leaving out null; none means
+// any.
+func (s *jsonSchema) types() []string {
+	var list []string
+	if len(s.Type) > 0 {
+		var one string
+		if json.Unmarshal(s.Type, &one) == nil {
+			list = []string{one}
+		} else {
+			_ = json.Unmarshal(s.Type, &list)
+		}
+	} else {
+		values := s.Enum
+		if s.Const != nil {
+			values = []any{s.Const}
+		}
+		for _, v := range values {
+			switch v.(type) {
+			case string:
+				list = append(list, "string")
+			case bool:
+				list = append(list, "boolean")
+			case float64:
+				list = append(list, "number")
+			}
+		}
+	}
+	return slices.DeleteFunc(list, func(t string) bool { return t == "null" })
+}
+
+// jsonType returns the JSON type t is encoded as, "" for any.
+func jsonType(t reflect.Type) string {
+	if t == rawMessageType {
+		return ""
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	}
+	return ""
+}
+
+// compatible reports whether a value of the Go type's JSON type want
+// and one of the schema's types have agree, in the direction values
+// flow: an integer fits where a number is accepted, not the other way.
+func (c *comparer) compatible(want string, have []string) bool {
+	for _, h := range have {
+		switch {
+		case h == want:
+			return true
+		case c.request && want == "integer" && h == "number":
+			return true
+		case !c.request && want == "number" && h == "integer":
+			return true
+		}
+	}
+	return false
+}
+
+func (c *comparer) compare(where string, t reflect.Type, s *jsonSchema) {
+	s = c.resolve(s)
+	if s == nil {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if alts := append(slices.Clone(s.AnyOf), s.OneOf...); len(alts) > 0 {
+		for _, alt := range alts {
+			if a := c.resolve(alt); a != nil && len(a.Type) > 0 && len(a.types()) == 0 {
+				continue // null
+			}
+			try := comparer{doc: c.doc, request: c.request}
+			try.compare(where, t, alt)
+			if len(try.problems) == 0 {
+				return
+			}
+		}
+		c.problem(where, "the client's %s matches none of the server's alternatives", describe(t))
+		return
+	}
+
+	want, have := jsonType(t), s.types()
+	if want == "" || len(have) == 0 {
+		return
+	}
+	if !c.compatible(want, have) {
+		if c.request {
+			c.problem(where, "the client sends %s, the server expects %s", article(want), strings.Join(have, " or "))
+		} else {
+			c.problem(where, "the client reads %s, the server sends %s", article(want), strings.Join(have, " or "))
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if s.Items != nil {
+			c.compare(where+"[]", t.Elem(), s.Items)
+		}
+	case reflect.Map:
+		var extra jsonSchema
+		if len(s.AdditionalProperties) > 0 && json.Unmarshal(s.AdditionalProperties, &extra) == nil {
+			c.compare(where+".*", t.Elem(), &extra)
+		}
+	case reflect.Struct:
+		c.compareObject(where, t, s)
+	}
+}
+
+// compareObject compares a struct's fields with an object's properties.
+func (c *comparer) compareObject(where string, t reflect.Type, s *jsonSchema) {
+	closed := string(s.AdditionalProperties) == "false"
+	sent := map[string]bool{}
+	for _, f := range jsonFields(t) {
+		sent[f.name] = true
+		p, ok := s.Properties[f.name]
+		switch {
+		case ok:
+			c.compare(where+"."+f.name, f.typ, p)
+		case c.request && closed:
+			c.problem(where+"."+f.name, "the server does not accept this field")
+		case !c.request && !f.optional && len(s.Properties) > 0:
+			c.problem(where+"."+f.name, "the server does not send this field")
+		}
+	}
+	if c.request {
+		for _, name := range s.Required {
+			if !sent[name] {
+				c.problem(where+"."+name, "the server requires this field, which the client does not send")
+			}
+		}
+	}
+}
+
+type jsonField struct {
+	name     string
+	typ      reflect.Type
+	optional bool // omitempty or a pointer: the value may be absent
+}
+
+// jsonFields lists the fields encoding/json would use for t.
+func jsonFields(t reflect.Type) []jsonField {
+	var out []jsonField
+	for f := range t.Fields() {
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				out = append(out, jsonFields(f.Type)...)
+				continue
+			}
+			name = f.Name
+		}
+		optional := f.Type.Kind() == reflect.Pointer || strings.Contains(","+opts+",", ",omitempty,")
+		out = append(out, jsonField{name: name, typ: f.Type, optional: optional})
+	}
+	return out
+}
+
+func describe(t reflect.Type) string {
+	if want := jsonType(t); want != "" {
+		return want
+	}
+	return t.String()
+}
+
+func article(jsonType string) string {
+	switch jsonType {
+	case "array", "object", "integer":
+		return "an " + jsonType
+	}
+	return "a " + jsonType
+}
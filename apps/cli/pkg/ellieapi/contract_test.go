@@ -0,0 +1,116 @@
+package ellieapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testSpec = `{
+  "openapi": "3.0.3",
+  "paths": {
+    "/api/auth/anthropic/status": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Status"}}}}}}},
+    "/api/auth/groq/status": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"type": "object", "properties": {"mode": {"type": "string"}}}}}}}}},
+    "/api/auth/{provider}/clear": {"post": {"responses": {"200": {"content": {"application/json": {"schema": {"type": "object", "properties": {"cleared": {"type": "string"}}}}}}}}},
+    "/api/auth/{provider}/api-key": {"post": {"requestBody": {"content": {"application/json": {"schema": {
+      "type": "object", "required": ["key", "label"], "properties": {"key": {"type": "string"}, "validate": {"type": "boolean"}, "label": {"type": "string"}}}}}}}},
+    "/api/auth/anthropic/oauth/authorize": {"post": {
+      "requestBody": {"content": {"application/json": {"schema": {"type": "object", "additionalProperties": false, "properties": {"mode": {"enum": ["max", "console"]}}}}}},
+      "responses": {"200": {"content": {"application/json": {"schema": {"allOf": [
+        {"type": "object", "properties": {"url": {"type": "string"}, "verifier": {"type": "string"}}},
+        {"properties": {"state": {"anyOf": [{"type": "string"}, {"type": "null"}]}}}]}}}}}}},
+    "/api/channels": {"get": {"responses": {"200": {}}}},
+    "/api/channels/whatsapp/login/wait": {"get": {}}
+  },
+  "components": {"schemas": {"Status": {"type": "object", "required": ["configured"], "properties": {
+    "mode": {"type": ["string", "null"]}, "source": {"type": "string"}, "configured": {"type": "boolean"}, "expires_at": {"type": "integer"}}}}}
+}`
+
+func TestCheckContract(t *testing.T) {
+	reports, err := CheckContract([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]EndpointReport{}
+	for _, r := range reports {
+		got[r.Method+" "+r.Path] = r
+	}
+	tests := []struct {
+		endpoint  string
+		routes    int
+		problems  []string
+		unchecked []string
+	}{
+		{"GET /api/auth/{provider}/status", 2, []string{
+			"/api/auth/groq/status: response.source: the server does not send this field",
+			"/api/auth/groq/status: response.configured: the server does not send this field",
+		}, nil},
+		{"POST /api/auth/{provider}/clear", 1, []string{
+			"response.cleared: the client reads a boolean, the server sends string",
+		}, nil},
+		{"POST /api/auth/{provider}/api-key", 1, []string{
+			"request.label: the server requires this field, which the client does not send",
+		}, nil},
+		{"POST /api/auth/anthropic/oauth/authorize", 1, []string{
+			"request.redirect_uri: the server does not accept this field",
+		}, nil},
+		{"GET /api/channels", 1, nil, []string{"response"}},
+		{"POST /api/channels/whatsapp/login/wait", 0, []string{
+			"/api/channels/whatsapp/login/wait does not accept POST",
+		}, nil},
+		{"POST /api/auth/anthropic/token", 0, []string{"the server has no such path"}, nil},
+	}
+	for _, tt := range tests {
+		r, ok := got[tt.endpoint]
+		if !ok {
+			t.Errorf("no report for %s", tt.endpoint)
+			continue
+		}
+		if len(r.Routes) != tt.routes || strings.Join(r.Problems, "\n") != strings.Join(tt.problems, "\n") ||
+			strings.Join(r.Unchecked, "\n") != strings.Join(tt.unchecked, "\n") {
+			t.Errorf("%s:\n routes %v\n problems %q\n unchecked %q", tt.endpoint, r.Routes, r.Problems, r.Unchecked)
+		}
+	}
+
+	if _, err := CheckContract([]byte(`{"openapi": "3.0.3"}`)); err == nil {
+		t.Error("a document without paths was accepted")
+	}
+}
+
+// TestEndpointsCoverClient calls every Client method and checks that
+// Endpoints lists each request it sends, so the contract check can't
+// miss a route.
+func TestEndpointsCoverClient(t *testing.T) {
+	var unlisted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		listed := false
+		for _, e := range Endpoints {
+			if e.Method == r.Method && pathMatches(e.Path, r.URL.Path) {
+				listed = true
+			}
+		}
+		if !listed {
+			unlisted = append(unlisted, r.Method+" "+r.URL.Path)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	c := New(srv.URL, srv.Client())
+	ctx := context.Background()
+
+	c.AuthStatus(ctx, "anthropic")
+	c.ClearAuth(ctx, "groq")
+	c.SetAPIKey(ctx, "groq", "k", false)
+	c.SetToken(ctx, "t")
+	c.OAuthAuthorize(ctx, "max", "")
+	c.OAuthExchange(ctx, OAuthExchange{})
+	c.Channels(ctx)
+	c.ChannelLogout(ctx, "whatsapp", "default")
+	c.WhatsAppLoginStart(ctx, "default", nil)
+	c.WhatsAppLoginWait(ctx, "default")
+	if len(unlisted) > 0 {
+		t.Errorf("requests missing from Endpoints: %v", unlisted)
+	}
+}
@@ -0,0 +1,380 @@
+// Package ellieapi is a client for the ellie server's local HTTP API: the
+// auth routes that store and report provider credentials, the channel
+// routes that connect messaging accounts, and the server-sent event
+// streams the server pushes chat and agent events over, which Stream
+// follows across dropped connections.
+//
+// Every method returns an *Error when the server answers with a status
+// other than 200, and an *UnreachableError when it could not be reached
+// at all, so callers can tell a server that refused from one that isn't
+// running.
+//
+// CompressTransport gives any http.Client gzip and deflate responses and,
+// for servers that take them, gzipped requests. Download streams a large
+// body, like a conversation export, to a writer instead of holding it in
+// memory.
+//
+// Endpoints lists the routes the client calls with the types it sends and
+// reads, and CheckContract compares them with the server's OpenAPI
+// document, to catch a server change that would break the client.
+package ellieapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client talks to the ellie server at BaseURL.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// New returns a client for the server at baseURL. A nil httpClient uses
+// http.DefaultClient.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTP: httpClient}
+}
+
+// Error is a response from the server with a status other than 200.
+type Error struct {
+	StatusCode int
+	// Code names the error for programs, e.g. "token_expired": the
+	// response's "code" field, or when it has none one of the generic
+	// codes for its status, e.g. "unauthorized" for 401.
+	Code string
+	// Message is the response's "error" field, or its body when it has
+	// none.
+	Message string
+	// Hint says what to do about the error, from the response's "hint"
+	// field; callers may fill it in for codes they know.
+	Hint string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned %d", e.StatusCode)
+	}
+	return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+}
+
+// statusCodes are the codes of errors whose response has none.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          "bad_request",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not_found",
+	http.StatusConflict:            "conflict",
+	http.StatusTooManyRequests:     "rate_limited",
+	http.StatusInternalServerError: "internal",
+	http.StatusBadGateway:          "unavailable",
+	http.StatusServiceUnavailable:  "unavailable",
+	http.StatusGatewayTimeout:      "unavailable",
+}
+
+// ResponseError reads resp's body into an *Error. The body may be
+// {"error": "...", "code": "...", "hint": "..."}, with the error an
+// object holding the other fields instead, or not JSON at all.
+func ResponseError(resp *http.Response) *Error {
+	body, _ := io.ReadAll(resp.Body)
+	e := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	type fields struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Hint    string `json:"hint"`
+	}
+	var parsed struct {
+		Error json.RawMessage `json:"error"`
+		fields
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		var nested fields
+		switch {
+		case json.Unmarshal(parsed.Error, &e.Message) == nil:
+		case json.Unmarshal(parsed.Error, &nested) == nil:
+			parsed.fields, e.Message = nested, nested.Message
+		case parsed.Message != "":
+			e.Message = parsed.Message
+		}
+		e.Code, e.Hint = parsed.Code, parsed.Hint
+	}
+	if e.Code == "" {
+		e.Code = statusCodes[resp.StatusCode]
+	}
+	return e
+}
+
+// IsStatus reports whether err is an *Error with status code.
+func IsStatus(err error, code int) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == code
+}
+
+// UnreachableError is returned when a request got no response.
+type UnreachableError struct {
+	BaseURL string
+	Err     error
+}
+
+func (e *UnreachableError) Error() string {
+	return "cannot reach server at " + e.BaseURL + ": " + e.Err.Error()
+}
+
+func (e *UnreachableError) Unwrap() error { return e.Err }
+
+// IsUnreachable reports whether err is an *UnreachableError.
+func IsUnreachable(err error) bool {
+	var e *UnreachableError
+	return errors.As(err, &e)
+}
+
+// do sends a request with in as its JSON body, when not nil, and decodes
+// a 200 response into out, when not nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil || method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return &UnreachableError{BaseURL: c.BaseURL, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ResponseError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// ── auth ────────────────────────────────────────────────────────────────────
+
+// AuthStatus is what the server has stored for a provider. Mode is nil
+// when nothing is configured; the other fields are set only for the
+// providers and modes that have them.
+type AuthStatus struct {
+	Mode       *string  `json:"mode"`
+	Source     string   `json:"source"`
+	Configured bool     `json:"configured"`
+	ExpiresAt  *float64 `json:"expires_at,omitempty"` // Unix milliseconds
+	Expired    *bool    `json:"expired,omitempty"`
+	Preview    *string  `json:"preview,omitempty"`
+
+	// Vertex AI (Gemini)
+	Project         string `json:"project,omitempty"`
+	Location        string `json:"location,omitempty"`
+	Account         string `json:"account,omitempty"`
+	CredentialsPath string `json:"credentials_path,omitempty"`
+
+	// Azure OpenAI
+	Endpoint   string `json:"endpoint,omitempty"`
+	Deployment string `json:"deployment,omitempty"`
+
+	// AWS Bedrock (Anthropic)
+	Region     string `json:"region,omitempty"`
+	AWSProfile string `json:"aws_profile,omitempty"`
+	RoleARN    string `json:"role_arn,omitempty"`
+
+	// Raw is the response as the server sent it, including fields this
+	// package doesn't know.
+	Raw json.RawMessage `json:"-"`
+}
+
+// AuthStatus returns the server's credential state for provider. Servers
+// older than a provider answer 404.
+func (c *Client) AuthStatus(ctx context.Context, provider string) (*AuthStatus, error) {
+	var raw json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "/api/auth/"+url.PathEscape(provider)+"/status", nil, &raw); err != nil {
+		return nil, err
+	}
+	status := &AuthStatus{Raw: raw}
+	if err := json.Unmarshal(raw, status); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return status, nil
+}
+
+// ClearAuth removes provider's stored credentials and reports whether
+// there were any.
+func (c *Client) ClearAuth(ctx context.Context, provider string) (bool, error) {
+	var out clearResponse
+	err := c.do(ctx, http.MethodPost, "/api/auth/"+url.PathEscape(provider)+"/clear", nil, &out)
+	return out.Cleared, err
+}
+
+type clearResponse struct {
+	Cleared bool `json:"cleared"`
+}
+
+type apiKeyRequest struct {
+	Key      string `json:"key"`
+	Validate bool   `json:"validate"`
+}
+
+type tokenRequest struct {
+	Token string `json:"token"`
+}
+
+// SetAPIKey stores an API key for provider. With validate the server
+// first checks the key with the provider and answers 401 if it is
+// rejected.
+func (c *Client) SetAPIKey(ctx context.Context, provider, key string, validate bool) error {
+	in := apiKeyRequest{Key: key, Validate: validate}
+	return c.do(ctx, http.MethodPost, "/api/auth/"+url.PathEscape(provider)+"/api-key", in, nil)
+}
+
+// SetToken stores an Anthropic bearer token.
+func (c *Client) SetToken(ctx context.Context, token string) error {
+	return c.do(ctx, http.MethodPost, "/api/auth/anthropic/token", tokenRequest{Token: token}, nil)
+}
+
+// OAuthAuthorization starts an Anthropic OAuth flow: the user opens URL,
+// and the code the browser gets back is exchanged with Verifier.
+type OAuthAuthorization struct {
+	URL      string `json:"url"`
+	Verifier string `json:"verifier"`
+	State    string `json:"state"`
+}
+
+type authorizeRequest struct {
+	Mode        string `json:"mode"`
+	RedirectURI string `json:"redirect_uri,omitempty"`
+}
+
+// OAuthAuthorize starts an Anthropic OAuth flow in mode — max or
+// console. redirectURI, when set, asks for the browser to be sent there
+// instead of the hosted callback page; servers that don't support it
+// leave it out of the URL.
+func (c *Client) OAuthAuthorize(ctx context.Context, mode, redirectURI string) (*OAuthAuthorization, error) {
+	in := authorizeRequest{Mode: mode, RedirectURI: redirectURI}
+	var out OAuthAuthorization
+	if err := c.do(ctx, http.MethodPost, "/api/auth/anthropic/oauth/authorize", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// OAuthExchange is the second step of an OAuth flow.
+type OAuthExchange struct {
+	CallbackCode string `json:"callback_code"` // code#state
+	Verifier     string `json:"verifier"`
+	Mode         string `json:"mode"`
+	RedirectURI  string `json:"redirect_uri,omitempty"`
+}
+
+// OAuthResult is the outcome of an exchange.
+type OAuthResult struct {
+	OK      bool   `json:"ok"`
+	Mode    string `json:"mode"`
+	Message string `json:"message"`
+}
+
+// OAuthExchange trades the browser's callback code for credentials,
+// which the server stores.
+func (c *Client) OAuthExchange(ctx context.Context, ex OAuthExchange) (*OAuthResult, error) {
+	var out OAuthResult
+	if err := c.do(ctx, http.MethodPost, "/api/auth/anthropic/oauth/exchange", ex, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ── channels ────────────────────────────────────────────────────────────────
+
+// Channel is a messaging channel and its connection state. Times are
+// Unix milliseconds, zero when unknown.
+type Channel struct {
+	ID          string        `json:"id"`
+	DisplayName string        `json:"displayName"`
+	Status      ChannelStatus `json:"status"`
+}
+
+// ChannelStatus is a channel's connection state: connected, connecting,
+// error, or anything else for a channel that isn't set up.
+type ChannelStatus struct {
+	State             string  `json:"state"`
+	ConnectedAt       float64 `json:"connectedAt,omitempty"`
+	Error             string  `json:"error,omitempty"`
+	Detail            string  `json:"detail,omitempty"`
+	ReconnectAttempts int     `json:"reconnectAttempts,omitempty"`
+	LastConnectedAt   float64 `json:"lastConnectedAt,omitempty"`
+	LastDisconnect    string  `json:"lastDisconnect,omitempty"`
+	LastMessageAt     float64 `json:"lastMessageAt,omitempty"`
+	LastError         string  `json:"lastError,omitempty"`
+	SelfID            string  `json:"selfId,omitempty"`
+}
+
+// Channels lists the server's channels.
+func (c *Client) Channels(ctx context.Context) ([]Channel, error) {
+	var out []Channel
+	if err := c.do(ctx, http.MethodGet, "/api/channels", nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type accountRequest struct {
+	AccountID string `json:"accountId"`
+}
+
+// ChannelLogout disconnects account from channel and removes its
+// credentials. The server answers 404 when there is no connection.
+func (c *Client) ChannelLogout(ctx context.Context, channel, account string) error {
+	return c.do(ctx, http.MethodPost, "/api/channels/"+url.PathEscape(channel)+"/logout", accountRequest{AccountID: account}, nil)
+}
+
+// WhatsAppLogin is the QR code to scan to link a WhatsApp account, both
+// as data and rendered for a terminal. Both are empty when the server
+// restores an existing session instead.
+type WhatsAppLogin struct {
+	QR         string `json:"qr"`
+	QRTerminal string `json:"qrTerminal"`
+}
+
+type loginStartRequest struct {
+	AccountID string         `json:"accountId"`
+	Settings  map[string]any `json:"settings"`
+}
+
+// WhatsAppLoginStart starts linking account with the channel settings.
+func (c *Client) WhatsAppLoginStart(ctx context.Context, account string, settings map[string]any) (*WhatsAppLogin, error) {
+	in := loginStartRequest{AccountID: account, Settings: settings}
+	var out WhatsAppLogin
+	if err := c.do(ctx, http.MethodPost, "/api/channels/whatsapp/login/start", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WhatsAppLoginWait waits for the phone to scan the code. The server
+// gives up after five minutes, so the client's timeout should be longer.
+func (c *Client) WhatsAppLoginWait(ctx context.Context, account string) error {
+	return c.do(ctx, http.MethodPost, "/api/channels/whatsapp/login/wait", accountRequest{AccountID: account}, nil)
+}
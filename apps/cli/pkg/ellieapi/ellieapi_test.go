@@ -0,0 +1,137 @@
+package ellieapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testServer(t *testing.T) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/auth/anthropic/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"mode":"oauth","source":"store","configured":true,"expires_at":1760000000000,"extra":1}`))
+	})
+	mux.HandleFunc("POST /api/auth/groq/api-key", func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Key      string `json:"key"`
+			Validate bool   `json:"validate"`
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		if in.Validate && in.Key != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid key"}`))
+		}
+	})
+	mux.HandleFunc("POST /api/auth/groq/clear", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cleared":true}`))
+	})
+	mux.HandleFunc("POST /api/auth/anthropic/oauth/authorize", func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]string
+		json.NewDecoder(r.Body).Decode(&in)
+		json.NewEncoder(w).Encode(map[string]string{
+			"url": "https://claude.ai/oauth?redirect_uri=" + in["redirect_uri"], "verifier": "v-" + in["mode"], "state": "s",
+		})
+	})
+	mux.HandleFunc("GET /api/channels", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"whatsapp","displayName":"WhatsApp","status":{"state":"connected","selfId":"+1555"}}]`))
+	})
+	mux.HandleFunc("POST /api/channels/whatsapp/logout", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("boom\n"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return New(srv.URL+"/", srv.Client())
+}
+
+func TestAuth(t *testing.T) {
+	c := testServer(t)
+	ctx := context.Background()
+
+	status, err := c.AuthStatus(ctx, "anthropic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Configured || status.Mode == nil || *status.Mode != "oauth" || *status.ExpiresAt != 1760000000000 {
+		t.Errorf("AuthStatus = %+v", status)
+	}
+	if !strings.Contains(string(status.Raw), `"extra":1`) {
+		t.Errorf("Raw = %s, want the server's fields kept", status.Raw)
+	}
+	if _, err := c.AuthStatus(ctx, "azure"); !IsStatus(err, http.StatusNotFound) {
+		t.Errorf("status of an unknown provider: %v, want a 404", err)
+	}
+
+	if err := c.SetAPIKey(ctx, "groq", "good", true); err != nil {
+		t.Errorf("SetAPIKey: %v", err)
+	}
+	err = c.SetAPIKey(ctx, "groq", "bad", true)
+	if !IsStatus(err, http.StatusUnauthorized) || err.Error() != "server returned 401: invalid key" {
+		t.Errorf("SetAPIKey with a bad key: %v", err)
+	}
+	if cleared, err := c.ClearAuth(ctx, "groq"); !cleared || err != nil {
+		t.Errorf("ClearAuth = %v, %v", cleared, err)
+	}
+
+	auth, err := c.OAuthAuthorize(ctx, "max", "http://localhost:1/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth.Verifier != "v-max" || !strings.HasSuffix(auth.URL, "redirect_uri=http://localhost:1/callback") {
+		t.Errorf("OAuthAuthorize = %+v", auth)
+	}
+}
+
+func TestChannels(t *testing.T) {
+	c := testServer(t)
+	ctx := context.Background()
+
+	channels, err := c.Channels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != 1 || channels[0].Status.State != "connected" || channels[0].Status.SelfID != "+1555" {
+		t.Errorf("Channels = %+v", channels)
+	}
+	err = c.ChannelLogout(ctx, "whatsapp", "default")
+	if !IsStatus(err, http.StatusInternalServerError) || err.(*Error).Message != "boom" {
+		t.Errorf("ChannelLogout: %v", err)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	c := New(srv.URL, nil)
+	_, err := c.Channels(context.Background())
+	if !IsUnreachable(err) || IsStatus(err, http.StatusNotFound) {
+		t.Errorf("request to a closed server: %v", err)
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	for _, tt := range []struct {
+		status int
+		body   string
+		want   Error
+	}{
+		{401, `{"error":"token expired","code":"token_expired","hint":"sign in again"}`,
+			Error{401, "token_expired", "token expired", "sign in again"}},
+		{400, `{"error":{"code":"invalid_model","message":"no such model"}}`,
+			Error{400, "invalid_model", "no such model", ""}},
+		{503, `{"message":"tts is down"}`, Error{503, "unavailable", "tts is down", ""}},
+		{500, "boom\n", Error{500, "internal", "boom", ""}},
+		{418, `{"error":"teapot"}`, Error{418, "", "teapot", ""}},
+	} {
+		rec := httptest.NewRecorder()
+		rec.WriteHeader(tt.status)
+		rec.WriteString(tt.body)
+		if got := ResponseError(rec.Result()); *got != tt.want {
+			t.Errorf("ResponseError(%d %s) = %+v, want %+v", tt.status, tt.body, *got, tt.want)
+		}
+	}
+}
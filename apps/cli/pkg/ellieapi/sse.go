@@ -0,0 +1,241 @@
+package ellieapi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ── server-sent events ──────────────────────────────────────────────────────
+
+// Event is one server-sent event.
+type Event struct {
+	// ID is the event's id field, or the last one the stream sent.
+	ID string
+	// Type is the event field; "message" when the event has none.
+	Type string
+	// Data is the event's data fields, joined by newlines.
+	Data string
+}
+
+// EventReader parses a text/event-stream body.
+type EventReader struct {
+	sc *bufio.Scanner
+	// LastID is the id of the last event read, to resume from.
+	LastID string
+	// Retry is the reconnection delay the stream asked for, or 0.
+	Retry time.Duration
+}
+
+// maxEventSize bounds one line of a stream; snapshots can be large.
+const maxEventSize = 10 << 20
+
+// NewEventReader returns a reader of the events in r.
+func NewEventReader(r io.Reader) *EventReader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxEventSize)
+	return &EventReader{sc: sc}
+}
+
+// Next returns the next event, or io.EOF when the stream ends. Comments,
+// and events without data, are skipped.
+func (r *EventReader) Next() (Event, error) {
+	var typ string
+	var data []string
+	hasData := false
+	for r.sc.Scan() {
+		line := strings.TrimSuffix(r.sc.Text(), "\r")
+		if line == "" {
+			if hasData {
+				if typ == "" {
+					typ = "message"
+				}
+				return Event{ID: r.LastID, Type: typ, Data: strings.Join(data, "\n")}, nil
+			}
+			typ = ""
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			typ = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				r.LastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				r.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := r.sc.Err(); err != nil {
+		return Event{}, err
+	}
+	return Event{}, io.EOF
+}
+
+// ErrStopStream, returned by the func given to Stream, ends the stream;
+// Stream then returns nil.
+var ErrStopStream = errors.New("stop stream")
+
+// StreamOptions configures Stream.
+type StreamOptions struct {
+	// Query, when set, gives the query of each connection, for streams
+	// that resume by a parameter, like afterSeq, rather than by
+	// Last-Event-ID.
+	Query func() url.Values
+	// MaxRetries is how many times in a row Stream reconnects before it
+	// gives up: 0 means DefaultMaxRetries, less than 0 never.
+	MaxRetries int
+	// OnReconnect, when set, is called when a dropped stream has been
+	// connected again: its events may repeat or skip some that were
+	// missed, depending on the stream.
+	OnReconnect func()
+}
+
+// DefaultMaxRetries is how many times in a row Stream reconnects by
+// default.
+const DefaultMaxRetries = 10
+
+// Reconnection delays: the first, and the most the doubling reaches.
+// A stream's retry field replaces the first.
+const (
+	baseRetryDelay = 500 * time.Millisecond
+	maxRetryDelay  = 5 * time.Second
+)
+
+// Stream reads the server-sent events at path and calls fn for each
+// until ctx is done, or fn returns an error, which Stream returns. When
+// the connection drops or the server ends the stream, it reconnects with
+// backoff, sending the last event id as Last-Event-ID. A server that
+// can't be reached at first, or that answers with an error status other
+// than 429 or 5xx, fails the stream at once.
+func (c *Client) Stream(ctx context.Context, path string, opts StreamOptions, fn func(Event) error) error {
+	maxRetries := opts.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultMaxRetries
+	}
+	var lastID string
+	var retry time.Duration
+	connected := false
+	for attempt := 0; ; attempt++ {
+		events, err := c.openStream(ctx, path, opts.Query, lastID)
+		if err == nil {
+			if connected && opts.OnReconnect != nil {
+				opts.OnReconnect()
+			}
+			connected, attempt = true, 0
+			err = readStream(events, fn)
+			events.body.Close()
+			lastID = events.LastID
+			if events.Retry > 0 {
+				retry = events.Retry
+			}
+			if errors.Is(err, ErrStopStream) {
+				return nil
+			}
+			var fnErr *callbackError
+			if errors.As(err, &fnErr) {
+				return fnErr.err
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !connected || !retryable(err) || maxRetries < 0 || attempt >= maxRetries {
+			return err
+		}
+		delay := retry
+		if delay == 0 {
+			delay = min(baseRetryDelay<<min(attempt, 4), maxRetryDelay)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// streamBody is an open stream.
+type streamBody struct {
+	*EventReader
+	body io.Closer
+}
+
+func (c *Client) openStream(ctx context.Context, path string, query func() url.Values, lastID string) (*streamBody, error) {
+	u := c.BaseURL + path
+	if query != nil {
+		if q := query(); len(q) > 0 {
+			u += "?" + q.Encode()
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, &UnreachableError{BaseURL: c.BaseURL, Err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, ResponseError(resp)
+	}
+	return &streamBody{EventReader: NewEventReader(resp.Body), body: resp.Body}, nil
+}
+
+// callbackError carries an error of Stream's fn past the reconnection.
+type callbackError struct{ err error }
+
+func (e *callbackError) Error() string { return e.err.Error() }
+
+// readStream calls fn for each event of s until it ends, returning nil
+// for a clean end.
+func readStream(s *streamBody, fn func(Event) error) error {
+	for {
+		ev, err := s.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(ev); err != nil {
+			if errors.Is(err, ErrStopStream) {
+				return err
+			}
+			return &callbackError{err}
+		}
+	}
+}
+
+// retryable reports whether a stream that failed with err is worth
+// reconnecting: it dropped or ended, or the server is briefly unable to
+// serve it.
+func retryable(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return true
+	}
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
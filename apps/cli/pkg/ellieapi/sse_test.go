@@ -0,0 +1,106 @@
+package ellieapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEventReader(t *testing.T) {
+	stream := ": comment\r\n" +
+		"retry: 250\r\n" +
+		"event: snapshot\r\nid: 7\r\ndata: {\"a\":\r\ndata:1}\r\n\r\n" +
+		"event: heartbeat\n\n" +
+		"data: plain\n\n" +
+		"id: 8\nevent: append\ndata\n\n"
+	r := NewEventReader(strings.NewReader(stream))
+	var got []Event
+	for {
+		ev, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ev)
+	}
+	want := []Event{
+		{ID: "7", Type: "snapshot", Data: "{\"a\":\n1}"},
+		{ID: "7", Type: "message", Data: "plain"},
+		{ID: "8", Type: "append", Data: ""},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if r.Retry != 250*time.Millisecond || r.LastID != "8" {
+		t.Errorf("Retry = %s, LastID = %q", r.Retry, r.LastID)
+	}
+}
+
+func TestStreamReconnects(t *testing.T) {
+	var conns atomic.Int32
+	var lastIDs, afterSeqs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := conns.Add(1)
+		lastIDs = append(lastIDs, r.Header.Get("Last-Event-ID"))
+		afterSeqs = append(afterSeqs, r.URL.Query().Get("afterSeq"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		// The first connection drops after one event.
+		fmt.Fprintf(w, "retry: 1\nid: %d\nevent: append\ndata: %d\n\n", n, n)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, nil)
+	var data []string
+	reconnects := 0
+	opts := StreamOptions{
+		Query:       func() url.Values { return url.Values{"afterSeq": {fmt.Sprint(len(data))}} },
+		OnReconnect: func() { reconnects++ },
+	}
+	err := c.Stream(context.Background(), "/api/events/sse", opts, func(ev Event) error {
+		data = append(data, ev.Data)
+		if len(data) == 2 {
+			return ErrStopStream
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(data, ",") != "1,2" || reconnects != 1 {
+		t.Errorf("data = %v after %d reconnects", data, reconnects)
+	}
+	if strings.Join(lastIDs, ",") != ",1" || strings.Join(afterSeqs, ",") != "0,1" {
+		t.Errorf("Last-Event-ID = %q, afterSeq = %q", lastIDs, afterSeqs)
+	}
+
+	stop := errors.New("enough")
+	err = c.Stream(context.Background(), "/", StreamOptions{}, func(Event) error { return stop })
+	if err != stop {
+		t.Errorf("the func's error was not returned: %v", err)
+	}
+}
+
+func TestStreamFailsAtOnce(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	c := New(srv.URL, nil)
+	err := c.Stream(context.Background(), "/missing", StreamOptions{}, func(Event) error { return nil })
+	if !IsStatus(err, http.StatusNotFound) {
+		t.Errorf("a 404 stream returned %v", err)
+	}
+
+	srv.Close()
+	err = c.Stream(context.Background(), "/", StreamOptions{}, func(Event) error { return nil })
+	if !IsUnreachable(err) {
+		t.Errorf("a stream from a stopped server returned %v", err)
+	}
+}